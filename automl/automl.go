@@ -0,0 +1,316 @@
+// Package automl searches over preprocessing and Classifiers for the
+// pipeline.Pipeline which predicts a set of Instances best, within a
+// time budget.
+package automl
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	base "github.com/sjwhitworth/golearn/base"
+	evaluation "github.com/sjwhitworth/golearn/evaluation"
+	pipeline "github.com/sjwhitworth/golearn/pipeline"
+
+	// Register the Classifiers DefaultSearch tries
+	_ "github.com/sjwhitworth/golearn/ensemble"
+	_ "github.com/sjwhitworth/golearn/knn"
+	_ "github.com/sjwhitworth/golearn/trees"
+)
+
+// Choice is one of the options for a stage of preprocessing. A nil
+// Step skips the stage.
+type Choice struct {
+	Name string
+	Step pipeline.Step
+}
+
+// Model is one of the Classifiers to try: the name it's registered
+// under and the parameters to override its defaults with (see
+// base.NewClassifier).
+type Model struct {
+	Name   string
+	Params map[string]interface{}
+}
+
+// String returns the name and parameters of the Model, e.g.
+// "knn(NearestNeighbours=5)"
+func (m Model) String() string {
+	if len(m.Params) == 0 {
+		return m.Name
+	}
+	params := make([]string, 0, len(m.Params))
+	for k, v := range m.Params {
+		params = append(params, fmt.Sprintf("%s=%v", k, v))
+	}
+	sort.Strings(params)
+	return fmt.Sprintf("%s(%s)", m.Name, strings.Join(params, ", "))
+}
+
+// Search describes which Pipelines to try, and how to compare them.
+// Every combination of one Choice from each stage and a Model is a
+// candidate, and they're tried in a random order until the Budget
+// runs out, so a short Budget amounts to a random search.
+type Search struct {
+	// Stages are the preprocessing stages, in the order they're
+	// applied
+	Stages [][]Choice
+	Models []Model
+	// Budget is how long to spend: once it's run out, no more
+	// candidates are started (though at least one is tried). Zero
+	// means trying them all.
+	Budget time.Duration
+	// Folds is the number of cross-validation folds each
+	// candidate is scored with (see evaluation.CrossValPredict)
+	Folds int
+	// Stratified makes each fold have the same proportion of each
+	// class (see evaluation.StratifiedFolds), rather than dividing
+	// the rows at random
+	Stratified bool
+	// Seed determines the order the candidates are tried in and
+	// the folds. Zero means a random seed.
+	Seed int64
+}
+
+// DefaultSearch returns a Search over scaling, one-hot encoding and
+// feature selection, and KNN, CART, random forests and gradient
+// boosting, with the given Budget and five stratified folds.
+func DefaultSearch(budget time.Duration) *Search {
+	return &Search{
+		Stages: [][]Choice{
+			{{"no scaling", nil}, {"standardise", pipeline.Standardise()}},
+			{{"no encoding", nil}, {"one-hot", pipeline.OneHot()}},
+			{{"all features", nil}, {"best half of features", pipeline.SelectFeatures(0.5)}},
+		},
+		Models: []Model{
+			{"knn", map[string]interface{}{"NearestNeighbours": 1}},
+			{"knn", map[string]interface{}{"NearestNeighbours": 5}},
+			{"cart", map[string]interface{}{"MaxDepth": 4}},
+			{"cart", nil},
+			{"randomforest", map[string]interface{}{"ForestSize": 50}},
+			{"gradientboosting", nil},
+		},
+		Budget:     budget,
+		Folds:      5,
+		Stratified: true,
+	}
+}
+
+// Trial records how one candidate did
+type Trial struct {
+	// Choices are the names of the Choice made for each stage
+	Choices []string
+	Model   Model
+	// Accuracy is the (weighted) cross-validated accuracy
+	Accuracy float64
+	Duration time.Duration
+	// Err is why the candidate couldn't be scored, if it couldn't
+	Err error
+
+	steps []pipeline.Step
+}
+
+// String describes the candidate, e.g.
+// "standardise → all features → knn(NearestNeighbours=5)". Stages
+// which were skipped are left out.
+func (t Trial) String() string {
+	parts := make([]string, 0, len(t.Choices)+1)
+	for k, name := range t.Choices {
+		if t.steps[k] != nil {
+			parts = append(parts, name)
+		}
+	}
+	parts = append(parts, t.Model.String())
+	return strings.Join(parts, " → ")
+}
+
+// newPipeline returns an untrained Pipeline for the candidate
+func (t Trial) newPipeline() (*pipeline.Pipeline, error) {
+	cls, err := base.NewClassifier(t.Model.Name, t.Model.Params)
+	if err != nil {
+		return nil, err
+	}
+	steps := make([]pipeline.Step, 0, len(t.steps))
+	for _, s := range t.steps {
+		if s != nil {
+			steps = append(steps, s)
+		}
+	}
+	return pipeline.NewPipeline(cls, steps...), nil
+}
+
+// Result is the outcome of a Search
+type Result struct {
+	// Best is the best candidate, trained on all of the Instances
+	Best *pipeline.Pipeline
+	// BestTrial is how it did
+	BestTrial Trial
+	// Trials are the candidates tried, in order
+	Trials []Trial
+	// Candidates is how many there were in all
+	Candidates int
+	Duration   time.Duration
+	Folds      int
+}
+
+// Fit runs DefaultSearch with the given budget on data.
+func Fit(data *base.Instances, budget time.Duration) (*Result, error) {
+	return DefaultSearch(budget).Run(data)
+}
+
+// Run tries candidates until the Budget runs out, then trains the one
+// with the best cross-validated accuracy on all of data. It returns
+// an error if no candidate could be scored.
+func (s *Search) Run(data *base.Instances) (*Result, error) {
+	start := time.Now()
+	seed := s.Seed
+	if seed == 0 {
+		seed = rand.Int63()
+	}
+	rng := rand.New(rand.NewSource(seed))
+	folds, err := s.folds(data, seed)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := s.candidates()
+	for i := range candidates {
+		j := i + rng.Intn(len(candidates)-i)
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	}
+
+	// The Pipelines share a Cache, so each preprocessing choice is
+	// only fitted once per fold
+	cache := pipeline.NewCache()
+	ret := &Result{Candidates: len(candidates), Folds: s.Folds}
+	best := -1
+	for _, t := range candidates {
+		if s.Budget > 0 && len(ret.Trials) > 0 && time.Since(start) >= s.Budget {
+			break
+		}
+		trialStart := time.Now()
+		t.Accuracy, t.Err = t.score(data, folds, cache)
+		t.Duration = time.Since(trialStart)
+		ret.Trials = append(ret.Trials, t)
+		if t.Err == nil && (best == -1 || t.Accuracy > ret.Trials[best].Accuracy) {
+			best = len(ret.Trials) - 1
+		}
+	}
+	if best == -1 {
+		return nil, fmt.Errorf("automl: none of the %d candidates tried could be scored", len(ret.Trials))
+	}
+
+	ret.BestTrial = ret.Trials[best]
+	p, err := ret.BestTrial.newPipeline()
+	if err != nil {
+		return nil, err
+	}
+	p.Fit(data)
+	ret.Best = p
+	ret.Duration = time.Since(start)
+	return ret, nil
+}
+
+// folds divides the rows of data into the folds each candidate is
+// scored with
+func (s *Search) folds(data *base.Instances, seed int64) ([][]int, error) {
+	if s.Stratified {
+		return evaluation.StratifiedFolds(data, s.Folds, seed)
+	}
+	return evaluation.RandomFolds(data.Rows, s.Folds, seed)
+}
+
+// candidates returns every combination of Choices and Models
+func (s *Search) candidates() []Trial {
+	ret := []Trial{{}}
+	for _, stage := range s.Stages {
+		next := make([]Trial, 0, len(ret)*len(stage))
+		for _, t := range ret {
+			for _, c := range stage {
+				next = append(next, Trial{
+					Choices: append(t.Choices[:len(t.Choices):len(t.Choices)], c.Name),
+					steps:   append(t.steps[:len(t.steps):len(t.steps)], c.Step),
+				})
+			}
+		}
+		ret = next
+	}
+	withModels := make([]Trial, 0, len(ret)*len(s.Models))
+	for _, t := range ret {
+		for _, m := range s.Models {
+			t.Model = m
+			withModels = append(withModels, t)
+		}
+	}
+	return withModels
+}
+
+// score returns the cross-validated accuracy of the candidate, or an
+// error if it can't be built or fails (e.g. because the Classifier
+// doesn't support the Attributes).
+func (t Trial) score(data *base.Instances, folds [][]int, cache *pipeline.Cache) (accuracy float64, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if err, _ = r.(error); err == nil {
+				err = fmt.Errorf("automl: %v", r)
+			}
+		}
+	}()
+	p, err := t.newPipeline()
+	if err != nil {
+		return 0, err
+	}
+	p.Cache = cache
+	predictions, _, err := evaluation.CrossValPredictWithFolds(p, data, folds)
+	if err != nil {
+		return 0, err
+	}
+	return evaluation.GetWeightedAccuracy(evaluation.GetWeightedConfusionMatrix(data, predictions)), nil
+}
+
+// Report returns a table of the candidates tried, from the most to
+// the least accurate, followed by those which failed and why.
+func (r *Result) Report() string {
+	var buffer bytes.Buffer
+	buffer.WriteString(fmt.Sprintf("Tried %d of %d candidate(s) in %s, with %d-fold cross-validation\n",
+		len(r.Trials), r.Candidates, r.Duration.Round(time.Millisecond), r.Folds))
+	buffer.WriteString(fmt.Sprintf("Best: %s (accuracy %.4f)\n\n", r.BestTrial, r.BestTrial.Accuracy))
+
+	trials := make([]Trial, len(r.Trials))
+	copy(trials, r.Trials)
+	sort.Stable(byAccuracy(trials))
+	w := tabwriter.NewWriter(&buffer, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "Accuracy\tTime\tPipeline")
+	for _, t := range trials {
+		if t.Err != nil {
+			fmt.Fprintf(w, "failed\t%s\t%s: %s\n", t.Duration.Round(time.Millisecond), t, t.Err)
+			continue
+		}
+		fmt.Fprintf(w, "%.4f\t%s\t%s\n", t.Accuracy, t.Duration.Round(time.Millisecond), t)
+	}
+	w.Flush()
+	return buffer.String()
+}
+
+// byAccuracy sorts Trials from the most to the least accurate, with
+// the failures last
+type byAccuracy []Trial
+
+func (b byAccuracy) Len() int {
+	return len(b)
+}
+
+func (b byAccuracy) Swap(i, j int) {
+	b[i], b[j] = b[j], b[i]
+}
+
+func (b byAccuracy) Less(i, j int) bool {
+	if (b[i].Err == nil) != (b[j].Err == nil) {
+		return b[i].Err == nil
+	}
+	return b[i].Accuracy > b[j].Accuracy
+}
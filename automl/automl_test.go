@@ -0,0 +1,83 @@
+package automl
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	base "github.com/sjwhitworth/golearn/base"
+	pipeline "github.com/sjwhitworth/golearn/pipeline"
+)
+
+func TestSearch(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	search := &Search{
+		Stages: [][]Choice{
+			{{"no scaling", nil}, {"standardise", pipeline.Standardise()}},
+		},
+		Models: []Model{
+			{"knn", map[string]interface{}{"NearestNeighbours": 5}},
+			{"cart", map[string]interface{}{"MaxDepth": 3}},
+			{"nosuchmodel", nil},
+		},
+		Folds: 3,
+		Seed:  1,
+	}
+	result, err := search.Run(inst)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if len(result.Trials) != 6 || result.Candidates != 6 {
+		testEnv.Fatal(result.Trials)
+	}
+	failed := 0
+	for _, t := range result.Trials {
+		if t.Err != nil {
+			failed++
+			if t.Model.Name != "nosuchmodel" {
+				testEnv.Error(t, t.Err)
+			}
+		}
+	}
+	if failed != 2 {
+		testEnv.Errorf("Expected 2 failures, got %d", failed)
+	}
+	if result.BestTrial.Accuracy < 0.9 || result.BestTrial.Err != nil {
+		testEnv.Error(result.BestTrial)
+	}
+	predictions := result.Best.Predict(inst)
+	if predictions.Rows != inst.Rows {
+		testEnv.Error(predictions)
+	}
+
+	report := result.Report()
+	if !strings.Contains(report, "Tried 6 of 6") || !strings.Contains(report, "standardise → knn(NearestNeighbours=5)") {
+		testEnv.Error(report)
+	}
+	lines := strings.Split(strings.TrimSpace(report), "\n")
+	if !strings.HasPrefix(lines[len(lines)-1], "failed") || !strings.HasPrefix(lines[4], "0.9") {
+		testEnv.Error(report)
+	}
+
+	// Once the budget's run out, no more candidates are started
+	search.Budget = time.Nanosecond
+	result, err = search.Run(inst)
+	if err == nil && len(result.Trials) != 1 {
+		testEnv.Error(result.Trials)
+	}
+}
+
+func TestDefaultSearch(testEnv *testing.T) {
+	search := DefaultSearch(time.Minute)
+	if n := len(search.candidates()); n != 48 {
+		testEnv.Errorf("Expected 48 candidates, got %d", n)
+	}
+	for _, t := range search.candidates() {
+		if _, err := t.newPipeline(); err != nil {
+			testEnv.Error(t, err)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package base
+
+import "fmt"
+
+// AppendInstances returns new Instances with the rows of first
+// followed by those of each of rest. Attributes are matched by name,
+// so they may be in a different order, but must be of the same type
+// and include the same class Attribute. The result has first's
+// Attributes, roles and kind of Storage, and the rows keep their
+// weights.
+//
+// Categorical values are matched by their string representation, so
+// sets of Instances read from different files can be appended: values
+// which first doesn't have yet are added to its Attributes.
+func AppendInstances(first *Instances, rest ...*Instances) (*Instances, error) {
+	rows := first.Rows
+	mappings := make([][]int, len(rest))
+	for k, other := range rest {
+		if other.Cols != first.Cols {
+			return nil, fmt.Errorf("base: can't append Instances with %d Attributes to Instances with %d", other.Cols, first.Cols)
+		}
+		cols, err := matchAttributes(first, other)
+		if err != nil {
+			return nil, err
+		}
+		if cols[first.ClassIndex] != other.ClassIndex {
+			return nil, fmt.Errorf("base: can't append Instances with a different class Attribute (%s)", other.GetClassAttr().GetName())
+		}
+		mappings[k] = cols
+		rows += other.Rows
+	}
+
+	ret := first.newInstancesLike(first.attributes, rows)
+	ret.ClassIndex = first.ClassIndex
+	ret.copyRoles(first)
+	for i := 0; i < first.Rows; i++ {
+		ret.copyRow(i, first, i)
+	}
+	row := first.Rows
+	for k, other := range rest {
+		for i := 0; i < other.Rows; i, row = i+1, row+1 {
+			for j, a := range first.attributes {
+				ret.Set(row, j, convertSysVal(a, other.attributes[mappings[k][j]], other.Get(i, mappings[k][j])))
+			}
+			ret.SetWeight(row, other.GetWeight(i))
+		}
+	}
+	return ret, nil
+}
+
+// matchAttributes returns the column of other with the same name as
+// each of inst's Attributes, checking that their types match.
+func matchAttributes(inst, other *Instances) ([]int, error) {
+	byName := make(map[string]int)
+	for j, a := range other.attributes {
+		byName[a.GetName()] = j
+	}
+	ret := make([]int, inst.Cols)
+	for j, a := range inst.attributes {
+		k, ok := byName[a.GetName()]
+		if !ok {
+			return nil, fmt.Errorf("base: Attribute %s is missing", a.GetName())
+		}
+		if other.attributes[k].GetType() != a.GetType() {
+			return nil, fmt.Errorf("base: Attribute %s has a different type", a.GetName())
+		}
+		ret[j] = k
+	}
+	return ret, nil
+}
+
+// convertSysVal converts a system representation value of from into
+// that of to, which must be of the same type.
+func convertSysVal(to, from Attribute, sysVal float64) float64 {
+	if IsMissingValue(sysVal) || to == from || to.GetType() == Float64Type {
+		return sysVal
+	}
+	return to.GetSysValFromString(from.GetStringFromSysVal(sysVal))
+}
+
+// MergeAttributes returns new Instances with the Attributes of left
+// followed by those of right, joining them row by row, e.g. to add
+// features computed elsewhere. The class Attribute is left's, and
+// goes last. If right has an Attribute with the same name as left's
+// class Attribute it's left out; any other Attribute which both have
+// is an error, as is a different number of rows. The roles of both
+// are kept, except that right's class Attribute (if it's different)
+// gets IgnoredRole, and the weights are left's.
+func MergeAttributes(left, right *Instances) (*Instances, error) {
+	if left.Rows != right.Rows {
+		return nil, fmt.Errorf("base: can't merge Instances with %d rows and %d rows", left.Rows, right.Rows)
+	}
+	classAttr := left.GetClassAttr()
+	names := make(map[string]bool)
+	attrs := make([]Attribute, 0, left.Cols+right.Cols)
+	sources := make([]*Instances, 0, cap(attrs))
+	cols := make([]int, 0, cap(attrs))
+	add := func(src *Instances, j int) error {
+		a := src.attributes[j]
+		if names[a.GetName()] {
+			return fmt.Errorf("base: both Instances have an Attribute called %s", a.GetName())
+		}
+		names[a.GetName()] = true
+		attrs = append(attrs, a)
+		sources = append(sources, src)
+		cols = append(cols, j)
+		return nil
+	}
+	names[classAttr.GetName()] = true
+	for j := range left.attributes {
+		if j == left.ClassIndex {
+			continue
+		}
+		if err := add(left, j); err != nil {
+			return nil, err
+		}
+	}
+	for j, a := range right.attributes {
+		if a.GetName() == classAttr.GetName() {
+			continue
+		}
+		if err := add(right, j); err != nil {
+			return nil, err
+		}
+	}
+	attrs = append(attrs, classAttr)
+	sources = append(sources, left)
+	cols = append(cols, left.ClassIndex)
+
+	var ret *Instances
+	if left.IsSparse() && right.IsSparse() {
+		ret = NewSparseInstances(attrs, left.Rows)
+	} else {
+		ret = NewInstances(attrs, left.Rows)
+	}
+	for j := 0; j < ret.ClassIndex; j++ {
+		role := sources[j].GetRole(cols[j])
+		if role == ClassRole {
+			// right's own class mustn't leak into the features
+			role = IgnoredRole
+		}
+		ret.SetRole(j, role)
+	}
+	for i := 0; i < left.Rows; i++ {
+		for j := range attrs {
+			if val := sources[j].Get(i, cols[j]); val != 0 {
+				ret.Set(i, j, val)
+			}
+		}
+	}
+	ret.weights = left.Weights()
+	return ret, nil
+}
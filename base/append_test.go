@@ -0,0 +1,83 @@
+package base
+
+import "testing"
+
+func TestAppendInstances(testEnv *testing.T) {
+	inst, err := ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	other, err := ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	// Reorder the other's Attributes, and give its class Attribute
+	// the values in a different order
+	attrs := other.attributes
+	other = other.SelectAttributes([]Attribute{attrs[2], attrs[0], attrs[1], attrs[3], attrs[4]})
+	species := NewCategoricalAttribute()
+	species.SetName(attrs[4].GetName())
+	for _, c := range []string{"Iris-virginica", "Iris-versicolor", "Iris-setosa"} {
+		species.GetSysValFromString(c)
+	}
+	for i := 0; i < other.Rows; i++ {
+		other.Set(i, 4, species.GetSysValFromString(other.GetClass(i)))
+	}
+	other.ReplaceAttr(4, species)
+	other.SetWeight(0, 2)
+
+	ret, err := AppendInstances(inst, other)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if ret.Rows != 300 || ret.Cols != 5 || ret.ClassIndex != 4 {
+		testEnv.Fatalf("%d x %d", ret.Rows, ret.Cols)
+	}
+	for i := 0; i < other.Rows; i++ {
+		if ret.GetClass(150+i) != other.GetClass(i) || ret.GetAttrStr(150+i, 0) != other.GetAttrStr(i, 1) {
+			testEnv.Fatalf("Row %d: %s != %s", i, ret.RowStr(150+i), other.RowStr(i))
+		}
+	}
+	if ret.GetWeight(0) != 1 || ret.GetWeight(150) != 2 {
+		testEnv.Error("Weights weren't kept")
+	}
+
+	if _, err := AppendInstances(inst, other.SelectAttributes(attrs[:4])); err == nil {
+		testEnv.Error("Appended Instances with too few Attributes")
+	}
+	renamed := NewFloatAttribute()
+	renamed.SetName("Something else")
+	other.ReplaceAttr(0, renamed)
+	if _, err := AppendInstances(inst, other); err == nil {
+		testEnv.Error("Appended Instances with a different Attribute")
+	}
+}
+
+func TestMergeAttributes(testEnv *testing.T) {
+	inst, err := ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	attrs := inst.attributes
+	left := inst.SelectAttributes([]Attribute{attrs[0], attrs[1], attrs[4]})
+	right := inst.SelectAttributes([]Attribute{attrs[2], attrs[3], attrs[4]})
+	right.SetAttributeRole(attrs[3], IDRole)
+
+	ret, err := MergeAttributes(left, right)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if ret.Cols != 5 || ret.GetClassAttr() != attrs[4] || !ret.Equal(inst) {
+		testEnv.Fatal(ret)
+	}
+	if ret.GetRole(3) != IDRole || ret.GetRole(2) != FeatureRole {
+		testEnv.Error("Roles weren't kept")
+	}
+
+	if _, err := MergeAttributes(left, left); err == nil {
+		testEnv.Error("Merged Instances with the same Attributes")
+	}
+	if _, err := MergeAttributes(left, right.SelectRows([]int{0, 1})); err == nil {
+		testEnv.Error("Merged Instances with different numbers of rows")
+	}
+}
@@ -0,0 +1,356 @@
+package base
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ParseARFFToInstances reads the Weka ARFF file given by filepath.
+// Unlike CSV, ARFF declares the type of each Attribute and the values
+// of each nominal (CategoricalAttribute) one, so nothing is guessed.
+// See ParseARFF.
+func ParseARFFToInstances(filepath string) (*Instances, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseARFF(file)
+}
+
+// ParseARFF reads ARFF data from r. Numeric, real and integer
+// attributes become FloatAttributes, and nominal attributes become
+// CategoricalAttributes whose values are in the declared order.
+// String attributes become StringAttributes. Values written as "?"
+// are missing. The last attribute is the class. If the data is in
+// ARFF's sparse format, the Instances are sparse.
+//
+// Date and relational attributes aren't supported.
+func ParseARFF(r io.Reader) (*Instances, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	attrs := make([]Attribute, 0)
+	inData := false
+	sparse := false
+	records := make([][]string, 0)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "%") {
+			continue
+		}
+		if !inData {
+			lower := strings.ToLower(text)
+			switch {
+			case strings.HasPrefix(lower, "@relation"):
+			case strings.HasPrefix(lower, "@attribute"):
+				a, err := parseARFFAttribute(text[len("@attribute"):])
+				if err != nil {
+					return nil, fmt.Errorf("base: line %d: %s", line, err)
+				}
+				attrs = append(attrs, a)
+			case strings.HasPrefix(lower, "@data"):
+				inData = true
+			default:
+				return nil, fmt.Errorf("base: line %d: unexpected %q", line, text)
+			}
+			continue
+		}
+		if strings.HasPrefix(text, "{") {
+			sparse = true
+		}
+		record, err := splitARFFRow(text)
+		if err != nil {
+			return nil, fmt.Errorf("base: line %d: %s", line, err)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(attrs) == 0 {
+		return nil, fmt.Errorf("base: no @attribute declarations")
+	}
+
+	var ret *Instances
+	if sparse {
+		ret = NewSparseInstances(attrs, len(records))
+	} else {
+		ret = NewInstances(attrs, len(records))
+	}
+	for i, record := range records {
+		if err := setARFFRow(ret, i, record); err != nil {
+			return nil, fmt.Errorf("base: data row %d: %s", i, err)
+		}
+	}
+	return ret, nil
+}
+
+// parseARFFAttribute parses the rest of an @attribute line
+func parseARFFAttribute(decl string) (Attribute, error) {
+	decl = strings.TrimSpace(decl)
+	name, rest, err := readARFFToken(decl)
+	if err != nil {
+		return nil, err
+	}
+	rest = strings.TrimSpace(rest)
+	if strings.HasPrefix(rest, "{") {
+		end := strings.LastIndex(rest, "}")
+		if end == -1 {
+			return nil, fmt.Errorf("unterminated nominal values %q", rest)
+		}
+		values, err := splitARFFValues(rest[1:end])
+		if err != nil {
+			return nil, err
+		}
+		ret := NewCategoricalAttribute()
+		ret.SetName(name)
+		for _, v := range values {
+			ret.GetSysValFromString(v)
+		}
+		return ret, nil
+	}
+	switch strings.ToLower(strings.Fields(rest + " ")[0]) {
+	case "numeric", "real", "integer":
+		ret := NewFloatAttribute()
+		ret.SetName(name)
+		return ret, nil
+	case "string":
+		ret := NewStringAttribute()
+		ret.SetName(name)
+		return ret, nil
+	}
+	return nil, fmt.Errorf("unsupported type %q for attribute %s", rest, name)
+}
+
+// readARFFToken reads a possibly quoted token from the start of s,
+// returning it and the rest of s.
+func readARFFToken(s string) (string, string, error) {
+	s = strings.TrimLeft(s, " \t")
+	if s == "" {
+		return "", "", fmt.Errorf("expected a value")
+	}
+	if quote := s[0]; quote == '\'' || quote == '"' {
+		var buf []byte
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				if i+1 < len(s) {
+					i++
+					buf = append(buf, s[i])
+				}
+			case quote:
+				return string(buf), s[i+1:], nil
+			default:
+				buf = append(buf, s[i])
+			}
+		}
+		return "", "", fmt.Errorf("unterminated quote in %q", s)
+	}
+	end := strings.IndexAny(s, " \t,{}")
+	if end == -1 {
+		return s, "", nil
+	}
+	return s[:end], s[end:], nil
+}
+
+// readARFFValue reads a value from the start of s, which runs to the
+// next comma unless it's quoted, and skips the comma after it.
+func readARFFValue(s string) (string, string, error) {
+	s = strings.TrimLeft(s, " \t")
+	var val, rest string
+	if s != "" && (s[0] == '\'' || s[0] == '"') {
+		var err error
+		if val, rest, err = readARFFToken(s); err != nil {
+			return "", "", err
+		}
+	} else {
+		end := strings.Index(s, ",")
+		if end == -1 {
+			end = len(s)
+		}
+		val, rest = strings.TrimSpace(s[:end]), s[end:]
+	}
+	rest = strings.TrimSpace(rest)
+	if strings.HasPrefix(rest, ",") {
+		rest = rest[1:]
+	} else if rest != "" {
+		return "", "", fmt.Errorf("expected a comma before %q", rest)
+	}
+	return val, rest, nil
+}
+
+// splitARFFValues splits a comma-separated list of possibly quoted
+// values
+func splitARFFValues(s string) ([]string, error) {
+	ret := make([]string, 0)
+	for strings.TrimSpace(s) != "" {
+		val, rest, err := readARFFValue(s)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, val)
+		s = rest
+	}
+	return ret, nil
+}
+
+// splitARFFRow splits a data row into its values. Rows in sparse
+// format ("{index value, ...}") are returned as alternating indices
+// and values, preceded by "{".
+func splitARFFRow(s string) ([]string, error) {
+	if !strings.HasPrefix(s, "{") {
+		return splitARFFValues(s)
+	}
+	end := strings.LastIndex(s, "}")
+	if end == -1 {
+		return nil, fmt.Errorf("unterminated sparse row %q", s)
+	}
+	ret := []string{"{"}
+	for s = s[1:end]; strings.TrimSpace(s) != ""; {
+		index, rest, err := readARFFToken(s)
+		if err != nil {
+			return nil, err
+		}
+		val, rest, err := readARFFValue(rest)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, index, val)
+		s = rest
+	}
+	return ret, nil
+}
+
+// setARFFRow stores a row returned by splitARFFRow in row i of inst
+func setARFFRow(inst *Instances, i int, record []string) error {
+	if len(record) > 0 && record[0] == "{" {
+		for k := 1; k+1 < len(record); k += 2 {
+			j, err := strconv.Atoi(record[k])
+			if err != nil || j < 0 || j >= inst.Cols {
+				return fmt.Errorf("bad index %q", record[k])
+			}
+			if err := setARFFValue(inst, i, j, record[k+1]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if len(record) != inst.Cols {
+		return fmt.Errorf("expected %d values, got %d", inst.Cols, len(record))
+	}
+	for j, val := range record {
+		if err := setARFFValue(inst, i, j, val); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setARFFValue stores a single value, checking it against the
+// Attribute's declaration
+func setARFFValue(inst *Instances, i, j int, val string) error {
+	if val == MissingString {
+		inst.SetMissing(i, j)
+		return nil
+	}
+	switch a := inst.GetAttr(j).(type) {
+	case *FloatAttribute:
+		f, err := a.CheckSysValFromString(val)
+		if err != nil {
+			return err
+		}
+		inst.Set(i, j, f)
+	case *CategoricalAttribute:
+		sysVal := a.GetSysVal(val)
+		if sysVal == -1 {
+			return fmt.Errorf("%q isn't a declared value of %s", val, a.GetName())
+		}
+		inst.Set(i, j, sysVal)
+	case *StringAttribute:
+		inst.Set(i, j, a.GetSysValFromString(val))
+	}
+	return nil
+}
+
+// SerializeInstancesToARFF writes inst to the file at filepath in
+// ARFF format (see WriteARFF).
+func SerializeInstancesToARFF(inst *Instances, filepath string, relation string) error {
+	file, err := os.Create(filepath)
+	if err != nil {
+		return err
+	}
+	if err := WriteARFF(file, inst, relation); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// WriteARFF writes inst to w in ARFF format, declaring the type of
+// each Attribute and all of the values of each CategoricalAttribute,
+// so that ParseARFF reads back the same Instances. The class
+// Attribute is written last.
+func WriteARFF(w io.Writer, inst *Instances, relation string) error {
+	buf := bufio.NewWriter(w)
+	order := make([]int, 0, inst.Cols)
+	for j := 0; j < inst.Cols; j++ {
+		if j != inst.ClassIndex {
+			order = append(order, j)
+		}
+	}
+	order = append(order, inst.ClassIndex)
+
+	fmt.Fprintf(buf, "@relation %s\n\n", quoteARFF(relation))
+	for _, j := range order {
+		a := inst.GetAttr(j)
+		switch attr := a.(type) {
+		case *CategoricalAttribute:
+			values := make([]string, len(attr.values))
+			for k, v := range attr.values {
+				values[k] = quoteARFF(v)
+			}
+			fmt.Fprintf(buf, "@attribute %s {%s}\n", quoteARFF(a.GetName()), strings.Join(values, ","))
+		case *FloatAttribute:
+			fmt.Fprintf(buf, "@attribute %s numeric\n", quoteARFF(a.GetName()))
+		case *StringAttribute:
+			fmt.Fprintf(buf, "@attribute %s string\n", quoteARFF(a.GetName()))
+		default:
+			return fmt.Errorf("base: can't write %s to ARFF", a)
+		}
+	}
+	buf.WriteString("\n@data\n")
+	values := make([]string, len(order))
+	for i := 0; i < inst.Rows; i++ {
+		for k, j := range order {
+			val := inst.Get(i, j)
+			switch {
+			case IsMissingValue(val):
+				values[k] = MissingString
+			case inst.GetAttr(j).GetType() == Float64Type:
+				values[k] = strconv.FormatFloat(val, 'g', -1, 64)
+			default:
+				values[k] = quoteARFF(inst.GetAttrStr(i, j))
+			}
+		}
+		buf.WriteString(strings.Join(values, ","))
+		buf.WriteString("\n")
+	}
+	return buf.Flush()
+}
+
+// quoteARFF quotes s if it contains anything which would stop it
+// being read back as a single value
+func quoteARFF(s string) string {
+	if s != "" && s != "?" && !strings.ContainsAny(s, " \t,{}'\"%\\") {
+		return s
+	}
+	s = strings.Replace(s, "\\", "\\\\", -1)
+	s = strings.Replace(s, "'", "\\'", -1)
+	return "'" + s + "'"
+}
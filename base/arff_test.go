@@ -0,0 +1,90 @@
+package base
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestARFFRoundTrip(testEnv *testing.T) {
+	inst, err := ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := WriteARFF(&buf, inst, "iris"); err != nil {
+		testEnv.Fatal(err)
+	}
+	read, err := ParseARFF(&buf)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if !read.Equal(inst) {
+		testEnv.Error("Instances differ after round trip")
+	}
+	for j := 0; j < inst.Cols; j++ {
+		if !read.GetAttr(j).Equals(inst.GetAttr(j)) {
+			testEnv.Error(read.GetAttr(j), inst.GetAttr(j))
+		}
+	}
+}
+
+func TestParseARFF(testEnv *testing.T) {
+	inst, err := ParseARFFToInstances("../examples/datasets/weather.arff")
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if inst.Rows != 14 || inst.Cols != 5 {
+		testEnv.Fatal(inst.Rows, inst.Cols)
+	}
+	// Nominal values are in the declared order, not the order seen
+	outlook := inst.GetAttr(0).(*CategoricalAttribute)
+	if outlook.GetSysVal("sunny") != 0 || outlook.GetSysVal("rainy") != 2 {
+		testEnv.Error(outlook)
+	}
+	if inst.GetAttr(1).GetType() != Float64Type || inst.Get(0, 1) != 85 {
+		testEnv.Error(inst.GetAttr(1), inst.Get(0, 1))
+	}
+	if !math.IsNaN(inst.Get(13, 2)) {
+		testEnv.Error("Missing value should be NaN")
+	}
+	if inst.GetClass(0) != "no" || inst.GetAttrStr(2, 0) != "overcast" {
+		testEnv.Error(inst.RowStr(0), inst.RowStr(2))
+	}
+}
+
+func TestParseARFFSparse(testEnv *testing.T) {
+	data := `% A sparse dataset
+@RELATION 'bag of words'
+@ATTRIBUTE 'word one' NUMERIC
+@ATTRIBUTE word2 REAL
+@ATTRIBUTE word3 INTEGER
+@ATTRIBUTE class {'not spam', spam}
+@DATA
+{0 2, 3 spam}
+{1 1.5, 3 'not spam'}
+`
+	inst, err := ParseARFF(strings.NewReader(data))
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if !inst.IsSparse() || inst.Rows != 2 {
+		testEnv.Fatal(inst)
+	}
+	if inst.Get(0, 0) != 2 || inst.Get(0, 1) != 0 || inst.GetClass(0) != "spam" {
+		testEnv.Error(inst.RowStr(0))
+	}
+	if inst.Get(1, 1) != 1.5 || inst.GetClass(1) != "not spam" {
+		testEnv.Error(inst.RowStr(1))
+	}
+	if inst.GetAttr(0).GetName() != "word one" {
+		testEnv.Error(inst.GetAttr(0))
+	}
+
+	// A class which wasn't declared is an error
+	_, err = ParseARFF(strings.NewReader(strings.Replace(data, "3 spam", "3 ham", 1)))
+	if err == nil {
+		testEnv.Error("Expected an error")
+	}
+}
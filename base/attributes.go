@@ -1,6 +1,7 @@
 package base
 
 import "fmt"
+import "math"
 import "strconv"
 
 const (
@@ -8,6 +9,9 @@ const (
 	CategoricalType = iota
 	// Float64Type should be replaced with a FractionalNumeric type [DEPRECATED].
 	Float64Type
+	// StringType is for Attributes which hold raw text, such as
+	// StringAttribute.
+	StringType
 )
 
 // Attribute Attributes disambiguate columns of the feature matrix and declare their types.
@@ -40,6 +44,12 @@ type Attribute interface {
 	Equals(Attribute) bool
 }
 
+// checkedAttribute is implemented by Attributes whose raw values
+// can be invalid, so need checking when read.
+type checkedAttribute interface {
+	CheckSysValFromString(string) (float64, error)
+}
+
 // FloatAttribute is an implementation which stores floating point
 // representations of numbers.
 type FloatAttribute struct {
@@ -92,8 +102,12 @@ func (Attr *FloatAttribute) String() string {
 }
 
 // CheckSysValFromString confirms whether a given rawVal can
-// be converted into a valid system representation.
+// be converted into a valid system representation. Missing values
+// are valid.
 func (Attr *FloatAttribute) CheckSysValFromString(rawVal string) (float64, error) {
+	if IsMissingString(rawVal) {
+		return math.NaN(), nil
+	}
 	f, err := strconv.ParseFloat(rawVal, 64)
 	if err != nil {
 		return 0.0, err
@@ -104,9 +118,13 @@ func (Attr *FloatAttribute) CheckSysValFromString(rawVal string) (float64, error
 // GetSysValFromString parses the given rawVal string to a float64 and returns it.
 //
 // float64 happens to be a 1-to-1 mapping to the system representation.
+// Missing values (see IsMissingString) become NaN.
 // IMPORTANT: This function panic()s if rawVal is not a valid float.
 // Use CheckSysValFromString to confirm.
 func (Attr *FloatAttribute) GetSysValFromString(rawVal string) float64 {
+	if IsMissingString(rawVal) {
+		return math.NaN()
+	}
 	f, err := strconv.ParseFloat(rawVal, 64)
 	if err != nil {
 		panic(err)
@@ -117,6 +135,9 @@ func (Attr *FloatAttribute) GetSysValFromString(rawVal string) float64 {
 // GetStringFromSysVal converts a given system value to to a string with two decimal
 // places of precision [TODO: revise this and allow more precision].
 func (Attr *FloatAttribute) GetStringFromSysVal(rawVal float64) string {
+	if IsMissingValue(rawVal) {
+		return MissingString
+	}
 	formatString := fmt.Sprintf("%%.%df", Attr.Precision)
 	return fmt.Sprintf(formatString, rawVal)
 }
@@ -186,6 +207,14 @@ func (Attr *CategoricalAttribute) GetUsrVal(sysVal float64) string {
 	return Attr.values[idx]
 }
 
+// GetValues returns a copy of the values this CategoricalAttribute
+// can take, in the order of their system representations.
+func (Attr *CategoricalAttribute) GetValues() []string {
+	ret := make([]string, len(Attr.values))
+	copy(ret, Attr.values)
+	return ret
+}
+
 // GetSysValFromString returns the system representation of rawVal
 // as an index into the Values slice. If rawVal is not inside
 // the Values slice, it is appended.
@@ -198,7 +227,12 @@ func (Attr *CategoricalAttribute) GetUsrVal(sysVal float64) string {
 // "iris-virginica"] and "iris-versicolor" is provided as the argument,
 // the Values slide becomes ["iris-setosa", "iris-virginica", "iris-versicolor"]
 // and 2.00 is returned as the system representation.
+//
+// Missing values (see IsMissingString) aren't added: NaN is returned.
 func (Attr *CategoricalAttribute) GetSysValFromString(rawVal string) float64 {
+	if IsMissingString(rawVal) {
+		return math.NaN()
+	}
 	// Match in raw values
 	catIndex := -1
 	for i, s := range Attr.values {
@@ -229,6 +263,9 @@ func (Attr *CategoricalAttribute) String() string {
 // the length of the array.
 // TODO: Return a user-configurable default instead.
 func (Attr *CategoricalAttribute) GetStringFromSysVal(val float64) string {
+	if IsMissingValue(val) {
+		return MissingString
+	}
 	convVal := int(val)
 	if convVal >= len(Attr.values) {
 		panic(fmt.Sprintf("Out of range: %d in %d", convVal, len(Attr.values)))
@@ -1,6 +1,8 @@
 package base
 
 import (
+	"sort"
+
 	"github.com/gonum/matrix/mat64"
 )
 
@@ -18,6 +20,58 @@ type Classifier interface {
 	String() string
 }
 
+// ClassifierFactory creates a new, untrained Classifier. It's used
+// wherever several similarly-configured Classifiers are needed.
+type ClassifierFactory func() Classifier
+
+// CloneableClassifier implementations can create untrained copies
+// of themselves with identical parameters, so that they can be
+// trained several times over (e.g. once per cross-validation fold).
+type CloneableClassifier interface {
+	Classifier
+	// Returns a new, untrained Classifier with the same parameters.
+	Clone() Classifier
+}
+
+// ProbabilisticClassifier implementations can also estimate how
+// likely each class is, rather than just picking the most likely one.
+type ProbabilisticClassifier interface {
+	Classifier
+	// Takes a set of Instances and returns, for each row, a map
+	// from each class' string representation to its estimated
+	// probability. Classes which are omitted have zero probability.
+	PredictProba(*Instances) []map[string]float64
+}
+
+// LeaveOneOutClassifier implementations can predict each row of the
+// Instances they were trained on as though it had been left out of
+// them, much faster than retraining without each row in turn (see
+// evaluation.LeaveOneOutPredict).
+type LeaveOneOutClassifier interface {
+	Classifier
+	// Returns, like Predict, the prediction for each training row
+	// made without that row.
+	PredictLeaveOneOut() *Instances
+}
+
+// MostLikelyClass returns the class with the highest probability in
+// a row returned by PredictProba, and that probability. Ties go to the
+// class which sorts first, so they're broken consistently.
+func MostLikelyClass(probabilities map[string]float64) (string, float64) {
+	classes := make([]string, 0, len(probabilities))
+	for c := range probabilities {
+		classes = append(classes, c)
+	}
+	sort.Strings(classes)
+	best, bestProb := "", 0.0
+	for _, c := range classes {
+		if best == "" || probabilities[c] > bestProb {
+			best, bestProb = c, probabilities[c]
+		}
+	}
+	return best, bestProb
+}
+
 // BaseClassifier stores options common to every classifier.
 type BaseClassifier struct {
 	TrainingData *Instances
@@ -0,0 +1,182 @@
+//go:build go1.18
+// +build go1.18
+
+package base
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// ColumnValue is the set of Go types a Column can hold.
+type ColumnValue interface {
+	float64 | float32 | int | string | time.Time
+}
+
+// Column is a typed view of one column of Instances, which converts
+// between the system representation and T once, when it's created,
+// rather than going through an Attribute for every value:
+//
+//	ages, err := base.NewColumn[int](inst, 0)
+//	names, err := base.NewColumn[string](inst, 1)
+//
+// Numeric columns can be viewed as float64, float32 or int,
+// TimeAttribute columns as time.Time (or numbers, the seconds since
+// the Unix epoch) and CategoricalAttribute or StringAttribute columns
+// as string. Setting a value sets it in the Instances.
+type Column[T ColumnValue] struct {
+	inst   *Instances
+	col    int
+	decode func(float64) T
+	encode func(T) float64
+}
+
+// NewColumn returns a Column of T for the given column of inst, or
+// an error if its Attribute can't hold values of T.
+func NewColumn[T ColumnValue](inst *Instances, col int) (*Column[T], error) {
+	attr := inst.GetAttr(col)
+	ret := &Column[T]{inst: inst, col: col}
+	var zero T
+	var decode, encode interface{}
+	switch any(zero).(type) {
+	case float64:
+		if attr.GetType() == Float64Type {
+			decode = func(v float64) float64 { return v }
+			encode = func(v float64) float64 { return v }
+		}
+	case float32:
+		if attr.GetType() == Float64Type {
+			decode = func(v float64) float32 { return float32(v) }
+			encode = func(v float32) float64 { return float64(v) }
+		}
+	case int:
+		if attr.GetType() == Float64Type {
+			// Missing values can't be represented, so they're 0
+			decode = func(v float64) int {
+				if IsMissingValue(v) {
+					return 0
+				}
+				return int(math.Floor(v + 0.5))
+			}
+			encode = func(v int) float64 { return float64(v) }
+		}
+	case string:
+		if attr.GetType() == CategoricalType || attr.GetType() == StringType {
+			decode = func(v float64) string {
+				if IsMissingValue(v) {
+					return ""
+				}
+				return attr.GetStringFromSysVal(v)
+			}
+			encode = func(v string) float64 { return attr.GetSysValFromString(v) }
+		}
+	case time.Time:
+		if t, ok := attr.(*TimeAttribute); ok {
+			decode = func(v float64) time.Time {
+				if IsMissingValue(v) {
+					return time.Time{}
+				}
+				return t.GetUsrVal(v)
+			}
+			encode = func(v time.Time) float64 {
+				if v.IsZero() {
+					return math.NaN()
+				}
+				return t.GetSysVal(v)
+			}
+		}
+	}
+	if decode == nil {
+		return nil, fmt.Errorf("base: can't view %s as a column of %T", attr, zero)
+	}
+	ret.decode = decode.(func(float64) T)
+	ret.encode = encode.(func(T) float64)
+	return ret, nil
+}
+
+// Len returns the number of rows
+func (c *Column[T]) Len() int {
+	return c.inst.Rows
+}
+
+// Attribute returns the Attribute of the column
+func (c *Column[T]) Attribute() Attribute {
+	return c.inst.GetAttr(c.col)
+}
+
+// At returns the value in the given row. Missing values are the zero
+// value of T, or NaN for floating-point types; use IsMissing to tell
+// them apart.
+func (c *Column[T]) At(row int) T {
+	return c.decode(c.inst.Get(row, c.col))
+}
+
+// IsMissing returns true if the value in the given row is missing
+func (c *Column[T]) IsMissing(row int) bool {
+	return c.inst.IsMissing(row, c.col)
+}
+
+// Set sets the value in the given row. Empty strings and zero times
+// are missing values.
+func (c *Column[T]) Set(row int, val T) {
+	c.inst.Set(row, c.col, c.encode(val))
+}
+
+// Values returns a new slice of every value in the column
+func (c *Column[T]) Values() []T {
+	ret := make([]T, c.inst.Rows)
+	for i := range ret {
+		ret[i] = c.At(i)
+	}
+	return ret
+}
+
+// SetValues sets every value in the column
+//
+// IMPORTANT: this function panic()s if there are the wrong number of
+// values.
+func (c *Column[T]) SetValues(vals []T) {
+	if len(vals) != c.inst.Rows {
+		panic(fmt.Sprintf("base: %d values for %d rows", len(vals), c.inst.Rows))
+	}
+	for i, v := range vals {
+		c.Set(i, v)
+	}
+}
+
+// Apply replaces each value in the column which isn't missing with
+// f of it.
+func (c *Column[T]) Apply(f func(T) T) {
+	for i := 0; i < c.inst.Rows; i++ {
+		if !c.IsMissing(i) {
+			c.Set(i, f(c.At(i)))
+		}
+	}
+}
+
+// MapColumn returns f of each value in c which isn't missing, and
+// the rows they came from.
+func MapColumn[T ColumnValue, U any](c *Column[T], f func(T) U) ([]U, []int) {
+	vals := make([]U, 0, c.Len())
+	rows := make([]int, 0, c.Len())
+	for i := 0; i < c.Len(); i++ {
+		if !c.IsMissing(i) {
+			vals = append(vals, f(c.At(i)))
+			rows = append(rows, i)
+		}
+	}
+	return vals, rows
+}
+
+// WhereColumn returns the rows of c whose values aren't missing and
+// satisfy pred, e.g. for SelectRows or ViewRows.
+func WhereColumn[T ColumnValue](c *Column[T], pred func(T) bool) []int {
+	ret := make([]int, 0)
+	for i := 0; i < c.Len(); i++ {
+		if !c.IsMissing(i) && pred(c.At(i)) {
+			ret = append(ret, i)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,77 @@
+//go:build go1.18
+// +build go1.18
+
+package base
+
+import (
+	"testing"
+	"time"
+)
+
+func TestColumn(testEnv *testing.T) {
+	inst, err := ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	lengths, err := NewColumn[float64](inst, 0)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if lengths.Len() != 150 || lengths.At(0) != 5.1 {
+		testEnv.Error(lengths.At(0))
+	}
+	species, err := NewColumn[string](inst, 4)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	setosa := WhereColumn(species, func(s string) bool { return s == "Iris-setosa" })
+	if len(setosa) != 50 {
+		testEnv.Error(len(setosa))
+	}
+	species.Set(0, "Iris-unknown")
+	if inst.GetClass(0) != "Iris-unknown" {
+		testEnv.Error(inst.RowStr(0))
+	}
+	if _, err := NewColumn[string](inst, 0); err == nil {
+		testEnv.Error("Expected an error viewing numbers as strings")
+	}
+	if _, err := NewColumn[time.Time](inst, 0); err == nil {
+		testEnv.Error("Expected an error viewing numbers as times")
+	}
+
+	rounded, err := NewColumn[int](inst, 0)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	rounded.Apply(func(v int) int { return v * 10 })
+	if inst.Get(0, 0) != 50 {
+		testEnv.Error(inst.RowStr(0))
+	}
+	doubled, rows := MapColumn(lengths, func(v float64) float64 { return 2 * v })
+	if len(doubled) != 150 || doubled[0] != 100 || rows[149] != 149 {
+		testEnv.Error(doubled[0], rows[149])
+	}
+}
+
+func TestTimeColumn(testEnv *testing.T) {
+	when := NewTimeAttribute("")
+	when.SetName("when")
+	inst := NewInstances([]Attribute{when, NewCategoricalAttribute()}, 2)
+	inst.SetAttrStr(0, 0, "2015-03-01 13:30:00")
+	inst.SetMissing(1, 0)
+
+	times, err := NewColumn[time.Time](inst, 0)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if !times.At(0).Equal(time.Date(2015, 3, 1, 13, 30, 0, 0, time.UTC)) {
+		testEnv.Error(times.At(0))
+	}
+	if !times.IsMissing(1) || !times.At(1).IsZero() {
+		testEnv.Error(times.At(1))
+	}
+	times.Set(1, time.Date(2016, 12, 25, 8, 0, 0, 0, time.UTC))
+	if inst.GetAttrStr(1, 0) != "2016-12-25T08:00:00Z" {
+		testEnv.Error(inst.RowStr(1))
+	}
+}
@@ -0,0 +1,101 @@
+package base
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CompatibilityError describes how Instances to predict differ from
+// the Instances a Classifier was trained on, as found by
+// CompatibilityCheck.
+type CompatibilityError struct {
+	// Missing are the Attributes of the training data which the
+	// other Instances don't have (by name)
+	Missing []string
+	// Mismatched are those whose types differ
+	Mismatched []string
+	// Moved are those which are in a different column, which
+	// Classifiers that look Attributes up by index get wrong
+	Moved []string
+	// Unseen holds, by Attribute name, the categorical values which
+	// occur in the other Instances but not in the training data
+	Unseen map[string][]string
+}
+
+// Error summarises the differences, e.g. "base: incompatible
+// Instances: missing Attributes colour; unseen values of size: XL"
+func (e *CompatibilityError) Error() string {
+	problems := make([]string, 0)
+	if len(e.Missing) > 0 {
+		problems = append(problems, "missing Attributes "+strings.Join(e.Missing, ", "))
+	}
+	if len(e.Mismatched) > 0 {
+		problems = append(problems, "different types of "+strings.Join(e.Mismatched, ", "))
+	}
+	if len(e.Moved) > 0 {
+		problems = append(problems, "moved Attributes "+strings.Join(e.Moved, ", "))
+	}
+	names := make([]string, 0, len(e.Unseen))
+	for name := range e.Unseen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		problems = append(problems, fmt.Sprintf("unseen values of %s: %s", name, strings.Join(e.Unseen[name], ", ")))
+	}
+	return "base: incompatible Instances: " + strings.Join(problems, "; ")
+}
+
+// CompatibilityCheck checks that test can be given to a Classifier
+// trained on train: that it has each of train's features and its
+// class Attribute, by name, in the same column and of the same type,
+// and that its categorical Attributes don't have values which train
+// doesn't. It returns nil if so, and a *CompatibilityError describing
+// every difference otherwise. Attributes which train doesn't use are
+// ignored.
+func CompatibilityCheck(train, test *Instances) error {
+	byName := make(map[string]int)
+	for j, a := range test.attributes {
+		byName[a.GetName()] = j
+	}
+	ret := &CompatibilityError{Unseen: make(map[string][]string)}
+	cols := append(train.FeatureIndices(), train.ClassIndex)
+	for _, j := range cols {
+		a := train.attributes[j]
+		k, ok := byName[a.GetName()]
+		if !ok {
+			ret.Missing = append(ret.Missing, a.GetName())
+			continue
+		}
+		b := test.attributes[k]
+		if attributeTypeName(a) != attributeTypeName(b) {
+			ret.Mismatched = append(ret.Mismatched, a.GetName())
+			continue
+		}
+		if j != k {
+			ret.Moved = append(ret.Moved, a.GetName())
+		}
+		if a.GetType() != CategoricalType {
+			continue
+		}
+		if _, hashed := a.(*HashedAttribute); hashed {
+			continue
+		}
+		seen := train.CountAttrValues(a)
+		unseen := make([]string, 0)
+		for v := range test.CountAttrValues(b) {
+			if _, ok := seen[v]; !ok {
+				unseen = append(unseen, v)
+			}
+		}
+		if len(unseen) > 0 {
+			sort.Strings(unseen)
+			ret.Unseen[a.GetName()] = unseen
+		}
+	}
+	if len(ret.Missing) == 0 && len(ret.Mismatched) == 0 && len(ret.Moved) == 0 && len(ret.Unseen) == 0 {
+		return nil
+	}
+	return ret
+}
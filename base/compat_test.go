@@ -0,0 +1,58 @@
+package base
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCompatibilityCheck(testEnv *testing.T) {
+	train, err := ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if err := CompatibilityCheck(train, train.Copy()); err != nil {
+		testEnv.Error(err)
+	}
+
+	test := train.Copy()
+	test.SetAttrStr(0, 4, "Iris-unknown")
+	err = CompatibilityCheck(train, test)
+	compat, ok := err.(*CompatibilityError)
+	if !ok || len(compat.Unseen["Species"]) != 1 || compat.Unseen["Species"][0] != "Iris-unknown" {
+		testEnv.Fatal(err)
+	}
+	if !strings.Contains(err.Error(), "unseen values of Species: Iris-unknown") {
+		testEnv.Error(err)
+	}
+
+	// Leaving out the first column moves the others
+	attrs := make([]Attribute, 0)
+	for j := 1; j < train.Cols; j++ {
+		attrs = append(attrs, train.GetAttr(j))
+	}
+	err = CompatibilityCheck(train, train.SelectAttributes(attrs))
+	if compat, ok = err.(*CompatibilityError); !ok {
+		testEnv.Fatal(err)
+	}
+	if len(compat.Missing) != 1 || compat.Missing[0] != "Sepal length" || len(compat.Moved) != 4 {
+		testEnv.Error(compat.Missing, compat.Moved)
+	}
+
+	// A column of a different type
+	attrs = make([]Attribute, train.Cols)
+	for j := range attrs {
+		attrs[j] = train.GetAttr(j)
+	}
+	attrs[1] = NewCategoricalAttribute()
+	attrs[1].SetName("Sepal width")
+	err = CompatibilityCheck(train, NewInstances(attrs, 1))
+	if compat, ok = err.(*CompatibilityError); !ok || len(compat.Mismatched) != 1 || compat.Mismatched[0] != "Sepal width" {
+		testEnv.Error(err)
+	}
+
+	// Attributes train ignores don't matter
+	train.SetRole(1, IgnoredRole)
+	if err := CompatibilityCheck(train, NewInstances(attrs, 1)); err != nil {
+		testEnv.Error(err)
+	}
+}
@@ -0,0 +1,165 @@
+package base
+
+import (
+	"io/ioutil"
+	"os"
+	"runtime"
+	"sync"
+)
+
+// Config controls how much of the machine golearn uses. Components
+// which train or predict concurrently (e.g. meta.BaggedModel and
+// evaluation.CrossValPredict) run at most Workers goroutines at once,
+// and fewer if each would need more than its share of MaxMemory;
+// CSVStreams choose their chunk size from MaxMemory; and data which
+// doesn't fit is spilled to memory-mapped files in TempDir.
+//
+// The zero Config means one worker per CPU, no memory limit and the
+// system's temporary directory.
+type Config struct {
+	// MaxWorkers is the most goroutines a component runs at once
+	MaxWorkers int
+	// MaxMemory is a hint of how many bytes a component may use
+	// for the rows it copies or reads
+	MaxMemory int64
+	// TempDir is where out-of-core data is spilled
+	TempDir string
+	// Float32 makes NewInstances (and so the readers and most
+	// filters) use a Float32Storage, halving the memory taken by
+	// numeric data at the cost of precision
+	Float32 bool
+}
+
+// DefaultChunkRows is the number of rows CSVStreams read at a time
+// when they're free to choose and there's no memory limit.
+const DefaultChunkRows = 1024
+
+var (
+	configLock sync.RWMutex
+	config     Config
+)
+
+// SetConfig replaces the Config used from then on by every package.
+func SetConfig(c Config) {
+	configLock.Lock()
+	defer configLock.Unlock()
+	config = c
+}
+
+// GetConfig returns the current Config
+func GetConfig() Config {
+	configLock.RLock()
+	defer configLock.RUnlock()
+	return config
+}
+
+// Workers returns the most goroutines a component should run at
+// once.
+func (c Config) Workers() int {
+	if c.MaxWorkers > 0 {
+		return c.MaxWorkers
+	}
+	return runtime.NumCPU()
+}
+
+// WorkersFor returns how many workers to run if each of them needs
+// the given number of bytes: as many as fit in MaxMemory, but at
+// least one and at most Workers.
+func (c Config) WorkersFor(bytes int64) int {
+	n := c.Workers()
+	if c.MaxMemory <= 0 || bytes <= 0 {
+		return n
+	}
+	if fit := c.MaxMemory / bytes; fit < int64(n) {
+		n = int(fit)
+	}
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// ChunkRows returns how many rows of cols values fit in MaxMemory,
+// at least one, or DefaultChunkRows if there's no limit.
+func (c Config) ChunkRows(cols int) int {
+	if c.MaxMemory <= 0 {
+		return DefaultChunkRows
+	}
+	rows := c.MaxMemory / (int64(c.ValueBytes()) * int64(cols))
+	if rows < 1 {
+		return 1
+	}
+	if rows > int64(^uint(0)>>1) {
+		return int(^uint(0) >> 1)
+	}
+	return int(rows)
+}
+
+// ValueBytes returns the number of bytes each value of new Instances
+// takes up: 4 if Float32 is set, otherwise 8.
+func (c Config) ValueBytes() int {
+	if c.Float32 {
+		return 4
+	}
+	return 8
+}
+
+// InstancesBytes returns roughly how many bytes of memory Instances
+// with the given dimensions take up, under the current Config.
+func InstancesBytes(rows, cols int) int64 {
+	return int64(GetConfig().ValueBytes()) * int64(rows) * int64(cols)
+}
+
+// TempDirectory returns the directory to spill data to
+func (c Config) TempDirectory() string {
+	if c.TempDir != "" {
+		return c.TempDir
+	}
+	return os.TempDir()
+}
+
+// CreateTemp creates a new temporary file in TempDirectory, with a
+// name starting with prefix. The caller should remove it.
+func (c Config) CreateTemp(prefix string) (*os.File, error) {
+	return ioutil.TempFile(c.TempDirectory(), prefix)
+}
+
+// Parallel calls f for each i from 0 to n-1, running at most workers
+// of the calls at once (see Config.Workers), and returns once they've
+// all finished. If a call panic()s, Parallel panic()s with the same
+// value once the rest have finished, so that the caller can recover.
+func Parallel(n, workers int, f func(i int)) {
+	if workers > n {
+		workers = n
+	}
+	pending := make(chan int, n)
+	for i := 0; i < n; i++ {
+		pending <- i
+	}
+	close(pending)
+	var wait sync.WaitGroup
+	var lock sync.Mutex
+	var panicked interface{}
+	for w := 0; w < workers; w++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					lock.Lock()
+					if panicked == nil {
+						panicked = r
+					}
+					lock.Unlock()
+				}
+			}()
+			for i := range pending {
+				f(i)
+			}
+		}()
+	}
+	wait.Wait()
+	if panicked != nil {
+		panic(panicked)
+	}
+}
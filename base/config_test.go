@@ -0,0 +1,66 @@
+package base
+
+import (
+	"runtime"
+	"sync/atomic"
+	"testing"
+)
+
+func TestConfig(testEnv *testing.T) {
+	defer SetConfig(GetConfig())
+	if GetConfig().Workers() != runtime.NumCPU() || GetConfig().ChunkRows(10) != DefaultChunkRows {
+		testEnv.Error(GetConfig())
+	}
+
+	SetConfig(Config{MaxWorkers: 4, MaxMemory: 8000})
+	c := GetConfig()
+	if c.Workers() != 4 {
+		testEnv.Error(c.Workers())
+	}
+	if n := c.WorkersFor(3000); n != 2 {
+		testEnv.Errorf("Expected 2 workers to fit, got %d", n)
+	}
+	if n := c.WorkersFor(10000); n != 1 {
+		testEnv.Errorf("Expected at least one worker, got %d", n)
+	}
+	if n := c.ChunkRows(10); n != 100 {
+		testEnv.Errorf("Expected 100 rows of 10 values to fit, got %d", n)
+	}
+
+	var running, most int32
+	calls := make([]int32, 20)
+	Parallel(len(calls), 3, func(i int) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			m := atomic.LoadInt32(&most)
+			if n <= m || atomic.CompareAndSwapInt32(&most, m, n) {
+				break
+			}
+		}
+		runtime.Gosched()
+		atomic.AddInt32(&calls[i], 1)
+		atomic.AddInt32(&running, -1)
+	})
+	for i, n := range calls {
+		if n != 1 {
+			testEnv.Errorf("Call %d made %d times", i, n)
+		}
+	}
+	if most > 3 {
+		testEnv.Errorf("%d calls ran at once", most)
+	}
+}
+
+func TestParallelPanic(testEnv *testing.T) {
+	defer func() {
+		if r := recover(); r != "oops" {
+			testEnv.Error(r)
+		}
+	}()
+	Parallel(10, 3, func(i int) {
+		if i == 4 {
+			panic("oops")
+		}
+	})
+	testEnv.Error("Expected a panic")
+}
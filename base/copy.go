@@ -0,0 +1,112 @@
+package base
+
+import (
+	"fmt"
+	"math"
+)
+
+// DefaultTolerance is the relative difference up to which Equal
+// considers numbers to be the same.
+const DefaultTolerance = 1e-9
+
+// Copy returns a deep copy of the Instances: new Storage of the same
+// kind (or dense, for a view or a memory-mapped file) and copies of
+// the Attributes, weights and roles, so that changing either one,
+// including adding values to a CategoricalAttribute, doesn't affect
+// the other. Attributes of types this package doesn't define are
+// shared.
+func (inst *Instances) Copy() *Instances {
+	attrs := make([]Attribute, inst.Cols)
+	for j, a := range inst.attributes {
+		attrs[j] = copyAttribute(a)
+	}
+	ret := inst.newInstancesLike(attrs, inst.Rows)
+	ret.ClassIndex = inst.ClassIndex
+	for i := 0; i < inst.Rows; i++ {
+		ret.copyRow(i, inst, i)
+	}
+	ret.weights = inst.Weights()
+	if inst.roles != nil {
+		ret.roles = make([]AttributeRole, len(inst.roles))
+		copy(ret.roles, inst.roles)
+	}
+	return ret
+}
+
+// copyAttribute returns a copy of a, or a itself if its type isn't
+// one of this package's
+func copyAttribute(a Attribute) Attribute {
+	switch attr := a.(type) {
+	case *FloatAttribute:
+		ret := *attr
+		return &ret
+	case *TimeAttribute:
+		ret := *attr
+		return &ret
+	case *HashedAttribute:
+		ret := *attr
+		return &ret
+	case *OrdinalAttribute:
+		return &OrdinalAttribute{attr.Name, attr.GetValues()}
+	case *CategoricalAttribute:
+		return &CategoricalAttribute{attr.Name, attr.GetValues()}
+	case *StringAttribute:
+		ret := &StringAttribute{attr.Name, make([]string, len(attr.values)), make(map[string]int)}
+		copy(ret.values, attr.values)
+		for k, v := range attr.index {
+			ret.index[k] = v
+		}
+		return ret
+	}
+	return a
+}
+
+// Compare returns an error describing the first difference between
+// inst and other, or nil if they have the same size, class index,
+// Attributes (by name and type) and weights, and the same values:
+// categorical values are compared by their string representation, so
+// the Attributes may list them in a different order, and numbers may
+// differ by tol relative to the larger of them (or absolutely, if
+// they're smaller than one). Missing values only match missing
+// values.
+func (inst *Instances) Compare(other *Instances, tol float64) error {
+	if inst.Rows != other.Rows || inst.Cols != other.Cols {
+		return fmt.Errorf("base: %dx%d Instances differ from %dx%d ones", inst.Rows, inst.Cols, other.Rows, other.Cols)
+	}
+	if inst.ClassIndex != other.ClassIndex {
+		return fmt.Errorf("base: the class index is %d, not %d", inst.ClassIndex, other.ClassIndex)
+	}
+	for j, a := range inst.attributes {
+		b := other.attributes[j]
+		if a.GetName() != b.GetName() || attributeTypeName(a) != attributeTypeName(b) {
+			return fmt.Errorf("base: Attribute %d is %s, not %s", j, a, b)
+		}
+	}
+	for i := 0; i < inst.Rows; i++ {
+		if !approxEqual(inst.GetWeight(i), other.GetWeight(i), tol) {
+			return fmt.Errorf("base: row %d has weight %g, not %g", i, inst.GetWeight(i), other.GetWeight(i))
+		}
+		for j, a := range inst.attributes {
+			x, y := inst.Get(i, j), other.Get(i, j)
+			same := IsMissingValue(x) == IsMissingValue(y)
+			if same && !IsMissingValue(x) {
+				if a.GetType() == Float64Type {
+					same = approxEqual(x, y, tol)
+				} else {
+					same = inst.GetAttrStr(i, j) == other.GetAttrStr(i, j)
+				}
+			}
+			if !same {
+				return fmt.Errorf("base: row %d, Attribute %s is %s, not %s", i, a.GetName(), inst.GetAttrStr(i, j), other.GetAttrStr(i, j))
+			}
+		}
+	}
+	return nil
+}
+
+// approxEqual returns true if x and y differ by at most tol relative
+// to the larger of them, or to one
+func approxEqual(x, y, tol float64) bool {
+	scale := math.Max(1, math.Max(math.Abs(x), math.Abs(y)))
+	return math.Abs(x-y) <= tol*scale
+}
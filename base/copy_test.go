@@ -0,0 +1,78 @@
+package base
+
+import "testing"
+
+func TestCopy(testEnv *testing.T) {
+	inst, err := ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	inst.SetWeight(3, 2)
+	inst.SetRole(1, IgnoredRole)
+	copied := inst.Copy()
+	if !copied.Equal(inst) || copied.GetWeight(3) != 2 || copied.GetRole(1) != IgnoredRole {
+		testEnv.Fatal(copied.Compare(inst, DefaultTolerance))
+	}
+
+	// Changes to the copy don't reach the original
+	copied.Set(0, 0, 100)
+	copied.SetAttrStr(1, 4, "Iris-unknown")
+	copied.SetWeight(3, 1)
+	if inst.Get(0, 0) == 100 || inst.GetWeight(3) != 2 {
+		testEnv.Error(inst.RowStr(0))
+	}
+	if n := len(inst.GetClassAttr().(*CategoricalAttribute).GetValues()); n != 3 {
+		testEnv.Errorf("The original class Attribute has %d values", n)
+	}
+	if err := copied.Compare(inst, DefaultTolerance); err == nil {
+		testEnv.Error("Expected the copy to differ")
+	}
+
+	sparse := newSparseIris(inst).Copy()
+	if !sparse.IsSparse() || !sparse.Equal(newSparseIris(inst)) {
+		testEnv.Error("Sparse copy differs")
+	}
+}
+
+func TestCompare(testEnv *testing.T) {
+	inst, err := ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	other := inst.Copy()
+	other.Set(0, 0, inst.Get(0, 0)+1e-12)
+	if err := other.Compare(inst, DefaultTolerance); err != nil {
+		testEnv.Error(err)
+	}
+	other.Set(0, 0, inst.Get(0, 0)+1e-3)
+	if other.Equal(inst) {
+		testEnv.Error("A difference of 1e-3 should be noticed")
+	}
+	if err := other.Compare(inst, 1e-2); err != nil {
+		testEnv.Error(err)
+	}
+
+	// Categorical values are compared by name, not index
+	attrs := make([]Attribute, inst.Cols)
+	for j := range attrs {
+		attrs[j] = inst.GetAttr(j)
+	}
+	class := NewCategoricalAttribute()
+	class.SetName(attrs[4].GetName())
+	class.GetSysValFromString("Iris-virginica")
+	attrs[4] = class
+	reordered := NewInstances(attrs, inst.Rows)
+	for i := 0; i < inst.Rows; i++ {
+		for j := 0; j < inst.Cols; j++ {
+			reordered.SetAttrStr(i, j, inst.GetAttrStr(i, j))
+		}
+		reordered.Set(i, 0, inst.Get(i, 0))
+	}
+	if err := reordered.Compare(inst, DefaultTolerance); err != nil {
+		testEnv.Error(err)
+	}
+	reordered.ClassIndex = 0
+	if reordered.Equal(inst) {
+		testEnv.Error("Expected a different class index to be noticed")
+	}
+}
@@ -0,0 +1,216 @@
+package base
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"text/tabwriter"
+
+	mat64 "github.com/gonum/matrix/mat64"
+)
+
+// LabelledMatrix is a square matrix of a statistic of each pair of a
+// set of Attributes, such as their correlation, with the names of the
+// Attributes as labels.
+type LabelledMatrix struct {
+	Labels []string
+	Values *mat64.Dense
+}
+
+// Get returns the value for the Attributes with the given names.
+//
+// IMPORTANT: panic()s if either name isn't one of the Labels.
+func (m *LabelledMatrix) Get(a, b string) float64 {
+	return m.Values.At(m.index(a), m.index(b))
+}
+
+// index returns the position of the given label
+func (m *LabelledMatrix) index(label string) int {
+	for i, l := range m.Labels {
+		if l == label {
+			return i
+		}
+	}
+	panic(fmt.Sprintf("base: no Attribute %s in the matrix", label))
+}
+
+// String returns the matrix as a table, with the labels along the
+// top and down the side.
+func (m *LabelledMatrix) String() string {
+	var buffer bytes.Buffer
+	w := tabwriter.NewWriter(&buffer, 0, 8, 2, ' ', tabwriter.AlignRight)
+	for _, l := range m.Labels {
+		fmt.Fprintf(w, "\t%s", l)
+	}
+	fmt.Fprintln(w, "\t")
+	for i, l := range m.Labels {
+		fmt.Fprintf(w, "%s", l)
+		for j := range m.Labels {
+			fmt.Fprintf(w, "\t%.3f", m.Values.At(i, j))
+		}
+		fmt.Fprintln(w, "\t")
+	}
+	w.Flush()
+	return buffer.String()
+}
+
+// CorrelationMethod is a measure of the correlation of two numeric
+// Attributes
+type CorrelationMethod int
+
+const (
+	// Pearson measures how linearly related the values are
+	Pearson CorrelationMethod = iota
+	// Spearman measures how well their ranks agree, i.e. how
+	// monotonically related the values are
+	Spearman
+)
+
+// Correlation returns the correlation matrix of the numeric
+// Attributes, measured with method. Each pair is compared over the
+// rows where neither is missing, and their correlation is NaN if
+// there are fewer than two of those, or either is constant over them.
+func (inst *Instances) Correlation(method CorrelationMethod) *LabelledMatrix {
+	cols := make([]int, 0)
+	labels := make([]string, 0)
+	for j, a := range inst.attributes {
+		if a.GetType() == Float64Type {
+			cols = append(cols, j)
+			labels = append(labels, a.GetName())
+		}
+	}
+	ret := &LabelledMatrix{labels, mat64.NewDense(len(cols), len(cols), nil)}
+	x := make([]float64, 0, inst.Rows)
+	y := make([]float64, 0, inst.Rows)
+	for a, j := range cols {
+		for b := a; b < len(cols); b++ {
+			x, y = x[:0], y[:0]
+			for i := 0; i < inst.Rows; i++ {
+				if !inst.IsMissing(i, j) && !inst.IsMissing(i, cols[b]) {
+					x = append(x, inst.Get(i, j))
+					y = append(y, inst.Get(i, cols[b]))
+				}
+			}
+			var r float64
+			if method == Spearman {
+				r = pearson(ranks(x), ranks(y))
+			} else {
+				r = pearson(x, y)
+			}
+			ret.Values.Set(a, b, r)
+			ret.Values.Set(b, a, r)
+		}
+	}
+	return ret
+}
+
+// pearson returns the Pearson correlation coefficient of x and y
+func pearson(x, y []float64) float64 {
+	n := float64(len(x))
+	if len(x) < 2 {
+		return math.NaN()
+	}
+	meanX, meanY := 0.0, 0.0
+	for i := range x {
+		meanX += x[i]
+		meanY += y[i]
+	}
+	meanX /= n
+	meanY /= n
+	sxy, sxx, syy := 0.0, 0.0, 0.0
+	for i := range x {
+		dx, dy := x[i]-meanX, y[i]-meanY
+		sxy += dx * dy
+		sxx += dx * dx
+		syy += dy * dy
+	}
+	if sxx == 0 || syy == 0 {
+		return math.NaN()
+	}
+	return sxy / math.Sqrt(sxx*syy)
+}
+
+// ranks returns the rank of each of vals, from 1, with tied values
+// sharing the mean of their ranks
+func ranks(vals []float64) []float64 {
+	order := make([]int, len(vals))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Sort(&byValue{order, vals})
+	ret := make([]float64, len(vals))
+	for start := 0; start < len(order); {
+		end := start + 1
+		for end < len(order) && vals[order[end]] == vals[order[start]] {
+			end++
+		}
+		rank := float64(start+end+1) / 2
+		for _, i := range order[start:end] {
+			ret[i] = rank
+		}
+		start = end
+	}
+	return ret
+}
+
+// byValue sorts indices by the values they refer to
+type byValue struct {
+	indices []int
+	vals    []float64
+}
+
+func (b *byValue) Len() int {
+	return len(b.indices)
+}
+
+func (b *byValue) Swap(i, j int) {
+	b.indices[i], b.indices[j] = b.indices[j], b.indices[i]
+}
+
+func (b *byValue) Less(i, j int) bool {
+	return b.vals[b.indices[i]] < b.vals[b.indices[j]]
+}
+
+// MutualInformation returns the mutual information, in nats, of each
+// pair of categorical Attributes, including the class if it's
+// categorical, so the diagonal is the entropy of each. Missing values
+// count as a value of their own.
+func (inst *Instances) MutualInformation() *LabelledMatrix {
+	cols := make([]int, 0)
+	labels := make([]string, 0)
+	for j, a := range inst.attributes {
+		if a.GetType() == CategoricalType {
+			cols = append(cols, j)
+			labels = append(labels, a.GetName())
+		}
+	}
+	ret := &LabelledMatrix{labels, mat64.NewDense(len(cols), len(cols), nil)}
+	for a, j := range cols {
+		for b := a; b < len(cols); b++ {
+			mi := inst.mutualInformation(j, cols[b])
+			ret.Values.Set(a, b, mi)
+			ret.Values.Set(b, a, mi)
+		}
+	}
+	return ret
+}
+
+// mutualInformation returns the mutual information of columns a and b
+func (inst *Instances) mutualInformation(a, b int) float64 {
+	joint := make(map[[2]string]float64)
+	left := make(map[string]float64)
+	right := make(map[string]float64)
+	for i := 0; i < inst.Rows; i++ {
+		x, y := inst.GetAttrStr(i, a), inst.GetAttrStr(i, b)
+		joint[[2]string{x, y}]++
+		left[x]++
+		right[y]++
+	}
+	n := float64(inst.Rows)
+	ret := 0.0
+	for key, count := range joint {
+		ret += count / n * math.Log(count*n/(left[key[0]]*right[key[1]]))
+	}
+	return ret
+}
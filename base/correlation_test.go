@@ -0,0 +1,73 @@
+package base
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestCorrelation(testEnv *testing.T) {
+	inst, err := ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	corr := inst.Correlation(Pearson)
+	if len(corr.Labels) != 4 || corr.Get("Sepal length", "Sepal length") != 1 {
+		testEnv.Fatal(corr)
+	}
+	// The well-known correlation of petal length and width
+	if r := corr.Get("Petal length", "Petal width"); math.Abs(r-0.963) > 0.001 {
+		testEnv.Error(r)
+	}
+	if corr.Get("Sepal width", "Petal length") != corr.Get("Petal length", "Sepal width") {
+		testEnv.Error("Not symmetric")
+	}
+	if !strings.Contains(corr.String(), "0.963") {
+		testEnv.Error(corr)
+	}
+
+	spearman := inst.Correlation(Spearman)
+	if r := spearman.Get("Petal length", "Petal width"); r < 0.9 || r > 1 {
+		testEnv.Error(r)
+	}
+}
+
+func TestRanks(testEnv *testing.T) {
+	r := ranks([]float64{10, 30, 20, 20})
+	if r[0] != 1 || r[1] != 4 || r[2] != 2.5 || r[3] != 2.5 {
+		testEnv.Error(r)
+	}
+	// A monotonic but non-linear relationship
+	x := []float64{1, 2, 3, 4, 5}
+	y := []float64{1, 4, 9, 16, 125}
+	if s := pearson(ranks(x), ranks(y)); s != 1 {
+		testEnv.Error(s)
+	}
+	if p := pearson(x, y); p >= 1 {
+		testEnv.Error(p)
+	}
+	if !math.IsNaN(pearson([]float64{1, 1}, []float64{1, 2})) {
+		testEnv.Error("Expected NaN for a constant")
+	}
+}
+
+func TestMutualInformation(testEnv *testing.T) {
+	inst, err := ParseCSVToInstances("../examples/datasets/tennis.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	mi := inst.MutualInformation()
+	if len(mi.Labels) != inst.Cols {
+		testEnv.Fatal(mi)
+	}
+	class := inst.GetClassAttr().GetName()
+	entropy := mi.Get(class, class)
+	if entropy <= 0 {
+		testEnv.Error(entropy)
+	}
+	for _, l := range mi.Labels {
+		if v := mi.Get(l, class); v < 0 || v > entropy+1e-9 {
+			testEnv.Errorf("%s: %f", l, v)
+		}
+	}
+}
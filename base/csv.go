@@ -1,6 +1,7 @@
 package base
 
 import (
+	"bufio"
 	"encoding/csv"
 	"fmt"
 	"io"
@@ -10,6 +11,118 @@ import (
 	"strings"
 )
 
+// CSVOptions controls how ParseCSVToInstancesWithOptions and
+// StreamCSVWithOptions read a CSV file. The zero value reads a
+// comma-separated file without headers, like ParseCSVToInstances.
+// Quoted fields may contain delimiters, quotes (doubled) and
+// newlines.
+type CSVOptions struct {
+	// Delimiter separates the fields, e.g. '\t' or ';'. Zero means
+	// a comma.
+	Delimiter rune
+	// Comment, if it isn't zero, starts lines which are ignored
+	Comment rune
+	// LazyQuotes allows quotes in unquoted fields, and undoubled
+	// quotes in quoted fields
+	LazyQuotes bool
+	// TrimLeadingSpace ignores spaces at the start of fields, e.g.
+	// after the delimiter in "1, 2, 3"
+	TrimLeadingSpace bool
+	// HasHeaders is true if the first row (after SkipRows) names
+	// the columns. Columns with an empty header are named after
+	// their index, and repeated names get a suffix: ".1", ".2", ...
+	HasHeaders bool
+	// SkipRows is the number of lines at the start of the file to
+	// skip, e.g. a preamble before the headers
+	SkipRows int
+	// MissingStrings are values which mark a missing value, on top
+	// of those recognised by IsMissingString, e.g. "NA" or "null"
+	MissingStrings []string
+	// Overrides gives the Attribute to use for some of the columns,
+	// by name, instead of inferring one (see
+	// ParseCSVToInstancesWithOverrides)
+	Overrides map[string]Attribute
+	// MaxLevels, if it isn't zero, is the most distinct values a
+	// categorical feature column may have: columns with more, such
+	// as IDs, are read as HashedAttributes with HashBuckets buckets
+	// (DefaultHashBuckets if it's zero). The class column, which
+	// is the last one, is never hashed.
+	MaxLevels   int
+	HashBuckets int
+}
+
+// hashBuckets returns the number of buckets for HashedAttributes
+func (o CSVOptions) hashBuckets() int {
+	if o.HashBuckets > 0 {
+		return o.HashBuckets
+	}
+	return DefaultHashBuckets
+}
+
+// newReader skips SkipRows lines of r and returns a csv.Reader for
+// the rest of it. Rows may have any number of fields, so check them.
+func (o CSVOptions) newReader(r io.Reader) (*csv.Reader, error) {
+	buffered := bufio.NewReader(r)
+	for i := 0; i < o.SkipRows; i++ {
+		if _, err := buffered.ReadString('\n'); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	reader := csv.NewReader(buffered)
+	if o.Delimiter != 0 {
+		reader.Comma = o.Delimiter
+	}
+	reader.Comment = o.Comment
+	reader.LazyQuotes = o.LazyQuotes
+	reader.TrimLeadingSpace = o.TrimLeadingSpace
+	reader.FieldsPerRecord = -1
+	return reader, nil
+}
+
+// open opens the CSV file at filepath for reading with newReader.
+// The caller should close the file.
+func (o CSVOptions) open(filepath string) (*os.File, *csv.Reader) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		panic(err)
+	}
+	reader, err := o.newReader(file)
+	if err != nil {
+		file.Close()
+		panic(err)
+	}
+	return file, reader
+}
+
+// isMissing returns true if entry marks a missing value
+func (o CSVOptions) isMissing(entry string) bool {
+	if IsMissingString(entry) {
+		return true
+	}
+	entry = strings.TrimSpace(entry)
+	for _, m := range o.MissingStrings {
+		if entry == m {
+			return true
+		}
+	}
+	return false
+}
+
+// normalise replaces the entries of record which are missing with
+// MissingString, so that every Attribute recognises them.
+func (o CSVOptions) normalise(record []string) {
+	if len(o.MissingStrings) == 0 {
+		return
+	}
+	for j, entry := range record {
+		if o.isMissing(entry) {
+			record[j] = MissingString
+		}
+	}
+}
+
 // ParseCSVGetRows returns the number of rows in a given file.
 func ParseCSVGetRows(filepath string) int {
 	file, err := os.Open(filepath)
@@ -35,10 +148,29 @@ func ParseCSVGetRows(filepath string) int {
 // ParseCSVGetAttributes returns an ordered slice of appropriate-ly typed
 // and named Attributes.
 func ParseCSVGetAttributes(filepath string, hasHeaders bool) []Attribute {
-	attrs := ParseCSVSniffAttributeTypes(filepath, hasHeaders)
-	names := ParseCSVSniffAttributeNames(filepath, hasHeaders)
-	for i, attr := range attrs {
-		attr.SetName(names[i])
+	return getCSVAttributes(filepath, CSVOptions{HasHeaders: hasHeaders})
+}
+
+// getCSVAttributes implements ParseCSVGetAttributes, applying the
+// Overrides
+func getCSVAttributes(filepath string, options CSVOptions) []Attribute {
+	types := sniffCSVColumnTypes(filepath, options)
+	names := sniffCSVNames(filepath, options)
+	if len(names) != len(types) {
+		panic(fmt.Errorf("base: %d headers for %d columns", len(names), len(types)))
+	}
+	attrs := make([]Attribute, len(types))
+	for i, t := range types {
+		if override, ok := options.Overrides[names[i]]; ok {
+			attrs[i] = override
+		} else if t == HashedColumn && i == len(types)-1 {
+			attrs[i] = CategoricalColumn.NewAttribute()
+		} else if t == HashedColumn {
+			attrs[i] = NewHashedAttribute(options.hashBuckets())
+		} else {
+			attrs[i] = t.NewAttribute()
+		}
+		attrs[i].SetName(names[i])
 	}
 	return attrs
 }
@@ -46,23 +178,20 @@ func ParseCSVGetAttributes(filepath string, hasHeaders bool) []Attribute {
 // ParseCsvSniffAttributeNames returns a slice containing the top row
 // of a given CSV file, or placeholders if hasHeaders is false.
 func ParseCSVSniffAttributeNames(filepath string, hasHeaders bool) []string {
-	file, err := os.Open(filepath)
-	if err != nil {
-		panic(err)
-	}
-	defer file.Close()
+	return sniffCSVNames(filepath, CSVOptions{HasHeaders: hasHeaders})
+}
 
-	reader := csv.NewReader(file)
+// sniffCSVNames implements ParseCSVSniffAttributeNames
+func sniffCSVNames(filepath string, options CSVOptions) []string {
+	file, reader := options.open(filepath)
+	defer file.Close()
 	headers, err := reader.Read()
 	if err != nil {
 		panic(err)
 	}
 
-	if hasHeaders {
-		for i, h := range headers {
-			headers[i] = strings.TrimSpace(h)
-		}
-		return headers
+	if options.HasHeaders {
+		return uniqueNames(headers)
 	}
 
 	for i := range headers {
@@ -72,47 +201,223 @@ func ParseCSVSniffAttributeNames(filepath string, hasHeaders bool) []string {
 
 }
 
-// ParseCSVSniffAttributeTypes returns a slice of appropriately-typed Attributes.
-//
-// The type of a given attribute is determined by looking at the first data row
-// of the CSV.
-func ParseCSVSniffAttributeTypes(filepath string, hasHeaders bool) []Attribute {
-	file, err := os.Open(filepath)
-	if err != nil {
-		panic(err)
+// uniqueNames trims the given headers, names the empty ones after
+// their index and adds a suffix to repeated ones, so that each is
+// different.
+func uniqueNames(headers []string) []string {
+	used := make(map[string]bool)
+	for i, h := range headers {
+		h = strings.TrimSpace(h)
+		if h == "" {
+			h = fmt.Sprintf("%d", i)
+		}
+		name := h
+		for k := 1; used[name]; k++ {
+			name = fmt.Sprintf("%s.%d", h, k)
+		}
+		used[name] = true
+		headers[i] = name
+	}
+	return headers
+}
+
+// CSVColumnType is the type of the values of a CSV column, as
+// inferred by ParseCSVSniffColumnTypes.
+type CSVColumnType int
+
+const (
+	// CategoricalColumn is for anything which isn't one of the
+	// other types, including columns with a mixture of them, and
+	// columns whose values are all missing.
+	CategoricalColumn CSVColumnType = iota
+	// IntegerColumn is for whole numbers
+	IntegerColumn
+	// FloatColumn is for other numbers
+	FloatColumn
+	// TimeColumn is for timestamps in any of the TimeLayouts
+	TimeColumn
+	// HashedColumn is for categorical columns with more distinct
+	// values than CSVOptions.MaxLevels
+	HashedColumn
+)
+
+// String returns the name of the CSVColumnType
+func (t CSVColumnType) String() string {
+	switch t {
+	case IntegerColumn:
+		return "integer"
+	case FloatColumn:
+		return "float"
+	case TimeColumn:
+		return "time"
+	case HashedColumn:
+		return "hashed"
 	}
+	return "categorical"
+}
+
+// NewAttribute returns a new, unnamed Attribute for a column of the
+// type: a FloatAttribute for numbers (including integers), a
+// TimeAttribute for timestamps, a HashedAttribute with
+// DefaultHashBuckets buckets for hashed columns and a
+// CategoricalAttribute otherwise.
+func (t CSVColumnType) NewAttribute() Attribute {
+	switch t {
+	case IntegerColumn, FloatColumn:
+		return NewFloatAttribute()
+	case TimeColumn:
+		return NewTimeAttribute("")
+	case HashedColumn:
+		return NewHashedAttribute(DefaultHashBuckets)
+	}
+	return new(CategoricalAttribute)
+}
+
+// ParseCSVSniffColumnTypes infers the type of each column of a CSV
+// file from every one of its values which isn't missing, so that a
+// column isn't taken to be numeric just because it starts with
+// numbers.
+func ParseCSVSniffColumnTypes(filepath string, hasHeaders bool) []CSVColumnType {
+	return sniffCSVColumnTypes(filepath, CSVOptions{HasHeaders: hasHeaders})
+}
+
+// sniffCSVColumnTypes implements ParseCSVSniffColumnTypes
+func sniffCSVColumnTypes(filepath string, options CSVOptions) []CSVColumnType {
+	file, reader := options.open(filepath)
 	defer file.Close()
-	reader := csv.NewReader(file)
-	attrs := make([]Attribute, 0)
-	if hasHeaders {
+	if options.HasHeaders {
 		_, err := reader.Read()
 		if err != nil {
 			panic(err)
 		}
 	}
-	columns, err := reader.Read()
-	if err != nil {
-		panic(err)
-	}
 
-	for _, entry := range columns {
-		matched, err := regexp.MatchString("^[-+]?[0-9]*\\.?[0-9]+([eE][-+]?[0-9]+)?$", entry)
-		if err != nil {
+	var columns []*columnTypes
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
 			panic(err)
 		}
-		if matched {
-			attrs = append(attrs, NewFloatAttribute())
-		} else {
-			attrs = append(attrs, new(CategoricalAttribute))
+		if columns == nil {
+			columns = make([]*columnTypes, len(row))
+			for j := range columns {
+				columns[j] = &columnTypes{isInt: true, isFloat: true, isTime: true, maxLevels: options.MaxLevels}
+			}
 		}
+		for j, entry := range row {
+			if j < len(columns) && !options.isMissing(entry) {
+				columns[j].add(entry)
+			}
+		}
+	}
+	if columns == nil {
+		panic(io.EOF)
+	}
+
+	ret := make([]CSVColumnType, len(columns))
+	for j, c := range columns {
+		ret[j] = c.columnType()
+	}
+	return ret
+}
+
+var (
+	csvIntPattern   = regexp.MustCompile("^[-+]?[0-9]+$")
+	csvFloatPattern = regexp.MustCompile("^[-+]?[0-9]*\\.?[0-9]+([eE][-+]?[0-9]+)?$")
+)
+
+// columnTypes keeps track of which types the values of a CSV column
+// could be
+type columnTypes struct {
+	seen    bool
+	isInt   bool
+	isFloat bool
+	isTime  bool
+	// levels holds the distinct values, up to one more than
+	// maxLevels, if that isn't zero
+	maxLevels int
+	levels    map[string]bool
+}
+
+// add rules out the types which entry, which isn't missing, isn't a
+// value of
+func (c *columnTypes) add(entry string) {
+	c.seen = true
+	c.isInt = c.isInt && csvIntPattern.MatchString(entry)
+	c.isFloat = c.isFloat && csvFloatPattern.MatchString(entry)
+	if c.isTime && !c.isFloat {
+		_, err := parseTime("", entry)
+		c.isTime = err == nil
+	}
+	if c.maxLevels > 0 && len(c.levels) <= c.maxLevels {
+		if c.levels == nil {
+			c.levels = make(map[string]bool)
+		}
+		c.levels[entry] = true
+	}
+}
+
+// columnType returns the most specific type the column could be
+func (c *columnTypes) columnType() CSVColumnType {
+	switch {
+	case !c.seen:
+		return CategoricalColumn
+	case c.isInt:
+		return IntegerColumn
+	case c.isFloat:
+		return FloatColumn
+	case c.isTime:
+		return TimeColumn
+	case c.maxLevels > 0 && len(c.levels) > c.maxLevels:
+		return HashedColumn
 	}
+	return CategoricalColumn
+}
 
+// ParseCSVSniffAttributeTypes returns a slice of appropriately-typed
+// Attributes, one for each column (see ParseCSVSniffColumnTypes and
+// CSVColumnType.NewAttribute).
+func ParseCSVSniffAttributeTypes(filepath string, hasHeaders bool) []Attribute {
+	types := ParseCSVSniffColumnTypes(filepath, hasHeaders)
+	attrs := make([]Attribute, len(types))
+	for j, t := range types {
+		attrs[j] = t.NewAttribute()
+	}
 	return attrs
 }
 
 // ParseCSVToInstances reads the CSV file given by filepath and returns
 // the read Instances.
 func ParseCSVToInstances(filepath string, hasHeaders bool) (instances *Instances, err error) {
+	return ParseCSVToInstancesWithOverrides(filepath, hasHeaders, nil)
+}
+
+// ParseCSVToInstancesWithOverrides is like ParseCSVToInstances, but
+// overrides gives the Attribute to use for some of the columns, by
+// name, instead of inferring one, e.g.
+//
+//	overrides := map[string]base.Attribute{
+//		"zip":  new(base.CategoricalAttribute),
+//		"date": base.NewTimeAttribute("02/01/2006"),
+//	}
+//
+// The overriding Attributes are named after their columns.
+func ParseCSVToInstancesWithOverrides(filepath string, hasHeaders bool, overrides map[string]Attribute) (instances *Instances, err error) {
+	return ParseCSVToInstancesWithOptions(filepath, CSVOptions{HasHeaders: hasHeaders, Overrides: overrides})
+}
+
+// ParseCSVToInstancesWithOptions reads the CSV file given by filepath
+// as described by options, e.g. a tab-separated file with headers and
+// "NA" for missing values:
+//
+//	options := base.CSVOptions{
+//		Delimiter:      '\t',
+//		HasHeaders:     true,
+//		MissingStrings: []string{"NA"},
+//	}
+func ParseCSVToInstancesWithOptions(filepath string, options CSVOptions) (instances *Instances, err error) {
 
 	defer func() {
 		if r := recover(); r != nil {
@@ -124,25 +429,22 @@ func ParseCSVToInstances(filepath string, hasHeaders bool) (instances *Instances
 	}()
 
 	// Read the number of rows in the file
-	rowCount := ParseCSVGetRows(filepath)
-	if hasHeaders {
+	rowCount := countCSVRows(filepath, options)
+	if options.HasHeaders {
 		rowCount--
 	}
 
 	// Read the row headers
-	attrs := ParseCSVGetAttributes(filepath, hasHeaders)
+	attrs := getCSVAttributes(filepath, options)
 
 	// Allocate the Instances to return
 	instances = NewInstances(attrs, rowCount)
 
 	// Read the input
-	file, err := os.Open(filepath)
-	if err != nil {
-		panic(err)
-	}
+	file, reader := options.open(filepath)
 	defer file.Close()
-	reader := csv.NewReader(file)
 
+	hasHeaders := options.HasHeaders
 	rowCounter := 0
 	for {
 		record, err := reader.Read()
@@ -157,6 +459,10 @@ func ParseCSVToInstances(filepath string, hasHeaders bool) (instances *Instances
 				continue
 			}
 		}
+		if len(record) != len(attrs) {
+			panic(fmt.Errorf("base: row %d has %d fields, expected %d", rowCounter, len(record), len(attrs)))
+		}
+		options.normalise(record)
 		for i := range attrs {
 			instances.SetAttrStr(rowCounter, i, record[i])
 		}
@@ -166,6 +472,24 @@ func ParseCSVToInstances(filepath string, hasHeaders bool) (instances *Instances
 	return
 }
 
+// countCSVRows returns the number of rows of the CSV file at
+// filepath, including the headers
+func countCSVRows(filepath string, options CSVOptions) int {
+	file, reader := options.open(filepath)
+	defer file.Close()
+	counter := 0
+	for {
+		_, err := reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			panic(err)
+		}
+		counter++
+	}
+	return counter
+}
+
 //ParseCSV parses a CSV file and returns the number of columns and rows, the headers, the labels associated with
 //classification, and the data that will be used for training.
 func ParseCSV(filepath string, label int, columns []int) (int, int, []string, []string, []float64) {
@@ -211,3 +535,108 @@ func ParseCSV(filepath string, label int, columns []int) (int, int, []string, []
 	cols := len(columns)
 	return cols, rows, headers, labels, data
 }
+
+// csvValue returns the value at the given row and column of inst as
+// it's written to a CSV file: numbers in full precision, timestamps
+// in their layout and anything else as its string representation.
+func csvValue(inst *Instances, row, col int) string {
+	val := inst.Get(row, col)
+	a := inst.GetAttr(col)
+	if IsMissingValue(val) || a.GetType() != Float64Type || hasNamedValues(a) {
+		return inst.GetAttrStr(row, col)
+	}
+	return strconv.FormatFloat(val, 'g', -1, 64)
+}
+
+// SerializeInstancesToCSV writes inst to the file at filepath in CSV
+// format (see WriteCSV).
+func SerializeInstancesToCSV(inst *Instances, filepath string) error {
+	file, err := os.Create(filepath)
+	if err != nil {
+		return err
+	}
+	if err := WriteCSV(file, inst); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// WriteCSV writes inst to w as CSV, with a header row of the names of
+// the Attributes, in the order they're in (so if the class Attribute
+// is the last one, ParseCSVToInstances reads back the same values).
+// Numbers are written in full, and missing values as MissingString.
+func WriteCSV(w io.Writer, inst *Instances) error {
+	writer := csv.NewWriter(w)
+	record := make([]string, inst.Cols)
+	for j := range record {
+		record[j] = inst.GetAttr(j).GetName()
+	}
+	if err := writer.Write(record); err != nil {
+		return err
+	}
+	for i := 0; i < inst.Rows; i++ {
+		for j := range record {
+			record[j] = csvValue(inst, i, j)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// SerializePredictionsToCSV writes predictions to the file at filepath
+// alongside some of the Attributes of the Instances they were made
+// for (see WritePredictionsCSV).
+func SerializePredictionsToCSV(original, predictions *Instances, filepath string, keep ...Attribute) error {
+	file, err := os.Create(filepath)
+	if err != nil {
+		return err
+	}
+	if err := WritePredictionsCSV(file, original, predictions, keep...); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// WritePredictionsCSV writes predictions (as returned by a
+// Classifier's Predict) to w as CSV, each row preceded by the values
+// of the Attributes keep of the corresponding row of original, e.g.
+// an ID column, so that scoring jobs can write their output or
+// submission files directly. The header row names the kept
+// Attributes and the predicted class Attribute.
+func WritePredictionsCSV(w io.Writer, original, predictions *Instances, keep ...Attribute) error {
+	if original.Rows != predictions.Rows {
+		return fmt.Errorf("base: %d predictions for %d rows", predictions.Rows, original.Rows)
+	}
+	cols := make([]int, len(keep))
+	for k, a := range keep {
+		if cols[k] = original.GetAttrIndex(a); cols[k] == -1 {
+			return fmt.Errorf("base: the Instances have no Attribute %s", a.GetName())
+		}
+	}
+
+	writer := csv.NewWriter(w)
+	record := make([]string, len(keep)+1)
+	for k, a := range keep {
+		record[k] = a.GetName()
+	}
+	record[len(keep)] = predictions.GetClassAttr().GetName()
+	if err := writer.Write(record); err != nil {
+		return err
+	}
+	for i := 0; i < original.Rows; i++ {
+		for k, j := range cols {
+			record[k] = csvValue(original, i, j)
+		}
+		record[len(keep)] = csvValue(predictions, i, predictions.ClassIndex)
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -1,6 +1,12 @@
 package base
 
-import "testing"
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
 
 func TestParseCSVGetRows(testEnv *testing.T) {
 	lineCount := ParseCSVGetRows("../examples/datasets/iris.csv")
@@ -104,3 +110,143 @@ func TestReadAwkwardInsatnces(testEnv *testing.T) {
 		testEnv.Error("Should be discrete!")
 	}
 }
+
+func TestParseCSVSniffColumnTypes(testEnv *testing.T) {
+	file, err := ioutil.TempFile("", "types")
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString("count,amount,zip,when,class\n1,1.5,12345,2015-03-01,a\n2,2,?,2015-03-02,b\n?,3,AB1 2CD,?,a\n")
+	file.Close()
+
+	types := ParseCSVSniffColumnTypes(file.Name(), true)
+	expected := []CSVColumnType{IntegerColumn, FloatColumn, CategoricalColumn, TimeColumn, CategoricalColumn}
+	for j, t := range expected {
+		if types[j] != t {
+			testEnv.Errorf("Column %d should be %s, is %s", j, t, types[j])
+		}
+	}
+
+	overrides := map[string]Attribute{"count": new(CategoricalAttribute)}
+	inst, err := ParseCSVToInstancesWithOverrides(file.Name(), true, overrides)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if inst.GetAttr(0).GetType() != CategoricalType || inst.GetAttr(0).GetName() != "count" {
+		testEnv.Error(inst.GetAttr(0))
+	}
+	if inst.GetAttrStr(1, 0) != "2" || inst.GetAttrStr(2, 2) != "AB1 2CD" {
+		testEnv.Error(inst.RowStr(1), inst.RowStr(2))
+	}
+	if _, ok := inst.GetAttr(3).(*TimeAttribute); !ok {
+		testEnv.Error(inst.GetAttr(3))
+	}
+}
+
+func TestParseCSVToInstancesWithOptions(testEnv *testing.T) {
+	file, err := ioutil.TempFile("", "options")
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString("Exported from somewhere\n\"with\" a preamble\nx;x;;name;class\n1,5;2;NA;\"Smith; J\";a\n2;NA;3;\"said \"\"hi\"\"\";b\n")
+	file.Close()
+
+	options := CSVOptions{
+		Delimiter:      ';',
+		HasHeaders:     true,
+		SkipRows:       2,
+		MissingStrings: []string{"NA"},
+	}
+	inst, err := ParseCSVToInstancesWithOptions(file.Name(), options)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if inst.Rows != 2 || inst.Cols != 5 {
+		testEnv.Fatal(inst)
+	}
+	names := []string{"x", "x.1", "2", "name", "class"}
+	for j, name := range names {
+		if inst.GetAttr(j).GetName() != name {
+			testEnv.Errorf("Column %d should be called %s, not %s", j, name, inst.GetAttr(j).GetName())
+		}
+	}
+	// "1,5" isn't a number, so x is categorical
+	if inst.GetAttr(0).GetType() != CategoricalType || inst.GetAttrStr(0, 0) != "1,5" {
+		testEnv.Error(inst.GetAttr(0), inst.RowStr(0))
+	}
+	if inst.GetAttr(1).GetType() != Float64Type || !inst.IsMissing(1, 1) || !inst.IsMissing(0, 2) {
+		testEnv.Error(inst.RowStr(0), inst.RowStr(1))
+	}
+	if inst.GetAttrStr(0, 3) != "Smith; J" || inst.GetAttrStr(1, 3) != "said \"hi\"" {
+		testEnv.Error(inst.RowStr(0), inst.RowStr(1))
+	}
+
+	stream, err := StreamCSVWithOptions(file.Name(), options, 1)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	defer stream.Close()
+	streamed, err := stream.ReadAll()
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if streamed.Rows != 2 || !streamed.IsMissing(1, 1) || streamed.GetAttrStr(1, 3) != "said \"hi\"" {
+		testEnv.Error(streamed)
+	}
+}
+
+func TestWriteCSV(testEnv *testing.T) {
+	inst, err := ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	inst.Set(0, 0, 5.125)
+	inst.SetMissing(1, 1)
+	file, err := ioutil.TempFile("", "written")
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	file.Close()
+	defer os.Remove(file.Name())
+	if err := SerializeInstancesToCSV(inst, file.Name()); err != nil {
+		testEnv.Fatal(err)
+	}
+	read, err := ParseCSVToInstances(file.Name(), true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if read.Rows != inst.Rows || read.GetAttr(0).GetName() != "Sepal length" {
+		testEnv.Fatal(read)
+	}
+	if read.Get(0, 0) != 5.125 || !read.IsMissing(1, 1) || read.GetClass(149) != inst.GetClass(149) {
+		testEnv.Error(read.RowStr(0), read.RowStr(1))
+	}
+}
+
+func TestWritePredictionsCSV(testEnv *testing.T) {
+	inst, err := ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	predictions := inst.GeneratePredictionVector()
+	for i := 0; i < inst.Rows; i++ {
+		predictions.SetAttrStr(i, 0, "Iris-setosa")
+	}
+	var buf bytes.Buffer
+	if err := WritePredictionsCSV(&buf, inst, predictions, inst.GetAttr(1)); err != nil {
+		testEnv.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 151 || lines[0] != "Sepal width,Species" || lines[1] != "3.5,Iris-setosa" {
+		testEnv.Error(lines[:2])
+	}
+
+	if err := WritePredictionsCSV(&buf, inst, predictions.SelectRows([]int{0}), inst.GetAttr(1)); err == nil {
+		testEnv.Error("Expected an error for the wrong number of predictions")
+	}
+	if err := WritePredictionsCSV(&buf, inst, predictions, NewFloatAttribute()); err == nil {
+		testEnv.Error("Expected an error for a missing Attribute")
+	}
+}
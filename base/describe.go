@@ -0,0 +1,201 @@
+package base
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// DescribeQuantiles are the quantiles Describe computes for numeric
+// Attributes.
+var DescribeQuantiles = []float64{0.25, 0.5, 0.75}
+
+// AttributeDescription holds the summary statistics of one Attribute,
+// as computed by Describe.
+type AttributeDescription struct {
+	Index     int
+	Attribute Attribute
+	// Count is the number of values which aren't missing, and
+	// Missing the number which are
+	Count   int
+	Missing int
+	// Numeric is true if the Attribute is a FloatAttribute (or a
+	// TimeAttribute), in which case Mean, Std (the sample standard
+	// deviation), Min, Max and Quantiles (one for each of
+	// DescribeQuantiles) are set, or NaN if every value is missing
+	Numeric   bool
+	Mean      float64
+	Std       float64
+	Min       float64
+	Max       float64
+	Quantiles []float64
+	// Frequencies counts each value of Attributes which aren't
+	// numeric
+	Frequencies map[string]int
+}
+
+// MissingRate returns the proportion of values which are missing
+func (d AttributeDescription) MissingRate() float64 {
+	if d.Count+d.Missing == 0 {
+		return 0
+	}
+	return float64(d.Missing) / float64(d.Count+d.Missing)
+}
+
+// TopValues returns the n most frequent values of a non-numeric
+// Attribute, from the most to the least common, or all of them if n
+// is negative.
+func (d AttributeDescription) TopValues(n int) []string {
+	values := make([]string, 0, len(d.Frequencies))
+	for v := range d.Frequencies {
+		values = append(values, v)
+	}
+	sort.Sort(&byCount{values, d.Frequencies})
+	if n >= 0 && n < len(values) {
+		values = values[:n]
+	}
+	return values
+}
+
+// Description holds an AttributeDescription for every Attribute of a
+// set of Instances.
+type Description []AttributeDescription
+
+// Describe returns summary statistics of each of the Attributes:
+// how many values are present and missing and, for numeric
+// Attributes, their mean, standard deviation, range and quantiles
+// or, for the others, how often each value occurs. Weights are
+// ignored.
+func (inst *Instances) Describe() Description {
+	ret := make(Description, inst.Cols)
+	for j, a := range inst.attributes {
+		d := AttributeDescription{Index: j, Attribute: a}
+		d.Missing = inst.CountMissing(j)
+		d.Count = inst.Rows - d.Missing
+		if a.GetType() == Float64Type {
+			d.Numeric = true
+			inst.describeNumeric(j, &d)
+		} else {
+			d.Frequencies = inst.CountAttrValues(a)
+		}
+		ret[j] = d
+	}
+	return ret
+}
+
+// describeNumeric fills in the statistics of the numeric Attribute at
+// index col
+func (inst *Instances) describeNumeric(col int, d *AttributeDescription) {
+	vals := make([]float64, 0, d.Count)
+	for i := 0; i < inst.Rows; i++ {
+		if val := inst.Get(i, col); !IsMissingValue(val) {
+			vals = append(vals, val)
+		}
+	}
+	d.Quantiles = make([]float64, len(DescribeQuantiles))
+	if len(vals) == 0 {
+		d.Mean, d.Std, d.Min, d.Max = math.NaN(), math.NaN(), math.NaN(), math.NaN()
+		for k := range d.Quantiles {
+			d.Quantiles[k] = math.NaN()
+		}
+		return
+	}
+	sort.Float64s(vals)
+	sum := 0.0
+	for _, v := range vals {
+		sum += v
+	}
+	d.Mean = sum / float64(len(vals))
+	d.Std = math.NaN()
+	if len(vals) > 1 {
+		sumSq := 0.0
+		for _, v := range vals {
+			sumSq += (v - d.Mean) * (v - d.Mean)
+		}
+		d.Std = math.Sqrt(sumSq / float64(len(vals)-1))
+	}
+	d.Min, d.Max = vals[0], vals[len(vals)-1]
+	for k, q := range DescribeQuantiles {
+		d.Quantiles[k] = quantile(vals, q)
+	}
+}
+
+// quantile returns the q-th quantile of sorted, interpolating
+// linearly between the closest values
+func quantile(sorted []float64, q float64) float64 {
+	pos := q * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	if lower >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	frac := pos - float64(lower)
+	return sorted[lower] + frac*(sorted[lower+1]-sorted[lower])
+}
+
+// String returns a table of the statistics, one row per Attribute.
+// Values of TimeAttributes are shown as times, and the three most
+// common values of other Attributes with their counts.
+func (d Description) String() string {
+	var buffer bytes.Buffer
+	w := tabwriter.NewWriter(&buffer, 0, 8, 2, ' ', 0)
+	header := []string{"#", "Name", "Type", "Count", "Missing", "Mean", "Std", "Min"}
+	for _, q := range DescribeQuantiles {
+		header = append(header, fmt.Sprintf("%g%%", q*100))
+	}
+	header = append(header, "Max", "Top values")
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, a := range d {
+		row := []string{
+			fmt.Sprintf("%d", a.Index),
+			a.Attribute.GetName(),
+			attributeTypeName(a.Attribute),
+			fmt.Sprintf("%d", a.Count),
+			fmt.Sprintf("%.1f%%", 100*a.MissingRate()),
+		}
+		if a.Numeric {
+			row = append(row, a.formatValue(a.Mean), a.formatStd(), a.formatValue(a.Min))
+			for _, q := range a.Quantiles {
+				row = append(row, a.formatValue(q))
+			}
+			row = append(row, a.formatValue(a.Max), "")
+		} else {
+			for k := 0; k < len(DescribeQuantiles)+4; k++ {
+				row = append(row, "")
+			}
+			top := a.TopValues(3)
+			for k, v := range top {
+				top[k] = fmt.Sprintf("%s (%d)", v, a.Frequencies[v])
+			}
+			if len(a.Frequencies) > 3 {
+				top = append(top, "...")
+			}
+			row = append(row, strings.Join(top, ", "))
+		}
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	w.Flush()
+	return buffer.String()
+}
+
+// formatValue formats a statistic of a numeric Attribute
+func (d AttributeDescription) formatValue(val float64) string {
+	if math.IsNaN(val) {
+		return "-"
+	}
+	if hasNamedValues(d.Attribute) {
+		return d.Attribute.GetStringFromSysVal(val)
+	}
+	return fmt.Sprintf("%.4g", val)
+}
+
+// formatStd formats the standard deviation, which for TimeAttributes
+// is a number of seconds rather than a time
+func (d AttributeDescription) formatStd() string {
+	if math.IsNaN(d.Std) {
+		return "-"
+	}
+	return fmt.Sprintf("%.4g", d.Std)
+}
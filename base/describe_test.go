@@ -0,0 +1,61 @@
+package base
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestDescribe(testEnv *testing.T) {
+	inst, err := ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	inst.SetMissing(0, 0)
+	d := inst.Describe()
+	if len(d) != 5 {
+		testEnv.Fatal(d)
+	}
+	sepal := d[0]
+	if !sepal.Numeric || sepal.Count != 149 || sepal.Missing != 1 {
+		testEnv.Error(sepal)
+	}
+	if math.Abs(sepal.MissingRate()-1.0/150) > 1e-9 || sepal.Min != 4.3 || sepal.Max != 7.9 {
+		testEnv.Error(sepal.MissingRate(), sepal.Min, sepal.Max)
+	}
+	if sepal.Quantiles[1] != 5.8 || sepal.Std <= 0 {
+		testEnv.Error(sepal.Quantiles, sepal.Std)
+	}
+	class := d[4]
+	if class.Numeric || class.Frequencies["Iris-setosa"] != 50 || len(class.Frequencies) != 3 {
+		testEnv.Error(class)
+	}
+	if top := class.TopValues(1); len(top) != 1 || top[0] != "Iris-setosa" {
+		testEnv.Error(top)
+	}
+
+	table := d.String()
+	lines := strings.Split(strings.TrimSpace(table), "\n")
+	if len(lines) != 6 || !strings.Contains(lines[0], "50%") {
+		testEnv.Fatal(table)
+	}
+	if !strings.Contains(lines[1], "Sepal length") || !strings.Contains(lines[1], "0.7%") {
+		testEnv.Error(lines[1])
+	}
+	if !strings.Contains(lines[5], "Iris-setosa (50)") {
+		testEnv.Error(lines[5])
+	}
+}
+
+func TestQuantile(testEnv *testing.T) {
+	vals := []float64{1, 2, 3, 4}
+	if q := quantile(vals, 0.5); q != 2.5 {
+		testEnv.Error(q)
+	}
+	if q := quantile(vals, 0); q != 1 {
+		testEnv.Error(q)
+	}
+	if q := quantile(vals, 1); q != 4 {
+		testEnv.Error(q)
+	}
+}
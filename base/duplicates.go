@@ -0,0 +1,167 @@
+package base
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DuplicateReport lists the rows of a set of Instances which are
+// exact duplicates of others, as found by Instances.DuplicateReport.
+type DuplicateReport struct {
+	// Attributes are the names of the Attributes compared
+	Attributes []string
+	// Groups holds the rows of each set of duplicates, in order;
+	// rows without duplicates aren't in any group
+	Groups [][]int
+	// Duplicates is the number of rows which repeat an earlier one
+	Duplicates int
+	// Rows is the number of rows there are in all
+	Rows int
+}
+
+// String describes how many rows are duplicated, and the first few
+// groups of duplicates.
+func (r *DuplicateReport) String() string {
+	var buffer bytes.Buffer
+	buffer.WriteString(fmt.Sprintf("%d of %d row(s) duplicate earlier ones, in %d group(s), comparing %s\n",
+		r.Duplicates, r.Rows, len(r.Groups), strings.Join(r.Attributes, ", ")))
+	for k, group := range r.Groups {
+		if k == 10 {
+			buffer.WriteString(fmt.Sprintf("... and %d more group(s)\n", len(r.Groups)-k))
+			break
+		}
+		rows := make([]string, len(group))
+		for i, row := range group {
+			rows[i] = fmt.Sprintf("%d", row)
+		}
+		buffer.WriteString(fmt.Sprintf("rows %s\n", strings.Join(rows, ", ")))
+	}
+	return buffer.String()
+}
+
+// duplicateColumns returns the columns of inst with the same names as
+// attrs, or every column if there are none.
+//
+// IMPORTANT: panic()s if inst has no Attribute with one of the names.
+func (inst *Instances) duplicateColumns(attrs []Attribute) []int {
+	if len(attrs) == 0 {
+		ret := make([]int, inst.Cols)
+		for j := range ret {
+			ret[j] = j
+		}
+		return ret
+	}
+	byName := make(map[string]int)
+	for j, a := range inst.attributes {
+		byName[a.GetName()] = j
+	}
+	ret := make([]int, len(attrs))
+	for k, a := range attrs {
+		j, ok := byName[a.GetName()]
+		if !ok {
+			panic(fmt.Sprintf("base: no Attribute %s", a.GetName()))
+		}
+		ret[k] = j
+	}
+	return ret
+}
+
+// rowKey returns a string which is the same for rows with the same
+// values of cols: numbers in full precision, and categorical values
+// by name, so that Instances with different Attributes can be
+// compared.
+func (inst *Instances) rowKey(row int, cols []int) string {
+	var buffer bytes.Buffer
+	for _, j := range cols {
+		val := inst.Get(row, j)
+		switch {
+		case IsMissingValue(val):
+			buffer.WriteString(MissingString)
+		case inst.attributes[j].GetType() == Float64Type:
+			buffer.WriteString(strconv.FormatFloat(val, 'g', -1, 64))
+		default:
+			buffer.WriteString(inst.GetAttrStr(row, j))
+		}
+		buffer.WriteByte(0)
+	}
+	return buffer.String()
+}
+
+// DuplicateReport finds the rows which have exactly the same values of
+// attrs (matched by name), or of every Attribute if there are none.
+// Weights are ignored.
+//
+// IMPORTANT: panic()s if one of attrs isn't one of the Attributes.
+func (inst *Instances) DuplicateReport(attrs ...Attribute) *DuplicateReport {
+	cols := inst.duplicateColumns(attrs)
+	ret := &DuplicateReport{Attributes: make([]string, len(cols)), Groups: make([][]int, 0), Rows: inst.Rows}
+	for k, j := range cols {
+		ret.Attributes[k] = inst.attributes[j].GetName()
+	}
+	first := make(map[string]int)
+	groups := make(map[string]int)
+	for i := 0; i < inst.Rows; i++ {
+		key := inst.rowKey(i, cols)
+		f, ok := first[key]
+		if !ok {
+			first[key] = i
+			continue
+		}
+		ret.Duplicates++
+		g, ok := groups[key]
+		if !ok {
+			g = len(ret.Groups)
+			groups[key] = g
+			ret.Groups = append(ret.Groups, []int{f})
+		}
+		ret.Groups[g] = append(ret.Groups[g], i)
+	}
+	return ret
+}
+
+// Deduplicate returns a copy of the Instances (see SelectRows) with
+// only the first of each group of rows which have the same values of
+// attrs (matched by name), or of every Attribute if there are none.
+//
+// IMPORTANT: panic()s if one of attrs isn't one of the Attributes.
+func (inst *Instances) Deduplicate(attrs ...Attribute) *Instances {
+	cols := inst.duplicateColumns(attrs)
+	seen := make(map[string]bool)
+	rows := make([]int, 0, inst.Rows)
+	for i := 0; i < inst.Rows; i++ {
+		key := inst.rowKey(i, cols)
+		if !seen[key] {
+			seen[key] = true
+			rows = append(rows, i)
+		}
+	}
+	return inst.SelectRows(rows)
+}
+
+// OverlappingRows returns the rows of test which have the same values
+// of attrs (matched by name), or of all of train's Attributes if
+// there are none, as a row of train. Such rows inflate the scores of
+// a Classifier evaluated on test, since it's seen them.
+//
+// IMPORTANT: panic()s if either set of Instances doesn't have one of
+// the Attributes.
+func OverlappingRows(train, test *Instances, attrs ...Attribute) []int {
+	if len(attrs) == 0 {
+		attrs = train.attributes
+	}
+	trainCols := train.duplicateColumns(attrs)
+	testCols := test.duplicateColumns(attrs)
+	seen := make(map[string]bool)
+	for i := 0; i < train.Rows; i++ {
+		seen[train.rowKey(i, trainCols)] = true
+	}
+	ret := make([]int, 0)
+	for i := 0; i < test.Rows; i++ {
+		if seen[test.rowKey(i, testCols)] {
+			ret = append(ret, i)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,56 @@
+package base
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDuplicates(testEnv *testing.T) {
+	inst, err := ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	// Iris has a few duplicated rows already
+	report := inst.DuplicateReport()
+	if report.Rows != 150 || report.Duplicates == 0 {
+		testEnv.Fatal(report)
+	}
+	deduped := inst.Deduplicate()
+	if deduped.Rows != inst.Rows-report.Duplicates {
+		testEnv.Error(deduped.Rows, report.Duplicates)
+	}
+	if again := deduped.DuplicateReport(); again.Duplicates != 0 || len(again.Groups) != 0 {
+		testEnv.Error(again)
+	}
+	for _, group := range report.Groups {
+		if len(group) < 2 || inst.RowStr(group[0]) != inst.RowStr(group[1]) {
+			testEnv.Error(group)
+		}
+	}
+	if !strings.HasPrefix(report.String(), fmt.Sprintf("%d of 150 row(s)", report.Duplicates)) || !strings.Contains(report.String(), "comparing Sepal length") {
+		testEnv.Error(report)
+	}
+
+	// Only the class: one row per class is left
+	byClass := inst.DuplicateReport(inst.GetClassAttr())
+	if byClass.Duplicates != 147 || len(byClass.Groups) != 3 || len(byClass.Groups[0]) != 50 {
+		testEnv.Error(byClass.Duplicates, len(byClass.Groups))
+	}
+	if rows := inst.Deduplicate(inst.GetClassAttr()).Rows; rows != 3 {
+		testEnv.Error(rows)
+	}
+}
+
+func TestOverlappingRows(testEnv *testing.T) {
+	inst, err := ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	inst = inst.Deduplicate()
+	train := inst.SelectRows([]int{0, 1, 2, 3})
+	test := inst.SelectRows([]int{3, 4, 5, 0})
+	if rows := OverlappingRows(train, test); len(rows) != 2 || rows[0] != 0 || rows[1] != 3 {
+		testEnv.Error(rows)
+	}
+}
@@ -0,0 +1,226 @@
+package base
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// The functions in this file generate random Instances with a known
+// structure, for tests, benchmarks and examples which shouldn't
+// depend on data files. Features are FloatAttributes named "x0",
+// "x1", ..., and the class Attribute, which comes last, is called
+// "class" (with values "0", "1", ...) or, for regression, "y". A
+// Seed of zero means a random seed.
+
+// ClassificationOptions describes the Instances MakeClassification
+// generates. Zero fields take the default given.
+type ClassificationOptions struct {
+	// Samples is the number of rows (100)
+	Samples int
+	// Features is the number of features (20), the first
+	// Informative of which (2) determine the class, and the rest
+	// are noise
+	Features    int
+	Informative int
+	// Classes is the number of classes (2)
+	Classes int
+	// ClassSep is how far apart the centres of the classes are,
+	// relative to their spread (1)
+	ClassSep float64
+	// Noise is the proportion of rows whose class is chosen at
+	// random instead
+	Noise float64
+	Seed  int64
+}
+
+// RegressionOptions describes the Instances MakeRegression generates.
+// Zero fields take the default given.
+type RegressionOptions struct {
+	// Samples is the number of rows (100)
+	Samples int
+	// Features is the number of features (20), the first
+	// Informative of which (10) the target depends on
+	Features    int
+	Informative int
+	// Bias is added to the target
+	Bias float64
+	// Noise is the standard deviation of the Gaussian noise added
+	// to the target
+	Noise float64
+	Seed  int64
+}
+
+// newGeneratorRand returns a random number generator with the given
+// seed, or a random one if it's zero
+func newGeneratorRand(seed int64) *rand.Rand {
+	if seed == 0 {
+		seed = rand.Int63()
+	}
+	return rand.New(rand.NewSource(seed))
+}
+
+// newGeneratedInstances returns Instances with the given number of
+// features and the class Attribute class
+func newGeneratedInstances(rows, features int, class Attribute) *Instances {
+	attrs := make([]Attribute, features+1)
+	for j := 0; j < features; j++ {
+		attrs[j] = NewFloatAttribute()
+		attrs[j].SetName(fmt.Sprintf("x%d", j))
+	}
+	attrs[features] = class
+	return NewInstances(attrs, rows)
+}
+
+// newGeneratedClass returns a CategoricalAttribute called "class"
+// with the values "0" to "classes-1"
+func newGeneratedClass(classes int) *CategoricalAttribute {
+	ret := NewCategoricalAttribute()
+	ret.SetName("class")
+	for k := 0; k < classes; k++ {
+		ret.GetSysValFromString(fmt.Sprintf("%d", k))
+	}
+	return ret
+}
+
+// MakeClassification returns Instances for a classification problem:
+// each class is a Gaussian cluster, with a standard deviation of one,
+// around a randomly chosen vertex of a hypercube with sides of
+// 2*ClassSep in the space of the informative features. The rows are
+// divided between the classes as evenly as possible, in random order.
+//
+// IMPORTANT: panic()s if there are more Informative features than
+// Features.
+func MakeClassification(opts ClassificationOptions) *Instances {
+	samples, features, informative, classes, sep := opts.Samples, opts.Features, opts.Informative, opts.Classes, opts.ClassSep
+	if samples == 0 {
+		samples = 100
+	}
+	if features == 0 {
+		features = 20
+	}
+	if informative == 0 {
+		informative = 2
+	}
+	if classes == 0 {
+		classes = 2
+	}
+	if sep == 0 {
+		sep = 1
+	}
+	if informative > features {
+		panic(fmt.Sprintf("base: %d informative features out of %d", informative, features))
+	}
+	rng := newGeneratorRand(opts.Seed)
+
+	// Pick distinct vertices while there are enough of them
+	centres := make([][]float64, classes)
+	space := 1 << uint(minInt(informative, 30))
+	used := make(map[int]bool)
+	for k := range centres {
+		vertex := rng.Intn(space)
+		for len(used) < space && used[vertex] {
+			vertex = rng.Intn(space)
+		}
+		used[vertex] = true
+		centres[k] = make([]float64, informative)
+		for j := range centres[k] {
+			centres[k][j] = -sep
+			if j < 30 && vertex&(1<<uint(j)) != 0 {
+				centres[k][j] = sep
+			}
+		}
+	}
+
+	ret := newGeneratedInstances(samples, features, newGeneratedClass(classes))
+	for n, i := range rng.Perm(samples) {
+		class := n % classes
+		for j := 0; j < features; j++ {
+			val := rng.NormFloat64()
+			if j < informative {
+				val += centres[class][j]
+			}
+			ret.Set(i, j, val)
+		}
+		if rng.Float64() < opts.Noise {
+			class = rng.Intn(classes)
+		}
+		ret.Set(i, features, float64(class))
+	}
+	return ret
+}
+
+// MakeBlobs returns Instances with the given number of rows, divided
+// as evenly as possible between centers isotropic Gaussian clusters
+// (with the given standard deviation) in that many features, whose
+// centres are uniformly distributed in [-10, 10]. The class is the
+// cluster each row belongs to, for testing clustering and simple
+// classifiers.
+func MakeBlobs(samples, centers, features int, std float64, seed int64) *Instances {
+	rng := newGeneratorRand(seed)
+	centres := make([][]float64, centers)
+	for k := range centres {
+		centres[k] = make([]float64, features)
+		for j := range centres[k] {
+			centres[k][j] = rng.Float64()*20 - 10
+		}
+	}
+	ret := newGeneratedInstances(samples, features, newGeneratedClass(centers))
+	for i := 0; i < samples; i++ {
+		class := i % centers
+		for j := 0; j < features; j++ {
+			ret.Set(i, j, centres[class][j]+std*rng.NormFloat64())
+		}
+		ret.Set(i, features, float64(class))
+	}
+	return ret
+}
+
+// MakeRegression returns Instances for a regression problem: the
+// features are standard normal, and the target "y" is a random
+// linear combination of the informative ones, with coefficients
+// between 0 and 100, plus the Bias and Gaussian Noise. The
+// coefficients are returned too, zero for the other features.
+//
+// IMPORTANT: panic()s if there are more Informative features than
+// Features.
+func MakeRegression(opts RegressionOptions) (*Instances, []float64) {
+	samples, features, informative := opts.Samples, opts.Features, opts.Informative
+	if samples == 0 {
+		samples = 100
+	}
+	if features == 0 {
+		features = 20
+	}
+	if informative == 0 {
+		informative = minInt(10, features)
+	}
+	if informative > features {
+		panic(fmt.Sprintf("base: %d informative features out of %d", informative, features))
+	}
+	rng := newGeneratorRand(opts.Seed)
+	coef := make([]float64, features)
+	for j := 0; j < informative; j++ {
+		coef[j] = 100 * rng.Float64()
+	}
+	y := NewFloatAttribute()
+	y.SetName("y")
+	ret := newGeneratedInstances(samples, features, y)
+	for i := 0; i < samples; i++ {
+		target := opts.Bias + opts.Noise*rng.NormFloat64()
+		for j := 0; j < features; j++ {
+			val := rng.NormFloat64()
+			target += coef[j] * val
+			ret.Set(i, j, val)
+		}
+		ret.Set(i, features, target)
+	}
+	return ret, coef
+}
+
+// minInt returns the smaller of a and b
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
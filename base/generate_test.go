@@ -0,0 +1,59 @@
+package base
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMakeClassification(testEnv *testing.T) {
+	inst := MakeClassification(ClassificationOptions{Samples: 300, Features: 5, Classes: 3, ClassSep: 5, Seed: 1})
+	if inst.Rows != 300 || inst.Cols != 6 || inst.GetAttr(0).GetName() != "x0" || inst.GetClassAttr().GetName() != "class" {
+		testEnv.Fatal(inst)
+	}
+	if dist := inst.GetClassDistribution(); dist["0"] != 100 || dist["2"] != 100 {
+		testEnv.Error(dist)
+	}
+	// The classes are well separated in the informative features
+	means := inst.GroupBy(inst.GetClassAttr()).Agg(AggMean, inst.GetAttr(0), inst.GetAttr(1))
+	for k := 0; k < means.Rows; k++ {
+		for j := 1; j < 3; j++ {
+			if v := math.Abs(means.Get(k, j)); v < 4 || v > 6 {
+				testEnv.Errorf("Class %s has mean %f", means.GetClass(k), means.Get(k, j))
+			}
+		}
+	}
+	if !inst.Equal(MakeClassification(ClassificationOptions{Samples: 300, Features: 5, Classes: 3, ClassSep: 5, Seed: 1})) {
+		testEnv.Error("The same seed should give the same Instances")
+	}
+	if inst := MakeClassification(ClassificationOptions{Seed: 2}); inst.Rows != 100 || inst.Cols != 21 {
+		testEnv.Error(inst.Rows, inst.Cols)
+	}
+}
+
+func TestMakeBlobs(testEnv *testing.T) {
+	inst := MakeBlobs(90, 3, 2, 0.01, 1)
+	if inst.Rows != 90 || inst.Cols != 3 || len(inst.GetClassDistribution()) != 3 {
+		testEnv.Fatal(inst)
+	}
+	// Rows of a cluster are close together
+	if d := math.Abs(inst.Get(0, 0) - inst.Get(3, 0)); d > 0.1 {
+		testEnv.Error(d)
+	}
+}
+
+func TestMakeRegression(testEnv *testing.T) {
+	inst, coef := MakeRegression(RegressionOptions{Samples: 50, Features: 4, Informative: 2, Bias: 3, Seed: 1})
+	if inst.Rows != 50 || inst.Cols != 5 || len(coef) != 4 || coef[2] != 0 || coef[0] == 0 {
+		testEnv.Fatal(inst, coef)
+	}
+	// Without noise, the target is exactly linear
+	for i := 0; i < inst.Rows; i++ {
+		y := 3.0
+		for j, c := range coef {
+			y += c * inst.Get(i, j)
+		}
+		if math.Abs(y-inst.Get(i, 4)) > 1e-9 {
+			testEnv.Fatalf("Row %d: expected %f, got %f", i, y, inst.Get(i, 4))
+		}
+	}
+}
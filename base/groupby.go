@@ -0,0 +1,187 @@
+package base
+
+import (
+	"fmt"
+	"math"
+)
+
+// Aggregation is a way of combining the values of an Attribute within
+// a group, for Grouping.Agg.
+type Aggregation int
+
+const (
+	// AggMean is the mean of the values which aren't missing
+	AggMean Aggregation = iota
+	// AggSum is their sum
+	AggSum
+	// AggCount is the number of values which aren't missing
+	AggCount
+	// AggMax is the largest value
+	AggMax
+	// AggMin is the smallest value
+	AggMin
+)
+
+// String returns the name of the Aggregation, e.g. "mean"
+func (a Aggregation) String() string {
+	switch a {
+	case AggMean:
+		return "mean"
+	case AggSum:
+		return "sum"
+	case AggCount:
+		return "count"
+	case AggMax:
+		return "max"
+	case AggMin:
+		return "min"
+	}
+	return fmt.Sprintf("Aggregation(%d)", int(a))
+}
+
+// aggregate combines vals, none of which are missing. The result is
+// missing if there are none, except when counting.
+func (a Aggregation) aggregate(vals []float64) float64 {
+	if a == AggCount {
+		return float64(len(vals))
+	}
+	if len(vals) == 0 {
+		return math.NaN()
+	}
+	ret := vals[0]
+	for _, v := range vals[1:] {
+		switch a {
+		case AggMean, AggSum:
+			ret += v
+		case AggMax:
+			ret = math.Max(ret, v)
+		case AggMin:
+			ret = math.Min(ret, v)
+		}
+	}
+	if a == AggMean {
+		ret /= float64(len(vals))
+	}
+	return ret
+}
+
+// Grouping divides the rows of a set of Instances into groups with
+// the same value of an Attribute. Use Instances.GroupBy to make one.
+type Grouping struct {
+	inst *Instances
+	col  int
+	// keys holds the value of each group, in the order they first
+	// occur, and rows the rows in it
+	keys []string
+	rows [][]int
+}
+
+// GroupBy returns a Grouping of the rows by the value of attr, for
+// aggregating the values of other Attributes within each group with
+// Agg. Rows where attr is missing aren't in any group.
+//
+// IMPORTANT: panic()s if attr isn't one of the Attributes.
+func (inst *Instances) GroupBy(attr Attribute) *Grouping {
+	col := inst.GetAttrIndex(attr)
+	if col == -1 {
+		panic("Invalid attribute")
+	}
+	ret := &Grouping{inst, col, make([]string, 0), make([][]int, 0)}
+	groups := make(map[string]int)
+	for i := 0; i < inst.Rows; i++ {
+		if inst.IsMissing(i, col) {
+			continue
+		}
+		key := inst.GetAttrStr(i, col)
+		k, ok := groups[key]
+		if !ok {
+			k = len(ret.keys)
+			groups[key] = k
+			ret.keys = append(ret.keys, key)
+			ret.rows = append(ret.rows, make([]int, 0))
+		}
+		ret.rows[k] = append(ret.rows[k], i)
+	}
+	return ret
+}
+
+// Len returns the number of groups
+func (g *Grouping) Len() int {
+	return len(g.keys)
+}
+
+// Keys returns the value of the Attribute in each group, in the order
+// they first occur, which is the order of the rows Agg returns.
+func (g *Grouping) Keys() []string {
+	ret := make([]string, len(g.keys))
+	copy(ret, g.keys)
+	return ret
+}
+
+// Rows returns the rows in the group with the given value, or nil if
+// there's no such group.
+func (g *Grouping) Rows(key string) []int {
+	for k, v := range g.keys {
+		if v == key {
+			return g.rows[k]
+		}
+	}
+	return nil
+}
+
+// Agg returns new Instances with a row for each group (see Keys). The
+// first Attribute is the one the rows were grouped by, and is the
+// class, and it's followed by a FloatAttribute for each of targets
+// named after the Aggregation, e.g. "mean(Sepal length)". Without
+// targets, every other numeric Attribute is aggregated. Weights are
+// ignored.
+//
+// As the results of different Aggregations of the same Grouping have
+// the same rows and class Attribute, they can be combined with
+// MergeAttributes.
+//
+// IMPORTANT: panic()s if one of targets isn't one of the Attributes,
+// or isn't numeric and the Aggregation isn't AggCount.
+func (g *Grouping) Agg(agg Aggregation, targets ...Attribute) *Instances {
+	cols := make([]int, 0, len(targets))
+	if len(targets) == 0 {
+		for j, a := range g.inst.attributes {
+			if j != g.col && a.GetType() == Float64Type {
+				cols = append(cols, j)
+			}
+		}
+	}
+	for _, a := range targets {
+		j := g.inst.GetAttrIndex(a)
+		if j == -1 {
+			panic("Invalid attribute")
+		}
+		if agg != AggCount && a.GetType() != Float64Type {
+			panic(fmt.Sprintf("base: can't take the %s of %s, which isn't numeric", agg, a.GetName()))
+		}
+		cols = append(cols, j)
+	}
+
+	attrs := make([]Attribute, len(cols)+1)
+	attrs[0] = g.inst.attributes[g.col]
+	for k, j := range cols {
+		attrs[k+1] = NewFloatAttribute()
+		attrs[k+1].SetName(fmt.Sprintf("%s(%s)", agg, g.inst.attributes[j].GetName()))
+	}
+	ret := NewInstances(attrs, len(g.keys))
+	ret.ClassIndex = 0
+	vals := make([]float64, 0)
+	for k, rows := range g.rows {
+		ret.Set(k, 0, g.inst.Get(rows[0], g.col))
+		for c, j := range cols {
+			vals = vals[:0]
+			for _, i := range rows {
+				if !g.inst.IsMissing(i, j) {
+					vals = append(vals, g.inst.Get(i, j))
+				}
+			}
+			ret.Set(k, c+1, agg.aggregate(vals))
+		}
+	}
+	return ret
+}
@@ -0,0 +1,63 @@
+package base
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGroupBy(testEnv *testing.T) {
+	inst, err := ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	inst.SetMissing(0, 0)
+	g := inst.GroupBy(inst.GetClassAttr())
+	if g.Len() != 3 || g.Keys()[0] != "Iris-setosa" || len(g.Rows("Iris-virginica")) != 50 {
+		testEnv.Fatal(g.Keys())
+	}
+
+	means := g.Agg(AggMean)
+	if means.Rows != 3 || means.Cols != 5 || means.ClassIndex != 0 || means.GetClass(1) != "Iris-versicolor" {
+		testEnv.Fatal(means)
+	}
+	if name := means.GetAttr(1).GetName(); name != "mean(Sepal length)" {
+		testEnv.Error(name)
+	}
+	// The setosa mean leaves out the missing value
+	sum := 0.0
+	for _, i := range g.Rows("Iris-setosa")[1:] {
+		sum += inst.Get(i, 0)
+	}
+	if math.Abs(means.Get(0, 1)-sum/49) > 1e-9 {
+		testEnv.Error(means.Get(0, 1), sum/49)
+	}
+
+	counts := g.Agg(AggCount, inst.GetAttr(0))
+	if counts.Get(0, 1) != 49 || counts.Get(2, 1) != 50 {
+		testEnv.Error(counts)
+	}
+	max := g.Agg(AggMax, inst.GetAttr(0))
+	if max.Get(2, 1) != 7.9 {
+		testEnv.Error(max)
+	}
+	merged, err := MergeAttributes(counts, max)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if merged.Cols != 3 || merged.GetAttr(1).GetName() != "max(Sepal length)" || merged.GetClass(2) != "Iris-virginica" {
+		testEnv.Error(merged)
+	}
+}
+
+func TestGroupByPanics(testEnv *testing.T) {
+	inst, err := ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	defer func() {
+		if recover() == nil {
+			testEnv.Error("Expected a panic for a categorical target")
+		}
+	}()
+	inst.GroupBy(inst.GetAttr(0)).Agg(AggSum, inst.GetClassAttr())
+}
@@ -0,0 +1,89 @@
+package base
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+)
+
+// DefaultHashBuckets is the number of buckets of the HashedAttributes
+// made when reading a CSV file, unless CSVOptions says otherwise.
+const DefaultHashBuckets = 1024
+
+// HashedAttribute is a categorical Attribute for columns with too many
+// distinct values to keep, such as IDs: each value is hashed into
+// one of a fixed number of Buckets, which is its system
+// representation, so the Attribute never grows however many values
+// it sees. Different values may share a bucket, and the original
+// values are lost: they're written as "bucket 0", "bucket 1", ...
+type HashedAttribute struct {
+	Name    string
+	Buckets int
+}
+
+// NewHashedAttribute returns a new HashedAttribute with the given
+// number of buckets.
+func NewHashedAttribute(buckets int) *HashedAttribute {
+	return &HashedAttribute{"", buckets}
+}
+
+// GetName returns the human-readable name of this HashedAttribute.
+func (Attr *HashedAttribute) GetName() string {
+	return Attr.Name
+}
+
+// SetName sets the human-readable name of this HashedAttribute.
+func (Attr *HashedAttribute) SetName(name string) {
+	Attr.Name = name
+}
+
+// GetType returns CategoricalType, since each bucket is a category.
+func (Attr *HashedAttribute) GetType() int {
+	return CategoricalType
+}
+
+// String returns a human-readable summary of this Attribute.
+func (Attr *HashedAttribute) String() string {
+	return fmt.Sprintf("HashedAttribute(\"%s\", %d buckets)", Attr.Name, Attr.Buckets)
+}
+
+// Equals tests a HashedAttribute for equality with another Attribute.
+//
+// Returns false if the other Attribute has a different name or
+// number of buckets, or isn't a HashedAttribute.
+func (Attr *HashedAttribute) Equals(other Attribute) bool {
+	attribute, ok := other.(*HashedAttribute)
+	if !ok {
+		return false
+	}
+	return Attr.Name == attribute.Name && Attr.Buckets == attribute.Buckets
+}
+
+// GetValues returns the names of the buckets, in the order of their
+// system representations.
+func (Attr *HashedAttribute) GetValues() []string {
+	ret := make([]string, Attr.Buckets)
+	for i := range ret {
+		ret[i] = fmt.Sprintf("bucket %d", i)
+	}
+	return ret
+}
+
+// GetSysValFromString returns the bucket rawVal hashes to (using
+// 32-bit FNV-1a), or NaN if it's missing.
+func (Attr *HashedAttribute) GetSysValFromString(rawVal string) float64 {
+	if IsMissingString(rawVal) {
+		return math.NaN()
+	}
+	h := fnv.New32a()
+	h.Write([]byte(rawVal))
+	return float64(h.Sum32() % uint32(Attr.Buckets))
+}
+
+// GetStringFromSysVal returns the name of the bucket val.
+func (Attr *HashedAttribute) GetStringFromSysVal(val float64) string {
+	if IsMissingValue(val) {
+		return MissingString
+	}
+	return fmt.Sprintf("bucket %d", int(val))
+}
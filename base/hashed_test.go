@@ -0,0 +1,85 @@
+package base
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestHashedAttribute(testEnv *testing.T) {
+	a := NewHashedAttribute(8)
+	a.SetName("id")
+	first := a.GetSysValFromString("user-1234")
+	if first < 0 || first >= 8 || a.GetSysValFromString("user-1234") != first {
+		testEnv.Error(first)
+	}
+	if !IsMissingValue(a.GetSysValFromString(MissingString)) {
+		testEnv.Error("Missing values should stay missing")
+	}
+	if s := a.GetStringFromSysVal(3); s != "bucket 3" || len(a.GetValues()) != 8 {
+		testEnv.Error(s, a.GetValues())
+	}
+
+	var buf bytes.Buffer
+	attrs := []Attribute{a}
+	if err := gob.NewEncoder(&buf).Encode(&attrs); err != nil {
+		testEnv.Fatal(err)
+	}
+	var decoded []Attribute
+	if err := gob.NewDecoder(&buf).Decode(&decoded); err != nil {
+		testEnv.Fatal(err)
+	}
+	if !decoded[0].Equals(a) {
+		testEnv.Error(decoded[0])
+	}
+}
+
+func TestParseCSVHashedColumns(testEnv *testing.T) {
+	file, err := ioutil.TempFile("", "hashed")
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString("id,colour,class\n")
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(file, "id%d,%s,c%d\n", i, []string{"red", "green"}[i%2], i)
+	}
+	file.Close()
+
+	options := CSVOptions{HasHeaders: true, MaxLevels: 10, HashBuckets: 16}
+	if types := sniffCSVColumnTypes(file.Name(), options); types[0] != HashedColumn || types[1] != CategoricalColumn {
+		testEnv.Error(types)
+	}
+	inst, err := ParseCSVToInstancesWithOptions(file.Name(), options)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	id, ok := inst.GetAttr(0).(*HashedAttribute)
+	if !ok || id.Buckets != 16 || id.GetName() != "id" {
+		testEnv.Fatal(inst.GetAttr(0))
+	}
+	if _, ok := inst.GetAttr(1).(*CategoricalAttribute); !ok {
+		testEnv.Error(inst.GetAttr(1))
+	}
+	// The class has 100 values, but isn't hashed
+	if _, ok := inst.GetClassAttr().(*CategoricalAttribute); !ok || inst.GetClass(99) != "c99" {
+		testEnv.Error(inst.GetClassAttr())
+	}
+	for i := 0; i < inst.Rows; i++ {
+		if inst.Get(i, 0) != id.GetSysValFromString(fmt.Sprintf("id%d", i)) {
+			testEnv.Fatalf("Row %d is in the wrong bucket", i)
+		}
+	}
+
+	// Without MaxLevels nothing is hashed
+	inst, err = ParseCSVToInstancesWithOptions(file.Name(), CSVOptions{HasHeaders: true})
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if _, ok := inst.GetAttr(0).(*CategoricalAttribute); !ok {
+		testEnv.Error(inst.GetAttr(0))
+	}
+}
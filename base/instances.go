@@ -23,14 +23,21 @@ const (
 const highBit int64 = -1 << 63
 
 // Instances represents a grid of numbers (typed by Attributes)
-// stored internally in mat.DenseMatrix as float64's.
+// stored internally as float64's, in a mat64.Dense unless another
+// Storage is given (see NewInstancesFromStorage).
 // See docs/instances.md for more information.
 type Instances struct {
-	storage    *mat64.Dense
+	storage    Storage
 	attributes []Attribute
 	Rows       int
 	Cols       int
 	ClassIndex int
+	// weights holds the weight of each row, or is nil if they're
+	// all one (see SetWeight)
+	weights []float64
+	// roles holds the AttributeRole of each column, or is nil if
+	// they're all features
+	roles []AttributeRole
 }
 
 func xorFloatOp(item float64) float64 {
@@ -131,8 +138,12 @@ func (inst *Instances) Sort(direction SortDirection, attrs []int) {
 }
 
 // NewInstances returns a preallocated Instances structure
-// with some helful values pre-filled.
+// with some helful values pre-filled. If Config.Float32 is set, they're
+// backed by a Float32Storage.
 func NewInstances(attrs []Attribute, rows int) *Instances {
+	if GetConfig().Float32 {
+		return NewFloat32Instances(attrs, rows)
+	}
 	rawStorage := make([]float64, rows*len(attrs))
 	return NewInstancesFromRaw(attrs, rows, rawStorage)
 }
@@ -163,7 +174,61 @@ func NewInstancesFromRaw(attrs []Attribute, rows int, data []float64) *Instances
 // NewInstancesFromDense creates a set of Instances from a mat64.Dense
 // matrix
 func NewInstancesFromDense(attrs []Attribute, rows int, mat *mat64.Dense) *Instances {
-	return &Instances{mat, attrs, rows, len(attrs), len(attrs) - 1}
+	return NewInstancesFromStorage(attrs, NewDenseStorage(mat))
+}
+
+// NewInstancesFromStorage creates a set of Instances backed by the
+// given Storage, which should have one column per Attribute. The last
+// Attribute is the class.
+func NewInstancesFromStorage(attrs []Attribute, storage Storage) *Instances {
+	rows, _ := storage.Dims()
+	return &Instances{storage, attrs, rows, len(attrs), len(attrs) - 1, nil, nil}
+}
+
+// NewSparseInstances returns an all-zero set of Instances backed by
+// a SparseStorage, for data where most values are zero.
+func NewSparseInstances(attrs []Attribute, rows int) *Instances {
+	return NewInstancesFromStorage(attrs, NewSparseStorage(rows, len(attrs)))
+}
+
+// NewFloat32Instances returns an all-zero set of Instances backed by
+// a Float32Storage, which takes half the memory at the cost of
+// precision.
+func NewFloat32Instances(attrs []Attribute, rows int) *Instances {
+	return NewInstancesFromStorage(attrs, NewFloat32Storage(rows, len(attrs)))
+}
+
+// newInstancesLike returns an empty set of Instances with the same
+// kind of Storage as inst.
+func (inst *Instances) newInstancesLike(attrs []Attribute, rows int) *Instances {
+	return NewInstancesFromStorage(attrs, inst.storage.New(rows, len(attrs)))
+}
+
+// Storage returns the Storage backing the Instances.
+func (inst *Instances) Storage() Storage {
+	return inst.storage
+}
+
+// IsSparse returns true if the Instances are backed by a
+// SparseStorage, or are a view of one.
+func (inst *Instances) IsSparse() bool {
+	storage := inst.storage
+	if v, ok := storage.(*ViewStorage); ok {
+		storage = v.Source()
+	}
+	_, ok := storage.(*SparseStorage)
+	return ok
+}
+
+// IsFloat32 returns true if the Instances are backed by a
+// Float32Storage, or are a view of one.
+func (inst *Instances) IsFloat32() bool {
+	storage := inst.storage
+	if v, ok := storage.(*ViewStorage); ok {
+		storage = v.Source()
+	}
+	_, ok := storage.(*Float32Storage)
+	return ok
 }
 
 // InstancesTrainTestSplit takes a given Instances (src) and a train-test fraction
@@ -173,12 +238,24 @@ func NewInstancesFromDense(attrs []Attribute, rows int, mat *mat64.Dense) *Insta
 // IMPORTANT: this function is only meaningful when prop is between 0.0 and 1.0.
 // Using any other values may result in odd behaviour.
 func InstancesTrainTestSplit(src *Instances, prop float64) (*Instances, *Instances) {
+	return instancesTrainTestSplit(src, prop, rand.Intn)
+}
+
+// InstancesTrainTestSplitWithSeed is like InstancesTrainTestSplit,
+// but the split (and the shuffling of src) is determined by seed, so
+// that it's the same every time.
+func InstancesTrainTestSplitWithSeed(src *Instances, prop float64, seed int64) (*Instances, *Instances) {
+	return instancesTrainTestSplit(src, prop, rand.New(rand.NewSource(seed)).Intn)
+}
+
+// instancesTrainTestSplit implements InstancesTrainTestSplit, taking
+// random numbers from intn.
+func instancesTrainTestSplit(src *Instances, prop float64, intn func(int) int) (*Instances, *Instances) {
 	trainingRows := make([]int, 0)
 	testingRows := make([]int, 0)
-	numAttrs := len(src.attributes)
-	src.Shuffle()
+	src.shuffle(intn)
 	for i := 0; i < src.Rows; i++ {
-		trainOrTest := rand.Intn(101)
+		trainOrTest := intn(101)
 		if trainOrTest > int(100*prop) {
 			trainingRows = append(trainingRows, i)
 		} else {
@@ -186,26 +263,21 @@ func InstancesTrainTestSplit(src *Instances, prop float64) (*Instances, *Instanc
 		}
 	}
 
-	rawTrainMatrix := mat64.NewDense(len(trainingRows), numAttrs, make([]float64, len(trainingRows)*numAttrs))
-	rawTestMatrix := mat64.NewDense(len(testingRows), numAttrs, make([]float64, len(testingRows)*numAttrs))
-
+	trainingRet := src.newInstancesLike(src.attributes, len(trainingRows))
+	testRet := src.newInstancesLike(src.attributes, len(testingRows))
+	trainingRet.copyRoles(src)
+	testRet.copyRoles(src)
 	for i, row := range trainingRows {
-		rowDat := src.storage.RowView(row)
-		rawTrainMatrix.SetRow(i, rowDat)
+		trainingRet.copyRow(i, src, row)
 	}
 	for i, row := range testingRows {
-		rowDat := src.storage.RowView(row)
-		rawTestMatrix.SetRow(i, rowDat)
+		testRet.copyRow(i, src, row)
 	}
-
-
-	trainingRet := NewInstancesFromDense(src.attributes, len(trainingRows), rawTrainMatrix)
-	testRet := NewInstancesFromDense(src.attributes, len(testingRows), rawTestMatrix)
 	return trainingRet, testRet
 }
 
 // CountAttrValues returns the distribution of values of a given
-// Attribute.
+// Attribute. Missing values aren't counted.
 // IMPORTANT: calls panic() if the attribute index of a cannot be
 // determined. Call GetAttrIndex(a) and check for a -1 return value.
 func (inst *Instances) CountAttrValues(a Attribute) map[string]int {
@@ -216,6 +288,9 @@ func (inst *Instances) CountAttrValues(a Attribute) map[string]int {
 	}
 	for i := 0; i < inst.Rows; i++ {
 		sysVal := inst.Get(i, attrIndex)
+		if IsMissingValue(sysVal) {
+			continue
+		}
 		stringVal := a.GetStringFromSysVal(sysVal)
 		ret[stringVal] += 1
 	}
@@ -255,6 +330,7 @@ func (inst *Instances) DecomposeOnAttributeValues(at Attribute) map[string]*Inst
 	rows := make(map[string]int)
 	for k := range counts {
 		tmp := NewInstances(newAttrs, counts[k])
+		tmp.copyRoles(inst)
 		ret[k] = tmp
 	}
 	for i := 0; i < inst.Rows; i++ {
@@ -270,6 +346,7 @@ func (inst *Instances) DecomposeOnAttributeValues(at Attribute) map[string]*Inst
 			dest.Set(destRow, newAttrCounter, inst.Get(i, j))
 			newAttrCounter++
 		}
+		dest.SetWeight(destRow, inst.GetWeight(i))
 		rows[classVar]++
 	}
 	return ret
@@ -317,14 +394,44 @@ func (inst *Instances) Set(row int, col int, val float64) {
 // GetRowVector returns a row of system representation
 // values at the given row index.
 func (inst *Instances) GetRowVector(row int) []float64 {
-	return inst.storage.RowView(row)
+	return inst.storage.Row(row)
 }
 
-// GetRowVector returns a row of system representation
-// values at the given row index, excluding the class attribute
+// NonZero returns the columns of the given row whose system
+// representation isn't zero, in increasing order, and their values.
+// For sparse Instances, this is much faster than looking at every
+// column.
+func (inst *Instances) NonZero(row int) (cols []int, vals []float64) {
+	return inst.storage.NonZero(row)
+}
+
+// copyRow copies the given row of src, which must have the same
+// number of columns, into row dst, which must be all zero.
+func (inst *Instances) copyRow(dst int, src *Instances, row int) {
+	cols, vals := src.NonZero(row)
+	for k, j := range cols {
+		inst.storage.Set(dst, j, vals[k])
+	}
+	if src.weights != nil {
+		inst.SetWeight(dst, src.weights[row])
+	}
+}
+
+// GetRowVectorWithoutClass returns a row of system representation
+// values at the given row index, excluding the class attribute and
+// any others which aren't features (see SetRole)
 func (inst *Instances) GetRowVectorWithoutClass(row int) []float64 {
 	rawRow := make([]float64, inst.Cols)
 	copy(rawRow, inst.GetRowVector(row))
+	if inst.roles != nil {
+		ret := make([]float64, 0, inst.Cols)
+		for j, v := range rawRow {
+			if inst.IsFeature(j) {
+				ret = append(ret, v)
+			}
+		}
+		return ret
+	}
 	return append(rawRow[0:inst.ClassIndex], rawRow[inst.ClassIndex+1:inst.Cols]...)
 }
 
@@ -338,12 +445,16 @@ func (inst *Instances) GetClass(row int) string {
 }
 
 // GetClassDist returns a map containing the count of each
-// class type (indexed by the class' string representation)
+// class type (indexed by the class' string representation).
+// Rows whose class is missing aren't counted.
 func (inst *Instances) GetClassDistribution() map[string]int {
 	ret := make(map[string]int)
 	attr := inst.GetAttr(inst.ClassIndex)
 	for i := 0; i < inst.Rows; i++ {
 		val := inst.Get(i, inst.ClassIndex)
+		if IsMissingValue(val) {
+			continue
+		}
 		cls := attr.GetStringFromSysVal(val)
 		ret[cls]++
 	}
@@ -409,6 +520,21 @@ func (inst *Instances) GetAttrIndex(of Attribute) int {
 	return -1
 }
 
+// GetAttrIndices returns the index of each of attrs, in order: e.g.
+// where the Attributes a model was trained on are in new data.
+//
+// IMPORTANT: panic()s if inst has no Attribute equal to one of attrs.
+func (inst *Instances) GetAttrIndices(attrs []Attribute) []int {
+	ret := make([]int, len(attrs))
+	for k, a := range attrs {
+		ret[k] = inst.GetAttrIndex(a)
+		if ret[k] == -1 {
+			panic(fmt.Sprintf("base: Attribute %s is missing", a.GetName()))
+		}
+	}
+	return ret
+}
+
 // ReplaceAttr overwrites the attribute at `index' with `a'
 func (inst *Instances) ReplaceAttr(index int, a Attribute) {
 	// Replace an Attribute at index with another
@@ -479,9 +605,14 @@ func (inst *Instances) String() string {
 }
 
 // SelectAttributes returns a new instance set containing
-// the values from this one with only the Attributes specified
+// the values from this one with only the Attributes specified.
+//
+// The values are copied, as they always have been, so that changing
+// them (e.g. with a filter, which works in place) doesn't change
+// these Instances; see ViewAttributes for Instances which share this
+// one's Storage.
 func (inst *Instances) SelectAttributes(attrs []Attribute) *Instances {
-	ret := NewInstances(attrs, inst.Rows)
+	ret := inst.newInstancesLike(attrs, inst.Rows)
 	attrIndices := make([]int, 0)
 	for _, a := range attrs {
 		attrIndex := inst.GetAttrIndex(a)
@@ -492,6 +623,24 @@ func (inst *Instances) SelectAttributes(attrs []Attribute) *Instances {
 			ret.Set(i, j, inst.Get(i, a))
 		}
 	}
+	ret.weights = inst.Weights()
+	ret.copyRoles(inst)
+	return ret
+}
+
+// SelectRows returns a new instance set containing copies of
+// the given rows (which may repeat) from this one, in order.
+//
+// The rows are copied, so that changing them doesn't change these
+// Instances; see ViewRows for Instances which share this one's
+// Storage.
+func (inst *Instances) SelectRows(rows []int) *Instances {
+	ret := inst.newInstancesLike(inst.attributes, len(rows))
+	ret.ClassIndex = inst.ClassIndex
+	ret.copyRoles(inst)
+	for i, r := range rows {
+		ret.copyRow(i, inst, r)
+	}
 	return ret
 }
 
@@ -507,8 +656,20 @@ func (inst *Instances) GeneratePredictionVector() *Instances {
 
 // Shuffle randomizes the row order in place
 func (inst *Instances) Shuffle() {
+	inst.shuffle(rand.Intn)
+}
+
+// ShuffleWithSeed randomizes the row order in place, in the same way
+// every time for the same seed.
+func (inst *Instances) ShuffleWithSeed(seed int64) {
+	inst.shuffle(rand.New(rand.NewSource(seed)).Intn)
+}
+
+// shuffle randomizes the row order in place, taking random numbers
+// from intn.
+func (inst *Instances) shuffle(intn func(int) int) {
 	for i := 0; i < inst.Rows; i++ {
-		j := rand.Intn(i + 1)
+		j := intn(i + 1)
 		inst.swapRows(i, j)
 	}
 }
@@ -519,44 +680,54 @@ func (inst *Instances) Shuffle() {
 // IMPORTANT: There's a high chance of seeing duplicate rows
 // whenever size is close to the row count.
 func (inst *Instances) SampleWithReplacement(size int) *Instances {
-	ret := NewInstances(inst.attributes, size)
+	ret := inst.newInstancesLike(inst.attributes, size)
+	ret.copyRoles(inst)
 	for i := 0; i < size; i++ {
 		srcRow := rand.Intn(inst.Rows)
-		for j := 0; j < inst.Cols; j++ {
-			ret.Set(i, j, inst.Get(srcRow, j))
-		}
+		ret.copyRow(i, inst, srcRow)
 	}
 	return ret
 }
 
-// Equal checks whether a given Instance set is exactly the same
-// as another: same size and same values (as determined by the Attributes)
+// SampleWithoutReplacement returns a new set of Instances of size `size'
+// containing distinct rows from this set of Instances, in random order.
 //
-// IMPORTANT: does not explicitly check if the Attributes are considered equal.
-func (inst *Instances) Equal(other *Instances) bool {
-	if inst.Rows != other.Rows {
-		return false
-	}
-	if inst.Cols != other.Cols {
-		return false
+// IMPORTANT: this function panic()s if size is larger than the row count.
+func (inst *Instances) SampleWithoutReplacement(size int) *Instances {
+	if size > inst.Rows {
+		panic("Sample size is larger than the number of rows")
 	}
-	for i := 0; i < inst.Rows; i++ {
-		for j := 0; j < inst.Cols; j++ {
-			if inst.GetAttrStr(i, j) != other.GetAttrStr(i, j) {
-				return false
-			}
-		}
+	ret := inst.newInstancesLike(inst.attributes, size)
+	ret.copyRoles(inst)
+	for i, srcRow := range rand.Perm(inst.Rows)[:size] {
+		ret.copyRow(i, inst, srcRow)
 	}
-	return true
+	return ret
+}
+
+// Equal checks whether a given Instance set is the same as another,
+// up to DefaultTolerance (see Compare).
+func (inst *Instances) Equal(other *Instances) bool {
+	return inst.Compare(other, DefaultTolerance) == nil
 }
 
 func (inst *Instances) swapRows(r1 int, r2 int) {
+	if v, ok := inst.storage.(*ViewStorage); ok {
+		v.swapRows(r1, r2)
+		if inst.weights != nil {
+			inst.weights[r1], inst.weights[r2] = inst.weights[r2], inst.weights[r1]
+		}
+		return
+	}
 	row1buf := make([]float64, inst.Cols)
 	row2buf := make([]float64, inst.Cols)
-	row1 := inst.storage.RowView(r1)
-	row2 := inst.storage.RowView(r2)
-	copy(row1buf, row1)
-	copy(row2buf, row2)
-	inst.storage.SetRow(r1, row2buf)
-	inst.storage.SetRow(r2, row1buf)
+	copy(row1buf, inst.storage.Row(r1))
+	copy(row2buf, inst.storage.Row(r2))
+	for j := 0; j < inst.Cols; j++ {
+		inst.storage.Set(r1, j, row2buf[j])
+		inst.storage.Set(r2, j, row1buf[j])
+	}
+	if inst.weights != nil {
+		inst.weights[r1], inst.weights[r2] = inst.weights[r2], inst.weights[r1]
+	}
 }
@@ -0,0 +1,147 @@
+package base
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// JSONField declares how a field of JSON records becomes an
+// Attribute.
+type JSONField struct {
+	// Path is the field's key. Keys of nested objects are separated
+	// by dots, and elements of arrays are picked by index, e.g.
+	// "user.address.city" or "scores.0".
+	Path string
+	// Name is the Attribute's name. If it's empty, Path is used.
+	Name string
+	// Type is Float64Type, CategoricalType or StringType.
+	Type int
+}
+
+// ParseJSONToInstances reads the newline-delimited JSON (JSON Lines)
+// file given by filepath. See ParseJSON.
+func ParseJSONToInstances(filepath string, fields []JSONField) (*Instances, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseJSON(file, fields)
+}
+
+// ParseJSON reads newline-delimited JSON records from r, with one
+// Attribute for each of fields, in the same order; the last one is
+// the class. Blank lines are skipped.
+//
+// Values can be strings, numbers or booleans. Values of
+// CategoricalAttributes are added as they're found, as
+// ParseCSVToInstances does. Fields which are left out or null are
+// missing.
+func ParseJSON(r io.Reader, fields []JSONField) (*Instances, error) {
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("base: no JSON fields given")
+	}
+	attrs := make([]Attribute, len(fields))
+	paths := make([][]string, len(fields))
+	for j, f := range fields {
+		switch f.Type {
+		case Float64Type:
+			attrs[j] = NewFloatAttribute()
+		case CategoricalType:
+			attrs[j] = NewCategoricalAttribute()
+		case StringType:
+			attrs[j] = NewStringAttribute()
+		default:
+			return nil, fmt.Errorf("base: field %s has unknown type %d", f.Path, f.Type)
+		}
+		name := f.Name
+		if name == "" {
+			name = f.Path
+		}
+		attrs[j].SetName(name)
+		paths[j] = strings.Split(f.Path, ".")
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	records := make([][]string, 0)
+	missing := make([][]bool, 0)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		decoder := json.NewDecoder(strings.NewReader(text))
+		decoder.UseNumber()
+		var obj interface{}
+		if err := decoder.Decode(&obj); err != nil {
+			return nil, fmt.Errorf("base: line %d: %s", line, err)
+		}
+		record := make([]string, len(fields))
+		absent := make([]bool, len(fields))
+		for j, path := range paths {
+			raw := lookupJSONPath(obj, path)
+			if raw == nil {
+				absent[j] = true
+				continue
+			}
+			val, ok := jsonValueString(raw)
+			if !ok {
+				return nil, fmt.Errorf("base: line %d: unsupported value %v for field %s", line, raw, fields[j].Path)
+			}
+			record[j] = val
+		}
+		records = append(records, record)
+		missing = append(missing, absent)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	ret := NewInstances(attrs, len(records))
+	for i, record := range records {
+		for j, val := range record {
+			if missing[i][j] {
+				ret.SetMissing(i, j)
+				continue
+			}
+			if f, ok := attrs[j].(*FloatAttribute); ok {
+				sysVal, err := f.CheckSysValFromString(val)
+				if err != nil {
+					return nil, fmt.Errorf("base: record %d, field %s: %s", i, fields[j].Path, err)
+				}
+				ret.Set(i, j, sysVal)
+				continue
+			}
+			ret.SetAttrStr(i, j, val)
+		}
+	}
+	return ret, nil
+}
+
+// lookupJSONPath returns the value at path in a decoded JSON value,
+// or nil if there isn't one.
+func lookupJSONPath(v interface{}, path []string) interface{} {
+	for _, key := range path {
+		switch node := v.(type) {
+		case map[string]interface{}:
+			v = node[key]
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil
+			}
+			v = node[i]
+		default:
+			return nil
+		}
+	}
+	return v
+}
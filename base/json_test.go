@@ -0,0 +1,55 @@
+package base
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestParseJSON(testEnv *testing.T) {
+	data := `{"user": {"age": 31, "country": "UK"}, "scores": [0.5, 2], "clicked": true}
+
+{"user": {"age": "45", "country": "FR"}, "scores": [1.5], "clicked": false}
+{"user": {"country": "UK"}, "scores": [], "clicked": true}
+`
+	fields := []JSONField{
+		{"user.age", "age", Float64Type},
+		{"user.country", "", CategoricalType},
+		{"scores.1", "second score", Float64Type},
+		{"clicked", "", CategoricalType},
+	}
+	inst, err := ParseJSON(strings.NewReader(data), fields)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if inst.Rows != 3 || inst.Cols != 4 {
+		testEnv.Fatal(inst.Rows, inst.Cols)
+	}
+	if inst.GetAttr(0).GetName() != "age" || inst.GetAttr(1).GetName() != "user.country" {
+		testEnv.Error(inst.GetAttr(0), inst.GetAttr(1))
+	}
+	if inst.Get(0, 0) != 31 || inst.Get(1, 0) != 45 || !math.IsNaN(inst.Get(2, 0)) {
+		testEnv.Error(inst.Get(0, 0), inst.Get(1, 0), inst.Get(2, 0))
+	}
+	if inst.GetAttrStr(1, 1) != "FR" || inst.GetAttrStr(2, 1) != "UK" {
+		testEnv.Error(inst.RowStr(1), inst.RowStr(2))
+	}
+	if inst.Get(0, 2) != 2 || !math.IsNaN(inst.Get(1, 2)) {
+		testEnv.Error(inst.Get(0, 2), inst.Get(1, 2))
+	}
+	if inst.GetClass(0) != "true" || inst.GetClass(1) != "false" {
+		testEnv.Error(inst.GetClass(0), inst.GetClass(1))
+	}
+
+	// Missing categorical fields are missing values too
+	inst, err = ParseJSON(strings.NewReader(`{"user": {"age": 3}, "clicked": null}`), fields)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if !inst.IsMissing(0, 1) || !inst.IsMissing(0, 3) || inst.IsMissing(0, 0) {
+		testEnv.Error(inst.RowStr(0))
+	}
+	if _, err := ParseJSON(strings.NewReader(`{"user": {"age": "old", "country": "UK"}, "clicked": true}`), fields); err == nil {
+		testEnv.Error("Expected an error")
+	}
+}
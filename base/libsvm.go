@@ -0,0 +1,173 @@
+package base
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ParseLibSVMToInstances reads the LibSVM (svmlight) file given by
+// filepath. See ParseLibSVM.
+func ParseLibSVMToInstances(filepath string) (*Instances, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ParseLibSVM(file)
+}
+
+// libSVMRow is a row of a LibSVM file before it's stored
+type libSVMRow struct {
+	label   float64
+	indices []int
+	values  []float64
+}
+
+// ParseLibSVM reads sparse data in the LibSVM (svmlight) format from
+// r, where each line is a label followed by the features which aren't
+// zero as index:value pairs, and anything after a # is a comment:
+//
+//	+1 1:0.5 7:1 # comment
+//
+// The result is sparse Instances with a FloatAttribute named after
+// each index up to the largest one used, and the label as the class
+// Attribute (the last one). Indices start at one unless an index of
+// zero is used. Labels which are all whole numbers are read as a
+// CategoricalAttribute, otherwise as a FloatAttribute.
+func ParseLibSVM(r io.Reader) (*Instances, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	rows := make([]libSVMRow, 0)
+	minIndex, maxIndex := 1, 0
+	categorical := true
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if i := strings.Index(text, "#"); i != -1 {
+			text = text[:i]
+		}
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		label, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return nil, fmt.Errorf("base: line %d: bad label %q", line, fields[0])
+		}
+		if label != math.Trunc(label) {
+			categorical = false
+		}
+		row := libSVMRow{label, make([]int, 0, len(fields)-1), make([]float64, 0, len(fields)-1)}
+		for _, f := range fields[1:] {
+			pair := strings.SplitN(f, ":", 2)
+			if len(pair) != 2 {
+				return nil, fmt.Errorf("base: line %d: expected index:value, got %q", line, f)
+			}
+			index, err := strconv.Atoi(pair[0])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("base: line %d: bad index %q", line, pair[0])
+			}
+			val, err := strconv.ParseFloat(pair[1], 64)
+			if err != nil {
+				return nil, fmt.Errorf("base: line %d: bad value %q", line, pair[1])
+			}
+			if index < minIndex {
+				minIndex = index
+			}
+			if index > maxIndex {
+				maxIndex = index
+			}
+			row.indices = append(row.indices, index)
+			row.values = append(row.values, val)
+		}
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	attrs := make([]Attribute, 0, maxIndex-minIndex+2)
+	for index := minIndex; index <= maxIndex; index++ {
+		a := NewFloatAttribute()
+		a.SetName(strconv.Itoa(index))
+		attrs = append(attrs, a)
+	}
+	var class Attribute
+	if categorical {
+		class = NewCategoricalAttribute()
+	} else {
+		class = NewFloatAttribute()
+	}
+	class.SetName("label")
+	attrs = append(attrs, class)
+
+	ret := NewSparseInstances(attrs, len(rows))
+	classCol := len(attrs) - 1
+	for i, row := range rows {
+		for k, index := range row.indices {
+			ret.Set(i, index-minIndex, row.values[k])
+		}
+		if categorical {
+			// Normalises e.g. "+1" and "1.0" to the same value
+			ret.SetAttrStr(i, classCol, strconv.FormatFloat(row.label, 'f', -1, 64))
+		} else {
+			ret.Set(i, classCol, row.label)
+		}
+	}
+	return ret, nil
+}
+
+// SerializeInstancesToLibSVM writes inst to the file at filepath in
+// the LibSVM format (see WriteLibSVM).
+func SerializeInstancesToLibSVM(inst *Instances, filepath string) error {
+	file, err := os.Create(filepath)
+	if err != nil {
+		return err
+	}
+	if err := WriteLibSVM(file, inst); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// WriteLibSVM writes inst to w in the LibSVM format: the class of
+// each row, followed by the values of the other Attributes which
+// aren't zero. Those are numbered from one in order, skipping the
+// class Attribute, and CategoricalAttributes are written as their
+// system representation. Sparse Instances are written without
+// looking at their zeros.
+//
+// LibSVM can't represent missing values, so NaNs are an error.
+func WriteLibSVM(w io.Writer, inst *Instances) error {
+	buf := bufio.NewWriter(w)
+	for i := 0; i < inst.Rows; i++ {
+		if inst.GetAttr(inst.ClassIndex).GetType() == Float64Type {
+			buf.WriteString(strconv.FormatFloat(inst.Get(i, inst.ClassIndex), 'g', -1, 64))
+		} else {
+			buf.WriteString(inst.GetClass(i))
+		}
+		cols, vals := inst.NonZero(i)
+		for k, j := range cols {
+			if j == inst.ClassIndex {
+				continue
+			}
+			if math.IsNaN(vals[k]) {
+				return fmt.Errorf("base: row %d, column %d: can't write a missing value", i, j)
+			}
+			index := j + 1
+			if j > inst.ClassIndex {
+				index--
+			}
+			fmt.Fprintf(buf, " %d:%s", index, strconv.FormatFloat(vals[k], 'g', -1, 64))
+		}
+		buf.WriteString("\n")
+	}
+	return buf.Flush()
+}
@@ -0,0 +1,67 @@
+package base
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestParseLibSVM(testEnv *testing.T) {
+	data := `# A comment
++1 1:0.5 3:2
+-1 2:1.5 # another comment
+
++1 5:-1
+`
+	inst, err := ParseLibSVM(strings.NewReader(data))
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if !inst.IsSparse() || inst.Rows != 3 || inst.Cols != 6 {
+		testEnv.Fatal(inst.Rows, inst.Cols)
+	}
+	if inst.Get(0, 0) != 0.5 || inst.Get(0, 2) != 2 || inst.Get(0, 1) != 0 || inst.Get(2, 4) != -1 {
+		testEnv.Error(inst.RowStr(0), inst.RowStr(2))
+	}
+	if inst.GetAttr(inst.ClassIndex).GetType() != CategoricalType {
+		testEnv.Error(inst.GetAttr(inst.ClassIndex))
+	}
+	if inst.GetClass(0) != "1" || inst.GetClass(1) != "-1" || inst.GetClass(2) != "1" {
+		testEnv.Error(inst.GetClass(0), inst.GetClass(1))
+	}
+
+	// Round trip
+	var buf bytes.Buffer
+	if err := WriteLibSVM(&buf, inst); err != nil {
+		testEnv.Fatal(err)
+	}
+	expected := "1 1:0.5 3:2\n-1 2:1.5\n1 5:-1\n"
+	if buf.String() != expected {
+		testEnv.Errorf("Got %q, expected %q", buf.String(), expected)
+	}
+
+	if _, err := ParseLibSVM(strings.NewReader("1 1-0.5\n")); err == nil {
+		testEnv.Error("Expected an error")
+	}
+}
+
+func TestParseLibSVMRegression(testEnv *testing.T) {
+	inst, err := ParseLibSVM(strings.NewReader("0.25 0:1 1:2\n1.5 1:3\n"))
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if inst.Cols != 3 || inst.GetAttr(0).GetName() != "0" || inst.Get(0, 0) != 1 || inst.Get(1, 1) != 3 {
+		testEnv.Error(inst.RowStr(0), inst.RowStr(1))
+	}
+	if inst.GetAttr(2).GetType() != Float64Type || inst.Get(0, 2) != 0.25 {
+		testEnv.Error(inst.GetAttr(2), inst.Get(0, 2))
+	}
+	var buf bytes.Buffer
+	if err := WriteLibSVM(&buf, inst); err != nil {
+		testEnv.Fatal(err)
+	}
+	// Written indices always start at one
+	if buf.String() != "0.25 1:1 2:2\n1.5 2:3\n" {
+		testEnv.Error(buf.String())
+	}
+}
@@ -0,0 +1,142 @@
+package base
+
+import (
+	"bytes"
+	"fmt"
+	"text/tabwriter"
+
+	mat64 "github.com/gonum/matrix/mat64"
+)
+
+// ColumnMapping says where a column of a matrix made by
+// InstancesToMat64 comes from, and how its values are encoded.
+type ColumnMapping struct {
+	// Column is the column of the matrix
+	Column int
+	// Index is the column of the Instances, and Attribute its
+	// Attribute
+	Index     int
+	Attribute Attribute
+	// Class is true for the column of the class matrix
+	Class bool
+}
+
+// Encoding describes how the values of the Attribute are represented
+// as numbers: "value" for numbers, "unix seconds" for timestamps,
+// "rank" for ordered categories and "index" for categories and text,
+// which are numbered in the order their Attribute lists them.
+func (m ColumnMapping) Encoding() string {
+	switch m.Attribute.(type) {
+	case *TimeAttribute:
+		return "unix seconds"
+	case *OrdinalAttribute:
+		return "rank"
+	}
+	if m.Attribute.GetType() == Float64Type {
+		return "value"
+	}
+	return "index"
+}
+
+// ColumnMappings describes every column of the matrices made by
+// InstancesToMat64
+type ColumnMappings []ColumnMapping
+
+// Attributes returns the Attribute of each column, those of the
+// features first, in the order InstancesFromMat64 takes them.
+func (m ColumnMappings) Attributes() []Attribute {
+	ret := make([]Attribute, 0, len(m))
+	for _, c := range m {
+		if !c.Class {
+			ret = append(ret, c.Attribute)
+		}
+	}
+	for _, c := range m {
+		if c.Class {
+			ret = append(ret, c.Attribute)
+		}
+	}
+	return ret
+}
+
+// String returns a table of the columns, the Attributes they come
+// from and their encodings.
+func (m ColumnMappings) String() string {
+	var buffer bytes.Buffer
+	w := tabwriter.NewWriter(&buffer, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "Matrix\tColumn\tAttribute\tEncoding")
+	for _, c := range m {
+		matrix := "x"
+		if c.Class {
+			matrix = "y"
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s (#%d)\t%s\n", matrix, c.Column, c.Attribute.GetName(), c.Index, c.Encoding())
+	}
+	w.Flush()
+	return buffer.String()
+}
+
+// InstancesToMat64 copies the features of inst (see IsFeature) into
+// the rows of a matrix x and its class into a single-column matrix y,
+// both holding the system representation of the values (so missing
+// values are NaN), and says where each column came from.
+func InstancesToMat64(inst *Instances) (*mat64.Dense, *mat64.Dense, ColumnMappings) {
+	cols := inst.FeatureIndices()
+	mapping := make(ColumnMappings, 0, len(cols)+1)
+	x := mat64.NewDense(inst.Rows, len(cols), nil)
+	for k, j := range cols {
+		mapping = append(mapping, ColumnMapping{k, j, inst.GetAttr(j), false})
+		for i := 0; i < inst.Rows; i++ {
+			x.Set(i, k, inst.Get(i, j))
+		}
+	}
+	y := mat64.NewDense(inst.Rows, 1, nil)
+	for i := 0; i < inst.Rows; i++ {
+		y.Set(i, 0, inst.Get(i, inst.ClassIndex))
+	}
+	mapping = append(mapping, ColumnMapping{0, inst.ClassIndex, inst.GetClassAttr(), true})
+	return x, y, mapping
+}
+
+// InstancesFromMat64 returns Instances with the columns of x as
+// features, followed by the single column of y (which may be nil) as
+// the class. attrs has an Attribute for each of them, e.g. from
+// ColumnMappings.Attributes, and the values are their system
+// representations; if attrs is nil, every column gets a FloatAttribute
+// named after its index, and the class one is named "class". Without
+// y, the last column of x is the class, as usual.
+func InstancesFromMat64(x, y *mat64.Dense, attrs []Attribute) (*Instances, error) {
+	rows, cols := x.Dims()
+	classCols := 0
+	if y != nil {
+		yRows, yCols := y.Dims()
+		if yRows != rows || yCols != 1 {
+			return nil, fmt.Errorf("base: y should be %dx1, got %dx%d", rows, yRows, yCols)
+		}
+		classCols = 1
+	}
+	if attrs == nil {
+		attrs = make([]Attribute, cols+classCols)
+		for k := range attrs {
+			attrs[k] = NewFloatAttribute()
+			attrs[k].SetName(fmt.Sprintf("%d", k))
+		}
+		if y != nil {
+			attrs[cols].SetName("class")
+		}
+	}
+	if len(attrs) != cols+classCols {
+		return nil, fmt.Errorf("base: %d Attributes for %d columns", len(attrs), cols+classCols)
+	}
+
+	ret := NewInstances(attrs, rows)
+	for i := 0; i < rows; i++ {
+		for k := 0; k < cols; k++ {
+			ret.Set(i, k, x.At(i, k))
+		}
+		if y != nil {
+			ret.Set(i, cols, y.At(i, 0))
+		}
+	}
+	return ret, nil
+}
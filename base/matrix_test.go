@@ -0,0 +1,49 @@
+package base
+
+import (
+	"strings"
+	"testing"
+
+	mat64 "github.com/gonum/matrix/mat64"
+)
+
+func TestMat64Conversion(testEnv *testing.T) {
+	inst, err := ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	inst.SetRole(1, IDRole)
+	x, y, mapping := InstancesToMat64(inst)
+	if rows, cols := x.Dims(); rows != 150 || cols != 3 {
+		testEnv.Fatal(rows, cols)
+	}
+	if x.At(0, 1) != inst.Get(0, 2) || y.At(149, 0) != inst.Get(149, 4) {
+		testEnv.Error(x.At(0, 1), y.At(149, 0))
+	}
+	if len(mapping) != 4 || mapping[1].Index != 2 || !mapping[3].Class || mapping[3].Encoding() != "index" {
+		testEnv.Error(mapping)
+	}
+	report := mapping.String()
+	if !strings.Contains(report, "x       1       Petal length (#2)  value") {
+		testEnv.Error(report)
+	}
+
+	back, err := InstancesFromMat64(x, y, mapping.Attributes())
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if back.Cols != 4 || back.GetClass(0) != inst.GetClass(0) || back.GetAttrStr(0, 1) != inst.GetAttrStr(0, 2) {
+		testEnv.Error(back.RowStr(0), inst.RowStr(0))
+	}
+
+	plain, err := InstancesFromMat64(x, nil, nil)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if plain.Cols != 3 || plain.GetAttr(2).GetName() != "2" || plain.ClassIndex != 2 {
+		testEnv.Error(plain)
+	}
+	if _, err := InstancesFromMat64(x, mat64.NewDense(3, 1, nil), nil); err == nil {
+		testEnv.Error("Expected an error for too few class values")
+	}
+}
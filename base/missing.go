@@ -0,0 +1,98 @@
+package base
+
+import (
+	"math"
+	"strings"
+)
+
+// MissingString is how missing values are written, and one of the
+// ways they're recognised when read (see IsMissingString).
+const MissingString = "?"
+
+// Missing values are stored as NaN, whatever the type of their
+// Attribute. Attributes read "?" and empty strings as missing (so
+// CSV, ARFF and the other loaders all do), and write missing values
+// as "?".
+
+// IsMissingValue returns true if the system representation sysVal
+// marks a missing value.
+func IsMissingValue(sysVal float64) bool {
+	return math.IsNaN(sysVal)
+}
+
+// IsMissingString returns true if the raw value val marks a missing
+// value: it's "?" or empty, ignoring whitespace.
+func IsMissingString(val string) bool {
+	val = strings.TrimSpace(val)
+	return val == "" || val == MissingString
+}
+
+// IsMissing returns true if the value at the given row and column
+// is missing.
+func (inst *Instances) IsMissing(row, col int) bool {
+	return IsMissingValue(inst.Get(row, col))
+}
+
+// SetMissing marks the value at the given row and column as missing.
+func (inst *Instances) SetMissing(row, col int) {
+	inst.Set(row, col, math.NaN())
+}
+
+// CountMissing returns the number of missing values of the Attribute
+// at index col.
+func (inst *Instances) CountMissing(col int) int {
+	ret := 0
+	for i := 0; i < inst.Rows; i++ {
+		if inst.IsMissing(i, col) {
+			ret++
+		}
+	}
+	return ret
+}
+
+// MissingCounts returns the number of missing values of each
+// Attribute.
+func (inst *Instances) MissingCounts() []int {
+	ret := make([]int, inst.Cols)
+	for i := 0; i < inst.Rows; i++ {
+		for j := range ret {
+			if inst.IsMissing(i, j) {
+				ret[j]++
+			}
+		}
+	}
+	return ret
+}
+
+// HasMissing returns true if any value is missing.
+func (inst *Instances) HasMissing() bool {
+	for i := 0; i < inst.Rows; i++ {
+		if inst.RowHasMissing(i) {
+			return true
+		}
+	}
+	return false
+}
+
+// RowHasMissing returns true if any value of the given row is
+// missing.
+func (inst *Instances) RowHasMissing(row int) bool {
+	for j := 0; j < inst.Cols; j++ {
+		if inst.IsMissing(row, j) {
+			return true
+		}
+	}
+	return false
+}
+
+// CompleteRows returns the indices of the rows without any missing
+// values, e.g. to drop the others with SelectRows.
+func (inst *Instances) CompleteRows() []int {
+	ret := make([]int, 0, inst.Rows)
+	for i := 0; i < inst.Rows; i++ {
+		if !inst.RowHasMissing(i) {
+			ret = append(ret, i)
+		}
+	}
+	return ret
+}
@@ -0,0 +1,55 @@
+package base
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestMissingValues(testEnv *testing.T) {
+	file, err := ioutil.TempFile("", "missing")
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString("a,b,c\n?,x,yes\n1.5,?,no\n2.5,y,\n")
+	file.Close()
+
+	inst, err := ParseCSVToInstances(file.Name(), true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	// The first column is numeric even though its first value is missing
+	if inst.GetAttr(0).GetType() != Float64Type {
+		testEnv.Error(inst.GetAttr(0))
+	}
+	if !inst.IsMissing(0, 0) || !inst.IsMissing(1, 1) || !inst.IsMissing(2, 2) || inst.IsMissing(0, 1) {
+		testEnv.Error(inst)
+	}
+	if counts := inst.MissingCounts(); counts[0] != 1 || counts[1] != 1 || counts[2] != 1 {
+		testEnv.Error(counts)
+	}
+	// Missing values aren't added as categorical values
+	if values := inst.GetAttr(1).(*CategoricalAttribute).values; len(values) != 2 {
+		testEnv.Error(values)
+	}
+	if dist := inst.GetClassDistribution(); len(dist) != 2 || dist["yes"] != 1 {
+		testEnv.Error(dist)
+	}
+	if inst.RowStr(1) != "1.50 ? no" {
+		testEnv.Error(inst.RowStr(1))
+	}
+	if !inst.HasMissing() || len(inst.CompleteRows()) != 0 {
+		testEnv.Error(inst.CompleteRows())
+	}
+	inst.Set(0, 0, 1)
+	inst.Set(0, 1, 0)
+	if rows := inst.CompleteRows(); len(rows) != 1 || rows[0] != 0 {
+		testEnv.Error(rows)
+	}
+	inst.SetMissing(0, 0)
+	if inst.CountMissing(0) != 1 || !strings.Contains(inst.Schema(), "1 missing") {
+		testEnv.Error(inst.Schema())
+	}
+}
@@ -0,0 +1,129 @@
+package base
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// The functions in this file write Instances to files with a compact
+// binary layout which can be memory-mapped (see OpenMmapInstances),
+// so that datasets larger than the available memory can be trained
+// on by learners which make sequential passes over the rows. The
+// layout is a header of five little-endian uint64s (a magic number,
+// the number of rows, the number of columns, the class index and
+// zero), then the system representation of every value as a
+// little-endian float64, row by row, then the gob-encoded Attributes.
+
+// mmapMagic identifies memory-mappable files
+const mmapMagic = 0x31706d6d6e726c67
+
+// mmapHeaderSize is the size of the header, in bytes
+const mmapHeaderSize = 5 * 8
+
+// MmapWriter writes a memory-mappable file a chunk of rows at a
+// time, e.g. from a CSVStream, so the whole dataset never has to be
+// in memory.
+type MmapWriter struct {
+	file       *os.File
+	w          *bufio.Writer
+	attrs      []Attribute
+	classIndex int
+	rows       int
+}
+
+// CreateMmapFile creates (or truncates) the file at path for writing
+// Instances with the given Attributes and class index.
+func CreateMmapFile(path string, attrs []Attribute, classIndex int) (*MmapWriter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	// The header's written on Close, once the rows are known
+	if _, err := f.Seek(mmapHeaderSize, 0); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return &MmapWriter{f, bufio.NewWriter(f), attrs, classIndex, 0}, nil
+}
+
+// Write appends the rows of chunk, which must have the same number of
+// Attributes as the file.
+func (m *MmapWriter) Write(chunk *Instances) error {
+	if chunk.Cols != len(m.attrs) {
+		return fmt.Errorf("base: can't write %d column(s) to a file with %d", chunk.Cols, len(m.attrs))
+	}
+	buf := make([]byte, 8)
+	for i := 0; i < chunk.Rows; i++ {
+		for j := 0; j < chunk.Cols; j++ {
+			binary.LittleEndian.PutUint64(buf, math.Float64bits(chunk.Get(i, j)))
+			if _, err := m.w.Write(buf); err != nil {
+				return err
+			}
+		}
+	}
+	m.rows += chunk.Rows
+	return nil
+}
+
+// Close writes the Attributes and the header, and closes the file.
+func (m *MmapWriter) Close() error {
+	err := gob.NewEncoder(m.w).Encode(&m.attrs)
+	if err == nil {
+		err = m.w.Flush()
+	}
+	if err == nil {
+		header := make([]byte, mmapHeaderSize)
+		binary.LittleEndian.PutUint64(header[0:], mmapMagic)
+		binary.LittleEndian.PutUint64(header[8:], uint64(m.rows))
+		binary.LittleEndian.PutUint64(header[16:], uint64(len(m.attrs)))
+		binary.LittleEndian.PutUint64(header[24:], uint64(m.classIndex))
+		_, err = m.file.WriteAt(header, 0)
+	}
+	if closeErr := m.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// WriteMmapFile writes inst to a memory-mappable file at path.
+func WriteMmapFile(path string, inst *Instances) error {
+	w, err := CreateMmapFile(path, inst.attributes, inst.ClassIndex)
+	if err != nil {
+		return err
+	}
+	if err := w.Write(inst); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// readMmapHeader reads the header and Attributes of a memory-mappable
+// file, returning the number of rows, the Attributes and the class
+// index.
+func readMmapHeader(f *os.File) (int, []Attribute, int, error) {
+	header := make([]byte, mmapHeaderSize)
+	if _, err := f.ReadAt(header, 0); err != nil {
+		return 0, nil, 0, fmt.Errorf("base: can't read header: %s", err)
+	}
+	if binary.LittleEndian.Uint64(header[0:]) != mmapMagic {
+		return 0, nil, 0, fmt.Errorf("base: %s isn't a memory-mappable file", f.Name())
+	}
+	rows := int(binary.LittleEndian.Uint64(header[8:]))
+	cols := int(binary.LittleEndian.Uint64(header[16:]))
+	classIndex := int(binary.LittleEndian.Uint64(header[24:]))
+	var attrs []Attribute
+	r := io.NewSectionReader(f, int64(mmapHeaderSize+rows*cols*8), math.MaxInt64>>1)
+	if err := gob.NewDecoder(r).Decode(&attrs); err != nil {
+		return 0, nil, 0, fmt.Errorf("base: can't read Attributes: %s", err)
+	}
+	if len(attrs) != cols {
+		return 0, nil, 0, fmt.Errorf("base: %d Attribute(s) for %d column(s)", len(attrs), cols)
+	}
+	return rows, attrs, classIndex, nil
+}
@@ -0,0 +1,13 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package base
+
+import "fmt"
+
+// OpenMmapInstances isn't supported on this platform: it always
+// returns an error. Files written by WriteMmapFile can still be
+// moved to one where it is.
+func OpenMmapInstances(path string) (*Instances, error) {
+	return nil, fmt.Errorf("base: memory-mapped files aren't supported on this platform")
+}
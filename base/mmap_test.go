@@ -0,0 +1,113 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package base
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMmapInstances(testEnv *testing.T) {
+	inst, err := ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "golearn")
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "iris.bin")
+
+	// Write it in two chunks
+	w, err := CreateMmapFile(path, inst.attributes, inst.ClassIndex)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if err := w.Write(inst.SelectRows(seqRows(0, 100))); err != nil {
+		testEnv.Fatal(err)
+	}
+	if err := w.Write(inst.SelectRows(seqRows(100, 150))); err != nil {
+		testEnv.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		testEnv.Fatal(err)
+	}
+
+	mapped, err := OpenMmapInstances(path)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if !mapped.Equal(inst) || mapped.ClassIndex != inst.ClassIndex {
+		testEnv.Fatal(mapped)
+	}
+	if mapped.GetClassAttr().GetName() != inst.GetClassAttr().GetName() {
+		testEnv.Error(mapped.GetClassAttr())
+	}
+	if _, ok := mapped.SelectRows([]int{0}).Storage().(*DenseStorage); !ok {
+		testEnv.Error("Copies should be in memory")
+	}
+	mapped.Set(0, 0, 42)
+	if err := mapped.Storage().(*MmapStorage).Close(); err != nil {
+		testEnv.Fatal(err)
+	}
+
+	reopened, err := OpenMmapInstances(path)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	defer reopened.Storage().(*MmapStorage).Close()
+	if reopened.Get(0, 0) != 42 {
+		testEnv.Error("Set wasn't written to the file")
+	}
+
+	if _, err := OpenMmapInstances("../examples/datasets/iris_headers.csv"); err == nil {
+		testEnv.Error("Opened a CSV file")
+	}
+}
+
+func seqRows(from, to int) []int {
+	ret := make([]int, 0, to-from)
+	for i := from; i < to; i++ {
+		ret = append(ret, i)
+	}
+	return ret
+}
+
+func TestCSVStreamSpill(testEnv *testing.T) {
+	inst, err := ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "golearn")
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer SetConfig(GetConfig())
+	SetConfig(Config{MaxMemory: 2000, TempDir: dir})
+
+	stream, err := StreamCSV("../examples/datasets/iris_headers.csv", true, AutoChunkSize)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	defer stream.Close()
+	all, err := stream.ReadAll()
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	storage, ok := all.Storage().(*MmapStorage)
+	if !ok {
+		testEnv.Fatal("Expected the rows to be spilled to disk")
+	}
+	defer storage.Close()
+	if !all.Equal(inst) {
+		testEnv.Error(all)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		testEnv.Error("Expected the spilled file to be removed", files)
+	}
+}
@@ -0,0 +1,112 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package base
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"syscall"
+)
+
+// MmapStorage is a Storage backed by a memory-mapped file written by
+// WriteMmapFile or an MmapWriter. The operating system pages the
+// values in as they're read and can drop them again, so it can be
+// much larger than the available memory, but reading rows in order
+// is much faster than jumping about. Setting a value writes it to the
+// file.
+type MmapStorage struct {
+	file *os.File
+	data []byte
+	rows int
+	cols int
+}
+
+// OpenMmapInstances memory-maps the file at path, returning
+// Instances backed by an MmapStorage. Copies of them (e.g. from
+// SelectRows) are in memory. Call Close on the MmapStorage (see
+// Instances.Storage) once they're no longer needed.
+func OpenMmapInstances(path string) (*Instances, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	rows, attrs, classIndex, err := readMmapHeader(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	data, err := syscall.Mmap(int(f.Fd()), 0, mmapHeaderSize+rows*len(attrs)*8, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("base: can't map %s: %s", path, err)
+	}
+	ret := NewInstancesFromStorage(attrs, &MmapStorage{f, data, rows, len(attrs)})
+	ret.ClassIndex = classIndex
+	return ret, nil
+}
+
+// Close unmaps and closes the file. The MmapStorage can't be used
+// afterwards.
+func (m *MmapStorage) Close() error {
+	err := syscall.Munmap(m.data)
+	if closeErr := m.file.Close(); err == nil {
+		err = closeErr
+	}
+	m.data = nil
+	return err
+}
+
+// Dims returns the number of rows and columns
+func (m *MmapStorage) Dims() (int, int) {
+	return m.rows, m.cols
+}
+
+// offset returns where the given value starts in the file
+func (m *MmapStorage) offset(row, col int) int {
+	if row < 0 || row >= m.rows || col < 0 || col >= m.cols {
+		panic(fmt.Sprintf("base: (%d, %d) is outside %dx%d storage", row, col, m.rows, m.cols))
+	}
+	return mmapHeaderSize + (row*m.cols+col)*8
+}
+
+// At returns the value at the given row and column
+func (m *MmapStorage) At(row, col int) float64 {
+	off := m.offset(row, col)
+	return math.Float64frombits(binary.LittleEndian.Uint64(m.data[off:]))
+}
+
+// Set sets the value at the given row and column in the file
+func (m *MmapStorage) Set(row, col int, val float64) {
+	off := m.offset(row, col)
+	binary.LittleEndian.PutUint64(m.data[off:], math.Float64bits(val))
+}
+
+// Row returns a new copy of the given row
+func (m *MmapStorage) Row(row int) []float64 {
+	ret := make([]float64, m.cols)
+	for j := range ret {
+		ret[j] = m.At(row, j)
+	}
+	return ret
+}
+
+// NonZero returns the non-zero columns of the given row
+func (m *MmapStorage) NonZero(row int) ([]int, []float64) {
+	cols := make([]int, 0)
+	vals := make([]float64, 0)
+	for j, v := range m.Row(row) {
+		if v != 0 {
+			cols = append(cols, j)
+			vals = append(vals, v)
+		}
+	}
+	return cols, vals
+}
+
+// New returns a zeroed in-memory DenseStorage of the given size
+func (m *MmapStorage) New(rows, cols int) Storage {
+	return (&DenseStorage{}).New(rows, cols)
+}
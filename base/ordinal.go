@@ -0,0 +1,125 @@
+package base
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// OrdinalAttribute is an Attribute whose values are categories with a
+// natural order, e.g. "low" < "medium" < "high". The system
+// representation of each is its rank, from zero, and GetType is
+// Float64Type, so that learners respect the order: trees split on
+// thresholds ("at most medium") and KNN measures distances between
+// ranks, rather than treating the values as unordered categories.
+type OrdinalAttribute struct {
+	Name string
+	// Levels are the values, from the lowest to the highest
+	Levels []string
+}
+
+// NewOrdinalAttribute returns a new OrdinalAttribute with the given
+// levels, from the lowest to the highest.
+func NewOrdinalAttribute(levels ...string) *OrdinalAttribute {
+	return &OrdinalAttribute{"", levels}
+}
+
+// GetName returns this OrdinalAttribute's human-readable name.
+func (Attr *OrdinalAttribute) GetName() string {
+	return Attr.Name
+}
+
+// SetName sets this OrdinalAttribute's human-readable name.
+func (Attr *OrdinalAttribute) SetName(name string) {
+	Attr.Name = name
+}
+
+// GetType returns Float64Type, since the system representation is
+// a rank.
+func (Attr *OrdinalAttribute) GetType() int {
+	return Float64Type
+}
+
+// String returns a human-readable summary of this Attribute.
+func (Attr *OrdinalAttribute) String() string {
+	return fmt.Sprintf("OrdinalAttribute(\"%s\", %s)", Attr.Name, strings.Join(Attr.Levels, " < "))
+}
+
+// Equals tests an OrdinalAttribute for equality with another
+// Attribute.
+//
+// Returns false if the other Attribute has a different name or
+// levels, or isn't an OrdinalAttribute.
+func (Attr *OrdinalAttribute) Equals(other Attribute) bool {
+	attribute, ok := other.(*OrdinalAttribute)
+	if !ok || Attr.Name != attribute.Name || len(Attr.Levels) != len(attribute.Levels) {
+		return false
+	}
+	for i, l := range Attr.Levels {
+		if l != attribute.Levels[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// GetValues returns a copy of the Levels
+func (Attr *OrdinalAttribute) GetValues() []string {
+	ret := make([]string, len(Attr.Levels))
+	copy(ret, Attr.Levels)
+	return ret
+}
+
+// CheckSysValFromString returns the rank of rawVal, or an error if
+// it isn't one of the Levels. Missing values are valid.
+func (Attr *OrdinalAttribute) CheckSysValFromString(rawVal string) (float64, error) {
+	if IsMissingString(rawVal) {
+		return math.NaN(), nil
+	}
+	for i, l := range Attr.Levels {
+		if l == rawVal {
+			return float64(i), nil
+		}
+	}
+	return 0, fmt.Errorf("base: %q isn't one of the levels of %s (%s)", rawVal, Attr.Name, strings.Join(Attr.Levels, ", "))
+}
+
+// GetSysValFromString returns the rank of rawVal.
+//
+// IMPORTANT: This function panic()s if rawVal isn't one of the
+// Levels. Use CheckSysValFromString to confirm.
+func (Attr *OrdinalAttribute) GetSysValFromString(rawVal string) float64 {
+	ret, err := Attr.CheckSysValFromString(rawVal)
+	if err != nil {
+		panic(err)
+	}
+	return ret
+}
+
+// GetStringFromSysVal returns the level with the given rank. Values
+// between ranks, such as the thresholds trees split at, are written
+// as the levels either side, e.g. "medium|high".
+//
+// IMPORTANT: This function panic()s if val is out of range.
+func (Attr *OrdinalAttribute) GetStringFromSysVal(val float64) string {
+	if IsMissingValue(val) {
+		return MissingString
+	}
+	if val < 0 || val > float64(len(Attr.Levels)-1) {
+		panic(fmt.Sprintf("Out of range: %f in %d levels", val, len(Attr.Levels)))
+	}
+	if lower := math.Floor(val); lower != val {
+		return Attr.Levels[int(lower)] + "|" + Attr.Levels[int(lower)+1]
+	}
+	return Attr.Levels[int(val)]
+}
+
+// hasNamedValues returns true if a is numeric but its values are
+// written as names, like a TimeAttribute or an OrdinalAttribute
+func hasNamedValues(a Attribute) bool {
+	switch a.(type) {
+	case *TimeAttribute, *OrdinalAttribute:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,82 @@
+package base
+
+import (
+	"bytes"
+	"encoding/gob"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestOrdinalAttribute(testEnv *testing.T) {
+	a := NewOrdinalAttribute("low", "medium", "high")
+	a.SetName("size")
+	if a.GetType() != Float64Type {
+		testEnv.Error("Ordinal values should be numeric")
+	}
+	if a.GetSysValFromString("low") != 0 || a.GetSysValFromString("high") != 2 {
+		testEnv.Error("Levels should be ranked in order")
+	}
+	if _, err := a.CheckSysValFromString("huge"); err == nil {
+		testEnv.Error("Unknown levels should be an error")
+	}
+	if !IsMissingValue(a.GetSysValFromString(MissingString)) {
+		testEnv.Error("Missing values should stay missing")
+	}
+	if s := a.GetStringFromSysVal(1); s != "medium" {
+		testEnv.Error(s)
+	}
+	if s := a.GetStringFromSysVal(0.5); s != "low|medium" {
+		testEnv.Error(s)
+	}
+	if a.Equals(NewOrdinalAttribute("high", "medium", "low")) || !a.Equals(copyAttribute(a)) {
+		testEnv.Error("Levels should be compared in order")
+	}
+
+	var buf bytes.Buffer
+	attrs := []Attribute{a}
+	if err := gob.NewEncoder(&buf).Encode(&attrs); err != nil {
+		testEnv.Fatal(err)
+	}
+	var decoded []Attribute
+	if err := gob.NewDecoder(&buf).Decode(&decoded); err != nil {
+		testEnv.Fatal(err)
+	}
+	if !decoded[0].Equals(a) {
+		testEnv.Error(decoded[0])
+	}
+}
+
+func TestParseCSVOrdinalColumns(testEnv *testing.T) {
+	file, err := ioutil.TempFile("", "ordinal")
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString("size,weight,class\nhigh,3.5,a\nlow,1.0,b\nmedium,2.1,a\n")
+	file.Close()
+
+	options := CSVOptions{HasHeaders: true, Overrides: map[string]Attribute{
+		"size": NewOrdinalAttribute("low", "medium", "high"),
+	}}
+	inst, err := ParseCSVToInstancesWithOptions(file.Name(), options)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if inst.Get(0, 0) != 2 || inst.Get(1, 0) != 0 || inst.Get(2, 0) != 1 {
+		testEnv.Error("Ordinal values should be read as their ranks")
+	}
+	if s := inst.GetAttrStr(2, 0); s != "medium" {
+		testEnv.Error(s)
+	}
+	if name := attributeTypeName(inst.GetAttr(0)); name != "ordinal" {
+		testEnv.Error(name)
+	}
+	var out bytes.Buffer
+	if err := WriteCSV(&out, inst); err != nil {
+		testEnv.Fatal(err)
+	}
+	if out.String() != "size,weight,class\nhigh,3.5,a\nlow,1,b\nmedium,2.1,a\n" {
+		testEnv.Error(out.String())
+	}
+}
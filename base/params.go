@@ -0,0 +1,138 @@
+package base
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// isNumericKind reports whether k is an integer or floating point kind.
+func isNumericKind(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Float64 && k != reflect.Uintptr
+}
+
+// isParamKind reports whether a field of kind k is treated as a
+// parameter (as opposed to trained state, like a slice or a pointer).
+func isParamKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Bool, reflect.String, reflect.Func:
+		return true
+	}
+	return isNumericKind(k)
+}
+
+// getStructValue dereferences obj, which should be a struct or a
+// pointer to one.
+func getStructValue(obj interface{}) (reflect.Value, error) {
+	v := reflect.ValueOf(obj)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return v, fmt.Errorf("base: can't get parameters of a nil %s", v.Type())
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return v, fmt.Errorf("base: expected a struct, got %s", v.Type())
+	}
+	return v, nil
+}
+
+// getStructParams adds the parameters of the struct v to ret,
+// including those promoted from embedded structs.
+func getStructParams(v reflect.Value, ret map[string]interface{}) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			getStructParams(v.Field(i), ret)
+			continue
+		}
+		if f.PkgPath != "" || f.Anonymous {
+			continue
+		}
+		if !isParamKind(f.Type.Kind()) {
+			continue
+		}
+		ret[f.Name] = v.Field(i).Interface()
+	}
+}
+
+// GetParams returns the parameters of a Classifier (or any other
+// struct), keyed on their field names.
+//
+// Parameters are the exported fields holding a number, a string,
+// a bool or a function (such as a ClassifierFactory), including
+// those of embedded structs (such as a Params struct). Slices,
+// maps, pointers and other nested structs are assumed to hold
+// trained state and are skipped.
+//
+// IMPORTANT: this function panic()s if obj isn't a struct or a
+// pointer to one.
+func GetParams(obj interface{}) map[string]interface{} {
+	v, err := getStructValue(obj)
+	if err != nil {
+		panic(err)
+	}
+	ret := make(map[string]interface{})
+	getStructParams(v, ret)
+	return ret
+}
+
+// SetParams updates the parameters of a Classifier (or any other
+// struct), given as a pointer, from a map keyed on field names.
+// Numeric values are converted to the field's type, so a float64
+// read from a configuration file can set an int parameter.
+//
+// Returns an error (and leaves obj partially updated) if a parameter
+// doesn't exist or can't hold the given value.
+func SetParams(obj interface{}, params map[string]interface{}) error {
+	if reflect.ValueOf(obj).Kind() != reflect.Ptr {
+		return fmt.Errorf("base: SetParams needs a pointer, got %T", obj)
+	}
+	v, err := getStructValue(obj)
+	if err != nil {
+		return err
+	}
+	for name, val := range params {
+		f, ok := v.Type().FieldByName(name)
+		if !ok || f.PkgPath != "" || f.Anonymous || !isParamKind(f.Type.Kind()) {
+			return fmt.Errorf("base: %s has no parameter %s", v.Type(), name)
+		}
+		field := v.FieldByIndex(f.Index)
+		if val == nil {
+			field.Set(reflect.Zero(f.Type))
+			continue
+		}
+		newVal := reflect.ValueOf(val)
+		if newVal.Type().AssignableTo(f.Type) {
+			field.Set(newVal)
+		} else if isNumericKind(newVal.Kind()) && isNumericKind(f.Type.Kind()) {
+			field.Set(newVal.Convert(f.Type))
+		} else {
+			return fmt.Errorf("base: can't set %s.%s (%s) to %v", v.Type(), name, f.Type, val)
+		}
+	}
+	return nil
+}
+
+// CloneClassifier returns an untrained copy of c with the same
+// parameters. If c doesn't implement CloneableClassifier, a new
+// value of the same type is created and its parameters (see
+// GetParams) are copied over.
+//
+// IMPORTANT: this function panic()s if c is neither a
+// CloneableClassifier nor a pointer to a struct.
+func CloneClassifier(c Classifier) Classifier {
+	if cc, ok := c.(CloneableClassifier); ok {
+		return cc.Clone()
+	}
+	v := reflect.ValueOf(c)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("base: can't clone a %T", c))
+	}
+	ret := reflect.New(v.Elem().Type())
+	err := SetParams(ret.Interface(), GetParams(c))
+	if err != nil {
+		panic(err)
+	}
+	return ret.Interface().(Classifier)
+}
@@ -0,0 +1,85 @@
+package base
+
+import "testing"
+
+type paramsTestClassifier struct {
+	BaseClassifier
+	Neighbours int
+	Threshold  float64
+	Name       string
+	Learner    ClassifierFactory
+	Weights    []float64
+	trained    bool
+}
+
+func (p *paramsTestClassifier) Fit(on *Instances) {
+	p.trained = true
+}
+
+func (p *paramsTestClassifier) Predict(what *Instances) *Instances {
+	return what.GeneratePredictionVector()
+}
+
+func (p *paramsTestClassifier) String() string {
+	return "paramsTestClassifier"
+}
+
+func TestGetParams(testEnv *testing.T) {
+	c := &paramsTestClassifier{Neighbours: 3, Threshold: 0.5, Name: "test"}
+	params := GetParams(c)
+	if len(params) != 4 {
+		testEnv.Error(params)
+	}
+	if params["Neighbours"] != 3 || params["Threshold"] != 0.5 || params["Name"] != "test" {
+		testEnv.Error(params)
+	}
+	if _, ok := params["Weights"]; ok {
+		testEnv.Error("Slices shouldn't be parameters")
+	}
+}
+
+func TestSetParams(testEnv *testing.T) {
+	c := new(paramsTestClassifier)
+	err := SetParams(c, map[string]interface{}{
+		"Neighbours": 5.0,
+		"Threshold":  2,
+		"Name":       "updated",
+	})
+	if err != nil {
+		testEnv.Error(err)
+	}
+	if c.Neighbours != 5 || c.Threshold != 2.0 || c.Name != "updated" {
+		testEnv.Error(c)
+	}
+	if SetParams(c, map[string]interface{}{"Missing": 1}) == nil {
+		testEnv.Error("Should fail on an unknown parameter")
+	}
+	if SetParams(c, map[string]interface{}{"Weights": []float64{1}}) == nil {
+		testEnv.Error("Should fail on a non-parameter field")
+	}
+	if SetParams(c, map[string]interface{}{"Name": 1}) == nil {
+		testEnv.Error("Should fail on a type mismatch")
+	}
+	if SetParams(*c, map[string]interface{}{"Name": "x"}) == nil {
+		testEnv.Error("Should fail without a pointer")
+	}
+}
+
+func TestCloneClassifier(testEnv *testing.T) {
+	c := &paramsTestClassifier{Neighbours: 3, Threshold: 0.5, Name: "test"}
+	c.Fit(nil)
+	clone, ok := CloneClassifier(c).(*paramsTestClassifier)
+	if !ok {
+		testEnv.Error("Clone has the wrong type")
+		return
+	}
+	if clone == c {
+		testEnv.Error("Clone should be a new value")
+	}
+	if clone.Neighbours != 3 || clone.Threshold != 0.5 || clone.Name != "test" {
+		testEnv.Error(clone)
+	}
+	if clone.trained {
+		testEnv.Error("Clone shouldn't be trained")
+	}
+}
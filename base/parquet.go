@@ -0,0 +1,510 @@
+package base
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ParquetOptions says how to read a Parquet file.
+type ParquetOptions struct {
+	// Columns are the names of the columns to read, in the order of
+	// the Attributes. Empty means every column, in the file's order.
+	// Other columns aren't read from the file at all.
+	Columns []string
+	// Class is the name of the class column, or empty for the last
+	// column read
+	Class string
+}
+
+// ParquetStream reads a Parquet file one row group at a time, like a
+// CSVStream, so files which don't fit in memory can be processed.
+//
+// Columns map to Attributes by their types: numbers (including
+// decimals) become FloatAttributes, dates and timestamps
+// (including Spark's INT96 ones) TimeAttributes, and strings and
+// booleans (as "true" and "false") CategoricalAttributes. Byte arrays
+// which aren't text are read as hexadecimal strings. Nulls are
+// missing values. Only flat columns can be read: nested and repeated
+// ones, such as lists, have to be left out of Columns.
+//
+// Pages may be uncompressed, or compressed with Snappy or gzip, and
+// values PLAIN or dictionary encoded (as Spark, pandas and PyArrow
+// write them by default), in either version of data page.
+type ParquetStream struct {
+	file      io.ReaderAt
+	closer    io.Closer
+	columns   []*parquetColumn
+	attrs     []Attribute
+	class     int
+	rowGroups []thriftStruct
+	chunk     *Instances
+	rows      int
+	err       error
+}
+
+// parquetColumn describes how to read a column of a Parquet file
+type parquetColumn struct {
+	// leaf is the index of the column amongst the row group's column
+	// chunks
+	leaf       int
+	name       string
+	physical   int
+	typeLength int
+	optional   bool
+	// scale is the number of decimal places of a decimal
+	scale int
+	// unsigned is true for unsigned integers
+	unsigned bool
+	// perSecond is the number of ticks per second of a timestamp,
+	// or zero if it isn't one; dates count days
+	perSecond int64
+	date      bool
+	// text is true for byte arrays which hold strings
+	text bool
+	attr Attribute
+}
+
+// ParseParquetToInstances reads the Parquet file given by filepath
+// into Instances, as described by options (see ParquetStream).
+func ParseParquetToInstances(filepath string, options ParquetOptions) (*Instances, error) {
+	stream, err := StreamParquet(filepath, options)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+	return stream.ReadAll()
+}
+
+// StreamParquet opens the Parquet file given by filepath for reading
+// a row group at a time, as described by options.
+func StreamParquet(filepath string, options ParquetOptions) (*ParquetStream, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	stream, err := NewParquetStream(file, info.Size(), options)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	stream.closer = file
+	return stream, nil
+}
+
+// NewParquetStream reads Parquet data of the given size from r a row
+// group at a time, as described by options.
+func NewParquetStream(r io.ReaderAt, size int64, options ParquetOptions) (*ParquetStream, error) {
+	if size < 12 {
+		return nil, fmt.Errorf("base: too short to be a Parquet file")
+	}
+	tail := make([]byte, 8)
+	if _, err := r.ReadAt(tail, size-8); err != nil {
+		return nil, err
+	}
+	if string(tail[4:]) != "PAR1" {
+		return nil, fmt.Errorf("base: not a Parquet file")
+	}
+	length := int64(binary.LittleEndian.Uint32(tail))
+	if length > size-12 {
+		return nil, fmt.Errorf("base: Parquet metadata is longer than the file")
+	}
+	footer := make([]byte, length)
+	if _, err := r.ReadAt(footer, size-8-length); err != nil {
+		return nil, err
+	}
+	meta, err := (&thriftReader{buf: footer}).readStruct(0)
+	if err != nil {
+		return nil, err
+	}
+
+	columns, err := parquetColumns(meta.list(2), options.Columns)
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("base: no columns to read from the Parquet file")
+	}
+	stream := &ParquetStream{
+		file:    r,
+		columns: columns,
+		attrs:   make([]Attribute, len(columns)),
+		class:   len(columns) - 1,
+	}
+	for j, c := range columns {
+		stream.attrs[j] = c.attr
+	}
+	if options.Class != "" {
+		stream.class = -1
+		for j, c := range columns {
+			if c.name == options.Class {
+				stream.class = j
+			}
+		}
+		if stream.class == -1 {
+			return nil, fmt.Errorf("base: no column called %s is read from the Parquet file", options.Class)
+		}
+	}
+	for _, g := range meta.list(4) {
+		if g, ok := g.(thriftStruct); ok {
+			stream.rowGroups = append(stream.rowGroups, g)
+		}
+	}
+	return stream, nil
+}
+
+// parquetColumns returns the columns of the Parquet schema to read:
+// those named by names, or every one if there are none.
+func parquetColumns(schema []interface{}, names []string) ([]*parquetColumn, error) {
+	// The schema is a tree flattened depth first, whose leaves are
+	// the column chunks of each row group. Flat columns are leaves
+	// at the top level which aren't repeated.
+	flat := make(map[string]*parquetColumn)
+	nested := make(map[string]bool)
+	order := make([]string, 0)
+	leaf := 0
+	var walk func(pos int, topLevel bool) (int, error)
+	walk = func(pos int, topLevel bool) (int, error) {
+		if pos >= len(schema) {
+			return 0, fmt.Errorf("base: Parquet schema ends early")
+		}
+		e, ok := schema[pos].(thriftStruct)
+		if !ok {
+			return 0, fmt.Errorf("base: Parquet schema is corrupt")
+		}
+		name := e.string(4)
+		if children := int(e.int(5, 0)); children > 0 {
+			next := pos + 1
+			for k := 0; k < children; k++ {
+				var err error
+				if next, err = walk(next, false); err != nil {
+					return 0, err
+				}
+			}
+			if topLevel {
+				nested[name] = true
+				order = append(order, name)
+			}
+			return next, nil
+		}
+		if !topLevel || e.int(3, 0) == 2 {
+			if topLevel {
+				nested[name] = true
+				order = append(order, name)
+			}
+			leaf++
+			return pos + 1, nil
+		}
+		c, err := newParquetColumn(e)
+		if err != nil {
+			return 0, err
+		}
+		c.leaf = leaf
+		leaf++
+		flat[name] = c
+		order = append(order, name)
+		return pos + 1, nil
+	}
+	if len(schema) == 0 {
+		return nil, fmt.Errorf("base: Parquet file has no schema")
+	}
+	root, _ := schema[0].(thriftStruct)
+	next := 1
+	for k := 0; k < int(root.int(5, 0)); k++ {
+		var err error
+		if next, err = walk(next, true); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(names) == 0 {
+		names = order
+	}
+	ret := make([]*parquetColumn, len(names))
+	for j, name := range names {
+		if nested[name] {
+			return nil, fmt.Errorf("base: Parquet column %s is nested or repeated, which isn't supported", name)
+		}
+		c, ok := flat[name]
+		if !ok {
+			return nil, fmt.Errorf("base: the Parquet file has no column called %s", name)
+		}
+		ret[j] = c
+	}
+	return ret, nil
+}
+
+// newParquetColumn returns how to read the column described by the
+// schema element e
+func newParquetColumn(e thriftStruct) (*parquetColumn, error) {
+	c := &parquetColumn{
+		name:       e.string(4),
+		physical:   int(e.int(1, -1)),
+		typeLength: int(e.int(2, 0)),
+		optional:   e.int(3, 0) == 1,
+		scale:      int(e.int(7, 0)),
+	}
+	// The logical type, if there is one, supersedes the older
+	// converted type
+	converted := e.int(6, -1)
+	decimal := converted == 5
+	if logical := e.child(10); logical != nil {
+		switch {
+		case logical.child(1) != nil || logical.child(4) != nil || logical.child(12) != nil:
+			c.text = true
+		case logical.child(5) != nil:
+			decimal = true
+			c.scale = int(logical.child(5).int(1, 0))
+		case logical.child(6) != nil:
+			c.date = true
+		case logical.child(8) != nil:
+			unit := logical.child(8).child(2)
+			switch {
+			case unit.child(1) != nil:
+				c.perSecond = 1e3
+			case unit.child(2) != nil:
+				c.perSecond = 1e6
+			case unit.child(3) != nil:
+				c.perSecond = 1e9
+			}
+		case logical.child(10) != nil:
+			c.unsigned = !logical.child(10).bool(2, true)
+		}
+	} else {
+		switch converted {
+		case 0, 4, 19:
+			// UTF8, ENUM and JSON
+			c.text = true
+		case 6:
+			c.date = true
+		case 9:
+			c.perSecond = 1e3
+		case 10:
+			c.perSecond = 1e6
+		case 11, 12, 13, 14:
+			c.unsigned = true
+		}
+	}
+	if !decimal {
+		c.scale = 0
+	}
+	if c.physical == parquetInt96 {
+		c.perSecond = 1e9
+	}
+
+	switch {
+	case c.date || c.perSecond > 0:
+		if c.physical != parquetInt32 && c.physical != parquetInt64 && c.physical != parquetInt96 {
+			return nil, fmt.Errorf("base: Parquet column %s is a date or time, but not stored as an integer", c.name)
+		}
+		c.attr = NewTimeAttribute("")
+	case c.physical == parquetBoolean:
+		c.attr = NewCategoricalAttribute()
+	case c.physical == parquetByteArray || c.physical == parquetFixedLenByteArray:
+		if decimal {
+			c.attr = NewFloatAttribute()
+		} else {
+			c.attr = NewCategoricalAttribute()
+		}
+	case c.physical >= parquetInt32 && c.physical <= parquetDouble:
+		c.attr = NewFloatAttribute()
+	default:
+		return nil, fmt.Errorf("base: Parquet column %s has unknown type %d", c.name, c.physical)
+	}
+	c.attr.SetName(c.name)
+	return c, nil
+}
+
+// set sets row i of column j of inst to value k of values
+func (c *parquetColumn) set(inst *Instances, i, j int, values *parquetValues, k int) {
+	switch {
+	case c.physical == parquetBoolean:
+		inst.SetAttrStr(i, j, strconv.FormatBool(values.bools[k]))
+	case values.floats != nil:
+		inst.Set(i, j, values.floats[k])
+	case values.ints != nil:
+		v := values.ints[k]
+		switch {
+		case c.date:
+			inst.Set(i, j, float64(v)*86400)
+		case c.perSecond > 0:
+			// Split the seconds from the rest so precision isn't
+			// lost to the size of the ticks
+			seconds, ticks := v/c.perSecond, v%c.perSecond
+			inst.Set(i, j, float64(seconds)+float64(ticks)/float64(c.perSecond))
+		case c.unsigned && c.physical == parquetInt32:
+			inst.Set(i, j, float64(uint32(v))/math.Pow10(c.scale))
+		case c.unsigned:
+			inst.Set(i, j, float64(uint64(v))/math.Pow10(c.scale))
+		default:
+			inst.Set(i, j, float64(v)/math.Pow10(c.scale))
+		}
+	case c.attr.GetType() == Float64Type:
+		// A decimal, as a big-endian two's complement integer
+		b := values.bytes[k]
+		v := 0.0
+		for _, d := range b {
+			v = v*256 + float64(d)
+		}
+		if len(b) > 0 && b[0]&0x80 != 0 {
+			v -= math.Pow(2, float64(8*len(b)))
+		}
+		inst.Set(i, j, v/math.Pow10(c.scale))
+	default:
+		b := values.bytes[k]
+		if c.text || (c.physical == parquetByteArray && isPrintable(b)) {
+			inst.SetAttrStr(i, j, string(b))
+		} else {
+			inst.SetAttrStr(i, j, hex.EncodeToString(b))
+		}
+	}
+}
+
+// isPrintable returns true if b is UTF-8 text without control
+// characters (other than whitespace), so a byte array without a
+// string type can be read as one: some writers leave it out.
+func isPrintable(b []byte) bool {
+	if !utf8.Valid(b) {
+		return false
+	}
+	for _, r := range string(b) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Next reads the next row group, which is then available from
+// Instances. It returns false at the end of the file or on an error
+// (see Err).
+func (s *ParquetStream) Next() bool {
+	s.chunk = nil
+	if s.err != nil || len(s.rowGroups) == 0 {
+		return false
+	}
+	group := s.rowGroups[0]
+	s.rowGroups = s.rowGroups[1:]
+	rows := int(group.int(3, 0))
+	if rows < 0 {
+		s.err = fmt.Errorf("base: Parquet row group has %d rows", rows)
+		return false
+	}
+	chunks := group.list(1)
+	// Check the columns agree on the number of rows before making
+	// room for them
+	for _, c := range s.columns {
+		if c.leaf < len(chunks) {
+			chunk, _ := chunks[c.leaf].(thriftStruct)
+			if values := chunk.child(3).int(5, -1); values != int64(rows) {
+				s.err = fmt.Errorf("base: Parquet column %s has %d values in a row group of %d rows", c.name, values, rows)
+				return false
+			}
+		}
+	}
+	chunk := NewInstances(s.attrs, rows)
+	chunk.ClassIndex = s.class
+	for j, c := range s.columns {
+		if err := s.readColumn(chunk, j, c, chunks, rows); err != nil {
+			s.err = fmt.Errorf("base: Parquet column %s: %s", c.name, err)
+			return false
+		}
+	}
+	s.rows += rows
+	s.chunk = chunk
+	return true
+}
+
+// readColumn reads column c of a row group, whose column chunks are
+// given by chunks, into column j of inst
+func (s *ParquetStream) readColumn(inst *Instances, j int, c *parquetColumn, chunks []interface{}, rows int) error {
+	if c.leaf >= len(chunks) {
+		return fmt.Errorf("missing from the row group")
+	}
+	chunk, _ := chunks[c.leaf].(thriftStruct)
+	if chunk.string(1) != "" {
+		return fmt.Errorf("stored in another file, %s", chunk.string(1))
+	}
+	meta := chunk.child(3)
+	if meta == nil {
+		return fmt.Errorf("no metadata")
+	}
+	start := meta.int(9, 0)
+	if offset := meta.int(11, 0); offset > 0 && offset < start {
+		start = offset
+	}
+	size := meta.int(7, 0)
+	if start < 0 || size < 0 || size > math.MaxInt32 {
+		return fmt.Errorf("corrupt column chunk")
+	}
+	data := make([]byte, size)
+	if _, err := s.file.ReadAt(data, start); err != nil {
+		return err
+	}
+	pages, err := readParquetChunk(data, meta, c.physical, c.typeLength, c.optional, rows)
+	if err != nil {
+		return err
+	}
+	i := 0
+	for _, p := range pages {
+		k := 0
+		for _, defined := range p.defined {
+			if defined {
+				c.set(inst, i, j, p.values, k)
+				k++
+			} else {
+				inst.SetMissing(i, j)
+			}
+			i++
+		}
+	}
+	return nil
+}
+
+// Instances returns the row group read by the last call to Next, or
+// nil if there isn't one.
+func (s *ParquetStream) Instances() *Instances {
+	return s.chunk
+}
+
+// Attributes returns the Attributes of each row group
+func (s *ParquetStream) Attributes() []Attribute {
+	return s.attrs
+}
+
+// Rows returns the number of rows read so far
+func (s *ParquetStream) Rows() int {
+	return s.rows
+}
+
+// Err returns the first error encountered by Next, if any
+func (s *ParquetStream) Err() error {
+	return s.err
+}
+
+// Close closes the file opened by StreamParquet. Streams created with
+// NewParquetStream don't close their io.ReaderAt.
+func (s *ParquetStream) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
+
+// ReadAll reads the rest of the stream into one set of Instances,
+// spilling to a memory-mapped file if they're too big, as
+// CSVStream.ReadAll does.
+func (s *ParquetStream) ReadAll() (*Instances, error) {
+	return readAllChunks(s, s.class)
+}
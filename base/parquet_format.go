@@ -0,0 +1,674 @@
+package base
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// This file decodes the parts of the Parquet format
+// (https://github.com/apache/parquet-format) which ParquetStream
+// needs: the Thrift metadata, pages, compression and encodings of
+// flat columns.
+
+// thriftStruct is a Thrift struct read with the compact protocol: the
+// values of its fields by id, as int64s, float64s, bools, []bytes,
+// []interface{}s (lists and sets) or thriftStructs. Maps are skipped.
+type thriftStruct map[int16]interface{}
+
+// int returns the integer field id, or def if it isn't set
+func (s thriftStruct) int(id int16, def int64) int64 {
+	if v, ok := s[id].(int64); ok {
+		return v
+	}
+	return def
+}
+
+// bool returns the boolean field id, or def if it isn't set
+func (s thriftStruct) bool(id int16, def bool) bool {
+	if v, ok := s[id].(bool); ok {
+		return v
+	}
+	return def
+}
+
+// string returns the binary field id as a string
+func (s thriftStruct) string(id int16) string {
+	v, _ := s[id].([]byte)
+	return string(v)
+}
+
+// list returns the list field id
+func (s thriftStruct) list(id int16) []interface{} {
+	v, _ := s[id].([]interface{})
+	return v
+}
+
+// child returns the struct field id, or nil if it isn't set
+func (s thriftStruct) child(id int16) thriftStruct {
+	v, _ := s[id].(thriftStruct)
+	return v
+}
+
+// thriftReader reads the Thrift compact protocol from buf, starting
+// at pos
+type thriftReader struct {
+	buf []byte
+	pos int
+}
+
+// maxThriftDepth limits how deeply structs and lists may nest, so a
+// corrupt file can't exhaust the stack
+const maxThriftDepth = 32
+
+var errThriftEOF = fmt.Errorf("base: Parquet metadata ends early")
+
+func (r *thriftReader) next() (byte, error) {
+	if r.pos >= len(r.buf) {
+		return 0, errThriftEOF
+	}
+	r.pos++
+	return r.buf[r.pos-1], nil
+}
+
+func (r *thriftReader) varint() (uint64, error) {
+	v, n := binary.Uvarint(r.buf[r.pos:])
+	if n <= 0 {
+		return 0, errThriftEOF
+	}
+	r.pos += n
+	return v, nil
+}
+
+func (r *thriftReader) zigzag() (int64, error) {
+	v, err := r.varint()
+	return int64(v>>1) ^ -int64(v&1), err
+}
+
+// readStruct reads the fields of a struct up to its stop byte
+func (r *thriftReader) readStruct(depth int) (thriftStruct, error) {
+	if depth > maxThriftDepth {
+		return nil, fmt.Errorf("base: Parquet metadata nests too deeply")
+	}
+	ret := make(thriftStruct)
+	id := int16(0)
+	for {
+		header, err := r.next()
+		if err != nil {
+			return nil, err
+		}
+		if header == 0 {
+			return ret, nil
+		}
+		if delta := header >> 4; delta != 0 {
+			id += int16(delta)
+		} else {
+			v, err := r.zigzag()
+			if err != nil {
+				return nil, err
+			}
+			id = int16(v)
+		}
+		switch t := header & 0x0f; t {
+		case 1, 2:
+			// Booleans are held in the field's type
+			ret[id] = t == 1
+		default:
+			if ret[id], err = r.readValue(t, depth); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// readValue reads a value of the given compact protocol type, other
+// than a boolean field
+func (r *thriftReader) readValue(t byte, depth int) (interface{}, error) {
+	switch t {
+	case 1, 2:
+		// Booleans in lists and maps take a byte each
+		b, err := r.next()
+		return b == 1, err
+	case 3:
+		b, err := r.next()
+		return int64(int8(b)), err
+	case 4, 5, 6:
+		return r.zigzag()
+	case 7:
+		if r.pos+8 > len(r.buf) {
+			return nil, errThriftEOF
+		}
+		r.pos += 8
+		return math.Float64frombits(binary.LittleEndian.Uint64(r.buf[r.pos-8:])), nil
+	case 8:
+		n, err := r.varint()
+		if err != nil {
+			return nil, err
+		}
+		if n > uint64(len(r.buf)-r.pos) {
+			return nil, errThriftEOF
+		}
+		r.pos += int(n)
+		return r.buf[r.pos-int(n) : r.pos], nil
+	case 9, 10:
+		header, err := r.next()
+		if err != nil {
+			return nil, err
+		}
+		size := uint64(header >> 4)
+		if size == 15 {
+			if size, err = r.varint(); err != nil {
+				return nil, err
+			}
+		}
+		if size > uint64(len(r.buf)-r.pos) {
+			return nil, errThriftEOF
+		}
+		ret := make([]interface{}, size)
+		for i := range ret {
+			if ret[i], err = r.readValue(header&0x0f, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return ret, nil
+	case 11:
+		size, err := r.varint()
+		if err != nil || size == 0 {
+			return nil, err
+		}
+		types, err := r.next()
+		if err != nil {
+			return nil, err
+		}
+		for i := uint64(0); i < size; i++ {
+			if _, err := r.readValue(types>>4, depth+1); err != nil {
+				return nil, err
+			}
+			if _, err := r.readValue(types&0x0f, depth+1); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	case 12:
+		return r.readStruct(depth + 1)
+	}
+	return nil, fmt.Errorf("base: unknown Thrift type %d in Parquet metadata", t)
+}
+
+// Parquet's physical types
+const (
+	parquetBoolean = iota
+	parquetInt32
+	parquetInt64
+	parquetInt96
+	parquetFloat
+	parquetDouble
+	parquetByteArray
+	parquetFixedLenByteArray
+)
+
+// Parquet's page types
+const (
+	parquetDataPage       = 0
+	parquetDictionaryPage = 2
+	parquetDataPageV2     = 3
+)
+
+// Parquet's encodings
+const (
+	parquetPlain           = 0
+	parquetPlainDictionary = 2
+	parquetRLE             = 3
+	parquetRLEDictionary   = 8
+)
+
+// Parquet's compression codecs
+const (
+	parquetCompressionNone   = 0
+	parquetCompressionSnappy = 1
+	parquetCompressionGzip   = 2
+)
+
+// parquetJulianDayOfUnixZero is the Julian day of 1970-01-01, from
+// which INT96 timestamps count
+const parquetJulianDayOfUnixZero = 2440588
+
+// decompressParquet returns the page data compressed with codec,
+// which is size bytes uncompressed
+func decompressParquet(codec int64, data []byte, size int) ([]byte, error) {
+	switch codec {
+	case parquetCompressionNone:
+		return data, nil
+	case parquetCompressionSnappy:
+		return decodeSnappy(data)
+	case parquetCompressionGzip:
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		ret := &bytes.Buffer{}
+		if size > 0 && size <= 64*len(data) {
+			ret.Grow(size)
+		}
+		if _, err := ret.ReadFrom(r); err != nil {
+			return nil, err
+		}
+		return ret.Bytes(), nil
+	}
+	return nil, fmt.Errorf("base: unsupported Parquet compression codec %d (only uncompressed, Snappy and gzip can be read)", codec)
+}
+
+var errSnappyCorrupt = fmt.Errorf("base: corrupt Snappy data in Parquet page")
+
+// decodeSnappy decompresses a Snappy block: the uncompressed length,
+// then literals and copies of earlier output
+func decodeSnappy(src []byte) ([]byte, error) {
+	size, n := binary.Uvarint(src)
+	if n <= 0 || size > uint64(math.MaxInt32) {
+		return nil, errSnappyCorrupt
+	}
+	// Don't trust the length with more memory than the data could
+	// plausibly expand to
+	capacity := size
+	if limit := uint64(len(src)) * 8; capacity > limit {
+		capacity = limit
+	}
+	dst := make([]byte, 0, capacity)
+	for s := n; s < len(src); {
+		tag := src[s]
+		s++
+		var length, offset int
+		switch tag & 3 {
+		case 0:
+			length = int(tag>>2) + 1
+			if extra := length - 60; extra > 0 {
+				if s+extra > len(src) {
+					return nil, errSnappyCorrupt
+				}
+				length = 0
+				for k := extra - 1; k >= 0; k-- {
+					length = length<<8 | int(src[s+k])
+				}
+				length++
+				s += extra
+			}
+			if length <= 0 || s+length > len(src) {
+				return nil, errSnappyCorrupt
+			}
+			dst = append(dst, src[s:s+length]...)
+			s += length
+			continue
+		case 1:
+			if s+1 > len(src) {
+				return nil, errSnappyCorrupt
+			}
+			length = 4 + int(tag>>2&7)
+			offset = int(tag>>5)<<8 | int(src[s])
+			s++
+		case 2:
+			if s+2 > len(src) {
+				return nil, errSnappyCorrupt
+			}
+			length = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint16(src[s:]))
+			s += 2
+		case 3:
+			if s+4 > len(src) {
+				return nil, errSnappyCorrupt
+			}
+			length = 1 + int(tag>>2)
+			offset = int(binary.LittleEndian.Uint32(src[s:]))
+			s += 4
+		}
+		if offset <= 0 || offset > len(dst) {
+			return nil, errSnappyCorrupt
+		}
+		// Copies may overlap what they produce
+		for k := 0; k < length; k++ {
+			dst = append(dst, dst[len(dst)-offset])
+		}
+	}
+	if uint64(len(dst)) != size {
+		return nil, errSnappyCorrupt
+	}
+	return dst, nil
+}
+
+var errParquetPage = fmt.Errorf("base: Parquet page ends early")
+
+// decodeHybrid decodes count values of bitWidth bits from the
+// RLE/bit-packing hybrid encoding used for levels and dictionary
+// indices
+func decodeHybrid(data []byte, bitWidth, count int) ([]int, error) {
+	if bitWidth > 32 {
+		return nil, fmt.Errorf("base: Parquet bit width %d is too wide", bitWidth)
+	}
+	ret := make([]int, 0, count)
+	byteWidth := (bitWidth + 7) / 8
+	for pos := 0; len(ret) < count; {
+		header, n := binary.Uvarint(data[pos:])
+		if n <= 0 {
+			return nil, errParquetPage
+		}
+		pos += n
+		if header&1 == 0 {
+			// A run of the same value
+			if pos+byteWidth > len(data) {
+				return nil, errParquetPage
+			}
+			v := 0
+			for k := byteWidth - 1; k >= 0; k-- {
+				v = v<<8 | int(data[pos+k])
+			}
+			pos += byteWidth
+			for k := uint64(0); k < header>>1 && len(ret) < count; k++ {
+				ret = append(ret, v)
+			}
+			continue
+		}
+		// Groups of eight values packed from the lowest bit
+		if header>>1 > uint64(len(data)) {
+			return nil, errParquetPage
+		}
+		values := int(header>>1) * 8
+		end := pos + (values*bitWidth+7)/8
+		if end > len(data) {
+			return nil, errParquetPage
+		}
+		for k := 0; k < values && len(ret) < count; k++ {
+			v := 0
+			for b := 0; b < bitWidth; b++ {
+				bit := k*bitWidth + b
+				v |= int(data[pos+bit/8]>>uint(bit%8)&1) << uint(b)
+			}
+			ret = append(ret, v)
+		}
+		pos = end
+	}
+	return ret, nil
+}
+
+// parquetValues holds values of one physical type: booleans in
+// bools, integers (INT96 timestamps as nanoseconds since the Unix
+// epoch) in ints, floating point numbers in floats, and byte arrays
+// in bytes.
+type parquetValues struct {
+	bools  []bool
+	ints   []int64
+	floats []float64
+	bytes  [][]byte
+}
+
+// pick returns the values of dictionary at indices
+func (dictionary *parquetValues) pick(indices []int) (*parquetValues, error) {
+	ret := &parquetValues{}
+	size := len(dictionary.bools) + len(dictionary.ints) + len(dictionary.floats) + len(dictionary.bytes)
+	for _, i := range indices {
+		if i >= size {
+			return nil, fmt.Errorf("base: Parquet dictionary index %d out of range", i)
+		}
+		switch {
+		case dictionary.bools != nil:
+			ret.bools = append(ret.bools, dictionary.bools[i])
+		case dictionary.ints != nil:
+			ret.ints = append(ret.ints, dictionary.ints[i])
+		case dictionary.floats != nil:
+			ret.floats = append(ret.floats, dictionary.floats[i])
+		default:
+			ret.bytes = append(ret.bytes, dictionary.bytes[i])
+		}
+	}
+	return ret, nil
+}
+
+// decodePlain decodes count values of the given physical type from
+// the PLAIN encoding
+func decodePlain(physical int, typeLength int, data []byte, count int) (*parquetValues, error) {
+	if count < 0 || count > 8*len(data) {
+		return nil, errParquetPage
+	}
+	ret := &parquetValues{}
+	width := map[int]int{parquetInt32: 4, parquetInt64: 8, parquetInt96: 12, parquetFloat: 4, parquetDouble: 8}[physical]
+	if width > 0 && len(data) < count*width {
+		return nil, errParquetPage
+	}
+	switch physical {
+	case parquetBoolean:
+		if len(data) < (count+7)/8 {
+			return nil, errParquetPage
+		}
+		ret.bools = make([]bool, count)
+		for i := range ret.bools {
+			ret.bools[i] = data[i/8]>>uint(i%8)&1 == 1
+		}
+	case parquetInt32:
+		ret.ints = make([]int64, count)
+		for i := range ret.ints {
+			ret.ints[i] = int64(int32(binary.LittleEndian.Uint32(data[4*i:])))
+		}
+	case parquetInt64:
+		ret.ints = make([]int64, count)
+		for i := range ret.ints {
+			ret.ints[i] = int64(binary.LittleEndian.Uint64(data[8*i:]))
+		}
+	case parquetInt96:
+		// Nanoseconds into the day, then the Julian day
+		ret.ints = make([]int64, count)
+		for i := range ret.ints {
+			nanos := int64(binary.LittleEndian.Uint64(data[12*i:]))
+			day := int64(int32(binary.LittleEndian.Uint32(data[12*i+8:])))
+			ret.ints[i] = (day-parquetJulianDayOfUnixZero)*86400e9 + nanos
+		}
+	case parquetFloat:
+		ret.floats = make([]float64, count)
+		for i := range ret.floats {
+			ret.floats[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(data[4*i:])))
+		}
+	case parquetDouble:
+		ret.floats = make([]float64, count)
+		for i := range ret.floats {
+			ret.floats[i] = math.Float64frombits(binary.LittleEndian.Uint64(data[8*i:]))
+		}
+	case parquetByteArray:
+		ret.bytes = make([][]byte, count)
+		pos := 0
+		for i := range ret.bytes {
+			if pos+4 > len(data) {
+				return nil, errParquetPage
+			}
+			n := int(binary.LittleEndian.Uint32(data[pos:]))
+			pos += 4
+			if n < 0 || pos+n > len(data) {
+				return nil, errParquetPage
+			}
+			ret.bytes[i] = data[pos : pos+n]
+			pos += n
+		}
+	case parquetFixedLenByteArray:
+		if typeLength <= 0 || len(data) < count*typeLength {
+			return nil, errParquetPage
+		}
+		ret.bytes = make([][]byte, count)
+		for i := range ret.bytes {
+			ret.bytes[i] = data[i*typeLength : (i+1)*typeLength]
+		}
+	default:
+		return nil, fmt.Errorf("base: unknown Parquet type %d", physical)
+	}
+	return ret, nil
+}
+
+// parquetPage is the decoded content of a data page: the definition
+// level of each row, and the values of those which aren't null
+type parquetPage struct {
+	defined []bool
+	values  *parquetValues
+}
+
+// readParquetChunk decodes the pages of a flat column chunk, from its
+// (compressed) bytes, into rows values. optional says whether the
+// column has definition levels.
+func readParquetChunk(chunk []byte, meta thriftStruct, physical, typeLength int, optional bool, rows int) ([]parquetPage, error) {
+	codec := meta.int(4, 0)
+	total := int(meta.int(5, 0))
+	var dictionary *parquetValues
+	pages := make([]parquetPage, 0)
+	r := &thriftReader{buf: chunk}
+	if total != rows {
+		return nil, fmt.Errorf("base: Parquet column chunk has %d values for %d rows", total, rows)
+	}
+	for read := 0; read < total; {
+		header, err := r.readStruct(0)
+		if err != nil {
+			return nil, err
+		}
+		size := int(header.int(3, 0))
+		if size < 0 || r.pos+size > len(chunk) {
+			return nil, errParquetPage
+		}
+		data := chunk[r.pos : r.pos+size]
+		r.pos += size
+		switch header.int(1, -1) {
+		case parquetDictionaryPage:
+			d := header.child(7)
+			page, err := decompressParquet(codec, data, int(header.int(2, 0)))
+			if err != nil {
+				return nil, err
+			}
+			if dictionary, err = decodePlain(physical, typeLength, page, int(d.int(1, 0))); err != nil {
+				return nil, err
+			}
+		case parquetDataPage:
+			d := header.child(5)
+			page, err := decompressParquet(codec, data, int(header.int(2, 0)))
+			if err != nil {
+				return nil, err
+			}
+			count := int(d.int(1, 0))
+			if count < 0 || read+count > rows {
+				return nil, errParquetPage
+			}
+			defined, rest, err := parquetLevels(page, optional, count, true)
+			if err != nil {
+				return nil, err
+			}
+			p, err := decodeParquetValues(rest, d.int(2, parquetPlain), dictionary, physical, typeLength, defined)
+			if err != nil {
+				return nil, err
+			}
+			pages = append(pages, p)
+			read += count
+		case parquetDataPageV2:
+			d := header.child(8)
+			count := int(d.int(1, 0))
+			if count < 0 || read+count > rows {
+				return nil, errParquetPage
+			}
+			levels := int(d.int(5, 0)) + int(d.int(6, 0))
+			if levels < 0 || levels > len(data) {
+				return nil, errParquetPage
+			}
+			defined, _, err := parquetLevels(data[:levels], optional, count, false)
+			if err != nil {
+				return nil, err
+			}
+			values := data[levels:]
+			if d.bool(7, true) {
+				if values, err = decompressParquet(codec, values, int(header.int(2, 0))-levels); err != nil {
+					return nil, err
+				}
+			}
+			p, err := decodeParquetValues(values, d.int(4, parquetPlain), dictionary, physical, typeLength, defined)
+			if err != nil {
+				return nil, err
+			}
+			pages = append(pages, p)
+			read += count
+		}
+	}
+	return pages, nil
+}
+
+// parquetLevels returns whether each of count rows is defined, from
+// the definition levels at the start of page (prefixed by their
+// length if prefixed is true), and the rest of page
+func parquetLevels(page []byte, optional bool, count int, prefixed bool) ([]bool, []byte, error) {
+	defined := make([]bool, count)
+	if !optional {
+		for i := range defined {
+			defined[i] = true
+		}
+		return defined, page, nil
+	}
+	levels := page
+	if prefixed {
+		if len(page) < 4 {
+			return nil, nil, errParquetPage
+		}
+		n := int(binary.LittleEndian.Uint32(page))
+		if n < 0 || 4+n > len(page) {
+			return nil, nil, errParquetPage
+		}
+		levels, page = page[4:4+n], page[4+n:]
+	}
+	decoded, err := decodeHybrid(levels, 1, count)
+	if err != nil {
+		return nil, nil, err
+	}
+	for i, l := range decoded {
+		defined[i] = l == 1
+	}
+	return defined, page, nil
+}
+
+// decodeParquetValues decodes the values of the defined rows of a
+// data page with the given encoding
+func decodeParquetValues(data []byte, encoding int64, dictionary *parquetValues, physical, typeLength int, defined []bool) (parquetPage, error) {
+	count := 0
+	for _, d := range defined {
+		if d {
+			count++
+		}
+	}
+	var values *parquetValues
+	var err error
+	switch encoding {
+	case parquetPlain:
+		values, err = decodePlain(physical, typeLength, data, count)
+	case parquetPlainDictionary, parquetRLEDictionary:
+		if dictionary == nil {
+			return parquetPage{}, fmt.Errorf("base: Parquet page is dictionary encoded, but there's no dictionary")
+		}
+		if len(data) == 0 {
+			if count > 0 {
+				return parquetPage{}, errParquetPage
+			}
+			values = &parquetValues{}
+			break
+		}
+		var indices []int
+		if indices, err = decodeHybrid(data[1:], int(data[0]), count); err == nil {
+			values, err = dictionary.pick(indices)
+		}
+	case parquetRLE:
+		if physical != parquetBoolean || len(data) < 4 {
+			return parquetPage{}, fmt.Errorf("base: unsupported RLE encoding of Parquet type %d", physical)
+		}
+		var bits []int
+		if bits, err = decodeHybrid(data[4:], 1, count); err == nil {
+			values = &parquetValues{bools: make([]bool, count)}
+			for i, b := range bits {
+				values.bools[i] = b == 1
+			}
+		}
+	default:
+		return parquetPage{}, fmt.Errorf("base: unsupported Parquet encoding %d", encoding)
+	}
+	if err != nil {
+		return parquetPage{}, err
+	}
+	return parquetPage{defined, values}, nil
+}
@@ -0,0 +1,216 @@
+package base
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The Parquet files were written to the parquet-format specification:
+// iris.parquet has two Snappy-compressed row groups (of 100 and 50
+// rows), with the species dictionary encoded over several pages, and
+// mixed_types.parquet has gzip-compressed row groups of three rows,
+// with a column of each type, nulls, both versions of data page and
+// a repeated column, tags.
+
+// mixedColumns are the columns of mixed_types.parquet which can be
+// read
+var mixedColumns = []string{"id", "score", "ratio", "price", "big", "day", "ts", "legacy", "flag", "city", "amount", "blob", "label"}
+
+func TestParseParquet(testEnv *testing.T) {
+	expected, err := ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	inst, err := ParseParquetToInstances("../examples/datasets/iris.parquet", ParquetOptions{})
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if inst.Rows != 150 || inst.Cols != 5 || inst.ClassIndex != 4 || inst.GetAttr(0).GetName() != "Sepal length" {
+		testEnv.Fatal(inst)
+	}
+	for i := 0; i < inst.Rows; i++ {
+		if inst.RowStr(i) != expected.RowStr(i) {
+			testEnv.Errorf("Row %d: %s, expected %s", i, inst.RowStr(i), expected.RowStr(i))
+		}
+	}
+
+	// Streaming reads a row group at a time, and only the
+	// projected columns
+	stream, err := StreamParquet("../examples/datasets/iris.parquet", ParquetOptions{Columns: []string{"Species", "Petal width"}, Class: "Species"})
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	defer stream.Close()
+	sizes := make([]int, 0)
+	row := 0
+	for stream.Next() {
+		chunk := stream.Instances()
+		sizes = append(sizes, chunk.Rows)
+		if chunk.Cols != 2 || chunk.ClassIndex != 0 {
+			testEnv.Fatal(chunk)
+		}
+		for i := 0; i < chunk.Rows; i++ {
+			if chunk.GetAttrStr(i, 0) != expected.GetClass(row) || chunk.Get(i, 1) != expected.Get(row, 3) {
+				testEnv.Errorf("Row %d: %s", row, chunk.RowStr(i))
+			}
+			row++
+		}
+	}
+	if err := stream.Err(); err != nil {
+		testEnv.Error(err)
+	}
+	if len(sizes) != 2 || sizes[0] != 100 || stream.Rows() != 150 {
+		testEnv.Error(sizes, stream.Rows())
+	}
+}
+
+func TestParseParquetTypes(testEnv *testing.T) {
+	inst, err := ParseParquetToInstances("../examples/datasets/mixed_types.parquet", ParquetOptions{Columns: mixedColumns})
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if inst.Rows != 5 || inst.Cols != len(mixedColumns) || inst.ClassIndex != inst.Cols-1 {
+		testEnv.Fatal(inst)
+	}
+	for j, name := range mixedColumns {
+		a := inst.GetAttr(j)
+		if a.GetName() != name {
+			testEnv.Errorf("Column %d is called %s, expected %s", j, a.GetName(), name)
+		}
+		_, isTime := a.(*TimeAttribute)
+		expectTime := name == "day" || name == "ts" || name == "legacy"
+		expectFloat := strings.Contains(" id score ratio price big amount ", " "+name+" ")
+		if isTime != expectTime || (a.GetType() == Float64Type) != (expectFloat || expectTime) {
+			testEnv.Errorf("Column %s is a %T", name, a)
+		}
+	}
+
+	numbers := map[string][]float64{
+		"id":     {1, 2, 3, 4, 5},
+		"score":  {0.5, math.NaN(), -2.25, 1e10, math.NaN()},
+		"ratio":  {0.25, 0.5, 0.75, 1, -1.5},
+		"price":  {19.99, -0.05, math.NaN(), 0, 1000},
+		"big":    {1, math.Pow(2, 63) + 5, 3, math.Pow(2, 64) - 1, 42},
+		"amount": {1234.5, -0.7, 0, -214748364.8, 1},
+	}
+	times := map[string][]string{
+		"day":    {"2020-01-01T00:00:00Z", "1969-12-31T00:00:00Z", "1970-01-01T00:00:00Z", "2000-02-29T00:00:00Z", "2024-12-31T00:00:00Z"},
+		"ts":     {"2020-01-01T12:30:15.25Z", "1969-12-31T23:59:59Z", "2021-06-01T00:00:00Z", "2000-02-29T06:00:00Z", "2024-12-31T23:59:59.999Z"},
+		"legacy": {"2020-01-01T12:30:15.25Z", "1969-12-31T23:59:59Z", "2021-06-01T00:00:00Z", "2000-02-29T06:00:00Z", "2024-12-31T23:59:59.999Z"},
+	}
+	strs := map[string][]string{
+		"flag":  {"true", "false", "", "true", "true"},
+		"city":  {"Paris", "", "Oslo", "Paris", "Lima"},
+		"blob":  {"0001", "ff", "7f", "ok", "10"},
+		"label": {"yes", "no", "no", "yes", "maybe"},
+	}
+	for j, name := range mixedColumns {
+		for i := 0; i < inst.Rows; i++ {
+			switch {
+			case numbers[name] != nil:
+				want := numbers[name][i]
+				if math.IsNaN(want) {
+					if !inst.IsMissing(i, j) {
+						testEnv.Errorf("%s, row %d: expected a missing value, got %f", name, i, inst.Get(i, j))
+					}
+				} else if got := inst.Get(i, j); math.Abs(got-want) > 1e-9*math.Max(1, math.Abs(want)) {
+					testEnv.Errorf("%s, row %d: %f, expected %f", name, i, got, want)
+				}
+			case times[name] != nil:
+				want, _ := time.Parse(time.RFC3339Nano, times[name][i])
+				got := inst.GetAttr(j).(*TimeAttribute).GetUsrVal(inst.Get(i, j))
+				if d := got.Sub(want); d > time.Microsecond || d < -time.Microsecond {
+					testEnv.Errorf("%s, row %d: %s, expected %s", name, i, got, want)
+				}
+			default:
+				want := strs[name][i]
+				if want == "" {
+					if !inst.IsMissing(i, j) {
+						testEnv.Errorf("%s, row %d: expected a missing value, got %s", name, i, inst.GetAttrStr(i, j))
+					}
+				} else if got := inst.GetAttrStr(i, j); got != want {
+					testEnv.Errorf("%s, row %d: %q, expected %q", name, i, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestParseParquetErrors(testEnv *testing.T) {
+	path := "../examples/datasets/mixed_types.parquet"
+	for _, options := range []ParquetOptions{
+		// tags is a repeated column
+		{},
+		{Columns: []string{"id", "tags"}},
+		{Columns: []string{"id", "colour"}},
+		{Columns: []string{"id", "score"}, Class: "label"},
+	} {
+		if _, err := ParseParquetToInstances(path, options); err == nil {
+			testEnv.Errorf("%+v should be an error", options)
+		}
+	}
+	if _, err := ParseParquetToInstances("../examples/datasets/iris_headers.csv", ParquetOptions{}); err == nil {
+		testEnv.Error("A CSV file isn't a Parquet file")
+	}
+
+	// Corrupt files are errors, not panics
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	for _, size := range []int{0, 4, 100, len(data) - 1} {
+		if _, err := readParquetBytes(data[:size]); err == nil {
+			testEnv.Errorf("Expected an error for the first %d bytes", size)
+		}
+	}
+	for i := range data {
+		for _, b := range []byte{0x00, 0xff, data[i] ^ 0x10} {
+			corrupt := append([]byte(nil), data...)
+			corrupt[i] = b
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						testEnv.Fatalf("Setting byte %d to %x: panic: %v", i, b, r)
+					}
+				}()
+				readParquetBytes(corrupt)
+			}()
+		}
+	}
+}
+
+// readParquetBytes reads the readable columns of mixed_types.parquet
+// from data
+func readParquetBytes(data []byte) (*Instances, error) {
+	stream, err := NewParquetStream(bytes.NewReader(data), int64(len(data)), ParquetOptions{Columns: mixedColumns})
+	if err != nil {
+		return nil, err
+	}
+	return stream.ReadAll()
+}
+
+func TestDecodeSnappy(testEnv *testing.T) {
+	// A literal, then copies with one, two and four byte offsets,
+	// the first overlapping its own output
+	src := []byte{
+		26, // 26 bytes uncompressed
+		2 << 2, 'a', 'b', 'c',
+		1 | (6-4)<<2, 3, // 6 bytes from 3 back
+		2 | (5-1)<<2, 9, 0, // 5 bytes from 9 back
+		3 | (12-1)<<2, 14, 0, 0, 0, // 12 bytes from 14 back
+	}
+	dst, err := decodeSnappy(src)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if string(dst) != "abcabcabcabcab"+"abcabcabcabc" {
+		testEnv.Error(string(dst))
+	}
+	if _, err := decodeSnappy([]byte{10, 1 | 2<<2, 5}); err == nil {
+		testEnv.Error("Expected an error for a copy from before the start")
+	}
+}
@@ -0,0 +1,41 @@
+package base
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Provenance records where an Attribute produced by a filter came
+// from, so that results (e.g. importances) can be reported in terms
+// of the original Attributes.
+type Provenance struct {
+	// Attribute is the name of the output Attribute
+	Attribute string
+	// Sources are the names of the Attributes it was derived from
+	Sources []string
+	// Transform describes what was done to them, e.g. "binned[3]",
+	// or is empty if the Attribute was passed through unchanged
+	Transform string
+}
+
+// PassThrough returns the Provenance of an Attribute which a filter
+// leaves alone.
+func PassThrough(name string) Provenance {
+	return Provenance{name, []string{name}, ""}
+}
+
+// String returns a summary such as "age→binned[3]", or
+// "x,y→nystroem→feature0" if the name changed.
+func (p Provenance) String() string {
+	var buffer bytes.Buffer
+	buffer.WriteString(strings.Join(p.Sources, ","))
+	if p.Transform != "" {
+		buffer.WriteString("→")
+		buffer.WriteString(p.Transform)
+	}
+	if len(p.Sources) != 1 || p.Sources[0] != p.Attribute {
+		buffer.WriteString("→")
+		buffer.WriteString(p.Attribute)
+	}
+	return buffer.String()
+}
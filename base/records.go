@@ -0,0 +1,220 @@
+package base
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// The functions in this file convert between Instances and the
+// record formats used to score new data, CSV rows and JSON objects,
+// so that every way of making predictions reads values the same way.
+// They take the Attributes of the training data, and unlike
+// SetAttrStr they never add values to a CategoricalAttribute:
+// values which weren't seen in training are an error.
+
+// parseRecordValue returns the system representation of the raw
+// value val of Attribute a. Missing values are read as NaN.
+func parseRecordValue(a Attribute, val string) (float64, error) {
+	if IsMissingString(val) {
+		return math.NaN(), nil
+	}
+	switch attr := a.(type) {
+	case checkedAttribute:
+		return attr.CheckSysValFromString(val)
+	case *CategoricalAttribute:
+		sysVal := attr.GetSysVal(val)
+		if sysVal == -1 {
+			return 0, fmt.Errorf("unknown value %q for attribute %s", val, a.GetName())
+		}
+		return sysVal, nil
+	}
+	return a.GetSysValFromString(val), nil
+}
+
+// RecordsToInstances converts rows of raw values (e.g. read from a
+// CSV file) into Instances with the given Attributes, one value per
+// Attribute in the same order. The class Attribute, which must be
+// last, can be left out of every row, e.g. when scoring new data.
+func RecordsToInstances(attrs []Attribute, records [][]string) (*Instances, error) {
+	ret := NewInstances(attrs, len(records))
+	for i, record := range records {
+		if len(record) != len(attrs) && len(record) != len(attrs)-1 {
+			return nil, fmt.Errorf("base: row %d: expected %d values, got %d", i, len(attrs), len(record))
+		}
+		for j, val := range record {
+			sysVal, err := parseRecordValue(attrs[j], val)
+			if err != nil {
+				return nil, fmt.Errorf("base: row %d: %s", i, err)
+			}
+			ret.Set(i, j, sysVal)
+		}
+	}
+	return ret, nil
+}
+
+// jsonValueString returns the raw string value of a decoded JSON
+// value: strings as they are, numbers in their shortest form and
+// booleans as "true" or "false". It returns false for anything else.
+func jsonValueString(v interface{}) (string, bool) {
+	switch val := v.(type) {
+	case string:
+		return val, true
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64), true
+	case json.Number:
+		return val.String(), true
+	case bool:
+		return strconv.FormatBool(val), true
+	}
+	return "", false
+}
+
+// ObjectsToInstances converts JSON objects, whose keys are Attribute
+// names, into Instances with the given Attributes. Values can be
+// strings, numbers or booleans, and values which are left out or null
+// are missing.
+func ObjectsToInstances(attrs []Attribute, objects []map[string]interface{}) (*Instances, error) {
+	ret := NewInstances(attrs, len(objects))
+	for i, obj := range objects {
+		for j, a := range attrs {
+			raw, ok := obj[a.GetName()]
+			if !ok || raw == nil {
+				ret.SetMissing(i, j)
+				continue
+			}
+			val, ok := jsonValueString(raw)
+			if !ok {
+				return nil, fmt.Errorf("base: object %d: unsupported value %v for attribute %s", i, raw, a.GetName())
+			}
+			sysVal, err := parseRecordValue(a, val)
+			if err != nil {
+				return nil, fmt.Errorf("base: object %d: %s", i, err)
+			}
+			ret.Set(i, j, sysVal)
+		}
+	}
+	return ret, nil
+}
+
+// DecodeJSONInstances reads JSON objects from r, either as a single
+// array or one after another (e.g. one per line), and converts them
+// with ObjectsToInstances.
+func DecodeJSONInstances(attrs []Attribute, r io.Reader) (*Instances, error) {
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	objects := make([]map[string]interface{}, 0)
+	for {
+		var v interface{}
+		if err := decoder.Decode(&v); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		switch val := v.(type) {
+		case map[string]interface{}:
+			objects = append(objects, val)
+		case []interface{}:
+			for _, o := range val {
+				obj, ok := o.(map[string]interface{})
+				if !ok {
+					return nil, fmt.Errorf("base: expected a JSON object, got %v", o)
+				}
+				objects = append(objects, obj)
+			}
+		default:
+			return nil, fmt.Errorf("base: expected a JSON object, got %v", v)
+		}
+	}
+	return ObjectsToInstances(attrs, objects)
+}
+
+// InstancesToObjects converts each row of inst into a JSON object
+// keyed by Attribute name, the inverse of ObjectsToInstances. Values
+// of FloatAttributes are numbers, timestamps are strings, and missing
+// values are null.
+func InstancesToObjects(inst *Instances) []map[string]interface{} {
+	ret := make([]map[string]interface{}, inst.Rows)
+	for i := range ret {
+		ret[i] = make(map[string]interface{})
+		for j := 0; j < inst.Cols; j++ {
+			a := inst.GetAttr(j)
+			val := inst.Get(i, j)
+			switch {
+			case IsMissingValue(val):
+				ret[i][a.GetName()] = nil
+			case a.GetType() != Float64Type:
+				ret[i][a.GetName()] = inst.GetAttrStr(i, j)
+			case hasNamedValues(a):
+				ret[i][a.GetName()] = inst.GetAttrStr(i, j)
+			default:
+				ret[i][a.GetName()] = val
+			}
+		}
+	}
+	return ret
+}
+
+// PredictCSV reads rows of CSV data from r (see RecordsToInstances),
+// predicts their classes with cls and writes them to w, one per
+// line. If hasHeaders is set, the first row is skipped.
+func PredictCSV(cls Classifier, attrs []Attribute, r io.Reader, w io.Writer, hasHeaders bool) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return err
+	}
+	if hasHeaders && len(records) > 0 {
+		records = records[1:]
+	}
+	inst, err := RecordsToInstances(attrs, records)
+	if err != nil {
+		return err
+	}
+	predictions := cls.Predict(inst)
+	writer := csv.NewWriter(w)
+	for i := 0; i < predictions.Rows; i++ {
+		if err := writer.Write([]string{predictions.GetClass(i)}); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// predictionObject is the JSON form of a prediction
+type predictionObject struct {
+	Class         string             `json:"class"`
+	Probabilities map[string]float64 `json:"probabilities,omitempty"`
+}
+
+// PredictJSON reads JSON objects from r (see DecodeJSONInstances),
+// predicts their classes with cls and writes them to w as one JSON
+// object per line with a "class" key. If cls is a
+// ProbabilisticClassifier, the objects also have "probabilities".
+func PredictJSON(cls Classifier, attrs []Attribute, r io.Reader, w io.Writer) error {
+	inst, err := DecodeJSONInstances(attrs, r)
+	if err != nil {
+		return err
+	}
+	predictions := cls.Predict(inst)
+	var probabilities []map[string]float64
+	if p, ok := cls.(ProbabilisticClassifier); ok {
+		probabilities = p.PredictProba(inst)
+	}
+	encoder := json.NewEncoder(w)
+	for i := 0; i < predictions.Rows; i++ {
+		obj := predictionObject{Class: predictions.GetClass(i)}
+		if probabilities != nil {
+			obj.Probabilities = probabilities[i]
+		}
+		if err := encoder.Encode(obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
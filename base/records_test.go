@@ -0,0 +1,111 @@
+package base
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+// petalClassifier is a trivial Classifier for the iris dataset which
+// only looks at petal length.
+type petalClassifier struct{}
+
+func (p *petalClassifier) Fit(on *Instances) {}
+
+func (p *petalClassifier) Predict(what *Instances) *Instances {
+	ret := what.GeneratePredictionVector()
+	for i := 0; i < what.Rows; i++ {
+		if what.Get(i, 2) < 2.5 {
+			ret.SetAttrStr(i, 0, "Iris-setosa")
+		} else {
+			ret.SetAttrStr(i, 0, "Iris-versicolor")
+		}
+	}
+	return ret
+}
+
+func (p *petalClassifier) PredictProba(what *Instances) []map[string]float64 {
+	predictions := p.Predict(what)
+	ret := make([]map[string]float64, what.Rows)
+	for i := range ret {
+		ret[i] = map[string]float64{predictions.GetClass(i): 1.0}
+	}
+	return ret
+}
+
+func (p *petalClassifier) String() string {
+	return "petalClassifier"
+}
+
+func getIrisAttributes(testEnv *testing.T) []Attribute {
+	inst, err := ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	attrs := make([]Attribute, inst.Cols)
+	for j := range attrs {
+		attrs[j] = inst.GetAttr(j)
+	}
+	return attrs
+}
+
+func TestRecordsToInstances(testEnv *testing.T) {
+	attrs := getIrisAttributes(testEnv)
+	inst, err := RecordsToInstances(attrs, [][]string{
+		{"5.1", "3.5", "1.4", "0.2", "Iris-setosa"},
+		{"7.0", "", "4.7", "1.4"},
+	})
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if inst.GetClass(0) != "Iris-setosa" || inst.Get(1, 0) != 7.0 || !math.IsNaN(inst.Get(1, 1)) {
+		testEnv.Error(inst)
+	}
+	if _, err := RecordsToInstances(attrs, [][]string{{"5.1", "3.5", "1.4", "0.2", "Iris-unknown"}}); err == nil {
+		testEnv.Error("Expected an error for an unknown class")
+	}
+	if _, err := RecordsToInstances(attrs, [][]string{{"5.1", "3.5"}}); err == nil {
+		testEnv.Error("Expected an error for a short row")
+	}
+}
+
+func TestPredictCSVAndJSON(testEnv *testing.T) {
+	attrs := getIrisAttributes(testEnv)
+	cls := new(petalClassifier)
+
+	var out bytes.Buffer
+	csvData := "Sepal length,Sepal width,Petal length,Petal width\n5.1,3.5,1.4,0.2\n7.0,3.2,4.7,1.4\n"
+	if err := PredictCSV(cls, attrs, strings.NewReader(csvData), &out, true); err != nil {
+		testEnv.Fatal(err)
+	}
+	if out.String() != "Iris-setosa\nIris-versicolor\n" {
+		testEnv.Error(out.String())
+	}
+
+	// The same rows as JSON are scored identically
+	out.Reset()
+	jsonData := `{"Sepal length": 5.1, "Sepal width": 3.5, "Petal length": 1.4, "Petal width": 0.2}
+{"Sepal length": "7.0", "Sepal width": 3.2, "Petal length": 4.7, "Petal width": null}`
+	if err := PredictJSON(cls, attrs, strings.NewReader(jsonData), &out); err != nil {
+		testEnv.Fatal(err)
+	}
+	expected := `{"class":"Iris-setosa","probabilities":{"Iris-setosa":1}}
+{"class":"Iris-versicolor","probabilities":{"Iris-versicolor":1}}
+`
+	if out.String() != expected {
+		testEnv.Error(out.String())
+	}
+
+	inst, err := DecodeJSONInstances(attrs, strings.NewReader("["+strings.Replace(jsonData, "\n", ",", 1)+"]"))
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	objects := InstancesToObjects(inst)
+	if !math.IsNaN(inst.Get(1, 3)) {
+		testEnv.Error(inst.RowStr(1))
+	}
+	if len(objects) != 2 || objects[0]["Petal length"] != 1.4 || objects[1]["Sepal width"] != 3.2 {
+		testEnv.Error(objects)
+	}
+}
@@ -0,0 +1,68 @@
+package base
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var (
+	registryLock sync.RWMutex
+	registry     = make(map[string]ClassifierFactory)
+)
+
+// RegisterClassifier makes a Classifier available by name to
+// NewClassifier. The factory should return a Classifier with its
+// default parameters. Packages register their Classifiers when
+// they're imported, so importing the golearn package makes all
+// of the built-in ones available.
+//
+// IMPORTANT: this function panic()s if name is already registered.
+func RegisterClassifier(name string, factory ClassifierFactory) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	if _, ok := registry[name]; ok {
+		panic(fmt.Sprintf("base: Classifier %s is already registered", name))
+	}
+	registry[name] = factory
+}
+
+// NewClassifier creates a registered Classifier by name, and then
+// overrides its default parameters with params (see SetParams),
+// which may be nil. This allows Classifiers to be constructed from
+// configuration files.
+func NewClassifier(name string, params map[string]interface{}) (Classifier, error) {
+	registryLock.RLock()
+	factory, ok := registry[name]
+	registryLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("base: unknown Classifier %s", name)
+	}
+	ret := factory()
+	if len(params) > 0 {
+		if err := SetParams(ret, params); err != nil {
+			return nil, err
+		}
+	}
+	if v, ok := ret.(interface {
+		Validate() error
+	}); ok {
+		if err := v.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return ret, nil
+}
+
+// ClassifierNames returns the names of the registered Classifiers,
+// in alphabetical order.
+func ClassifierNames() []string {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	ret := make([]string, 0, len(registry))
+	for name := range registry {
+		ret = append(ret, name)
+	}
+	sort.Strings(ret)
+	return ret
+}
@@ -0,0 +1,55 @@
+package base
+
+import (
+	"sync"
+	"testing"
+)
+
+var registerParamsTest sync.Once
+
+func TestClassifierRegistry(testEnv *testing.T) {
+	registerParamsTest.Do(func() {
+		RegisterClassifier("paramstest", func() Classifier {
+			return &paramsTestClassifier{Neighbours: 2}
+		})
+	})
+	found := false
+	for _, name := range ClassifierNames() {
+		if name == "paramstest" {
+			found = true
+		}
+	}
+	if !found {
+		testEnv.Error(ClassifierNames())
+	}
+
+	c, err := NewClassifier("paramstest", nil)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if c.(*paramsTestClassifier).Neighbours != 2 {
+		testEnv.Error(c)
+	}
+	c, err = NewClassifier("paramstest", map[string]interface{}{"Neighbours": 4.0})
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if c.(*paramsTestClassifier).Neighbours != 4 {
+		testEnv.Error(c)
+	}
+	if _, err := NewClassifier("paramstest", map[string]interface{}{"Missing": 1}); err == nil {
+		testEnv.Error("Should fail on an unknown parameter")
+	}
+	if _, err := NewClassifier("missing", nil); err == nil {
+		testEnv.Error("Should fail on an unknown Classifier")
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			testEnv.Error("Registering a name twice should panic")
+		}
+	}()
+	RegisterClassifier("paramstest", func() Classifier {
+		return new(paramsTestClassifier)
+	})
+}
@@ -0,0 +1,136 @@
+package base
+
+import "fmt"
+
+// AttributeRole says what a column of a set of Instances is for.
+// Every column is a feature unless its role is set otherwise, and
+// learners only train on features, so identifiers and other columns
+// can be kept alongside the data without being dropped.
+type AttributeRole int
+
+const (
+	// FeatureRole is for columns which are trained on.
+	FeatureRole AttributeRole = iota
+	// ClassRole is for columns which could be the class. The one
+	// at ClassIndex is the class, and the others aren't trained on,
+	// so switching between them (see SetClassAttribute) doesn't
+	// leak one target into the features for another.
+	ClassRole
+	// IDRole is for identifiers, which are carried through (e.g.
+	// to label predictions) but not trained on.
+	IDRole
+	// IgnoredRole is for columns which are neither trained on nor
+	// needed.
+	IgnoredRole
+)
+
+// String returns the name of the AttributeRole
+func (r AttributeRole) String() string {
+	switch r {
+	case FeatureRole:
+		return "feature"
+	case ClassRole:
+		return "class"
+	case IDRole:
+		return "id"
+	case IgnoredRole:
+		return "ignored"
+	}
+	return fmt.Sprintf("AttributeRole(%d)", int(r))
+}
+
+// GetRole returns the role of the Attribute at index col. The
+// class Attribute always has ClassRole.
+func (inst *Instances) GetRole(col int) AttributeRole {
+	if col == inst.ClassIndex {
+		return ClassRole
+	}
+	if inst.roles == nil {
+		return FeatureRole
+	}
+	return inst.roles[col]
+}
+
+// SetRole sets the role of the Attribute at index col. Setting the
+// role of the class Attribute only takes effect once another
+// Attribute becomes the class.
+func (inst *Instances) SetRole(col int, role AttributeRole) {
+	if inst.roles == nil {
+		if role == FeatureRole {
+			return
+		}
+		inst.roles = make([]AttributeRole, inst.Cols)
+	}
+	inst.roles[col] = role
+}
+
+// SetAttributeRole sets the role of Attribute a.
+//
+// IMPORTANT: this function panic()s if a isn't one of the Attributes.
+func (inst *Instances) SetAttributeRole(a Attribute, role AttributeRole) {
+	col := inst.GetAttrIndex(a)
+	if col == -1 {
+		panic("Invalid attribute")
+	}
+	inst.SetRole(col, role)
+}
+
+// SetClassAttribute makes a the class Attribute. The previous class
+// Attribute keeps ClassRole, so it isn't trained on unless its role
+// is set back to FeatureRole.
+func (inst *Instances) SetClassAttribute(a Attribute) error {
+	col := inst.GetAttrIndex(a)
+	if col == -1 {
+		return fmt.Errorf("base: %s isn't one of the Attributes", a)
+	}
+	if col == inst.ClassIndex {
+		return nil
+	}
+	inst.SetRole(inst.ClassIndex, ClassRole)
+	inst.SetRole(col, ClassRole)
+	inst.ClassIndex = col
+	return nil
+}
+
+// IsFeature returns true if the Attribute at index col is trained on:
+// it isn't the class and its role is FeatureRole.
+func (inst *Instances) IsFeature(col int) bool {
+	return inst.GetRole(col) == FeatureRole
+}
+
+// FeatureIndices returns the indices of the Attributes which are
+// trained on, in order.
+func (inst *Instances) FeatureIndices() []int {
+	ret := make([]int, 0, inst.Cols)
+	for j := 0; j < inst.Cols; j++ {
+		if inst.IsFeature(j) {
+			ret = append(ret, j)
+		}
+	}
+	return ret
+}
+
+// ColumnsWithRole returns the indices of the Attributes with the
+// given role, in order.
+func (inst *Instances) ColumnsWithRole(role AttributeRole) []int {
+	ret := make([]int, 0)
+	for j := 0; j < inst.Cols; j++ {
+		if inst.GetRole(j) == role {
+			ret = append(ret, j)
+		}
+	}
+	return ret
+}
+
+// copyRoles gives inst the roles of the Attributes of src which it
+// shares
+func (inst *Instances) copyRoles(src *Instances) {
+	if src.roles == nil {
+		return
+	}
+	for j := 0; j < inst.Cols; j++ {
+		if k := src.GetAttrIndex(inst.attributes[j]); k != -1 && src.roles[k] != FeatureRole {
+			inst.SetRole(j, src.roles[k])
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package base
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRoles(testEnv *testing.T) {
+	inst, err := ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if features := inst.FeatureIndices(); len(features) != 4 || inst.GetRole(4) != ClassRole {
+		testEnv.Fatal(features)
+	}
+	inst.SetRole(0, IDRole)
+	inst.SetAttributeRole(inst.GetAttr(1), IgnoredRole)
+	if features := inst.FeatureIndices(); len(features) != 2 || features[0] != 2 {
+		testEnv.Error(features)
+	}
+	if row := inst.GetRowVectorWithoutClass(0); len(row) != 2 || row[0] != 1.4 {
+		testEnv.Error(row)
+	}
+	if schema := inst.Schema(); !strings.Contains(schema, "id") || !strings.Contains(schema, "ignored") {
+		testEnv.Error(schema)
+	}
+
+	// Roles are kept by derived Instances
+	rows := inst.SelectRows([]int{0, 1})
+	if rows.GetRole(0) != IDRole || rows.IsFeature(1) {
+		testEnv.Error(rows.FeatureIndices())
+	}
+	sub := inst.SelectAttributes([]Attribute{inst.GetAttr(1), inst.GetAttr(3), inst.GetAttr(4)})
+	if sub.GetRole(0) != IgnoredRole || !sub.IsFeature(1) {
+		testEnv.Error(sub.FeatureIndices())
+	}
+
+	// Switching the class keeps the old one out of the features
+	if err := inst.SetClassAttribute(inst.GetAttr(3)); err != nil {
+		testEnv.Fatal(err)
+	}
+	if inst.ClassIndex != 3 || inst.IsFeature(4) || inst.GetRole(4) != ClassRole {
+		testEnv.Error(inst.FeatureIndices())
+	}
+	if candidates := inst.ColumnsWithRole(ClassRole); len(candidates) != 2 {
+		testEnv.Error(candidates)
+	}
+	if features := inst.FeatureIndices(); len(features) != 1 || features[0] != 2 {
+		testEnv.Error(features)
+	}
+	if err := inst.SetClassAttribute(NewFloatAttribute()); err == nil {
+		testEnv.Error("Expected an error for an unknown Attribute")
+	}
+}
+
+func TestGetAttrIndices(testEnv *testing.T) {
+	inst, err := ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	attrs := []Attribute{inst.GetAttr(3), inst.GetAttr(1), inst.GetAttr(4)}
+	if cols := inst.GetAttrIndices(attrs); len(cols) != 3 || cols[0] != 3 || cols[1] != 1 || cols[2] != 4 {
+		testEnv.Error(cols)
+	}
+	defer func() {
+		if recover() == nil {
+			testEnv.Error("No panic for a missing Attribute")
+		}
+	}()
+	inst.SelectAttributes(attrs).GetAttrIndices([]Attribute{inst.GetAttr(0)})
+}
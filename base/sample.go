@@ -0,0 +1,275 @@
+package base
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// The functions in this file draw random samples. They take the
+// *rand.Rand to use, so that samples can be reproduced by seeding it,
+// and return row indices (for SelectRows) so that the same samples
+// can be used by bagging, mini-batch training and anything else.
+
+// Reservoir keeps a uniform random sample of a fixed number of rows
+// from Instances which arrive a chunk at a time (e.g. from a
+// CSVStream), without keeping the rest.
+type Reservoir struct {
+	size   int
+	rng    *rand.Rand
+	sample *Instances
+	seen   int
+}
+
+// NewReservoir returns an empty Reservoir which keeps size rows.
+func NewReservoir(size int, rng *rand.Rand) *Reservoir {
+	return &Reservoir{size, rng, nil, 0}
+}
+
+// Add offers each row of chunk to the sample. Every chunk must have
+// the same Attributes.
+func (r *Reservoir) Add(chunk *Instances) {
+	for i := 0; i < chunk.Rows; i++ {
+		r.addRow(chunk, i)
+	}
+}
+
+// addRow offers the given row of chunk to the sample
+func (r *Reservoir) addRow(chunk *Instances, row int) {
+	if r.sample == nil {
+		r.sample = chunk.newInstancesLike(chunk.attributes, r.size)
+		r.sample.ClassIndex = chunk.ClassIndex
+		r.sample.copyRoles(chunk)
+	}
+	dst := r.seen
+	if r.seen >= r.size {
+		dst = r.rng.Intn(r.seen + 1)
+	}
+	r.seen++
+	if dst >= r.size {
+		return
+	}
+	for j := 0; j < chunk.Cols; j++ {
+		r.sample.Set(dst, j, chunk.Get(row, j))
+	}
+}
+
+// AddStream offers every row that's left in stream to the sample,
+// returning the stream's error, if any.
+func (r *Reservoir) AddStream(stream *CSVStream) error {
+	for stream.Next() {
+		r.Add(stream.Instances())
+	}
+	return stream.Err()
+}
+
+// AddChannel offers the rows of each chunk received from chunks to
+// the sample, until it's closed.
+func (r *Reservoir) AddChannel(chunks <-chan *Instances) {
+	for chunk := range chunks {
+		r.Add(chunk)
+	}
+}
+
+// Seen returns the number of rows offered to the Reservoir
+func (r *Reservoir) Seen() int {
+	return r.seen
+}
+
+// Instances returns the sample, which has fewer than size rows if
+// fewer than that have been seen, or nil if nothing has been added.
+func (r *Reservoir) Instances() *Instances {
+	if r.sample == nil || r.seen >= r.size {
+		return r.sample
+	}
+	rows := make([]int, r.seen)
+	for i := range rows {
+		rows[i] = i
+	}
+	return r.sample.SelectRows(rows)
+}
+
+// StratifiedReservoir keeps a uniform random sample of up to a fixed
+// number of rows of each class from Instances which arrive a chunk at
+// a time, so that rare classes are represented however common the
+// others are.
+type StratifiedReservoir struct {
+	size int
+	rng  *rand.Rand
+	// classes holds a Reservoir for each class, in the order they
+	// were first seen
+	classes    []string
+	reservoirs map[string]*Reservoir
+	seen       int
+}
+
+// NewStratifiedReservoir returns an empty StratifiedReservoir which
+// keeps size rows of each class.
+func NewStratifiedReservoir(size int, rng *rand.Rand) *StratifiedReservoir {
+	return &StratifiedReservoir{size, rng, make([]string, 0), make(map[string]*Reservoir), 0}
+}
+
+// Add offers each row of chunk to the sample of its class. Every
+// chunk must have the same Attributes.
+func (r *StratifiedReservoir) Add(chunk *Instances) {
+	for i := 0; i < chunk.Rows; i++ {
+		class := chunk.GetClass(i)
+		reservoir, ok := r.reservoirs[class]
+		if !ok {
+			reservoir = NewReservoir(r.size, r.rng)
+			r.reservoirs[class] = reservoir
+			r.classes = append(r.classes, class)
+		}
+		reservoir.addRow(chunk, i)
+		r.seen++
+	}
+}
+
+// AddStream offers every row that's left in stream to the sample,
+// returning the stream's error, if any.
+func (r *StratifiedReservoir) AddStream(stream *CSVStream) error {
+	for stream.Next() {
+		r.Add(stream.Instances())
+	}
+	return stream.Err()
+}
+
+// AddChannel offers the rows of each chunk received from chunks to
+// the sample, until it's closed.
+func (r *StratifiedReservoir) AddChannel(chunks <-chan *Instances) {
+	for chunk := range chunks {
+		r.Add(chunk)
+	}
+}
+
+// Seen returns the number of rows offered to the StratifiedReservoir
+func (r *StratifiedReservoir) Seen() int {
+	return r.seen
+}
+
+// SeenClass returns the number of rows of the given class offered to
+// the StratifiedReservoir
+func (r *StratifiedReservoir) SeenClass(class string) int {
+	if reservoir, ok := r.reservoirs[class]; ok {
+		return reservoir.Seen()
+	}
+	return 0
+}
+
+// Instances returns the samples of every class, in the order the
+// classes were first seen, or nil if nothing has been added.
+func (r *StratifiedReservoir) Instances() *Instances {
+	if len(r.classes) == 0 {
+		return nil
+	}
+	rest := make([]*Instances, 0, len(r.classes)-1)
+	for _, class := range r.classes[1:] {
+		rest = append(rest, r.reservoirs[class].Instances())
+	}
+	ret, err := AppendInstances(r.reservoirs[r.classes[0]].Instances(), rest...)
+	if err != nil {
+		// The samples all have the same Attributes
+		panic(err)
+	}
+	return ret
+}
+
+// WeightedSampleWithoutReplacement returns size distinct indices
+// into weights, where the chance of picking each one is proportional
+// to its weight (Efraimidis and Spirakis' method). Indices with zero
+// weight are only picked once the others run out.
+//
+// IMPORTANT: this function panic()s if size is larger than
+// len(weights) or a weight is negative.
+func WeightedSampleWithoutReplacement(weights []float64, size int, rng *rand.Rand) []int {
+	if size > len(weights) {
+		panic(fmt.Sprintf("base: can't draw %d of %d rows", size, len(weights)))
+	}
+	keys := make([]float64, len(weights))
+	order := make([]int, len(weights))
+	for i, w := range weights {
+		if w < 0 {
+			panic(fmt.Sprintf("base: negative weight %g", w))
+		}
+		// Compares log(u)/w rather than u^(1/w), which underflows
+		keys[i] = math.Log(rng.Float64()) / w
+		order[i] = i
+	}
+	sort.Sort(&byKey{order, keys})
+	return order[:size]
+}
+
+// byKey sorts indices by decreasing key
+type byKey struct {
+	indices []int
+	keys    []float64
+}
+
+func (b *byKey) Len() int {
+	return len(b.indices)
+}
+
+func (b *byKey) Swap(i, j int) {
+	b.indices[i], b.indices[j] = b.indices[j], b.indices[i]
+}
+
+func (b *byKey) Less(i, j int) bool {
+	return b.keys[b.indices[i]] > b.keys[b.indices[j]]
+}
+
+// StratifiedSample returns the indices of size distinct rows of inst
+// drawn so that each class makes up the same proportion of them as it
+// does of inst (as nearly as whole numbers of rows allow). Rows with
+// a missing class are never drawn.
+//
+// IMPORTANT: this function panic()s if size is larger than the
+// number of rows with a class.
+func StratifiedSample(inst *Instances, size int, rng *rand.Rand) []int {
+	byClass := make(map[string][]int)
+	classes := make([]string, 0)
+	total := 0
+	for i := 0; i < inst.Rows; i++ {
+		if inst.IsMissing(i, inst.ClassIndex) {
+			continue
+		}
+		c := inst.GetClass(i)
+		if _, ok := byClass[c]; !ok {
+			classes = append(classes, c)
+		}
+		byClass[c] = append(byClass[c], i)
+		total++
+	}
+	if size > total {
+		panic(fmt.Sprintf("base: can't draw %d of %d rows", size, total))
+	}
+	sort.Strings(classes)
+
+	// Each class gets the whole part of its share, and the rows left
+	// over go to the classes with the largest remainders
+	counts := make([]int, len(classes))
+	remainders := make([]float64, len(classes))
+	order := make([]int, len(classes))
+	assigned := 0
+	for k, c := range classes {
+		share := float64(size) * float64(len(byClass[c])) / float64(total)
+		counts[k] = int(share)
+		remainders[k] = share - float64(counts[k])
+		order[k] = k
+		assigned += counts[k]
+	}
+	sort.Stable(&byKey{order, remainders})
+	for k := 0; assigned < size; k++ {
+		counts[order[k]]++
+		assigned++
+	}
+
+	ret := make([]int, 0, size)
+	for k, c := range classes {
+		rows := byClass[c]
+		for _, p := range rng.Perm(len(rows))[:counts[k]] {
+			ret = append(ret, rows[p])
+		}
+	}
+	return ret
+}
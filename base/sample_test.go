@@ -0,0 +1,181 @@
+package base
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestSampleWithoutReplacement(testEnv *testing.T) {
+	inst, err := ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Error(err)
+		return
+	}
+	sample := inst.SampleWithoutReplacement(inst.Rows)
+	if sample.Rows != inst.Rows {
+		testEnv.Error(sample.Rows)
+	}
+	dist := sample.CountClassValues()
+	for c := range dist {
+		if dist[c] != 50 {
+			testEnv.Error(dist)
+		}
+	}
+
+	sample = inst.SampleWithoutReplacement(10)
+	if sample.Rows != 10 {
+		testEnv.Error(sample.Rows)
+	}
+}
+
+func TestReservoir(testEnv *testing.T) {
+	inst, err := ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	stream, err := StreamCSV("../examples/datasets/iris_headers.csv", true, 40)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	defer stream.Close()
+	reservoir := NewReservoir(30, rand.New(rand.NewSource(1)))
+	for stream.Next() {
+		reservoir.Add(stream.Instances())
+	}
+	sample := reservoir.Instances()
+	if reservoir.Seen() != 150 || sample.Rows != 30 {
+		testEnv.Fatal(reservoir.Seen(), sample.Rows)
+	}
+	// Every class should be represented, and every row should come
+	// from the dataset
+	if dist := sample.GetClassDistribution(); len(dist) != 3 {
+		testEnv.Error(dist)
+	}
+	rows := make(map[string]bool)
+	for i := 0; i < inst.Rows; i++ {
+		rows[inst.RowStr(i)] = true
+	}
+	for i := 0; i < sample.Rows; i++ {
+		if !rows[sample.RowStr(i)] {
+			testEnv.Error(sample.RowStr(i))
+		}
+	}
+
+	small := NewReservoir(300, rand.New(rand.NewSource(1)))
+	small.Add(inst)
+	if small.Instances().Rows != 150 {
+		testEnv.Error(small.Instances().Rows)
+	}
+}
+
+func TestStratifiedReservoir(testEnv *testing.T) {
+	inst, err := ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	// Make virginica rare
+	rare := inst.Filter(func(row int) bool {
+		return inst.GetClass(row) != "Iris-virginica" || row%10 == 0
+	})
+	chunks := make(chan *Instances)
+	go func() {
+		for start := 0; start < rare.Rows; start += 20 {
+			rows := make([]int, 0, 20)
+			for i := start; i < start+20 && i < rare.Rows; i++ {
+				rows = append(rows, i)
+			}
+			chunks <- rare.SelectRows(rows)
+		}
+		close(chunks)
+	}()
+	reservoir := NewStratifiedReservoir(10, rand.New(rand.NewSource(1)))
+	reservoir.AddChannel(chunks)
+	sample := reservoir.Instances()
+	if reservoir.Seen() != rare.Rows || reservoir.SeenClass("Iris-virginica") != 5 {
+		testEnv.Fatal(reservoir.Seen(), reservoir.SeenClass("Iris-virginica"))
+	}
+	dist := sample.GetClassDistribution()
+	if sample.Rows != 25 || dist["Iris-setosa"] != 10 || dist["Iris-virginica"] != 5 {
+		testEnv.Error(dist)
+	}
+
+	stream, err := StreamCSV("../examples/datasets/iris_headers.csv", true, 40)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	defer stream.Close()
+	uniform := NewReservoir(30, rand.New(rand.NewSource(1)))
+	if err := uniform.AddStream(stream); err != nil || uniform.Seen() != 150 {
+		testEnv.Error(err, uniform.Seen())
+	}
+}
+
+func TestWeightedSampleWithoutReplacement(testEnv *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	weights := []float64{0, 1, 1, 8}
+	counts := make([]int, len(weights))
+	for trial := 0; trial < 1000; trial++ {
+		sample := WeightedSampleWithoutReplacement(weights, 2, rng)
+		if len(sample) != 2 || sample[0] == sample[1] {
+			testEnv.Fatal(sample)
+		}
+		for _, i := range sample {
+			counts[i]++
+		}
+	}
+	if counts[0] != 0 || counts[3] < 900 || counts[1] < 400 || counts[2] < 400 {
+		testEnv.Error(counts)
+	}
+	if sample := WeightedSampleWithoutReplacement(weights, 4, rng); sample[3] != 0 {
+		testEnv.Error(sample)
+	}
+}
+
+func TestStratifiedSample(testEnv *testing.T) {
+	inst, err := ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	// Drop a virginica row so the shares aren't whole numbers
+	inst = inst.SelectRows(inst.CompleteRows()[:149])
+	rows := StratifiedSample(inst, 31, rand.New(rand.NewSource(1)))
+	if len(rows) != 31 {
+		testEnv.Fatal(rows)
+	}
+	seen := make(map[int]bool)
+	for _, r := range rows {
+		if seen[r] {
+			testEnv.Error("Duplicate row", r)
+		}
+		seen[r] = true
+	}
+	dist := inst.SelectRows(rows).GetClassDistribution()
+	if dist["Iris-setosa"]+dist["Iris-versicolor"] != 21 || dist["Iris-virginica"] != 10 {
+		testEnv.Error(dist)
+	}
+}
+
+func TestSeededShuffleAndSplit(testEnv *testing.T) {
+	first, err := ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	second, err := ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	first.ShuffleWithSeed(7)
+	second.ShuffleWithSeed(7)
+	if !first.Equal(second) {
+		testEnv.Error("The same seed shuffled differently")
+	}
+
+	train1, test1 := InstancesTrainTestSplitWithSeed(first, 0.3, 11)
+	train2, test2 := InstancesTrainTestSplitWithSeed(second, 0.3, 11)
+	if !train1.Equal(train2) || !test1.Equal(test2) {
+		testEnv.Error("The same seed split differently")
+	}
+	if train1.Rows+test1.Rows != 150 || test1.Rows == 0 || train1.Rows == 0 {
+		testEnv.Errorf("%d training and %d test rows", train1.Rows, test1.Rows)
+	}
+}
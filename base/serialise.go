@@ -0,0 +1,93 @@
+package base
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+)
+
+func init() {
+	gob.Register(&FloatAttribute{})
+	gob.Register(&CategoricalAttribute{})
+	gob.Register(&TimeAttribute{})
+	gob.Register(&StringAttribute{})
+	gob.Register(&HashedAttribute{})
+	gob.Register(&OrdinalAttribute{})
+}
+
+// WriteClassifier serialises a trained Classifier to w, in gob
+// format. The Classifier's concrete type, and those of any
+// Classifiers or Attributes it contains, must be registered with
+// gob.Register: the built-in ones are when their package is imported.
+func WriteClassifier(w io.Writer, c Classifier) error {
+	if err := gob.NewEncoder(w).Encode(&c); err != nil {
+		return fmt.Errorf("base: can't serialise %T: %s", c, err)
+	}
+	return nil
+}
+
+// ReadClassifier deserialises a Classifier written by WriteClassifier.
+func ReadClassifier(r io.Reader) (Classifier, error) {
+	var c Classifier
+	if err := gob.NewDecoder(r).Decode(&c); err != nil {
+		return nil, fmt.Errorf("base: can't deserialise Classifier: %s", err)
+	}
+	return c, nil
+}
+
+// SaveClassifier serialises a trained Classifier to the file at
+// path (see WriteClassifier).
+func SaveClassifier(path string, c Classifier) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := WriteClassifier(f, c); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadClassifier deserialises a Classifier from the file at path
+// (see SaveClassifier). It can predict on new Instances straight
+// away, provided they have the same Attributes it was trained on.
+func LoadClassifier(path string) (Classifier, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadClassifier(f)
+}
+
+// categoricalAttributeGob is how a CategoricalAttribute
+// is serialised.
+type categoricalAttributeGob struct {
+	Name   string
+	Values []string
+}
+
+// GobEncode serialises the CategoricalAttribute including its
+// values, which map system representations to strings.
+func (Attr *CategoricalAttribute) GobEncode() ([]byte, error) {
+	var b bytes.Buffer
+	err := gob.NewEncoder(&b).Encode(categoricalAttributeGob{Attr.Name, Attr.values})
+	return b.Bytes(), err
+}
+
+// GobDecode deserialises a CategoricalAttribute
+func (Attr *CategoricalAttribute) GobDecode(data []byte) error {
+	var g categoricalAttributeGob
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&g); err != nil {
+		return err
+	}
+	Attr.Name = g.Name
+	Attr.values = g.Values
+	if Attr.values == nil {
+		Attr.values = make([]string, 0)
+	}
+	return nil
+}
@@ -59,8 +59,9 @@ func TestSortDesc(testEnv *testing.T) {
 		testEnv.Error(inst1)
 	}
 	if !inst2.Equal(inst1) {
-		inst1.storage.Sub(inst1.storage, inst2.storage)
-		testEnv.Error(inst1.storage)
+		diff := inst1.storage.(*DenseStorage).Dense
+		diff.Sub(diff, inst2.storage.(*DenseStorage).Dense)
+		testEnv.Error(diff)
 		testEnv.Error("Instances don't match")
 		testEnv.Error(inst1)
 		testEnv.Error(inst2)
@@ -97,8 +98,9 @@ func TestSortAsc(testEnv *testing.T) {
 	}
 
 	if !inst2.Equal(inst) {
-		inst.storage.Sub(inst.storage, inst2.storage)
-		testEnv.Error(inst.storage)
+		diff := inst.storage.(*DenseStorage).Dense
+		diff.Sub(diff, inst2.storage.(*DenseStorage).Dense)
+		testEnv.Error(diff)
 		testEnv.Error("Instances don't match")
 		testEnv.Error(inst)
 		testEnv.Error(inst2)
@@ -0,0 +1,232 @@
+//go:build go1.8
+// +build go1.8
+
+package base
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// sqlNumericTypes and sqlTimeTypes are the database type names (see
+// sql.ColumnType.DatabaseTypeName) of columns which become
+// FloatAttributes and TimeAttributes, when the driver doesn't say
+// what Go type they scan into.
+var (
+	sqlNumericTypes = []string{"INT", "INTEGER", "BIGINT", "SMALLINT", "TINYINT", "MEDIUMINT",
+		"SERIAL", "BIGSERIAL", "REAL", "FLOAT", "FLOAT4", "FLOAT8", "DOUBLE", "NUMERIC", "DECIMAL",
+		"INT2", "INT4", "INT8"}
+	sqlTimeTypes = []string{"DATE", "DATETIME", "TIMESTAMP", "TIMESTAMPTZ"}
+)
+
+// InstancesFromSQL runs query on db and returns the result as
+// Instances, with one Attribute per column of the result, named after
+// it. Numeric columns become FloatAttributes, dates and times
+// TimeAttributes, and anything else (including booleans, as "true" and
+// "false") CategoricalAttributes; if the driver doesn't report the
+// types, they're inferred from the first batch of rows. NULLs are
+// missing values.
+//
+// The class Attribute is the column called classColumn, or the last
+// column if classColumn is empty. Rows are read in batches of
+// Config.ChunkRows.
+//
+// InstancesFromSQL needs the column types added to database/sql in
+// Go 1.8, and isn't built by older releases.
+func InstancesFromSQL(db *sql.DB, query string, classColumn string) (*Instances, error) {
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	classIndex := len(types) - 1
+	if classColumn != "" {
+		classIndex = -1
+		for j, t := range types {
+			if t.Name() == classColumn {
+				classIndex = j
+			}
+		}
+		if classIndex == -1 {
+			return nil, fmt.Errorf("base: the query has no column called %s", classColumn)
+		}
+	}
+
+	batchSize := GetConfig().ChunkRows(len(types))
+	var attrs []Attribute
+	batches := make([]*Instances, 0)
+	for {
+		batch, err := readSQLBatch(rows, len(types), batchSize)
+		if err != nil {
+			return nil, err
+		}
+		if attrs == nil {
+			attrs = sqlAttributes(types, batch)
+		}
+		if len(batch) == 0 {
+			break
+		}
+		inst := NewInstances(attrs, len(batch))
+		inst.ClassIndex = classIndex
+		for i, values := range batch {
+			for j, v := range values {
+				if err := setSQLValue(inst, i, j, v); err != nil {
+					return nil, fmt.Errorf("base: column %s: %s", types[j].Name(), err)
+				}
+			}
+		}
+		batches = append(batches, inst)
+		if len(batch) < batchSize {
+			break
+		}
+	}
+
+	if len(batches) == 0 {
+		ret := NewInstances(attrs, 0)
+		ret.ClassIndex = classIndex
+		return ret, nil
+	}
+	return AppendInstances(batches[0], batches[1:]...)
+}
+
+// readSQLBatch scans up to batchSize rows of cols values each
+func readSQLBatch(rows *sql.Rows, cols, batchSize int) ([][]interface{}, error) {
+	ret := make([][]interface{}, 0, batchSize)
+	for len(ret) < batchSize && rows.Next() {
+		values := make([]interface{}, cols)
+		pointers := make([]interface{}, cols)
+		for j := range values {
+			pointers[j] = &values[j]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return nil, err
+		}
+		ret = append(ret, values)
+	}
+	return ret, rows.Err()
+}
+
+// sqlAttributes returns an Attribute for each column, going by the
+// Go type it scans into, then its database type name, then the first
+// value in batch which isn't NULL.
+func sqlAttributes(types []*sql.ColumnType, batch [][]interface{}) []Attribute {
+	attrs := make([]Attribute, len(types))
+	for j, t := range types {
+		var kind reflect.Type
+		if scan := t.ScanType(); scan != nil && scan.Kind() != reflect.Interface {
+			kind = scan
+		}
+		for _, values := range batch {
+			if kind != nil {
+				break
+			}
+			if values[j] != nil {
+				kind = reflect.TypeOf(values[j])
+			}
+		}
+		switch {
+		case isSQLNumeric(kind, t.DatabaseTypeName()):
+			attrs[j] = NewFloatAttribute()
+		case kind == reflect.TypeOf(time.Time{}) || sqlTypeIn(t.DatabaseTypeName(), sqlTimeTypes):
+			attrs[j] = NewTimeAttribute("")
+		default:
+			attrs[j] = NewCategoricalAttribute()
+		}
+		attrs[j].SetName(t.Name())
+	}
+	return attrs
+}
+
+// isSQLNumeric returns true if values of Go type kind, or the given
+// database type, are numbers
+func isSQLNumeric(kind reflect.Type, databaseType string) bool {
+	if kind != nil {
+		for kind.Kind() == reflect.Ptr {
+			kind = kind.Elem()
+		}
+		switch kind {
+		case reflect.TypeOf(sql.NullInt64{}), reflect.TypeOf(sql.NullFloat64{}):
+			return true
+		}
+		k := kind.Kind()
+		if k >= reflect.Int && k <= reflect.Float64 && k != reflect.Uintptr {
+			return true
+		}
+	}
+	return sqlTypeIn(databaseType, sqlNumericTypes)
+}
+
+// sqlTypeIn returns true if the database type name (ignoring any
+// size, e.g. "DECIMAL(10,2)") is one of names
+func sqlTypeIn(databaseType string, names []string) bool {
+	databaseType = strings.ToUpper(databaseType)
+	if i := strings.Index(databaseType, "("); i != -1 {
+		databaseType = databaseType[:i]
+	}
+	for _, n := range names {
+		if databaseType == n {
+			return true
+		}
+	}
+	return false
+}
+
+// setSQLValue sets the value of the given row and column of inst to
+// v, as scanned from the database
+func setSQLValue(inst *Instances, row, col int, v interface{}) error {
+	attr := inst.GetAttr(col)
+	switch v := v.(type) {
+	case nil:
+		inst.SetMissing(row, col)
+	case int64:
+		if attr.GetType() == Float64Type {
+			inst.Set(row, col, float64(v))
+		} else {
+			inst.SetAttrStr(row, col, strconv.FormatInt(v, 10))
+		}
+	case float64:
+		if attr.GetType() == Float64Type {
+			inst.Set(row, col, v)
+		} else {
+			inst.SetAttrStr(row, col, strconv.FormatFloat(v, 'g', -1, 64))
+		}
+	case bool:
+		inst.SetAttrStr(row, col, strconv.FormatBool(v))
+	case time.Time:
+		if t, ok := attr.(*TimeAttribute); ok {
+			inst.Set(row, col, t.GetSysVal(v))
+		} else {
+			inst.SetAttrStr(row, col, v.Format(time.RFC3339))
+		}
+	case []byte:
+		return setSQLString(inst, row, col, string(v))
+	case string:
+		return setSQLString(inst, row, col, v)
+	default:
+		return fmt.Errorf("unsupported value %v (%T)", v, v)
+	}
+	return nil
+}
+
+// setSQLString sets a value which the driver returned as text,
+// checking that it suits the Attribute
+func setSQLString(inst *Instances, row, col int, v string) error {
+	if c, ok := inst.GetAttr(col).(checkedAttribute); ok {
+		val, err := c.CheckSysValFromString(v)
+		if err != nil {
+			return err
+		}
+		inst.Set(row, col, val)
+		return nil
+	}
+	inst.SetAttrStr(row, col, v)
+	return nil
+}
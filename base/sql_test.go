@@ -0,0 +1,153 @@
+//go:build go1.8
+// +build go1.8
+
+package base
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// fakeDriver serves the same result set for every query: a numeric
+// column, a text one and a timestamp, then the class, as described by
+// fakeColumns
+type fakeDriver struct{}
+
+var fakeColumns = []struct {
+	name, databaseType string
+	scanType           reflect.Type
+}{
+	{"age", "INTEGER", reflect.TypeOf(int64(0))},
+	{"city", "VARCHAR", reflect.TypeOf("")},
+	{"joined", "", nil},
+	{"churned", "BOOLEAN", reflect.TypeOf(false)},
+}
+
+var fakeValues = [][]driver.Value{
+	{int64(31), []byte("Leeds"), time.Date(2015, 3, 1, 0, 0, 0, 0, time.UTC), false},
+	{nil, "York", nil, true},
+	{int64(52), nil, time.Date(2016, 12, 25, 0, 0, 0, 0, time.UTC), false},
+}
+
+func init() {
+	sql.Register("golearnfake", fakeDriver{})
+}
+
+func (fakeDriver) Open(name string) (driver.Conn, error) {
+	return fakeConn{}, nil
+}
+
+type fakeConn struct{}
+
+func (fakeConn) Prepare(query string) (driver.Stmt, error) {
+	if query != "SELECT * FROM customers" {
+		return nil, fmt.Errorf("no such table")
+	}
+	return fakeStmt{}, nil
+}
+
+func (fakeConn) Close() error {
+	return nil
+}
+
+func (fakeConn) Begin() (driver.Tx, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+type fakeStmt struct{}
+
+func (fakeStmt) Close() error {
+	return nil
+}
+
+func (fakeStmt) NumInput() int {
+	return 0
+}
+
+func (fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, fmt.Errorf("not supported")
+}
+
+func (fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return &fakeRows{}, nil
+}
+
+type fakeRows struct {
+	row int
+}
+
+func (r *fakeRows) Columns() []string {
+	ret := make([]string, len(fakeColumns))
+	for j, c := range fakeColumns {
+		ret[j] = c.name
+	}
+	return ret
+}
+
+func (r *fakeRows) ColumnTypeDatabaseTypeName(j int) string {
+	return fakeColumns[j].databaseType
+}
+
+func (r *fakeRows) ColumnTypeScanType(j int) reflect.Type {
+	if fakeColumns[j].scanType == nil {
+		return reflect.TypeOf(new(interface{})).Elem()
+	}
+	return fakeColumns[j].scanType
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.row == len(fakeValues) {
+		return io.EOF
+	}
+	copy(dest, fakeValues[r.row])
+	r.row++
+	return nil
+}
+
+func TestInstancesFromSQL(testEnv *testing.T) {
+	db, err := sql.Open("golearnfake", "")
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	defer db.Close()
+	// Read a row at a time
+	defer SetConfig(GetConfig())
+	SetConfig(Config{MaxMemory: 32})
+
+	inst, err := InstancesFromSQL(db, "SELECT * FROM customers", "churned")
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if inst.Rows != 3 || inst.Cols != 4 || inst.ClassIndex != 3 {
+		testEnv.Fatal(inst)
+	}
+	if inst.GetAttr(0).GetType() != Float64Type || inst.Get(2, 0) != 52 || !inst.IsMissing(1, 0) {
+		testEnv.Error(inst.GetAttr(0), inst.RowStr(1), inst.RowStr(2))
+	}
+	if inst.GetAttr(1).GetType() != CategoricalType || inst.GetAttrStr(0, 1) != "Leeds" || !inst.IsMissing(2, 1) {
+		testEnv.Error(inst.GetAttr(1), inst.RowStr(0), inst.RowStr(2))
+	}
+	// The type of the timestamps is inferred from their values
+	if _, ok := inst.GetAttr(2).(*TimeAttribute); !ok || inst.GetAttrStr(2, 2) != "2016-12-25T00:00:00Z" {
+		testEnv.Error(inst.GetAttr(2), inst.RowStr(2))
+	}
+	if inst.GetClass(1) != "true" || inst.GetClass(2) != "false" {
+		testEnv.Error(inst.RowStr(1), inst.RowStr(2))
+	}
+
+	if _, err := InstancesFromSQL(db, "SELECT * FROM customers", "nosuchcolumn"); err == nil {
+		testEnv.Error("Expected an error for a missing class column")
+	}
+	if _, err := InstancesFromSQL(db, "SELECT * FROM nosuchtable", ""); err == nil {
+		testEnv.Error("Expected an error for a failed query")
+	}
+}
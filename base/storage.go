@@ -0,0 +1,238 @@
+package base
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// Storage holds the system representation of the values of a set
+// of Instances. Instances are dense by default, but can be backed by
+// any Storage, e.g. a SparseStorage for data which is mostly zero.
+type Storage interface {
+	// Dims returns the number of rows and columns.
+	Dims() (rows, cols int)
+	// At returns the value at the given row and column.
+	At(row, col int) float64
+	// Set sets the value at the given row and column.
+	Set(row, col int, val float64)
+	// Row returns the values of a row. Depending on the Storage,
+	// it may share memory with it, so it mustn't be modified.
+	Row(row int) []float64
+	// NonZero returns the columns of a row which aren't zero, in
+	// increasing order, and their values.
+	NonZero(row int) (cols []int, vals []float64)
+	// New returns an empty Storage of the same kind.
+	New(rows, cols int) Storage
+}
+
+// DenseStorage is a Storage which keeps every value in a
+// mat64.Dense.
+type DenseStorage struct {
+	*mat64.Dense
+}
+
+// NewDenseStorage returns a DenseStorage around mat.
+func NewDenseStorage(mat *mat64.Dense) *DenseStorage {
+	return &DenseStorage{mat}
+}
+
+// Row returns a view of the given row
+func (d *DenseStorage) Row(row int) []float64 {
+	return d.Dense.RowView(row)
+}
+
+// NonZero returns the non-zero columns of the given row
+func (d *DenseStorage) NonZero(row int) ([]int, []float64) {
+	cols := make([]int, 0)
+	vals := make([]float64, 0)
+	for j, v := range d.Dense.RowView(row) {
+		if v != 0 {
+			cols = append(cols, j)
+			vals = append(vals, v)
+		}
+	}
+	return cols, vals
+}
+
+// New returns a zeroed DenseStorage of the given size
+func (d *DenseStorage) New(rows, cols int) Storage {
+	return NewDenseStorage(mat64.NewDense(rows, cols, make([]float64, rows*cols)))
+}
+
+// SparseStorage is a Storage which only keeps the values which
+// aren't zero, so its size depends on the number of those rather
+// than on the number of rows times columns. It's suited to data such
+// as bag-of-words or one-hot encoded features, where most values are
+// zero. Looking up a value takes time proportional to the logarithm
+// of the number of non-zero values in its row.
+type SparseStorage struct {
+	rows int
+	cols int
+	// indices holds the non-zero columns of each row, in order
+	indices [][]int
+	values  [][]float64
+}
+
+// NewSparseStorage returns an all-zero SparseStorage of the given size.
+func NewSparseStorage(rows, cols int) *SparseStorage {
+	return &SparseStorage{
+		rows,
+		cols,
+		make([][]int, rows),
+		make([][]float64, rows),
+	}
+}
+
+// Dims returns the number of rows and columns
+func (s *SparseStorage) Dims() (int, int) {
+	return s.rows, s.cols
+}
+
+// find returns the position of col in the non-zero columns of row,
+// or where it would be inserted
+func (s *SparseStorage) find(row, col int) int {
+	return sort.SearchInts(s.indices[row], col)
+}
+
+// At returns the value at the given row and column
+func (s *SparseStorage) At(row, col int) float64 {
+	s.checkBounds(row, col)
+	i := s.find(row, col)
+	if i < len(s.indices[row]) && s.indices[row][i] == col {
+		return s.values[row][i]
+	}
+	return 0
+}
+
+// Set sets the value at the given row and column, removing it
+// from storage if it's zero
+func (s *SparseStorage) Set(row, col int, val float64) {
+	s.checkBounds(row, col)
+	i := s.find(row, col)
+	present := i < len(s.indices[row]) && s.indices[row][i] == col
+	switch {
+	case present && val != 0:
+		s.values[row][i] = val
+	case present:
+		s.indices[row] = append(s.indices[row][:i], s.indices[row][i+1:]...)
+		s.values[row] = append(s.values[row][:i], s.values[row][i+1:]...)
+	case val != 0:
+		s.indices[row] = append(s.indices[row], 0)
+		s.values[row] = append(s.values[row], 0)
+		copy(s.indices[row][i+1:], s.indices[row][i:])
+		copy(s.values[row][i+1:], s.values[row][i:])
+		s.indices[row][i] = col
+		s.values[row][i] = val
+	}
+}
+
+// checkBounds panic()s if row or col is out of range
+func (s *SparseStorage) checkBounds(row, col int) {
+	if row < 0 || row >= s.rows || col < 0 || col >= s.cols {
+		panic(fmt.Sprintf("base: (%d, %d) is outside %dx%d storage", row, col, s.rows, s.cols))
+	}
+}
+
+// Row returns a new dense copy of the given row
+func (s *SparseStorage) Row(row int) []float64 {
+	ret := make([]float64, s.cols)
+	for i, j := range s.indices[row] {
+		ret[j] = s.values[row][i]
+	}
+	return ret
+}
+
+// NonZero returns the non-zero columns of the given row. They
+// share memory with the SparseStorage, so mustn't be modified.
+func (s *SparseStorage) NonZero(row int) ([]int, []float64) {
+	return s.indices[row], s.values[row]
+}
+
+// New returns an all-zero SparseStorage of the given size
+func (s *SparseStorage) New(rows, cols int) Storage {
+	return NewSparseStorage(rows, cols)
+}
+
+// Count returns the number of non-zero values
+func (s *SparseStorage) Count() int {
+	ret := 0
+	for _, idx := range s.indices {
+		ret += len(idx)
+	}
+	return ret
+}
+
+// Float32Storage is a dense Storage which keeps every value as a
+// float32, so it takes half the memory of a DenseStorage. Values are
+// rounded to float32 precision when they're set: about seven
+// significant digits, so integers (and category indices) are exact
+// up to 2^24, but TimeAttribute values lose their seconds. Missing
+// values stay NaN.
+type Float32Storage struct {
+	rows int
+	cols int
+	data []float32
+}
+
+// NewFloat32Storage returns an all-zero Float32Storage of the given
+// size.
+func NewFloat32Storage(rows, cols int) *Float32Storage {
+	return &Float32Storage{rows, cols, make([]float32, rows*cols)}
+}
+
+// Dims returns the number of rows and columns
+func (f *Float32Storage) Dims() (int, int) {
+	return f.rows, f.cols
+}
+
+// index returns the position of the given row and column in data,
+// panic()ing if they're out of range
+func (f *Float32Storage) index(row, col int) int {
+	if row < 0 || row >= f.rows || col < 0 || col >= f.cols {
+		panic(fmt.Sprintf("base: (%d, %d) is outside %dx%d storage", row, col, f.rows, f.cols))
+	}
+	return row*f.cols + col
+}
+
+// At returns the value at the given row and column
+func (f *Float32Storage) At(row, col int) float64 {
+	return float64(f.data[f.index(row, col)])
+}
+
+// Set rounds val to a float32 and stores it at the given row and
+// column
+func (f *Float32Storage) Set(row, col int, val float64) {
+	f.data[f.index(row, col)] = float32(val)
+}
+
+// Row returns a new float64 copy of the given row
+func (f *Float32Storage) Row(row int) []float64 {
+	if row < 0 || row >= f.rows {
+		panic(fmt.Sprintf("base: row %d is outside %dx%d storage", row, f.rows, f.cols))
+	}
+	ret := make([]float64, f.cols)
+	for j, v := range f.data[row*f.cols : (row+1)*f.cols] {
+		ret[j] = float64(v)
+	}
+	return ret
+}
+
+// NonZero returns the non-zero columns of the given row
+func (f *Float32Storage) NonZero(row int) ([]int, []float64) {
+	cols := make([]int, 0)
+	vals := make([]float64, 0)
+	for j, v := range f.Row(row) {
+		if v != 0 {
+			cols = append(cols, j)
+			vals = append(vals, v)
+		}
+	}
+	return cols, vals
+}
+
+// New returns an all-zero Float32Storage of the given size
+func (f *Float32Storage) New(rows, cols int) Storage {
+	return NewFloat32Storage(rows, cols)
+}
@@ -0,0 +1,134 @@
+package base
+
+import "testing"
+
+func TestSparseStorage(testEnv *testing.T) {
+	s := NewSparseStorage(2, 5)
+	s.Set(0, 3, 1.5)
+	s.Set(0, 1, 2.5)
+	s.Set(1, 4, -1)
+	if s.At(0, 3) != 1.5 || s.At(0, 1) != 2.5 || s.At(0, 0) != 0 || s.At(1, 4) != -1 {
+		testEnv.Error(s.Row(0), s.Row(1))
+	}
+	cols, vals := s.NonZero(0)
+	if len(cols) != 2 || cols[0] != 1 || cols[1] != 3 || vals[0] != 2.5 || vals[1] != 1.5 {
+		testEnv.Error(cols, vals)
+	}
+	s.Set(0, 1, 0)
+	if s.At(0, 1) != 0 || s.Count() != 2 {
+		testEnv.Error(s.Row(0), s.Count())
+	}
+	if row := s.Row(1); len(row) != 5 || row[4] != -1 {
+		testEnv.Error(row)
+	}
+}
+
+// newSparseIris returns a sparse copy of the iris dataset
+func newSparseIris(inst *Instances) *Instances {
+	attrs := make([]Attribute, inst.Cols)
+	for j := range attrs {
+		attrs[j] = inst.GetAttr(j)
+	}
+	ret := NewSparseInstances(attrs, inst.Rows)
+	for i := 0; i < inst.Rows; i++ {
+		for j := 0; j < inst.Cols; j++ {
+			ret.Set(i, j, inst.Get(i, j))
+		}
+	}
+	return ret
+}
+
+func TestSparseInstances(testEnv *testing.T) {
+	inst, err := ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	sparse := newSparseIris(inst)
+	if !sparse.IsSparse() || inst.IsSparse() {
+		testEnv.Error("Wrong storage")
+	}
+	if !sparse.Equal(inst) {
+		testEnv.Error("Sparse copy differs")
+	}
+	if dist := sparse.GetClassDistribution(); dist["Iris-setosa"] != 50 {
+		testEnv.Error(dist)
+	}
+
+	// Derived Instances stay sparse
+	rows := sparse.SelectRows([]int{0, 50, 100})
+	if !rows.IsSparse() || rows.GetClass(1) != inst.GetClass(50) {
+		testEnv.Error(rows)
+	}
+	sample := sparse.SampleWithoutReplacement(10)
+	if !sample.IsSparse() || sample.Rows != 10 {
+		testEnv.Error(sample)
+	}
+	train, test := InstancesTrainTestSplit(sparse, 0.5)
+	if !train.IsSparse() || !test.IsSparse() || train.Rows+test.Rows != inst.Rows {
+		testEnv.Error(train.Rows, test.Rows)
+	}
+
+	sparse.Sort(Ascending, []int{0})
+	for i := 1; i < sparse.Rows; i++ {
+		if sparse.Get(i-1, 0) > sparse.Get(i, 0) {
+			testEnv.Fatalf("Not sorted at row %d", i)
+		}
+	}
+}
+
+func TestSparseInstancesSize(testEnv *testing.T) {
+	// 100,000 features would need 800MB stored densely
+	attrs := make([]Attribute, 100001)
+	for j := range attrs {
+		attrs[j] = NewFloatAttribute()
+	}
+	inst := NewSparseInstances(attrs, 1000)
+	for i := 0; i < inst.Rows; i++ {
+		inst.Set(i, (i*7919)%100000, 1.0)
+	}
+	for i := 0; i < inst.Rows; i++ {
+		cols, _ := inst.NonZero(i)
+		if len(cols) != 1 || cols[0] != (i*7919)%100000 {
+			testEnv.Fatal(cols)
+		}
+	}
+	if n := inst.Storage().(*SparseStorage).Count(); n != 1000 {
+		testEnv.Error(n)
+	}
+}
+
+func TestFloat32Instances(testEnv *testing.T) {
+	defer SetConfig(GetConfig())
+	SetConfig(Config{Float32: true})
+	inst, err := ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if !inst.IsFloat32() || inst.IsSparse() {
+		testEnv.Fatal("Expected float32 storage")
+	}
+	if inst.GetAttrStr(0, 0) != "5.10" || inst.GetClass(0) != "Iris-setosa" {
+		testEnv.Error(inst.GetAttrStr(0, 0), inst.GetClass(0))
+	}
+	if v := inst.Get(0, 0); v != float64(float32(5.1)) {
+		testEnv.Errorf("Expected 5.1 rounded to float32, got %v", v)
+	}
+	if n := GetConfig().ChunkRows(10); n != DefaultChunkRows {
+		testEnv.Error(n)
+	}
+	if n := InstancesBytes(10, 10); n != 400 {
+		testEnv.Error(n)
+	}
+
+	rows := inst.SelectRows([]int{0, 50, 100})
+	if !rows.IsFloat32() || rows.GetClass(1) != inst.GetClass(50) {
+		testEnv.Error(rows)
+	}
+	inst.SetMissing(1, 2)
+	if !inst.IsMissing(1, 2) {
+		testEnv.Error("Missing value lost")
+	}
+	if row := inst.GetRowVector(1); len(row) != inst.Cols || row[0] != inst.Get(1, 0) {
+		testEnv.Error(row)
+	}
+}
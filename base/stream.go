@@ -0,0 +1,272 @@
+package base
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+)
+
+// CSVStream reads a CSV file a chunk of rows at a time, so files
+// which don't fit in memory can be processed, e.g. by learners which
+// are trained incrementally. Use it like a bufio.Scanner:
+//
+//	stream, err := base.StreamCSV("big.csv", true, 1000)
+//	if err != nil {
+//		...
+//	}
+//	defer stream.Close()
+//	for stream.Next() {
+//		chunk := stream.Instances()
+//		...
+//	}
+//	if err := stream.Err(); err != nil {
+//		...
+//	}
+//
+// Every chunk shares the same Attributes, so CategoricalAttribute
+// values have the same system representation throughout.
+type CSVStream struct {
+	reader     *csv.Reader
+	closer     io.Closer
+	attrs      []Attribute
+	chunkSize  int
+	skipHeader bool
+	options    CSVOptions
+	chunk      *Instances
+	rows       int
+	err        error
+}
+
+// AutoChunkSize can be passed as the chunkSize of a CSVStream to
+// read as many rows at a time as fit in the MaxMemory of the Config
+// (see Config.ChunkRows).
+const AutoChunkSize = -1
+
+// StreamCSV opens the CSV file given by filepath for reading in
+// chunks of chunkSize rows (one row at a time if chunkSize is one,
+// or see AutoChunkSize).
+//
+// The Attributes are inferred as ParseCSVToInstances does, from
+// every row of the file, so it's read through once before the first
+// chunk. Pass known Attributes to NewCSVStream to skip that pass.
+func StreamCSV(filepath string, hasHeaders bool, chunkSize int) (stream *CSVStream, err error) {
+	return StreamCSVWithOptions(filepath, CSVOptions{HasHeaders: hasHeaders}, chunkSize)
+}
+
+// StreamCSVWithOptions is like StreamCSV, but reads the file as
+// described by options (see ParseCSVToInstancesWithOptions).
+func StreamCSVWithOptions(filepath string, options CSVOptions, chunkSize int) (stream *CSVStream, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			var ok bool
+			if err, ok = r.(error); !ok {
+				err = fmt.Errorf("base: StreamCSV: %v", r)
+			}
+		}
+	}()
+	attrs := getCSVAttributes(filepath, options)
+	file, err := os.Open(filepath)
+	if err != nil {
+		return nil, err
+	}
+	stream, err = newCSVStream(file, attrs, options, chunkSize)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	stream.closer = file
+	return stream, nil
+}
+
+// NewCSVStream reads CSV data from r in chunks of chunkSize rows,
+// using the given Attributes (one per column). Passing the
+// Attributes of the training data when streaming test data keeps
+// their CategoricalAttribute values consistent.
+func NewCSVStream(r io.Reader, attrs []Attribute, hasHeaders bool, chunkSize int) (*CSVStream, error) {
+	return newCSVStream(r, attrs, CSVOptions{HasHeaders: hasHeaders}, chunkSize)
+}
+
+// newCSVStream implements NewCSVStream, reading r as described by
+// options (whose Overrides are ignored)
+func newCSVStream(r io.Reader, attrs []Attribute, options CSVOptions, chunkSize int) (*CSVStream, error) {
+	if chunkSize == AutoChunkSize {
+		chunkSize = GetConfig().ChunkRows(len(attrs))
+	}
+	if chunkSize < 1 {
+		return nil, fmt.Errorf("base: chunkSize should be at least 1, got %d", chunkSize)
+	}
+	reader, err := options.newReader(r)
+	if err != nil {
+		return nil, err
+	}
+	reader.FieldsPerRecord = len(attrs)
+	return &CSVStream{
+		reader:     reader,
+		attrs:      attrs,
+		chunkSize:  chunkSize,
+		skipHeader: options.HasHeaders,
+		options:    options,
+	}, nil
+}
+
+// Next reads the next chunk of rows, which is then available from
+// Instances. It returns false at the end of the file or on an error
+// (see Err).
+func (s *CSVStream) Next() bool {
+	s.chunk = nil
+	if s.err != nil {
+		return false
+	}
+	if s.skipHeader {
+		s.skipHeader = false
+		if _, err := s.reader.Read(); err != nil {
+			if err != io.EOF {
+				s.err = err
+			}
+			return false
+		}
+	}
+	records := make([][]string, 0, s.chunkSize)
+	for len(records) < s.chunkSize {
+		record, err := s.reader.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			s.err = err
+			return false
+		}
+		s.options.normalise(record)
+		records = append(records, record)
+	}
+	if len(records) == 0 {
+		return false
+	}
+
+	chunk := NewInstances(s.attrs, len(records))
+	for i, record := range records {
+		for j, a := range s.attrs {
+			if c, ok := a.(checkedAttribute); ok {
+				val, err := c.CheckSysValFromString(record[j])
+				if err != nil {
+					s.err = fmt.Errorf("base: row %d, column %d: %s", s.rows+i, j, err)
+					return false
+				}
+				chunk.Set(i, j, val)
+				continue
+			}
+			chunk.SetAttrStr(i, j, record[j])
+		}
+	}
+	s.rows += len(records)
+	s.chunk = chunk
+	return true
+}
+
+// Instances returns the chunk read by the last call to Next, or nil
+// if there isn't one.
+func (s *CSVStream) Instances() *Instances {
+	return s.chunk
+}
+
+// Attributes returns the Attributes of each chunk
+func (s *CSVStream) Attributes() []Attribute {
+	return s.attrs
+}
+
+// Rows returns the number of rows read so far
+func (s *CSVStream) Rows() int {
+	return s.rows
+}
+
+// Err returns the first error encountered by Next, if any
+func (s *CSVStream) Err() error {
+	return s.err
+}
+
+// Close closes the file opened by StreamCSV. Streams created with
+// NewCSVStream don't close their io.Reader.
+func (s *CSVStream) Close() error {
+	if s.closer == nil {
+		return nil
+	}
+	return s.closer.Close()
+}
+
+// ReadAll reads the rest of the stream into one set of Instances. If
+// they'd be bigger than the MaxMemory of the Config, the chunks are
+// spilled to a memory-mapped file in its TempDir instead of being
+// kept in memory (see OpenMmapInstances), which is removed once it's
+// mapped; call Close on their MmapStorage when they're no longer
+// needed.
+func (s *CSVStream) ReadAll() (*Instances, error) {
+	return readAllChunks(s, len(s.attrs)-1)
+}
+
+// chunkStream is a stream of Instances with the same Attributes, such
+// as a CSVStream or ParquetStream
+type chunkStream interface {
+	Next() bool
+	Instances() *Instances
+	Attributes() []Attribute
+	Err() error
+}
+
+// readAllChunks implements ReadAll for s, whose class Attribute is
+// the one at classIndex
+func readAllChunks(s chunkStream, classIndex int) (*Instances, error) {
+	attrs := s.Attributes()
+	config := GetConfig()
+	chunks := make([]*Instances, 0)
+	rows := 0
+	var spill *MmapWriter
+	var spillPath string
+	for s.Next() {
+		chunk := s.Instances()
+		rows += chunk.Rows
+		if spill == nil && config.MaxMemory > 0 && InstancesBytes(rows, len(attrs)) > config.MaxMemory {
+			file, err := config.CreateTemp("golearn")
+			if err != nil {
+				return nil, err
+			}
+			spillPath = file.Name()
+			file.Close()
+			defer os.Remove(spillPath)
+			if spill, err = CreateMmapFile(spillPath, attrs, chunk.ClassIndex); err != nil {
+				return nil, err
+			}
+			for _, c := range chunks {
+				if err := spill.Write(c); err != nil {
+					spill.Close()
+					return nil, err
+				}
+			}
+			chunks = nil
+		}
+		if spill == nil {
+			chunks = append(chunks, chunk)
+		} else if err := spill.Write(chunk); err != nil {
+			spill.Close()
+			return nil, err
+		}
+	}
+	if s.Err() != nil {
+		if spill != nil {
+			spill.Close()
+		}
+		return nil, s.Err()
+	}
+
+	if spill != nil {
+		if err := spill.Close(); err != nil {
+			return nil, err
+		}
+		return OpenMmapInstances(spillPath)
+	}
+	if len(chunks) == 0 {
+		ret := NewInstances(attrs, 0)
+		ret.ClassIndex = classIndex
+		return ret, nil
+	}
+	return AppendInstances(chunks[0], chunks[1:]...)
+}
@@ -0,0 +1,84 @@
+package base
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStreamCSV(testEnv *testing.T) {
+	inst, err := ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	stream, err := StreamCSV("../examples/datasets/iris_headers.csv", true, 40)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	defer stream.Close()
+	sizes := make([]int, 0)
+	row := 0
+	for stream.Next() {
+		chunk := stream.Instances()
+		sizes = append(sizes, chunk.Rows)
+		for i := 0; i < chunk.Rows; i++ {
+			if chunk.RowStr(i) != inst.RowStr(row) {
+				testEnv.Errorf("Row %d: %s, expected %s", row, chunk.RowStr(i), inst.RowStr(row))
+			}
+			row++
+		}
+	}
+	if err := stream.Err(); err != nil {
+		testEnv.Error(err)
+	}
+	if len(sizes) != 4 || sizes[3] != 30 || stream.Rows() != 150 {
+		testEnv.Error(sizes, stream.Rows())
+	}
+	if stream.Next() || stream.Instances() != nil {
+		testEnv.Error("Expected the stream to stay finished")
+	}
+}
+
+func TestNewCSVStream(testEnv *testing.T) {
+	x := NewFloatAttribute()
+	class := NewCategoricalAttribute()
+	attrs := []Attribute{x, class}
+	stream, err := NewCSVStream(strings.NewReader("x,class\n1.5,a\n2,b\noops,a\n"), attrs, true, 1)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	count := 0
+	for stream.Next() {
+		count++
+		if chunk := stream.Instances(); chunk.Rows != 1 {
+			testEnv.Error(chunk)
+		}
+	}
+	if count != 2 || stream.Err() == nil {
+		testEnv.Errorf("Expected 2 rows then an error, got %d rows and %v", count, stream.Err())
+	}
+	if _, err := NewCSVStream(strings.NewReader(""), attrs, false, 0); err == nil {
+		testEnv.Error("Expected an error for an empty chunk size")
+	}
+}
+
+func TestCSVStreamReadAll(testEnv *testing.T) {
+	inst, err := ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	stream, err := StreamCSV("../examples/datasets/iris_headers.csv", true, AutoChunkSize)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	defer stream.Close()
+	if stream.chunkSize != DefaultChunkRows {
+		testEnv.Error(stream.chunkSize)
+	}
+	all, err := stream.ReadAll()
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if !all.Equal(inst) {
+		testEnv.Error(all)
+	}
+}
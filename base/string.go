@@ -0,0 +1,117 @@
+package base
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"math"
+)
+
+// StringAttribute is an Attribute implementation which keeps the raw
+// text of each value, e.g. a document or a free-text comment, so
+// that it can be vectorised within the library. Unlike a
+// CategoricalAttribute its values aren't categories: GetType returns
+// StringType, and learners which don't understand text skip it.
+//
+// The system representation is an index into a table of the text
+// seen so far. Identical text shares an index, so the table only
+// grows with the number of distinct values.
+type StringAttribute struct {
+	Name   string
+	values []string
+	index  map[string]int
+}
+
+// NewStringAttribute returns a new, empty StringAttribute.
+func NewStringAttribute() *StringAttribute {
+	return &StringAttribute{"", make([]string, 0), make(map[string]int)}
+}
+
+// GetName returns this StringAttribute's human-readable name.
+func (Attr *StringAttribute) GetName() string {
+	return Attr.Name
+}
+
+// SetName sets this StringAttribute's human-readable name.
+func (Attr *StringAttribute) SetName(name string) {
+	Attr.Name = name
+}
+
+// GetType returns StringType.
+func (Attr *StringAttribute) GetType() int {
+	return StringType
+}
+
+// String returns a human-readable summary of this Attribute.
+func (Attr *StringAttribute) String() string {
+	return fmt.Sprintf("StringAttribute(%s)", Attr.Name)
+}
+
+// Equals tests a StringAttribute for equality with another Attribute.
+//
+// Returns false if the other Attribute has a different name or isn't
+// a StringAttribute. The text each of them has seen doesn't matter.
+func (Attr *StringAttribute) Equals(other Attribute) bool {
+	attribute, ok := other.(*StringAttribute)
+	if !ok {
+		return false
+	}
+	return Attr.Name == attribute.Name
+}
+
+// GetSysValFromString returns the system representation of rawVal,
+// adding it to the table if it hasn't been seen before. Missing
+// values (see IsMissingString) aren't added: NaN is returned.
+func (Attr *StringAttribute) GetSysValFromString(rawVal string) float64 {
+	if IsMissingString(rawVal) {
+		return math.NaN()
+	}
+	if Attr.index == nil {
+		Attr.index = make(map[string]int)
+	}
+	idx, ok := Attr.index[rawVal]
+	if !ok {
+		idx = len(Attr.values)
+		Attr.values = append(Attr.values, rawVal)
+		Attr.index[rawVal] = idx
+	}
+	return float64(idx)
+}
+
+// GetStringFromSysVal returns the text whose system representation
+// is val.
+//
+// IMPORTANT: This function panic()s if val isn't in the table.
+func (Attr *StringAttribute) GetStringFromSysVal(val float64) string {
+	if IsMissingValue(val) {
+		return MissingString
+	}
+	idx := int(val)
+	if idx < 0 || idx >= len(Attr.values) {
+		panic(fmt.Sprintf("Out of range: %d in %d", idx, len(Attr.values)))
+	}
+	return Attr.values[idx]
+}
+
+// GobEncode serialises the StringAttribute including its table of
+// text, in the same way as a CategoricalAttribute.
+func (Attr *StringAttribute) GobEncode() ([]byte, error) {
+	var b bytes.Buffer
+	err := gob.NewEncoder(&b).Encode(categoricalAttributeGob{Attr.Name, Attr.values})
+	return b.Bytes(), err
+}
+
+// GobDecode deserialises a StringAttribute
+func (Attr *StringAttribute) GobDecode(data []byte) error {
+	var g categoricalAttributeGob
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&g); err != nil {
+		return err
+	}
+	Attr.Name = g.Name
+	Attr.values = make([]string, 0, len(g.Values))
+	Attr.index = make(map[string]int)
+	for _, v := range g.Values {
+		Attr.GetSysValFromString(v)
+	}
+	return nil
+}
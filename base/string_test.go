@@ -0,0 +1,79 @@
+package base
+
+import (
+	"bytes"
+	"encoding/gob"
+	"strings"
+	"testing"
+)
+
+func TestStringAttribute(testEnv *testing.T) {
+	a := NewStringAttribute()
+	a.SetName("review")
+	first := a.GetSysValFromString("Great, would buy again")
+	second := a.GetSysValFromString("Broke after a week")
+	if first == second || a.GetSysValFromString("Great, would buy again") != first {
+		testEnv.Error(first, second)
+	}
+	if a.GetStringFromSysVal(second) != "Broke after a week" {
+		testEnv.Error(a.GetStringFromSysVal(second))
+	}
+	if !IsMissingValue(a.GetSysValFromString("?")) || a.GetType() != StringType {
+		testEnv.Error(a)
+	}
+
+	var buf bytes.Buffer
+	var encoded Attribute = a
+	if err := gob.NewEncoder(&buf).Encode(&encoded); err != nil {
+		testEnv.Fatal(err)
+	}
+	var decoded Attribute
+	if err := gob.NewDecoder(&buf).Decode(&decoded); err != nil {
+		testEnv.Fatal(err)
+	}
+	if !decoded.Equals(a) || decoded.GetStringFromSysVal(second) != "Broke after a week" {
+		testEnv.Error(decoded)
+	}
+	if decoded.GetSysValFromString("Broke after a week") != second {
+		testEnv.Error("Decoded attribute lost its table")
+	}
+}
+
+func TestParseARFFStrings(testEnv *testing.T) {
+	data := `@relation reviews
+@attribute text string
+@attribute stars numeric
+@attribute label {good,bad}
+@data
+'Great, would buy again',5,good
+'Broke after a week',1,bad
+?,3,good
+`
+	inst, err := ParseARFF(strings.NewReader(data))
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if inst.GetAttr(0).GetType() != StringType || inst.GetAttrStr(1, 0) != "Broke after a week" {
+		testEnv.Fatal(inst.GetAttr(0), inst.RowStr(1))
+	}
+	if !inst.IsMissing(2, 0) {
+		testEnv.Error("Missing text should be NaN")
+	}
+	if summary := inst.Schema(); !strings.Contains(summary, "string") {
+		testEnv.Error(summary)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteARFF(&buf, inst, "reviews"); err != nil {
+		testEnv.Fatal(err)
+	}
+	read, err := ParseARFF(&buf)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	for i := 0; i < inst.Rows; i++ {
+		if read.RowStr(i) != inst.RowStr(i) {
+			testEnv.Error(read.RowStr(i), inst.RowStr(i))
+		}
+	}
+}
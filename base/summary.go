@@ -0,0 +1,195 @@
+package base
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// Summariser implementations can describe themselves compactly,
+// e.g. for interactive use in a notebook, without printing all of
+// their contents like String() does.
+type Summariser interface {
+	Summary() string
+}
+
+// Summarise returns obj's Summary() if it's a Summariser, otherwise
+// its type and parameters (see GetParams).
+func Summarise(obj interface{}) string {
+	if s, ok := obj.(Summariser); ok {
+		return s.Summary()
+	}
+	v, err := getStructValue(obj)
+	if err != nil {
+		return fmt.Sprintf("%v", obj)
+	}
+	return fmt.Sprintf("%s(%s)", v.Type().Name(), FormatParams(GetParams(obj)))
+}
+
+// FormatParams returns the parameters (as returned by GetParams)
+// as a comma-separated list of name: value pairs, sorted by name.
+// Function parameters show whether they're set.
+func FormatParams(params map[string]interface{}) string {
+	names := make([]string, 0, len(params))
+	for name := range params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		val := params[name]
+		if v := reflect.ValueOf(val); v.Kind() == reflect.Func {
+			if v.IsNil() {
+				val = "default"
+			} else {
+				val = "custom"
+			}
+		}
+		names[i] = fmt.Sprintf("%s: %v", name, val)
+	}
+	return strings.Join(names, ", ")
+}
+
+// FormatHistogram returns a bar chart of the counts in hist, one
+// line per key in increasing order, with bars up to width characters
+// wide.
+func FormatHistogram(hist map[int]int, width int) string {
+	keys := make([]int, 0, len(hist))
+	maxCount := 0
+	for k, c := range hist {
+		keys = append(keys, k)
+		if c > maxCount {
+			maxCount = c
+		}
+	}
+	sort.Ints(keys)
+	keyWidth := 0
+	for _, k := range keys {
+		if l := len(fmt.Sprintf("%d", k)); l > keyWidth {
+			keyWidth = l
+		}
+	}
+	var buffer bytes.Buffer
+	for _, k := range keys {
+		bar := 0
+		if maxCount > 0 {
+			bar = int(math.Ceil(float64(hist[k]*width) / float64(maxCount)))
+		}
+		buffer.WriteString(fmt.Sprintf("%*d | %s %d\n", keyWidth, k, strings.Repeat("#", bar), hist[k]))
+	}
+	return buffer.String()
+}
+
+// Schema returns a table describing each of the Attributes: its
+// type and, for FloatAttributes, the range and mean of its values
+// or, for CategoricalAttributes, its most common values, and how many
+// are missing. The class Attribute is marked with a *, and
+// Attributes which aren't features with their AttributeRole.
+func (inst *Instances) Schema() string {
+	var buffer bytes.Buffer
+	buffer.WriteString(fmt.Sprintf("Instances with %d row(s) %d attribute(s)\n", inst.Rows, inst.Cols))
+	w := tabwriter.NewWriter(&buffer, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "\t#\tName\tType\tValues")
+	for i, a := range inst.attributes {
+		prefix := ""
+		if i == inst.ClassIndex {
+			prefix = "*"
+		} else if role := inst.GetRole(i); role != FeatureRole {
+			prefix = role.String()
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\n", prefix, i, a.GetName(), attributeTypeName(a), inst.summariseAttr(i))
+	}
+	w.Flush()
+	return buffer.String()
+}
+
+// attributeTypeName returns a short description of a's type
+func attributeTypeName(a Attribute) string {
+	if isTimeAttribute(a) {
+		return "time"
+	}
+	if _, ok := a.(*OrdinalAttribute); ok {
+		return "ordinal"
+	}
+	switch a.GetType() {
+	case Float64Type:
+		return "float"
+	case CategoricalType:
+		return "categorical"
+	case StringType:
+		return "string"
+	}
+	return fmt.Sprintf("%d", a.GetType())
+}
+
+// summariseAttr describes the values of the Attribute at index col
+func (inst *Instances) summariseAttr(col int) string {
+	a := inst.attributes[col]
+	if inst.Rows == 0 {
+		return "(empty)"
+	}
+	missing := ""
+	if n := inst.CountMissing(col); n > 0 {
+		missing = fmt.Sprintf(", %d missing", n)
+	}
+	if a.GetType() == Float64Type {
+		min, max, sum, count := math.Inf(1), math.Inf(-1), 0.0, 0
+		for i := 0; i < inst.Rows; i++ {
+			val := inst.Get(i, col)
+			if IsMissingValue(val) {
+				continue
+			}
+			min = math.Min(min, val)
+			max = math.Max(max, val)
+			sum += val
+			count++
+		}
+		if count == 0 {
+			return "all missing"
+		}
+		if hasNamedValues(a) {
+			return fmt.Sprintf("from %s to %s%s", a.GetStringFromSysVal(min), a.GetStringFromSysVal(max), missing)
+		}
+		return fmt.Sprintf("min %.4g, mean %.4g, max %.4g%s", min, sum/float64(count), max, missing)
+	}
+	counts := inst.CountAttrValues(a)
+	values := make([]string, 0, len(counts))
+	for v := range counts {
+		values = append(values, v)
+	}
+	sort.Sort(&byCount{values, counts})
+	top := make([]string, 0, 3)
+	for i := 0; i < len(values) && i < 3; i++ {
+		top = append(top, fmt.Sprintf("%s (%d)", values[i], counts[values[i]]))
+	}
+	if len(values) > 3 {
+		top = append(top, "...")
+	}
+	return fmt.Sprintf("%d distinct: %s%s", len(values), strings.Join(top, ", "), missing)
+}
+
+// byCount sorts values from most to least common, then
+// alphabetically.
+type byCount struct {
+	values []string
+	counts map[string]int
+}
+
+func (b *byCount) Len() int {
+	return len(b.values)
+}
+
+func (b *byCount) Swap(i, j int) {
+	b.values[i], b.values[j] = b.values[j], b.values[i]
+}
+
+func (b *byCount) Less(i, j int) bool {
+	ci, cj := b.counts[b.values[i]], b.counts[b.values[j]]
+	if ci != cj {
+		return ci > cj
+	}
+	return b.values[i] < b.values[j]
+}
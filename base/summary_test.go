@@ -0,0 +1,36 @@
+package base
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSchema(testEnv *testing.T) {
+	inst, err := ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	schema := inst.Schema()
+	lines := strings.Split(strings.TrimSpace(schema), "\n")
+	if len(lines) != 7 {
+		testEnv.Fatal(schema)
+	}
+	if !strings.Contains(lines[2], "Sepal length") || !strings.Contains(lines[2], "min 4.3, mean 5.843, max 7.9") {
+		testEnv.Error(lines[2])
+	}
+	if !strings.HasPrefix(lines[6], "*") || !strings.Contains(lines[6], "3 distinct: Iris-setosa (50)") {
+		testEnv.Error(lines[6])
+	}
+}
+
+func TestSummarise(testEnv *testing.T) {
+	c := &paramsTestClassifier{Neighbours: 3, Name: "test"}
+	summary := Summarise(c)
+	if summary != "paramsTestClassifier(Learner: default, Name: test, Neighbours: 3, Threshold: 0)" {
+		testEnv.Error(summary)
+	}
+	hist := FormatHistogram(map[int]int{1: 2, 10: 4}, 4)
+	if hist != " 1 | ## 2\n10 | #### 4\n" {
+		testEnv.Errorf("%q", hist)
+	}
+}
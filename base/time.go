@@ -0,0 +1,257 @@
+package base
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// TimeLayouts are the layouts tried, in order, when reading the
+// values of a TimeAttribute without a Layout, and when sniffing the
+// types of CSV columns.
+var TimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// TimeAttribute is an implementation which stores timestamps as the
+// number of seconds since the Unix epoch. Its GetType is Float64Type,
+// so learners treat timestamps as numbers; use ExpandTimeAttribute to
+// derive features such as the hour and day of the week.
+type TimeAttribute struct {
+	Name string
+	// Layout is the time.Parse layout of the values. If it's empty,
+	// each of TimeLayouts is tried, and values are written in
+	// RFC 3339 format. Timestamps without a time zone are in UTC.
+	Layout string
+}
+
+// NewTimeAttribute returns a new TimeAttribute whose values have the
+// given layout (see time.Parse), or any of TimeLayouts if it's empty.
+func NewTimeAttribute(layout string) *TimeAttribute {
+	return &TimeAttribute{"", layout}
+}
+
+// GetName returns this TimeAttribute's human-readable name.
+func (Attr *TimeAttribute) GetName() string {
+	return Attr.Name
+}
+
+// SetName sets this TimeAttribute's human-readable name.
+func (Attr *TimeAttribute) SetName(name string) {
+	Attr.Name = name
+}
+
+// GetType returns Float64Type, since the system representation is
+// numeric.
+func (Attr *TimeAttribute) GetType() int {
+	return Float64Type
+}
+
+// String returns a human-readable summary of this Attribute.
+func (Attr *TimeAttribute) String() string {
+	return fmt.Sprintf("TimeAttribute(%s)", Attr.Name)
+}
+
+// Equals tests a TimeAttribute for equality with another Attribute.
+//
+// Returns false if the other Attribute has a different name or
+// layout, or isn't a TimeAttribute.
+func (Attr *TimeAttribute) Equals(other Attribute) bool {
+	attribute, ok := other.(*TimeAttribute)
+	if !ok {
+		return false
+	}
+	return Attr.Name == attribute.Name && Attr.Layout == attribute.Layout
+}
+
+// isTimeAttribute returns true if a is a TimeAttribute
+func isTimeAttribute(a Attribute) bool {
+	_, ok := a.(*TimeAttribute)
+	return ok
+}
+
+// parseTime parses rawVal with layout, or with the first of
+// TimeLayouts which works if layout is empty.
+func parseTime(layout string, rawVal string) (time.Time, error) {
+	if layout != "" {
+		return time.Parse(layout, rawVal)
+	}
+	var err error
+	for _, l := range TimeLayouts {
+		var t time.Time
+		if t, err = time.Parse(l, rawVal); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("base: can't parse %q as a time", rawVal)
+}
+
+// CheckSysValFromString confirms whether a given rawVal can be
+// parsed as a timestamp, returning its system representation.
+// Missing values are valid.
+func (Attr *TimeAttribute) CheckSysValFromString(rawVal string) (float64, error) {
+	if IsMissingString(rawVal) {
+		return math.NaN(), nil
+	}
+	t, err := parseTime(Attr.Layout, rawVal)
+	if err != nil {
+		return 0, err
+	}
+	return Attr.GetSysVal(t), nil
+}
+
+// GetSysValFromString parses rawVal as a timestamp and returns its
+// system representation.
+//
+// IMPORTANT: This function panic()s if rawVal isn't a valid
+// timestamp. Use CheckSysValFromString to confirm.
+func (Attr *TimeAttribute) GetSysValFromString(rawVal string) float64 {
+	ret, err := Attr.CheckSysValFromString(rawVal)
+	if err != nil {
+		panic(err)
+	}
+	return ret
+}
+
+// GetStringFromSysVal formats the timestamp with the Layout (or in
+// RFC 3339 format), in UTC.
+func (Attr *TimeAttribute) GetStringFromSysVal(val float64) string {
+	if IsMissingValue(val) {
+		return MissingString
+	}
+	layout := Attr.Layout
+	if layout == "" {
+		layout = time.RFC3339
+	}
+	return Attr.GetUsrVal(val).Format(layout)
+}
+
+// GetSysVal returns the system representation of t: the number of
+// seconds since the Unix epoch.
+func (Attr *TimeAttribute) GetSysVal(t time.Time) float64 {
+	return float64(t.Unix()) + float64(t.Nanosecond())/1e9
+}
+
+// GetUsrVal returns the timestamp represented by sysVal, in UTC.
+func (Attr *TimeAttribute) GetUsrVal(sysVal float64) time.Time {
+	secs := math.Floor(sysVal)
+	return time.Unix(int64(secs), int64((sysVal-secs)*1e9+0.5)).UTC()
+}
+
+// TimeFeature is a feature which can be derived from a timestamp by
+// ExpandTimeAttribute.
+type TimeFeature int
+
+const (
+	// Hour is the hour of the day (0-23), as a FloatAttribute
+	Hour TimeFeature = iota
+	// DayOfWeek is a CategoricalAttribute ("Sunday" to "Saturday")
+	DayOfWeek
+	// DayOfMonth is the day of the month (1-31), as a FloatAttribute
+	DayOfMonth
+	// Month is a CategoricalAttribute ("January" to "December")
+	Month
+	// Year is a FloatAttribute
+	Year
+)
+
+// newAttribute returns the Attribute holding the feature derived
+// from the TimeAttribute called name
+func (f TimeFeature) newAttribute(name string) Attribute {
+	switch f {
+	case DayOfWeek:
+		ret := NewCategoricalAttribute()
+		ret.SetName(name + "_weekday")
+		for d := time.Sunday; d <= time.Saturday; d++ {
+			ret.GetSysValFromString(d.String())
+		}
+		return ret
+	case Month:
+		ret := NewCategoricalAttribute()
+		ret.SetName(name + "_month")
+		for m := time.January; m <= time.December; m++ {
+			ret.GetSysValFromString(m.String())
+		}
+		return ret
+	}
+	ret := NewFloatAttribute()
+	ret.Precision = 0
+	switch f {
+	case Hour:
+		ret.SetName(name + "_hour")
+	case DayOfMonth:
+		ret.SetName(name + "_day")
+	case Year:
+		ret.SetName(name + "_year")
+	default:
+		panic(fmt.Sprintf("base: unknown TimeFeature %d", f))
+	}
+	return ret
+}
+
+// value returns the system representation of the feature of t
+func (f TimeFeature) value(t time.Time) float64 {
+	switch f {
+	case Hour:
+		return float64(t.Hour())
+	case DayOfWeek:
+		return float64(t.Weekday())
+	case DayOfMonth:
+		return float64(t.Day())
+	case Month:
+		return float64(t.Month() - 1)
+	}
+	return float64(t.Year())
+}
+
+// ExpandTimeAttribute returns a copy of inst with the given features
+// of the TimeAttribute at index col added after it, named after it
+// (e.g. "created_hour"). The features of missing timestamps are
+// missing.
+//
+// IMPORTANT: this function panic()s if the Attribute at index col
+// isn't a TimeAttribute.
+func ExpandTimeAttribute(inst *Instances, col int, features ...TimeFeature) *Instances {
+	timeAttr, ok := inst.GetAttr(col).(*TimeAttribute)
+	if !ok {
+		panic(fmt.Sprintf("base: %s isn't a TimeAttribute", inst.GetAttr(col)))
+	}
+	attrs := make([]Attribute, 0, inst.Cols+len(features))
+	for j := 0; j < inst.Cols; j++ {
+		attrs = append(attrs, inst.GetAttr(j))
+		if j == col {
+			for _, f := range features {
+				attrs = append(attrs, f.newAttribute(timeAttr.GetName()))
+			}
+		}
+	}
+	ret := inst.newInstancesLike(attrs, inst.Rows)
+	ret.ClassIndex = inst.ClassIndex
+	ret.weights = inst.Weights()
+	ret.copyRoles(inst)
+	if inst.ClassIndex > col {
+		ret.ClassIndex += len(features)
+	}
+	for i := 0; i < inst.Rows; i++ {
+		for j := 0; j < inst.Cols; j++ {
+			dst := j
+			if j > col {
+				dst += len(features)
+			}
+			ret.Set(i, dst, inst.Get(i, j))
+		}
+		val := inst.Get(i, col)
+		for k, f := range features {
+			if IsMissingValue(val) {
+				ret.SetMissing(i, col+1+k)
+			} else {
+				ret.Set(i, col+1+k, f.value(timeAttr.GetUsrVal(val)))
+			}
+		}
+	}
+	return ret
+}
@@ -0,0 +1,70 @@
+package base
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestTimeAttribute(testEnv *testing.T) {
+	a := NewTimeAttribute("")
+	sysVal := a.GetSysValFromString("2015-03-01T13:30:00Z")
+	if sysVal != float64(time.Date(2015, 3, 1, 13, 30, 0, 0, time.UTC).Unix()) {
+		testEnv.Error(sysVal)
+	}
+	if s := a.GetStringFromSysVal(sysVal); s != "2015-03-01T13:30:00Z" {
+		testEnv.Error(s)
+	}
+	if v := a.GetSysValFromString("2015-03-01"); v != sysVal-13.5*3600 {
+		testEnv.Error(v)
+	}
+	if _, err := a.CheckSysValFromString("yesterday"); err == nil {
+		testEnv.Error("Expected an error")
+	}
+	if !IsMissingValue(a.GetSysValFromString("?")) {
+		testEnv.Error("Expected a missing value")
+	}
+
+	b := NewTimeAttribute("02/01/2006 15:04")
+	if v := b.GetSysValFromString("01/03/2015 13:30"); v != sysVal {
+		testEnv.Error(v)
+	}
+	if s := b.GetStringFromSysVal(sysVal + 0.25); s != "01/03/2015 13:30" {
+		testEnv.Error(s)
+	}
+	if !b.GetUsrVal(sysVal + 0.25).Equal(time.Date(2015, 3, 1, 13, 30, 0, 250000000, time.UTC)) {
+		testEnv.Error(b.GetUsrVal(sysVal + 0.25))
+	}
+}
+
+func TestExpandTimeAttribute(testEnv *testing.T) {
+	file, err := ioutil.TempFile("", "time")
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString("created,amount,class\n2015-03-01 13:30:00,1.5,a\n2016-12-25 08:00:00,2,b\n?,3,a\n")
+	file.Close()
+
+	inst, err := ParseCSVToInstances(file.Name(), true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if _, ok := inst.GetAttr(0).(*TimeAttribute); !ok {
+		testEnv.Fatal(inst.GetAttr(0))
+	}
+	expanded := ExpandTimeAttribute(inst, 0, Hour, DayOfWeek, Month)
+	if expanded.Cols != 6 || expanded.ClassIndex != 5 || expanded.GetClass(1) != "b" {
+		testEnv.Fatal(expanded)
+	}
+	if expanded.GetAttr(1).GetName() != "created_hour" || expanded.Get(0, 1) != 13 || expanded.Get(1, 1) != 8 {
+		testEnv.Error(expanded.RowStr(0), expanded.RowStr(1))
+	}
+	if expanded.GetAttrStr(0, 2) != "Sunday" || expanded.GetAttrStr(1, 3) != "December" {
+		testEnv.Error(expanded.RowStr(0), expanded.RowStr(1))
+	}
+	if !expanded.IsMissing(2, 1) || !expanded.IsMissing(2, 3) || expanded.Get(2, 4) != 3 {
+		testEnv.Error(expanded.RowStr(2))
+	}
+}
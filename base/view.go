@@ -0,0 +1,165 @@
+package base
+
+import "fmt"
+
+// ViewStorage is a Storage which presents some of the rows and
+// columns of another Storage, in any order, without copying them.
+// Setting a value changes it in the underlying Storage, but
+// reordering the rows of a view (e.g. with Shuffle or Sort) only
+// reorders the view.
+type ViewStorage struct {
+	src Storage
+	// rows and cols map the view's indices to those of src
+	rows []int
+	cols []int
+}
+
+// NewViewStorage returns a ViewStorage of the given rows and
+// columns of src. If rows or cols is nil, all of them are used. Views
+// of a ViewStorage look through it to its own source.
+//
+// IMPORTANT: this function panic()s if any of the indices are out of
+// range.
+func NewViewStorage(src Storage, rows, cols []int) *ViewStorage {
+	srcRows, srcCols := src.Dims()
+	rows = viewIndices(rows, srcRows)
+	cols = viewIndices(cols, srcCols)
+	if v, ok := src.(*ViewStorage); ok {
+		for i, r := range rows {
+			rows[i] = v.rows[r]
+		}
+		for j, c := range cols {
+			cols[j] = v.cols[c]
+		}
+		src = v.src
+	}
+	return &ViewStorage{src, rows, cols}
+}
+
+// viewIndices returns a copy of indices, or 0...n-1 if it's nil,
+// checking that they're in range
+func viewIndices(indices []int, n int) []int {
+	if indices == nil {
+		ret := make([]int, n)
+		for i := range ret {
+			ret[i] = i
+		}
+		return ret
+	}
+	ret := make([]int, len(indices))
+	for i, idx := range indices {
+		if idx < 0 || idx >= n {
+			panic(fmt.Sprintf("base: index %d is out of range for a view of %d", idx, n))
+		}
+		ret[i] = idx
+	}
+	return ret
+}
+
+// Source returns the Storage the view is of
+func (v *ViewStorage) Source() Storage {
+	return v.src
+}
+
+// Dims returns the number of rows and columns in the view
+func (v *ViewStorage) Dims() (int, int) {
+	return len(v.rows), len(v.cols)
+}
+
+// At returns the value at the given row and column of the view
+func (v *ViewStorage) At(row, col int) float64 {
+	return v.src.At(v.rows[row], v.cols[col])
+}
+
+// Set sets the value at the given row and column of the view, and
+// so of the underlying Storage
+func (v *ViewStorage) Set(row, col int, val float64) {
+	v.src.Set(v.rows[row], v.cols[col], val)
+}
+
+// Row returns a new copy of the given row of the view
+func (v *ViewStorage) Row(row int) []float64 {
+	src := v.src.Row(v.rows[row])
+	ret := make([]float64, len(v.cols))
+	for j, c := range v.cols {
+		ret[j] = src[c]
+	}
+	return ret
+}
+
+// NonZero returns the non-zero columns of the given row of the view
+func (v *ViewStorage) NonZero(row int) ([]int, []float64) {
+	cols := make([]int, 0)
+	vals := make([]float64, 0)
+	for j, c := range v.cols {
+		if val := v.src.At(v.rows[row], c); val != 0 {
+			cols = append(cols, j)
+			vals = append(vals, val)
+		}
+	}
+	return cols, vals
+}
+
+// New returns an empty Storage of the same kind as the underlying
+// one, so that copies of a view aren't views.
+func (v *ViewStorage) New(rows, cols int) Storage {
+	return v.src.New(rows, cols)
+}
+
+// swapRows swaps two rows of the view, leaving the underlying
+// Storage alone
+func (v *ViewStorage) swapRows(r1, r2 int) {
+	v.rows[r1], v.rows[r2] = v.rows[r2], v.rows[r1]
+}
+
+// ViewRows returns Instances made up of the given rows (which may
+// repeat) of these, in order, or all of them if rows is nil, sharing
+// their Storage rather than copying it. The Attributes, weights and
+// roles are the same. It's the zero-copy SelectRows, for splitting
+// large datasets into folds.
+//
+// IMPORTANT: setting a value of the view sets it in these Instances,
+// and vice versa. Use SelectRows for an independent copy.
+func (inst *Instances) ViewRows(rows []int) *Instances {
+	ret := NewInstancesFromStorage(inst.attributes, NewViewStorage(inst.storage, rows, nil))
+	ret.ClassIndex = inst.ClassIndex
+	if inst.roles != nil {
+		ret.roles = append([]AttributeRole(nil), inst.roles...)
+	}
+	if rows == nil {
+		ret.weights = inst.Weights()
+	} else if inst.weights != nil {
+		for i, r := range rows {
+			ret.SetWeight(i, inst.weights[r])
+		}
+	}
+	return ret
+}
+
+// ViewAttributes returns Instances with only the given Attributes of
+// these, sharing their Storage rather than copying it, like a
+// SelectAttributes which doesn't use any more memory.
+//
+// IMPORTANT: setting a value of the view sets it in these Instances,
+// and vice versa. Use SelectAttributes for an independent copy. This
+// function panic()s if any of the Attributes can't be found.
+func (inst *Instances) ViewAttributes(attrs []Attribute) *Instances {
+	cols := make([]int, len(attrs))
+	for j, a := range attrs {
+		cols[j] = inst.GetAttrIndex(a)
+		if cols[j] == -1 {
+			panic("Invalid attribute")
+		}
+	}
+	ret := NewInstancesFromStorage(attrs, NewViewStorage(inst.storage, nil, cols))
+	ret.weights = inst.Weights()
+	ret.copyRoles(inst)
+	return ret
+}
+
+// IsView returns true if the Instances share the Storage of others
+// (see ViewRows and ViewAttributes).
+func (inst *Instances) IsView() bool {
+	_, ok := inst.storage.(*ViewStorage)
+	return ok
+}
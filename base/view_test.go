@@ -0,0 +1,50 @@
+package base
+
+import "testing"
+
+func TestViews(testEnv *testing.T) {
+	inst, err := ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	inst.SetWeight(100, 3)
+	rows := inst.ViewRows([]int{100, 0, 50})
+	if !rows.IsView() || rows.Rows != 3 || rows.GetClass(0) != "Iris-virginica" || rows.GetWeight(0) != 3 {
+		testEnv.Fatal(rows)
+	}
+	if !rows.Equal(inst.SelectRows([]int{100, 0, 50})) {
+		testEnv.Error("View differs from a copy")
+	}
+
+	// Values are shared...
+	rows.Set(1, 0, 42)
+	if inst.Get(0, 0) != 42 {
+		testEnv.Error("Setting a value of a view should set it in the source")
+	}
+	// ...but the order isn't
+	rows.Sort(Ascending, []int{0})
+	if rows.Get(0, 0) != 6.3 || inst.GetClass(100) != "Iris-virginica" || rows.GetWeight(0) != 3 {
+		testEnv.Error(rows)
+	}
+
+	// Views of views look through to the source
+	attrs := []Attribute{inst.GetAttr(3), inst.GetAttr(4)}
+	narrow := rows.ViewAttributes(attrs)
+	if narrow.Cols != 2 || narrow.GetClass(2) != rows.GetClass(2) || narrow.Get(0, 0) != inst.Get(100, 3) {
+		testEnv.Error(narrow)
+	}
+	if narrow.Storage().(*ViewStorage).Source() != inst.Storage() {
+		testEnv.Error("Expected a view of the source")
+	}
+	if copied := narrow.SelectRows([]int{0}); copied.IsView() {
+		testEnv.Error("Copies of views shouldn't be views")
+	}
+
+	sparse := newSparseIris(inst).ViewRows([]int{1, 2})
+	if !sparse.IsSparse() {
+		testEnv.Error("Views of sparse Instances should be sparse")
+	}
+	if cols, _ := sparse.NonZero(0); len(cols) != 4 {
+		testEnv.Error(cols)
+	}
+}
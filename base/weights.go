@@ -0,0 +1,86 @@
+package base
+
+// Every row of a set of Instances has a weight, which is one unless
+// it's been set otherwise. Weights say how much each row counts,
+// e.g. for boosting, cost-sensitive learning, or correcting for rows
+// which were sampled unevenly. They're carried along when rows are
+// copied, sorted, shuffled or sampled.
+
+// GetWeight returns the weight of the given row.
+func (inst *Instances) GetWeight(row int) float64 {
+	if inst.weights == nil {
+		return 1.0
+	}
+	return inst.weights[row]
+}
+
+// SetWeight sets the weight of the given row.
+func (inst *Instances) SetWeight(row int, weight float64) {
+	if inst.weights == nil {
+		if weight == 1.0 {
+			return
+		}
+		inst.weights = make([]float64, inst.Rows)
+		for i := range inst.weights {
+			inst.weights[i] = 1.0
+		}
+	}
+	inst.weights[row] = weight
+}
+
+// SetWeights sets the weight of every row. If weights is nil, they
+// all become one.
+//
+// IMPORTANT: this function panic()s if there isn't one weight per row.
+func (inst *Instances) SetWeights(weights []float64) {
+	if weights == nil {
+		inst.weights = nil
+		return
+	}
+	if len(weights) != inst.Rows {
+		panic("base: expected one weight per row")
+	}
+	inst.weights = make([]float64, inst.Rows)
+	copy(inst.weights, weights)
+}
+
+// HasWeights returns true if any row's weight has been set.
+func (inst *Instances) HasWeights() bool {
+	return inst.weights != nil
+}
+
+// Weights returns a copy of the weight of each row, or nil if
+// they're all one, as learners which take weights expect.
+func (inst *Instances) Weights() []float64 {
+	if inst.weights == nil {
+		return nil
+	}
+	ret := make([]float64, len(inst.weights))
+	copy(ret, inst.weights)
+	return ret
+}
+
+// TotalWeight returns the sum of the weights of the rows.
+func (inst *Instances) TotalWeight() float64 {
+	if inst.weights == nil {
+		return float64(inst.Rows)
+	}
+	ret := 0.0
+	for _, w := range inst.weights {
+		ret += w
+	}
+	return ret
+}
+
+// GetWeightedClassDistribution returns the total weight of the rows
+// of each class. Rows whose class is missing aren't counted.
+func (inst *Instances) GetWeightedClassDistribution() map[string]float64 {
+	ret := make(map[string]float64)
+	for i := 0; i < inst.Rows; i++ {
+		if inst.IsMissing(i, inst.ClassIndex) {
+			continue
+		}
+		ret[inst.GetClass(i)] += inst.GetWeight(i)
+	}
+	return ret
+}
@@ -0,0 +1,48 @@
+package base
+
+import "testing"
+
+func TestWeights(testEnv *testing.T) {
+	inst, err := ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if inst.HasWeights() || inst.GetWeight(3) != 1 || inst.TotalWeight() != 150 {
+		testEnv.Fatal("Rows should have weight one by default")
+	}
+	inst.SetWeight(0, 1)
+	if inst.HasWeights() {
+		testEnv.Error("Setting a weight of one shouldn't allocate weights")
+	}
+	for i := 0; i < inst.Rows; i++ {
+		if inst.GetClass(i) == "Iris-setosa" {
+			inst.SetWeight(i, 2)
+		}
+	}
+	if w := inst.TotalWeight(); w != 200 {
+		testEnv.Error(w)
+	}
+	if dist := inst.GetWeightedClassDistribution(); dist["Iris-setosa"] != 100 || dist["Iris-virginica"] != 50 {
+		testEnv.Error(dist)
+	}
+
+	// Weights follow their rows
+	rows := inst.SelectRows([]int{0, 149})
+	if rows.GetWeight(0) != 2 || rows.GetWeight(1) != 1 {
+		testEnv.Error(rows.Weights())
+	}
+	inst.Shuffle()
+	for i := 0; i < inst.Rows; i++ {
+		if expected := map[bool]float64{true: 2, false: 1}[inst.GetClass(i) == "Iris-setosa"]; inst.GetWeight(i) != expected {
+			testEnv.Fatalf("Row %d has weight %f after shuffling", i, inst.GetWeight(i))
+		}
+	}
+	if sub := inst.SelectAttributes([]Attribute{inst.GetAttr(0)}); sub.TotalWeight() != 200 {
+		testEnv.Error(sub.TotalWeight())
+	}
+
+	inst.SetWeights(nil)
+	if inst.HasWeights() || inst.TotalWeight() != 150 {
+		testEnv.Error(inst.Weights())
+	}
+}
@@ -0,0 +1,93 @@
+package base
+
+// Filter returns a view (see ViewRows) of the rows for which keep
+// returns true, in order. Use SelectRows on the result for an
+// independent copy.
+func (inst *Instances) Filter(keep func(row int) bool) *Instances {
+	rows := make([]int, 0)
+	for i := 0; i < inst.Rows; i++ {
+		if keep(i) {
+			rows = append(rows, i)
+		}
+	}
+	return inst.ViewRows(rows)
+}
+
+// Condition tests the value of an Attribute, for Where. Test is
+// given its system representation, which is NaN if it's missing.
+type Condition struct {
+	Attribute Attribute
+	Test      func(sysVal float64) bool
+}
+
+// AttrEquals returns a Condition which holds if the value of a is
+// value, written as it would be in a CSV file.
+func AttrEquals(a Attribute, value string) Condition {
+	return AttrIn(a, value)
+}
+
+// AttrIn returns a Condition which holds if the value of a is any of
+// values, written as they would be in a CSV file.
+func AttrIn(a Attribute, values ...string) Condition {
+	set := make(map[string]bool)
+	for _, v := range values {
+		set[v] = true
+	}
+	return Condition{a, func(sysVal float64) bool {
+		return !IsMissingValue(sysVal) && set[a.GetStringFromSysVal(sysVal)]
+	}}
+}
+
+// AttrLess returns a Condition which holds if the value of a, which
+// should be numeric, is less than value.
+func AttrLess(a Attribute, value float64) Condition {
+	return Condition{a, func(sysVal float64) bool {
+		return sysVal < value
+	}}
+}
+
+// AttrGreater returns a Condition which holds if the value of a,
+// which should be numeric, is greater than value.
+func AttrGreater(a Attribute, value float64) Condition {
+	return Condition{a, func(sysVal float64) bool {
+		return sysVal > value
+	}}
+}
+
+// AttrMissing returns a Condition which holds if the value of a is
+// missing.
+func AttrMissing(a Attribute) Condition {
+	return Condition{a, IsMissingValue}
+}
+
+// Not returns a Condition which holds if c doesn't.
+func Not(c Condition) Condition {
+	return Condition{c.Attribute, func(sysVal float64) bool {
+		return !c.Test(sysVal)
+	}}
+}
+
+// Where returns a view (see ViewRows) of the rows for which all of
+// the conditions hold, e.g.
+//
+//	setosa := inst.Where(base.AttrEquals(inst.GetClassAttr(), "Iris-setosa"))
+//
+// IMPORTANT: this function panic()s if any of the Attributes can't
+// be found.
+func (inst *Instances) Where(conditions ...Condition) *Instances {
+	cols := make([]int, len(conditions))
+	for k, c := range conditions {
+		cols[k] = inst.GetAttrIndex(c.Attribute)
+		if cols[k] == -1 {
+			panic("Invalid attribute")
+		}
+	}
+	return inst.Filter(func(row int) bool {
+		for k, c := range conditions {
+			if !c.Test(inst.Get(row, cols[k])) {
+				return false
+			}
+		}
+		return true
+	})
+}
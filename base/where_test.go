@@ -0,0 +1,36 @@
+package base
+
+import "testing"
+
+func TestWhere(testEnv *testing.T) {
+	inst, err := ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	setosa := inst.Where(AttrEquals(inst.GetClassAttr(), "Iris-setosa"))
+	if setosa.Rows != 50 || setosa.GetClass(49) != "Iris-setosa" || !setosa.IsView() {
+		testEnv.Error(setosa.Rows)
+	}
+	others := inst.Where(Not(AttrEquals(inst.GetClassAttr(), "Iris-setosa")))
+	if others.Rows != 100 {
+		testEnv.Error(others.Rows)
+	}
+	long := inst.Where(AttrIn(inst.GetClassAttr(), "Iris-versicolor", "Iris-virginica"), AttrGreater(inst.GetAttr(2), 5))
+	for i := 0; i < long.Rows; i++ {
+		if long.Get(i, 2) <= 5 || long.GetClass(i) == "Iris-setosa" {
+			testEnv.Fatal(long.RowStr(i))
+		}
+	}
+	if short := inst.Where(AttrLess(inst.GetAttr(2), 2)); short.Rows != 50 {
+		testEnv.Error(short.Rows)
+	}
+
+	inst.SetMissing(3, 0)
+	if missing := inst.Where(AttrMissing(inst.GetAttr(0))); missing.Rows != 1 || missing.Get(0, 1) != inst.Get(3, 1) {
+		testEnv.Error(missing)
+	}
+	even := inst.Filter(func(row int) bool { return row%2 == 0 })
+	if even.Rows != 75 || even.Get(1, 1) != inst.Get(2, 1) {
+		testEnv.Error(even.Rows)
+	}
+}
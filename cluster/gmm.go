@@ -0,0 +1,424 @@
+package cluster
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	base "github.com/sjwhitworth/golearn/base"
+	mathutil "github.com/sjwhitworth/golearn/mathutil"
+)
+
+// GaussianMixtureParams holds the parameters of GaussianMixture.
+type GaussianMixtureParams struct {
+	// Components is the number of Gaussians in the mixture
+	Components int
+	// Covariance is the shape of each Gaussian: "full" (or ""),
+	// with any covariance between features, or "diagonal", with
+	// independent features, which needs far fewer parameters
+	Covariance string
+	// MaxIterations is the most EM steps each initialisation takes
+	MaxIterations int
+	// Tolerance stops EM once the mean log-likelihood of the rows
+	// improves by less than it
+	Tolerance float64
+	// Regularisation is added to the variance of every feature, so
+	// that covariances stay invertible
+	Regularisation float64
+	// Inits is the number of initialisations, of which the one with
+	// the highest log-likelihood is kept
+	Inits int
+	// Seed makes the initial means reproducible. Zero means a
+	// different random seed each time.
+	Seed int64
+}
+
+// DefaultGaussianMixtureParams returns the GaussianMixtureParams used
+// by NewGaussianMixture before its argument is applied.
+func DefaultGaussianMixtureParams() GaussianMixtureParams {
+	return GaussianMixtureParams{
+		Components:     1,
+		Covariance:     "full",
+		MaxIterations:  100,
+		Tolerance:      1e-3,
+		Regularisation: 1e-6,
+		Inits:          1,
+	}
+}
+
+// Validate checks that the GaussianMixtureParams are usable.
+func (p GaussianMixtureParams) Validate() error {
+	if p.Components < 1 {
+		return fmt.Errorf("cluster: Components should be at least 1, got %d", p.Components)
+	}
+	switch p.Covariance {
+	case "", "full", "diagonal":
+	default:
+		return fmt.Errorf("cluster: unknown Covariance %q", p.Covariance)
+	}
+	if p.MaxIterations < 1 {
+		return fmt.Errorf("cluster: MaxIterations should be at least 1, got %d", p.MaxIterations)
+	}
+	if p.Tolerance < 0 {
+		return fmt.Errorf("cluster: Tolerance can't be negative, got %f", p.Tolerance)
+	}
+	if p.Regularisation < 0 {
+		return fmt.Errorf("cluster: Regularisation can't be negative, got %f", p.Regularisation)
+	}
+	if p.Inits < 1 {
+		return fmt.Errorf("cluster: Inits should be at least 1, got %d", p.Inits)
+	}
+	return nil
+}
+
+// GaussianMixture models the rows as drawn from a mixture of
+// Gaussians, fit by expectation-maximisation: each component's
+// responsibility for each row is estimated from the current
+// parameters, then the parameters re-estimated from the rows it's
+// responsible for, until the likelihood stops improving. The initial
+// means are rows picked as in k-means++ (Arthur and Vassilvitskii,
+// 2007).
+//
+// Each row is the point given by the stored values of its features.
+// A missing value puts it at zero on that axis, which can drag a
+// component towards the origin, so they're best imputed first. The
+// covariances adapt to the scale of each feature, but the
+// initialisation and Regularisation don't, so very different scales
+// are still best standardised (see pipeline.Standardise).
+type GaussianMixture struct {
+	GaussianMixtureParams
+	// Weights holds the proportion of rows drawn from each
+	// component, Means the mean of each, and Covariances the
+	// covariance matrix of each, whose entries off the diagonal are
+	// zero if Covariance is "diagonal"
+	Weights     []float64
+	Means       [][]float64
+	Covariances [][][]float64
+	// LogLikelihood is the log-likelihood of the training rows
+	LogLikelihood float64
+	// Iterations is the number of EM steps taken, and Converged
+	// whether they stopped before MaxIterations
+	Iterations int
+	Converged  bool
+	// Attributes holds the features trained on
+	Attributes []base.Attribute
+	// cholesky holds the lower Cholesky factor of each covariance,
+	// worked out again if the GaussianMixture was decoded
+	cholesky [][][]float64
+}
+
+// NewGaussianMixture returns a GaussianMixture with the given number
+// of components, and the DefaultGaussianMixtureParams otherwise.
+func NewGaussianMixture(components int) *GaussianMixture {
+	params := DefaultGaussianMixtureParams()
+	params.Components = components
+	return &GaussianMixture{GaussianMixtureParams: params}
+}
+
+// NewGaussianMixtureFromParams returns a new GaussianMixture with the
+// given parameters, or an error if they're invalid.
+func NewGaussianMixtureFromParams(params GaussianMixtureParams) (*GaussianMixture, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	return &GaussianMixture{GaussianMixtureParams: params}, nil
+}
+
+// choleskyFactor returns the lower triangular L with L L' = a, or
+// false if a isn't positive definite
+func choleskyFactor(a [][]float64) ([][]float64, bool) {
+	ret := make([][]float64, len(a))
+	for i := range ret {
+		ret[i] = make([]float64, len(a))
+		for j := 0; j <= i; j++ {
+			sum := a[i][j]
+			for k := 0; k < j; k++ {
+				sum -= ret[i][k] * ret[j][k]
+			}
+			if i == j {
+				if sum <= 0 {
+					return nil, false
+				}
+				ret[i][i] = math.Sqrt(sum)
+			} else {
+				ret[i][j] = sum / ret[j][j]
+			}
+		}
+	}
+	return ret, true
+}
+
+// logDensity returns the log density of x under component k
+func (g *GaussianMixture) logDensity(x []float64, k int) float64 {
+	// With L L' the covariance, the squared Mahalanobis distance
+	// is |z|^2 where L z = x - mean
+	l := g.cholesky[k]
+	z := make([]float64, len(x))
+	distance, logDet := 0.0, 0.0
+	for i := range x {
+		sum := x[i] - g.Means[k][i]
+		for j := 0; j < i; j++ {
+			sum -= l[i][j] * z[j]
+		}
+		z[i] = sum / l[i][i]
+		distance += z[i] * z[i]
+		logDet += 2 * math.Log(l[i][i])
+	}
+	return -0.5 * (float64(len(x))*math.Log(2*math.Pi) + logDet + distance)
+}
+
+// expectation returns each component's responsibility for each row,
+// and the log-likelihood of the rows
+func (g *GaussianMixture) expectation(rows [][]float64) ([][]float64, float64) {
+	ret := make([][]float64, len(rows))
+	total := 0.0
+	for i, x := range rows {
+		ret[i] = make([]float64, g.Components)
+		for k := range ret[i] {
+			ret[i][k] = math.Log(g.Weights[k]) + g.logDensity(x, k)
+		}
+		norm := mathutil.LogSumExp(ret[i])
+		for k := range ret[i] {
+			ret[i][k] = math.Exp(ret[i][k] - norm)
+		}
+		total += norm
+	}
+	return ret, total
+}
+
+// maximisation re-estimates the parameters from the responsibilities,
+// or returns an error if a covariance isn't positive definite
+func (g *GaussianMixture) maximisation(rows, responsibilities [][]float64) error {
+	features := len(rows[0])
+	g.Weights = make([]float64, g.Components)
+	g.Means = make([][]float64, g.Components)
+	g.Covariances = make([][][]float64, g.Components)
+	for k := 0; k < g.Components; k++ {
+		// Components responsible for nothing keep a sliver of
+		// weight, so their log stays finite
+		total := 10 * math.SmallestNonzeroFloat64
+		g.Means[k] = make([]float64, features)
+		for i, x := range rows {
+			r := responsibilities[i][k]
+			total += r
+			for j, v := range x {
+				g.Means[k][j] += r * v
+			}
+		}
+		for j := range g.Means[k] {
+			g.Means[k][j] /= total
+		}
+		g.Weights[k] = total / float64(len(rows))
+
+		covariance := make([][]float64, features)
+		for a := range covariance {
+			covariance[a] = make([]float64, features)
+		}
+		for i, x := range rows {
+			r := responsibilities[i][k]
+			for a := range covariance {
+				da := x[a] - g.Means[k][a]
+				if g.Covariance == "diagonal" {
+					covariance[a][a] += r * da * da
+					continue
+				}
+				for b := 0; b <= a; b++ {
+					covariance[a][b] += r * da * (x[b] - g.Means[k][b])
+				}
+			}
+		}
+		for a := range covariance {
+			for b := 0; b <= a; b++ {
+				covariance[a][b] /= total
+				covariance[b][a] = covariance[a][b]
+			}
+			covariance[a][a] += g.Regularisation
+		}
+		g.Covariances[k] = covariance
+	}
+	return g.factorise()
+}
+
+// factorise works out the Cholesky factor of each covariance, or
+// returns an error if one isn't positive definite
+func (g *GaussianMixture) factorise() error {
+	g.cholesky = make([][][]float64, len(g.Covariances))
+	for k, covariance := range g.Covariances {
+		l, ok := choleskyFactor(covariance)
+		if !ok {
+			return fmt.Errorf("cluster: covariance of component %d isn't positive definite, try more Regularisation", k)
+		}
+		g.cholesky[k] = l
+	}
+	return nil
+}
+
+// initialise returns hard responsibilities assigning each row to the
+// nearest of Components rows picked by k-means++: each with
+// probability proportional to its squared distance from the nearest
+// picked so far
+func (g *GaussianMixture) initialise(rows [][]float64, rng *rand.Rand) [][]float64 {
+	centres := kmeansPlusPlus(rows, nil, g.Components, rng)
+	ret := make([][]float64, len(rows))
+	for i, x := range rows {
+		ret[i] = make([]float64, g.Components)
+		best, _ := nearestCentre(x, centres)
+		ret[i][best] = 1
+	}
+	return ret
+}
+
+// Fit fits the GaussianMixture to the rows of data, returning an
+// error if the parameters are invalid, there are fewer rows than
+// Components or a covariance can't be inverted.
+func (g *GaussianMixture) Fit(data *base.Instances) error {
+	if err := g.Validate(); err != nil {
+		return err
+	}
+	if data.Rows < g.Components {
+		return fmt.Errorf("cluster: %d rows can't fit %d components", data.Rows, g.Components)
+	}
+	cols := data.FeatureIndices()
+	g.Attributes = make([]base.Attribute, len(cols))
+	for k, j := range cols {
+		g.Attributes[k] = data.GetAttr(j)
+	}
+	rows := features(data)
+	seed := g.Seed
+	if seed == 0 {
+		seed = rand.Int63()
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	var best *GaussianMixture
+	for init := 0; init < g.Inits; init++ {
+		fitted := &GaussianMixture{GaussianMixtureParams: g.GaussianMixtureParams, Attributes: g.Attributes}
+		if err := fitted.maximisation(rows, fitted.initialise(rows, rng)); err != nil {
+			return err
+		}
+		previous := math.Inf(-1)
+		for fitted.Iterations < fitted.MaxIterations {
+			responsibilities, logLikelihood := fitted.expectation(rows)
+			fitted.Iterations++
+			fitted.LogLikelihood = logLikelihood
+			if (logLikelihood-previous)/float64(len(rows)) < fitted.Tolerance {
+				fitted.Converged = true
+				break
+			}
+			previous = logLikelihood
+			if err := fitted.maximisation(rows, responsibilities); err != nil {
+				return err
+			}
+		}
+		if !fitted.Converged {
+			_, fitted.LogLikelihood = fitted.expectation(rows)
+		}
+		if best == nil || fitted.LogLikelihood > best.LogLikelihood {
+			best = fitted
+		}
+	}
+	*g = *best
+	return nil
+}
+
+// rows returns the features of each row of what.
+//
+// IMPORTANT: this function panic()s if Fit hasn't been called, if
+// a covariance isn't positive definite, or if what is missing one of
+// the Attributes.
+func (g *GaussianMixture) rows(what *base.Instances) [][]float64 {
+	if g.Covariances == nil {
+		panic("Call Fit() beforehand")
+	}
+	if g.cholesky == nil {
+		if err := g.factorise(); err != nil {
+			panic(err.Error())
+		}
+	}
+	cols := what.GetAttrIndices(g.Attributes)
+	ret := make([][]float64, what.Rows)
+	for i := range ret {
+		ret[i] = featureRow(what, i, cols, make([]float64, len(cols)))
+	}
+	return ret
+}
+
+// Responsibilities returns the probability that each row of what was
+// drawn from each component.
+func (g *GaussianMixture) Responsibilities(what *base.Instances) [][]float64 {
+	ret, _ := g.expectation(g.rows(what))
+	return ret
+}
+
+// Predict returns the component most likely to have drawn each row
+// of what.
+func (g *GaussianMixture) Predict(what *base.Instances) []int {
+	responsibilities := g.Responsibilities(what)
+	ret := make([]int, len(responsibilities))
+	for i, r := range responsibilities {
+		for k := range r {
+			if r[k] > r[ret[i]] {
+				ret[i] = k
+			}
+		}
+	}
+	return ret
+}
+
+// ScoreSamples returns the log density of the mixture at each row of
+// what.
+func (g *GaussianMixture) ScoreSamples(what *base.Instances) []float64 {
+	rows := g.rows(what)
+	ret := make([]float64, len(rows))
+	logs := make([]float64, g.Components)
+	for i, x := range rows {
+		for k := range logs {
+			logs[k] = math.Log(g.Weights[k]) + g.logDensity(x, k)
+		}
+		ret[i] = mathutil.LogSumExp(logs)
+	}
+	return ret
+}
+
+// Score returns the log-likelihood of the rows of what.
+func (g *GaussianMixture) Score(what *base.Instances) float64 {
+	_, ret := g.expectation(g.rows(what))
+	return ret
+}
+
+// Parameters returns the number of free parameters of the mixture:
+// the weights (less one, since they sum to one), means and
+// covariances.
+func (g *GaussianMixture) Parameters() int {
+	features := len(g.Attributes)
+	covariances := features * (features + 1) / 2
+	if g.Covariance == "diagonal" {
+		covariances = features
+	}
+	return g.Components - 1 + g.Components*(features+covariances)
+}
+
+// BIC returns the Bayesian information criterion of the mixture on
+// the rows of what, which penalises each parameter by the log of
+// the number of rows. Lower is better, so fitting mixtures with
+// different numbers of components and keeping the lowest selects
+// one.
+func (g *GaussianMixture) BIC(what *base.Instances) float64 {
+	return -2*g.Score(what) + float64(g.Parameters())*math.Log(float64(what.Rows))
+}
+
+// AIC returns the Akaike information criterion of the mixture on the
+// rows of what, which penalises each parameter by two. Lower is
+// better, and it tends to pick more components than BIC.
+func (g *GaussianMixture) AIC(what *base.Instances) float64 {
+	return -2*g.Score(what) + 2*float64(g.Parameters())
+}
+
+// Clone returns an untrained GaussianMixture with the same parameters
+func (g *GaussianMixture) Clone() *GaussianMixture {
+	return &GaussianMixture{GaussianMixtureParams: g.GaussianMixtureParams}
+}
+
+func (g *GaussianMixture) String() string {
+	return fmt.Sprintf("GaussianMixture(Components: %d, Covariance: %q)", g.Components, g.Covariance)
+}
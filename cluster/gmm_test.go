@@ -0,0 +1,206 @@
+package cluster
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	base "github.com/sjwhitworth/golearn/base"
+)
+
+// newCorrelated returns rows drawn from a Gaussian with mean (1, -2),
+// variances 4 and 1, and covariance 1.5 between the features
+func newCorrelated(rows int, seed int64) *base.Instances {
+	rng := rand.New(rand.NewSource(seed))
+	data := base.MakeBlobs(rows, 1, 2, 1, seed)
+	for i := 0; i < rows; i++ {
+		a, b := rng.NormFloat64(), rng.NormFloat64()
+		// The Cholesky factor of the covariance is
+		// [[2, 0], [0.75, sqrt(1 - 0.75^2)]]
+		data.Set(i, 0, 1+2*a)
+		data.Set(i, 1, -2+0.75*a+math.Sqrt(1-0.75*0.75)*b)
+	}
+	return data
+}
+
+func TestGaussianMixture(testEnv *testing.T) {
+	data := base.MakeBlobs(300, 3, 2, 0.5, 1)
+	classes := make([]int, data.Rows)
+	for i := range classes {
+		classes[i] = int(data.Get(i, 2))
+	}
+	for _, covariance := range []string{"full", "diagonal"} {
+		g := NewGaussianMixture(3)
+		g.Covariance = covariance
+		g.Seed = 1
+		if err := g.Fit(data); err != nil {
+			testEnv.Fatal(err)
+		}
+		if !g.Converged || g.Iterations > g.MaxIterations {
+			testEnv.Errorf("%s: %d iterations", covariance, g.Iterations)
+		}
+		if !samePartition(g.Predict(data), classes) {
+			testEnv.Errorf("%s: components don't match the blobs", covariance)
+		}
+		total := 0.0
+		for k, w := range g.Weights {
+			total += w
+			if math.Abs(w-1.0/3) > 0.01 {
+				testEnv.Errorf("%s: component %d has weight %f", covariance, k, w)
+			}
+			for j := 0; j < 2; j++ {
+				if math.Abs(g.Covariances[k][j][j]-0.25) > 0.1 {
+					testEnv.Errorf("%s: component %d has variance %f", covariance, k, g.Covariances[k][j][j])
+				}
+			}
+		}
+		if math.Abs(total-1) > 1e-9 {
+			testEnv.Error(total)
+		}
+		for i, r := range g.Responsibilities(data) {
+			total := 0.0
+			for _, p := range r {
+				total += p
+			}
+			if math.Abs(total-1) > 1e-9 {
+				testEnv.Fatalf("%s: row %d has responsibilities %v", covariance, i, r)
+			}
+		}
+		if math.Abs(g.Score(data)-g.LogLikelihood) > 1e-6 {
+			testEnv.Errorf("%s: scores %f, but trained to %f", covariance, g.Score(data), g.LogLikelihood)
+		}
+		total = 0.0
+		for _, s := range g.ScoreSamples(data) {
+			total += s
+		}
+		if math.Abs(total-g.LogLikelihood) > 1e-6 {
+			testEnv.Errorf("%s: samples score %f", covariance, total)
+		}
+	}
+}
+
+func TestGaussianMixtureCovariance(testEnv *testing.T) {
+	data := newCorrelated(2000, 2)
+	full := NewGaussianMixture(1)
+	if err := full.Fit(data); err != nil {
+		testEnv.Fatal(err)
+	}
+	expected := [][]float64{{4, 1.5}, {1.5, 1}}
+	for a := range expected {
+		for b := range expected[a] {
+			if math.Abs(full.Covariances[0][a][b]-expected[a][b]) > 0.2 {
+				testEnv.Errorf("Covariance %d, %d is %f", a, b, full.Covariances[0][a][b])
+			}
+		}
+	}
+	if math.Abs(full.Means[0][0]-1) > 0.1 || math.Abs(full.Means[0][1]+2) > 0.1 {
+		testEnv.Error(full.Means)
+	}
+
+	diagonal := NewGaussianMixture(1)
+	diagonal.Covariance = "diagonal"
+	if err := diagonal.Fit(data); err != nil {
+		testEnv.Fatal(err)
+	}
+	if diagonal.Covariances[0][0][1] != 0 || math.Abs(diagonal.Covariances[0][0][0]-4) > 0.2 {
+		testEnv.Error(diagonal.Covariances)
+	}
+	if full.Parameters() != 5 || diagonal.Parameters() != 4 {
+		testEnv.Error(full.Parameters(), diagonal.Parameters())
+	}
+	// The correlation is worth the extra parameter
+	if full.BIC(data) >= diagonal.BIC(data) {
+		testEnv.Errorf("Full BIC %f should beat diagonal %f", full.BIC(data), diagonal.BIC(data))
+	}
+
+	// A GaussianMixture rebuilt from its exported fields, as when
+	// decoded, scores the same
+	decoded := &GaussianMixture{
+		GaussianMixtureParams: full.GaussianMixtureParams,
+		Weights:               full.Weights,
+		Means:                 full.Means,
+		Covariances:           full.Covariances,
+		Attributes:            full.Attributes,
+	}
+	if math.Abs(decoded.Score(data)-full.Score(data)) > 1e-6 {
+		testEnv.Error(decoded.Score(data), full.Score(data))
+	}
+}
+
+func TestGaussianMixtureSelection(testEnv *testing.T) {
+	data := base.MakeBlobs(300, 3, 2, 0.5, 3)
+	bics := make([]float64, 6)
+	aics := make([]float64, 6)
+	previous := math.Inf(-1)
+	for k := 1; k <= 5; k++ {
+		g := NewGaussianMixture(k)
+		g.Inits = 3
+		g.Seed = 1
+		if err := g.Fit(data); err != nil {
+			testEnv.Fatal(err)
+		}
+		// More components never fit worse
+		if g.LogLikelihood < previous-1 {
+			testEnv.Errorf("%d components: %f, fewer had %f", k, g.LogLikelihood, previous)
+		}
+		previous = g.LogLikelihood
+		bics[k], aics[k] = g.BIC(data), g.AIC(data)
+		if aics[k] >= bics[k] {
+			testEnv.Errorf("AIC %f should penalise less than BIC %f", aics[k], bics[k])
+		}
+	}
+	for k := 1; k <= 5; k++ {
+		if k != 3 && bics[k] <= bics[3] {
+			testEnv.Errorf("BIC picks %d components over 3: %v", k, bics[1:])
+		}
+	}
+}
+
+func TestGaussianMixtureClone(testEnv *testing.T) {
+	data := base.MakeBlobs(200, 2, 2, 0.5, 1)
+	g := NewGaussianMixture(2)
+	g.Covariance, g.Inits, g.Seed = "diagonal", 2, 1
+	if err := g.Fit(data); err != nil {
+		testEnv.Fatal(err)
+	}
+	clone := g.Clone()
+	if clone == g || clone.GaussianMixtureParams != g.GaussianMixtureParams {
+		testEnv.Fatal(clone)
+	}
+	if clone.Weights != nil || clone.Means != nil || clone.Covariances != nil || clone.Attributes != nil {
+		testEnv.Error("Clone shouldn't be trained")
+	}
+	// With the same Seed, it finds the same mixture
+	if err := clone.Fit(data); err != nil {
+		testEnv.Fatal(err)
+	}
+	if clone.LogLikelihood != g.LogLikelihood {
+		testEnv.Error(clone.LogLikelihood, g.LogLikelihood)
+	}
+}
+
+func TestGaussianMixtureParams(testEnv *testing.T) {
+	if err := DefaultGaussianMixtureParams().Validate(); err != nil {
+		testEnv.Error(err)
+	}
+	valid := DefaultGaussianMixtureParams()
+	for _, change := range []func(p *GaussianMixtureParams){
+		func(p *GaussianMixtureParams) { p.Components = 0 },
+		func(p *GaussianMixtureParams) { p.Covariance = "spherical" },
+		func(p *GaussianMixtureParams) { p.MaxIterations = 0 },
+		func(p *GaussianMixtureParams) { p.Tolerance = -1 },
+		func(p *GaussianMixtureParams) { p.Regularisation = -1 },
+		func(p *GaussianMixtureParams) { p.Inits = 0 },
+	} {
+		params := valid
+		change(&params)
+		if _, err := NewGaussianMixtureFromParams(params); err == nil {
+			testEnv.Errorf("%+v should be invalid", params)
+		}
+	}
+
+	g := NewGaussianMixture(5)
+	if err := g.Fit(base.MakeBlobs(3, 1, 2, 1, 1)); err == nil {
+		testEnv.Error("3 rows shouldn't fit 5 components")
+	}
+}
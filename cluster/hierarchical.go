@@ -0,0 +1,364 @@
+// Package cluster groups the rows of Instances by their features,
+// without looking at their class.
+package cluster
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	base "github.com/sjwhitworth/golearn/base"
+)
+
+// Merge is one step of agglomerative clustering, which joins two
+// clusters into one. Clusters 0 to n-1 are the n rows, and cluster
+// n+m is the one made by Merges[m].
+type Merge struct {
+	// Left and Right are the clusters joined, with Left the lower
+	Left, Right int
+	// Height is the distance between them under the linkage
+	Height float64
+	// Size is the number of rows in the new cluster
+	Size int
+}
+
+// Dendrogram is the tree of Merges made by Agglomerate, from the
+// rows up, in order of increasing Height.
+type Dendrogram struct {
+	// Labels names each row in Newick and JSON exports. It holds
+	// the row numbers unless set otherwise.
+	Labels []string
+	Merges []Merge
+}
+
+// features returns the features of each row of data, with missing
+// values as zero
+func features(data *base.Instances) [][]float64 {
+	cols := data.FeatureIndices()
+	ret := make([][]float64, data.Rows)
+	for i := range ret {
+		ret[i] = featureRow(data, i, cols, make([]float64, len(cols)))
+	}
+	return ret
+}
+
+// featureRow fills dst with the values of cols in row i of data, with
+// missing values as zero, and returns it
+func featureRow(data *base.Instances, i int, cols []int, dst []float64) []float64 {
+	for k, j := range cols {
+		dst[k] = 0
+		if v := data.Get(i, j); !base.IsMissingValue(v) {
+			dst[k] = v
+		}
+	}
+	return dst
+}
+
+// linkageUpdate returns the distance from cluster k to the union of
+// clusters i and j, given the distances between the three and their
+// sizes, by the Lance-Williams formula for the linkage
+func linkageUpdate(linkage string, dki, dkj, dij float64, ni, nj, nk int) float64 {
+	switch linkage {
+	case "single":
+		return math.Min(dki, dkj)
+	case "complete":
+		return math.Max(dki, dkj)
+	case "average":
+		return (float64(ni)*dki + float64(nj)*dkj) / float64(ni+nj)
+	}
+	// Ward's
+	squared := (float64(ni+nk)*dki*dki + float64(nj+nk)*dkj*dkj - float64(nk)*dij*dij) / float64(ni+nj+nk)
+	return math.Sqrt(math.Max(squared, 0))
+}
+
+// foundMerge is a merge as Agglomerate finds it: of the clusters
+// holding rows a and b, at height
+type foundMerge struct {
+	a, b   int
+	height float64
+}
+
+// byHeight sorts merges from the lowest
+type byHeight []foundMerge
+
+func (m byHeight) Len() int           { return len(m) }
+func (m byHeight) Swap(i, j int)      { m[i], m[j] = m[j], m[i] }
+func (m byHeight) Less(i, j int) bool { return m[i].height < m[j].height }
+
+// Agglomerate clusters the rows of data hierarchically: starting with
+// each row on its own, it repeatedly merges the two closest clusters
+// until one is left. The distance between rows is Euclidean, and
+// between clusters is given by the linkage:
+//
+//	"single": the distance between their closest rows
+//	"complete": the distance between their furthest rows
+//	"average": the mean distance between their rows
+//	"ward": the increase in the variance within clusters from
+//	merging them (Ward, 1963), scaled to match the distance between
+//	single rows
+//
+// The distance between every pair of rows is kept, so memory grows
+// with the square of the number of rows. Merges are found with the
+// nearest-neighbour chain algorithm, so time does too.
+func Agglomerate(data *base.Instances, linkage string) (*Dendrogram, error) {
+	switch linkage {
+	case "single", "complete", "average", "ward":
+	default:
+		return nil, fmt.Errorf("cluster: unknown linkage %q", linkage)
+	}
+	if data.Rows == 0 {
+		return nil, fmt.Errorf("cluster: no rows to cluster")
+	}
+	rows := features(data)
+	n := len(rows)
+	distances := make([][]float64, n)
+	for i := range distances {
+		distances[i] = make([]float64, n)
+		for j := 0; j < i; j++ {
+			sum := 0.0
+			for k := range rows[i] {
+				sum += (rows[i][k] - rows[j][k]) * (rows[i][k] - rows[j][k])
+			}
+			distances[i][j] = math.Sqrt(sum)
+			distances[j][i] = distances[i][j]
+		}
+	}
+
+	// Each slot of distances holds an active cluster until it's
+	// merged into another. Merges are found out of order, so they're
+	// recorded by a row from each side, then sorted.
+	merges := make([]foundMerge, 0, n-1)
+	active := make([]bool, n)
+	sizes := make([]int, n)
+	for i := range active {
+		active[i], sizes[i] = true, 1
+	}
+	chain := make([]int, 0)
+	for len(merges) < n-1 {
+		if len(chain) == 0 {
+			for i := range active {
+				if active[i] {
+					chain = append(chain, i)
+					break
+				}
+			}
+		}
+		a := chain[len(chain)-1]
+		// Ties go to the previous cluster in the chain, so that
+		// it always ends
+		b, nearest := -1, math.Inf(1)
+		if len(chain) > 1 {
+			b = chain[len(chain)-2]
+			nearest = distances[a][b]
+		}
+		for k := range active {
+			if active[k] && k != a && distances[a][k] < nearest {
+				b, nearest = k, distances[a][k]
+			}
+		}
+		if len(chain) < 2 || b != chain[len(chain)-2] {
+			chain = append(chain, b)
+			continue
+		}
+		chain = chain[:len(chain)-2]
+		merges = append(merges, foundMerge{a, b, nearest})
+		// The union takes a's slot
+		for k := range active {
+			if active[k] && k != a && k != b {
+				distances[a][k] = linkageUpdate(linkage, distances[k][a], distances[k][b], nearest, sizes[a], sizes[b], sizes[k])
+				distances[k][a] = distances[a][k]
+			}
+		}
+		active[b] = false
+		sizes[a] += sizes[b]
+	}
+	// Merges at the same height keep their order, so none comes
+	// before one it depends on
+	sort.Stable(byHeight(merges))
+
+	ret := &Dendrogram{Labels: make([]string, n), Merges: make([]Merge, len(merges))}
+	for i := range ret.Labels {
+		ret.Labels[i] = strconv.Itoa(i)
+	}
+	sets := newDisjointSets(n)
+	ids := make([]int, n)
+	for i := range ids {
+		ids[i] = i
+	}
+	for m, f := range merges {
+		left, right := ids[sets.find(f.a)], ids[sets.find(f.b)]
+		if left > right {
+			left, right = right, left
+		}
+		root := sets.union(f.a, f.b)
+		ids[root] = n + m
+		ret.Merges[m] = Merge{left, right, f.height, sets.sizes[root]}
+	}
+	return ret, nil
+}
+
+// disjointSets is a union-find structure over rows
+type disjointSets struct {
+	parents, sizes []int
+}
+
+func newDisjointSets(n int) *disjointSets {
+	ret := &disjointSets{make([]int, n), make([]int, n)}
+	for i := range ret.parents {
+		ret.parents[i], ret.sizes[i] = i, 1
+	}
+	return ret
+}
+
+// find returns the root of i's set
+func (s *disjointSets) find(i int) int {
+	for s.parents[i] != i {
+		s.parents[i] = s.parents[s.parents[i]]
+		i = s.parents[i]
+	}
+	return i
+}
+
+// union joins the sets of i and j, and returns the new root
+func (s *disjointSets) union(i, j int) int {
+	i, j = s.find(i), s.find(j)
+	if i == j {
+		return i
+	}
+	if s.sizes[i] < s.sizes[j] {
+		i, j = j, i
+	}
+	s.parents[j] = i
+	s.sizes[i] += s.sizes[j]
+	return i
+}
+
+// leaf returns a row of cluster c
+func (d *Dendrogram) leaf(c int) int {
+	for c >= len(d.Labels) {
+		c = d.Merges[c-len(d.Labels)].Left
+	}
+	return c
+}
+
+// assign returns the cluster of each row after the first merges
+// Merges, numbered from zero in order of their first row
+func (d *Dendrogram) assign(merges int) []int {
+	sets := newDisjointSets(len(d.Labels))
+	for _, m := range d.Merges[:merges] {
+		sets.union(d.leaf(m.Left), d.leaf(m.Right))
+	}
+	ret := make([]int, len(d.Labels))
+	numbers := make(map[int]int)
+	for i := range ret {
+		root := sets.find(i)
+		if _, ok := numbers[root]; !ok {
+			numbers[root] = len(numbers)
+		}
+		ret[i] = numbers[root]
+	}
+	return ret
+}
+
+// CutHeight returns the cluster of each row when the Dendrogram is
+// cut at the given height, so that clusters are only merged if
+// they're no further apart than it. Clusters are numbered from zero
+// in order of their first row.
+func (d *Dendrogram) CutHeight(height float64) []int {
+	merges := sort.Search(len(d.Merges), func(m int) bool {
+		return d.Merges[m].Height > height
+	})
+	return d.assign(merges)
+}
+
+// CutClusters returns the cluster of each row when the Dendrogram is
+// cut where there are k clusters, numbered from zero in order of
+// their first row, or an error if k isn't between one and the number
+// of rows.
+func (d *Dendrogram) CutClusters(k int) ([]int, error) {
+	if k < 1 || k > len(d.Labels) {
+		return nil, fmt.Errorf("cluster: can't cut %d rows into %d clusters", len(d.Labels), k)
+	}
+	return d.assign(len(d.Labels) - k), nil
+}
+
+// height returns the height of cluster c
+func (d *Dendrogram) height(c int) float64 {
+	if c < len(d.Labels) {
+		return 0
+	}
+	return d.Merges[c-len(d.Labels)].Height
+}
+
+// newickLabel quotes label if it has any characters which mean
+// something in the Newick format
+func newickLabel(label string) string {
+	if !strings.ContainsAny(label, " \t\n()[]':;,_") {
+		return label
+	}
+	return "'" + strings.Replace(label, "'", "''", -1) + "'"
+}
+
+// writeNewick writes cluster c in the Newick format to b
+func (d *Dendrogram) writeNewick(b *bytes.Buffer, c int) {
+	if c < len(d.Labels) {
+		b.WriteString(newickLabel(d.Labels[c]))
+		return
+	}
+	m := d.Merges[c-len(d.Labels)]
+	b.WriteString("(")
+	for k, child := range []int{m.Left, m.Right} {
+		if k > 0 {
+			b.WriteString(",")
+		}
+		d.writeNewick(b, child)
+		b.WriteString(":")
+		b.WriteString(strconv.FormatFloat(m.Height-d.height(child), 'g', -1, 64))
+	}
+	b.WriteString(")")
+}
+
+// Newick returns the Dendrogram in the Newick format, e.g.
+// "((0:1,1:1):1.5,2:2.5);", with each row named by its label and
+// each branch as long as the difference in height it spans, for
+// viewing in phylogenetic tree tools.
+func (d *Dendrogram) Newick() string {
+	b := &bytes.Buffer{}
+	d.writeNewick(b, len(d.Labels)+len(d.Merges)-1)
+	b.WriteString(";")
+	return b.String()
+}
+
+// dendrogramJSON is the JSON form of a cluster of a Dendrogram
+type dendrogramJSON struct {
+	Label    *string          `json:"label,omitempty"`
+	Row      *int             `json:"row,omitempty"`
+	Height   float64          `json:"height"`
+	Size     int              `json:"size"`
+	Children []dendrogramJSON `json:"children,omitempty"`
+}
+
+// toJSON returns the JSON form of cluster c
+func (d *Dendrogram) toJSON(c int) dendrogramJSON {
+	if c < len(d.Labels) {
+		row := c
+		return dendrogramJSON{Label: &d.Labels[c], Row: &row, Size: 1}
+	}
+	m := d.Merges[c-len(d.Labels)]
+	return dendrogramJSON{
+		Height:   m.Height,
+		Size:     m.Size,
+		Children: []dendrogramJSON{d.toJSON(m.Left), d.toJSON(m.Right)},
+	}
+}
+
+// MarshalJSON encodes the Dendrogram as a nested JSON object: each
+// cluster has its height, size and two children, and each row its
+// label and row number, with a height of zero.
+func (d *Dendrogram) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.toJSON(len(d.Labels) + len(d.Merges) - 1))
+}
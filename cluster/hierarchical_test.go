@@ -0,0 +1,136 @@
+package cluster
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+
+	base "github.com/sjwhitworth/golearn/base"
+)
+
+// newPoints returns Instances with a single feature holding xs
+func newPoints(xs ...float64) *base.Instances {
+	x := base.NewFloatAttribute()
+	x.SetName("x")
+	class := base.NewFloatAttribute()
+	class.SetName("class")
+	ret := base.NewInstances([]base.Attribute{x, class}, len(xs))
+	for i, v := range xs {
+		ret.Set(i, 0, v)
+	}
+	return ret
+}
+
+// samePartition returns true if a and b group the rows the same way
+func samePartition(a, b []int) bool {
+	forward, backward := make(map[int]int), make(map[int]int)
+	for i := range a {
+		if c, ok := forward[a[i]]; ok && c != b[i] {
+			return false
+		}
+		if c, ok := backward[b[i]]; ok && c != a[i] {
+			return false
+		}
+		forward[a[i]], backward[b[i]] = b[i], a[i]
+	}
+	return true
+}
+
+func TestAgglomerate(testEnv *testing.T) {
+	data := newPoints(7, 0, 3, 1)
+	for linkage, heights := range map[string][]float64{
+		"single":   {1, 2, 4},
+		"complete": {1, 3, 7},
+		"average":  {1, 2.5, 17.0 / 3},
+		"ward":     {1, math.Sqrt(25.0 / 3), math.Sqrt(1.5) * 17 / 3},
+	} {
+		d, err := Agglomerate(data, linkage)
+		if err != nil {
+			testEnv.Fatal(err)
+		}
+		if len(d.Merges) != 3 {
+			testEnv.Fatalf("%s: %v", linkage, d.Merges)
+		}
+		expected := []Merge{{1, 3, heights[0], 2}, {2, 4, heights[1], 3}, {0, 5, heights[2], 4}}
+		for m, e := range expected {
+			got := d.Merges[m]
+			if got.Left != e.Left || got.Right != e.Right || got.Size != e.Size || math.Abs(got.Height-e.Height) > 1e-9 {
+				testEnv.Errorf("%s: merge %d is %v, expected %v", linkage, m, got, e)
+			}
+		}
+	}
+	if _, err := Agglomerate(data, "centroid"); err == nil {
+		testEnv.Error("Unknown linkages should be rejected")
+	}
+}
+
+func TestDendrogramCut(testEnv *testing.T) {
+	data := base.MakeBlobs(90, 3, 2, 0.5, 1)
+	classes := make([]int, data.Rows)
+	for i := range classes {
+		classes[i] = int(data.Get(i, 2))
+	}
+	for _, linkage := range []string{"single", "complete", "average", "ward"} {
+		d, err := Agglomerate(data, linkage)
+		if err != nil {
+			testEnv.Fatal(err)
+		}
+		clusters, err := d.CutClusters(3)
+		if err != nil {
+			testEnv.Fatal(err)
+		}
+		if !samePartition(clusters, classes) {
+			testEnv.Errorf("%s: %v", linkage, clusters)
+		}
+		// Cutting between the last merges gives the same clusters
+		height := (d.Merges[len(d.Merges)-3].Height + d.Merges[len(d.Merges)-2].Height) / 2
+		if byHeight := d.CutHeight(height); !reflect.DeepEqual(byHeight, clusters) {
+			testEnv.Errorf("%s: cut at %f gives %v", linkage, height, byHeight)
+		}
+	}
+
+	d, _ := Agglomerate(newPoints(7, 0, 3, 1), "single")
+	if clusters := d.CutHeight(1.5); !reflect.DeepEqual(clusters, []int{0, 1, 2, 1}) {
+		testEnv.Error(clusters)
+	}
+	if clusters, _ := d.CutClusters(4); !reflect.DeepEqual(clusters, []int{0, 1, 2, 3}) {
+		testEnv.Error(clusters)
+	}
+	if clusters, _ := d.CutClusters(1); !reflect.DeepEqual(clusters, []int{0, 0, 0, 0}) {
+		testEnv.Error(clusters)
+	}
+	if _, err := d.CutClusters(5); err == nil {
+		testEnv.Error("Can't cut 4 rows into 5 clusters")
+	}
+}
+
+func TestDendrogramExport(testEnv *testing.T) {
+	d, err := Agglomerate(newPoints(7, 0, 3, 1), "single")
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if newick := d.Newick(); newick != "(0:4,(2:2,(1:1,3:1):1):2);" {
+		testEnv.Error(newick)
+	}
+	d.Labels[0] = "far away"
+	if newick := d.Newick(); newick != "('far away':4,(2:2,(1:1,3:1):1):2);" {
+		testEnv.Error(newick)
+	}
+
+	encoded, err := json.Marshal(d)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		testEnv.Fatal(err)
+	}
+	if decoded["height"] != 4.0 || decoded["size"] != 4.0 {
+		testEnv.Error(string(encoded))
+	}
+	leaf := decoded["children"].([]interface{})[0].(map[string]interface{})
+	if leaf["label"] != "far away" || leaf["row"] != 0.0 || leaf["children"] != nil {
+		testEnv.Error(string(encoded))
+	}
+}
@@ -0,0 +1,410 @@
+package cluster
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	base "github.com/sjwhitworth/golearn/base"
+)
+
+// KMeansParams holds the parameters of KMeans.
+type KMeansParams struct {
+	// Clusters is the number of centres
+	Clusters int
+	// Init picks the initial centres: "k-means++" (or ""), rows
+	// spread out by picking each with probability proportional to
+	// its squared distance from the nearest picked so far, or
+	// "random", rows picked uniformly
+	Init string
+	// BatchSize, if it isn't zero, trains on a random sample of that
+	// many rows at each iteration, moving each centre towards the
+	// rows nearest it by a step which shrinks as it sees more of
+	// them (Sculley, 2010). Memory and time per iteration then
+	// don't grow with the number of rows. Zero means Lloyd's
+	// algorithm, which moves each centre to the mean of the rows
+	// nearest it at each iteration.
+	BatchSize int
+	// MaxIterations is the most iterations each initialisation
+	// takes
+	MaxIterations int
+	// Tolerance stops the iterations once no centre moves further
+	// than it. With a BatchSize, the centres keep moving with the
+	// noise of the batches, by less as they see more rows, so a
+	// larger Tolerance suits it: of the order of the spread of the
+	// clusters divided by the number of rows to train on.
+	Tolerance float64
+	// Inits is the number of initialisations, of which the one with
+	// the lowest Inertia is kept
+	Inits int
+	// Seed makes the initial centres and the batches reproducible.
+	// Zero means a different random seed each time.
+	Seed int64
+}
+
+// DefaultKMeansParams returns the KMeansParams used by NewKMeans
+// before its argument is applied.
+func DefaultKMeansParams() KMeansParams {
+	return KMeansParams{
+		Clusters:      8,
+		Init:          "k-means++",
+		MaxIterations: 300,
+		Tolerance:     1e-4,
+		Inits:         1,
+	}
+}
+
+// Validate checks that the KMeansParams are usable.
+func (p KMeansParams) Validate() error {
+	if p.Clusters < 1 {
+		return fmt.Errorf("cluster: Clusters should be at least 1, got %d", p.Clusters)
+	}
+	switch p.Init {
+	case "", "k-means++", "random":
+	default:
+		return fmt.Errorf("cluster: unknown Init %q", p.Init)
+	}
+	if p.BatchSize < 0 {
+		return fmt.Errorf("cluster: BatchSize can't be negative, got %d", p.BatchSize)
+	}
+	if p.MaxIterations < 1 {
+		return fmt.Errorf("cluster: MaxIterations should be at least 1, got %d", p.MaxIterations)
+	}
+	if p.Tolerance < 0 {
+		return fmt.Errorf("cluster: Tolerance can't be negative, got %f", p.Tolerance)
+	}
+	if p.Inits < 1 {
+		return fmt.Errorf("cluster: Inits should be at least 1, got %d", p.Inits)
+	}
+	return nil
+}
+
+// KMeans divides the rows into Clusters groups, each represented by
+// a centre, so as to minimise the (row-weighted, see
+// base.Instances.SetWeight) sum of squared distances from each row to
+// the nearest centre. The initial centres are picked by k-means++
+// (Arthur and Vassilvitskii, 2007) unless Init says otherwise.
+//
+// Rows are read from the Instances as they're needed, so training
+// with a BatchSize only holds the centres, a batch, and the sample
+// of rows the initial centres are picked from (three batches, or all
+// the rows without a BatchSize) in memory. As for GaussianMixture,
+// each row is the point given by the stored values of its features,
+// with missing values as zero; Euclidean distance weighs every
+// feature alike, so they should have similar scales (see
+// pipeline.Standardise).
+type KMeans struct {
+	KMeansParams
+	// Centres holds the centre of each cluster
+	Centres [][]float64
+	// Inertia is the sum of squared distances from each training
+	// row to the nearest centre, times the row's weight
+	Inertia float64
+	// Iterations is the number of iterations taken, and Converged
+	// whether they stopped before MaxIterations
+	Iterations int
+	Converged  bool
+	// Attributes holds the features trained on
+	Attributes []base.Attribute
+}
+
+// NewKMeans returns a KMeans with the given number of clusters, and
+// the DefaultKMeansParams otherwise.
+func NewKMeans(clusters int) *KMeans {
+	params := DefaultKMeansParams()
+	params.Clusters = clusters
+	return &KMeans{KMeansParams: params}
+}
+
+// NewKMeansFromParams returns a new KMeans with the given parameters,
+// or an error if they're invalid.
+func NewKMeansFromParams(params KMeansParams) (*KMeans, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	return &KMeans{KMeansParams: params}, nil
+}
+
+// squaredDistance returns the squared Euclidean distance between x
+// and y
+func squaredDistance(x, y []float64) float64 {
+	ret := 0.0
+	for j := range x {
+		ret += (x[j] - y[j]) * (x[j] - y[j])
+	}
+	return ret
+}
+
+// nearestCentre returns the index of the centre nearest x, and its
+// squared distance
+func nearestCentre(x []float64, centres [][]float64) (int, float64) {
+	best, distance := 0, math.Inf(1)
+	for k, c := range centres {
+		if d := squaredDistance(x, c); d < distance {
+			best, distance = k, d
+		}
+	}
+	return best, distance
+}
+
+// kmeansPlusPlus returns k of rows picked by k-means++: the first
+// uniformly, then each with probability proportional to its squared
+// distance from the nearest picked so far, times its weight (all
+// ones if weights is nil)
+func kmeansPlusPlus(rows [][]float64, weights []float64, k int, rng *rand.Rand) [][]float64 {
+	centres := [][]float64{rows[rng.Intn(len(rows))]}
+	nearest := make([]float64, len(rows))
+	for i, x := range rows {
+		nearest[i] = squaredDistance(x, centres[0])
+		if weights != nil {
+			nearest[i] *= weights[i]
+		}
+	}
+	for len(centres) < k {
+		total := 0.0
+		for _, d := range nearest {
+			total += d
+		}
+		next := rng.Intn(len(rows))
+		if total > 0 {
+			target := rng.Float64() * total
+			for i, d := range nearest {
+				target -= d
+				if target < 0 {
+					next = i
+					break
+				}
+			}
+		}
+		centres = append(centres, rows[next])
+		for i, x := range rows {
+			d := squaredDistance(x, rows[next])
+			if weights != nil {
+				d *= weights[i]
+			}
+			nearest[i] = math.Min(nearest[i], d)
+		}
+	}
+	return centres
+}
+
+// initialise returns the initial centres, picked from a sample of
+// the rows of data
+func (m *KMeans) initialise(data *base.Instances, cols []int, rng *rand.Rand) [][]float64 {
+	size := 3 * m.BatchSize
+	if size < m.Clusters {
+		size = m.Clusters
+	}
+	var sample []int
+	if m.BatchSize == 0 || size >= data.Rows {
+		sample = rng.Perm(data.Rows)
+	} else {
+		// Draw distinct rows without permuting all of them
+		seen := make(map[int]bool)
+		for len(sample) < size {
+			if i := rng.Intn(data.Rows); !seen[i] {
+				seen[i] = true
+				sample = append(sample, i)
+			}
+		}
+	}
+	rows := make([][]float64, len(sample))
+	weights := make([]float64, len(sample))
+	for s, i := range sample {
+		rows[s] = featureRow(data, i, cols, make([]float64, len(cols)))
+		weights[s] = data.GetWeight(i)
+	}
+	var picked [][]float64
+	if m.Init == "random" {
+		picked = rows[:m.Clusters]
+	} else {
+		picked = kmeansPlusPlus(rows, weights, m.Clusters, rng)
+	}
+	ret := make([][]float64, len(picked))
+	for k := range picked {
+		ret[k] = append([]float64(nil), picked[k]...)
+	}
+	return ret
+}
+
+// lloydStep moves each centre to the weighted mean of the rows of
+// data nearest it, leaving those with no rows where they are, and
+// returns the furthest any moved
+func (m *KMeans) lloydStep(data *base.Instances, cols []int) float64 {
+	sums := make([][]float64, len(m.Centres))
+	totals := make([]float64, len(m.Centres))
+	for k := range sums {
+		sums[k] = make([]float64, len(cols))
+	}
+	x := make([]float64, len(cols))
+	for i := 0; i < data.Rows; i++ {
+		featureRow(data, i, cols, x)
+		k, _ := nearestCentre(x, m.Centres)
+		w := data.GetWeight(i)
+		totals[k] += w
+		for j, v := range x {
+			sums[k][j] += w * v
+		}
+	}
+	shift := 0.0
+	for k, c := range m.Centres {
+		if totals[k] == 0 {
+			continue
+		}
+		for j := range sums[k] {
+			sums[k][j] /= totals[k]
+		}
+		shift = math.Max(shift, squaredDistance(c, sums[k]))
+		m.Centres[k] = sums[k]
+	}
+	return math.Sqrt(shift)
+}
+
+// batchStep moves the centres towards the rows of a random batch of
+// data nearest them, each by its share of the weight the centre has
+// seen so far (added to counts), and returns the furthest any moved
+func (m *KMeans) batchStep(data *base.Instances, cols []int, counts []float64, rng *rand.Rand) float64 {
+	batch := make([][]float64, m.BatchSize)
+	nearest := make([]int, m.BatchSize)
+	weights := make([]float64, m.BatchSize)
+	for b := range batch {
+		i := rng.Intn(data.Rows)
+		batch[b] = featureRow(data, i, cols, make([]float64, len(cols)))
+		nearest[b], _ = nearestCentre(batch[b], m.Centres)
+		weights[b] = data.GetWeight(i)
+	}
+	old := make([][]float64, len(m.Centres))
+	for k, c := range m.Centres {
+		old[k] = append([]float64(nil), c...)
+	}
+	for b, x := range batch {
+		k := nearest[b]
+		counts[k] += weights[b]
+		if counts[k] == 0 {
+			continue
+		}
+		rate := weights[b] / counts[k]
+		for j, v := range x {
+			m.Centres[k][j] += rate * (v - m.Centres[k][j])
+		}
+	}
+	shift := 0.0
+	for k := range old {
+		shift = math.Max(shift, squaredDistance(old[k], m.Centres[k]))
+	}
+	return math.Sqrt(shift)
+}
+
+// inertia returns the weighted sum of squared distances from each
+// row of data to the nearest centre
+func (m *KMeans) inertia(data *base.Instances, cols []int) float64 {
+	ret := 0.0
+	x := make([]float64, len(cols))
+	for i := 0; i < data.Rows; i++ {
+		_, d := nearestCentre(featureRow(data, i, cols, x), m.Centres)
+		ret += data.GetWeight(i) * d
+	}
+	return ret
+}
+
+// Fit finds the centres of the rows of data, returning an error if
+// the parameters are invalid or there are fewer rows than Clusters.
+func (m *KMeans) Fit(data *base.Instances) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+	if data.Rows < m.Clusters {
+		return fmt.Errorf("cluster: %d rows can't fit %d clusters", data.Rows, m.Clusters)
+	}
+	cols := data.FeatureIndices()
+	m.Attributes = make([]base.Attribute, len(cols))
+	for k, j := range cols {
+		m.Attributes[k] = data.GetAttr(j)
+	}
+	seed := m.Seed
+	if seed == 0 {
+		seed = rand.Int63()
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	var best *KMeans
+	for init := 0; init < m.Inits; init++ {
+		fitted := &KMeans{KMeansParams: m.KMeansParams, Attributes: m.Attributes}
+		fitted.Centres = fitted.initialise(data, cols, rng)
+		counts := make([]float64, m.Clusters)
+		for fitted.Iterations < fitted.MaxIterations {
+			var shift float64
+			if fitted.BatchSize == 0 {
+				shift = fitted.lloydStep(data, cols)
+			} else {
+				shift = fitted.batchStep(data, cols, counts, rng)
+			}
+			fitted.Iterations++
+			if shift <= fitted.Tolerance {
+				fitted.Converged = true
+				break
+			}
+		}
+		fitted.Inertia = fitted.inertia(data, cols)
+		if best == nil || fitted.Inertia < best.Inertia {
+			best = fitted
+		}
+	}
+	*m = *best
+	return nil
+}
+
+// getColumns returns the column of each of the training Attributes
+// in what.
+//
+// IMPORTANT: this function panic()s if Fit hasn't been called, or
+// if what is missing one of the Attributes.
+func (m *KMeans) getColumns(what *base.Instances) []int {
+	if m.Centres == nil {
+		panic("Call Fit() beforehand")
+	}
+	return what.GetAttrIndices(m.Attributes)
+}
+
+// Predict returns the cluster of the nearest centre to each row of
+// what.
+func (m *KMeans) Predict(what *base.Instances) []int {
+	cols := m.getColumns(what)
+	ret := make([]int, what.Rows)
+	x := make([]float64, len(cols))
+	for i := range ret {
+		ret[i], _ = nearestCentre(featureRow(what, i, cols, x), m.Centres)
+	}
+	return ret
+}
+
+// Distances returns the Euclidean distance from each row of what to
+// each centre.
+func (m *KMeans) Distances(what *base.Instances) [][]float64 {
+	cols := m.getColumns(what)
+	ret := make([][]float64, what.Rows)
+	x := make([]float64, len(cols))
+	for i := range ret {
+		featureRow(what, i, cols, x)
+		ret[i] = make([]float64, len(m.Centres))
+		for k, c := range m.Centres {
+			ret[i][k] = math.Sqrt(squaredDistance(x, c))
+		}
+	}
+	return ret
+}
+
+// Score returns minus the weighted sum of squared distances from
+// each row of what to the nearest centre, so that higher is better.
+func (m *KMeans) Score(what *base.Instances) float64 {
+	return -m.inertia(what, m.getColumns(what))
+}
+
+// Clone returns an untrained KMeans with the same parameters
+func (m *KMeans) Clone() *KMeans {
+	return &KMeans{KMeansParams: m.KMeansParams}
+}
+
+func (m *KMeans) String() string {
+	return fmt.Sprintf("KMeans(Clusters: %d, BatchSize: %d)", m.Clusters, m.BatchSize)
+}
@@ -0,0 +1,181 @@
+package cluster
+
+import (
+	"math"
+	"testing"
+
+	base "github.com/sjwhitworth/golearn/base"
+)
+
+func TestKMeans(testEnv *testing.T) {
+	data := base.MakeBlobs(600, 4, 3, 0.5, 1)
+	classes := make([]int, data.Rows)
+	for i := range classes {
+		classes[i] = int(data.Get(i, 3))
+	}
+	for _, batchSize := range []int{0, 50} {
+		for _, init := range []string{"k-means++", "random"} {
+			m := NewKMeans(4)
+			m.Init = init
+			m.BatchSize = batchSize
+			if batchSize > 0 {
+				m.Tolerance = 1e-2
+			}
+			m.Inits = 3
+			m.Seed = 1
+			if err := m.Fit(data); err != nil {
+				testEnv.Fatal(err)
+			}
+			if !m.Converged || m.Iterations > m.MaxIterations {
+				testEnv.Errorf("%s, batches of %d: %d iterations", init, batchSize, m.Iterations)
+			}
+			predictions := m.Predict(data)
+			if !samePartition(predictions, classes) {
+				testEnv.Errorf("%s, batches of %d: clusters don't match the blobs", init, batchSize)
+			}
+			// Each row is nearest its own centre, at about the
+			// spread of the blobs
+			distances := m.Distances(data)
+			for i, d := range distances {
+				for k := range d {
+					if d[k] < d[predictions[i]] {
+						testEnv.Fatalf("Row %d is nearer centre %d than %d", i, k, predictions[i])
+					}
+				}
+			}
+			if mean := m.Inertia / float64(data.Rows); mean > 3*0.25*1.2 {
+				testEnv.Errorf("%s, batches of %d: mean squared distance %f", init, batchSize, mean)
+			}
+			if math.Abs(m.Score(data)+m.Inertia) > 1e-6*m.Inertia {
+				testEnv.Error(m.Score(data), m.Inertia)
+			}
+		}
+	}
+}
+
+func TestKMeansLimits(testEnv *testing.T) {
+	data := base.MakeBlobs(200, 3, 2, 2, 1)
+	m := NewKMeans(3)
+	m.MaxIterations = 1
+	m.Tolerance = 0
+	m.Seed = 1
+	if err := m.Fit(data); err != nil {
+		testEnv.Fatal(err)
+	}
+	if m.Iterations != 1 || m.Converged {
+		testEnv.Error(m.Iterations, m.Converged)
+	}
+
+	// A looser Tolerance stops sooner
+	strict, loose := NewKMeans(3), NewKMeans(3)
+	strict.Seed, loose.Seed = 1, 1
+	strict.Tolerance, loose.Tolerance = 0, 1
+	if err := strict.Fit(data); err != nil {
+		testEnv.Fatal(err)
+	}
+	if err := loose.Fit(data); err != nil {
+		testEnv.Fatal(err)
+	}
+	if !strict.Converged || loose.Iterations > strict.Iterations || loose.Inertia < strict.Inertia-1e-9 {
+		testEnv.Error(strict.Iterations, loose.Iterations, strict.Inertia, loose.Inertia)
+	}
+
+	// The same Seed gives the same centres
+	again := NewKMeans(3)
+	again.Seed, again.Tolerance = 1, 0
+	if err := again.Fit(data); err != nil {
+		testEnv.Fatal(err)
+	}
+	for k := range strict.Centres {
+		for j := range strict.Centres[k] {
+			if again.Centres[k][j] != strict.Centres[k][j] {
+				testEnv.Fatal(again.Centres, strict.Centres)
+			}
+		}
+	}
+}
+
+func TestKMeansPlusPlus(testEnv *testing.T) {
+	// With as many centres as distinct rows, k-means++ never picks
+	// a row at distance zero from one it's already picked
+	data := newPoints(0, 0, 0, 5, 5, 9)
+	for seed := int64(1); seed <= 20; seed++ {
+		m := NewKMeans(3)
+		m.Seed = seed
+		if err := m.Fit(data); err != nil {
+			testEnv.Fatal(err)
+		}
+		if m.Inertia != 0 {
+			testEnv.Errorf("Seed %d: centres %v", seed, m.Centres)
+		}
+	}
+}
+
+func TestKMeansWeights(testEnv *testing.T) {
+	// A heavy row pulls its centre towards it
+	data := newPoints(0, 1, 10, 11)
+	data.SetWeight(1, 3)
+	m := NewKMeans(2)
+	m.Seed = 1
+	if err := m.Fit(data); err != nil {
+		testEnv.Fatal(err)
+	}
+	predictions := m.Predict(data)
+	if c := m.Centres[predictions[0]][0]; math.Abs(c-0.75) > 1e-9 {
+		testEnv.Error(m.Centres)
+	}
+	if c := m.Centres[predictions[2]][0]; math.Abs(c-10.5) > 1e-9 {
+		testEnv.Error(m.Centres)
+	}
+}
+
+func TestKMeansClone(testEnv *testing.T) {
+	data := base.MakeBlobs(200, 3, 2, 0.5, 1)
+	m := NewKMeans(3)
+	m.BatchSize, m.Tolerance, m.Seed = 20, 1e-2, 1
+	if err := m.Fit(data); err != nil {
+		testEnv.Fatal(err)
+	}
+	clone := m.Clone()
+	if clone == m || clone.KMeansParams != m.KMeansParams {
+		testEnv.Fatal(clone)
+	}
+	if clone.Centres != nil || clone.Attributes != nil || clone.Iterations != 0 {
+		testEnv.Error("Clone shouldn't be trained")
+	}
+	// With the same Seed, it finds the same centres
+	if err := clone.Fit(data); err != nil {
+		testEnv.Fatal(err)
+	}
+	for k := range m.Centres {
+		for j := range m.Centres[k] {
+			if clone.Centres[k][j] != m.Centres[k][j] {
+				testEnv.Fatal(clone.Centres, m.Centres)
+			}
+		}
+	}
+}
+
+func TestKMeansParams(testEnv *testing.T) {
+	for _, params := range []KMeansParams{
+		{Clusters: 0, MaxIterations: 1, Inits: 1},
+		{Clusters: 2, Init: "furthest", MaxIterations: 1, Inits: 1},
+		{Clusters: 2, BatchSize: -1, MaxIterations: 1, Inits: 1},
+		{Clusters: 2, MaxIterations: 0, Inits: 1},
+		{Clusters: 2, MaxIterations: 1, Tolerance: -1, Inits: 1},
+		{Clusters: 2, MaxIterations: 1, Inits: 0},
+	} {
+		if _, err := NewKMeansFromParams(params); err == nil {
+			testEnv.Errorf("%+v should be invalid", params)
+		}
+	}
+	if err := NewKMeans(5).Fit(newPoints(0, 1, 2)); err == nil {
+		testEnv.Error("Five clusters of three rows")
+	}
+	defer func() {
+		if recover() == nil {
+			testEnv.Error("Predict before Fit didn't panic")
+		}
+	}()
+	NewKMeans(2).Predict(newPoints(0, 1))
+}
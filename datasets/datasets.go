@@ -0,0 +1,217 @@
+// Package datasets loads well-known datasets, such as Iris and Adult
+// from the UCI Machine Learning Repository, as ready-to-use
+// Instances. Each is downloaded the first time it's needed and cached
+// in CacheDir.
+package datasets
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+
+	base "github.com/sjwhitworth/golearn/base"
+)
+
+// CacheDir is the directory datasets are downloaded to. If it's
+// empty, it's $GOLEARN_DATA or, failing that, golearn/datasets in the
+// user's cache directory.
+var CacheDir string
+
+// downloadLock stops two goroutines downloading the same file at once
+var downloadLock sync.Mutex
+
+// Dataset describes a CSV file which can be downloaded and read as
+// Instances.
+type Dataset struct {
+	// FileName is the name of the file in the cache
+	FileName string
+	URL      string
+	// Options says how to read the file. Without headers, columns
+	// are named by their index, "0", "1", ..., unless Names are
+	// given.
+	Options base.CSVOptions
+	// Names are the names of the Attributes, if the file has no
+	// headers
+	Names []string
+	// Class is the name of the class Attribute, or empty if it's
+	// the last one
+	Class string
+	// Ignored are the names of Attributes which aren't features,
+	// e.g. IDs, and get base.IgnoredRole
+	Ignored []string
+	// Categorical are the names of columns of numbers to read as
+	// CategoricalAttributes, e.g. a class numbered from one
+	Categorical []string
+}
+
+// cacheDir returns the directory to download to
+func cacheDir() (string, error) {
+	if CacheDir != "" {
+		return CacheDir, nil
+	}
+	if dir := os.Getenv("GOLEARN_DATA"); dir != "" {
+		return dir, nil
+	}
+	dir := userCacheDir()
+	if dir == "" {
+		return "", fmt.Errorf("datasets: no cache directory (set CacheDir)")
+	}
+	return filepath.Join(dir, "golearn", "datasets"), nil
+}
+
+// userCacheDir returns the directory for the user's cached files, in
+// the place the platform expects, or an empty string if the
+// environment doesn't say where that is.
+func userCacheDir() string {
+	switch runtime.GOOS {
+	case "windows":
+		return os.Getenv("LocalAppData")
+	case "darwin":
+		if home := os.Getenv("HOME"); home != "" {
+			return filepath.Join(home, "Library", "Caches")
+		}
+		return ""
+	case "plan9":
+		if home := os.Getenv("home"); home != "" {
+			return filepath.Join(home, "lib", "cache")
+		}
+		return ""
+	}
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return dir
+	}
+	if home := os.Getenv("HOME"); home != "" {
+		return filepath.Join(home, ".cache")
+	}
+	return ""
+}
+
+// Path returns the path of the cached file, downloading it first if
+// it isn't there yet.
+func (d *Dataset) Path() (string, error) {
+	dir, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, d.FileName)
+	downloadLock.Lock()
+	defer downloadLock.Unlock()
+	if _, err := os.Stat(path); err == nil {
+		return path, nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	if err := download(d.URL, dir, path); err != nil {
+		return "", fmt.Errorf("datasets: can't download %s: %s", d.URL, err)
+	}
+	return path, nil
+}
+
+// download fetches url to path, via a temporary file in dir so that
+// an interrupted download isn't mistaken for a cached one
+func download(url, dir, path string) error {
+	resp, err := http.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s", resp.Status)
+	}
+	file, err := ioutil.TempFile(dir, "download")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if _, err := io.Copy(file, resp.Body); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// Load downloads the Dataset if it isn't cached, and reads it.
+func (d *Dataset) Load() (*base.Instances, error) {
+	path, err := d.Path()
+	if err != nil {
+		return nil, err
+	}
+	inst, err := base.ParseCSVToInstancesWithOptions(path, d.options())
+	if err != nil {
+		return nil, err
+	}
+	if d.Names != nil {
+		if len(d.Names) != inst.Cols {
+			return nil, fmt.Errorf("datasets: %s has %d columns, expected %d", d.FileName, inst.Cols, len(d.Names))
+		}
+		for j, name := range d.Names {
+			inst.GetAttr(j).SetName(name)
+		}
+	}
+	if d.Class != "" {
+		class := attributeNamed(inst, d.Class)
+		if class == nil {
+			return nil, fmt.Errorf("datasets: %s has no Attribute %s", d.FileName, d.Class)
+		}
+		// The last column is a feature after all
+		last := inst.ClassIndex
+		if err := inst.SetClassAttribute(class); err != nil {
+			return nil, err
+		}
+		if last != inst.ClassIndex {
+			inst.SetRole(last, base.FeatureRole)
+		}
+	}
+	for _, name := range d.Ignored {
+		a := attributeNamed(inst, name)
+		if a == nil {
+			return nil, fmt.Errorf("datasets: %s has no Attribute %s", d.FileName, name)
+		}
+		inst.SetRole(inst.GetAttrIndex(a), base.IgnoredRole)
+	}
+	return inst, nil
+}
+
+// options returns the Options, overriding the Categorical columns
+// with new CategoricalAttributes
+func (d *Dataset) options() base.CSVOptions {
+	ret := d.Options
+	if len(d.Categorical) == 0 {
+		return ret
+	}
+	ret.Overrides = make(map[string]base.Attribute)
+	for name, a := range d.Options.Overrides {
+		ret.Overrides[name] = a
+	}
+	for _, name := range d.Categorical {
+		// Without headers, columns are named by their index
+		for j, n := range d.Names {
+			if n == name && !d.Options.HasHeaders {
+				name = fmt.Sprintf("%d", j)
+				break
+			}
+		}
+		ret.Overrides[name] = base.NewCategoricalAttribute()
+	}
+	return ret
+}
+
+// attributeNamed returns the Attribute of inst with the given name,
+// or nil
+func attributeNamed(inst *base.Instances, name string) base.Attribute {
+	for j := 0; j < inst.Cols; j++ {
+		if inst.GetAttr(j).GetName() == name {
+			return inst.GetAttr(j)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package datasets
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	base "github.com/sjwhitworth/golearn/base"
+)
+
+// serveFiles serves the given contents by path, counting the requests
+func serveFiles(files map[string]string, requests *int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		content, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(content))
+	}))
+}
+
+func TestDatasetLoad(testEnv *testing.T) {
+	dir, err := ioutil.TempDir("", "datasets")
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(old string) { CacheDir = old }(CacheDir)
+	CacheDir = dir
+
+	iris, err := ioutil.ReadFile("../examples/datasets/iris.csv")
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	requests := 0
+	server := serveFiles(map[string]string{
+		"/iris.data": string(iris),
+		"/wine.data": "1,14.23,1.71\n2,12.37,.94\n3,12.86,1.35\n",
+	}, &requests)
+	defer server.Close()
+
+	d := *Iris
+	d.URL = server.URL + "/iris.data"
+	inst, err := d.Load()
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if inst.Rows != 150 || inst.GetAttr(0).GetName() != "Sepal length" || inst.GetClass(0) != "Iris-setosa" {
+		testEnv.Fatal(inst)
+	}
+	// The second time, the cached file is read
+	if _, err := d.Load(); err != nil || requests != 1 {
+		testEnv.Error(err, requests)
+	}
+
+	wine := Dataset{
+		FileName:    "wine.data",
+		URL:         server.URL + "/wine.data",
+		Names:       []string{"cultivar", "alcohol", "malic acid"},
+		Class:       "cultivar",
+		Categorical: []string{"cultivar"},
+	}
+	inst, err = wine.Load()
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if inst.ClassIndex != 0 || inst.GetClassAttr().GetType() != base.CategoricalType || inst.GetClass(1) != "2" {
+		testEnv.Error(inst)
+	}
+	if !inst.IsFeature(2) || inst.Get(1, 2) != 0.94 {
+		testEnv.Error(inst.GetRole(2), inst.Get(1, 2))
+	}
+
+	missing := Dataset{FileName: "missing.data", URL: server.URL + "/missing.data"}
+	if _, err := missing.Load(); err == nil {
+		testEnv.Error("Expected an error for a missing file")
+	}
+	if _, err := os.Stat(dir + "/missing.data"); !os.IsNotExist(err) {
+		testEnv.Error("A failed download shouldn't be cached")
+	}
+}
+
+func TestBreastCancerNames(testEnv *testing.T) {
+	names := breastCancerNames()
+	if len(names) != 32 || names[2] != "mean radius" || names[12] != "radius error" || names[31] != "worst fractal dimension" {
+		testEnv.Error(names)
+	}
+}
+
+func TestCacheDir(testEnv *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" || runtime.GOOS == "plan9" {
+		testEnv.Skip("the cache directory isn't from $XDG_CACHE_HOME or $HOME")
+	}
+	defer func(old string) { CacheDir = old }(CacheDir)
+	CacheDir = ""
+	for _, name := range []string{"GOLEARN_DATA", "XDG_CACHE_HOME", "HOME"} {
+		defer os.Setenv(name, os.Getenv(name))
+	}
+
+	os.Setenv("GOLEARN_DATA", "")
+	os.Setenv("XDG_CACHE_HOME", "")
+	os.Setenv("HOME", "/home/someone")
+	if dir, err := cacheDir(); err != nil || dir != filepath.Join("/home/someone", ".cache", "golearn", "datasets") {
+		testEnv.Error(dir, err)
+	}
+	os.Setenv("XDG_CACHE_HOME", "/var/cache")
+	if dir, err := cacheDir(); err != nil || dir != filepath.Join("/var/cache", "golearn", "datasets") {
+		testEnv.Error(dir, err)
+	}
+	os.Setenv("XDG_CACHE_HOME", "")
+	os.Setenv("HOME", "")
+	if dir, err := cacheDir(); err == nil {
+		testEnv.Error(dir)
+	}
+	os.Setenv("GOLEARN_DATA", "/data")
+	if dir, err := cacheDir(); err != nil || dir != "/data" {
+		testEnv.Error(dir, err)
+	}
+}
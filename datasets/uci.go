@@ -0,0 +1,88 @@
+package datasets
+
+import (
+	"fmt"
+
+	base "github.com/sjwhitworth/golearn/base"
+)
+
+// uciURL is where the UCI Machine Learning Repository keeps its
+// datasets
+const uciURL = "https://archive.ics.uci.edu/ml/machine-learning-databases/"
+
+// Iris is Fisher's iris data: four measurements of 150 flowers of
+// three species.
+var Iris = &Dataset{
+	FileName: "iris.data",
+	URL:      uciURL + "iris/iris.data",
+	Names:    []string{"Sepal length", "Sepal width", "Petal length", "Petal width", "Species"},
+}
+
+// BreastCancer is the Wisconsin Diagnostic Breast Cancer data: 30
+// measurements of the cell nuclei in 569 biopsies, which are malignant
+// ("M") or benign ("B"). The ID is ignored.
+var BreastCancer = &Dataset{
+	FileName: "wdbc.data",
+	URL:      uciURL + "breast-cancer-wisconsin/wdbc.data",
+	Names:    breastCancerNames(),
+	Class:    "diagnosis",
+	Ignored:  []string{"id"},
+}
+
+// breastCancerNames returns the names of the columns of BreastCancer
+func breastCancerNames() []string {
+	features := []string{"radius", "texture", "perimeter", "area", "smoothness",
+		"compactness", "concavity", "concave points", "symmetry", "fractal dimension"}
+	ret := []string{"id", "diagnosis"}
+	for _, format := range []string{"mean %s", "%s error", "worst %s"} {
+		for _, f := range features {
+			ret = append(ret, fmt.Sprintf(format, f))
+		}
+	}
+	return ret
+}
+
+// Adult is the census income data: 14 demographic Attributes of
+// 32,561 adults, and whether they earn more than $50,000 a year
+// (">50K" or "<=50K").
+var Adult = &Dataset{
+	FileName: "adult.data",
+	URL:      uciURL + "adult/adult.data",
+	Names: []string{"age", "workclass", "fnlwgt", "education", "education-num",
+		"marital-status", "occupation", "relationship", "race", "sex",
+		"capital-gain", "capital-loss", "hours-per-week", "native-country", "income"},
+	Options: base.CSVOptions{TrimLeadingSpace: true},
+}
+
+// Wine is the results of a chemical analysis of 178 wines from three
+// cultivars ("1", "2" and "3"), which are the class.
+var Wine = &Dataset{
+	FileName: "wine.data",
+	URL:      uciURL + "wine/wine.data",
+	Names: []string{"cultivar", "alcohol", "malic acid", "ash", "alcalinity of ash",
+		"magnesium", "total phenols", "flavanoids", "nonflavanoid phenols",
+		"proanthocyanins", "colour intensity", "hue", "OD280/OD315", "proline"},
+	Class:       "cultivar",
+	Categorical: []string{"cultivar"},
+}
+
+// LoadIris returns the Iris Dataset, downloading it if need be.
+func LoadIris() (*base.Instances, error) {
+	return Iris.Load()
+}
+
+// LoadBreastCancer returns the BreastCancer Dataset, downloading it
+// if need be.
+func LoadBreastCancer() (*base.Instances, error) {
+	return BreastCancer.Load()
+}
+
+// LoadAdult returns the Adult Dataset, downloading it if need be.
+func LoadAdult() (*base.Instances, error) {
+	return Adult.Load()
+}
+
+// LoadWine returns the Wine Dataset, downloading it if need be.
+func LoadWine() (*base.Instances, error) {
+	return Wine.Load()
+}
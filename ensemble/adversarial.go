@@ -0,0 +1,177 @@
+package ensemble
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	base "github.com/sjwhitworth/golearn/base"
+	eval "github.com/sjwhitworth/golearn/evaluation"
+	trees "github.com/sjwhitworth/golearn/trees"
+)
+
+// AdversarialValidation describes how well a RandomForest can tell
+// two sets of Instances apart, e.g. training and test data. If they
+// come from the same distribution it can't, and the AUC is close to
+// 0.5. Otherwise the Attributes which give them away have drifted
+// between the two, or leak how the rows were split.
+type AdversarialValidation struct {
+	// AUC is the out-of-fold area under the ROC curve of the forest
+	// at picking out the second set of Instances.
+	AUC float64
+	// Attributes are the features of the Instances, most
+	// discriminative first.
+	Attributes []base.Attribute
+	// Importance holds the drop in AUC on held-out rows when the
+	// values of each of Attributes are shuffled.
+	Importance []float64
+}
+
+// String returns the AUC and the five most discriminative Attributes
+func (a *AdversarialValidation) String() string {
+	ret := fmt.Sprintf("AUC %.4f", a.AUC)
+	for i := 0; i < len(a.Attributes) && i < 5; i++ {
+		ret += fmt.Sprintf("\n%s\t%.4f", a.Attributes[i].GetName(), a.Importance[i])
+	}
+	return ret
+}
+
+// byImportance sorts Attributes by decreasing importance
+type byImportance struct {
+	attrs      []base.Attribute
+	importance []float64
+}
+
+func (b *byImportance) Len() int {
+	return len(b.attrs)
+}
+
+func (b *byImportance) Swap(i, j int) {
+	b.attrs[i], b.attrs[j] = b.attrs[j], b.attrs[i]
+	b.importance[i], b.importance[j] = b.importance[j], b.importance[i]
+}
+
+func (b *byImportance) Less(i, j int) bool {
+	return b.importance[i] > b.importance[j]
+}
+
+// adversarialInstances labels the rows of first and second with the
+// set they came from, keeping the numeric and categorical features of
+// first (matched by name in second).
+func adversarialInstances(first, second *base.Instances) (*base.Instances, error) {
+	type column struct {
+		first, second int
+	}
+	attrs := make([]base.Attribute, 0)
+	cols := make([]column, 0)
+	for _, j := range first.FeatureIndices() {
+		a := first.GetAttr(j)
+		k := -1
+		for c := 0; c < second.Cols; c++ {
+			if second.GetAttr(c).GetName() == a.GetName() {
+				k = c
+				break
+			}
+		}
+		if k == -1 {
+			return nil, fmt.Errorf("ensemble: %s is missing from the second Instances", a.GetName())
+		}
+		// Use new Attributes, so that values only in second
+		// aren't added to those of first
+		var attr base.Attribute
+		switch a.GetType() {
+		case base.Float64Type:
+			attr = base.NewFloatAttribute()
+		case base.CategoricalType:
+			attr = base.NewCategoricalAttribute()
+		default:
+			continue
+		}
+		attr.SetName(a.GetName())
+		attrs = append(attrs, attr)
+		cols = append(cols, column{j, k})
+	}
+	if len(attrs) == 0 {
+		return nil, fmt.Errorf("ensemble: no features to compare")
+	}
+	class := base.NewCategoricalAttribute()
+	class.SetName("dataset")
+	attrs = append(attrs, class)
+
+	ret := base.NewInstances(attrs, first.Rows+second.Rows)
+	for s, from := range []*base.Instances{first, second} {
+		offset := s * first.Rows
+		for i := 0; i < from.Rows; i++ {
+			for k, c := range cols {
+				src := c.first
+				if s == 1 {
+					src = c.second
+				}
+				if attrs[k].GetType() == base.Float64Type {
+					ret.Set(offset+i, k, from.Get(i, src))
+				} else {
+					ret.SetAttrStr(offset+i, k, from.GetAttrStr(i, src))
+				}
+			}
+			ret.SetAttrStr(offset+i, len(cols), fmt.Sprintf("%d", s))
+		}
+	}
+	return ret, nil
+}
+
+// NewAdversarialValidation trains RandomForests of forestSize CART
+// trees to tell the rows of first (e.g. training data) from those of
+// second (e.g. test data), using the features of first. The AUC is
+// estimated with folds-fold cross-validation. Each Attribute's
+// importance is found by training on a random two thirds of the rows
+// and measuring how much shuffling its values lowers the AUC on the
+// rest.
+func NewAdversarialValidation(first, second *base.Instances, forestSize, folds int, seed int64) (*AdversarialValidation, error) {
+	data, err := adversarialInstances(first, second)
+	if err != nil {
+		return nil, err
+	}
+	features := int(math.Sqrt(float64(len(data.FeatureIndices()))))
+	if features < 1 {
+		features = 1
+	}
+	newForest := func() *RandomForest {
+		ret := NewRandomForest(forestSize, features)
+		ret.Seed = seed
+		ret.BaseLearner = func() base.Classifier {
+			return trees.NewCARTDecisionTree(nil)
+		}
+		return ret
+	}
+
+	_, probabilities, err := eval.CrossValPredictWithSeed(newForest(), data, folds, seed)
+	if err != nil {
+		return nil, err
+	}
+	ret := &AdversarialValidation{AUC: eval.GetAUC(data, probabilities, "1")}
+
+	// Measure the importance of each Attribute on held-out rows
+	rng := rand.New(rand.NewSource(seed))
+	perm := rng.Perm(data.Rows)
+	split := data.Rows * 2 / 3
+	train, test := data.SelectRows(perm[:split]), data.SelectRows(perm[split:])
+	forest := newForest()
+	forest.Fit(train)
+	baseline := eval.GetAUC(test, forest.PredictProba(test), "1")
+	for j := 0; j < data.Cols-1; j++ {
+		shuffled := test.SelectRows(rng.Perm(test.Rows))
+		for i := 0; i < test.Rows; i++ {
+			shuffled.Set(i, data.ClassIndex, test.Get(i, data.ClassIndex))
+			for k := 0; k < data.Cols-1; k++ {
+				if k != j {
+					shuffled.Set(i, k, test.Get(i, k))
+				}
+			}
+		}
+		ret.Attributes = append(ret.Attributes, data.GetAttr(j))
+		ret.Importance = append(ret.Importance, baseline-eval.GetAUC(shuffled, forest.PredictProba(shuffled), "1"))
+	}
+	sort.Sort(&byImportance{ret.Attributes, ret.Importance})
+	return ret, nil
+}
@@ -0,0 +1,46 @@
+package ensemble
+
+import (
+	"testing"
+
+	base "github.com/sjwhitworth/golearn/base"
+)
+
+func TestAdversarialValidation(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	even := make([]int, 0)
+	odd := make([]int, 0)
+	for i := 0; i < inst.Rows; i++ {
+		if i%2 == 0 {
+			even = append(even, i)
+		} else {
+			odd = append(odd, i)
+		}
+	}
+	first, second := inst.SelectRows(even), inst.SelectRows(odd)
+	same, err := NewAdversarialValidation(first, second, 20, 3, 1)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if same.AUC > 0.75 {
+		testEnv.Errorf("Rows from the same data shouldn't be told apart: %s", same)
+	}
+
+	// Shift the sepal width of the second set
+	for i := 0; i < second.Rows; i++ {
+		second.Set(i, 1, second.Get(i, 1)+2)
+	}
+	drifted, err := NewAdversarialValidation(first, second, 20, 3, 1)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if drifted.AUC < 0.9 {
+		testEnv.Errorf("Drifted rows should be told apart: %s", drifted)
+	}
+	if name := drifted.Attributes[0].GetName(); name != inst.GetAttr(1).GetName() {
+		testEnv.Errorf("Expected the sepal width to be most discriminative: %s", drifted)
+	}
+}
@@ -0,0 +1,453 @@
+package ensemble
+
+import (
+	"encoding/gob"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	base "github.com/sjwhitworth/golearn/base"
+	mathutil "github.com/sjwhitworth/golearn/mathutil"
+)
+
+// GradientBoostingParams holds the parameters of GradientBoosting.
+type GradientBoostingParams struct {
+	// NumTrees is the number of boosting rounds. Each round adds
+	// one tree per output (see GradientBoosting).
+	NumTrees int
+	// LearningRate shrinks the contribution of each tree. Smaller
+	// values need more trees, but overfit less.
+	LearningRate float64
+	// MaxDepth limits the number of splits between the root and
+	// any leaf of each tree
+	MaxDepth int
+	// MinLeafSize is the smallest number of rows a leaf can have
+	MinLeafSize int
+	// Lambda penalises splits which leave little weight on either
+	// side when growing the trees
+	Lambda float64
+	// Objective is the loss to minimise: "logistic" (the default
+	// for categorical classes), "focal", or "squared" (the default,
+	// and only choice, for numeric classes). It's ignored if
+	// GradientBoosting.Loss is set.
+	Objective string
+	// FocalGamma controls how much the "focal" objective
+	// down-weights rows which are already classified well. Zero
+	// makes it equivalent to "logistic".
+	FocalGamma float64
+	// FocalAlpha is the weight the "focal" objective gives to the
+	// positive class of each output, the rest goes to the negative.
+	FocalAlpha float64
+	// Subsample is the fraction of the rows, drawn without
+	// replacement, each tree is fitted to (stochastic gradient
+	// boosting). Zero means all of them.
+	Subsample float64
+	// ColumnSample is the fraction of the Attributes each tree can
+	// split on. Zero means all of them.
+	ColumnSample float64
+	// Seed makes subsampling reproducible. Zero means a different
+	// random seed each time.
+	Seed int64
+}
+
+// DefaultGradientBoostingParams returns the GradientBoostingParams
+// used by the "gradientboosting" entry in the classifier registry.
+func DefaultGradientBoostingParams() GradientBoostingParams {
+	return GradientBoostingParams{
+		NumTrees:     100,
+		LearningRate: 0.1,
+		MaxDepth:     3,
+		MinLeafSize:  1,
+		Lambda:       1.0,
+		FocalGamma:   2.0,
+		FocalAlpha:   0.25,
+		Subsample:    1.0,
+		ColumnSample: 1.0,
+	}
+}
+
+// Validate checks that the GradientBoostingParams are usable.
+func (p GradientBoostingParams) Validate() error {
+	if p.NumTrees < 1 {
+		return fmt.Errorf("ensemble: NumTrees should be at least 1, got %d", p.NumTrees)
+	}
+	if p.LearningRate <= 0 {
+		return fmt.Errorf("ensemble: LearningRate should be positive, got %f", p.LearningRate)
+	}
+	if p.MaxDepth < 1 {
+		return fmt.Errorf("ensemble: MaxDepth should be at least 1, got %d", p.MaxDepth)
+	}
+	if p.MinLeafSize < 1 {
+		return fmt.Errorf("ensemble: MinLeafSize should be at least 1, got %d", p.MinLeafSize)
+	}
+	if p.Lambda < 0 {
+		return fmt.Errorf("ensemble: Lambda can't be negative, got %f", p.Lambda)
+	}
+	if p.FocalGamma < 0 {
+		return fmt.Errorf("ensemble: FocalGamma can't be negative, got %f", p.FocalGamma)
+	}
+	if p.FocalAlpha <= 0 || p.FocalAlpha >= 1 {
+		return fmt.Errorf("ensemble: FocalAlpha should be in (0, 1), got %f", p.FocalAlpha)
+	}
+	if p.Subsample < 0 || p.Subsample > 1 {
+		return fmt.Errorf("ensemble: Subsample should be in [0, 1], got %f", p.Subsample)
+	}
+	if p.ColumnSample < 0 || p.ColumnSample > 1 {
+		return fmt.Errorf("ensemble: ColumnSample should be in [0, 1], got %f", p.ColumnSample)
+	}
+	switch p.Objective {
+	case "", "logistic", "focal", "squared":
+	default:
+		return fmt.Errorf("ensemble: unknown Objective %q", p.Objective)
+	}
+	return nil
+}
+
+// GradientBoosting builds an additive model of small regression
+// trees, each fitted to the gradient of the loss of the ones before
+// it. Categorical classes are predicted one-vs-rest: there's one
+// output (a sequence of trees predicting log-odds) per class, or a
+// single output for the second class if there are only two. Numeric
+// classes are predicted directly by a single output.
+//
+// Trees split on the system representation of each Attribute, so
+// CategoricalAttributes are treated as ordered.
+type GradientBoosting struct {
+	base.BaseClassifier
+	GradientBoostingParams
+	// Loss, if set, is minimised instead of the Objective. Custom
+	// Losses must be registered with gob.Register to save the
+	// GradientBoosting.
+	Loss Loss
+	// Trees holds the trees of each output
+	Trees [][]*GradientTree
+	// InitialScores holds the score of each output before any trees
+	InitialScores []float64
+	// Classes holds the class values seen during training, sorted
+	Classes []string
+	// Attributes holds the Attributes the trees split on
+	Attributes []base.Attribute
+	// Regression is true if the class Attribute is numeric
+	Regression bool
+}
+
+// NewGradientBoosting returns a GradientBoosting which trains
+// numTrees trees per output with the given learning rate and
+// maximum depth, and the DefaultGradientBoostingParams otherwise.
+func NewGradientBoosting(numTrees int, learningRate float64, maxDepth int) *GradientBoosting {
+	params := DefaultGradientBoostingParams()
+	params.NumTrees = numTrees
+	params.LearningRate = learningRate
+	params.MaxDepth = maxDepth
+	return &GradientBoosting{GradientBoostingParams: params}
+}
+
+// NewGradientBoostingFromParams returns a new GradientBoosting with
+// the given parameters, or an error if they're invalid.
+func NewGradientBoostingFromParams(params GradientBoostingParams) (*GradientBoosting, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	return &GradientBoosting{GradientBoostingParams: params}, nil
+}
+
+func init() {
+	base.RegisterClassifier("gradientboosting", func() base.Classifier {
+		ret, _ := NewGradientBoostingFromParams(DefaultGradientBoostingParams())
+		return ret
+	})
+	gob.Register(&GradientBoosting{})
+}
+
+// Fit trains the GradientBoosting on the given Instances, weighting
+// each row by its weight in on (see base.Instances.GetWeight).
+func (g *GradientBoosting) Fit(on *base.Instances) {
+	g.FitWeighted(on, on.Weights())
+}
+
+// FitWeighted trains the GradientBoosting on the given Instances,
+// scaling the loss of each row by its weight. Weighting the rare
+// classes more heavily is an alternative to resampling when the
+// classes are imbalanced. If weights is nil, every row has weight one.
+//
+// IMPORTANT: this function panic()s if there isn't one weight per
+// row, or if the Objective doesn't suit the class Attribute.
+func (g *GradientBoosting) FitWeighted(on *base.Instances, weights []float64) {
+	if weights == nil {
+		weights = make([]float64, on.Rows)
+		for i := range weights {
+			weights[i] = 1.0
+		}
+	}
+	if len(weights) != on.Rows {
+		panic(fmt.Sprintf("ensemble: %d weight(s) for %d row(s)", len(weights), on.Rows))
+	}
+
+	g.Attributes = make([]base.Attribute, 0)
+	cols := make([]int, 0)
+	for i := 0; i < on.Cols; i++ {
+		if on.IsFeature(i) {
+			g.Attributes = append(g.Attributes, on.GetAttr(i))
+			cols = append(cols, i)
+		}
+	}
+	g.Regression = on.GetClassAttr().GetType() == base.Float64Type
+	loss := g.Loss
+	if loss == nil {
+		var err error
+		loss, err = newLoss(g.GradientBoostingParams, g.Regression)
+		if err != nil {
+			panic(err.Error())
+		}
+	}
+
+	// Work out the targets of each output
+	targets := make([][]float64, 0)
+	g.Classes = nil
+	if g.Regression {
+		y := make([]float64, on.Rows)
+		for i := range y {
+			y[i] = on.Get(i, on.ClassIndex)
+		}
+		targets = append(targets, y)
+	} else {
+		g.Classes = make([]string, 0)
+		for c := range on.CountClassValues() {
+			g.Classes = append(g.Classes, c)
+		}
+		sort.Strings(g.Classes)
+		positives := g.Classes
+		if len(g.Classes) <= 2 {
+			positives = g.Classes[len(g.Classes)-1:]
+		}
+		for _, c := range positives {
+			y := make([]float64, on.Rows)
+			for i := range y {
+				if on.GetClass(i) == c {
+					y[i] = 1.0
+				}
+			}
+			targets = append(targets, y)
+		}
+	}
+
+	seed := g.Seed
+	if seed == 0 {
+		seed = rand.Int63()
+	}
+	rng := rand.New(rand.NewSource(seed))
+	rows := make([]int, on.Rows)
+	for i := range rows {
+		rows[i] = i
+	}
+	g.InitialScores = make([]float64, len(targets))
+	g.Trees = make([][]*GradientTree, len(targets))
+	for k, y := range targets {
+		g.InitialScores[k] = loss.InitialScore(y, weights)
+		scores := make([]float64, on.Rows)
+		for i := range scores {
+			scores[i] = g.InitialScores[k]
+		}
+		grad := make([]float64, on.Rows)
+		builder := &gradientTreeBuilder{
+			data:        on,
+			cols:        cols,
+			grad:        grad,
+			weight:      weights,
+			maxDepth:    g.MaxDepth,
+			minLeafSize: g.MinLeafSize,
+			lambda:      g.Lambda,
+		}
+		builder.leafValue = func(leafRows []int) float64 {
+			leafY := make([]float64, len(leafRows))
+			leafScores := make([]float64, len(leafRows))
+			leafWeights := make([]float64, len(leafRows))
+			for j, r := range leafRows {
+				leafY[j] = y[r]
+				leafScores[j] = scores[r]
+				leafWeights[j] = weights[r]
+			}
+			return g.LearningRate * loss.LeafValue(leafY, leafScores, leafWeights)
+		}
+		g.Trees[k] = make([]*GradientTree, 0, g.NumTrees)
+		for round := 0; round < g.NumTrees; round++ {
+			for i := range rows {
+				grad[i] = -loss.NegativeGradient(y[i], scores[i]) * weights[i]
+			}
+			builder.features = sampleIndices(rng, len(cols), g.ColumnSample)
+			tree := builder.build(sampleIndices(rng, on.Rows, g.Subsample))
+			g.Trees[k] = append(g.Trees[k], tree)
+			for i := range rows {
+				scores[i] += tree.getLeaf(on, i, cols).Value
+			}
+		}
+	}
+}
+
+// sampleIndices returns the given fraction of the integers in
+// [0, n), in increasing order, or all of them if fraction is zero.
+// At least one is always returned.
+func sampleIndices(rng *rand.Rand, n int, fraction float64) []int {
+	count := n
+	if fraction > 0 {
+		count = int(math.Ceil(fraction * float64(n)))
+	}
+	ret := make([]int, count)
+	if count == n {
+		for i := range ret {
+			ret[i] = i
+		}
+		return ret
+	}
+	copy(ret, rng.Perm(n)[:count])
+	sort.Ints(ret)
+	return ret
+}
+
+// getColumns returns the column of each of the training Attributes
+// in what.
+//
+// IMPORTANT: this function panic()s if Fit hasn't been called, or
+// if what is missing one of the Attributes.
+func (g *GradientBoosting) getColumns(what *base.Instances) []int {
+	if g.Trees == nil {
+		panic("Call Fit() beforehand")
+	}
+	return what.GetAttrIndices(g.Attributes)
+}
+
+// DecisionFunction returns the raw score of each output for each
+// row of what: log-odds for categorical classes, and the predicted
+// value for numeric ones.
+func (g *GradientBoosting) DecisionFunction(what *base.Instances) [][]float64 {
+	cols := g.getColumns(what)
+	ret := make([][]float64, what.Rows)
+	for i := range ret {
+		ret[i] = make([]float64, len(g.Trees))
+		for k, trees := range g.Trees {
+			ret[i][k] = g.InitialScores[k]
+			for _, t := range trees {
+				ret[i][k] += t.getLeaf(what, i, cols).Value
+			}
+		}
+	}
+	return ret
+}
+
+// probabilities converts the scores of a row into class probabilities
+func (g *GradientBoosting) probabilities(scores []float64) map[string]float64 {
+	ret := make(map[string]float64)
+	if len(g.Classes) <= 2 {
+		p := mathutil.Sigmoid(scores[0])
+		ret[g.Classes[len(g.Classes)-1]] = p
+		if len(g.Classes) == 2 {
+			ret[g.Classes[0]] = 1 - p
+		}
+		return ret
+	}
+	// Normalises the sigmoids in log space, since they can all
+	// underflow to zero
+	logs := make([]float64, len(scores))
+	for k := range scores {
+		logs[k] = mathutil.LogSigmoid(scores[k])
+	}
+	for k, p := range mathutil.Softmax(logs) {
+		ret[g.Classes[k]] = p
+	}
+	return ret
+}
+
+// Predict returns the most probable class of each row of what, or
+// the predicted value if the class Attribute is numeric.
+func (g *GradientBoosting) Predict(what *base.Instances) *base.Instances {
+	ret := what.GeneratePredictionVector()
+	for i, scores := range g.DecisionFunction(what) {
+		if g.Regression {
+			ret.Set(i, 0, scores[0])
+			continue
+		}
+		if len(g.Classes) == 1 {
+			ret.SetAttrStr(i, 0, g.Classes[0])
+			continue
+		}
+		probs := g.probabilities(scores)
+		best := g.Classes[0]
+		for _, c := range g.Classes {
+			if probs[c] > probs[best] {
+				best = c
+			}
+		}
+		ret.SetAttrStr(i, 0, best)
+	}
+	return ret
+}
+
+// PredictProba returns the estimated probability of each class for
+// each row of what. With more than two classes, the one-vs-rest
+// probabilities are normalised to sum to one.
+//
+// IMPORTANT: this function panic()s if the class Attribute is numeric.
+func (g *GradientBoosting) PredictProba(what *base.Instances) []map[string]float64 {
+	if g.Regression {
+		panic("ensemble: can't estimate probabilities of a numeric class")
+	}
+	scores := g.DecisionFunction(what)
+	ret := make([]map[string]float64, what.Rows)
+	for i := range ret {
+		ret[i] = g.probabilities(scores[i])
+	}
+	return ret
+}
+
+// Apply returns, for each row of what, the index of the leaf it
+// lands in for each tree. Trees are numbered output by output.
+func (g *GradientBoosting) Apply(what *base.Instances) [][]int {
+	cols := g.getColumns(what)
+	ret := make([][]int, what.Rows)
+	for i := range ret {
+		ret[i] = make([]int, 0)
+		for _, trees := range g.Trees {
+			for _, t := range trees {
+				ret[i] = append(ret[i], t.getLeaf(what, i, cols).LeafIndex)
+			}
+		}
+	}
+	return ret
+}
+
+// LeafCounts returns the number of leaves in each tree, in the
+// same order as Apply.
+func (g *GradientBoosting) LeafCounts() []int {
+	ret := make([]int, 0)
+	for _, trees := range g.Trees {
+		for _, t := range trees {
+			ret = append(ret, t.Leaves)
+		}
+	}
+	return ret
+}
+
+// Clone returns an untrained GradientBoosting with the same parameters
+func (g *GradientBoosting) Clone() base.Classifier {
+	return &GradientBoosting{GradientBoostingParams: g.GradientBoostingParams, Loss: g.Loss}
+}
+
+// Summary returns the GradientBoosting's parameters and the number
+// and size of its trees
+func (g *GradientBoosting) Summary() string {
+	params := base.FormatParams(base.GetParams(g))
+	if g.Trees == nil {
+		return fmt.Sprintf("GradientBoosting(%s)\nUntrained\n", params)
+	}
+	counts := g.LeafCounts()
+	leaves := 0
+	for _, c := range counts {
+		leaves += c
+	}
+	return fmt.Sprintf("GradientBoosting(%s)\n%d output(s), %d tree(s), %d leaves\n", params, len(g.Trees), len(counts), leaves)
+}
+
+func (g *GradientBoosting) String() string {
+	return fmt.Sprintf("GradientBoosting(NumTrees: %d, LearningRate: %g, MaxDepth: %d, Subsample: %g, ColumnSample: %g, Objective: %q)", g.NumTrees, g.LearningRate, g.MaxDepth, g.Subsample, g.ColumnSample, g.Objective)
+}
@@ -0,0 +1,324 @@
+package ensemble
+
+import (
+	"math"
+	"testing"
+
+	base "github.com/sjwhitworth/golearn/base"
+	eval "github.com/sjwhitworth/golearn/evaluation"
+	mathutil "github.com/sjwhitworth/golearn/mathutil"
+)
+
+// Check GradientBoosting can be used with LeafEmbedding
+var _ LeafModel = &GradientBoosting{}
+
+func TestGradientBoosting(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		panic(err)
+	}
+	gb := NewGradientBoosting(20, 0.3, 2)
+	gb.Fit(inst)
+	predictions := gb.Predict(inst)
+	accuracy := eval.GetAccuracy(eval.GetConfusionMatrix(inst, predictions))
+	if accuracy < 0.95 {
+		testEnv.Errorf("Accuracy too low: %.3f", accuracy)
+	}
+	for _, probs := range gb.PredictProba(inst) {
+		total := 0.0
+		for _, p := range probs {
+			total += p
+		}
+		if math.Abs(total-1) > 1e-9 {
+			testEnv.Errorf("Probabilities sum to %f", total)
+		}
+	}
+	counts := gb.LeafCounts()
+	if len(counts) != 60 {
+		testEnv.Errorf("Expected 60 trees, got %d", len(counts))
+	}
+	for i, leaves := range gb.Apply(inst) {
+		for t, leaf := range leaves {
+			if leaf < 0 || leaf >= counts[t] {
+				testEnv.Fatalf("Row %d has leaf %d in tree %d", i, leaf, t)
+			}
+		}
+	}
+	if _, err := NewGradientBoostingFromParams(GradientBoostingParams{NumTrees: 10}); err == nil {
+		testEnv.Error("Expected an error for a zero LearningRate")
+	}
+}
+
+// newImbalancedInstances returns 100 rows with one numeric Attribute
+// and a class: ten rows have x = 0, four of which are "b", and the
+// rest have x = 1 and are "a".
+func newImbalancedInstances() *base.Instances {
+	x := base.NewFloatAttribute()
+	x.SetName("x")
+	class := base.NewCategoricalAttribute()
+	class.SetName("class")
+	inst := base.NewInstances([]base.Attribute{x, class}, 100)
+	for i := 0; i < 100; i++ {
+		if i < 10 {
+			inst.Set(i, 0, 0.0)
+		} else {
+			inst.Set(i, 0, 1.0)
+		}
+		if i < 4 {
+			inst.SetAttrStr(i, 1, "b")
+		} else {
+			inst.SetAttrStr(i, 1, "a")
+		}
+	}
+	return inst
+}
+
+func TestGradientBoostingWeights(testEnv *testing.T) {
+	inst := newImbalancedInstances()
+	weights := make([]float64, inst.Rows)
+	for i := range weights {
+		weights[i] = 1.0
+		if inst.GetClass(i) == "b" {
+			weights[i] = 3.0
+		}
+	}
+	for _, objective := range []string{"logistic", "focal"} {
+		gb := NewGradientBoosting(50, 0.3, 1)
+		gb.Objective = objective
+		gb.FocalAlpha = 0.5
+		gb.Fit(inst)
+		if c := gb.Predict(inst).GetClass(0); c != "a" {
+			testEnv.Errorf("%s: expected a without weights, got %s", objective, c)
+		}
+		gb.FitWeighted(inst, weights)
+		if c := gb.Predict(inst).GetClass(0); c != "b" {
+			testEnv.Errorf("%s: expected b with weights, got %s", objective, c)
+		}
+		if c := gb.Predict(inst).GetClass(50); c != "a" {
+			testEnv.Errorf("%s: expected a with weights, got %s", objective, c)
+		}
+
+		// Fit uses the weights of the Instances
+		weighted := inst.Copy()
+		for i, w := range weights {
+			weighted.SetWeight(i, w)
+		}
+		fitted := gb.Clone().(*GradientBoosting)
+		fitted.Fit(weighted)
+		got, expected := fitted.Predict(inst), gb.Predict(inst)
+		for i := 0; i < inst.Rows; i++ {
+			if got.GetClass(i) != expected.GetClass(i) {
+				testEnv.Fatalf("%s, row %d: Fit predicted %s, FitWeighted %s", objective, i, got.GetClass(i), expected.GetClass(i))
+			}
+		}
+	}
+}
+
+func TestFocalLoss(testEnv *testing.T) {
+	loss := FocalLoss{2.0, 0.25}
+	value := func(y, f float64) float64 {
+		p := mathutil.Sigmoid(f)
+		if y > 0.5 {
+			return -loss.Alpha * math.Pow(1-p, loss.Gamma) * math.Log(p)
+		}
+		return -(1 - loss.Alpha) * math.Pow(p, loss.Gamma) * math.Log(1-p)
+	}
+	for _, y := range []float64{0, 1} {
+		for _, f := range []float64{-3, -0.5, 0, 0.7, 2} {
+			expected := (value(y, f+1e-6) - value(y, f-1e-6)) / 2e-6
+			if grad := -loss.NegativeGradient(y, f); math.Abs(grad-expected) > 1e-6 {
+				testEnv.Errorf("y=%g f=%g: gradient %f, expected %f", y, f, grad, expected)
+			}
+		}
+	}
+	// Without focusing, it's half the logistic loss
+	loss = FocalLoss{0, 0.5}
+	for _, y := range []float64{0, 1} {
+		grad := loss.NegativeGradient(y, 0.3)
+		expected := LogisticLoss{}.NegativeGradient(y, 0.3)
+		if math.Abs(2*grad-expected) > 1e-9 {
+			testEnv.Errorf("y=%g: gradient %f, expected %f", y, 2*grad, expected)
+		}
+	}
+}
+
+func TestGradientBoostingProbabilities(testEnv *testing.T) {
+	g := NewGradientBoosting(1, 0.1, 1)
+	g.Classes = []string{"a", "b", "c"}
+	// Every sigmoid underflows to zero
+	p := g.probabilities([]float64{-2000, -2000, -2100})
+	if math.Abs(p["a"]-0.5) > 1e-9 || math.Abs(p["b"]-0.5) > 1e-9 || p["c"] > 1e-9 {
+		testEnv.Error(p)
+	}
+}
+
+func TestGradientBoostingRegression(testEnv *testing.T) {
+	x := base.NewFloatAttribute()
+	x.SetName("x")
+	y := base.NewFloatAttribute()
+	y.SetName("y")
+	inst := base.NewInstances([]base.Attribute{x, y}, 50)
+	for i := 0; i < 50; i++ {
+		inst.Set(i, 0, float64(i))
+		inst.Set(i, 1, float64(i/10))
+	}
+	gb := NewGradientBoosting(100, 0.3, 3)
+	gb.Fit(inst)
+	predictions := gb.Predict(inst)
+	for i := 0; i < 50; i++ {
+		if math.Abs(predictions.Get(i, 0)-inst.Get(i, 1)) > 0.1 {
+			testEnv.Errorf("Row %d: predicted %f, expected %f", i, predictions.Get(i, 0), inst.Get(i, 1))
+		}
+	}
+	gb.Objective = "focal"
+	defer func() {
+		if recover() == nil {
+			testEnv.Error("Expected a panic for a focal objective with a numeric class")
+		}
+	}()
+	gb.Fit(inst)
+}
+
+// absoluteLoss is the absolute error, whose leaf values are medians
+type absoluteLoss struct{}
+
+func (absoluteLoss) InitialScore(y, w []float64) float64 {
+	return WeightedQuantile(y, w, 0.5)
+}
+
+func (absoluteLoss) NegativeGradient(y, f float64) float64 {
+	if y > f {
+		return 1
+	}
+	return -1
+}
+
+func (absoluteLoss) LeafValue(y, f, w []float64) float64 {
+	return QuantileLoss{0.5}.LeafValue(y, f, w)
+}
+
+func TestGradientBoostingLoss(testEnv *testing.T) {
+	x := base.NewFloatAttribute()
+	x.SetName("x")
+	y := base.NewFloatAttribute()
+	y.SetName("y")
+	inst := base.NewInstances([]base.Attribute{x, y}, 100)
+	for i := 0; i < 100; i++ {
+		inst.Set(i, 0, float64(i/20))
+		inst.Set(i, 1, float64(i%20))
+	}
+	// One large outlier shouldn't move the medians
+	inst.Set(0, 1, 1000)
+
+	gb := NewGradientBoosting(50, 0.5, 2)
+	gb.Loss = absoluteLoss{}
+	gb.Fit(inst)
+	predictions := gb.Predict(inst)
+	for i := 0; i < 100; i++ {
+		if p := predictions.Get(i, 0); p < 9 || p > 10 {
+			testEnv.Errorf("Row %d: predicted %f, expected the median", i, p)
+		}
+	}
+
+	gb.Loss = QuantileLoss{0.9}
+	gb.Fit(inst)
+	predictions = gb.Predict(inst)
+	below := 0
+	for i := 0; i < 100; i++ {
+		if inst.Get(i, 1) <= predictions.Get(i, 0) {
+			below++
+		}
+	}
+	if below < 85 || below > 95 {
+		testEnv.Errorf("Expected about 90 rows below the 0.9 quantile, got %d", below)
+	}
+	if _, ok := gb.Clone().(*GradientBoosting).Loss.(QuantileLoss); !ok {
+		testEnv.Error("Clone should keep the Loss")
+	}
+}
+
+// splitAttributes returns the Attributes a GradientTree splits on
+func splitAttributes(n *GradientTreeNode, ret map[int]bool) map[int]bool {
+	if !n.IsLeaf() {
+		ret[n.Attr] = true
+		splitAttributes(n.Left, ret)
+		splitAttributes(n.Right, ret)
+	}
+	return ret
+}
+
+func TestGradientBoostingSubsampling(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		panic(err)
+	}
+	params := DefaultGradientBoostingParams()
+	params.NumTrees = 20
+	params.Subsample = 0.5
+	params.ColumnSample = 0.25
+	params.Seed = 42
+	gb, err := NewGradientBoostingFromParams(params)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	gb.Fit(inst)
+	for _, trees := range gb.Trees {
+		for _, t := range trees {
+			if attrs := splitAttributes(t.Root, map[int]bool{}); len(attrs) > 1 {
+				testEnv.Errorf("Expected one Attribute per tree, got %v", attrs)
+			}
+		}
+	}
+	accuracy := eval.GetAccuracy(eval.GetConfusionMatrix(inst, gb.Predict(inst)))
+	if accuracy < 0.9 {
+		testEnv.Errorf("Accuracy too low: %.3f", accuracy)
+	}
+
+	// The same Seed gives the same model
+	first := gb.DecisionFunction(inst)
+	gb.Fit(inst)
+	second := gb.DecisionFunction(inst)
+	for i := range first {
+		for k := range first[i] {
+			if first[i][k] != second[i][k] {
+				testEnv.Fatalf("Row %d differs: %v, %v", i, first[i], second[i])
+			}
+		}
+	}
+
+	params.Subsample = 1.5
+	if _, err := NewGradientBoostingFromParams(params); err == nil {
+		testEnv.Error("Expected an error for Subsample > 1")
+	}
+}
+
+func TestGradientBoostingMissingValues(testEnv *testing.T) {
+	// The "a" rows have x between 0 and 9 or missing, and the "b"
+	// rows have x between 20 and 29
+	x := base.NewFloatAttribute()
+	x.SetName("x")
+	class := base.NewCategoricalAttribute()
+	class.SetName("class")
+	inst := base.NewInstances([]base.Attribute{x, class}, 40)
+	for i := 0; i < 40; i++ {
+		switch {
+		case i < 10:
+			inst.Set(i, 0, float64(i))
+			inst.SetAttrStr(i, 1, "a")
+		case i < 20:
+			inst.SetMissing(i, 0)
+			inst.SetAttrStr(i, 1, "a")
+		default:
+			inst.Set(i, 0, float64(i))
+			inst.SetAttrStr(i, 1, "b")
+		}
+	}
+	gb := NewGradientBoosting(10, 0.3, 1)
+	gb.Fit(inst)
+	predictions := gb.Predict(inst)
+	for i := 0; i < inst.Rows; i++ {
+		if predictions.GetClass(i) != inst.GetClass(i) {
+			testEnv.Errorf("Row %d: predicted %s", i, predictions.GetClass(i))
+		}
+	}
+}
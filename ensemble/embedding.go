@@ -0,0 +1,87 @@
+package ensemble
+
+import (
+	"fmt"
+
+	base "github.com/sjwhitworth/golearn/base"
+	pipeline "github.com/sjwhitworth/golearn/pipeline"
+)
+
+// LeafModel implementations are ensembles of trees which can
+// report the leaf each row lands in, such as a RandomForest.
+type LeafModel interface {
+	base.Classifier
+	// Returns, for each row, the index of its leaf in each tree.
+	Apply(*base.Instances) [][]int
+	// Returns the number of leaves in each tree.
+	LeafCounts() []int
+}
+
+// LeafEmbedding is a pipeline.Filter which trains a LeafModel and
+// replaces the Attributes of each row with a one-hot encoding of the
+// leaves it lands in: one numeric Attribute per leaf of each tree,
+// set to one if the row lands in it and zero otherwise. A linear
+// model trained on these features can combine the trees better than
+// voting does.
+type LeafEmbedding struct {
+	Model LeafModel
+	attrs []base.Attribute
+	// offsets holds the index of each tree's first Attribute
+	offsets []int
+}
+
+// NewLeafEmbedding returns a LeafEmbedding around an untrained model.
+func NewLeafEmbedding(model LeafModel) *LeafEmbedding {
+	return &LeafEmbedding{Model: model}
+}
+
+// LeafEmbeddingStep returns a pipeline.Step which embeds the
+// Instances using a LeafModel created by factory.
+func LeafEmbeddingStep(factory func() LeafModel) pipeline.Step {
+	return func() pipeline.Filter {
+		return NewLeafEmbedding(factory())
+	}
+}
+
+// Fit trains the LeafModel on the given Instances.
+func (l *LeafEmbedding) Fit(on *base.Instances) {
+	l.Model.Fit(on)
+	l.attrs = make([]base.Attribute, 0)
+	l.offsets = make([]int, 0)
+	for t, count := range l.Model.LeafCounts() {
+		l.offsets = append(l.offsets, len(l.attrs))
+		for leaf := 0; leaf < count; leaf++ {
+			attr := base.NewFloatAttribute()
+			attr.SetName(fmt.Sprintf("leaf%d_%d", t, leaf))
+			l.attrs = append(l.attrs, attr)
+		}
+	}
+	l.attrs = append(l.attrs, on.GetClassAttr())
+}
+
+// Transform returns the one-hot encoded leaves of each row of what,
+// followed by its class.
+//
+// IMPORTANT: this function panic()s if Fit hasn't been called.
+func (l *LeafEmbedding) Transform(what *base.Instances) *base.Instances {
+	if l.attrs == nil {
+		panic("Call Fit() beforehand")
+	}
+	ret := base.NewInstances(l.attrs, what.Rows)
+	classCol := len(l.attrs) - 1
+	for i, leaves := range l.Model.Apply(what) {
+		for t, leaf := range leaves {
+			if leaf >= 0 {
+				ret.Set(i, l.offsets[t]+leaf, 1.0)
+			}
+		}
+		ret.Set(i, classCol, what.Get(i, what.ClassIndex))
+	}
+	return ret
+}
+
+// String returns a description of the LeafEmbedding and the
+// parameters of its LeafModel.
+func (l *LeafEmbedding) String() string {
+	return fmt.Sprintf("LeafEmbedding(%T(%s))", l.Model, base.FormatParams(base.GetParams(l.Model)))
+}
@@ -3,11 +3,32 @@
 	Ensemble contains classifiers which combine other classifiers.
 
 	RandomForest:
-		Generates ForestSize bagged decision trees (currently ID3-based)
-			each considering a fixed number of random features.
+		Generates ForestSize bagged decision trees (ID3-based unless
+			another BaseLearner is given) each considering a fixed
+			number of random features.
 
 		Built on meta.Bagging
 
+	RandomSubspace:
+		Trains EnsembleSize models (ID3 decision trees by default) on
+			all of the rows, but only a fixed number of random
+			features each.
+
+	RotationForest:
+		Trains ForestSize models on rotated copies of the data: the
+			(numeric) features are split into random groups of
+			GroupSize, and each group is replaced by its principal
+			components.
+
+		Both are built on meta.Bagging
+
+	GradientBoosting:
+		Adds NumTrees small regression trees per class, each fitted
+			to the gradient of the loss of the ones before it. The
+			loss is logistic, focal, squared or a custom Loss, and
+			rows can be weighted. Each tree can be fitted to a
+			random subsample of the rows and Attributes.
+
 */
 
 package ensemble
\ No newline at end of file
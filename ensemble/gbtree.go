@@ -0,0 +1,183 @@
+package ensemble
+
+import (
+	"sort"
+
+	base "github.com/sjwhitworth/golearn/base"
+)
+
+// GradientTreeNode is a node of a GradientTree. Rows whose value of
+// the Attr'th training Attribute is less than or equal to Threshold
+// go Left, the others go Right. Rows where it's missing go Left if
+// MissingLeft is true, which is learnt from the training rows with
+// missing values, and Right otherwise.
+type GradientTreeNode struct {
+	Attr        int
+	Threshold   float64
+	MissingLeft bool
+	Left        *GradientTreeNode
+	Right       *GradientTreeNode
+	// Value is the score a leaf adds to the rows which reach it
+	Value float64
+	// LeafIndex numbers the leaves from left to right
+	LeafIndex int
+}
+
+// IsLeaf returns true if the node has no children
+func (n *GradientTreeNode) IsLeaf() bool {
+	return n.Left == nil
+}
+
+// GradientTree is a binary regression tree fitted to the gradients
+// of a loss function during gradient boosting.
+type GradientTree struct {
+	Root   *GradientTreeNode
+	Leaves int
+}
+
+// getLeaf follows the tree down for the given row of what, where
+// cols maps each training Attribute to its column in what.
+func (t *GradientTree) getLeaf(what *base.Instances, row int, cols []int) *GradientTreeNode {
+	cur := t.Root
+	for !cur.IsLeaf() {
+		if cur.goesLeft(what.Get(row, cols[cur.Attr])) {
+			cur = cur.Left
+		} else {
+			cur = cur.Right
+		}
+	}
+	return cur
+}
+
+// goesLeft returns true if rows with the given value go Left
+func (n *GradientTreeNode) goesLeft(val float64) bool {
+	if base.IsMissingValue(val) {
+		return n.MissingLeft
+	}
+	return val <= n.Threshold
+}
+
+// gradientTreeBuilder grows a GradientTree greedily, choosing the
+// split which best fits the gradients by weighted least squares.
+type gradientTreeBuilder struct {
+	data *base.Instances
+	cols []int
+	// features holds the indices of the cols which can be split on
+	features []int
+	// grad holds the gradient of each row, times its weight
+	grad        []float64
+	weight      []float64
+	maxDepth    int
+	minLeafSize int
+	lambda      float64
+	leafValue   func(rows []int) float64
+	leaves      int
+}
+
+// build returns a GradientTree fitted to the given rows
+func (b *gradientTreeBuilder) build(rows []int) *GradientTree {
+	b.leaves = 0
+	root := b.buildNode(rows, 0)
+	return &GradientTree{root, b.leaves}
+}
+
+// score returns the reduction in squared error from a leaf with the
+// given sums of weighted gradients and weights.
+func (b *gradientTreeBuilder) score(g, w float64) float64 {
+	return g * g / (w + b.lambda)
+}
+
+func (b *gradientTreeBuilder) newLeaf(rows []int) *GradientTreeNode {
+	ret := &GradientTreeNode{Value: b.leafValue(rows), LeafIndex: b.leaves}
+	b.leaves++
+	return ret
+}
+
+func (b *gradientTreeBuilder) buildNode(rows []int, depth int) *GradientTreeNode {
+	if depth >= b.maxDepth || len(rows) < 2*b.minLeafSize {
+		return b.newLeaf(rows)
+	}
+	g, w := 0.0, 0.0
+	for _, r := range rows {
+		g += b.grad[r]
+		w += b.weight[r]
+	}
+	parent := b.score(g, w)
+
+	bestGain, bestAttr, bestThreshold, bestMissingLeft := 1e-12, -1, 0.0, false
+	sorted := make([]int, 0, len(rows))
+	for _, a := range b.features {
+		col := b.cols[a]
+		// Rows with a missing value are tried on either side
+		sorted = sorted[:0]
+		gm, wm, missing := 0.0, 0.0, 0
+		for _, r := range rows {
+			if b.data.IsMissing(r, col) {
+				gm += b.grad[r]
+				wm += b.weight[r]
+				missing++
+			} else {
+				sorted = append(sorted, r)
+			}
+		}
+		sort.Sort(&byColumn{sorted, b.data, col})
+		gl, wl := 0.0, 0.0
+		for i := 0; i < len(sorted)-1; i++ {
+			gl += b.grad[sorted[i]]
+			wl += b.weight[sorted[i]]
+			cur, next := b.data.Get(sorted[i], col), b.data.Get(sorted[i+1], col)
+			if cur == next {
+				continue
+			}
+			nLeft, nRight := i+1, len(sorted)-i-1
+			if nLeft >= b.minLeafSize && nRight+missing >= b.minLeafSize {
+				gain := b.score(gl, wl) + b.score(g-gl, w-wl) - parent
+				if gain > bestGain {
+					bestGain, bestAttr, bestThreshold, bestMissingLeft = gain, a, (cur+next)/2, false
+				}
+			}
+			if missing > 0 && nLeft+missing >= b.minLeafSize && nRight >= b.minLeafSize {
+				gain := b.score(gl+gm, wl+wm) + b.score(g-gl-gm, w-wl-wm) - parent
+				if gain > bestGain {
+					bestGain, bestAttr, bestThreshold, bestMissingLeft = gain, a, (cur+next)/2, true
+				}
+			}
+		}
+	}
+	if bestAttr == -1 {
+		return b.newLeaf(rows)
+	}
+
+	ret := &GradientTreeNode{Attr: bestAttr, Threshold: bestThreshold, MissingLeft: bestMissingLeft}
+	left := make([]int, 0)
+	right := make([]int, 0)
+	for _, r := range rows {
+		if ret.goesLeft(b.data.Get(r, b.cols[bestAttr])) {
+			left = append(left, r)
+		} else {
+			right = append(right, r)
+		}
+	}
+	ret.Left = b.buildNode(left, depth+1)
+	ret.Right = b.buildNode(right, depth+1)
+	return ret
+}
+
+// byColumn sorts row indices by their value in a column
+type byColumn struct {
+	rows []int
+	data *base.Instances
+	col  int
+}
+
+func (b *byColumn) Len() int {
+	return len(b.rows)
+}
+
+func (b *byColumn) Swap(i, j int) {
+	b.rows[i], b.rows[j] = b.rows[j], b.rows[i]
+}
+
+func (b *byColumn) Less(i, j int) bool {
+	return b.data.Get(b.rows[i], b.col) < b.data.Get(b.rows[j], b.col)
+}
@@ -0,0 +1,268 @@
+package ensemble
+
+import (
+	"encoding/gob"
+	"fmt"
+	"math"
+	"sort"
+
+	mathutil "github.com/sjwhitworth/golearn/mathutil"
+)
+
+// Loss is a loss function minimised by GradientBoosting. Each tree
+// is grown to fit the negative gradient of the loss by (weighted)
+// least squares, and the values of its leaves are then chosen to
+// minimise the loss itself.
+//
+// For categorical classes, targets are 1 for rows of the output's
+// class and 0 for the others, and scores are log-odds. For numeric
+// classes, targets are the class values and scores are predictions.
+type Loss interface {
+	// InitialScore returns the constant score which minimises
+	// the loss over targets y with weights w.
+	InitialScore(y, w []float64) float64
+	// NegativeGradient returns the negative derivative of the
+	// loss with respect to the score f, at target y.
+	NegativeGradient(y, f float64) float64
+	// LeafValue returns the amount to add to the scores f of the
+	// rows which reach a leaf, given their targets y and weights w,
+	// to minimise their loss. GradientBoosting shrinks it by the
+	// learning rate.
+	LeafValue(y, f, w []float64) float64
+}
+
+func init() {
+	gob.Register(SquaredLoss{})
+	gob.Register(LogisticLoss{})
+	gob.Register(FocalLoss{})
+	gob.Register(QuantileLoss{})
+}
+
+// newLoss returns the built-in Loss with the given name (see
+// GradientBoostingParams.Objective).
+func newLoss(p GradientBoostingParams, regression bool) (Loss, error) {
+	objective := p.Objective
+	if objective == "" {
+		objective = "logistic"
+		if regression {
+			objective = "squared"
+		}
+	}
+	switch objective {
+	case "squared":
+		return SquaredLoss{}, nil
+	case "logistic":
+		if regression {
+			break
+		}
+		return LogisticLoss{}, nil
+	case "focal":
+		if regression {
+			break
+		}
+		return FocalLoss{p.FocalGamma, p.FocalAlpha}, nil
+	default:
+		return nil, fmt.Errorf("ensemble: unknown Objective %q", objective)
+	}
+	return nil, fmt.Errorf("ensemble: Objective %q needs a categorical class Attribute", objective)
+}
+
+// weightedMean returns the mean of y weighted by w
+func weightedMean(y, w []float64) float64 {
+	var sum, total mathutil.KahanSummer
+	for i := range y {
+		sum.Add(y[i] * w[i])
+		total.Add(w[i])
+	}
+	if total.Sum() == 0 {
+		return 0
+	}
+	return sum.Sum() / total.Sum()
+}
+
+// WeightedQuantile returns the smallest of the values such that
+// their weights up to and including it make up at least the
+// fraction q of the total.
+func WeightedQuantile(values, weights []float64, q float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	order := make([]int, len(values))
+	total := 0.0
+	for i := range order {
+		order[i] = i
+		total += weights[i]
+	}
+	sort.Sort(&byFloat{order, values})
+	cumulative := 0.0
+	for _, i := range order {
+		cumulative += weights[i]
+		if cumulative >= q*total {
+			return values[i]
+		}
+	}
+	return values[order[len(order)-1]]
+}
+
+// byFloat sorts indices by increasing value
+type byFloat struct {
+	order  []int
+	values []float64
+}
+
+func (b *byFloat) Len() int {
+	return len(b.order)
+}
+
+func (b *byFloat) Swap(i, j int) {
+	b.order[i], b.order[j] = b.order[j], b.order[i]
+}
+
+func (b *byFloat) Less(i, j int) bool {
+	return b.values[b.order[i]] < b.values[b.order[j]]
+}
+
+// NewtonLeafValue returns the Newton step which minimises the
+// second-order approximation of a loss over the rows of a leaf,
+// given the first and second derivatives of the loss at each row.
+// It's the LeafValue of most smooth losses.
+func NewtonLeafValue(grad, hess, w []float64) float64 {
+	sumGrad, sumHess := 0.0, 0.0
+	for i := range grad {
+		sumGrad += grad[i] * w[i]
+		sumHess += hess[i] * w[i]
+	}
+	if sumHess <= 0 {
+		return 0
+	}
+	return -sumGrad / sumHess
+}
+
+// logOdds returns the log-odds of p, clamped away from 0 and 1
+func logOdds(p float64) float64 {
+	p = math.Max(1e-6, math.Min(1-1e-6, p))
+	return math.Log(p / (1 - p))
+}
+
+// SquaredLoss is half the squared error, for regression
+type SquaredLoss struct{}
+
+// InitialScore returns the weighted mean of y
+func (SquaredLoss) InitialScore(y, w []float64) float64 {
+	return weightedMean(y, w)
+}
+
+// NegativeGradient returns the residual y - f
+func (SquaredLoss) NegativeGradient(y, f float64) float64 {
+	return y - f
+}
+
+// LeafValue returns the weighted mean residual
+func (SquaredLoss) LeafValue(y, f, w []float64) float64 {
+	residuals := make([]float64, len(y))
+	for i := range y {
+		residuals[i] = y[i] - f[i]
+	}
+	return weightedMean(residuals, w)
+}
+
+// LogisticLoss is the binomial deviance (log loss)
+type LogisticLoss struct{}
+
+// InitialScore returns the log-odds of the weighted mean of y
+func (LogisticLoss) InitialScore(y, w []float64) float64 {
+	return logOdds(weightedMean(y, w))
+}
+
+// NegativeGradient returns y minus the probability implied by f
+func (LogisticLoss) NegativeGradient(y, f float64) float64 {
+	return y - mathutil.Sigmoid(f)
+}
+
+// LeafValue returns a Newton step
+func (LogisticLoss) LeafValue(y, f, w []float64) float64 {
+	grad := make([]float64, len(y))
+	hess := make([]float64, len(y))
+	for i := range y {
+		p := mathutil.Sigmoid(f[i])
+		grad[i] = p - y[i]
+		hess[i] = math.Max(p*(1-p), 1e-16)
+	}
+	return NewtonLeafValue(grad, hess, w)
+}
+
+// FocalLoss down-weights well-classified rows so that training
+// concentrates on the hard ones (Lin et al., 2017):
+//
+//	-Alpha (1-p)^Gamma log(p)          if y = 1
+//	-(1-Alpha) p^Gamma log(1-p)        if y = 0
+//
+// With Gamma zero and Alpha 0.5 it's half the LogisticLoss.
+type FocalLoss struct {
+	Gamma float64
+	Alpha float64
+}
+
+// InitialScore returns the log-odds of the weighted mean of y
+func (l FocalLoss) InitialScore(y, w []float64) float64 {
+	return logOdds(weightedMean(y, w))
+}
+
+// derivative returns the derivative of the loss with respect
+// to the score f
+func (l FocalLoss) derivative(y, f float64) float64 {
+	p := mathutil.Sigmoid(f)
+	if y > 0.5 {
+		return l.Alpha * math.Pow(1-p, l.Gamma) * (l.Gamma*p*mathutil.ClampedLog(p, 1e-300) - (1 - p))
+	}
+	return (1 - l.Alpha) * math.Pow(p, l.Gamma) * (p - l.Gamma*(1-p)*mathutil.ClampedLog(1-p, 1e-300))
+}
+
+// NegativeGradient returns the negative derivative of the loss
+func (l FocalLoss) NegativeGradient(y, f float64) float64 {
+	return -l.derivative(y, f)
+}
+
+// LeafValue returns a Newton step. The second derivative is
+// estimated numerically. The focal loss isn't convex everywhere,
+// so it's kept positive to keep the steps in the right direction.
+func (l FocalLoss) LeafValue(y, f, w []float64) float64 {
+	const h = 1e-5
+	grad := make([]float64, len(y))
+	hess := make([]float64, len(y))
+	for i := range y {
+		grad[i] = l.derivative(y[i], f[i])
+		hess[i] = (l.derivative(y[i], f[i]+h) - l.derivative(y[i], f[i]-h)) / (2 * h)
+		hess[i] = math.Max(hess[i], 1e-6)
+	}
+	return NewtonLeafValue(grad, hess, w)
+}
+
+// QuantileLoss is the pinball loss, for predicting the Alpha
+// quantile of a numeric class rather than its mean. It's an
+// example of a Loss whose leaf values aren't Newton steps.
+type QuantileLoss struct {
+	Alpha float64
+}
+
+// InitialScore returns the weighted Alpha quantile of y
+func (l QuantileLoss) InitialScore(y, w []float64) float64 {
+	return WeightedQuantile(y, w, l.Alpha)
+}
+
+// NegativeGradient returns Alpha if y is above f, Alpha-1 otherwise
+func (l QuantileLoss) NegativeGradient(y, f float64) float64 {
+	if y > f {
+		return l.Alpha
+	}
+	return l.Alpha - 1
+}
+
+// LeafValue returns the weighted Alpha quantile of the residuals
+func (l QuantileLoss) LeafValue(y, f, w []float64) float64 {
+	residuals := make([]float64, len(y))
+	for i := range y {
+		residuals[i] = y[i] - f[i]
+	}
+	return WeightedQuantile(residuals, w, l.Alpha)
+}
@@ -1,19 +1,107 @@
 package ensemble
 
 import (
+	"encoding/gob"
 	base "github.com/sjwhitworth/golearn/base"
 	meta "github.com/sjwhitworth/golearn/meta"
 	trees "github.com/sjwhitworth/golearn/trees"
 	"fmt"
 )
 
+// RandomForestParams holds the parameters of a RandomForest.
+type RandomForestParams struct {
+	// ForestSize controls the number of trees that get built
+	ForestSize int
+	// Features controls the number of features used to build
+	// each tree. Zero means all of them.
+	Features int
+	// MaxDepth limits the depth of each tree. Zero means
+	// unlimited. Ignored if there's a BaseLearner.
+	MaxDepth int
+	// Seed makes training reproducible. Zero means a different
+	// random seed each time.
+	Seed int64
+}
+
+// DefaultRandomForestParams returns the RandomForestParams used
+// by NewRandomForestWithOptions before any options are applied.
+func DefaultRandomForestParams() RandomForestParams {
+	return RandomForestParams{
+		ForestSize: 10,
+	}
+}
+
+// Validate checks that the RandomForestParams are usable.
+func (p RandomForestParams) Validate() error {
+	if p.ForestSize < 1 {
+		return fmt.Errorf("ensemble: ForestSize should be at least 1, got %d", p.ForestSize)
+	}
+	if p.Features < 0 {
+		return fmt.Errorf("ensemble: Features can't be negative, got %d", p.Features)
+	}
+	if p.MaxDepth < 0 {
+		return fmt.Errorf("ensemble: MaxDepth can't be negative, got %d", p.MaxDepth)
+	}
+	return nil
+}
+
+// ValidateFor checks that the RandomForestParams are usable on the
+// given Instances: as well as Validate, there must be at least
+// Features features to choose from.
+func (p RandomForestParams) ValidateFor(on *base.Instances) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	if features := len(on.FeatureIndices()); p.Features > features {
+		return fmt.Errorf("ensemble: Features is %d, but there are only %d features", p.Features, features)
+	}
+	return nil
+}
+
+// RandomForestOption modifies RandomForestParams, see
+// NewRandomForestWithOptions.
+type RandomForestOption func(*RandomForestParams)
+
+// WithForestSize sets the number of trees in a RandomForest.
+func WithForestSize(size int) RandomForestOption {
+	return func(p *RandomForestParams) {
+		p.ForestSize = size
+	}
+}
+
+// WithFeatures sets the number of features used to build each tree.
+func WithFeatures(features int) RandomForestOption {
+	return func(p *RandomForestParams) {
+		p.Features = features
+	}
+}
+
+// WithMaxDepth sets the maximum depth of each tree.
+func WithMaxDepth(depth int) RandomForestOption {
+	return func(p *RandomForestParams) {
+		p.MaxDepth = depth
+	}
+}
+
+// WithSeed makes training reproducible.
+func WithSeed(seed int64) RandomForestOption {
+	return func(p *RandomForestParams) {
+		p.Seed = seed
+	}
+}
+
 // RandomForest classifies instances using an ensemble
 // of bagged random decision trees
 type RandomForest struct {
 	base.BaseClassifier
-	ForestSize int
-	Features   int
-	Model      *meta.BaggedModel
+	RandomForestParams
+	Model *meta.BaggedModel
+	// BaseLearner creates each of the forest's members. If it's nil,
+	// unpruned ID3 decision trees are used.
+	BaseLearner base.ClassifierFactory
+	// Progress, if set, is called after each tree is trained
+	// with the running out-of-bag error (see meta.Progress).
+	Progress meta.ProgressFunc
 }
 
 // NewRandomForests generates and return a new random forests
@@ -22,29 +110,196 @@ type RandomForest struct {
 func NewRandomForest(forestSize int, features int) *RandomForest {
 	ret := &RandomForest{
 		base.BaseClassifier{},
-		forestSize,
-		features,
+		RandomForestParams{ForestSize: forestSize, Features: features},
+		nil,
+		nil,
 		nil,
 	}
 	return ret
 }
 
+func init() {
+	base.RegisterClassifier("randomforest", func() base.Classifier {
+		ret, _ := NewRandomForestFromParams(DefaultRandomForestParams())
+		return ret
+	})
+	gob.Register(&RandomForest{})
+	gob.Register(&RandomSubspace{})
+}
+
+// NewRandomForestFromParams returns a new RandomForest with the
+// given parameters, or an error if they're invalid.
+func NewRandomForestFromParams(params RandomForestParams) (*RandomForest, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	return &RandomForest{
+		base.BaseClassifier{},
+		params,
+		nil,
+		nil,
+		nil,
+	}, nil
+}
+
+// NewRandomForestWithOptions returns a new RandomForest with the
+// DefaultRandomForestParams, as modified by opts.
+func NewRandomForestWithOptions(opts ...RandomForestOption) (*RandomForest, error) {
+	params := DefaultRandomForestParams()
+	for _, o := range opts {
+		o(&params)
+	}
+	return NewRandomForestFromParams(params)
+}
+
+// newID3Learner returns the default RandomForest.BaseLearner
+func (f *RandomForest) newID3Learner() base.ClassifierFactory {
+	maxDepth := f.MaxDepth
+	return func() base.Classifier {
+		ret := trees.NewID3DecisionTree(0.00)
+		ret.MaxDepth = maxDepth
+		return ret
+	}
+}
+
 // Train builds the RandomForest on the specified instances
+//
+// IMPORTANT: this function panic()s if the parameters aren't usable
+// on the instances (see ValidateFor).
 func (f *RandomForest) Fit(on *base.Instances) {
+	if err := f.ValidateFor(on); err != nil {
+		panic(err.Error())
+	}
+	learner := f.BaseLearner
+	if learner == nil {
+		learner = f.newID3Learner()
+	}
 	f.Model = new(meta.BaggedModel)
 	f.Model.RandomFeatures = f.Features
-	for i := 0; i < f.ForestSize; i++ {
-		tree := trees.NewID3DecisionTree(0.00)
-		f.Model.AddModel(tree)
-	}
+	f.Model.Seed = f.Seed
+	f.Model.Progress = f.Progress
+	f.Model.TrackOOBError = f.Progress != nil
+	f.Model.AddModels(f.ForestSize, learner)
 	f.Model.Fit(on)
 }
 
+// AddTrees trains n more trees on the Instances the RandomForest
+// was fitted on and adds them to the forest, keeping the existing
+// ones. Together with OOBError, this allows growing the forest until
+// the error stops improving rather than guessing ForestSize up front.
+//
+// IMPORTANT: this function panic()s if Fit hasn't been called.
+func (f *RandomForest) AddTrees(n int) {
+	if f.Model == nil {
+		panic("Call Fit() beforehand")
+	}
+	learner := f.BaseLearner
+	if learner == nil {
+		learner = f.newID3Learner()
+	}
+	f.Model.Progress = f.Progress
+	f.Model.TrackOOBError = f.Progress != nil
+	f.Model.AddModels(n, learner)
+	f.Model.WarmFit()
+	f.ForestSize = len(f.Model.Models)
+}
+
+// OOBError returns the out-of-bag error estimate of a trained
+// RandomForest (see meta.BaggedModel.OOBError)
+func (f *RandomForest) OOBError() float64 {
+	return f.Model.OOBError()
+}
+
+// Apply returns, for each row of with, the index of the leaf it
+// lands in for each tree (see trees.DecisionTreeNode.Apply). Members
+// which aren't trees.LeafAppliers give -1.
+func (f *RandomForest) Apply(with *base.Instances) [][]int {
+	ret := make([][]int, with.Rows)
+	for i := range ret {
+		ret[i] = make([]int, len(f.Model.Models))
+	}
+	for j, m := range f.Model.Models {
+		var leaves []int
+		if t, ok := m.(trees.LeafApplier); ok {
+			leaves = t.Apply(f.Model.ModelInstances(j, with))
+		}
+		for i := range ret {
+			if leaves == nil {
+				ret[i][j] = -1
+			} else {
+				ret[i][j] = leaves[i]
+			}
+		}
+	}
+	return ret
+}
+
+// LeafCounts returns the number of leaves in each tree, or zero
+// for members which aren't trees.LeafAppliers.
+func (f *RandomForest) LeafCounts() []int {
+	ret := make([]int, len(f.Model.Models))
+	for j, m := range f.Model.Models {
+		if t, ok := m.(trees.LeafApplier); ok {
+			ret[j] = t.LeafCount()
+		}
+	}
+	return ret
+}
+
 // Predict generates predictions from a trained RandomForest
 func (f *RandomForest) Predict(with *base.Instances) *base.Instances {
 	return f.Model.Predict(with)
 }
 
+// PredictProba generates class probability estimates from a trained
+// RandomForest by averaging those of its trees
+func (f *RandomForest) PredictProba(with *base.Instances) []map[string]float64 {
+	return f.Model.PredictProba(with)
+}
+
+// Clone returns an untrained RandomForest with the same parameters
+func (f *RandomForest) Clone() base.Classifier {
+	return &RandomForest{
+		base.BaseClassifier{},
+		f.RandomForestParams,
+		nil,
+		f.BaseLearner,
+		f.Progress,
+	}
+}
+
+// Summary returns the RandomForest's parameters, the composition of
+// the ensemble and a summary of the shape of its trees
+func (f *RandomForest) Summary() string {
+	params := base.FormatParams(base.GetParams(f))
+	if f.Model == nil {
+		return fmt.Sprintf("RandomForest(%s)\nUntrained\n", params)
+	}
+	roots := trees.TreeRoots(f.Model.Models)
+	return fmt.Sprintf("RandomForest(%s)\n%s%s", params, f.Model.Summary(), trees.SummariseTrees(roots))
+}
+
+// Save serialises the trained RandomForest to the file at path,
+// see LoadRandomForest. A custom BaseLearner isn't saved, but its
+// trained trees are (provided they're registered with gob.Register).
+func (f *RandomForest) Save(path string) error {
+	return base.SaveClassifier(path, f)
+}
+
+// LoadRandomForest deserialises a RandomForest saved to the file
+// at path.
+func LoadRandomForest(path string) (*RandomForest, error) {
+	c, err := base.LoadClassifier(path)
+	if err != nil {
+		return nil, err
+	}
+	ret, ok := c.(*RandomForest)
+	if !ok {
+		return nil, fmt.Errorf("ensemble: %s contains a %T, not a RandomForest", path, c)
+	}
+	return ret, nil
+}
+
 func (f *RandomForest) String() string {
 	return fmt.Sprintf("RandomForest(ForestSize: %d, Features:%d, %s\n)", f.ForestSize, f.Features, f.Model)
 }
\ No newline at end of file
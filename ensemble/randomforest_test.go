@@ -2,9 +2,15 @@ package ensemble
 
 import (
 	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
 	base "github.com/sjwhitworth/golearn/base"
 	eval "github.com/sjwhitworth/golearn/evaluation"
 	filters "github.com/sjwhitworth/golearn/filters"
+	knn "github.com/sjwhitworth/golearn/knn"
+	pipeline "github.com/sjwhitworth/golearn/pipeline"
+	trees "github.com/sjwhitworth/golearn/trees"
 	"testing"
 )
 
@@ -27,3 +33,358 @@ func TestRandomForest1(testEnv *testing.T) {
 	fmt.Println(confusionMat)
 	fmt.Println(eval.GetSummary(confusionMat))
 }
+
+func TestRandomForestBaseLearner(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		panic(err)
+	}
+	trainData, testData := base.InstancesTrainTestSplit(inst, 0.60)
+	filt := filters.NewChiMergeFilter(trainData, 0.90)
+	filt.AddAllNumericAttributes()
+	filt.Build()
+	filt.Run(testData)
+	filt.Run(trainData)
+	rf := NewRandomForest(10, 3)
+	rf.BaseLearner = func() base.Classifier {
+		return trees.NewRandomTree(2)
+	}
+	rf.Fit(trainData)
+	for _, m := range rf.Model.Models {
+		if _, ok := m.(*trees.RandomTree); !ok {
+			testEnv.Errorf("Expected a RandomTree, got %s", m)
+		}
+	}
+	predictions := rf.Predict(testData)
+	confusionMat := eval.GetConfusionMatrix(testData, predictions)
+	fmt.Println(eval.GetSummary(confusionMat))
+}
+
+func TestRandomForestClone(testEnv *testing.T) {
+	rf := NewRandomForest(10, 3)
+	rf.BaseLearner = func() base.Classifier {
+		return trees.NewRandomTree(2)
+	}
+	clone := rf.Clone().(*RandomForest)
+	if clone.ForestSize != 10 || clone.Features != 3 || clone.BaseLearner == nil {
+		testEnv.Error(clone)
+	}
+	params := base.GetParams(clone)
+	if params["ForestSize"] != 10 || params["Features"] != 3 {
+		testEnv.Error(params)
+	}
+}
+
+func TestRandomForestParams(testEnv *testing.T) {
+	if _, err := NewRandomForestWithOptions(WithForestSize(0)); err == nil {
+		testEnv.Error("Expected an error for an empty forest")
+	}
+	if _, err := NewRandomForestFromParams(RandomForestParams{ForestSize: 5, Features: -1}); err == nil {
+		testEnv.Error("Expected an error for negative Features")
+	}
+	rf, err := NewRandomForestWithOptions(WithFeatures(3), WithMaxDepth(2), WithSeed(42))
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	defaults := DefaultRandomForestParams()
+	if rf.ForestSize != defaults.ForestSize || rf.Features != 3 || rf.MaxDepth != 2 || rf.Seed != 42 {
+		testEnv.Error(rf.RandomForestParams)
+	}
+	params := base.GetParams(rf)
+	if params["MaxDepth"] != 2 || params["Seed"] != int64(42) {
+		testEnv.Error(params)
+	}
+
+	// Iris only has 4 features to choose from
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if err := NewRandomForest(2, 4).ValidateFor(inst); err != nil {
+		testEnv.Error(err)
+	}
+	tooMany := NewRandomForest(2, 5)
+	if err := tooMany.ValidateFor(inst); err == nil {
+		testEnv.Fatal("Expected an error for more Features than features")
+	}
+	defer func() {
+		if recover() == nil {
+			testEnv.Error("Fit should panic with more Features than features")
+		}
+	}()
+	tooMany.Fit(inst)
+}
+
+func TestRandomForestSeed(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		panic(err)
+	}
+	filt := filters.NewChiMergeFilter(inst, 0.90)
+	filt.AddAllNumericAttributes()
+	filt.Build()
+	filt.Run(inst)
+
+	predict := func() *base.Instances {
+		rf, err := NewRandomForestWithOptions(WithForestSize(5), WithFeatures(2), WithMaxDepth(2), WithSeed(7))
+		if err != nil {
+			testEnv.Fatal(err)
+		}
+		rf.Fit(inst)
+		for _, m := range rf.Model.Models {
+			if m.(*trees.ID3DecisionTree).MaxDepth != 2 {
+				testEnv.Error(m)
+			}
+		}
+		return rf.Predict(inst)
+	}
+	first, second := predict(), predict()
+	for i := 0; i < inst.Rows; i++ {
+		if first.GetClass(i) != second.GetClass(i) {
+			testEnv.Errorf("Row %d: %s != %s", i, first.GetClass(i), second.GetClass(i))
+		}
+	}
+}
+
+func TestRandomForestAddTrees(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		panic(err)
+	}
+	filt := filters.NewChiMergeFilter(inst, 0.90)
+	filt.AddAllNumericAttributes()
+	filt.Build()
+	filt.Run(inst)
+
+	rf := NewRandomForest(5, 3)
+	rf.Fit(inst)
+	first := rf.Model.Models[0]
+	oob := rf.OOBError()
+	if oob < 0 || oob > 1 {
+		testEnv.Error(oob)
+	}
+	rf.AddTrees(10)
+	if rf.ForestSize != 15 || len(rf.Model.Models) != 15 {
+		testEnv.Errorf("Expected 15 trees, got %d (%d)", len(rf.Model.Models), rf.ForestSize)
+	}
+	if rf.Model.Models[0] != first {
+		testEnv.Error("Existing trees shouldn't be replaced")
+	}
+	oob = rf.OOBError()
+	if oob < 0 || oob > 0.5 {
+		testEnv.Error(oob)
+	}
+	predictions := rf.Predict(inst)
+	if predictions.Rows != inst.Rows {
+		testEnv.Error(predictions)
+	}
+}
+
+func TestRandomForestRegistry(testEnv *testing.T) {
+	c, err := base.NewClassifier("randomforest", map[string]interface{}{
+		"ForestSize": 20.0,
+		"Features":   2.0,
+	})
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	rf := c.(*RandomForest)
+	if rf.ForestSize != 20 || rf.Features != 2 {
+		testEnv.Error(rf.RandomForestParams)
+	}
+	if _, err := base.NewClassifier("randomforest", map[string]interface{}{"ForestSize": 0}); err == nil {
+		testEnv.Error("Expected an error for an empty forest")
+	}
+}
+
+func TestRandomForestApply(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		panic(err)
+	}
+	filt := filters.NewChiMergeFilter(inst, 0.90)
+	filt.AddAllNumericAttributes()
+	filt.Build()
+	filt.Run(inst)
+
+	rf := NewRandomForest(5, 2)
+	rf.Fit(inst)
+	leaves := rf.Apply(inst)
+	counts := rf.LeafCounts()
+	if len(leaves) != inst.Rows || len(counts) != 5 {
+		testEnv.Fatal(counts)
+	}
+	for i := range leaves {
+		for j, leaf := range leaves[i] {
+			if leaf < 0 || leaf >= counts[j] {
+				testEnv.Errorf("Row %d: leaf %d of tree %d is out of range", i, leaf, j)
+			}
+		}
+	}
+}
+
+func TestRandomSubspace(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		panic(err)
+	}
+	filt := filters.NewChiMergeFilter(inst, 0.90)
+	filt.AddAllNumericAttributes()
+	filt.Build()
+	filt.Run(inst)
+
+	rs := NewRandomSubspace(10, 3)
+	rs.Seed = 1
+	rs.Fit(inst)
+	predictions := rs.Predict(inst)
+	accuracy := eval.GetAccuracy(eval.GetConfusionMatrix(inst, predictions))
+	if accuracy < 0.7 {
+		testEnv.Error(accuracy)
+	}
+}
+
+func TestRotationForest(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		panic(err)
+	}
+
+	rf := NewRotationForest(10, 2)
+	rf.Seed = 1
+	rf.Fit(inst)
+	predictions := rf.Predict(inst)
+	accuracy := eval.GetAccuracy(eval.GetConfusionMatrix(inst, predictions))
+	if accuracy < 0.8 {
+		testEnv.Error(accuracy)
+	}
+	proba := rf.PredictProba(inst)
+	for i := range proba {
+		total := 0.0
+		for _, p := range proba[i] {
+			total += p
+		}
+		if math.Abs(total-1) > 1e-6 {
+			testEnv.Errorf("Row %d: probabilities add up to %f", i, total)
+		}
+	}
+}
+
+func TestLeafEmbedding(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		panic(err)
+	}
+
+	embedding := LeafEmbeddingStep(func() LeafModel {
+		rf := NewRandomForest(5, 3)
+		rf.Seed = 1
+		return rf
+	})
+	p := pipeline.NewPipeline(knn.NewKnnClassifier("euclidean", 3), pipeline.ChiMerge(0.90), embedding)
+	p.Fit(inst)
+
+	embedded := p.Transform(inst)
+	if embedded.Rows != inst.Rows || embedded.ClassIndex != embedded.Cols-1 {
+		testEnv.Fatal(embedded)
+	}
+	// Each row should land in exactly one leaf of each tree
+	for i := 0; i < embedded.Rows; i++ {
+		total := 0.0
+		for j := 0; j < embedded.Cols; j++ {
+			if j != embedded.ClassIndex {
+				total += embedded.Get(i, j)
+			}
+		}
+		if total != 5 {
+			testEnv.Errorf("Row %d lands in %f leaves", i, total)
+		}
+		if embedded.GetClass(i) != inst.GetClass(i) {
+			testEnv.Errorf("Row %d has the wrong class", i)
+		}
+	}
+
+	// On held-out rows, KNN on the leaves should beat KNN on the
+	// discretised features the forest was trained on, and be about
+	// as good as KNN on the raw features, which is close to the best
+	// possible on iris. Single splits differ by a row or two, so
+	// the accuracies are averaged over several.
+	var leaves, discretised, raw float64
+	for seed := int64(1); seed <= 5; seed++ {
+		trainData, testData := base.InstancesTrainTestSplitWithSeed(inst, 0.5, seed)
+		heldOut := func(cls base.Classifier) float64 {
+			cls.Fit(trainData)
+			return eval.GetAccuracy(eval.GetConfusionMatrix(testData, cls.Predict(testData))) / 5
+		}
+		leaves += heldOut(pipeline.NewPipeline(knn.NewKnnClassifier("euclidean", 3), pipeline.ChiMerge(0.90), embedding))
+		discretised += heldOut(pipeline.NewPipeline(knn.NewKnnClassifier("euclidean", 3), pipeline.ChiMerge(0.90)))
+		raw += heldOut(knn.NewKnnClassifier("euclidean", 3))
+	}
+	if leaves < discretised || leaves < raw-0.02 {
+		testEnv.Errorf("Leaf accuracy %.3f, discretised %.3f, raw %.3f", leaves, discretised, raw)
+	}
+}
+
+func TestRandomForestSave(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		panic(err)
+	}
+	filt := filters.NewChiMergeFilter(inst, 0.90)
+	filt.AddAllNumericAttributes()
+	filt.Build()
+	filt.Run(inst)
+
+	rf := NewRandomForest(5, 2)
+	rf.Fit(inst)
+	f, err := ioutil.TempFile("", "randomforest")
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+	if err := rf.Save(f.Name()); err != nil {
+		testEnv.Fatal(err)
+	}
+	loaded, err := LoadRandomForest(f.Name())
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if loaded.ForestSize != 5 || loaded.Features != 2 || len(loaded.Model.Models) != 5 {
+		testEnv.Fatal(loaded)
+	}
+
+	// Reload the Instances, so that they don't share any Attributes
+	// with the saved forest
+	fresh, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		panic(err)
+	}
+	filt.Run(fresh)
+	expected := rf.Predict(inst)
+	predictions := loaded.Predict(fresh)
+	for i := 0; i < inst.Rows; i++ {
+		if expected.GetClass(i) != predictions.GetClass(i) {
+			testEnv.Errorf("Row %d: expected %s, got %s", i, expected.GetClass(i), predictions.GetClass(i))
+		}
+	}
+}
+
+func TestRandomForestFloat32(testEnv *testing.T) {
+	defer base.SetConfig(base.GetConfig())
+	base.SetConfig(base.Config{Float32: true})
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if !inst.IsFloat32() {
+		testEnv.Fatal("Expected float32 storage")
+	}
+	trainData, testData := base.InstancesTrainTestSplitWithSeed(inst, 0.60, 1)
+	rf := NewRandomForest(10, 3)
+	rf.Seed = 1
+	rf.Fit(trainData)
+	predictions := rf.Predict(testData)
+	if acc := eval.GetAccuracy(eval.GetConfusionMatrix(testData, predictions)); acc < 0.8 {
+		testEnv.Errorf("Accuracy too low on float32 storage: %.2f", acc)
+	}
+}
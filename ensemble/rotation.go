@@ -0,0 +1,235 @@
+package ensemble
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/gonum/matrix/mat64"
+	base "github.com/sjwhitworth/golearn/base"
+	meta "github.com/sjwhitworth/golearn/meta"
+	pca "github.com/sjwhitworth/golearn/pca"
+	pipeline "github.com/sjwhitworth/golearn/pipeline"
+	trees "github.com/sjwhitworth/golearn/trees"
+)
+
+// RotationForest classifies instances using an ensemble of
+// models, each trained on a rotation of the Attributes: they're
+// split into random groups, and each group is replaced by its
+// principal components (computed on a random subset of the rows
+// and classes). The Attributes must be numeric.
+type RotationForest struct {
+	base.BaseClassifier
+	ForestSize int
+	// GroupSize is the number of Attributes in each group
+	GroupSize int
+	// Seed makes training reproducible. Zero means a different
+	// random seed each time.
+	Seed  int64
+	Model *meta.BaggedModel
+	// BaseLearner creates each of the forest's members. If it's
+	// nil, unpruned ID3 decision trees on ChiMerge-discretised
+	// Attributes are used.
+	BaseLearner base.ClassifierFactory
+}
+
+// NewRotationForest returns a new RotationForest of forestSize
+// models, splitting the Attributes into groups of groupSize.
+func NewRotationForest(forestSize int, groupSize int) *RotationForest {
+	return &RotationForest{
+		base.BaseClassifier{},
+		forestSize,
+		groupSize,
+		0,
+		nil,
+		nil,
+	}
+}
+
+// newDiscretisedID3Learner is the default RotationForest.BaseLearner
+func newDiscretisedID3Learner() base.Classifier {
+	return pipeline.NewPipeline(trees.NewID3DecisionTree(0.00), pipeline.ChiMerge(0.90))
+}
+
+// Fit trains each of the models on its own rotation of on
+func (f *RotationForest) Fit(on *base.Instances) {
+	learner := f.BaseLearner
+	if learner == nil {
+		learner = newDiscretisedID3Learner
+	}
+	seed := f.Seed
+	if seed == 0 {
+		seed = rand.Int63()
+	}
+	seeds := rand.New(rand.NewSource(seed))
+	f.Model = new(meta.BaggedModel)
+	// Every model sees all of the rows
+	f.Model.WithoutReplacement = true
+	f.Model.Seed = seeds.Int63()
+	for i := 0; i < f.ForestSize; i++ {
+		f.Model.AddModel(&rotatedClassifier{
+			GroupSize: f.GroupSize,
+			Seed:      seeds.Int63(),
+			Inner:     learner(),
+		})
+	}
+	f.Model.Fit(on)
+}
+
+// Predict outputs the majority vote of the models
+func (f *RotationForest) Predict(with *base.Instances) *base.Instances {
+	return f.Model.Predict(with)
+}
+
+// PredictProba averages the class probabilities of the models
+func (f *RotationForest) PredictProba(with *base.Instances) []map[string]float64 {
+	return f.Model.PredictProba(with)
+}
+
+// Clone returns an untrained RotationForest with the same parameters
+func (f *RotationForest) Clone() base.Classifier {
+	ret := NewRotationForest(f.ForestSize, f.GroupSize)
+	ret.Seed = f.Seed
+	ret.BaseLearner = f.BaseLearner
+	return ret
+}
+
+func (f *RotationForest) String() string {
+	return fmt.Sprintf("RotationForest(ForestSize: %d, GroupSize: %d, %s\n)", f.ForestSize, f.GroupSize, f.Model)
+}
+
+// rotatedClassifier trains its Inner base.Classifier on a random
+// rotation of the Instances it's given.
+type rotatedClassifier struct {
+	GroupSize int
+	Seed      int64
+	Inner     base.Classifier
+	groups    [][]int
+	pcas      []*pca.PCA
+	attrs     []base.Attribute
+}
+
+// Fit computes the rotation and trains the Inner base.Classifier
+func (r *rotatedClassifier) Fit(on *base.Instances) {
+	rng := rand.New(rand.NewSource(r.Seed))
+	groupSize := r.GroupSize
+	if groupSize < 1 {
+		groupSize = 1
+	}
+
+	// Split the Attributes into random groups
+	cols := make([]int, 0)
+	for _, j := range rng.Perm(on.Cols) {
+		if on.IsFeature(j) {
+			cols = append(cols, j)
+		}
+	}
+	r.groups = make([][]int, 0)
+	for len(cols) > 0 {
+		size := groupSize
+		if size > len(cols) {
+			size = len(cols)
+		}
+		r.groups = append(r.groups, cols[:size])
+		cols = cols[size:]
+	}
+
+	// Compute the principal components of each group
+	classes := make([]string, 0)
+	for c := range on.CountClassValues() {
+		classes = append(classes, c)
+	}
+	r.pcas = make([]*pca.PCA, len(r.groups))
+	r.attrs = make([]base.Attribute, 0)
+	for g, group := range r.groups {
+		rows := r.sampleRows(on, classes, rng)
+		data := mat64.NewDense(len(rows), len(group), nil)
+		for i, row := range rows {
+			for j, col := range group {
+				data.Set(i, j, on.Get(row, col))
+			}
+		}
+		r.pcas[g] = pca.NewPCA(0)
+		r.pcas[g].Fit(data)
+		for k := range group {
+			attr := base.NewFloatAttribute()
+			attr.SetName(fmt.Sprintf("rotation%d_%d", g, k))
+			r.attrs = append(r.attrs, attr)
+		}
+	}
+	r.attrs = append(r.attrs, on.GetClassAttr())
+	r.Inner.Fit(r.rotate(on))
+}
+
+// sampleRows picks 75% of the rows (with replacement) belonging
+// to a random, non-empty subset of the classes.
+func (r *rotatedClassifier) sampleRows(on *base.Instances, classes []string, rng *rand.Rand) []int {
+	keep := make(map[string]bool)
+	for _, c := range classes {
+		keep[c] = rng.Intn(2) == 0
+	}
+	keep[classes[rng.Intn(len(classes))]] = true
+	candidates := make([]int, 0)
+	for i := 0; i < on.Rows; i++ {
+		if keep[on.GetClass(i)] {
+			candidates = append(candidates, i)
+		}
+	}
+	size := len(candidates) * 3 / 4
+	if size < 2 {
+		return candidates
+	}
+	ret := make([]int, size)
+	for i := range ret {
+		ret[i] = candidates[rng.Intn(len(candidates))]
+	}
+	return ret
+}
+
+// rotate returns the rotated version of what
+func (r *rotatedClassifier) rotate(what *base.Instances) *base.Instances {
+	ret := base.NewInstances(r.attrs, what.Rows)
+	col := 0
+	for g, group := range r.groups {
+		data := mat64.NewDense(what.Rows, len(group), nil)
+		for i := 0; i < what.Rows; i++ {
+			for j, c := range group {
+				data.Set(i, j, what.Get(i, c))
+			}
+		}
+		rotated := r.pcas[g].Transform(data)
+		for i := 0; i < what.Rows; i++ {
+			for k := range group {
+				ret.Set(i, col+k, rotated.At(i, k))
+			}
+		}
+		col += len(group)
+	}
+	for i := 0; i < what.Rows; i++ {
+		ret.Set(i, col, what.Get(i, what.ClassIndex))
+	}
+	return ret
+}
+
+// Predict rotates what and passes it to the Inner base.Classifier
+func (r *rotatedClassifier) Predict(what *base.Instances) *base.Instances {
+	return r.Inner.Predict(r.rotate(what))
+}
+
+// PredictProba rotates what and passes it to the Inner
+// base.Classifier, if it's a base.ProbabilisticClassifier
+func (r *rotatedClassifier) PredictProba(what *base.Instances) []map[string]float64 {
+	rotated := r.rotate(what)
+	if p, ok := r.Inner.(base.ProbabilisticClassifier); ok {
+		return p.PredictProba(rotated)
+	}
+	predictions := r.Inner.Predict(rotated)
+	ret := make([]map[string]float64, predictions.Rows)
+	for i := range ret {
+		ret[i] = map[string]float64{predictions.GetClass(i): 1.0}
+	}
+	return ret
+}
+
+func (r *rotatedClassifier) String() string {
+	return fmt.Sprintf("rotatedClassifier(%s)", r.Inner)
+}
@@ -0,0 +1,80 @@
+package ensemble
+
+import (
+	"fmt"
+
+	base "github.com/sjwhitworth/golearn/base"
+	meta "github.com/sjwhitworth/golearn/meta"
+	trees "github.com/sjwhitworth/golearn/trees"
+)
+
+// RandomSubspace classifies instances using an ensemble of
+// models, each trained on all of the rows but only a random
+// subset of the Attributes.
+type RandomSubspace struct {
+	base.BaseClassifier
+	EnsembleSize int
+	Features     int
+	// Seed makes training reproducible. Zero means a different
+	// random seed each time.
+	Seed  int64
+	Model *meta.BaggedModel
+	// BaseLearner creates each of the ensemble's members. If it's
+	// nil, unpruned ID3 decision trees are used.
+	BaseLearner base.ClassifierFactory
+}
+
+// NewRandomSubspace returns a new RandomSubspace ensemble of
+// ensembleSize models, each trained on features Attributes.
+func NewRandomSubspace(ensembleSize int, features int) *RandomSubspace {
+	return &RandomSubspace{
+		base.BaseClassifier{},
+		ensembleSize,
+		features,
+		0,
+		nil,
+		nil,
+	}
+}
+
+// Fit trains each of the models on a random subspace
+//
+// IMPORTANT: this function panic()s if Features is more than the
+// number of features of on.
+func (r *RandomSubspace) Fit(on *base.Instances) {
+	learner := r.BaseLearner
+	if learner == nil {
+		learner = func() base.Classifier {
+			return trees.NewID3DecisionTree(0.00)
+		}
+	}
+	r.Model = new(meta.BaggedModel)
+	r.Model.RandomFeatures = r.Features
+	// Every model sees all of the rows
+	r.Model.WithoutReplacement = true
+	r.Model.Seed = r.Seed
+	r.Model.AddModels(r.EnsembleSize, learner)
+	r.Model.Fit(on)
+}
+
+// Predict outputs the majority vote of the models
+func (r *RandomSubspace) Predict(with *base.Instances) *base.Instances {
+	return r.Model.Predict(with)
+}
+
+// PredictProba averages the class probabilities of the models
+func (r *RandomSubspace) PredictProba(with *base.Instances) []map[string]float64 {
+	return r.Model.PredictProba(with)
+}
+
+// Clone returns an untrained RandomSubspace with the same parameters
+func (r *RandomSubspace) Clone() base.Classifier {
+	ret := NewRandomSubspace(r.EnsembleSize, r.Features)
+	ret.Seed = r.Seed
+	ret.BaseLearner = r.BaseLearner
+	return ret
+}
+
+func (r *RandomSubspace) String() string {
+	return fmt.Sprintf("RandomSubspace(EnsembleSize: %d, Features: %d, %s\n)", r.EnsembleSize, r.Features, r.Model)
+}
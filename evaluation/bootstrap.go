@@ -0,0 +1,156 @@
+package evaluation
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+
+	"github.com/sjwhitworth/golearn/base"
+)
+
+// BootstrapResult holds the error rates (proportions of rows
+// misclassified) estimated by Bootstrap632Plus.
+type BootstrapResult struct {
+	// TrainingError is the error of a Classifier trained on all of
+	// the rows, on those same rows, which is optimistic
+	TrainingError float64
+	// OutOfBagError is the leave-one-out bootstrap error: the mean,
+	// over the rows, of the error on each row of the Classifiers
+	// whose resample didn't include it, which is pessimistic
+	OutOfBagError float64
+	// NoInformationError is the error expected if the features
+	// told the Classifier nothing about the class
+	NoInformationError float64
+	// Overfitting is the relative overfitting rate: how far the
+	// OutOfBagError is from the TrainingError towards the
+	// NoInformationError, from 0 to 1
+	Overfitting float64
+	// Error632 is the .632 estimate, and Error632Plus the .632+
+	// estimate, which gives the OutOfBagError more weight the more
+	// the Classifier overfits
+	Error632     float64
+	Error632Plus float64
+	// Samples is the number of resamples trained on
+	Samples int
+}
+
+// String describes the estimates
+func (r *BootstrapResult) String() string {
+	var buffer bytes.Buffer
+	buffer.WriteString(fmt.Sprintf("Training error:       %.4f\n", r.TrainingError))
+	buffer.WriteString(fmt.Sprintf("Out-of-bag error:     %.4f\n", r.OutOfBagError))
+	buffer.WriteString(fmt.Sprintf("No-information error: %.4f\n", r.NoInformationError))
+	buffer.WriteString(fmt.Sprintf(".632 error:           %.4f\n", r.Error632))
+	buffer.WriteString(fmt.Sprintf(".632+ error:          %.4f (from %d resamples)\n", r.Error632Plus, r.Samples))
+	return buffer.String()
+}
+
+// Bootstrap632Plus estimates the error rate of the Classifiers
+// created by factory with Efron and Tibshirani's .632+ bootstrap
+// estimator. Each of samples Classifiers is trained on a resample of
+// the rows of data, drawn with replacement, and tested on the rows it
+// didn't draw; their error is combined with the error of a Classifier
+// trained and tested on all of data. This has less variance than
+// cross-validation on very small datasets. The resamples are
+// determined by seed, and trained concurrently, as allowed by
+// base.GetConfig.
+func Bootstrap632Plus(factory base.ClassifierFactory, data *base.Instances, samples int, seed int64) (*BootstrapResult, error) {
+	if samples < 1 {
+		return nil, fmt.Errorf("evaluation: need at least 1 resample, got %d", samples)
+	}
+	if data.Rows < 2 {
+		return nil, fmt.Errorf("evaluation: can't resample %d rows", data.Rows)
+	}
+	rng := rand.New(rand.NewSource(seed))
+	resamples := make([][]int, samples)
+	for k := range resamples {
+		resamples[k] = make([]int, data.Rows)
+		for i := range resamples[k] {
+			resamples[k][i] = rng.Intn(data.Rows)
+		}
+	}
+
+	// Count how often each row is misclassified when it's out of
+	// the bag
+	wrong := make([][]bool, samples)
+	outOfBag := make([][]int, samples)
+	workers := base.GetConfig().WorkersFor(base.InstancesBytes(data.Rows, data.Cols))
+	base.Parallel(samples, workers, func(k int) {
+		inBag := make([]bool, data.Rows)
+		for _, r := range resamples[k] {
+			inBag[r] = true
+		}
+		for r, ok := range inBag {
+			if !ok {
+				outOfBag[k] = append(outOfBag[k], r)
+			}
+		}
+		if len(outOfBag[k]) == 0 {
+			return
+		}
+		c := factory()
+		c.Fit(data.ViewRows(resamples[k]))
+		testData := data.ViewRows(outOfBag[k])
+		predictions := c.Predict(testData)
+		wrong[k] = make([]bool, len(outOfBag[k]))
+		for i := range outOfBag[k] {
+			wrong[k][i] = predictions.GetClass(i) != testData.GetClass(i)
+		}
+	})
+	errors := make([]float64, data.Rows)
+	tested := make([]float64, data.Rows)
+	for k := range outOfBag {
+		for i, r := range outOfBag[k] {
+			tested[r]++
+			if wrong[k][i] {
+				errors[r]++
+			}
+		}
+	}
+	ret := &BootstrapResult{Samples: samples}
+	rows := 0.0
+	for r := range errors {
+		if tested[r] > 0 {
+			ret.OutOfBagError += errors[r] / tested[r]
+			rows++
+		}
+	}
+	if rows == 0 {
+		return nil, fmt.Errorf("evaluation: every row was drawn in each of %d resamples", samples)
+	}
+	ret.OutOfBagError /= rows
+
+	// The training error, and the no-information error: the
+	// chance that the class and prediction of independently drawn
+	// rows differ
+	c := factory()
+	c.Fit(data)
+	predictions := c.Predict(data)
+	n := float64(data.Rows)
+	actual := make(map[string]float64)
+	predicted := make(map[string]float64)
+	for i := 0; i < data.Rows; i++ {
+		class, prediction := data.GetClass(i), predictions.GetClass(i)
+		if class != prediction {
+			ret.TrainingError++
+		}
+		actual[class]++
+		predicted[prediction]++
+	}
+	ret.TrainingError /= n
+	for class, count := range actual {
+		ret.NoInformationError += count / n * (1 - predicted[class]/n)
+	}
+
+	ret.Error632 = 0.368*ret.TrainingError + 0.632*ret.OutOfBagError
+	oob := ret.OutOfBagError
+	if oob > ret.NoInformationError {
+		oob = ret.NoInformationError
+	}
+	if oob > ret.TrainingError && ret.NoInformationError > ret.TrainingError {
+		ret.Overfitting = (oob - ret.TrainingError) / (ret.NoInformationError - ret.TrainingError)
+	}
+	weight := 0.632 / (1 - 0.368*ret.Overfitting)
+	ret.Error632Plus = (1-weight)*ret.TrainingError + weight*oob
+	return ret, nil
+}
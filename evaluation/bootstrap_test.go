@@ -0,0 +1,58 @@
+package evaluation
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/sjwhitworth/golearn/base"
+	"github.com/sjwhitworth/golearn/knn"
+)
+
+func TestBootstrap632Plus(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	factory := func() base.Classifier {
+		return knn.NewKnnClassifier("euclidean", 1)
+	}
+	result, err := Bootstrap632Plus(factory, inst, 20, 1)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	// 1-NN memorises the training data
+	if result.TrainingError != 0 {
+		testEnv.Error(result.TrainingError)
+	}
+	// Three balanced classes, predicted perfectly
+	if math.Abs(result.NoInformationError-2.0/3) > 1e-9 {
+		testEnv.Error(result.NoInformationError)
+	}
+	if result.OutOfBagError <= 0 || result.OutOfBagError > 0.15 {
+		testEnv.Error(result.OutOfBagError)
+	}
+	if result.Error632Plus < result.Error632 || result.Error632Plus > result.OutOfBagError {
+		testEnv.Error(result)
+	}
+	if !strings.Contains(result.String(), ".632+") {
+		testEnv.Error(result.String())
+	}
+
+	// A Classifier that ignores the features can't overfit its way
+	// below the no-information error
+	constant := func() base.Classifier {
+		return &constantClassifier{}
+	}
+	result, err = Bootstrap632Plus(constant, inst, 10, 1)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if math.Abs(result.Error632Plus-2.0/3) > 0.01 || result.Overfitting != 0 {
+		testEnv.Error(result.OutOfBagError, result.NoInformationError, result.Overfitting, result.Error632Plus)
+	}
+
+	if _, err := Bootstrap632Plus(factory, inst, 0, 1); err == nil {
+		testEnv.Error("Should refuse no resamples")
+	}
+}
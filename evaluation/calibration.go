@@ -0,0 +1,93 @@
+package evaluation
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/sjwhitworth/golearn/base"
+)
+
+// GetBrierScore returns the Brier score of the probabilities of class
+// (e.g. from a base.ProbabilisticClassifier or CrossValPredict) for
+// the rows of ref: the mean squared difference between each
+// probability and one if the row has the class, or zero otherwise.
+// It's between 0 and 1, and lower is better; like GetLogLoss it
+// rewards well-calibrated probabilities, but punishes confident
+// mistakes less harshly. Rows are weighted by ref's weights.
+func GetBrierScore(ref *base.Instances, probabilities []map[string]float64, class string) float64 {
+	if ref.Rows != len(probabilities) {
+		panic("Row counts should match")
+	}
+	total, weight := 0.0, 0.0
+	for i := 0; i < ref.Rows; i++ {
+		d := probabilities[i][class]
+		if ref.GetClass(i) == class {
+			d--
+		}
+		w := ref.GetWeight(i)
+		total += w * d * d
+		weight += w
+	}
+	return total / weight
+}
+
+// CalibrationBin is one point on a calibration curve (or reliability
+// diagram): of the rows whose probability of the class is in [Lower,
+// Upper), the mean probability is MeanPredicted, and the proportion
+// which actually have the class is Observed. For well-calibrated
+// probabilities they're about the same.
+type CalibrationBin struct {
+	Lower         float64
+	Upper         float64
+	MeanPredicted float64
+	Observed      float64
+	// Weight is the total weight of the rows in the bin (the number
+	// of them, if they're unweighted)
+	Weight float64
+}
+
+// GetCalibrationCurve divides [0, 1] into bins of equal width (the
+// last includes 1) and returns, for each which the probabilities of
+// class for the rows of ref fall into, how many of those rows
+// actually have the class. Empty bins are left out. Rows are
+// weighted by ref's weights.
+//
+// IMPORTANT: panic()s if bins isn't positive.
+func GetCalibrationCurve(ref *base.Instances, probabilities []map[string]float64, class string, bins int) []CalibrationBin {
+	if ref.Rows != len(probabilities) {
+		panic("Row counts should match")
+	}
+	if bins < 1 {
+		panic(fmt.Sprintf("evaluation: need at least 1 bin, got %d", bins))
+	}
+	predicted := make([]float64, bins)
+	observed := make([]float64, bins)
+	weights := make([]float64, bins)
+	for i := 0; i < ref.Rows; i++ {
+		p := probabilities[i][class]
+		k := int(math.Min(math.Floor(p*float64(bins)), float64(bins-1)))
+		if k < 0 {
+			k = 0
+		}
+		w := ref.GetWeight(i)
+		predicted[k] += w * p
+		if ref.GetClass(i) == class {
+			observed[k] += w
+		}
+		weights[k] += w
+	}
+	ret := make([]CalibrationBin, 0, bins)
+	for k := range weights {
+		if weights[k] == 0 {
+			continue
+		}
+		ret = append(ret, CalibrationBin{
+			Lower:         float64(k) / float64(bins),
+			Upper:         float64(k+1) / float64(bins),
+			MeanPredicted: predicted[k] / weights[k],
+			Observed:      observed[k] / weights[k],
+			Weight:        weights[k],
+		})
+	}
+	return ret
+}
@@ -0,0 +1,58 @@
+package evaluation
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sjwhitworth/golearn/base"
+)
+
+func TestBrierScore(testEnv *testing.T) {
+	attrs := []base.Attribute{base.NewCategoricalAttribute()}
+	ref := base.NewInstances(attrs, 4)
+	for i, c := range []string{"a", "b", "a", "b"} {
+		ref.SetAttrStr(i, 0, c)
+	}
+	probabilities := []map[string]float64{
+		{"a": 1},
+		{"a": 0.5, "b": 0.5},
+		{"a": 0.8, "b": 0.2},
+		{"b": 1},
+	}
+	expected := (0 + 0.25 + 0.04 + 0) / 4
+	if score := GetBrierScore(ref, probabilities, "a"); math.Abs(score-expected) > 1e-9 {
+		testEnv.Error(score)
+	}
+	ref.SetWeight(1, 3)
+	expected = (0 + 3*0.25 + 0.04 + 0) / 6
+	if score := GetBrierScore(ref, probabilities, "a"); math.Abs(score-expected) > 1e-9 {
+		testEnv.Error(score)
+	}
+}
+
+func TestCalibrationCurve(testEnv *testing.T) {
+	attrs := []base.Attribute{base.NewCategoricalAttribute()}
+	ref := base.NewInstances(attrs, 6)
+	probabilities := make([]map[string]float64, 6)
+	for i, c := range []string{"a", "b", "b", "a", "a", "b"} {
+		ref.SetAttrStr(i, 0, c)
+	}
+	for i, p := range []float64{0.1, 0.2, 0.3, 0.9, 1, 0.8} {
+		probabilities[i] = map[string]float64{"a": p}
+	}
+	curve := GetCalibrationCurve(ref, probabilities, "a", 2)
+	if len(curve) != 2 {
+		testEnv.Fatal(curve)
+	}
+	if low := curve[0]; low.Weight != 3 || math.Abs(low.MeanPredicted-0.2) > 1e-9 || math.Abs(low.Observed-1.0/3) > 1e-9 {
+		testEnv.Error(low)
+	}
+	// A probability of one goes in the last bin
+	if high := curve[1]; high.Lower != 0.5 || high.Weight != 3 || math.Abs(high.MeanPredicted-0.9) > 1e-9 || math.Abs(high.Observed-2.0/3) > 1e-9 {
+		testEnv.Error(high)
+	}
+	// Empty bins are left out, and 0.9 and 1 share the last
+	if curve := GetCalibrationCurve(ref, probabilities, "a", 10); len(curve) != 5 {
+		testEnv.Error(curve)
+	}
+}
@@ -3,6 +3,10 @@ package evaluation
 import (
 	"bytes"
 	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
 	"github.com/sjwhitworth/golearn/base"
 )
 
@@ -17,21 +21,162 @@ func GetConfusionMatrix(ref *base.Instances, gen *base.Instances) map[string]map
 		panic("Row counts should match")
 	}
 
-	ret := make(map[string]map[string]int)
+	ret := make(ConfusionMatrix)
+
+	for i := 0; i < ref.Rows; i++ {
+		ret.Add(ref.GetClass(i), gen.GetClass(i), 1)
+	}
+	return ret
+}
+
+// Add adds count to the number of rows of class actual which were
+// predicted to have class predicted.
+func (c ConfusionMatrix) Add(actual, predicted string, count int) {
+	if _, ok := c[actual]; !ok {
+		c[actual] = make(map[string]int)
+	}
+	c[actual][predicted] += count
+}
+
+// Merge adds each of the counts of other to c, e.g. to total the
+// matrices of the folds of CrossValidate.
+func (c ConfusionMatrix) Merge(other ConfusionMatrix) {
+	for actual, row := range other {
+		for predicted, count := range row {
+			c.Add(actual, predicted, count)
+		}
+	}
+}
+
+// MergeConfusionMatrices returns a new ConfusionMatrix with the
+// total counts of matrices, which any of the metrics below can be
+// computed from. Micro-averaged metrics of the total weigh every row
+// equally, unlike the mean of each matrix's metrics.
+func MergeConfusionMatrices(matrices ...ConfusionMatrix) ConfusionMatrix {
+	ret := make(ConfusionMatrix)
+	for _, c := range matrices {
+		ret.Merge(c)
+	}
+	return ret
+}
 
+// WeightedConfusionMatrix is a nested map of the total weight of
+// the rows with each actual and predicted class (see
+// base.Instances.SetWeight).
+type WeightedConfusionMatrix map[string]map[string]float64
+
+// GetWeightedConfusionMatrix builds a WeightedConfusionMatrix from
+// a set of reference (`ref') and generated (`gen') Instances, where
+// each row counts as much as its weight in ref. If none of the
+// weights have been set, it has the same entries as
+// GetConfusionMatrix.
+func GetWeightedConfusionMatrix(ref *base.Instances, gen *base.Instances) WeightedConfusionMatrix {
+	if ref.Rows != gen.Rows {
+		panic("Row counts should match")
+	}
+	ret := make(WeightedConfusionMatrix)
 	for i := 0; i < ref.Rows; i++ {
 		referenceClass := ref.GetClass(i)
 		predictedClass := gen.GetClass(i)
-		if _, ok := ret[referenceClass]; ok {
-			ret[referenceClass][predictedClass] += 1
-		} else {
-			ret[referenceClass] = make(map[string]int)
-			ret[referenceClass][predictedClass] = 1
+		if _, ok := ret[referenceClass]; !ok {
+			ret[referenceClass] = make(map[string]float64)
 		}
+		ret[referenceClass][predictedClass] += ref.GetWeight(i)
 	}
 	return ret
 }
 
+// Normalisation says how NormaliseConfusionMatrix scales the entries
+// of a ConfusionMatrix.
+type Normalisation int
+
+const (
+	// NoNormalisation leaves the counts as they are
+	NoNormalisation Normalisation = iota
+	// NormaliseRows divides each entry by the number of rows which
+	// actually have its class, so the diagonal holds the recall of
+	// each class
+	NormaliseRows
+	// NormaliseColumns divides each entry by the number of rows
+	// predicted to have its class, so the diagonal holds the
+	// precision of each class
+	NormaliseColumns
+	// NormaliseTotal divides each entry by the total number of rows
+	NormaliseTotal
+)
+
+// NormaliseConfusionMatrix returns the proportions of a
+// ConfusionMatrix's counts given by the Normalisation.
+func NormaliseConfusionMatrix(c ConfusionMatrix, by Normalisation) map[string]map[string]float64 {
+	rowTotals := make(map[string]float64)
+	colTotals := make(map[string]float64)
+	total := 0.0
+	for actual := range c {
+		for predicted, n := range c[actual] {
+			rowTotals[actual] += float64(n)
+			colTotals[predicted] += float64(n)
+			total += float64(n)
+		}
+	}
+	ret := make(map[string]map[string]float64)
+	for actual := range c {
+		ret[actual] = make(map[string]float64)
+		for predicted, n := range c[actual] {
+			switch by {
+			case NormaliseRows:
+				ret[actual][predicted] = float64(n) / rowTotals[actual]
+			case NormaliseColumns:
+				ret[actual][predicted] = float64(n) / colTotals[predicted]
+			case NormaliseTotal:
+				ret[actual][predicted] = float64(n) / total
+			default:
+				ret[actual][predicted] = float64(n)
+			}
+		}
+	}
+	return ret
+}
+
+// PrintConfusionMatrix writes a ConfusionMatrix to w as a table, with
+// a row for each actual class and a column for each predicted class,
+// both in order. Unless the entries are normalised (see
+// NormaliseConfusionMatrix), they're written as counts; otherwise as
+// proportions, to two decimal places.
+func PrintConfusionMatrix(w io.Writer, c ConfusionMatrix, by Normalisation) error {
+	seen := make(map[string]bool)
+	for actual := range c {
+		seen[actual] = true
+		for predicted := range c[actual] {
+			seen[predicted] = true
+		}
+	}
+	classes := make([]string, 0, len(seen))
+	for k := range seen {
+		classes = append(classes, k)
+	}
+	sort.Strings(classes)
+	entries := NormaliseConfusionMatrix(c, by)
+
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprint(tw, "actual \\ predicted\t")
+	for _, k := range classes {
+		fmt.Fprintf(tw, "%s\t", k)
+	}
+	fmt.Fprintln(tw)
+	for _, actual := range classes {
+		fmt.Fprintf(tw, "%s\t", actual)
+		for _, predicted := range classes {
+			if by == NoNormalisation {
+				fmt.Fprintf(tw, "%.0f\t", entries[actual][predicted])
+			} else {
+				fmt.Fprintf(tw, "%.2f\t", entries[actual][predicted])
+			}
+		}
+		fmt.Fprintln(tw)
+	}
+	return tw.Flush()
+}
+
 // GetTruePositives returns the number of times an entry is
 // predicted successfully in a given ConfusionMatrix.
 func GetTruePositives(class string, c ConfusionMatrix) float64 {
@@ -124,6 +269,23 @@ func GetAccuracy(c ConfusionMatrix) float64 {
 	return float64(correct) / float64(total)
 }
 
+// GetWeightedAccuracy computes the proportion of the total weight
+// of the rows in a WeightedConfusionMatrix which were classified
+// correctly.
+func GetWeightedAccuracy(c WeightedConfusionMatrix) float64 {
+	correct := 0.0
+	total := 0.0
+	for i := range c {
+		for j := range c[i] {
+			if i == j {
+				correct += c[i][j]
+			}
+			total += c[i][j]
+		}
+	}
+	return correct / total
+}
+
 // GetMicroPrecision assesses Classifier performance across
 // all classes using the total true positives and false positives.
 func GetMicroPrecision(c ConfusionMatrix) float64 {
@@ -168,6 +330,104 @@ func GetMacroRecall(c ConfusionMatrix) float64 {
 	return recallVals / float64(len(c))
 }
 
+// GetMicroF1Score assesses Classifier performance across all
+// classes by combining the micro-averaged precision and recall.
+func GetMicroF1Score(c ConfusionMatrix) float64 {
+	precision := GetMicroPrecision(c)
+	recall := GetMicroRecall(c)
+	return 2 * (precision * recall) / (precision + recall)
+}
+
+// GetMacroF1Score assesses Classifier performance across all
+// classes by averaging the F1 scores achieved for each class.
+func GetMacroF1Score(c ConfusionMatrix) float64 {
+	f1Vals := 0.0
+	for k := range c {
+		f1Vals += GetF1Score(k, c)
+	}
+	return f1Vals / float64(len(c))
+}
+
+// GetSupport returns the number of times a given class actually
+// occurs in a ConfusionMatrix.
+func GetSupport(class string, c ConfusionMatrix) float64 {
+	ret := 0.0
+	for k := range c[class] {
+		ret += float64(c[class][k])
+	}
+	return ret
+}
+
+// getSupportWeighted averages a per-class measure, weighting each
+// class by its support, so that common classes count for more.
+func getSupportWeighted(c ConfusionMatrix, measure func(string, ConfusionMatrix) float64) float64 {
+	total, support := 0.0, 0.0
+	for k := range c {
+		s := GetSupport(k, c)
+		total += s * measure(k, c)
+		support += s
+	}
+	return total / support
+}
+
+// GetSupportWeightedPrecision assesses Classifier performance across
+// all classes by averaging the precision achieved for each class,
+// weighted by how often it occurs.
+func GetSupportWeightedPrecision(c ConfusionMatrix) float64 {
+	return getSupportWeighted(c, GetPrecision)
+}
+
+// GetSupportWeightedRecall assesses Classifier performance across
+// all classes by averaging the recall achieved for each class,
+// weighted by how often it occurs.
+func GetSupportWeightedRecall(c ConfusionMatrix) float64 {
+	return getSupportWeighted(c, GetRecall)
+}
+
+// GetSupportWeightedF1Score assesses Classifier performance across
+// all classes by averaging the F1 score achieved for each class,
+// weighted by how often it occurs.
+func GetSupportWeightedF1Score(c ConfusionMatrix) float64 {
+	return getSupportWeighted(c, GetF1Score)
+}
+
+// GetClassificationReport returns a table of the precision, recall,
+// F1 score and support of each class in a given ConfusionMatrix,
+// followed by the overall accuracy and the macro-averaged and
+// support-weighted averages of each measure, e.g.
+//
+//	              precision  recall  f1-score  support
+//	 Iris-setosa       1.00    1.00      1.00       50
+//	 ...
+//	    accuracy                         0.97      150
+//	   macro avg       0.97    0.97      0.97      150
+//	weighted avg       0.97    0.97      0.97      150
+func GetClassificationReport(c ConfusionMatrix) string {
+	classes := make([]string, 0, len(c))
+	for k := range c {
+		classes = append(classes, k)
+	}
+	sort.Strings(classes)
+	support := 0.0
+	for _, k := range classes {
+		support += GetSupport(k, c)
+	}
+
+	var buffer bytes.Buffer
+	w := tabwriter.NewWriter(&buffer, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "\tprecision\trecall\tf1-score\tsupport\t")
+	for _, k := range classes {
+		fmt.Fprintf(w, "%s\t%.2f\t%.2f\t%.2f\t%.0f\t\n", k, GetPrecision(k, c), GetRecall(k, c), GetF1Score(k, c), GetSupport(k, c))
+	}
+	fmt.Fprintln(w, "\t\t\t\t\t")
+	fmt.Fprintf(w, "accuracy\t\t\t%.2f\t%.0f\t\n", GetAccuracy(c), support)
+	fmt.Fprintf(w, "macro avg\t%.2f\t%.2f\t%.2f\t%.0f\t\n", GetMacroPrecision(c), GetMacroRecall(c), GetMacroF1Score(c), support)
+	fmt.Fprintf(w, "weighted avg\t%.2f\t%.2f\t%.2f\t%.0f\t\n",
+		GetSupportWeightedPrecision(c), GetSupportWeightedRecall(c), GetSupportWeightedF1Score(c), support)
+	w.Flush()
+	return buffer.String()
+}
+
 // GetSummary returns a table of precision, recall, true positive,
 // false positive, and true negatives for each class for a given
 // ConfusionMatrix
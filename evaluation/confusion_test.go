@@ -1,8 +1,12 @@
 package evaluation
 
 import (
+	"bytes"
 	"math"
+	"strings"
 	"testing"
+
+	"github.com/sjwhitworth/golearn/base"
 )
 
 func TestMetrics(testEnv *testing.T) {
@@ -102,3 +106,219 @@ func TestMetrics(testEnv *testing.T) {
 		testEnv.Error(accuracy)
 	}
 }
+
+func TestAveragedMetrics(testEnv *testing.T) {
+	confusionMat := ConfusionMatrix{
+		"a": {"a": 75, "b": 5},
+		"b": {"a": 10, "b": 10},
+	}
+	if s := GetSupport("a", confusionMat); s != 80 {
+		testEnv.Error(s)
+	}
+	if f1 := GetMicroF1Score(confusionMat); math.Abs(f1-0.85) >= 0.001 {
+		testEnv.Error(f1)
+	}
+	if f1 := GetMacroF1Score(confusionMat); math.Abs(f1-0.740) >= 0.001 {
+		testEnv.Error(f1)
+	}
+	if precision := GetSupportWeightedPrecision(confusionMat); math.Abs(precision-0.839) >= 0.001 {
+		testEnv.Error(precision)
+	}
+	// Weighting recall by support gives the accuracy
+	if recall := GetSupportWeightedRecall(confusionMat); math.Abs(recall-0.85) >= 0.001 {
+		testEnv.Error(recall)
+	}
+	if f1 := GetSupportWeightedF1Score(confusionMat); math.Abs(f1-0.842) >= 0.001 {
+		testEnv.Error(f1)
+	}
+
+	report := GetClassificationReport(confusionMat)
+	lines := strings.Split(report, "\n")
+	if len(lines) != 8 || !strings.Contains(lines[0], "precision") {
+		testEnv.Fatal(report)
+	}
+	if fields := strings.Fields(lines[1]); strings.Join(fields, " ") != "a 0.88 0.94 0.91 80" {
+		testEnv.Error(fields)
+	}
+	if fields := strings.Fields(lines[4]); strings.Join(fields, " ") != "accuracy 0.85 100" {
+		testEnv.Error(fields)
+	}
+	if fields := strings.Fields(lines[6]); strings.Join(fields, " ") != "weighted avg 0.84 0.85 0.84 100" {
+		testEnv.Error(fields)
+	}
+}
+
+func TestMergeConfusionMatrices(testEnv *testing.T) {
+	first := ConfusionMatrix{
+		"a": {"a": 40, "b": 2},
+		"b": {"a": 5, "b": 3},
+	}
+	second := ConfusionMatrix{
+		"a": {"a": 35, "b": 3},
+		"b": {"a": 5, "b": 7},
+		"c": {"c": 1},
+	}
+	total := MergeConfusionMatrices(first, second)
+	if total["a"]["a"] != 75 || total["a"]["b"] != 5 || total["b"]["a"] != 10 || total["b"]["b"] != 10 || total["c"]["c"] != 1 {
+		testEnv.Fatal(total)
+	}
+	if first["a"]["a"] != 40 {
+		testEnv.Error("The matrices merged shouldn't change")
+	}
+	if a := GetAccuracy(total); math.Abs(a-86.0/101) > 1e-9 {
+		testEnv.Error(a)
+	}
+	first.Add("c", "a", 2)
+	if first["c"]["a"] != 2 {
+		testEnv.Error(first)
+	}
+}
+
+func TestWeightedConfusionMatrix(testEnv *testing.T) {
+	attrs := []base.Attribute{base.NewCategoricalAttribute()}
+	ref := base.NewInstances(attrs, 4)
+	gen := base.NewInstances(attrs, 4)
+	for i, c := range []string{"a", "a", "b", "b"} {
+		ref.SetAttrStr(i, 0, c)
+	}
+	for i, c := range []string{"a", "b", "b", "b"} {
+		gen.SetAttrStr(i, 0, c)
+	}
+	unweighted := GetWeightedConfusionMatrix(ref, gen)
+	if a := GetWeightedAccuracy(unweighted); math.Abs(a-0.75) > 1e-9 {
+		testEnv.Error(a)
+	}
+
+	// Make the misclassified row count as much as the others together
+	ref.SetWeight(1, 3)
+	c := GetWeightedConfusionMatrix(ref, gen)
+	if c["a"]["b"] != 3 || c["b"]["b"] != 2 {
+		testEnv.Error(c)
+	}
+	if a := GetWeightedAccuracy(c); math.Abs(a-0.5) > 1e-9 {
+		testEnv.Error(a)
+	}
+}
+
+func TestAUC(testEnv *testing.T) {
+	attrs := []base.Attribute{base.NewCategoricalAttribute()}
+	ref := base.NewInstances(attrs, 4)
+	for i, c := range []string{"a", "b", "a", "b"} {
+		ref.SetAttrStr(i, 0, c)
+	}
+	probabilities := []map[string]float64{
+		{"a": 0.9, "b": 0.1},
+		{"a": 0.4, "b": 0.6},
+		{"a": 0.4, "b": 0.6},
+		{"a": 0.1, "b": 0.9},
+	}
+	// Of the four pairs, three are ordered correctly and one is tied
+	if auc := GetAUC(ref, probabilities, "a"); math.Abs(auc-0.875) > 1e-9 {
+		testEnv.Error(auc)
+	}
+	if auc := GetAUC(ref, probabilities, "b"); math.Abs(auc-0.875) > 1e-9 {
+		testEnv.Error(auc)
+	}
+	if auc := GetAUC(ref, probabilities, "c"); !math.IsNaN(auc) {
+		testEnv.Error(auc)
+	}
+}
+
+func TestROCCurve(testEnv *testing.T) {
+	attrs := []base.Attribute{base.NewCategoricalAttribute()}
+	ref := base.NewInstances(attrs, 4)
+	for i, c := range []string{"a", "b", "a", "b"} {
+		ref.SetAttrStr(i, 0, c)
+	}
+	probabilities := []map[string]float64{
+		{"a": 0.9, "b": 0.1},
+		{"a": 0.4, "b": 0.6},
+		{"a": 0.4, "b": 0.6},
+		{"a": 0.1, "b": 0.9},
+	}
+	curve := GetROCCurve(ref, probabilities, "a")
+	expected := ROCCurve{{math.Inf(1), 0, 0}, {0.9, 0, 0.5}, {0.4, 0.5, 1}, {0.1, 1, 1}}
+	if len(curve) != len(expected) {
+		testEnv.Fatal(curve)
+	}
+	for i := range curve {
+		if curve[i] != expected[i] {
+			testEnv.Error(i, curve[i])
+		}
+	}
+	if auc := curve.AUC(); math.Abs(auc-GetAUC(ref, probabilities, "a")) > 1e-9 {
+		testEnv.Error(auc)
+	}
+	if curve := GetROCCurve(ref, probabilities, "c"); curve != nil || !math.IsNaN(curve.AUC()) {
+		testEnv.Error(curve)
+	}
+}
+
+func TestNormaliseConfusionMatrix(testEnv *testing.T) {
+	confusionMat := ConfusionMatrix{
+		"a": {"a": 75, "b": 5},
+		"b": {"a": 10, "b": 10, "c": 5},
+	}
+	rows := NormaliseConfusionMatrix(confusionMat, NormaliseRows)
+	if math.Abs(rows["a"]["a"]-GetRecall("a", confusionMat)) > 1e-9 || rows["b"]["c"] != 0.2 {
+		testEnv.Error(rows)
+	}
+	cols := NormaliseConfusionMatrix(confusionMat, NormaliseColumns)
+	if math.Abs(cols["a"]["a"]-GetPrecision("a", confusionMat)) > 1e-9 || cols["b"]["c"] != 1 {
+		testEnv.Error(cols)
+	}
+	total := NormaliseConfusionMatrix(confusionMat, NormaliseTotal)
+	if math.Abs(total["a"]["a"]-75.0/105) > 1e-9 {
+		testEnv.Error(total)
+	}
+
+	var buf bytes.Buffer
+	if err := PrintConfusionMatrix(&buf, confusionMat, NoNormalisation); err != nil {
+		testEnv.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	// The predicted class c gets a row too
+	if len(lines) != 4 || strings.Join(strings.Fields(lines[2]), " ") != "b 10 10 5" {
+		testEnv.Fatal(buf.String())
+	}
+	if strings.Join(strings.Fields(lines[3]), " ") != "c 0 0 0" {
+		testEnv.Error(lines[3])
+	}
+	buf.Reset()
+	PrintConfusionMatrix(&buf, confusionMat, NormaliseRows)
+	if !strings.Contains(buf.String(), "0.94") {
+		testEnv.Error(buf.String())
+	}
+}
+
+func TestMultiClassAUC(testEnv *testing.T) {
+	attrs := []base.Attribute{base.NewCategoricalAttribute()}
+	ref := base.NewInstances(attrs, 4)
+	for i, c := range []string{"a", "b", "c", "c"} {
+		ref.SetAttrStr(i, 0, c)
+	}
+	probabilities := []map[string]float64{
+		{"a": 0.8, "b": 0.1, "c": 0.1},
+		{"a": 0.1, "b": 0.2, "c": 0.7},
+		{"a": 0.1, "b": 0.3, "c": 0.6},
+		{"a": 0.2, "b": 0.1, "c": 0.7},
+	}
+	curves := GetROCCurves(ref, probabilities)
+	if len(curves) != 3 {
+		testEnv.Fatal(curves)
+	}
+	for class, curve := range curves {
+		if math.Abs(curve.AUC()-GetAUC(ref, probabilities, class)) > 1e-9 {
+			testEnv.Error(class, curve)
+		}
+	}
+	// a is ranked perfectly, b above two of the three other rows
+	// and c above 2.5 of four pairs
+	a, b, c := 1.0, 2.0/3, 0.625
+	if auc := GetMacroAUC(ref, probabilities); math.Abs(auc-(a+b+c)/3) > 1e-9 {
+		testEnv.Error(auc)
+	}
+	if auc := GetSupportWeightedAUC(ref, probabilities); math.Abs(auc-(a+b+2*c)/4) > 1e-9 {
+		testEnv.Error(auc)
+	}
+}
@@ -0,0 +1,110 @@
+package evaluation
+
+import (
+	"math"
+	"sort"
+
+	"github.com/sjwhitworth/golearn/base"
+)
+
+// CoveragePoint is one point on a coverage curve: a classifier which
+// abstains unless its most likely class has a probability of at
+// least Threshold predicts the Coverage proportion of rows, and gets
+// Accuracy of those right.
+type CoveragePoint struct {
+	Threshold float64
+	Coverage  float64
+	Accuracy  float64
+}
+
+// GetCoverageCurve returns the trade-off between coverage and
+// accuracy as the confidence threshold of an abstaining classifier
+// (e.g. meta.AbstainingClassifier) is raised, given the class
+// probabilities (from a base.ProbabilisticClassifier or
+// CrossValPredict) for the rows of ref. There's one point per
+// distinct confidence, from the highest (least coverage) to the
+// lowest (everything's predicted). Rows are weighted by ref's
+// weights.
+func GetCoverageCurve(ref *base.Instances, probabilities []map[string]float64) []CoveragePoint {
+	if ref.Rows != len(probabilities) {
+		panic("Row counts should match")
+	}
+	rows := make([]int, ref.Rows)
+	scores := make([]float64, ref.Rows)
+	correct := make([]bool, ref.Rows)
+	for i := range rows {
+		rows[i] = i
+		class, prob := base.MostLikelyClass(probabilities[i])
+		scores[i] = prob
+		correct[i] = class != "" && class == ref.GetClass(i)
+	}
+	sort.Sort(sort.Reverse(&byScore{rows, scores}))
+
+	total := ref.TotalWeight()
+	ret := make([]CoveragePoint, 0)
+	covered, right := 0.0, 0.0
+	for start := 0; start < len(rows); {
+		end := start
+		for end < len(rows) && scores[rows[end]] == scores[rows[start]] {
+			w := ref.GetWeight(rows[end])
+			covered += w
+			if correct[rows[end]] {
+				right += w
+			}
+			end++
+		}
+		ret = append(ret, CoveragePoint{scores[rows[start]], covered / total, right / covered})
+		start = end
+	}
+	return ret
+}
+
+// ThresholdForAccuracy returns the lowest Threshold on a coverage
+// curve (and so the most coverage) which gets at least the given
+// accuracy, and false if none does.
+func ThresholdForAccuracy(curve []CoveragePoint, accuracy float64) (float64, bool) {
+	threshold, ok := 0.0, false
+	for _, p := range curve {
+		if p.Accuracy >= accuracy {
+			threshold, ok = p.Threshold, true
+		}
+	}
+	return threshold, ok
+}
+
+// GetCoverage returns the (weighted) proportion of rows an abstaining
+// classifier made a prediction for, i.e. where the class isn't
+// missing.
+func GetCoverage(predictions *base.Instances) float64 {
+	covered := 0.0
+	for i := 0; i < predictions.Rows; i++ {
+		if !predictions.IsMissing(i, predictions.ClassIndex) {
+			covered += predictions.GetWeight(i)
+		}
+	}
+	return covered / predictions.TotalWeight()
+}
+
+// GetSelectiveAccuracy returns the (weighted) accuracy of an
+// abstaining classifier on the rows of ref it made a prediction for,
+// or NaN if it abstained on all of them.
+func GetSelectiveAccuracy(ref *base.Instances, predictions *base.Instances) float64 {
+	if ref.Rows != predictions.Rows {
+		panic("Row counts should match")
+	}
+	covered, right := 0.0, 0.0
+	for i := 0; i < ref.Rows; i++ {
+		if predictions.IsMissing(i, predictions.ClassIndex) {
+			continue
+		}
+		w := ref.GetWeight(i)
+		covered += w
+		if predictions.GetClass(i) == ref.GetClass(i) {
+			right += w
+		}
+	}
+	if covered == 0 {
+		return math.NaN()
+	}
+	return right / covered
+}
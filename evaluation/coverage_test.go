@@ -0,0 +1,55 @@
+package evaluation
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sjwhitworth/golearn/base"
+)
+
+func TestCoverageCurve(testEnv *testing.T) {
+	class := base.NewCategoricalAttribute()
+	class.SetName("class")
+	ref := base.NewInstances([]base.Attribute{class}, 4)
+	probabilities := []map[string]float64{
+		{"a": 0.9, "b": 0.1},
+		{"a": 0.2, "b": 0.8},
+		{"a": 0.6, "b": 0.4},
+		{"a": 0.4, "b": 0.6},
+	}
+	for i := 0; i < ref.Rows; i++ {
+		ref.SetAttrStr(i, 0, "a")
+	}
+	curve := GetCoverageCurve(ref, probabilities)
+	expected := []CoveragePoint{
+		{0.9, 0.25, 1},
+		{0.8, 0.5, 0.5},
+		{0.6, 1, 0.5},
+	}
+	if len(curve) != len(expected) {
+		testEnv.Fatal(curve)
+	}
+	for i, p := range expected {
+		if math.Abs(curve[i].Threshold-p.Threshold) > 1e-9 || curve[i].Coverage != p.Coverage || curve[i].Accuracy != p.Accuracy {
+			testEnv.Errorf("Point %d should be %v, is %v", i, p, curve[i])
+		}
+	}
+	if t, ok := ThresholdForAccuracy(curve, 0.75); !ok || t != 0.9 {
+		testEnv.Errorf("Threshold should be 0.9, is %.2f", t)
+	}
+	if _, ok := ThresholdForAccuracy(curve, 1.1); ok {
+		testEnv.Error("Impossible accuracy reached")
+	}
+
+	predictions := ref.GeneratePredictionVector()
+	predictions.SetAttrStr(0, 0, "a")
+	predictions.SetAttrStr(1, 0, "b")
+	predictions.SetMissing(2, 0)
+	predictions.SetMissing(3, 0)
+	if c := GetCoverage(predictions); c != 0.5 {
+		testEnv.Errorf("Coverage should be 0.5, is %.2f", c)
+	}
+	if a := GetSelectiveAccuracy(ref, predictions); a != 0.5 {
+		testEnv.Errorf("Selective accuracy should be 0.5, is %.2f", a)
+	}
+}
@@ -0,0 +1,432 @@
+package evaluation
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"text/tabwriter"
+
+	"github.com/sjwhitworth/golearn/base"
+)
+
+// selectRows copies the given rows of from, and their weights and
+// roles, into a new set of Instances.
+func selectRows(from *base.Instances, rows []int) *base.Instances {
+	attrs := make([]base.Attribute, from.Cols)
+	for j := range attrs {
+		attrs[j] = from.GetAttr(j)
+	}
+	ret := base.NewInstances(attrs, len(rows))
+	ret.ClassIndex = from.ClassIndex
+	for i, r := range rows {
+		for j := 0; j < from.Cols; j++ {
+			ret.Set(i, j, from.Get(r, j))
+		}
+		ret.SetWeight(i, from.GetWeight(r))
+	}
+	for j := 0; j < from.Cols; j++ {
+		ret.SetRole(j, from.GetRole(j))
+	}
+	return ret
+}
+
+// generateFolds randomly assigns each of rows row indices to
+// one of folds roughly equally-sized partitions.
+func generateFolds(rows int, folds int, rng *rand.Rand) [][]int {
+	ret := make([][]int, folds)
+	for i, r := range rng.Perm(rows) {
+		ret[i%folds] = append(ret[i%folds], r)
+	}
+	return ret
+}
+
+// checkFolds returns an error if rows can't be divided into folds
+// partitions
+func checkFolds(rows, folds int) error {
+	if folds < 2 {
+		return fmt.Errorf("evaluation: need at least 2 folds, got %d", folds)
+	}
+	if folds > rows {
+		return fmt.Errorf("evaluation: can't divide %d rows into %d folds", rows, folds)
+	}
+	return nil
+}
+
+// checkPartitions returns an error unless there are at least two
+// partitions, none of them empty, which hold each of rows rows once
+func checkPartitions(rows int, partitions [][]int) error {
+	if err := checkFolds(rows, len(partitions)); err != nil {
+		return err
+	}
+	seen := make([]bool, rows)
+	for i, p := range partitions {
+		if len(p) == 0 {
+			return fmt.Errorf("evaluation: fold %d is empty", i)
+		}
+		for _, r := range p {
+			if r < 0 || r >= rows {
+				return fmt.Errorf("evaluation: fold %d has row %d, out of %d", i, r, rows)
+			}
+			if seen[r] {
+				return fmt.Errorf("evaluation: row %d is in more than one fold", r)
+			}
+			seen[r] = true
+		}
+	}
+	for r, ok := range seen {
+		if !ok {
+			return fmt.Errorf("evaluation: row %d isn't in any fold", r)
+		}
+	}
+	return nil
+}
+
+// foldSplits returns a Split for each of the partitions, testing on
+// it and training on the others
+func foldSplits(partitions [][]int) []Split {
+	ret := make([]Split, len(partitions))
+	for i := range partitions {
+		ret[i].Test = partitions[i]
+		ret[i].Train = make([]int, 0)
+		for j := range partitions {
+			if i != j {
+				ret[i].Train = append(ret[i].Train, partitions[j]...)
+			}
+		}
+	}
+	return ret
+}
+
+// eachSplit calls fn concurrently for each of the splits of data's
+// rows, with views of the rows to train on and to test on. At most
+// workers calls run at once, or as many as base.GetConfig allows if
+// it's zero.
+func eachSplit(data *base.Instances, splits []Split, workers int, fn func(i int, trainData, testData *base.Instances)) {
+	if workers <= 0 {
+		workers = base.GetConfig().WorkersFor(base.InstancesBytes(data.Rows, data.Cols))
+	}
+	base.Parallel(len(splits), workers, func(i int) {
+		fn(i, data.ViewRows(splits[i].Train), data.ViewRows(splits[i].Test))
+	})
+}
+
+// CrossValPredict generates an out-of-fold prediction for every row
+// of data. The rows are randomly divided into folds partitions, and
+// each partition is predicted by cls after training it on the others.
+//
+// If cls is a base.ProbabilisticClassifier, the out-of-fold class
+// probabilities are also returned (otherwise that value is nil).
+//
+// cls itself isn't trained: each fold uses a fresh copy of it
+// created with base.CloneClassifier. The folds are trained
+// concurrently, as allowed by base.GetConfig.
+func CrossValPredict(cls base.Classifier, data *base.Instances, folds int) (*base.Instances, []map[string]float64, error) {
+	return CrossValPredictWithSeed(cls, data, folds, rand.Int63())
+}
+
+// CrossValPredictWithSeed is like CrossValPredict, but the folds are
+// determined by seed, so that they're the same every time.
+func CrossValPredictWithSeed(cls base.Classifier, data *base.Instances, folds int, seed int64) (*base.Instances, []map[string]float64, error) {
+	if err := checkFolds(data.Rows, folds); err != nil {
+		return nil, nil, err
+	}
+	return CrossValPredictWithFolds(cls, data, generateFolds(data.Rows, folds, rand.New(rand.NewSource(seed))))
+}
+
+// CrossValPredictWithFolds is like CrossValPredict, but with the
+// given partitions of the rows (e.g. from StratifiedFolds), which
+// must hold each row exactly once.
+func CrossValPredictWithFolds(cls base.Classifier, data *base.Instances, partitions [][]int) (*base.Instances, []map[string]float64, error) {
+	if err := checkPartitions(data.Rows, partitions); err != nil {
+		return nil, nil, err
+	}
+
+	_, isProb := cls.(base.ProbabilisticClassifier)
+	predictions := data.GeneratePredictionVector()
+	var probabilities []map[string]float64
+	if isProb {
+		probabilities = make([]map[string]float64, data.Rows)
+	}
+
+	// Train and predict the folds concurrently, then gather the
+	// results
+	foldPredictions := make([]*base.Instances, len(partitions))
+	foldProbabilities := make([][]map[string]float64, len(partitions))
+	eachSplit(data, foldSplits(partitions), 0, func(i int, trainData, testData *base.Instances) {
+		c := base.CloneClassifier(cls)
+		c.Fit(trainData)
+		foldPredictions[i] = c.Predict(testData)
+		if isProb {
+			foldProbabilities[i] = c.(base.ProbabilisticClassifier).PredictProba(testData)
+		}
+	})
+	for i := range partitions {
+		for j, r := range partitions[i] {
+			predictions.SetAttrStr(r, 0, foldPredictions[i].GetClass(j))
+			if isProb {
+				probabilities[r] = foldProbabilities[i][j]
+			}
+		}
+	}
+	return predictions, probabilities, nil
+}
+
+// LeaveOneOutPredict generates an out-of-fold prediction for every
+// row of data like CrossValPredict, but with as many folds as there
+// are rows, so each row is predicted by a copy of cls trained on all
+// of the others. That's only practical for small data, unless cls is a
+// base.LeaveOneOutClassifier (like knn.KNNClassifier), which is
+// trained just once.
+func LeaveOneOutPredict(cls base.Classifier, data *base.Instances) (*base.Instances, error) {
+	if err := checkFolds(data.Rows, data.Rows); err != nil {
+		return nil, err
+	}
+	if _, ok := cls.(base.LeaveOneOutClassifier); ok {
+		c := base.CloneClassifier(cls)
+		c.Fit(data)
+		return c.(base.LeaveOneOutClassifier).PredictLeaveOneOut(), nil
+	}
+	partitions := make([][]int, data.Rows)
+	for i := range partitions {
+		partitions[i] = []int{i}
+	}
+	predictions, _, err := CrossValPredictWithFolds(cls, data, partitions)
+	return predictions, err
+}
+
+// FoldMetrics are the scores of a Classifier on one set of test
+// rows. The macro-averaged metrics are NaN if a class was never
+// predicted (or, for recall, never occurred) in the fold.
+type FoldMetrics struct {
+	Accuracy       float64
+	MacroPrecision float64
+	MacroRecall    float64
+	MacroF1        float64
+}
+
+// getFoldMetrics computes the FoldMetrics for a ConfusionMatrix
+func getFoldMetrics(c ConfusionMatrix) FoldMetrics {
+	return FoldMetrics{
+		Accuracy:       GetAccuracy(c),
+		MacroPrecision: GetMacroPrecision(c),
+		MacroRecall:    GetMacroRecall(c),
+		MacroF1:        GetMacroF1Score(c),
+	}
+}
+
+// FoldResult is the outcome of one fold of CrossValidate.
+type FoldResult struct {
+	FoldMetrics
+	// Confusion is the ConfusionMatrix of the fold's test rows
+	Confusion ConfusionMatrix
+	// TrainRows and TestRows are the number of rows trained and
+	// tested on
+	TrainRows int
+	TestRows  int
+}
+
+// CrossValidationResult holds the results of each fold of
+// CrossValidate, and the mean and (sample) standard deviation of
+// each metric across them.
+type CrossValidationResult struct {
+	Folds []FoldResult
+	Mean  FoldMetrics
+	Std   FoldMetrics
+	// Predictions holds the out-of-fold prediction for every row
+	Predictions *base.Instances
+}
+
+// String returns a table of the metrics of each fold, followed by
+// their means and standard deviations.
+func (r *CrossValidationResult) String() string {
+	var buffer bytes.Buffer
+	w := tabwriter.NewWriter(&buffer, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "Fold\tAccuracy\tPrecision\tRecall\tF1")
+	for i, f := range r.Folds {
+		fmt.Fprintf(w, "%d\t%.4f\t%.4f\t%.4f\t%.4f\n", i, f.Accuracy, f.MacroPrecision, f.MacroRecall, f.MacroF1)
+	}
+	fmt.Fprintf(w, "Mean\t%.4f\t%.4f\t%.4f\t%.4f\n", r.Mean.Accuracy, r.Mean.MacroPrecision, r.Mean.MacroRecall, r.Mean.MacroF1)
+	fmt.Fprintf(w, "Std\t%.4f\t%.4f\t%.4f\t%.4f\n", r.Std.Accuracy, r.Std.MacroPrecision, r.Std.MacroRecall, r.Std.MacroF1)
+	w.Flush()
+	return buffer.String()
+}
+
+// Confusion returns the total ConfusionMatrix of the Folds. After
+// CrossValidate, it's the same as that of the Predictions.
+func (r *CrossValidationResult) Confusion() ConfusionMatrix {
+	ret := make(ConfusionMatrix)
+	for _, f := range r.Folds {
+		ret.Merge(f.Confusion)
+	}
+	return ret
+}
+
+// summarise fills in the Mean and Std from the Folds
+func (r *CrossValidationResult) summarise() {
+	accuracy := make([]float64, len(r.Folds))
+	precision := make([]float64, len(r.Folds))
+	recall := make([]float64, len(r.Folds))
+	f1 := make([]float64, len(r.Folds))
+	for i, f := range r.Folds {
+		accuracy[i], precision[i], recall[i], f1[i] = f.Accuracy, f.MacroPrecision, f.MacroRecall, f.MacroF1
+	}
+	r.Mean.Accuracy, r.Std.Accuracy = meanStd(accuracy)
+	r.Mean.MacroPrecision, r.Std.MacroPrecision = meanStd(precision)
+	r.Mean.MacroRecall, r.Std.MacroRecall = meanStd(recall)
+	r.Mean.MacroF1, r.Std.MacroF1 = meanStd(f1)
+}
+
+// meanStd returns the mean and sample standard deviation of vals
+func meanStd(vals []float64) (float64, float64) {
+	mean := 0.0
+	for _, v := range vals {
+		mean += v
+	}
+	mean /= float64(len(vals))
+	variance := 0.0
+	for _, v := range vals {
+		variance += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(variance / float64(len(vals)-1))
+}
+
+// CrossValidate estimates how well the Classifiers created by
+// factory generalise. The rows of data are randomly divided into
+// folds partitions, and each partition is predicted by a new
+// Classifier trained on the others. The folds are trained
+// concurrently, as allowed by base.GetConfig.
+func CrossValidate(factory base.ClassifierFactory, data *base.Instances, folds int) (*CrossValidationResult, error) {
+	return CrossValidateWithSeed(factory, data, folds, rand.Int63())
+}
+
+// CrossValidateWithSeed is like CrossValidate, but the folds are
+// determined by seed, so that they're the same every time.
+func CrossValidateWithSeed(factory base.ClassifierFactory, data *base.Instances, folds int, seed int64) (*CrossValidationResult, error) {
+	if err := checkFolds(data.Rows, folds); err != nil {
+		return nil, err
+	}
+	return CrossValidateWithFolds(factory, data, generateFolds(data.Rows, folds, rand.New(rand.NewSource(seed))))
+}
+
+// CrossValidateWithFolds is like CrossValidate, but with the given
+// partitions of the rows (e.g. from StratifiedFolds), which must
+// hold each row exactly once.
+func CrossValidateWithFolds(factory base.ClassifierFactory, data *base.Instances, partitions [][]int) (*CrossValidationResult, error) {
+	if err := checkPartitions(data.Rows, partitions); err != nil {
+		return nil, err
+	}
+	return crossValidate(factory, data, foldSplits(partitions), 0, nil), nil
+}
+
+// CrossValidationOptions configure CrossValidateWithOptions. Zero
+// fields take the default given.
+type CrossValidationOptions struct {
+	// Folds is the number of partitions of the rows (5)
+	Folds int
+	// Stratified makes each fold have the same proportion of each
+	// class (see StratifiedFolds)
+	Stratified bool
+	// Workers is the most folds trained at once (as many as
+	// base.GetConfig allows)
+	Workers int
+	// Seed determines the folds, and the seed each fold's
+	// Classifier is given (a random one)
+	Seed int64
+}
+
+// CrossValidateWithOptions is like CrossValidate, but configured by
+// opts. Classifiers with a Seed parameter (see base.GetParams), such
+// as ensemble.RandomForest, are given a different seed in each fold,
+// drawn from opts.Seed: the folds don't share a random number
+// generator, which would make the results depend on how they're
+// scheduled, and the same opts.Seed gives the same results whatever
+// the number of Workers.
+func CrossValidateWithOptions(factory base.ClassifierFactory, data *base.Instances, opts CrossValidationOptions) (*CrossValidationResult, error) {
+	folds, seed := opts.Folds, opts.Seed
+	if folds == 0 {
+		folds = 5
+	}
+	if seed == 0 {
+		seed = rand.Int63()
+	}
+	if err := checkFolds(data.Rows, folds); err != nil {
+		return nil, err
+	}
+	rng := rand.New(rand.NewSource(seed))
+	var partitions [][]int
+	if opts.Stratified {
+		partitions = generateStratifiedFolds(data, folds, rng)
+	} else {
+		partitions = generateFolds(data.Rows, folds, rng)
+	}
+	seeds := make([]int64, folds)
+	for i := range seeds {
+		seeds[i] = rng.Int63()
+	}
+	return crossValidate(factory, data, foldSplits(partitions), opts.Workers, seeds), nil
+}
+
+// CrossValidateWithSplits is like CrossValidate, but each fold trains
+// and tests on the rows given by one of splits (e.g. from
+// TimeSeriesSplits), which needn't partition the rows. Since some rows
+// may be tested more than once, or not at all, the result has no
+// Predictions.
+func CrossValidateWithSplits(factory base.ClassifierFactory, data *base.Instances, splits []Split) (*CrossValidationResult, error) {
+	if len(splits) == 0 {
+		return nil, fmt.Errorf("evaluation: no splits")
+	}
+	for i, split := range splits {
+		if len(split.Train) == 0 || len(split.Test) == 0 {
+			return nil, fmt.Errorf("evaluation: split %d has %d training and %d test rows", i, len(split.Train), len(split.Test))
+		}
+		for _, rows := range [][]int{split.Train, split.Test} {
+			for _, r := range rows {
+				if r < 0 || r >= data.Rows {
+					return nil, fmt.Errorf("evaluation: split %d has row %d, out of %d", i, r, data.Rows)
+				}
+			}
+		}
+	}
+	ret := crossValidate(factory, data, splits, 0, nil)
+	ret.Predictions = nil
+	return ret, nil
+}
+
+// seedClassifier sets the Seed parameter of c, if it has one
+func seedClassifier(c base.Classifier, seed int64) {
+	v := reflect.ValueOf(c)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return
+	}
+	if _, ok := base.GetParams(c)["Seed"]; ok {
+		base.SetParams(c, map[string]interface{}{"Seed": seed})
+	}
+}
+
+// crossValidate implements CrossValidateWithFolds, training at most
+// workers folds at once, and seeding the Classifier in each fold with
+// the corresponding one of seeds, if there are any.
+func crossValidate(factory base.ClassifierFactory, data *base.Instances, splits []Split, workers int, seeds []int64) *CrossValidationResult {
+	ret := &CrossValidationResult{Folds: make([]FoldResult, len(splits))}
+	foldPredictions := make([]*base.Instances, len(splits))
+	eachSplit(data, splits, workers, func(i int, trainData, testData *base.Instances) {
+		c := factory()
+		if seeds != nil {
+			seedClassifier(c, seeds[i])
+		}
+		c.Fit(trainData)
+		foldPredictions[i] = c.Predict(testData)
+		confusion := ConfusionMatrix(GetConfusionMatrix(testData, foldPredictions[i]))
+		ret.Folds[i] = FoldResult{getFoldMetrics(confusion), confusion, trainData.Rows, testData.Rows}
+	})
+	ret.Predictions = data.GeneratePredictionVector()
+	for i := range splits {
+		for j, r := range splits[i].Test {
+			ret.Predictions.SetAttrStr(r, 0, foldPredictions[i].GetClass(j))
+		}
+	}
+	ret.summarise()
+	return ret
+}
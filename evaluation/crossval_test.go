@@ -0,0 +1,216 @@
+package evaluation
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/sjwhitworth/golearn/base"
+	"github.com/sjwhitworth/golearn/knn"
+)
+
+func TestCrossValPredict(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Error(err)
+		return
+	}
+	cls := knn.NewKnnClassifier("euclidean", 3)
+	predictions, probabilities, err := CrossValPredict(cls, inst, 5)
+	if err != nil {
+		testEnv.Error(err)
+		return
+	}
+	if predictions.Rows != inst.Rows {
+		testEnv.Errorf("Expected %d predictions, got %d", inst.Rows, predictions.Rows)
+	}
+	if len(probabilities) != inst.Rows {
+		testEnv.Errorf("Expected %d probabilities, got %d", inst.Rows, len(probabilities))
+	}
+	for i, p := range probabilities {
+		total := 0.0
+		for c := range p {
+			total += p[c]
+		}
+		if math.Abs(total-1.0) > 0.0001 {
+			testEnv.Error(i, p)
+		}
+	}
+	accuracy := GetAccuracy(GetConfusionMatrix(inst, predictions))
+	if accuracy < 0.8 {
+		testEnv.Error(accuracy)
+	}
+
+	_, _, err = CrossValPredict(cls, inst, 1)
+	if err == nil {
+		testEnv.Error("Should refuse a single fold")
+	}
+}
+
+func TestCrossValPredictWithSeed(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	cls := knn.NewKnnClassifier("euclidean", 3)
+	_, first, err := CrossValPredictWithSeed(cls, inst, 5, 3)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	_, second, err := CrossValPredictWithSeed(cls, inst, 5, 3)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	for i := range first {
+		for c, p := range first[i] {
+			if second[i][c] != p {
+				testEnv.Fatalf("Row %d differs: %v, %v", i, first[i], second[i])
+			}
+		}
+	}
+}
+
+func TestCrossValidate(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	factory := func() base.Classifier {
+		return knn.NewKnnClassifier("euclidean", 3)
+	}
+	result, err := CrossValidateWithSeed(factory, inst, 5, 1)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if len(result.Folds) != 5 {
+		testEnv.Fatal(result.Folds)
+	}
+	total := 0.0
+	for _, f := range result.Folds {
+		if f.TrainRows+f.TestRows != inst.Rows || f.TestRows != 30 {
+			testEnv.Error(f.TrainRows, f.TestRows)
+		}
+		total += f.Accuracy
+	}
+	if math.Abs(total/5-result.Mean.Accuracy) > 1e-9 || result.Mean.Accuracy < 0.9 {
+		testEnv.Error(result.Mean.Accuracy)
+	}
+	if result.Std.Accuracy < 0 || result.Std.Accuracy > 0.1 {
+		testEnv.Error(result.Std.Accuracy)
+	}
+	if !strings.Contains(result.String(), "Mean") {
+		testEnv.Error(result.String())
+	}
+	if !reflect.DeepEqual(result.Confusion(), ConfusionMatrix(GetConfusionMatrix(inst, result.Predictions))) {
+		testEnv.Error("The folds' matrices should add up to that of the predictions")
+	}
+
+	if _, err := CrossValidate(factory, inst, 1); err == nil {
+		testEnv.Error("Should refuse a single fold")
+	}
+}
+
+// randomClassifier guesses classes at random, from its Seed
+type randomClassifier struct {
+	Seed    int64
+	classes []string
+}
+
+func (c *randomClassifier) Fit(from *base.Instances) {
+	c.classes = c.classes[:0]
+	for class := range from.CountClassValues() {
+		c.classes = append(c.classes, class)
+	}
+	sort.Strings(c.classes)
+}
+
+func (c *randomClassifier) Predict(what *base.Instances) *base.Instances {
+	rng := rand.New(rand.NewSource(c.Seed))
+	ret := what.GeneratePredictionVector()
+	for i := 0; i < what.Rows; i++ {
+		ret.SetAttrStr(i, 0, c.classes[rng.Intn(len(c.classes))])
+	}
+	return ret
+}
+
+func (c *randomClassifier) String() string {
+	return "randomClassifier"
+}
+
+func TestCrossValidateWithOptions(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	factory := func() base.Classifier {
+		return &randomClassifier{}
+	}
+	first, err := CrossValidateWithOptions(factory, inst, CrossValidationOptions{Folds: 6, Workers: 1, Seed: 3})
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	second, err := CrossValidateWithOptions(factory, inst, CrossValidationOptions{Folds: 6, Workers: 6, Seed: 3})
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	for i := range first.Folds {
+		if first.Folds[i].Accuracy != second.Folds[i].Accuracy {
+			testEnv.Fatalf("Fold %d differs with more workers: %f, %f", i, first.Folds[i].Accuracy, second.Folds[i].Accuracy)
+		}
+	}
+	// Each fold should have been given its own seed
+	for i := 0; i < inst.Rows; i++ {
+		if first.Predictions.GetClass(i) != second.Predictions.GetClass(i) {
+			testEnv.Fatalf("Row %d differs with more workers", i)
+		}
+	}
+	if first.Std.Accuracy == 0 {
+		testEnv.Error("Every fold guessed the same way")
+	}
+
+	result, err := CrossValidateWithOptions(factory, inst, CrossValidationOptions{Stratified: true})
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if len(result.Folds) != 5 || result.Folds[0].TestRows != 30 {
+		testEnv.Error(result)
+	}
+}
+
+func TestLeaveOneOutPredict(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	// KNN takes the fast path, and the slow one when wrapped
+	cls := knn.NewKnnClassifier("euclidean", 1)
+	fast, err := LeaveOneOutPredict(cls, inst)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	slow, err := LeaveOneOutPredict(&wrappedClassifier{cls}, inst)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	fastAccuracy := GetAccuracy(GetConfusionMatrix(inst, fast))
+	slowAccuracy := GetAccuracy(GetConfusionMatrix(inst, slow))
+	// 1-NN would be perfect if rows weren't left out
+	if fastAccuracy < 0.9 || fastAccuracy == 1 || math.Abs(fastAccuracy-slowAccuracy) > 0.02 {
+		testEnv.Error(fastAccuracy, slowAccuracy)
+	}
+	if cls.TrainingData != nil {
+		testEnv.Error("cls itself shouldn't be trained")
+	}
+}
+
+// wrappedClassifier hides whether a Classifier has a fast path
+type wrappedClassifier struct {
+	base.Classifier
+}
+
+func (c *wrappedClassifier) Clone() base.Classifier {
+	return &wrappedClassifier{base.CloneClassifier(c.Classifier)}
+}
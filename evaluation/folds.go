@@ -0,0 +1,140 @@
+package evaluation
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+
+	"github.com/sjwhitworth/golearn/base"
+)
+
+// RandomFolds randomly divides rows row indices into folds
+// partitions whose sizes differ by at most one, for
+// CrossValidateWithFolds and CrossValPredictWithFolds. The partitions
+// are determined by seed, as in CrossValPredictWithSeed.
+func RandomFolds(rows, folds int, seed int64) ([][]int, error) {
+	if err := checkFolds(rows, folds); err != nil {
+		return nil, err
+	}
+	return generateFolds(rows, folds, rand.New(rand.NewSource(seed))), nil
+}
+
+// StratifiedFolds randomly divides the rows of data into folds
+// partitions, like RandomFolds, but so that each has (to within one
+// row) the same proportion of each class as data. Random folds of
+// small or imbalanced data can leave a class out of a fold, or out
+// of the rows trained on, altogether; stratified ones only do so if
+// the class has fewer rows than there are folds. The partitions are
+// determined by seed.
+func StratifiedFolds(data *base.Instances, folds int, seed int64) ([][]int, error) {
+	if err := checkFolds(data.Rows, folds); err != nil {
+		return nil, err
+	}
+	return generateStratifiedFolds(data, folds, rand.New(rand.NewSource(seed))), nil
+}
+
+// generateStratifiedFolds implements StratifiedFolds: the rows of
+// each class are shuffled, then dealt out in turn, continuing from
+// one class to the next, so that the folds' sizes differ by at most
+// one too.
+func generateStratifiedFolds(data *base.Instances, folds int, rng *rand.Rand) [][]int {
+	byClass := make(map[string][]int)
+	for i := 0; i < data.Rows; i++ {
+		class := data.GetClass(i)
+		byClass[class] = append(byClass[class], i)
+	}
+	classes := make([]string, 0, len(byClass))
+	for class := range byClass {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+
+	ret := make([][]int, folds)
+	next := 0
+	for _, class := range classes {
+		rows := byClass[class]
+		for _, k := range rng.Perm(len(rows)) {
+			ret[next%folds] = append(ret[next%folds], rows[k])
+			next++
+		}
+	}
+	return ret
+}
+
+// Split is a set of rows to train on, and a set to test on.
+type Split struct {
+	Train []int
+	Test  []int
+}
+
+// TimeSeriesOptions describe the Splits TimeSeriesSplits makes. Zero
+// fields take the default given.
+type TimeSeriesOptions struct {
+	// Splits is the number of test windows (5)
+	Splits int
+	// TestSize is the number of rows in each test window (as many
+	// as divide the rows into Splits+1 windows)
+	TestSize int
+	// MaxTrainSize limits training to that many of the latest rows
+	// before each test window, rolling the training window forward
+	// with the test window. Zero trains on all of the earlier rows,
+	// expanding the training window instead.
+	MaxTrainSize int
+	// Gap is the number of rows left out between each training
+	// window and test window, e.g. when the class is only known
+	// some time later
+	Gap int
+}
+
+// TimeSeriesSplits orders the rows of data by the values of attr
+// (e.g. a base.TimeAttribute), and returns Splits which test on
+// successive windows of them, the last ending with the latest row,
+// and train only on rows before each: unlike random folds, no
+// Classifier gets to learn from the future of the rows it's tested
+// on. Rows at the same time keep their order.
+//
+// It returns an error if attr isn't one of data's Attributes, or has
+// missing values, or if there aren't enough rows for the windows.
+func TimeSeriesSplits(data *base.Instances, attr base.Attribute, opts TimeSeriesOptions) ([]Split, error) {
+	col := data.GetAttrIndex(attr)
+	if col == -1 {
+		return nil, fmt.Errorf("evaluation: no Attribute %s", attr.GetName())
+	}
+	splits, testSize := opts.Splits, opts.TestSize
+	if splits == 0 {
+		splits = 5
+	}
+	if testSize == 0 {
+		testSize = data.Rows / (splits + 1)
+	}
+	if splits < 1 || testSize < 1 || opts.Gap < 0 || opts.MaxTrainSize < 0 {
+		return nil, fmt.Errorf("evaluation: invalid TimeSeriesOptions %+v", opts)
+	}
+	firstTest := data.Rows - splits*testSize
+	if firstTest-opts.Gap < 1 {
+		return nil, fmt.Errorf("evaluation: can't make %d test windows of %d rows, with a gap of %d, from %d rows", splits, testSize, opts.Gap, data.Rows)
+	}
+
+	order := make([]int, data.Rows)
+	times := make([]float64, data.Rows)
+	for i := range order {
+		if data.IsMissing(i, col) {
+			return nil, fmt.Errorf("evaluation: row %d has no %s", i, attr.GetName())
+		}
+		order[i] = i
+		times[i] = data.Get(i, col)
+	}
+	sort.Stable(&byScore{order, times})
+
+	ret := make([]Split, splits)
+	for k := range ret {
+		testStart := firstTest + k*testSize
+		trainEnd := testStart - opts.Gap
+		trainStart := 0
+		if opts.MaxTrainSize > 0 && trainEnd-opts.MaxTrainSize > 0 {
+			trainStart = trainEnd - opts.MaxTrainSize
+		}
+		ret[k] = Split{order[trainStart:trainEnd], order[testStart : testStart+testSize]}
+	}
+	return ret, nil
+}
@@ -0,0 +1,147 @@
+package evaluation
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/sjwhitworth/golearn/base"
+	"github.com/sjwhitworth/golearn/knn"
+)
+
+func TestStratifiedFolds(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	folds, err := StratifiedFolds(inst, 5, 1)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if err := checkPartitions(inst.Rows, folds); err != nil {
+		testEnv.Fatal(err)
+	}
+	// Iris has 50 rows of each class, so each fold should have 10
+	for k, fold := range folds {
+		counts := make(map[string]int)
+		for _, r := range fold {
+			counts[inst.GetClass(r)]++
+		}
+		if len(counts) != 3 {
+			testEnv.Error(k, counts)
+		}
+		for class, n := range counts {
+			if n != 10 {
+				testEnv.Error(k, class, n)
+			}
+		}
+	}
+
+	again, _ := StratifiedFolds(inst, 5, 1)
+	for k := range folds {
+		for i := range folds[k] {
+			if folds[k][i] != again[k][i] {
+				testEnv.Fatal("The same seed should give the same folds")
+			}
+		}
+	}
+
+	if _, err := StratifiedFolds(inst, 1, 1); err == nil {
+		testEnv.Error("Should refuse a single fold")
+	}
+}
+
+func TestCrossValidateWithFolds(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	factory := func() base.Classifier {
+		return knn.NewKnnClassifier("euclidean", 3)
+	}
+	folds, err := StratifiedFolds(inst, 3, 2)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	result, err := CrossValidateWithFolds(factory, inst, folds)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if len(result.Folds) != 3 || result.Mean.Accuracy < 0.9 {
+		testEnv.Error(result)
+	}
+
+	// Every row should be in exactly one fold
+	if _, err := CrossValidateWithFolds(factory, inst, folds[1:]); err == nil {
+		testEnv.Error("Rows missing from the folds should be an error")
+	}
+	folds[0] = append(folds[0], folds[1][0])
+	if _, _, err := CrossValPredictWithFolds(knn.NewKnnClassifier("euclidean", 3), inst, folds); err == nil {
+		testEnv.Error("Rows in two folds should be an error")
+	}
+}
+
+func TestTimeSeriesSplits(testEnv *testing.T) {
+	when := base.NewTimeAttribute("")
+	when.SetName("when")
+	x := base.NewFloatAttribute()
+	x.SetName("x")
+	class := base.NewCategoricalAttribute()
+	class.SetName("class")
+	inst := base.NewInstances([]base.Attribute{when, x, class}, 24)
+	// The rows are in a random order, a day apart
+	for i, day := range rand.New(rand.NewSource(1)).Perm(24) {
+		inst.Set(i, 0, float64(day*86400))
+		inst.Set(i, 1, float64(day))
+		inst.SetAttrStr(i, 2, []string{"a", "b"}[day%2])
+	}
+
+	splits, err := TimeSeriesSplits(inst, when, TimeSeriesOptions{Splits: 3})
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if len(splits) != 3 {
+		testEnv.Fatal(splits)
+	}
+	for k, split := range splits {
+		// Expanding windows of six test rows
+		if len(split.Test) != 6 || len(split.Train) != 6*(k+1) {
+			testEnv.Error(k, len(split.Train), len(split.Test))
+		}
+		for _, r := range split.Train {
+			for _, t := range split.Test {
+				if inst.Get(r, 0) >= inst.Get(t, 0) {
+					testEnv.Fatalf("Split %d trains on row %d, after row %d", k, r, t)
+				}
+			}
+		}
+	}
+	if last := splits[2].Test; inst.Get(last[len(last)-1], 0) != 23*86400 {
+		testEnv.Error("The last window should end with the latest row")
+	}
+
+	splits, err = TimeSeriesSplits(inst, when, TimeSeriesOptions{Splits: 2, TestSize: 4, MaxTrainSize: 5, Gap: 2})
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	for _, split := range splits {
+		latest := inst.Get(split.Train[len(split.Train)-1], 0)
+		if len(split.Train) != 5 || inst.Get(split.Test[0], 0)-latest != 3*86400 {
+			testEnv.Error(len(split.Train), latest)
+		}
+	}
+
+	factory := func() base.Classifier {
+		return knn.NewKnnClassifier("euclidean", 1)
+	}
+	result, err := CrossValidateWithSplits(factory, inst, splits)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if len(result.Folds) != 2 || result.Folds[0].TestRows != 4 || result.Predictions != nil {
+		testEnv.Error(result)
+	}
+
+	if _, err := TimeSeriesSplits(inst, when, TimeSeriesOptions{Splits: 6, TestSize: 4}); err == nil {
+		testEnv.Error("There should be no rows left to train the first window on")
+	}
+}
@@ -0,0 +1,153 @@
+package evaluation
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/sjwhitworth/golearn/base"
+)
+
+// PredictionFunction returns a numeric prediction for each row of a
+// set of Instances, e.g. the probability of one class.
+type PredictionFunction func(*base.Instances) []float64
+
+// ProbabilityOf returns a PredictionFunction giving the probability
+// which cls assigns to class.
+func ProbabilityOf(cls base.ProbabilisticClassifier, class string) PredictionFunction {
+	return func(what *base.Instances) []float64 {
+		probs := cls.PredictProba(what)
+		ret := make([]float64, len(probs))
+		for i, p := range probs {
+			ret[i] = p[class]
+		}
+		return ret
+	}
+}
+
+// Interaction is the strength of the interaction between two
+// Attributes.
+type Interaction struct {
+	First  base.Attribute
+	Second base.Attribute
+	// Strength is Friedman's H² statistic: the fraction of the
+	// variance of the pair's joint partial dependence which isn't
+	// explained by their separate effects. It's 0 if they don't
+	// interact at all.
+	Strength float64
+}
+
+func (i Interaction) String() string {
+	return fmt.Sprintf("%s x %s: %.4f", i.First.GetName(), i.Second.GetName(), i.Strength)
+}
+
+// partialDependence estimates the partial dependence of predict on
+// the columns in cols at each row of data: the average prediction
+// over every row of data once those columns are replaced by that
+// row's values. The result is centred on zero.
+func partialDependence(predict PredictionFunction, data *base.Instances, cols []int) []float64 {
+	n := data.Rows
+	rows := make([]int, n*n)
+	for r := range rows {
+		rows[r] = r % n
+	}
+	grid := selectRows(data, rows)
+	for i := 0; i < n; i++ {
+		for k := 0; k < n; k++ {
+			for _, j := range cols {
+				grid.Set(i*n+k, j, data.Get(i, j))
+			}
+		}
+	}
+	predictions := predict(grid)
+	ret := make([]float64, n)
+	mean := 0.0
+	for i := range ret {
+		for k := 0; k < n; k++ {
+			ret[i] += predictions[i*n+k]
+		}
+		ret[i] /= float64(n)
+		mean += ret[i] / float64(n)
+	}
+	for i := range ret {
+		ret[i] -= mean
+	}
+	return ret
+}
+
+// hStatistic computes Friedman's H² from the joint and separate
+// partial dependences of two Attributes
+func hStatistic(joint, first, second []float64) float64 {
+	num, denom := 0.0, 0.0
+	for i := range joint {
+		d := joint[i] - first[i] - second[i]
+		num += d * d
+		denom += joint[i] * joint[i]
+	}
+	if denom == 0 {
+		return 0
+	}
+	return num / denom
+}
+
+// InteractionStrength returns Friedman's H² statistic for the
+// interaction between the Attributes at indices a and b, according
+// to the predictions of predict over the rows of data.
+//
+// IMPORTANT: this makes two predictions for every pair of rows of
+// data, so pass a sample of a few hundred rows at most (e.g. from
+// SampleWithoutReplacement).
+func InteractionStrength(predict PredictionFunction, data *base.Instances, a, b int) float64 {
+	return hStatistic(
+		partialDependence(predict, data, []int{a, b}),
+		partialDependence(predict, data, []int{a}),
+		partialDependence(predict, data, []int{b}),
+	)
+}
+
+// TopInteractions measures the interaction (see InteractionStrength)
+// between every pair of non-class Attributes of data and returns the
+// n strongest, strongest first. If n is zero or less, every pair is
+// returned.
+func TopInteractions(predict PredictionFunction, data *base.Instances, n int) []Interaction {
+	cols := make([]int, 0)
+	for j := 0; j < data.Cols; j++ {
+		if data.IsFeature(j) {
+			cols = append(cols, j)
+		}
+	}
+	single := make(map[int][]float64)
+	for _, j := range cols {
+		single[j] = partialDependence(predict, data, []int{j})
+	}
+	ret := make([]Interaction, 0)
+	for x, a := range cols {
+		for _, b := range cols[x+1:] {
+			joint := partialDependence(predict, data, []int{a, b})
+			ret = append(ret, Interaction{
+				data.GetAttr(a),
+				data.GetAttr(b),
+				hStatistic(joint, single[a], single[b]),
+			})
+		}
+	}
+	sort.Sort(byStrength(ret))
+	if n > 0 && n < len(ret) {
+		ret = ret[:n]
+	}
+	return ret
+}
+
+// byStrength sorts Interactions strongest first
+type byStrength []Interaction
+
+func (b byStrength) Len() int {
+	return len(b)
+}
+
+func (b byStrength) Swap(i, j int) {
+	b[i], b[j] = b[j], b[i]
+}
+
+func (b byStrength) Less(i, j int) bool {
+	return b[i].Strength > b[j].Strength
+}
@@ -0,0 +1,52 @@
+package evaluation
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/sjwhitworth/golearn/base"
+)
+
+func TestTopInteractions(testEnv *testing.T) {
+	attrs := make([]base.Attribute, 5)
+	for j := range attrs {
+		attrs[j] = base.NewFloatAttribute()
+	}
+	attrs[0].SetName("a")
+	attrs[1].SetName("b")
+	attrs[2].SetName("c")
+	attrs[3].SetName("d")
+	attrs[4].SetName("y")
+	rng := rand.New(rand.NewSource(1))
+	inst := base.NewInstances(attrs, 40)
+	for i := 0; i < inst.Rows; i++ {
+		for j := 0; j < 4; j++ {
+			inst.Set(i, j, rng.Float64()*2-1)
+		}
+	}
+	// b and c interact, a and d only have separate effects
+	predict := func(what *base.Instances) []float64 {
+		ret := make([]float64, what.Rows)
+		for i := range ret {
+			ret[i] = what.Get(i, 0) + what.Get(i, 1)*what.Get(i, 2) + 2*what.Get(i, 3)
+		}
+		return ret
+	}
+
+	top := TopInteractions(predict, inst, 2)
+	if len(top) != 2 {
+		testEnv.Fatal(top)
+	}
+	if top[0].First.GetName() != "b" || top[0].Second.GetName() != "c" || top[0].Strength < 0.9 {
+		testEnv.Error(top[0])
+	}
+	if top[1].Strength > 1e-9 {
+		testEnv.Error(top[1])
+	}
+	if h := InteractionStrength(predict, inst, 0, 3); h > 1e-9 {
+		testEnv.Error(h)
+	}
+	if all := TopInteractions(predict, inst, 0); len(all) != 6 {
+		testEnv.Error(all)
+	}
+}
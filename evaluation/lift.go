@@ -0,0 +1,148 @@
+package evaluation
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/sjwhitworth/golearn/base"
+)
+
+// LiftRow is one bin of a LiftTable: the rows whose probability of
+// the class ranks between MinScore and MaxScore.
+type LiftRow struct {
+	MinScore float64
+	MaxScore float64
+	// Rows is the number of rows in the bin, and Positives how many
+	// of them have the class
+	Rows      int
+	Positives int
+	// ResponseRate is the proportion of the bin's rows with the
+	// class, and Lift is that relative to the proportion of all the
+	// rows with it
+	ResponseRate float64
+	Lift         float64
+	// CumulativeGain is the proportion of all the rows with the
+	// class which are in this bin or the ones before it, and
+	// CumulativeLift is the Lift of those bins together
+	CumulativeGain float64
+	CumulativeLift float64
+}
+
+// LiftTable divides rows into bins of (nearly) equal size by their
+// probability of a class, from the highest to the lowest, as returned
+// by GetLiftTable. With ten bins it's the usual decile lift table.
+type LiftTable []LiftRow
+
+// String returns the LiftTable as a table, one line per bin.
+func (t LiftTable) String() string {
+	var buffer bytes.Buffer
+	w := tabwriter.NewWriter(&buffer, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "Bin\tScores\tRows\tPositives\tResponse\tLift\tGain\tCumulative lift\t")
+	for k, r := range t {
+		fmt.Fprintf(w, "%d\t%.3f-%.3f\t%d\t%d\t%.4f\t%.2f\t%.4f\t%.2f\t\n",
+			k+1, r.MinScore, r.MaxScore, r.Rows, r.Positives, r.ResponseRate, r.Lift, r.CumulativeGain, r.CumulativeLift)
+	}
+	w.Flush()
+	return buffer.String()
+}
+
+// rankByScore returns the rows of ref from the highest probability of
+// class to the lowest (ties in row order), the probabilities and how
+// many of the rows have the class
+func rankByScore(ref *base.Instances, probabilities []map[string]float64, class string) ([]int, []float64, int) {
+	if ref.Rows != len(probabilities) {
+		panic("Row counts should match")
+	}
+	rows := make([]int, ref.Rows)
+	scores := make([]float64, ref.Rows)
+	positives := 0
+	for i := range rows {
+		rows[i] = i
+		scores[i] = probabilities[i][class]
+		if ref.GetClass(i) == class {
+			positives++
+		}
+	}
+	sort.Stable(sort.Reverse(&byScore{rows, scores}))
+	return rows, scores, positives
+}
+
+// GetLiftTable ranks the rows of ref by their probability of class
+// (e.g. from a base.ProbabilisticClassifier or CrossValPredict), from
+// the highest to the lowest, and divides them into bins (e.g. 10 for
+// deciles) to show how much better than random picking the rows the
+// probabilities rank highly is. Tied rows may be split between bins.
+// If no rows have the class, nil is returned.
+//
+// IMPORTANT: panic()s if bins isn't positive.
+func GetLiftTable(ref *base.Instances, probabilities []map[string]float64, class string, bins int) LiftTable {
+	if bins < 1 {
+		panic(fmt.Sprintf("evaluation: need at least 1 bin, got %d", bins))
+	}
+	rows, scores, positives := rankByScore(ref, probabilities, class)
+	if positives == 0 {
+		return nil
+	}
+	overall := float64(positives) / float64(len(rows))
+	ret := make(LiftTable, 0, bins)
+	seen, seenPositives := 0, 0
+	for k := 0; k < bins; k++ {
+		start, end := k*len(rows)/bins, (k+1)*len(rows)/bins
+		if start == end {
+			continue
+		}
+		r := LiftRow{MinScore: scores[rows[end-1]], MaxScore: scores[rows[start]], Rows: end - start}
+		for _, i := range rows[start:end] {
+			if ref.GetClass(i) == class {
+				r.Positives++
+			}
+		}
+		seen += r.Rows
+		seenPositives += r.Positives
+		r.ResponseRate = float64(r.Positives) / float64(r.Rows)
+		r.Lift = r.ResponseRate / overall
+		r.CumulativeGain = float64(seenPositives) / float64(positives)
+		r.CumulativeLift = float64(seenPositives) / float64(seen) / overall
+		ret = append(ret, r)
+	}
+	return ret
+}
+
+// GainsPoint is one point on a cumulative gains curve: picking the
+// rows whose probability of the class is at least Threshold picks the
+// Targeted proportion of the rows, and the Captured proportion of
+// those with the class.
+type GainsPoint struct {
+	Threshold float64
+	Targeted  float64
+	Captured  float64
+}
+
+// GetCumulativeGains returns the cumulative gains curve when the rows
+// of ref are picked in order of their probability of class: there's
+// one point per distinct probability, from the highest to the lowest,
+// so the last is always (1, 1). Picking at random would give a
+// straight line, with Captured equal to Targeted. If no rows have the
+// class, nil is returned.
+func GetCumulativeGains(ref *base.Instances, probabilities []map[string]float64, class string) []GainsPoint {
+	rows, scores, positives := rankByScore(ref, probabilities, class)
+	if positives == 0 {
+		return nil
+	}
+	ret := make([]GainsPoint, 0)
+	captured := 0
+	for start := 0; start < len(rows); {
+		end := start
+		for end < len(rows) && scores[rows[end]] == scores[rows[start]] {
+			if ref.GetClass(rows[end]) == class {
+				captured++
+			}
+			end++
+		}
+		ret = append(ret, GainsPoint{scores[rows[start]], float64(end) / float64(len(rows)), float64(captured) / float64(positives)})
+		start = end
+	}
+	return ret
+}
@@ -0,0 +1,52 @@
+package evaluation
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/sjwhitworth/golearn/base"
+)
+
+func TestLiftTable(testEnv *testing.T) {
+	attrs := []base.Attribute{base.NewCategoricalAttribute()}
+	ref := base.NewInstances(attrs, 10)
+	probabilities := make([]map[string]float64, 10)
+	// The rows with the highest probabilities are the positive ones,
+	// apart from one
+	for i, c := range []string{"yes", "yes", "no", "yes", "no", "no", "no", "no", "no", "yes"} {
+		ref.SetAttrStr(i, 0, c)
+		probabilities[i] = map[string]float64{"yes": 1 - float64(i)/10}
+	}
+	table := GetLiftTable(ref, probabilities, "yes", 5)
+	if len(table) != 5 {
+		testEnv.Fatal(table)
+	}
+	first := table[0]
+	if first.Rows != 2 || first.Positives != 2 || first.MaxScore != 1 || math.Abs(first.MinScore-0.9) > 1e-9 {
+		testEnv.Error(first)
+	}
+	if math.Abs(first.Lift-2.5) > 1e-9 || math.Abs(first.CumulativeGain-0.5) > 1e-9 {
+		testEnv.Error(first)
+	}
+	if second := table[1]; math.Abs(second.CumulativeGain-0.75) > 1e-9 || math.Abs(second.CumulativeLift-1.875) > 1e-9 {
+		testEnv.Error(second)
+	}
+	if last := table[4]; last.CumulativeGain != 1 || math.Abs(last.CumulativeLift-1) > 1e-9 {
+		testEnv.Error(last)
+	}
+	if !strings.Contains(table.String(), "Cumulative lift") {
+		testEnv.Error(table.String())
+	}
+	if GetLiftTable(ref, probabilities, "maybe", 10) != nil {
+		testEnv.Error("No positives should give no table")
+	}
+
+	gains := GetCumulativeGains(ref, probabilities, "yes")
+	if len(gains) != 10 || gains[1].Targeted != 0.2 || gains[1].Captured != 0.5 {
+		testEnv.Fatal(gains)
+	}
+	if last := gains[9]; last.Targeted != 1 || last.Captured != 1 {
+		testEnv.Error(last)
+	}
+}
@@ -0,0 +1,34 @@
+package evaluation
+
+import (
+	"math"
+
+	"github.com/sjwhitworth/golearn/base"
+)
+
+// LogLossEpsilon is how far GetLogLoss keeps probabilities from zero
+// and one, so that a single confident mistake costs a large but
+// finite amount.
+const LogLossEpsilon = 1e-15
+
+// GetLogLoss returns the log loss (cross-entropy) of the class
+// probabilities (e.g. from a base.ProbabilisticClassifier or
+// CrossValPredict) for the rows of ref: the mean negative natural
+// logarithm of the probability given to each row's actual class,
+// clipped to [LogLossEpsilon, 1-LogLossEpsilon]. Lower is better,
+// and unlike accuracy it rewards well-calibrated probabilities. Rows
+// are weighted by ref's weights.
+func GetLogLoss(ref *base.Instances, probabilities []map[string]float64) float64 {
+	if ref.Rows != len(probabilities) {
+		panic("Row counts should match")
+	}
+	total, weight := 0.0, 0.0
+	for i := 0; i < ref.Rows; i++ {
+		p := probabilities[i][ref.GetClass(i)]
+		p = math.Max(LogLossEpsilon, math.Min(1-LogLossEpsilon, p))
+		w := ref.GetWeight(i)
+		total -= w * math.Log(p)
+		weight += w
+	}
+	return total / weight
+}
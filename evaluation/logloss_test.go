@@ -0,0 +1,39 @@
+package evaluation
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sjwhitworth/golearn/base"
+)
+
+func TestLogLoss(testEnv *testing.T) {
+	attrs := []base.Attribute{base.NewCategoricalAttribute()}
+	ref := base.NewInstances(attrs, 3)
+	for i, c := range []string{"a", "b", "a"} {
+		ref.SetAttrStr(i, 0, c)
+	}
+	probabilities := []map[string]float64{
+		{"a": 0.8, "b": 0.2},
+		{"a": 0.5, "b": 0.5},
+		{"a": 1},
+	}
+	expected := -(math.Log(0.8) + math.Log(0.5) + math.Log(1-LogLossEpsilon)) / 3
+	if loss := GetLogLoss(ref, probabilities); math.Abs(loss-expected) > 1e-9 {
+		testEnv.Error(loss)
+	}
+
+	// A confident mistake is clipped rather than infinite
+	probabilities[2] = map[string]float64{"b": 1}
+	if loss := GetLogLoss(ref, probabilities); math.IsInf(loss, 0) || loss < 10 {
+		testEnv.Error(loss)
+	}
+
+	// Weights count rows more or less
+	probabilities[2] = map[string]float64{"a": 1}
+	ref.SetWeight(0, 2)
+	expected = -(2*math.Log(0.8) + math.Log(0.5) + math.Log(1-LogLossEpsilon)) / 4
+	if loss := GetLogLoss(ref, probabilities); math.Abs(loss-expected) > 1e-9 {
+		testEnv.Error(loss)
+	}
+}
@@ -0,0 +1,46 @@
+package evaluation
+
+import (
+	"github.com/sjwhitworth/golearn/base"
+)
+
+// Misclassified returns the rows of test which trained (a Classifier
+// already fitted) predicts the wrong class for, so that they can be
+// inspected. Two Attributes with IgnoredRole are added to them (see
+// base.MergeAttributes): "predicted", the class predicted, and
+// "confidence", the probability trained gives it if it's a
+// base.ProbabilisticClassifier (otherwise it's missing). The rows
+// keep their weights and order, and rows with no prediction aren't
+// included. It returns an error if test already has an Attribute
+// with either name.
+func Misclassified(trained base.Classifier, test *base.Instances) (*base.Instances, error) {
+	predictions := trained.Predict(test)
+	var probabilities []map[string]float64
+	if p, ok := trained.(base.ProbabilisticClassifier); ok {
+		probabilities = p.PredictProba(test)
+	}
+
+	rows := make([]int, 0)
+	for i := 0; i < test.Rows; i++ {
+		if !predictions.IsMissing(i, 0) && predictions.GetClass(i) != test.GetClass(i) {
+			rows = append(rows, i)
+		}
+	}
+
+	predicted := base.NewCategoricalAttribute()
+	predicted.SetName("predicted")
+	confidence := base.NewFloatAttribute()
+	confidence.SetName("confidence")
+	annotations := base.NewInstances([]base.Attribute{predicted, confidence}, len(rows))
+	annotations.SetRole(0, base.IgnoredRole)
+	for k, i := range rows {
+		class := predictions.GetClass(i)
+		annotations.SetAttrStr(k, 0, class)
+		if probabilities != nil {
+			annotations.Set(k, 1, probabilities[i][class])
+		} else {
+			annotations.SetMissing(k, 1)
+		}
+	}
+	return base.MergeAttributes(test.SelectRows(rows), annotations)
+}
@@ -0,0 +1,46 @@
+package evaluation
+
+import (
+	"testing"
+
+	"github.com/sjwhitworth/golearn/base"
+	"github.com/sjwhitworth/golearn/knn"
+)
+
+func TestMisclassified(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	trainData, testData := base.InstancesTrainTestSplitWithSeed(inst, 0.5, 1)
+	cls := knn.NewKnnClassifier("euclidean", 1)
+	cls.Fit(trainData)
+
+	wrong, err := Misclassified(cls, testData)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if wrong.Rows == 0 || wrong.Cols != testData.Cols+2 {
+		testEnv.Fatal(wrong)
+	}
+	if wrong.GetClassAttr().GetName() != testData.GetClassAttr().GetName() {
+		testEnv.Error(wrong.GetClassAttr())
+	}
+	predicted, confidence := wrong.Cols-3, wrong.Cols-2
+	if wrong.GetRole(predicted) != base.IgnoredRole || wrong.GetRole(confidence) != base.IgnoredRole {
+		testEnv.Error("The annotations shouldn't be features")
+	}
+	for i := 0; i < wrong.Rows; i++ {
+		if wrong.GetAttrStr(i, predicted) == wrong.GetClass(i) {
+			testEnv.Errorf("Row %d was predicted correctly", i)
+		}
+		// The single nearest neighbour is always certain
+		if wrong.Get(i, confidence) != 1 {
+			testEnv.Errorf("Row %d has confidence %.2f", i, wrong.Get(i, confidence))
+		}
+	}
+	confusion := GetConfusionMatrix(testData, cls.Predict(testData))
+	if errors := float64(testData.Rows) * (1 - GetAccuracy(confusion)); int(errors+0.5) != wrong.Rows {
+		testEnv.Errorf("%d rows misclassified, not %.0f", wrong.Rows, errors)
+	}
+}
@@ -0,0 +1,163 @@
+package evaluation
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/sjwhitworth/golearn/base"
+)
+
+// StratifiedSubsamples returns splits independent random divisions of
+// the rows of data into a set to test on, holding about testProportion
+// of them, and a set to train on holding the rest, for
+// CrossValidateWithSplits. Like StratifiedFolds, each set has (to
+// within one row) the same proportion of each class as data. Unlike
+// folds, the test sets overlap, and some rows may never be tested.
+// The splits are determined by seed.
+func StratifiedSubsamples(data *base.Instances, splits int, testProportion float64, seed int64) ([]Split, error) {
+	if splits < 1 {
+		return nil, fmt.Errorf("evaluation: need at least 1 split, got %d", splits)
+	}
+	if testProportion <= 0 || testProportion >= 1 {
+		return nil, fmt.Errorf("evaluation: test proportion %f should be between 0 and 1", testProportion)
+	}
+	ret := generateStratifiedSubsamples(data, splits, testProportion, rand.New(rand.NewSource(seed)))
+	if len(ret[0].Train) == 0 || len(ret[0].Test) == 0 {
+		return nil, fmt.Errorf("evaluation: can't test on %f of %d rows", testProportion, data.Rows)
+	}
+	return ret, nil
+}
+
+// generateStratifiedSubsamples implements StratifiedSubsamples: in
+// each split, the rows of each class are shuffled, and the first
+// testProportion of them (rounded) are tested on.
+func generateStratifiedSubsamples(data *base.Instances, splits int, testProportion float64, rng *rand.Rand) []Split {
+	byClass := make(map[string][]int)
+	for i := 0; i < data.Rows; i++ {
+		class := data.GetClass(i)
+		byClass[class] = append(byClass[class], i)
+	}
+	classes := make([]string, 0, len(byClass))
+	for class := range byClass {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+
+	ret := make([]Split, splits)
+	for k := range ret {
+		ret[k] = Split{make([]int, 0), make([]int, 0)}
+		for _, class := range classes {
+			rows := byClass[class]
+			test := int(math.Floor(float64(len(rows))*testProportion + 0.5))
+			for n, i := range rng.Perm(len(rows)) {
+				if n < test {
+					ret[k].Test = append(ret[k].Test, rows[i])
+				} else {
+					ret[k].Train = append(ret[k].Train, rows[i])
+				}
+			}
+		}
+	}
+	return ret
+}
+
+// MonteCarloOptions configure MonteCarloValidate. Zero fields take the
+// default given.
+type MonteCarloOptions struct {
+	// Splits is the number of random divisions of the rows (10)
+	Splits int
+	// TestProportion is the proportion of the rows tested on in
+	// each (0.25)
+	TestProportion float64
+	// Workers is the most splits trained at once (as many as
+	// base.GetConfig allows)
+	Workers int
+	// Seed determines the splits, and the seed each split's
+	// Classifier is given (a random one)
+	Seed int64
+}
+
+// MonteCarloValidate estimates how well the Classifiers created by
+// factory generalise with repeated random subsampling: each of
+// opts.Splits Classifiers is trained on a stratified random sample of
+// the rows of data, and tested on the rest (see
+// StratifiedSubsamples). Unlike CrossValidate, the number of splits
+// and the number of rows tested on are independent, so it's cheaper
+// than repeating cross-validation to get as many estimates. Each
+// split's Classifier is seeded as in CrossValidateWithOptions. Use
+// ConfidenceInterval to summarise the distribution of the metrics.
+// Since rows may be tested more than once, or not at all, the result
+// has no Predictions.
+func MonteCarloValidate(factory base.ClassifierFactory, data *base.Instances, opts MonteCarloOptions) (*CrossValidationResult, error) {
+	splits, proportion, seed := opts.Splits, opts.TestProportion, opts.Seed
+	if splits == 0 {
+		splits = 10
+	}
+	if proportion == 0 {
+		proportion = 0.25
+	}
+	if seed == 0 {
+		seed = rand.Int63()
+	}
+	rng := rand.New(rand.NewSource(seed))
+	subsamples, err := StratifiedSubsamples(data, splits, proportion, rng.Int63())
+	if err != nil {
+		return nil, err
+	}
+	seeds := make([]int64, splits)
+	for i := range seeds {
+		seeds[i] = rng.Int63()
+	}
+	ret := crossValidate(factory, data, subsamples, opts.Workers, seeds)
+	ret.Predictions = nil
+	return ret, nil
+}
+
+// ConfidenceInterval returns the lower and upper bounds of a
+// confidence interval, at the given level (e.g. 0.95), for the mean
+// of each metric. Because the folds' training rows overlap, the
+// metrics aren't independent, so the interval is widened with Nadeau
+// and Bengio's correction, as in CorrectedPairedTTest. The bounds are
+// NaN if there are fewer than two folds.
+func (r *CrossValidationResult) ConfidenceInterval(level float64) (FoldMetrics, FoldMetrics) {
+	trainRows, testRows := 0, 0
+	for _, f := range r.Folds {
+		trainRows += f.TrainRows
+		testRows += f.TestRows
+	}
+	k := float64(len(r.Folds))
+	scale := math.NaN()
+	if len(r.Folds) > 1 {
+		scale = studentTQuantile(1-level, k-1) * math.Sqrt(1/k+float64(testRows)/float64(trainRows))
+	}
+	bounds := func(mean, std float64) (float64, float64) {
+		return mean - scale*std, mean + scale*std
+	}
+	var lower, upper FoldMetrics
+	lower.Accuracy, upper.Accuracy = bounds(r.Mean.Accuracy, r.Std.Accuracy)
+	lower.MacroPrecision, upper.MacroPrecision = bounds(r.Mean.MacroPrecision, r.Std.MacroPrecision)
+	lower.MacroRecall, upper.MacroRecall = bounds(r.Mean.MacroRecall, r.Std.MacroRecall)
+	lower.MacroF1, upper.MacroF1 = bounds(r.Mean.MacroF1, r.Std.MacroF1)
+	return lower, upper
+}
+
+// studentTQuantile returns the t such that the two-sided p-value of
+// t under Student's t distribution with df degrees of freedom is p,
+// found by bisection
+func studentTQuantile(p, df float64) float64 {
+	lo, hi := 0.0, 1.0
+	for studentTPValue(hi, df) > p {
+		lo, hi = hi, hi*2
+	}
+	for i := 0; i < 100; i++ {
+		mid := (lo + hi) / 2
+		if studentTPValue(mid, df) > p {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
@@ -0,0 +1,80 @@
+package evaluation
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sjwhitworth/golearn/base"
+	"github.com/sjwhitworth/golearn/knn"
+)
+
+func TestStratifiedSubsamples(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	splits, err := StratifiedSubsamples(inst, 4, 0.2, 1)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	for k, split := range splits {
+		if len(split.Train) != 120 || len(split.Test) != 30 {
+			testEnv.Errorf("Split %d has %d training and %d test rows", k, len(split.Train), len(split.Test))
+		}
+		counts := make(map[string]int)
+		seen := make(map[int]bool)
+		for _, r := range split.Test {
+			counts[inst.GetClass(r)]++
+			seen[r] = true
+		}
+		for _, r := range split.Train {
+			if seen[r] {
+				testEnv.Errorf("Split %d trains and tests on row %d", k, r)
+			}
+		}
+		for class, count := range counts {
+			if count != 10 {
+				testEnv.Errorf("Split %d tests on %d rows of %s", k, count, class)
+			}
+		}
+	}
+	if _, err := StratifiedSubsamples(inst, 4, 1, 1); err == nil {
+		testEnv.Error("Testing on every row should be an error")
+	}
+}
+
+func TestMonteCarloValidate(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	factory := func() base.Classifier { return knn.NewKnnClassifier("euclidean", 5) }
+	result, err := MonteCarloValidate(factory, inst, MonteCarloOptions{Splits: 8, Seed: 1})
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if len(result.Folds) != 8 || result.Predictions != nil {
+		testEnv.Fatal(result)
+	}
+	for _, f := range result.Folds {
+		if f.TestRows != 39 || f.TrainRows != 111 {
+			testEnv.Error(f.TrainRows, f.TestRows)
+		}
+	}
+	if result.Mean.Accuracy < 0.85 {
+		testEnv.Error(result.Mean.Accuracy)
+	}
+
+	lower, upper := result.ConfidenceInterval(0.95)
+	if !(lower.Accuracy <= result.Mean.Accuracy && result.Mean.Accuracy <= upper.Accuracy) {
+		testEnv.Error(lower.Accuracy, upper.Accuracy)
+	}
+	narrower, _ := result.ConfidenceInterval(0.5)
+	if narrower.Accuracy < lower.Accuracy {
+		testEnv.Error("A lower confidence level should give a narrower interval")
+	}
+	// t with 7 degrees of freedom
+	if t := studentTQuantile(0.05, 7); math.Abs(t-2.3646) > 1e-3 {
+		testEnv.Error(t)
+	}
+}
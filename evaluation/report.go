@@ -0,0 +1,208 @@
+package evaluation
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"time"
+
+	"github.com/sjwhitworth/golearn/base"
+)
+
+// ClassMetrics are the scores of a Classifier on one class.
+type ClassMetrics struct {
+	Precision float64
+	Recall    float64
+	F1        float64
+	// Support is how many rows actually have the class
+	Support float64
+}
+
+// Report bundles everything known about how a Classifier performed,
+// so that it can be logged, e.g. as JSON to an experiment tracking
+// system. Metrics which are undefined (such as the precision of a
+// class which was never predicted) are NaN, and null in JSON.
+type Report struct {
+	// Classifier describes the Classifier, and Params are its
+	// parameters (see base.GetParams), apart from functions
+	Classifier string
+	Params     map[string]interface{}
+	Confusion  ConfusionMatrix
+	// Classes holds the metrics of each actual class
+	Classes map[string]ClassMetrics
+
+	Accuracy                 float64
+	MacroPrecision           float64
+	MacroRecall              float64
+	MacroF1                  float64
+	MicroF1                  float64
+	SupportWeightedPrecision float64
+	SupportWeightedRecall    float64
+	SupportWeightedF1        float64
+
+	// CrossValidation holds the results of each fold, if the
+	// Classifier was cross-validated
+	CrossValidation *CrossValidationResult
+	// TrainingTime and PredictionTime are how long training and
+	// predicting took, if they were timed. For cross-validation,
+	// TrainingTime covers both, for every fold.
+	TrainingTime   time.Duration
+	PredictionTime time.Duration
+}
+
+// classifierParams returns the parameters of cls which can be
+// represented in JSON
+func classifierParams(cls base.Classifier) map[string]interface{} {
+	ret := make(map[string]interface{})
+	v := reflect.ValueOf(cls)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return ret
+	}
+	for k, p := range base.GetParams(cls) {
+		if reflect.ValueOf(p).Kind() != reflect.Func {
+			ret[k] = p
+		}
+	}
+	return ret
+}
+
+// GetReport returns a Report of how well predictions match the
+// classes of ref, made by cls.
+func GetReport(cls base.Classifier, ref, predictions *base.Instances) *Report {
+	return newReport(cls, GetConfusionMatrix(ref, predictions))
+}
+
+// newReport computes the metrics of a Report from a ConfusionMatrix
+func newReport(cls base.Classifier, c ConfusionMatrix) *Report {
+	ret := &Report{
+		Classifier:               cls.String(),
+		Params:                   classifierParams(cls),
+		Confusion:                c,
+		Classes:                  make(map[string]ClassMetrics),
+		Accuracy:                 GetAccuracy(c),
+		MacroPrecision:           GetMacroPrecision(c),
+		MacroRecall:              GetMacroRecall(c),
+		MacroF1:                  GetMacroF1Score(c),
+		MicroF1:                  GetMicroF1Score(c),
+		SupportWeightedPrecision: GetSupportWeightedPrecision(c),
+		SupportWeightedRecall:    GetSupportWeightedRecall(c),
+		SupportWeightedF1:        GetSupportWeightedF1Score(c),
+	}
+	for k := range c {
+		ret.Classes[k] = ClassMetrics{GetPrecision(k, c), GetRecall(k, c), GetF1Score(k, c), GetSupport(k, c)}
+	}
+	return ret
+}
+
+// EvaluateHoldout trains cls on train, predicts test with it, and
+// returns a Report of the predictions, including how long each took.
+func EvaluateHoldout(cls base.Classifier, train, test *base.Instances) *Report {
+	start := time.Now()
+	cls.Fit(train)
+	trained := time.Now()
+	predictions := cls.Predict(test)
+	ret := GetReport(cls, test, predictions)
+	ret.TrainingTime = trained.Sub(start)
+	ret.PredictionTime = time.Since(trained)
+	return ret
+}
+
+// CrossValidationReport cross-validates the Classifiers created by
+// factory on data, as configured by opts (see
+// CrossValidateWithOptions), and returns a Report of the out-of-fold
+// predictions, with the results of each fold.
+func CrossValidationReport(factory base.ClassifierFactory, data *base.Instances, opts CrossValidationOptions) (*Report, error) {
+	start := time.Now()
+	result, err := CrossValidateWithOptions(factory, data, opts)
+	if err != nil {
+		return nil, err
+	}
+	ret := GetReport(factory(), data, result.Predictions)
+	ret.CrossValidation = result
+	ret.TrainingTime = time.Since(start)
+	return ret, nil
+}
+
+// jsonFloat returns f, or nil if it can't be represented in JSON
+func jsonFloat(f float64) *float64 {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil
+	}
+	return &f
+}
+
+// reportJSON is the JSON form of a Report
+type reportJSON struct {
+	Classifier               string                      `json:"classifier"`
+	Params                   map[string]interface{}      `json:"params"`
+	Confusion                ConfusionMatrix             `json:"confusion"`
+	Classes                  map[string]classMetricsJSON `json:"classes"`
+	Accuracy                 *float64                    `json:"accuracy"`
+	MacroPrecision           *float64                    `json:"macro_precision"`
+	MacroRecall              *float64                    `json:"macro_recall"`
+	MacroF1                  *float64                    `json:"macro_f1"`
+	MicroF1                  *float64                    `json:"micro_f1"`
+	SupportWeightedPrecision *float64                    `json:"weighted_precision"`
+	SupportWeightedRecall    *float64                    `json:"weighted_recall"`
+	SupportWeightedF1        *float64                    `json:"weighted_f1"`
+	Folds                    []foldJSON                  `json:"folds,omitempty"`
+	TrainingSeconds          float64                     `json:"training_seconds"`
+	PredictionSeconds        float64                     `json:"prediction_seconds"`
+}
+
+// classMetricsJSON is the JSON form of ClassMetrics
+type classMetricsJSON struct {
+	Precision *float64 `json:"precision"`
+	Recall    *float64 `json:"recall"`
+	F1        *float64 `json:"f1"`
+	Support   float64  `json:"support"`
+}
+
+// foldJSON is the JSON form of a FoldResult
+type foldJSON struct {
+	Accuracy       *float64 `json:"accuracy"`
+	MacroPrecision *float64 `json:"macro_precision"`
+	MacroRecall    *float64 `json:"macro_recall"`
+	MacroF1        *float64 `json:"macro_f1"`
+	TrainRows      int      `json:"train_rows"`
+	TestRows       int      `json:"test_rows"`
+}
+
+// MarshalJSON encodes the Report as a JSON object with snake_case
+// keys, the durations in seconds and undefined metrics as null.
+func (r *Report) MarshalJSON() ([]byte, error) {
+	obj := reportJSON{
+		Classifier:               r.Classifier,
+		Params:                   r.Params,
+		Confusion:                r.Confusion,
+		Classes:                  make(map[string]classMetricsJSON),
+		Accuracy:                 jsonFloat(r.Accuracy),
+		MacroPrecision:           jsonFloat(r.MacroPrecision),
+		MacroRecall:              jsonFloat(r.MacroRecall),
+		MacroF1:                  jsonFloat(r.MacroF1),
+		MicroF1:                  jsonFloat(r.MicroF1),
+		SupportWeightedPrecision: jsonFloat(r.SupportWeightedPrecision),
+		SupportWeightedRecall:    jsonFloat(r.SupportWeightedRecall),
+		SupportWeightedF1:        jsonFloat(r.SupportWeightedF1),
+		TrainingSeconds:          r.TrainingTime.Seconds(),
+		PredictionSeconds:        r.PredictionTime.Seconds(),
+	}
+	for k, m := range r.Classes {
+		obj.Classes[k] = classMetricsJSON{jsonFloat(m.Precision), jsonFloat(m.Recall), jsonFloat(m.F1), m.Support}
+	}
+	if r.CrossValidation != nil {
+		for _, f := range r.CrossValidation.Folds {
+			obj.Folds = append(obj.Folds, foldJSON{
+				jsonFloat(f.Accuracy), jsonFloat(f.MacroPrecision), jsonFloat(f.MacroRecall), jsonFloat(f.MacroF1),
+				f.TrainRows, f.TestRows,
+			})
+		}
+	}
+	return json.Marshal(obj)
+}
+
+// String returns the classification report (see
+// GetClassificationReport), preceded by the Classifier.
+func (r *Report) String() string {
+	return r.Classifier + "\n" + GetClassificationReport(r.Confusion)
+}
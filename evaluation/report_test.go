@@ -0,0 +1,60 @@
+package evaluation
+
+import (
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/sjwhitworth/golearn/base"
+	"github.com/sjwhitworth/golearn/knn"
+)
+
+func TestReport(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	train, test := base.InstancesTrainTestSplitWithSeed(inst, 0.5, 1)
+	report := EvaluateHoldout(knn.NewKnnClassifier("euclidean", 3), train, test)
+	if report.Accuracy < 0.85 || report.Params["NearestNeighbours"] != 3 || len(report.Classes) != 3 {
+		testEnv.Error(report.Accuracy, report.Params, report.Classes)
+	}
+	if report.TrainingTime <= 0 || report.PredictionTime <= 0 {
+		testEnv.Error(report.TrainingTime, report.PredictionTime)
+	}
+	if !strings.HasPrefix(report.String(), "KNNClassifier(euclidean, 3)\n") {
+		testEnv.Error(report.String())
+	}
+
+	factory := func() base.Classifier {
+		return knn.NewKnnClassifier("euclidean", 3)
+	}
+	report, err = CrossValidationReport(factory, inst, CrossValidationOptions{Folds: 3, Seed: 1})
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	// Undefined metrics should be null in JSON
+	report.MacroPrecision = math.NaN()
+	data, err := json.Marshal(report)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		testEnv.Fatal(err)
+	}
+	if decoded["macro_precision"] != nil || decoded["accuracy"].(float64) != report.Accuracy {
+		testEnv.Error(string(data))
+	}
+	if folds := decoded["folds"].([]interface{}); len(folds) != 3 {
+		testEnv.Error(folds)
+	}
+	setosa := decoded["classes"].(map[string]interface{})["Iris-setosa"].(map[string]interface{})
+	if setosa["support"].(float64) != 50 {
+		testEnv.Error(setosa)
+	}
+	if decoded["params"].(map[string]interface{})["DistanceFunc"] != "euclidean" {
+		testEnv.Error(decoded["params"])
+	}
+}
@@ -0,0 +1,191 @@
+package evaluation
+
+import (
+	"math"
+	"sort"
+
+	"github.com/sjwhitworth/golearn/base"
+)
+
+// byScore sorts row indices by their score
+type byScore struct {
+	rows   []int
+	scores []float64
+}
+
+func (b *byScore) Len() int {
+	return len(b.rows)
+}
+
+func (b *byScore) Swap(i, j int) {
+	b.rows[i], b.rows[j] = b.rows[j], b.rows[i]
+}
+
+func (b *byScore) Less(i, j int) bool {
+	return b.scores[b.rows[i]] < b.scores[b.rows[j]]
+}
+
+// GetAUC returns the area under the ROC curve when the probabilities
+// of class (e.g. from a base.ProbabilisticClassifier or
+// CrossValPredict) are used to pick out the rows of ref with that
+// class: the chance that a random row of the class gets a higher
+// probability than a random row without it. 0.5 is no better than
+// guessing. Ties count half. If every row has the class, or none do,
+// NaN is returned.
+func GetAUC(ref *base.Instances, probabilities []map[string]float64, class string) float64 {
+	if ref.Rows != len(probabilities) {
+		panic("Row counts should match")
+	}
+	rows := make([]int, ref.Rows)
+	scores := make([]float64, ref.Rows)
+	for i := range rows {
+		rows[i] = i
+		scores[i] = probabilities[i][class]
+	}
+	sort.Sort(&byScore{rows, scores})
+
+	// Sum the ranks of the rows with the class, giving tied rows
+	// the average of their ranks (Mann-Whitney U)
+	positives, negatives := 0.0, 0.0
+	rankSum := 0.0
+	for start := 0; start < len(rows); {
+		end := start
+		for end < len(rows) && scores[rows[end]] == scores[rows[start]] {
+			end++
+		}
+		rank := float64(start+end+1) / 2
+		for _, r := range rows[start:end] {
+			if ref.GetClass(r) == class {
+				positives++
+				rankSum += rank
+			} else {
+				negatives++
+			}
+		}
+		start = end
+	}
+	if positives == 0 || negatives == 0 {
+		return math.NaN()
+	}
+	return (rankSum - positives*(positives+1)/2) / (positives * negatives)
+}
+
+// ROCPoint is one point on a ROC curve: predicting the class for the
+// rows whose probability of it is at least Threshold picks out the
+// TruePositiveRate proportion of the rows with the class, and the
+// FalsePositiveRate proportion of those without it.
+type ROCPoint struct {
+	Threshold         float64
+	FalsePositiveRate float64
+	TruePositiveRate  float64
+}
+
+// ROCCurve is a receiver operating characteristic curve, as returned
+// by GetROCCurve.
+type ROCCurve []ROCPoint
+
+// GetROCCurve returns the ROC curve when the probabilities of class
+// (e.g. from a base.ProbabilisticClassifier or CrossValPredict) are
+// used to pick out the rows of ref with that class. There's one point
+// per distinct probability, from the highest to the lowest, after a
+// first point at (0, 0) with an infinite Threshold, so the last is
+// always (1, 1). If every row has the class, or none do, nil is
+// returned.
+func GetROCCurve(ref *base.Instances, probabilities []map[string]float64, class string) ROCCurve {
+	if ref.Rows != len(probabilities) {
+		panic("Row counts should match")
+	}
+	rows := make([]int, ref.Rows)
+	scores := make([]float64, ref.Rows)
+	positives, negatives := 0.0, 0.0
+	for i := range rows {
+		rows[i] = i
+		scores[i] = probabilities[i][class]
+		if ref.GetClass(i) == class {
+			positives++
+		} else {
+			negatives++
+		}
+	}
+	if positives == 0 || negatives == 0 {
+		return nil
+	}
+	sort.Sort(sort.Reverse(&byScore{rows, scores}))
+
+	ret := ROCCurve{{math.Inf(1), 0, 0}}
+	truePositives, falsePositives := 0.0, 0.0
+	for start := 0; start < len(rows); {
+		end := start
+		for end < len(rows) && scores[rows[end]] == scores[rows[start]] {
+			if ref.GetClass(rows[end]) == class {
+				truePositives++
+			} else {
+				falsePositives++
+			}
+			end++
+		}
+		ret = append(ret, ROCPoint{scores[rows[start]], falsePositives / negatives, truePositives / positives})
+		start = end
+	}
+	return ret
+}
+
+// AUC returns the area under the curve, by the trapezoidal rule. For
+// a curve from GetROCCurve it's the same as GetAUC. It's NaN if the
+// curve is empty.
+func (c ROCCurve) AUC() float64 {
+	if len(c) == 0 {
+		return math.NaN()
+	}
+	ret := 0.0
+	for i := 1; i < len(c); i++ {
+		ret += (c[i].FalsePositiveRate - c[i-1].FalsePositiveRate) * (c[i].TruePositiveRate + c[i-1].TruePositiveRate) / 2
+	}
+	return ret
+}
+
+// GetROCCurves returns the one-vs-rest ROC curve (see GetROCCurve) of
+// each class which some, but not all, of the rows of ref have, for
+// problems with more than two classes.
+func GetROCCurves(ref *base.Instances, probabilities []map[string]float64) map[string]ROCCurve {
+	ret := make(map[string]ROCCurve)
+	for class := range ref.CountClassValues() {
+		if curve := GetROCCurve(ref, probabilities, class); curve != nil {
+			ret[class] = curve
+		}
+	}
+	return ret
+}
+
+// GetMacroAUC returns the mean of the one-vs-rest AUCs (see GetAUC)
+// of each class which some, but not all, of the rows of ref have, or
+// NaN if there are none.
+func GetMacroAUC(ref *base.Instances, probabilities []map[string]float64) float64 {
+	total, classes := 0.0, 0
+	for class := range ref.CountClassValues() {
+		if auc := GetAUC(ref, probabilities, class); !math.IsNaN(auc) {
+			total += auc
+			classes++
+		}
+	}
+	if classes == 0 {
+		return math.NaN()
+	}
+	return total / float64(classes)
+}
+
+// GetSupportWeightedAUC is like GetMacroAUC, but weights each class'
+// AUC by how many rows have it.
+func GetSupportWeightedAUC(ref *base.Instances, probabilities []map[string]float64) float64 {
+	total, support := 0.0, 0.0
+	for class, n := range ref.CountClassValues() {
+		if auc := GetAUC(ref, probabilities, class); !math.IsNaN(auc) {
+			total += float64(n) * auc
+			support += float64(n)
+		}
+	}
+	if support == 0 {
+		return math.NaN()
+	}
+	return total / support
+}
@@ -0,0 +1,147 @@
+package evaluation
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/sjwhitworth/golearn/base"
+)
+
+// segment accumulates the rows and errors of one value (or bin) of
+// an Attribute
+type segment struct {
+	attr   string
+	name   string
+	rows   float64
+	errors float64
+}
+
+// binEdges returns the upper edges of up to bins bins of the values
+// of col, each holding roughly the same number of rows. Missing
+// values are ignored.
+func binEdges(inst *base.Instances, col int, bins int) []float64 {
+	values := make([]float64, 0, inst.Rows)
+	for i := 0; i < inst.Rows; i++ {
+		if !inst.IsMissing(i, col) {
+			values = append(values, inst.Get(i, col))
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	sort.Float64s(values)
+	ret := make([]float64, 0, bins)
+	for k := 1; k <= bins; k++ {
+		edge := values[(len(values)*k-1)/bins]
+		if len(ret) == 0 || edge > ret[len(ret)-1] {
+			ret = append(ret, edge)
+		}
+	}
+	return ret
+}
+
+// GetSegmentErrors slices the errors of predictions against ref by
+// the class and by the value of each feature of ref, to find where
+// a Classifier does worst. Numeric Attributes are divided into up to
+// bins bins with roughly the same number of rows each, and missing
+// values form their own segment. Rows count as much as their weight.
+//
+// Each row of the result describes a segment: the name of the
+// Attribute, the segment, its total weight ("rows"), the weight of
+// those which were misclassified ("errors"), how many times the
+// overall error rate its error rate is ("lift") and its error rate.
+// They're sorted with the highest error rate first; use Sort to
+// order them differently.
+//
+// IMPORTANT: this function panic()s if ref and predictions have
+// different numbers of rows.
+func GetSegmentErrors(ref *base.Instances, predictions *base.Instances, bins int) *base.Instances {
+	if ref.Rows != predictions.Rows {
+		panic("Row counts should match")
+	}
+	if bins < 1 {
+		bins = 1
+	}
+	wrong := make([]bool, ref.Rows)
+	totalRows, totalErrors := 0.0, 0.0
+	for i := range wrong {
+		wrong[i] = ref.GetClass(i) != predictions.GetClass(i)
+		totalRows += ref.GetWeight(i)
+		if wrong[i] {
+			totalErrors += ref.GetWeight(i)
+		}
+	}
+
+	cols := append([]int{ref.ClassIndex}, ref.FeatureIndices()...)
+	segments := make([]*segment, 0)
+	for _, col := range cols {
+		attr := ref.GetAttr(col)
+		var edges []float64
+		if attr.GetType() == base.Float64Type {
+			edges = binEdges(ref, col, bins)
+		}
+		byName := make(map[string]*segment)
+		for i := 0; i < ref.Rows; i++ {
+			var name string
+			switch {
+			case ref.IsMissing(i, col):
+				name = base.MissingString
+			case attr.GetType() == base.Float64Type:
+				val := ref.Get(i, col)
+				k := sort.SearchFloat64s(edges, val)
+				if k == 0 {
+					name = fmt.Sprintf("<= %.4g", edges[0])
+				} else {
+					name = fmt.Sprintf("(%.4g, %.4g]", edges[k-1], edges[k])
+				}
+			default:
+				name = ref.GetAttrStr(i, col)
+			}
+			s, ok := byName[name]
+			if !ok {
+				s = &segment{attr: attr.GetName(), name: name}
+				byName[name] = s
+				segments = append(segments, s)
+			}
+			s.rows += ref.GetWeight(i)
+			if wrong[i] {
+				s.errors += ref.GetWeight(i)
+			}
+		}
+	}
+
+	attrNames := base.NewCategoricalAttribute()
+	attrNames.SetName("attribute")
+	segmentNames := base.NewCategoricalAttribute()
+	segmentNames.SetName("segment")
+	attrs := []base.Attribute{attrNames, segmentNames}
+	for _, name := range []string{"rows", "errors", "lift", "error rate"} {
+		a := base.NewFloatAttribute()
+		a.SetName(name)
+		a.Precision = 4
+		attrs = append(attrs, a)
+	}
+	ret := base.NewInstances(attrs, len(segments))
+	overall := 0.0
+	if totalRows > 0 {
+		overall = totalErrors / totalRows
+	}
+	for i, s := range segments {
+		rate := 0.0
+		if s.rows > 0 {
+			rate = s.errors / s.rows
+		}
+		lift := 0.0
+		if overall > 0 {
+			lift = rate / overall
+		}
+		ret.SetAttrStr(i, 0, s.attr)
+		ret.SetAttrStr(i, 1, s.name)
+		ret.Set(i, 2, s.rows)
+		ret.Set(i, 3, s.errors)
+		ret.Set(i, 4, lift)
+		ret.Set(i, 5, rate)
+	}
+	ret.Sort(base.Descending, []int{5})
+	return ret
+}
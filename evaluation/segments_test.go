@@ -0,0 +1,46 @@
+package evaluation
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sjwhitworth/golearn/base"
+)
+
+func TestSegmentErrors(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	// Get every Iris-virginica wrong
+	predictions := inst.GeneratePredictionVector()
+	for i := 0; i < inst.Rows; i++ {
+		if inst.GetClass(i) == "Iris-virginica" {
+			predictions.SetAttrStr(i, 0, "Iris-versicolor")
+		} else {
+			predictions.SetAttrStr(i, 0, inst.GetClass(i))
+		}
+	}
+	report := GetSegmentErrors(inst, predictions, 4)
+	if report.Cols != 6 || report.Rows < 3+4*2 {
+		testEnv.Fatal(report)
+	}
+	if report.Get(0, 5) != 1 {
+		testEnv.Fatal(report)
+	}
+	found := false
+	for i := 0; i < report.Rows; i++ {
+		if report.GetAttrStr(i, 0) == "Species" && report.GetAttrStr(i, 1) == "Iris-virginica" {
+			found = true
+			if report.Get(i, 2) != 50 || report.Get(i, 3) != 50 || math.Abs(report.Get(i, 4)-3) > 1e-9 {
+				testEnv.Error(report.RowStr(i))
+			}
+		}
+		if i > 0 && report.Get(i, 5) > report.Get(i-1, 5) {
+			testEnv.Errorf("Not sorted at row %d", i)
+		}
+	}
+	if !found {
+		testEnv.Error(report)
+	}
+}
@@ -0,0 +1,205 @@
+package evaluation
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/sjwhitworth/golearn/base"
+)
+
+// SignificanceTest is the outcome of a test of whether two
+// Classifiers perform differently: PValue is the probability of a
+// Statistic at least as extreme if they actually perform equally
+// well, so a small PValue (conventionally below 0.05) means the
+// difference is unlikely to be down to chance.
+type SignificanceTest struct {
+	Statistic float64
+	PValue    float64
+}
+
+// String returns the statistic and p-value
+func (t SignificanceTest) String() string {
+	return fmt.Sprintf("statistic %.4f, p-value %.4f", t.Statistic, t.PValue)
+}
+
+// McNemarTest compares two sets of predictions, first and second, of
+// the classes of the same rows of ref (e.g. out-of-fold predictions
+// from CrossValPredict). It only considers the rows which exactly one
+// of them gets right, and tests whether each is as likely to be the
+// one; the Statistic is chi-squared with one degree of freedom, with
+// a continuity correction. If they always agree on which rows they
+// get right, the PValue is 1.
+func McNemarTest(ref, first, second *base.Instances) SignificanceTest {
+	if ref.Rows != first.Rows || ref.Rows != second.Rows {
+		panic("Row counts should match")
+	}
+	onlyFirst, onlySecond := 0.0, 0.0
+	for i := 0; i < ref.Rows; i++ {
+		class := ref.GetClass(i)
+		firstRight, secondRight := first.GetClass(i) == class, second.GetClass(i) == class
+		if firstRight && !secondRight {
+			onlyFirst++
+		} else if secondRight && !firstRight {
+			onlySecond++
+		}
+	}
+	if onlyFirst+onlySecond == 0 {
+		return SignificanceTest{0, 1}
+	}
+	diff := math.Max(math.Abs(onlyFirst-onlySecond)-1, 0)
+	statistic := diff * diff / (onlyFirst + onlySecond)
+	return SignificanceTest{statistic, math.Erfc(math.Sqrt(statistic / 2))}
+}
+
+// CorrectedPairedTTest compares the accuracies of two Classifiers
+// cross-validated on the same folds, with Nadeau and Bengio's
+// corrected resampled t-test: a paired t-test of the difference in
+// each fold (first minus second), whose variance is inflated to
+// allow for the folds' training rows overlapping. The Statistic has
+// a t distribution with one degree of freedom fewer than there are
+// folds, and the PValue is two-sided. It returns an error if the
+// results aren't from the same number of folds of the same sizes.
+func CorrectedPairedTTest(first, second *CrossValidationResult) (SignificanceTest, error) {
+	if len(first.Folds) != len(second.Folds) || len(first.Folds) < 2 {
+		return SignificanceTest{}, fmt.Errorf("evaluation: can't compare %d folds with %d", len(first.Folds), len(second.Folds))
+	}
+	diffs := make([]float64, len(first.Folds))
+	trainRows, testRows := 0, 0
+	for i, f := range first.Folds {
+		s := second.Folds[i]
+		if f.TrainRows != s.TrainRows || f.TestRows != s.TestRows {
+			return SignificanceTest{}, fmt.Errorf("evaluation: fold %d has different rows in each result", i)
+		}
+		diffs[i] = f.Accuracy - s.Accuracy
+		trainRows += f.TrainRows
+		testRows += f.TestRows
+	}
+	mean, std := meanStd(diffs)
+	k := float64(len(diffs))
+	variance := (1/k + float64(testRows)/float64(trainRows)) * std * std
+	if variance == 0 {
+		if mean == 0 {
+			return SignificanceTest{0, 1}, nil
+		}
+		return SignificanceTest{math.Copysign(math.Inf(1), mean), 0}, nil
+	}
+	t := mean / math.Sqrt(variance)
+	return SignificanceTest{t, studentTPValue(t, k-1)}, nil
+}
+
+// studentTPValue returns the two-sided p-value of t under Student's
+// t distribution with df degrees of freedom
+func studentTPValue(t, df float64) float64 {
+	return regularisedIncompleteBeta(df/(df+t*t), df/2, 0.5)
+}
+
+// regularisedIncompleteBeta returns I_x(a, b), evaluated with a
+// continued fraction (as in Numerical Recipes)
+func regularisedIncompleteBeta(x, a, b float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	la, _ := math.Lgamma(a)
+	lb, _ := math.Lgamma(b)
+	lab, _ := math.Lgamma(a + b)
+	front := math.Exp(lab - la - lb + a*math.Log(x) + b*math.Log(1-x))
+	// The continued fraction converges quickly on this side of
+	// the mean; use the symmetry I_x(a, b) = 1 - I_1-x(b, a) on the
+	// other
+	if x > (a+1)/(a+b+2) {
+		return 1 - front*betaContinuedFraction(1-x, b, a)/b
+	}
+	return front * betaContinuedFraction(x, a, b) / a
+}
+
+// betaContinuedFraction evaluates the continued fraction for the
+// incomplete beta function with Lentz's method
+func betaContinuedFraction(x, a, b float64) float64 {
+	const tiny = 1e-300
+	c, d := 1.0, 1-(a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	ret := d
+	for m := 1.0; m <= 300; m++ {
+		// Even step
+		num := m * (b - m) * x / ((a + 2*m - 1) * (a + 2*m))
+		d = 1 + num*d
+		c = 1 + num/c
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		ret *= d * c
+		// Odd step
+		num = -(a + m) * (a + b + m) * x / ((a + 2*m) * (a + 2*m + 1))
+		d = 1 + num*d
+		c = 1 + num/c
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		ret *= delta
+		if math.Abs(delta-1) < 1e-12 {
+			break
+		}
+	}
+	return ret
+}
+
+// Comparison is the outcome of CompareClassifiers
+type Comparison struct {
+	First, Second *CrossValidationResult
+	// TTest is the corrected paired t-test of their accuracies in
+	// each fold
+	TTest SignificanceTest
+	// McNemar is McNemar's test of their out-of-fold predictions
+	McNemar SignificanceTest
+}
+
+// String describes the accuracy of each Classifier and the tests
+func (c *Comparison) String() string {
+	var buffer bytes.Buffer
+	buffer.WriteString(fmt.Sprintf("First accuracy:  %.4f (std %.4f)\n", c.First.Mean.Accuracy, c.First.Std.Accuracy))
+	buffer.WriteString(fmt.Sprintf("Second accuracy: %.4f (std %.4f)\n", c.Second.Mean.Accuracy, c.Second.Std.Accuracy))
+	buffer.WriteString(fmt.Sprintf("Corrected paired t-test: %s\n", c.TTest))
+	buffer.WriteString(fmt.Sprintf("McNemar's test: %s\n", c.McNemar))
+	return buffer.String()
+}
+
+// CompareClassifiers cross-validates the Classifiers created by
+// first and second on the same folds of data, determined by seed (see
+// CrossValidateWithSeed), and tests whether their accuracies differ
+// significantly, with both CorrectedPairedTTest and McNemarTest.
+func CompareClassifiers(first, second base.ClassifierFactory, data *base.Instances, folds int, seed int64) (*Comparison, error) {
+	if err := checkFolds(data.Rows, folds); err != nil {
+		return nil, err
+	}
+	partitions := generateFolds(data.Rows, folds, rand.New(rand.NewSource(seed)))
+	ret := &Comparison{}
+	var err error
+	if ret.First, err = CrossValidateWithFolds(first, data, partitions); err != nil {
+		return nil, err
+	}
+	if ret.Second, err = CrossValidateWithFolds(second, data, partitions); err != nil {
+		return nil, err
+	}
+	if ret.TTest, err = CorrectedPairedTTest(ret.First, ret.Second); err != nil {
+		return nil, err
+	}
+	ret.McNemar = McNemarTest(data, ret.First.Predictions, ret.Second.Predictions)
+	return ret, nil
+}
@@ -0,0 +1,94 @@
+package evaluation
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/sjwhitworth/golearn/base"
+	"github.com/sjwhitworth/golearn/knn"
+)
+
+// constantClassifier always predicts the first class
+type constantClassifier struct{}
+
+func (c *constantClassifier) Fit(*base.Instances) {}
+
+func (c *constantClassifier) Predict(what *base.Instances) *base.Instances {
+	return what.GeneratePredictionVector()
+}
+
+func (c *constantClassifier) String() string {
+	return "constantClassifier"
+}
+
+func TestStudentTPValue(testEnv *testing.T) {
+	// Critical values of the t distribution at the 5% level
+	for _, c := range []struct{ t, df float64 }{{12.706, 1}, {2.228, 10}, {2.042, 30}} {
+		if p := studentTPValue(c.t, c.df); math.Abs(p-0.05) > 1e-3 {
+			testEnv.Error(c, p)
+		}
+	}
+	if p := studentTPValue(0, 5); math.Abs(p-1) > 1e-9 {
+		testEnv.Error(p)
+	}
+}
+
+func TestMcNemarTest(testEnv *testing.T) {
+	attrs := []base.Attribute{base.NewCategoricalAttribute()}
+	ref := base.NewInstances(attrs, 20)
+	first := base.NewInstances(attrs, 20)
+	second := base.NewInstances(attrs, 20)
+	for i := 0; i < 20; i++ {
+		ref.SetAttrStr(i, 0, "a")
+		first.SetAttrStr(i, 0, "a")
+		second.SetAttrStr(i, 0, "a")
+	}
+	// Only the first gets 10 rows right, and only the second 2
+	for i := 0; i < 10; i++ {
+		second.SetAttrStr(i, 0, "b")
+	}
+	for i := 10; i < 12; i++ {
+		first.SetAttrStr(i, 0, "b")
+	}
+	test := McNemarTest(ref, first, second)
+	if math.Abs(test.Statistic-49.0/12) > 1e-9 || math.Abs(test.PValue-0.0433) > 1e-3 {
+		testEnv.Error(test)
+	}
+	if test := McNemarTest(ref, first, first); test.PValue != 1 {
+		testEnv.Error(test)
+	}
+}
+
+func TestCompareClassifiers(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	knnFactory := func() base.Classifier {
+		return knn.NewKnnClassifier("euclidean", 3)
+	}
+	constantFactory := func() base.Classifier {
+		return &constantClassifier{}
+	}
+	comparison, err := CompareClassifiers(knnFactory, constantFactory, inst, 10, 1)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if comparison.TTest.Statistic <= 0 || comparison.TTest.PValue > 0.01 || comparison.McNemar.PValue > 0.01 {
+		testEnv.Error(comparison)
+	}
+	if comparison.First.Predictions.Rows != inst.Rows || !strings.Contains(comparison.String(), "McNemar") {
+		testEnv.Error(comparison)
+	}
+
+	// KNN breaks ties by label, so the same Classifier on the same
+	// folds makes the same predictions
+	comparison, err = CompareClassifiers(knnFactory, knnFactory, inst, 10, 1)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if comparison.TTest.PValue != 1 || comparison.McNemar.PValue != 1 {
+		testEnv.Error(comparison)
+	}
+}
@@ -0,0 +1,91 @@
+package evaluation
+
+import (
+	"math"
+	"sort"
+
+	"github.com/sjwhitworth/golearn/base"
+)
+
+// ThresholdMetric scores predicting a class for the rows whose
+// probability of it is at least some threshold, given how many rows
+// that gets right and wrong. Higher is better.
+type ThresholdMetric func(truePositives, falsePositives, trueNegatives, falseNegatives float64) float64
+
+// F1Metric is the F1 score of the class (see GetF1Score)
+func F1Metric(tp, fp, tn, fn float64) float64 {
+	if tp == 0 {
+		return 0
+	}
+	return 2 * tp / (2*tp + fp + fn)
+}
+
+// YoudensJ is the true positive rate minus the false positive rate,
+// which is highest at the point of the ROC curve furthest above the
+// diagonal
+func YoudensJ(tp, fp, tn, fn float64) float64 {
+	j := 0.0
+	if tp+fn > 0 {
+		j += tp / (tp + fn)
+	}
+	if fp+tn > 0 {
+		j -= fp / (fp + tn)
+	}
+	return j
+}
+
+// CostWeightedAccuracy returns a ThresholdMetric for when a false
+// positive costs fpCost and a false negative fnCost (and correct
+// predictions nothing): one minus the mean cost per row, so that
+// it's the accuracy when both costs are one.
+func CostWeightedAccuracy(fpCost, fnCost float64) ThresholdMetric {
+	return func(tp, fp, tn, fn float64) float64 {
+		return 1 - (fpCost*fp+fnCost*fn)/(tp+fp+tn+fn)
+	}
+}
+
+// TuneThreshold finds the threshold at which to predict class for
+// the rows of ref, given their probabilities of it (e.g. from
+// CrossValPredict), which maximises metric, and returns it and its
+// score. Each distinct probability is tried, and ties go to the
+// higher threshold. If there are no rows, it returns NaN.
+func TuneThreshold(ref *base.Instances, probabilities []map[string]float64, class string, metric ThresholdMetric) (float64, float64) {
+	if ref.Rows != len(probabilities) {
+		panic("Row counts should match")
+	}
+	rows := make([]int, ref.Rows)
+	scores := make([]float64, ref.Rows)
+	positives, negatives := 0.0, 0.0
+	for i := range rows {
+		rows[i] = i
+		scores[i] = probabilities[i][class]
+		if ref.GetClass(i) == class {
+			positives++
+		} else {
+			negatives++
+		}
+	}
+	sort.Sort(sort.Reverse(&byScore{rows, scores}))
+
+	best, bestScore := math.NaN(), math.Inf(-1)
+	tp, fp := 0.0, 0.0
+	for start := 0; start < len(rows); {
+		end := start
+		for end < len(rows) && scores[rows[end]] == scores[rows[start]] {
+			if ref.GetClass(rows[end]) == class {
+				tp++
+			} else {
+				fp++
+			}
+			end++
+		}
+		if score := metric(tp, fp, negatives-fp, positives-tp); score > bestScore {
+			best, bestScore = scores[rows[start]], score
+		}
+		start = end
+	}
+	if math.IsNaN(best) {
+		return best, math.NaN()
+	}
+	return best, bestScore
+}
@@ -0,0 +1,33 @@
+package evaluation
+
+import (
+	"math"
+	"testing"
+
+	"github.com/sjwhitworth/golearn/base"
+)
+
+func TestTuneThreshold(testEnv *testing.T) {
+	attrs := []base.Attribute{base.NewCategoricalAttribute()}
+	ref := base.NewInstances(attrs, 8)
+	probabilities := make([]map[string]float64, 8)
+	for i, c := range []string{"yes", "yes", "no", "yes", "no", "no", "no", "no"} {
+		ref.SetAttrStr(i, 0, c)
+		probabilities[i] = map[string]float64{"yes": 0.9 - float64(i)/10}
+	}
+	// Everything down to the third "yes" gets F1 6/7; stopping at
+	// the second gets 0.8
+	threshold, score := TuneThreshold(ref, probabilities, "yes", F1Metric)
+	if math.Abs(threshold-0.6) > 1e-9 || math.Abs(score-6.0/7) > 1e-9 {
+		testEnv.Error(threshold, score)
+	}
+	threshold, score = TuneThreshold(ref, probabilities, "yes", YoudensJ)
+	if math.Abs(threshold-0.6) > 1e-9 || math.Abs(score-0.8) > 1e-9 {
+		testEnv.Error(threshold, score)
+	}
+	// When false positives cost a lot, predict fewer rows
+	threshold, _ = TuneThreshold(ref, probabilities, "yes", CostWeightedAccuracy(10, 1))
+	if math.Abs(threshold-0.8) > 1e-9 {
+		testEnv.Error(threshold)
+	}
+}
@@ -3,6 +3,7 @@ package filters
 import (
 	"fmt"
 	base "github.com/sjwhitworth/golearn/base"
+	stats "github.com/sjwhitworth/golearn/stats"
 	"math"
 )
 
@@ -44,7 +45,7 @@ func (b *BinningFilter) AddAttribute(a base.Attribute) {
 // to the BinningFilter for discretiation
 func (b *BinningFilter) AddAllNumericAttributes() {
 	for i := 0; i < b.Instances.Cols; i++ {
-		if i == b.Instances.ClassIndex {
+		if !b.Instances.IsFeature(i) {
 			continue
 		}
 		attr := b.Instances.GetAttr(i)
@@ -56,7 +57,7 @@ func (b *BinningFilter) AddAllNumericAttributes() {
 }
 
 // Build computes and stores the bin values
-// for the training instances.
+// for the training instances, ignoring missing values.
 func (b *BinningFilter) Build() {
 	for _, attr := range b.Attributes {
 		maxVal := math.Inf(-1)
@@ -76,8 +77,22 @@ func (b *BinningFilter) Build() {
 	}
 }
 
+// BuildFromStats computes and stores the bin values from
+// statistics gathered in a single pass over data which is too large
+// to load at once (e.g. with stats.StreamStats), rather than from
+// the training Instances.
+func (b *BinningFilter) BuildFromStats(s *stats.InstancesStats) {
+	for _, attr := range b.Attributes {
+		m := s.Moments(attr)
+		b.MaxVals[attr] = m.Max()
+		b.MinVals[attr] = m.Min()
+	}
+	b.trained = true
+}
+
 // Run applies a trained BinningFilter to a set of Instances,
-// discretising any numeric attributes added.
+// discretising any numeric attributes added. Missing values stay
+// missing.
 //
 // IMPORTANT: Run discretises in-place, so make sure to take
 // a copy if the original instances are still needed
@@ -92,7 +107,7 @@ func (b *BinningFilter) Run(on *base.Instances) {
 	if !b.trained {
 		panic("Call Build() beforehand")
 	}
-	for attr := range b.Attributes {
+	for _, attr := range b.Attributes {
 		minVal := b.MinVals[attr]
 		maxVal := b.MaxVals[attr]
 		disc := 0
@@ -101,6 +116,9 @@ func (b *BinningFilter) Run(on *base.Instances) {
 		delta /= float32(b.BinCount)
 		for i := 0; i < on.Rows; i++ {
 			val := on.Get(i, attr)
+			if base.IsMissingValue(val) {
+				continue
+			}
 			if val <= minVal {
 				disc = 0
 			} else {
@@ -119,3 +137,22 @@ func (b *BinningFilter) Run(on *base.Instances) {
 		on.ReplaceAttr(attr, newAttribute)
 	}
 }
+
+// Provenance returns where each Attribute of the Instances produced
+// by Run comes from: the binned Attributes are transformed, and the
+// others are passed through.
+func (b *BinningFilter) Provenance() []base.Provenance {
+	binned := make(map[int]bool)
+	for _, attr := range b.Attributes {
+		binned[attr] = true
+	}
+	ret := make([]base.Provenance, b.Instances.Cols)
+	for j := range ret {
+		name := b.Instances.GetAttr(j).GetName()
+		ret[j] = base.PassThrough(name)
+		if binned[j] {
+			ret[j].Transform = fmt.Sprintf("binned[%d]", b.BinCount)
+		}
+	}
+	return ret
+}
@@ -2,7 +2,10 @@ package filters
 
 import (
 	base "github.com/sjwhitworth/golearn/base"
+	stats "github.com/sjwhitworth/golearn/stats"
+	"io/ioutil"
 	"math"
+	"os"
 	"testing"
 )
 
@@ -26,3 +29,90 @@ func TestBinning(testEnv *testing.T) {
 		}
 	}
 }
+
+func TestBinningFromStats(testEnv *testing.T) {
+	inst1, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		panic(err)
+	}
+	stream, err := base.StreamCSV("../examples/datasets/iris_headers.csv", true, 50)
+	if err != nil {
+		panic(err)
+	}
+	defer stream.Close()
+	s, err := stats.StreamStats(stream, 20)
+	if err != nil {
+		panic(err)
+	}
+	filt1 := NewBinningFilter(inst1, 10)
+	filt1.AddAttribute(inst1.GetAttr(0))
+	filt1.Build()
+	filt2 := NewBinningFilter(inst1, 10)
+	filt2.AddAttribute(inst1.GetAttr(0))
+	filt2.BuildFromStats(s)
+	if filt1.MinVals[0] != filt2.MinVals[0] || filt1.MaxVals[0] != filt2.MaxVals[0] {
+		testEnv.Error(filt1.MinVals, filt2.MinVals, filt1.MaxVals, filt2.MaxVals)
+	}
+}
+
+func TestBinningProvenance(testEnv *testing.T) {
+	// The numeric column comes after a categorical one, and only it
+	// is binned
+	colour := base.NewCategoricalAttribute()
+	colour.SetName("colour")
+	x := base.NewFloatAttribute()
+	x.SetName("x")
+	class := base.NewCategoricalAttribute()
+	class.SetName("class")
+	inst := base.NewInstances([]base.Attribute{colour, x, class}, 4)
+	for i := 0; i < inst.Rows; i++ {
+		inst.SetAttrStr(i, 0, []string{"red", "blue"}[i%2])
+		inst.Set(i, 1, float64(i))
+		inst.SetAttrStr(i, 2, []string{"a", "b"}[i/2])
+	}
+	filt := NewBinningFilter(inst, 2)
+	filt.AddAllNumericAttributes()
+	filt.Build()
+	provenance := filt.Provenance()
+	filt.Run(inst)
+	for j, expected := range []string{"colour", "x→binned[2]", "class"} {
+		if s := provenance[j].String(); s != expected {
+			testEnv.Errorf("Column %d: %s, expected %s", j, s, expected)
+		}
+	}
+	if inst.GetAttr(1).GetType() != base.CategoricalType || inst.GetAttrStr(0, 1) != "0" || inst.GetAttrStr(3, 1) != "1" {
+		testEnv.Error("x wasn't binned")
+	}
+	if inst.GetAttrStr(0, 0) != "red" || inst.GetAttrStr(3, 2) != "b" {
+		testEnv.Error("Unbinned columns shouldn't change")
+	}
+}
+
+func TestBinningMissing(testEnv *testing.T) {
+	file, err := ioutil.TempFile("", "binning")
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString("x,class\n0,a\n?,a\n1,a\n3,b\n?,b\n4,b\n")
+	file.Close()
+	inst, err := base.ParseCSVToInstances(file.Name(), true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	filt := NewBinningFilter(inst, 2)
+	filt.AddAllNumericAttributes()
+	filt.Build()
+	if filt.MinVals[0] != 0 || filt.MaxVals[0] != 4 {
+		testEnv.Fatal(filt.MinVals, filt.MaxVals)
+	}
+	filt.Run(inst)
+	for i, expected := range []string{"0", "?", "0", "1", "?", "1"} {
+		if s := inst.GetAttrStr(i, 0); s != expected {
+			testEnv.Errorf("Row %d: %s, expected %s", i, s, expected)
+		}
+	}
+	if inst.CountMissing(0) != 2 {
+		testEnv.Error(inst.CountMissing(0))
+	}
+}
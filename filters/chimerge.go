@@ -4,6 +4,7 @@ import (
 	"fmt"
 	base "github.com/sjwhitworth/golearn/base"
 	"math"
+	"sort"
 )
 
 // ChiMergeFilter implements supervised discretisation
@@ -47,7 +48,7 @@ func (c *ChiMergeFilter) Build() {
 // to the ChiMergeFilter for discretisation
 func (b *ChiMergeFilter) AddAllNumericAttributes() {
 	for i := 0; i < b.Instances.Cols; i++ {
-		if i == b.Instances.ClassIndex {
+		if !b.Instances.IsFeature(i) {
 			continue
 		}
 		attr := b.Instances.GetAttr(i)
@@ -58,7 +59,8 @@ func (b *ChiMergeFilter) AddAllNumericAttributes() {
 	}
 }
 
-// Run discretises the set of Instances `on'
+// Run discretises the set of Instances `on'. Missing values stay
+// missing.
 //
 // IMPORTANT: ChiMergeFilter discretises in place.
 func (c *ChiMergeFilter) Run(on *base.Instances) {
@@ -69,9 +71,12 @@ func (c *ChiMergeFilter) Run(on *base.Instances) {
 		table := c.Tables[attr]
 		for i := 0; i < on.Rows; i++ {
 			val := on.Get(i, attr)
+			if base.IsMissingValue(val) {
+				continue
+			}
 			dis := 0
 			for j, k := range table {
-				if k.Value < val {
+				if k.Value <= val {
 					dis = j
 					continue
 				}
@@ -104,6 +109,26 @@ func (c *ChiMergeFilter) AddAttribute(attr base.Attribute) {
 	c.Attributes = append(c.Attributes, attrIndex)
 }
 
+// Provenance returns where each Attribute of the Instances produced
+// by Run comes from: the discretised Attributes are transformed
+// (into the given number of intervals), and the others are passed
+// through.
+//
+// IMPORTANT: This function panic()s if the filter hasn't been trained.
+func (c *ChiMergeFilter) Provenance() []base.Provenance {
+	if !c._Trained {
+		panic("Call Build() beforehand")
+	}
+	ret := make([]base.Provenance, c.Instances.Cols)
+	for j := range ret {
+		ret[j] = base.PassThrough(c.Instances.GetAttr(j).GetName())
+		if table, ok := c.Tables[j]; ok {
+			ret[j].Transform = fmt.Sprintf("chimerge[%d]", len(table))
+		}
+	}
+	return ret
+}
+
 type FrequencyTableEntry struct {
 	Value     float64
 	Frequency map[string]int
@@ -115,14 +140,16 @@ func (t *FrequencyTableEntry) String() string {
 
 func ChiMBuildFrequencyTable(attr int, inst *base.Instances) []*FrequencyTableEntry {
 	ret := make([]*FrequencyTableEntry, 0)
-	var attribute *base.FloatAttribute
-	attribute, ok := inst.GetAttr(attr).(*base.FloatAttribute)
-	if !ok {
+	// Any numeric Attribute (e.g. a TimeAttribute) is binned by its
+	// system representation
+	if inst.GetAttr(attr).GetType() != base.Float64Type {
 		panic("only use Chi-M on numeric stuff")
 	}
 	for i := 0; i < inst.Rows; i++ {
-		value := inst.Get(i, attr)
-		valueConv := attribute.GetUsrVal(value)
+		valueConv := inst.Get(i, attr)
+		if base.IsMissingValue(valueConv) || inst.IsMissing(i, inst.ClassIndex) {
+			continue
+		}
 		class := inst.GetClass(i)
 		// Search the frequency table for the value
 		found := false
@@ -141,10 +168,19 @@ func ChiMBuildFrequencyTable(attr int, inst *base.Instances) []*FrequencyTableEn
 			ret = append(ret, newEntry)
 		}
 	}
-
+	// Intervals are merged with their neighbours, so they have to
+	// be in order
+	sort.Sort(byValue(ret))
 	return ret
 }
 
+// byValue sorts FrequencyTableEntries in ascending order of Value
+type byValue []*FrequencyTableEntry
+
+func (b byValue) Len() int           { return len(b) }
+func (b byValue) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byValue) Less(i, j int) bool { return b[i].Value < b[j].Value }
+
 func chiSquaredPdf(k float64, x float64) float64 {
 	if x < 0 {
 		return 0
@@ -3,7 +3,9 @@ package filters
 import (
 	"fmt"
 	base "github.com/sjwhitworth/golearn/base"
+	"io/ioutil"
 	"math"
+	"os"
 	"testing"
 )
 
@@ -147,3 +149,115 @@ func TestChiMerge3(testEnv *testing.T) {
 	filt.Run(inst)
 	fmt.Println(inst)
 }
+
+func TestChiMergeDateColumn(testEnv *testing.T) {
+	// Dates are sniffed as TimeAttributes, which are numeric
+	file, err := ioutil.TempFile("", "chimerge")
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString("created,amount,class\n")
+	for day := 1; day <= 20; day++ {
+		class := "early"
+		if day > 10 {
+			class = "late"
+		}
+		fmt.Fprintf(file, "2015-03-%02d,%d.5,%s\n", day, day%3, class)
+	}
+	file.Close()
+	inst, err := base.ParseCSVToInstances(file.Name(), true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if _, ok := inst.GetAttr(0).(*base.TimeAttribute); !ok {
+		testEnv.Fatal(inst.GetAttr(0))
+	}
+
+	filt := NewChiMergeFilter(inst, 0.90)
+	filt.AddAllNumericAttributes()
+	if len(filt.Attributes) != 2 {
+		testEnv.Fatal(filt.Attributes)
+	}
+	filt.Build()
+	filt.Run(inst)
+	if _, ok := inst.GetAttr(0).(*base.CategoricalAttribute); !ok {
+		testEnv.Fatal(inst.GetAttr(0))
+	}
+	// The dates split where the class changes
+	if inst.GetAttrStr(0, 0) != inst.GetAttrStr(9, 0) || inst.GetAttrStr(0, 0) == inst.GetAttrStr(19, 0) {
+		testEnv.Error(inst.GetAttrStr(0, 0), inst.GetAttrStr(9, 0), inst.GetAttrStr(19, 0))
+	}
+}
+
+func TestChiMergeOrdinalColumn(testEnv *testing.T) {
+	size := base.NewOrdinalAttribute("xs", "s", "m", "l", "xl")
+	size.SetName("size")
+	class := base.NewCategoricalAttribute()
+	class.SetName("class")
+	inst := base.NewInstances([]base.Attribute{size, class}, 20)
+	for i := 0; i < inst.Rows; i++ {
+		inst.Set(i, 0, float64(i/4))
+		if i < 8 {
+			inst.SetAttrStr(i, 1, "small")
+		} else {
+			inst.SetAttrStr(i, 1, "large")
+		}
+	}
+	filt := NewChiMergeFilter(inst, 0.90)
+	filt.AddAllNumericAttributes()
+	if len(filt.Attributes) != 1 {
+		testEnv.Fatal(filt.Attributes)
+	}
+	filt.Build()
+	if len(filt.Tables[0]) != 2 {
+		testEnv.Error(filt.Tables[0])
+	}
+	filt.Run(inst)
+	if _, ok := inst.GetAttr(0).(*base.CategoricalAttribute); !ok {
+		testEnv.Fatal(inst.GetAttr(0))
+	}
+}
+
+func TestChiMergeMissing(testEnv *testing.T) {
+	file, err := ioutil.TempFile("", "chimerge")
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	defer os.Remove(file.Name())
+	file.WriteString("x,class\n")
+	for i := 0; i < 20; i++ {
+		class := "low"
+		if i >= 10 {
+			class = "high"
+		}
+		if i%5 == 2 {
+			fmt.Fprintf(file, "?,%s\n", class)
+		} else {
+			fmt.Fprintf(file, "%d,%s\n", i, class)
+		}
+	}
+	file.Close()
+	inst, err := base.ParseCSVToInstances(file.Name(), true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	filt := NewChiMergeFilter(inst, 0.90)
+	filt.AddAllNumericAttributes()
+	filt.Build()
+	// Missing values don't get an interval of their own
+	for _, entry := range filt.Tables[0] {
+		if base.IsMissingValue(entry.Value) {
+			testEnv.Fatal(filt.Tables[0])
+		}
+	}
+	filt.Run(inst)
+	for i := 0; i < inst.Rows; i++ {
+		if missing := inst.IsMissing(i, 0); missing != (i%5 == 2) {
+			testEnv.Errorf("Row %d: %s", i, inst.GetAttrStr(i, 0))
+		}
+	}
+	if inst.GetAttrStr(0, 0) == inst.GetAttrStr(19, 0) {
+		testEnv.Error(inst.GetAttrStr(0, 0), inst.GetAttrStr(19, 0))
+	}
+}
@@ -1,2 +1,14 @@
-//Package golearn is a machine learning library for Go.
+// Package golearn is a machine learning library for Go.
+//
+// Importing it makes every built-in Classifier available by name
+// through base.NewClassifier.
 package golearn
+
+import (
+	_ "github.com/sjwhitworth/golearn/ensemble"
+	_ "github.com/sjwhitworth/golearn/knn"
+	_ "github.com/sjwhitworth/golearn/linear_models"
+	_ "github.com/sjwhitworth/golearn/neural"
+	_ "github.com/sjwhitworth/golearn/svm"
+	_ "github.com/sjwhitworth/golearn/trees"
+)
@@ -0,0 +1,76 @@
+package kernels
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	pairwise "github.com/sjwhitworth/golearn/metrics/pairwise"
+)
+
+// randomData returns rows x cols standard normal values
+func randomData(rows, cols int, seed int64) *mat64.Dense {
+	rng := rand.New(rand.NewSource(seed))
+	ret := mat64.NewDense(rows, cols, nil)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			ret.Set(i, j, rng.NormFloat64())
+		}
+	}
+	return ret
+}
+
+// kernelError returns the mean absolute difference between the dot
+// products of the features of each pair of rows and the kernel
+// between them
+func kernelError(data, features *mat64.Dense, kernel Kernel) float64 {
+	rows, _ := data.Dims()
+	_, cols := features.Dims()
+	ret := 0.0
+	for i := 0; i < rows; i++ {
+		for j := 0; j < rows; j++ {
+			dot := 0.0
+			for k := 0; k < cols; k++ {
+				dot += features.At(i, k) * features.At(j, k)
+			}
+			expected := kernel.InnerProduct(rowVector(data, i), rowVector(data, j))
+			ret += math.Abs(dot-expected) / float64(rows*rows)
+		}
+	}
+	return ret
+}
+
+func TestNystroem(testEnv *testing.T) {
+	data := randomData(30, 3, 1)
+	kernel := pairwise.NewRBFKernel(0.5)
+
+	// With every row as a landmark, the kernel is reproduced
+	n := NewNystroem(kernel, 0, 1)
+	features := n.FitTransform(data)
+	if err := kernelError(data, features, kernel); err > 1e-6 {
+		testEnv.Error(err)
+	}
+
+	// With fewer, it's approximated
+	n = NewNystroem(kernel, 20, 1)
+	features = n.FitTransform(data)
+	if _, cols := features.Dims(); cols != 20 {
+		testEnv.Fatal(cols)
+	}
+	if err := kernelError(data, features, kernel); err > 0.05 {
+		testEnv.Error(err)
+	}
+}
+
+func TestRandomFourier(testEnv *testing.T) {
+	data := randomData(30, 3, 2)
+	f := NewRandomFourier(0.5, 2000, 1)
+	features := f.FitTransform(data)
+	if rows, cols := features.Dims(); rows != 30 || cols != 2000 {
+		testEnv.Fatal(rows, cols)
+	}
+	if err := kernelError(data, features, pairwise.NewRBFKernel(0.5)); err > 0.05 {
+		testEnv.Error(err)
+	}
+}
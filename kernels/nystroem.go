@@ -0,0 +1,134 @@
+// Package kernels approximates kernels such as the RBF kernel with
+// explicit feature maps, so that linear models trained on the
+// features behave like kernel machines without computing a kernel
+// matrix over every pair of rows.
+package kernels
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/gonum/matrix/mat64"
+	pca "github.com/sjwhitworth/golearn/pca"
+)
+
+// Kernel is implemented by the kernels in metrics/pairwise, e.g.
+// pairwise.RBFKernel. Vectors are passed as single-column matrices.
+type Kernel interface {
+	InnerProduct(vectorX *mat64.Dense, vectorY *mat64.Dense) float64
+}
+
+// Nystroem approximates a Kernel using a random sample of the
+// training rows as landmarks (Williams and Seeger, 2001). The dot
+// product of the features of two rows approximates the kernel
+// between them, and the approximation improves with the number of
+// Components. Unlike RandomFourier, it works with any Kernel.
+type Nystroem struct {
+	Kernel Kernel
+	// Components is the number of landmarks, and so of features.
+	Components int
+	Seed       int64
+	landmarks  []*mat64.Dense
+	// normalization is the inverse square root of the kernel
+	// matrix of the landmarks
+	normalization [][]float64
+}
+
+// NewNystroem returns a Nystroem which approximates kernel with the
+// given number of components, picking landmarks with the given seed.
+func NewNystroem(kernel Kernel, components int, seed int64) *Nystroem {
+	return &Nystroem{
+		Kernel:     kernel,
+		Components: components,
+		Seed:       seed,
+	}
+}
+
+// rowVector returns row i of data as a single-column matrix
+func rowVector(data *mat64.Dense, i int) *mat64.Dense {
+	_, cols := data.Dims()
+	ret := make([]float64, cols)
+	for j := range ret {
+		ret[j] = data.At(i, j)
+	}
+	return mat64.NewDense(cols, 1, ret)
+}
+
+// Fit picks the landmarks from the rows of data. If there are fewer
+// rows than Components, they're all used.
+func (n *Nystroem) Fit(data *mat64.Dense) {
+	rows, _ := data.Dims()
+	rng := rand.New(rand.NewSource(n.Seed))
+	perm := rng.Perm(rows)
+	if n.Components > 0 && n.Components < rows {
+		perm = perm[:n.Components]
+	}
+	n.landmarks = make([]*mat64.Dense, len(perm))
+	for k, i := range perm {
+		n.landmarks[k] = rowVector(data, i)
+	}
+
+	m := len(n.landmarks)
+	kernel := make([][]float64, m)
+	for i := range kernel {
+		kernel[i] = make([]float64, m)
+		for j := 0; j <= i; j++ {
+			kernel[i][j] = n.Kernel.InnerProduct(n.landmarks[i], n.landmarks[j])
+			kernel[j][i] = kernel[i][j]
+		}
+	}
+
+	// Compute the (pseudo-)inverse square root of the kernel
+	// matrix, ignoring directions with no variance
+	values, vectors := pca.SymmetricEigen(kernel)
+	n.normalization = make([][]float64, m)
+	for i := range n.normalization {
+		n.normalization[i] = make([]float64, m)
+	}
+	for k, v := range values {
+		if v <= 1e-12 {
+			continue
+		}
+		scale := 1 / math.Sqrt(v)
+		for i := 0; i < m; i++ {
+			for j := 0; j < m; j++ {
+				n.normalization[i][j] += vectors[i][k] * vectors[j][k] * scale
+			}
+		}
+	}
+}
+
+// Transform returns the features of each row of data, one column
+// per landmark.
+//
+// IMPORTANT: this function panic()s if Fit hasn't been called.
+func (n *Nystroem) Transform(data *mat64.Dense) *mat64.Dense {
+	if n.landmarks == nil {
+		panic("Call Fit() beforehand")
+	}
+	rows, _ := data.Dims()
+	m := len(n.landmarks)
+	ret := mat64.NewDense(rows, m, nil)
+	kernel := make([]float64, m)
+	for i := 0; i < rows; i++ {
+		row := rowVector(data, i)
+		for k, l := range n.landmarks {
+			kernel[k] = n.Kernel.InnerProduct(row, l)
+		}
+		for j := 0; j < m; j++ {
+			sum := 0.0
+			for k := 0; k < m; k++ {
+				sum += kernel[k] * n.normalization[k][j]
+			}
+			ret.Set(i, j, sum)
+		}
+	}
+	return ret
+}
+
+// FitTransform picks the landmarks from data and returns its
+// features.
+func (n *Nystroem) FitTransform(data *mat64.Dense) *mat64.Dense {
+	n.Fit(data)
+	return n.Transform(data)
+}
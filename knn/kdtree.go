@@ -0,0 +1,173 @@
+package knn
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+)
+
+// neighbour is a training row and its distance from a query
+type neighbour struct {
+	row      int
+	distance float64
+}
+
+// euclideanDistance returns the straight-line distance between a and b
+func euclideanDistance(a, b []float64) float64 {
+	sum := 0.0
+	for i := range a {
+		d := a[i] - b[i]
+		sum += d * d
+	}
+	return math.Sqrt(sum)
+}
+
+// manhattanDistance returns the sum of the absolute differences of
+// a and b
+func manhattanDistance(a, b []float64) float64 {
+	sum := 0.0
+	for i := range a {
+		sum += math.Abs(a[i] - b[i])
+	}
+	return sum
+}
+
+// kdTree indexes a set of points so that the nearest ones to a query
+// can be found without measuring the distance to each. Each node
+// splits the points below it at the median of the dimension along
+// which they're most spread out.
+type kdTree struct {
+	points [][]float64
+	root   *kdNode
+}
+
+// kdNode holds one point, and the points below it either side of
+// that point along dimension dim
+type kdNode struct {
+	row         int
+	dim         int
+	left, right *kdNode
+}
+
+// newKDTree builds a kdTree of points, which are referred to by
+// their index
+func newKDTree(points [][]float64) *kdTree {
+	rows := make([]int, len(points))
+	for i := range rows {
+		rows[i] = i
+	}
+	ret := &kdTree{points: points}
+	ret.root = ret.build(rows)
+	return ret
+}
+
+// build returns the subtree holding rows, which it reorders
+func (t *kdTree) build(rows []int) *kdNode {
+	if len(rows) == 0 {
+		return nil
+	}
+	dim, spread := 0, -1.0
+	for d := range t.points[rows[0]] {
+		lo, hi := math.Inf(1), math.Inf(-1)
+		for _, r := range rows {
+			lo = math.Min(lo, t.points[r][d])
+			hi = math.Max(hi, t.points[r][d])
+		}
+		if hi-lo > spread {
+			dim, spread = d, hi-lo
+		}
+	}
+	sort.Sort(&byCoordinate{rows, t.points, dim})
+	median := len(rows) / 2
+	return &kdNode{
+		row:   rows[median],
+		dim:   dim,
+		left:  t.build(rows[:median]),
+		right: t.build(rows[median+1:]),
+	}
+}
+
+// nearest returns the k points nearest query by distance (which must
+// be no less than the difference along any one dimension), nearest
+// first, leaving out the point exclude (if it's not -1).
+func (t *kdTree) nearest(query []float64, k int, distance func(a, b []float64) float64, exclude int) []neighbour {
+	found := &neighbourHeap{}
+	var search func(n *kdNode)
+	search = func(n *kdNode) {
+		if n == nil {
+			return
+		}
+		if n.row != exclude {
+			d := distance(t.points[n.row], query)
+			if found.Len() < k {
+				heap.Push(found, neighbour{n.row, d})
+			} else if d < (*found)[0].distance {
+				(*found)[0] = neighbour{n.row, d}
+				heap.Fix(found, 0)
+			}
+		}
+		diff := query[n.dim] - t.points[n.row][n.dim]
+		near, far := n.left, n.right
+		if diff > 0 {
+			near, far = far, near
+		}
+		search(near)
+		// The far side can only hold nearer points if the splitting
+		// plane is nearer than the furthest point found
+		if found.Len() < k || math.Abs(diff) < (*found)[0].distance {
+			search(far)
+		}
+	}
+	search(t.root)
+	ret := make([]neighbour, found.Len())
+	for i := len(ret) - 1; i >= 0; i-- {
+		ret[i] = heap.Pop(found).(neighbour)
+	}
+	return ret
+}
+
+// byCoordinate sorts rows by their value along one dimension
+type byCoordinate struct {
+	rows   []int
+	points [][]float64
+	dim    int
+}
+
+func (b *byCoordinate) Len() int {
+	return len(b.rows)
+}
+
+func (b *byCoordinate) Swap(i, j int) {
+	b.rows[i], b.rows[j] = b.rows[j], b.rows[i]
+}
+
+func (b *byCoordinate) Less(i, j int) bool {
+	return b.points[b.rows[i]][b.dim] < b.points[b.rows[j]][b.dim]
+}
+
+// neighbourHeap is a max-heap of neighbours by distance, so that the
+// furthest of those found so far can be replaced
+type neighbourHeap []neighbour
+
+func (h neighbourHeap) Len() int {
+	return len(h)
+}
+
+func (h neighbourHeap) Less(i, j int) bool {
+	return h[i].distance > h[j].distance
+}
+
+func (h neighbourHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+func (h *neighbourHeap) Push(x interface{}) {
+	*h = append(*h, x.(neighbour))
+}
+
+func (h *neighbourHeap) Pop() interface{} {
+	old := *h
+	ret := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return ret
+}
@@ -0,0 +1,107 @@
+package knn
+
+import (
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/sjwhitworth/golearn/base"
+)
+
+func TestKDTreeNearest(testEnv *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	points := make([][]float64, 500)
+	for i := range points {
+		points[i] = []float64{rng.Float64(), rng.Float64(), rng.Float64() * 10}
+	}
+	tree := newKDTree(points)
+	for _, distance := range []func(a, b []float64) float64{euclideanDistance, manhattanDistance} {
+		for q := 0; q < 20; q++ {
+			query := []float64{rng.Float64(), rng.Float64(), rng.Float64() * 10}
+			exclude := rng.Intn(len(points))
+			// Compare with measuring the distance to every point
+			expected := make([]float64, 0, len(points))
+			for i, p := range points {
+				if i != exclude {
+					expected = append(expected, distance(p, query))
+				}
+			}
+			sort.Float64s(expected)
+			found := tree.nearest(query, 7, distance, exclude)
+			if len(found) != 7 {
+				testEnv.Fatal(found)
+			}
+			for i, n := range found {
+				if n.row == exclude || n.distance != expected[i] {
+					testEnv.Fatalf("Neighbour %d is row %d at %f, not at %f", i, n.row, n.distance, expected[i])
+				}
+			}
+		}
+	}
+}
+
+func TestKnnClassifierKDTree(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	trainData, testData := base.InstancesTrainTestSplitWithSeed(inst, 0.5, 1)
+	for _, distfunc := range []string{"euclidean", "manhattan"} {
+		tree := NewKnnClassifier(distfunc, 5)
+		tree.Fit(trainData)
+		brute := NewKnnClassifier(distfunc, 5)
+		brute.Search = "brute"
+		brute.Fit(trainData)
+		if tree.tree == nil || brute.tree != nil {
+			testEnv.Fatal("Only the KD-tree classifier should build a tree")
+		}
+		treeProbs := tree.PredictProba(testData)
+		bruteProbs := brute.PredictProba(testData)
+		different := 0
+		for i := range treeProbs {
+			for c, p := range treeProbs[i] {
+				if bruteProbs[i][c] != p {
+					different++
+					break
+				}
+			}
+		}
+		// Rows can only differ where neighbours are tied
+		if different > testData.Rows/20 {
+			testEnv.Errorf("%d of %d rows differ with %s distances", different, testData.Rows, distfunc)
+		}
+	}
+}
+
+func TestKnnClassifierDistanceWeighted(testEnv *testing.T) {
+	attrs := []base.Attribute{base.NewFloatAttribute(), base.NewCategoricalAttribute()}
+	trainData := base.NewInstances(attrs, 3)
+	for i, row := range []struct {
+		x     float64
+		class string
+	}{{0, "a"}, {3, "b"}, {3.5, "b"}} {
+		trainData.Set(i, 0, row.x)
+		trainData.SetAttrStr(i, 1, row.class)
+	}
+	testData := base.NewInstances(attrs, 2)
+	testData.Set(0, 0, 1)
+	testData.Set(1, 0, 3)
+
+	cls := NewKnnClassifier("euclidean", 3)
+	cls.Fit(trainData)
+	if c := cls.Predict(testData).GetClass(0); c != "b" {
+		testEnv.Errorf("The majority should win without weighting, not %s", c)
+	}
+	cls = cls.Clone().(*KNNClassifier)
+	cls.DistanceWeighted = true
+	cls.Fit(trainData)
+	// a is at 1, and the bs at 2 and 2.5, so a gets 1 of 1.9 of the votes
+	probs := cls.PredictProba(testData)
+	if p := probs[0]["a"]; p < 0.526 || p > 0.527 {
+		testEnv.Error(probs[0])
+	}
+	// An exact match takes every vote
+	if probs[1]["b"] != 1 {
+		testEnv.Error(probs[1])
+	}
+}
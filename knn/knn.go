@@ -4,6 +4,11 @@
 package knn
 
 import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+
 	"github.com/gonum/matrix/mat64"
 	base "github.com/sjwhitworth/golearn/base"
 	pairwiseMetrics "github.com/sjwhitworth/golearn/metrics/pairwise"
@@ -11,12 +16,42 @@ import (
 )
 
 // A KNN Classifier. Consists of a data matrix, associated labels in the same order as the matrix, and a distance function.
-// The accepted distance functions at this time are 'euclidean' and 'manhattan'.
+// The accepted distance functions at this time are 'euclidean', 'manhattan' and 'cosine'.
+//
+// Search is how Fit indexes the training data, and so how the
+// neighbours of each row are found. "kdtree" (the default) builds a
+// KD-tree, so that each prediction only measures the distance to a
+// fraction of the training rows; KD-trees don't support cosine
+// distances, which measure the distance to every training row, like
+// "brute". "lsh" finds approximate neighbours with locality sensitive
+// hashing (see HashTables), which is much faster than a KD-tree when
+// there are many features, but may miss some of the nearest
+// neighbours.
+//
+// If DistanceWeighted is set, each neighbour's vote counts in inverse
+// proportion to its distance, so nearer neighbours count for more.
 type KNNClassifier struct {
 	base.BaseEstimator
 	TrainingData      *base.Instances
 	DistanceFunc      string
 	NearestNeighbours int
+	Search            string
+	DistanceWeighted  bool
+	// HashTables is the number of hash tables the "lsh" search
+	// looks neighbours up in (10), and HashBits the number of
+	// hashes each is keyed on (8). More tables find more of the
+	// nearest neighbours; more bits compare fewer rows.
+	HashTables int
+	HashBits   int
+	// BucketWidth is the width of the buckets Euclidean and
+	// Manhattan distances are hashed into. Zero means the mean distance
+	// between random pairs of training rows.
+	BucketWidth float64
+	// Seed makes the "lsh" search reproducible. Zero means a
+	// different random seed each time.
+	Seed int64
+	tree *kdTree
+	lsh  *lshIndex
 }
 
 // Returns a new classifier
@@ -27,19 +62,76 @@ func NewKnnClassifier(distfunc string, neighbours int) *KNNClassifier {
 	return &KNN
 }
 
-// Train stores the training data for llater
+func init() {
+	base.RegisterClassifier("knn", func() base.Classifier {
+		return NewKnnClassifier("euclidean", 1)
+	})
+}
+
+// Fit stores the training data for later, and indexes it as Search
+// says.
+//
+// IMPORTANT: panic()s if Search isn't one of those above.
 func (KNN *KNNClassifier) Fit(trainingData *base.Instances) {
 	KNN.TrainingData = trainingData
+	KNN.tree, KNN.lsh = nil, nil
+	search := KNN.Search
+	if search == "" {
+		search = "kdtree"
+	}
+	if search != "kdtree" && search != "brute" && search != "lsh" {
+		panic(fmt.Sprintf("knn: unknown search %q", KNN.Search))
+	}
+	if search == "brute" || KNN.distance() == nil || (search == "kdtree" && KNN.DistanceFunc == "cosine") {
+		return
+	}
+	points := make([][]float64, trainingData.Rows)
+	for i := range points {
+		points[i] = trainingData.GetRowVectorWithoutClass(i)
+	}
+	if search == "kdtree" {
+		KNN.tree = newKDTree(points)
+		return
+	}
+	tables, bits, seed := KNN.HashTables, KNN.HashBits, KNN.Seed
+	if tables == 0 {
+		tables = 10
+	}
+	if bits == 0 {
+		bits = 8
+	}
+	if seed == 0 {
+		seed = rand.Int63()
+	}
+	KNN.lsh = newLSHIndex(points, KNN.DistanceFunc, tables, bits, KNN.BucketWidth, rand.New(rand.NewSource(seed)))
 }
 
-// Returns a classification for the vector, based on a vector input, using the KNN algorithm.
-// See http://en.wikipedia.org/wiki/K-nearest_neighbors_algorithm.
-func (KNN *KNNClassifier) PredictOne(vector []float64) string {
+// distance returns the function which measures DistanceFunc, or nil
+// if it's not one of those above
+func (KNN *KNNClassifier) distance() func(a, b []float64) float64 {
+	switch KNN.DistanceFunc {
+	case "euclidean":
+		return euclideanDistance
+	case "manhattan":
+		return manhattanDistance
+	case "cosine":
+		return cosineDistance
+	}
+	return nil
+}
+
+// getNeighbours returns the vector's nearest neighbours, nearest
+// first, ignoring the training row exclude (if it's not -1).
+func (KNN *KNNClassifier) getNeighbours(vector []float64, exclude int) []neighbour {
+	if KNN.tree != nil {
+		return KNN.tree.nearest(vector, KNN.NearestNeighbours, KNN.distance(), exclude)
+	}
+	if KNN.lsh != nil {
+		return KNN.lsh.nearest(vector, KNN.NearestNeighbours, KNN.distance(), exclude)
+	}
 
 	rows := KNN.TrainingData.Rows
 	rownumbers := make(map[int]float64)
-	labels := make([]string, 0)
-	maxmap := make(map[string]int)
 
 	convertedVector := util.FloatsToMatrix(vector)
 
@@ -57,7 +149,7 @@ func (KNN *KNNClassifier) PredictOne(vector []float64) string {
 		}
 	case "manhattan":
 		{
-			manhattan := pairwiseMetrics.NewEuclidean()
+			manhattan := pairwiseMetrics.NewManhattan()
 			for i := 0; i < rows; i++ {
 				row := KNN.TrainingData.GetRowVectorWithoutClass(i)
 				rowMat := util.FloatsToMatrix(row)
@@ -65,25 +157,59 @@ func (KNN *KNNClassifier) PredictOne(vector []float64) string {
 				rownumbers[i] = distance
 			}
 		}
+	case "cosine":
+		for i := 0; i < rows; i++ {
+			rownumbers[i] = cosineDistance(KNN.TrainingData.GetRowVectorWithoutClass(i), vector)
+		}
 	}
 
+	delete(rownumbers, exclude)
 	sorted := util.SortIntMap(rownumbers)
-	values := sorted[:KNN.NearestNeighbours]
-
-	for _, elem := range values {
-		label := KNN.TrainingData.GetClass(elem)
-		labels = append(labels, label)
+	ret := make([]neighbour, KNN.NearestNeighbours)
+	for i, elem := range sorted[:KNN.NearestNeighbours] {
+		ret[i] = neighbour{elem, rownumbers[elem]}
+	}
+	return ret
+}
 
-		if _, ok := maxmap[label]; ok {
-			maxmap[label] += 1
-		} else {
-			maxmap[label] = 1
+// getNeighbourVotes returns the proportion of the votes of the
+// vector's nearest neighbours for each class label, ignoring the
+// training row exclude (if it's not -1). Each neighbour gets one vote
+// unless DistanceWeighted is set, when it gets the inverse of its
+// distance; if any neighbours are at no distance at all, only they
+// vote.
+func (KNN *KNNClassifier) getNeighbourVotes(vector []float64, exclude int) map[string]float64 {
+	neighbours := KNN.getNeighbours(vector, exclude)
+	exact := false
+	if KNN.DistanceWeighted {
+		for _, n := range neighbours {
+			exact = exact || n.distance == 0
 		}
 	}
 
-	sortedlabels := util.SortStringMap(maxmap)
-	label := sortedlabels[0]
+	votes := make(map[string]float64)
+	total := 0.0
+	for _, n := range neighbours {
+		weight := 1.0
+		if exact && n.distance != 0 {
+			continue
+		} else if KNN.DistanceWeighted && !exact {
+			weight = 1 / n.distance
+		}
+		votes[KNN.TrainingData.GetClass(n.row)] += weight
+		total += weight
+	}
+	for label := range votes {
+		votes[label] /= total
+	}
+	return votes
+}
 
+// Returns a classification for the vector, based on a vector input, using the KNN algorithm.
+// See http://en.wikipedia.org/wiki/K-nearest_neighbors_algorithm.
+// Tied votes go to the first label alphabetically.
+func (KNN *KNNClassifier) PredictOne(vector []float64) string {
+	label, _ := base.MostLikelyClass(KNN.getNeighbourVotes(vector, -1))
 	return label
 }
 
@@ -95,6 +221,64 @@ func (KNN *KNNClassifier) Predict(what *base.Instances) *base.Instances {
 	return ret
 }
 
+// PredictLeaveOneOut predicts each training row from its nearest
+// neighbours among the other training rows, which is the same as
+// leave-one-out cross-validation without having to retrain.
+func (KNN *KNNClassifier) PredictLeaveOneOut() *base.Instances {
+	ret := KNN.TrainingData.GeneratePredictionVector()
+	for i := 0; i < KNN.TrainingData.Rows; i++ {
+		label, _ := base.MostLikelyClass(KNN.getNeighbourVotes(KNN.TrainingData.GetRowVectorWithoutClass(i), i))
+		ret.SetAttrStr(i, 0, label)
+	}
+	return ret
+}
+
+// PredictProba returns, for each row, the fraction of its nearest
+// neighbours' votes for each class.
+func (KNN *KNNClassifier) PredictProba(what *base.Instances) []map[string]float64 {
+	ret := make([]map[string]float64, what.Rows)
+	for i := 0; i < what.Rows; i++ {
+		ret[i] = KNN.getNeighbourVotes(what.GetRowVectorWithoutClass(i), -1)
+	}
+	return ret
+}
+
+// Clone returns an untrained KNNClassifier with the same distance
+// function, number of neighbours and options
+func (KNN *KNNClassifier) Clone() base.Classifier {
+	ret := NewKnnClassifier(KNN.DistanceFunc, KNN.NearestNeighbours)
+	ret.Search = KNN.Search
+	ret.DistanceWeighted = KNN.DistanceWeighted
+	ret.HashTables = KNN.HashTables
+	ret.HashBits = KNN.HashBits
+	ret.BucketWidth = KNN.BucketWidth
+	ret.Seed = KNN.Seed
+	return ret
+}
+
+// String returns a human-readable summary of this KNNClassifier
+func (KNN *KNNClassifier) String() string {
+	return fmt.Sprintf("KNNClassifier(%s, %d)", KNN.DistanceFunc, KNN.NearestNeighbours)
+}
+
+// Summary returns the KNNClassifier's parameters and the class
+// distribution of its training data
+func (KNN *KNNClassifier) Summary() string {
+	if KNN.TrainingData == nil {
+		return fmt.Sprintf("%s\nUntrained\n", KNN)
+	}
+	dist := KNN.TrainingData.GetClassDistribution()
+	classes := make([]string, 0, len(dist))
+	for c := range dist {
+		classes = append(classes, c)
+	}
+	sort.Strings(classes)
+	for i, c := range classes {
+		classes[i] = fmt.Sprintf("%s (%d)", c, dist[c])
+	}
+	return fmt.Sprintf("%s\nTrained on %d row(s): %s\n", KNN, KNN.TrainingData.Rows, strings.Join(classes, ", "))
+}
+
 //A KNN Regressor. Consists of a data matrix, associated result variables in the same order as the matrix, and a name.
 type KNNRegressor struct {
 	base.BaseEstimator
@@ -118,6 +302,12 @@ func (KNN *KNNRegressor) Fit(values []float64, numbers []float64, rows int, cols
 	KNN.Values = values
 }
 
+// Clone returns an untrained KNNRegressor with the same distance
+// function
+func (KNN *KNNRegressor) Clone() *KNNRegressor {
+	return NewKnnRegressor(KNN.DistanceFunc)
+}
+
 func (KNN *KNNRegressor) Predict(vector *mat64.Dense, K int) float64 {
 
 	// Get the number of rows
@@ -1,6 +1,7 @@
 package knn
 
 import (
+	"github.com/gonum/matrix/mat64"
 	"github.com/sjwhitworth/golearn/base"
 	. "github.com/smartystreets/goconvey/convey"
 	"testing"
@@ -40,3 +41,47 @@ func TestKnnClassifier(t *testing.T) {
 		})
 	})
 }
+
+func TestKnnOrdinal(testEnv *testing.T) {
+	// Levels are as far apart as their ranks, so "s" is nearest
+	// "xs" and "l" nearest "xl"
+	size := base.NewOrdinalAttribute("xs", "s", "m", "l", "xl")
+	size.SetName("size")
+	class := base.NewCategoricalAttribute()
+	class.SetName("class")
+	train := base.NewInstances([]base.Attribute{size, class}, 2)
+	train.SetAttrStr(0, 0, "xs")
+	train.SetAttrStr(0, 1, "small")
+	train.SetAttrStr(1, 0, "xl")
+	train.SetAttrStr(1, 1, "large")
+	test := base.NewInstances([]base.Attribute{size, class}, 2)
+	test.SetAttrStr(0, 0, "s")
+	test.SetAttrStr(1, 0, "l")
+
+	for _, search := range []string{"kdtree", "brute"} {
+		cls := NewKnnClassifier("euclidean", 1)
+		cls.Search = search
+		cls.Fit(train)
+		predictions := cls.Predict(test)
+		if predictions.GetClass(0) != "small" || predictions.GetClass(1) != "large" {
+			testEnv.Errorf("%s: predicted %s and %s", search, predictions.GetClass(0), predictions.GetClass(1))
+		}
+	}
+}
+
+func TestKnnRegressorClone(testEnv *testing.T) {
+	reg := NewKnnRegressor("manhattan")
+	reg.Fit([]float64{1, 2, 3}, []float64{0, 0, 1, 1, 5, 5}, 3, 2)
+	clone := reg.Clone()
+	if clone == reg || clone.DistanceFunc != "manhattan" {
+		testEnv.Fatal(clone)
+	}
+	if clone.Data != nil || clone.Values != nil {
+		testEnv.Error("Clone shouldn't be trained")
+	}
+	clone.Fit([]float64{1, 2, 3}, []float64{0, 0, 1, 1, 5, 5}, 3, 2)
+	query := mat64.NewDense(1, 2, []float64{4, 4})
+	if got, want := clone.Predict(query, 1), reg.Predict(query, 1); got != want {
+		testEnv.Error(got, want)
+	}
+}
@@ -0,0 +1,166 @@
+package knn
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+)
+
+// cosineDistance returns one minus the cosine of the angle between a
+// and b, or one if either is all zeros
+func cosineDistance(a, b []float64) float64 {
+	dot, normA, normB := 0.0, 0.0, 0.0
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 1
+	}
+	return 1 - dot/math.Sqrt(normA*normB)
+}
+
+// lshIndex finds approximate nearest neighbours with locality
+// sensitive hashing: each of several tables hashes every point by
+// which side of some random hyperplanes it falls (for cosine
+// distances), or which bucket of some random projections it falls in
+// (for Euclidean and Manhattan distances, with Gaussian and Cauchy
+// projections, which are 2- and 1-stable). Near points are likely to
+// share a bucket in at least one table, and only the points which
+// share one with a query are compared with it.
+type lshIndex struct {
+	points [][]float64
+	cosine bool
+	// projections holds the random vectors of each table, and
+	// offsets the random offset of each bucket boundary
+	projections [][][]float64
+	offsets     [][]float64
+	width       float64
+	tables      []map[string][]int
+}
+
+// newLSHIndex hashes points into tables tables, keyed on bits hashes
+// each, for the given distance function. width is the width of the
+// buckets of random projections; if it's zero, it's the mean distance
+// between random pairs of points.
+func newLSHIndex(points [][]float64, distfunc string, tables, bits int, width float64, rng *rand.Rand) *lshIndex {
+	ret := &lshIndex{
+		points:      points,
+		cosine:      distfunc == "cosine",
+		projections: make([][][]float64, tables),
+		offsets:     make([][]float64, tables),
+		tables:      make([]map[string][]int, tables),
+	}
+	draw := rng.NormFloat64
+	distance := euclideanDistance
+	if distfunc == "manhattan" {
+		draw = func() float64 {
+			return math.Tan(math.Pi * (rng.Float64() - 0.5))
+		}
+		distance = manhattanDistance
+	}
+	if width == 0 && !ret.cosine && len(points) > 1 {
+		for i := 0; i < 100; i++ {
+			width += distance(points[rng.Intn(len(points))], points[rng.Intn(len(points))])
+		}
+		width /= 100
+	}
+	if width == 0 {
+		width = 1
+	}
+	ret.width = width
+
+	dims := 0
+	if len(points) > 0 {
+		dims = len(points[0])
+	}
+	for t := range ret.tables {
+		ret.projections[t] = make([][]float64, bits)
+		ret.offsets[t] = make([]float64, bits)
+		for b := range ret.projections[t] {
+			ret.projections[t][b] = make([]float64, dims)
+			for i := range ret.projections[t][b] {
+				ret.projections[t][b][i] = draw()
+			}
+			ret.offsets[t][b] = rng.Float64() * width
+		}
+		ret.tables[t] = make(map[string][]int)
+		for r, p := range points {
+			key := ret.key(t, p)
+			ret.tables[t][key] = append(ret.tables[t][key], r)
+		}
+	}
+	return ret
+}
+
+// key returns the bucket of table t which point falls in
+func (l *lshIndex) key(t int, point []float64) string {
+	buf := make([]byte, 0, 4*len(l.projections[t]))
+	for b, projection := range l.projections[t] {
+		dot := 0.0
+		for i, v := range projection {
+			dot += v * point[i]
+		}
+		if l.cosine {
+			if dot >= 0 {
+				buf = append(buf, '1')
+			} else {
+				buf = append(buf, '0')
+			}
+			continue
+		}
+		buf = strconv.AppendInt(buf, int64(math.Floor((dot+l.offsets[t][b])/l.width)), 10)
+		buf = append(buf, ',')
+	}
+	return string(buf)
+}
+
+// nearest returns the k points nearest query by distance among those
+// which share a bucket with it, nearest first, leaving out the point
+// exclude (if it's not -1). If fewer than k do, every point is
+// compared with it instead.
+func (l *lshIndex) nearest(query []float64, k int, distance func(a, b []float64) float64, exclude int) []neighbour {
+	seen := make(map[int]bool)
+	candidates := make([]neighbour, 0)
+	for t := range l.tables {
+		for _, r := range l.tables[t][l.key(t, query)] {
+			if r != exclude && !seen[r] {
+				seen[r] = true
+				candidates = append(candidates, neighbour{r, distance(l.points[r], query)})
+			}
+		}
+	}
+	if len(candidates) < k {
+		candidates = candidates[:0]
+		for r, p := range l.points {
+			if r != exclude {
+				candidates = append(candidates, neighbour{r, distance(p, query)})
+			}
+		}
+	}
+	sort.Sort(byDistance(candidates))
+	if len(candidates) > k {
+		candidates = candidates[:k]
+	}
+	return candidates
+}
+
+// byDistance sorts neighbours, nearest first, then by row
+type byDistance []neighbour
+
+func (b byDistance) Len() int {
+	return len(b)
+}
+
+func (b byDistance) Swap(i, j int) {
+	b[i], b[j] = b[j], b[i]
+}
+
+func (b byDistance) Less(i, j int) bool {
+	if b[i].distance != b[j].distance {
+		return b[i].distance < b[j].distance
+	}
+	return b[i].row < b[j].row
+}
@@ -0,0 +1,105 @@
+package knn
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/sjwhitworth/golearn/base"
+)
+
+func TestLSHNearest(testEnv *testing.T) {
+	// Clusters of points in 50 dimensions, where most of each
+	// point's nearest neighbours are in its own cluster
+	rng := rand.New(rand.NewSource(1))
+	centres := make([][]float64, 20)
+	for c := range centres {
+		centres[c] = make([]float64, 50)
+		for i := range centres[c] {
+			centres[c][i] = rng.NormFloat64() * 10
+		}
+	}
+	points := make([][]float64, 2000)
+	for r := range points {
+		points[r] = make([]float64, 50)
+		for i := range points[r] {
+			points[r][i] = centres[r%20][i] + rng.NormFloat64()
+		}
+	}
+	exact := newKDTree(points)
+	for _, distfunc := range []string{"euclidean", "manhattan", "cosine"} {
+		index := newLSHIndex(points, distfunc, 10, 8, 0, rng)
+		distance := (&KNNClassifier{DistanceFunc: distfunc}).distance()
+		found, total := 0, 0
+		for q := 0; q < 50; q++ {
+			r := rng.Intn(len(points))
+			approximate := index.nearest(points[r], 10, distance, r)
+			if len(approximate) != 10 {
+				testEnv.Fatal(approximate)
+			}
+			for i := 1; i < len(approximate); i++ {
+				if approximate[i].distance < approximate[i-1].distance {
+					testEnv.Fatalf("Neighbours out of order: %v", approximate)
+				}
+			}
+			nearest := make(map[int]bool)
+			var want []neighbour
+			if distfunc == "cosine" {
+				want = (&lshIndex{points: points}).nearest(points[r], 10, distance, r)
+			} else {
+				want = exact.nearest(points[r], 10, distance, r)
+			}
+			for _, n := range want {
+				nearest[n.row] = true
+			}
+			for _, n := range approximate {
+				if nearest[n.row] {
+					found++
+				}
+			}
+			total += len(want)
+		}
+		if recall := float64(found) / float64(total); recall < 0.8 {
+			testEnv.Errorf("Found %.2f of the nearest neighbours with %s distances", recall, distfunc)
+		}
+	}
+}
+
+func TestKnnClassifierLSH(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	trainData, testData := base.InstancesTrainTestSplitWithSeed(inst, 0.5, 1)
+	for _, distfunc := range []string{"euclidean", "cosine"} {
+		cls := NewKnnClassifier(distfunc, 5)
+		cls.Search = "lsh"
+		cls.Seed = 1
+		cls.Fit(trainData)
+		if cls.lsh == nil {
+			testEnv.Fatal("Should have hashed the training data")
+		}
+		predictions := cls.Predict(testData)
+		correct := 0
+		for i := 0; i < testData.Rows; i++ {
+			if predictions.GetClass(i) == testData.GetClass(i) {
+				correct++
+			}
+		}
+		if accuracy := float64(correct) / float64(testData.Rows); accuracy < 0.85 {
+			testEnv.Errorf("Accuracy %.2f with %s distances", accuracy, distfunc)
+		}
+		clone := cls.Clone().(*KNNClassifier)
+		if clone.Search != "lsh" || clone.Seed != 1 || clone.lsh != nil {
+			testEnv.Error(clone)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			testEnv.Error("Should panic with an unknown search")
+		}
+	}()
+	cls := NewKnnClassifier("euclidean", 5)
+	cls.Search = "ball tree"
+	cls.Fit(trainData)
+}
@@ -0,0 +1,447 @@
+package linear_models
+
+import (
+	"encoding/gob"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	base "github.com/sjwhitworth/golearn/base"
+	eval "github.com/sjwhitworth/golearn/evaluation"
+)
+
+// ElasticNetParams holds the parameters of ElasticNet.
+type ElasticNetParams struct {
+	// Lambda is the strength of the penalty on the weights. Zero
+	// means ordinary least squares.
+	Lambda float64
+	// L1Ratio is the share of the penalty on the absolute values of
+	// the weights, which drives some of them to zero (the lasso);
+	// the rest is on their squares, which shrinks them all (ridge
+	// regression)
+	L1Ratio float64
+	// MaxIterations is the most passes coordinate descent makes
+	// over the features
+	MaxIterations int
+	// Tolerance stops coordinate descent once no (standardised)
+	// weight changes by more in a pass
+	Tolerance float64
+	// Folds, if it's at least two, makes Fit choose the Lambda
+	// with the lowest cross-validated squared error from those of
+	// RegularisationPath (see ElasticNet.CVErrors)
+	Folds int
+	// Seed determines the folds. Zero means a different random
+	// seed each time.
+	Seed int64
+}
+
+// DefaultElasticNetParams returns the ElasticNetParams used by
+// NewElasticNet before its arguments are applied.
+func DefaultElasticNetParams() ElasticNetParams {
+	return ElasticNetParams{
+		Lambda:        1.0,
+		L1Ratio:       0.5,
+		MaxIterations: 1000,
+		Tolerance:     1e-6,
+	}
+}
+
+// Validate checks that the ElasticNetParams are usable.
+func (p ElasticNetParams) Validate() error {
+	if p.Lambda < 0 {
+		return fmt.Errorf("linear_models: Lambda can't be negative, got %f", p.Lambda)
+	}
+	if p.L1Ratio < 0 || p.L1Ratio > 1 {
+		return fmt.Errorf("linear_models: L1Ratio should be in [0, 1], got %f", p.L1Ratio)
+	}
+	if p.MaxIterations < 1 {
+		return fmt.Errorf("linear_models: MaxIterations should be at least 1, got %d", p.MaxIterations)
+	}
+	if p.Tolerance < 0 {
+		return fmt.Errorf("linear_models: Tolerance can't be negative, got %f", p.Tolerance)
+	}
+	if p.Folds == 1 || p.Folds < 0 {
+		return fmt.Errorf("linear_models: need at least 2 Folds, got %d", p.Folds)
+	}
+	return nil
+}
+
+// ElasticNet is a linear regression whose weights are penalised by a
+// mix of their absolute values and their squares, to stop it
+// overfitting when there are many features. It minimises
+//
+//	1/2 * mean squared error + Lambda * (L1Ratio * sum |w| + (1 - L1Ratio) / 2 * sum w^2)
+//
+// by coordinate descent over the standardised features, as in
+// glmnet, so the penalty doesn't depend on the features' scales. The
+// intercept isn't penalised, and rows count as much as their weights
+// (see base.Instances.SetWeight). Features are used by their system
+// representation, with missing values as zero, and the class must be
+// numeric.
+type ElasticNet struct {
+	base.BaseClassifier
+	ElasticNetParams
+	// Weights holds the weight of each feature, and Intercept the
+	// prediction when they're all zero
+	Weights   []float64
+	Intercept float64
+	// Attributes holds the features trained on
+	Attributes []base.Attribute
+	// Lambdas and CVErrors hold the Lambdas tried and their mean
+	// squared errors when Fit cross-validates (see Folds)
+	Lambdas  []float64
+	CVErrors []float64
+}
+
+// NewElasticNet returns an ElasticNet with the given penalty and
+// L1Ratio, and the DefaultElasticNetParams otherwise.
+func NewElasticNet(lambda, l1Ratio float64) *ElasticNet {
+	params := DefaultElasticNetParams()
+	params.Lambda = lambda
+	params.L1Ratio = l1Ratio
+	return &ElasticNet{ElasticNetParams: params}
+}
+
+// NewRidge returns an ElasticNet which only penalises the squares of
+// the weights (ridge regression).
+func NewRidge(lambda float64) *ElasticNet {
+	return NewElasticNet(lambda, 0)
+}
+
+// NewLasso returns an ElasticNet which only penalises the absolute
+// values of the weights (the lasso).
+func NewLasso(lambda float64) *ElasticNet {
+	return NewElasticNet(lambda, 1)
+}
+
+// NewElasticNetFromParams returns a new ElasticNet with the given
+// parameters, or an error if they're invalid.
+func NewElasticNetFromParams(params ElasticNetParams) (*ElasticNet, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	return &ElasticNet{ElasticNetParams: params}, nil
+}
+
+func init() {
+	base.RegisterClassifier("elasticnet", func() base.Classifier {
+		return NewElasticNet(1, 0.5)
+	})
+	base.RegisterClassifier("ridge", func() base.Classifier {
+		return NewRidge(1)
+	})
+	base.RegisterClassifier("lasso", func() base.Classifier {
+		return NewLasso(1)
+	})
+	gob.Register(&ElasticNet{})
+}
+
+// design holds some training rows with standardised features, laid
+// out by feature, for coordinate descent
+type design struct {
+	// z holds the standardised value of each feature of each row,
+	// and v the share of the total weight each row has
+	z [][]float64
+	v []float64
+	// means and sds are those of each feature, which are
+	// standardised to a (weighted) mean of zero and a standard
+	// deviation of one; constant features have a zero sd
+	means, sds []float64
+	y          []float64
+	yMean      float64
+}
+
+// newDesign standardises the given rows of on, using columns cols
+// as the features.
+//
+// IMPORTANT: this function panic()s if the class isn't numeric.
+func newDesign(on *base.Instances, cols, rows []int) *design {
+	if on.GetClassAttr().GetType() != base.Float64Type {
+		panic("linear_models: regression needs a numeric class")
+	}
+	d := &design{
+		z:     make([][]float64, len(cols)),
+		v:     make([]float64, len(rows)),
+		means: make([]float64, len(cols)),
+		sds:   make([]float64, len(cols)),
+		y:     make([]float64, len(rows)),
+	}
+	total := 0.0
+	for k, i := range rows {
+		d.v[k] = on.GetWeight(i)
+		total += d.v[k]
+	}
+	for k, i := range rows {
+		d.v[k] /= total
+		d.y[k] = on.Get(i, on.ClassIndex)
+		d.yMean += d.v[k] * d.y[k]
+	}
+	for j := range cols {
+		d.z[j] = make([]float64, len(rows))
+	}
+	for k, i := range rows {
+		for j, x := range features(on, i, cols) {
+			d.z[j][k] = x
+			d.means[j] += d.v[k] * x
+		}
+	}
+	for j := range cols {
+		variance := 0.0
+		for k, x := range d.z[j] {
+			variance += d.v[k] * (x - d.means[j]) * (x - d.means[j])
+		}
+		if variance > 1e-12 {
+			d.sds[j] = math.Sqrt(variance)
+		}
+		for k, x := range d.z[j] {
+			if d.sds[j] > 0 {
+				d.z[j][k] = (x - d.means[j]) / d.sds[j]
+			} else {
+				d.z[j][k] = 0
+			}
+		}
+	}
+	return d
+}
+
+// descend runs coordinate descent from the standardised weights
+// beta, updating them.
+func (d *design) descend(beta []float64, lambda, l1Ratio float64, maxIterations int, tolerance float64) {
+	residuals := make([]float64, len(d.y))
+	for k, y := range d.y {
+		residuals[k] = y - d.yMean
+		for j := range beta {
+			residuals[k] -= d.z[j][k] * beta[j]
+		}
+	}
+	l1, l2 := lambda*l1Ratio, lambda*(1-l1Ratio)
+	for iteration := 0; iteration < maxIterations; iteration++ {
+		maxChange := 0.0
+		for j := range beta {
+			if d.sds[j] == 0 {
+				continue
+			}
+			rho := beta[j]
+			for k, z := range d.z[j] {
+				rho += d.v[k] * z * residuals[k]
+			}
+			// Soft-thresholding
+			updated := 0.0
+			if rho > l1 {
+				updated = (rho - l1) / (1 + l2)
+			} else if rho < -l1 {
+				updated = (rho + l1) / (1 + l2)
+			}
+			if change := updated - beta[j]; change != 0 {
+				for k, z := range d.z[j] {
+					residuals[k] -= z * change
+				}
+				maxChange = math.Max(maxChange, math.Abs(change))
+				beta[j] = updated
+			}
+		}
+		if maxChange <= tolerance {
+			return
+		}
+	}
+}
+
+// lambdaMax returns the smallest Lambda at which every weight is
+// zero, treating ridge regression as if L1Ratio were 0.001
+func (d *design) lambdaMax(l1Ratio float64) float64 {
+	ret := 0.0
+	for j := range d.z {
+		dot := 0.0
+		for k, z := range d.z[j] {
+			dot += d.v[k] * z * (d.y[k] - d.yMean)
+		}
+		ret = math.Max(ret, math.Abs(dot))
+	}
+	return ret / math.Max(l1Ratio, 1e-3)
+}
+
+// unstandardise returns the weights and intercept on the original
+// scale of the features
+func (d *design) unstandardise(beta []float64) ([]float64, float64) {
+	weights := make([]float64, len(beta))
+	intercept := d.yMean
+	for j, b := range beta {
+		if d.sds[j] > 0 {
+			weights[j] = b / d.sds[j]
+			intercept -= weights[j] * d.means[j]
+		}
+	}
+	return weights, intercept
+}
+
+// defaultLambdas returns 100 Lambdas from lambdaMax down to a
+// thousandth of it, evenly spaced on a log scale
+func (d *design) defaultLambdas(l1Ratio float64) []float64 {
+	max := d.lambdaMax(l1Ratio)
+	if max == 0 {
+		return []float64{0}
+	}
+	ret := make([]float64, 100)
+	for i := range ret {
+		ret[i] = max * math.Pow(1e-3, float64(i)/float64(len(ret)-1))
+	}
+	return ret
+}
+
+// allRows returns the indices of every row of on
+func allRows(on *base.Instances) []int {
+	ret := make([]int, on.Rows)
+	for i := range ret {
+		ret[i] = i
+	}
+	return ret
+}
+
+// path fits the weights for each of lambdas in turn, starting each
+// from the weights of the last, and returns the weights and
+// intercepts on the original scale
+func (d *design) path(p ElasticNetParams, lambdas []float64) ([][]float64, []float64) {
+	beta := make([]float64, len(d.z))
+	weights := make([][]float64, len(lambdas))
+	intercepts := make([]float64, len(lambdas))
+	for i, lambda := range lambdas {
+		d.descend(beta, lambda, p.L1Ratio, p.MaxIterations, p.Tolerance)
+		weights[i], intercepts[i] = d.unstandardise(beta)
+	}
+	return weights, intercepts
+}
+
+// RegularisationPath fits an ElasticNet with the given parameters for
+// each of lambdas, which are sorted from the largest down, starting
+// each from the weights of the last, which is much quicker than
+// fitting them separately. If lambdas is nil, it uses 100 Lambdas
+// from the smallest which makes every weight zero down to a thousandth
+// of it, evenly spaced on a log scale. params.Folds is ignored.
+//
+// IMPORTANT: this function panic()s if the class isn't numeric.
+func RegularisationPath(on *base.Instances, params ElasticNetParams, lambdas []float64) ([]*ElasticNet, error) {
+	params.Folds = 0
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	cols := on.FeatureIndices()
+	d := newDesign(on, cols, allRows(on))
+	if lambdas == nil {
+		lambdas = d.defaultLambdas(params.L1Ratio)
+	}
+	lambdas = append([]float64(nil), lambdas...)
+	sort.Sort(sort.Reverse(sort.Float64Slice(lambdas)))
+	weights, intercepts := d.path(params, lambdas)
+	ret := make([]*ElasticNet, len(lambdas))
+	for i, lambda := range lambdas {
+		p := params
+		p.Lambda = lambda
+		ret[i] = &ElasticNet{ElasticNetParams: p, Weights: weights[i], Intercept: intercepts[i]}
+		ret[i].Attributes = make([]base.Attribute, len(cols))
+		for k, j := range cols {
+			ret[i].Attributes[k] = on.GetAttr(j)
+		}
+	}
+	return ret, nil
+}
+
+// Fit trains the ElasticNet on the given Instances. If Folds is at
+// least two, it first sets Lambda to the one of the regularisation
+// path with the lowest mean squared error in cross-validation.
+//
+// IMPORTANT: this function panic()s if the parameters are invalid,
+// the class isn't numeric, or there are fewer rows than Folds.
+func (e *ElasticNet) Fit(on *base.Instances) {
+	if err := e.Validate(); err != nil {
+		panic(err.Error())
+	}
+	cols := on.FeatureIndices()
+	e.Attributes = make([]base.Attribute, len(cols))
+	for k, j := range cols {
+		e.Attributes[k] = on.GetAttr(j)
+	}
+	d := newDesign(on, cols, allRows(on))
+	e.Lambdas, e.CVErrors = nil, nil
+	if e.Folds >= 2 {
+		e.crossValidate(on, cols, d.defaultLambdas(e.L1Ratio))
+	}
+	beta := make([]float64, len(cols))
+	d.descend(beta, e.Lambda, e.L1Ratio, e.MaxIterations, e.Tolerance)
+	e.Weights, e.Intercept = d.unstandardise(beta)
+}
+
+// crossValidate sets Lambdas, CVErrors and Lambda by training the
+// regularisation path on all but one of Folds random partitions of
+// the rows of on in turn, and testing it on the other
+func (e *ElasticNet) crossValidate(on *base.Instances, cols []int, lambdas []float64) {
+	seed := e.Seed
+	if seed == 0 {
+		seed = rand.Int63()
+	}
+	folds, err := eval.RandomFolds(on.Rows, e.Folds, seed)
+	if err != nil {
+		panic(err.Error())
+	}
+	e.Lambdas = lambdas
+	e.CVErrors = make([]float64, len(lambdas))
+	total := 0.0
+	for f, test := range folds {
+		train := make([]int, 0, on.Rows-len(test))
+		for g := range folds {
+			if g != f {
+				train = append(train, folds[g]...)
+			}
+		}
+		weights, intercepts := newDesign(on, cols, train).path(e.ElasticNetParams, lambdas)
+		for _, i := range test {
+			x := features(on, i, cols)
+			w := on.GetWeight(i)
+			for l := range lambdas {
+				diff := on.Get(i, on.ClassIndex) - intercepts[l] - dot(weights[l], x)
+				e.CVErrors[l] += w * diff * diff
+			}
+			total += w
+		}
+	}
+	best := 0
+	for l := range e.CVErrors {
+		e.CVErrors[l] /= total
+		if e.CVErrors[l] < e.CVErrors[best] {
+			best = l
+		}
+	}
+	e.Lambda = lambdas[best]
+}
+
+// getColumns returns the column of each of the training Attributes
+// in what.
+//
+// IMPORTANT: this function panic()s if Fit hasn't been called, or
+// if what is missing one of the Attributes.
+func (e *ElasticNet) getColumns(what *base.Instances) []int {
+	if e.Weights == nil {
+		panic("Call Fit() beforehand")
+	}
+	return what.GetAttrIndices(e.Attributes)
+}
+
+// Predict returns the predicted value of the class of each row of
+// what.
+func (e *ElasticNet) Predict(what *base.Instances) *base.Instances {
+	cols := e.getColumns(what)
+	ret := what.GeneratePredictionVector()
+	for i := 0; i < what.Rows; i++ {
+		ret.Set(i, 0, e.Intercept+dot(e.Weights, features(what, i, cols)))
+	}
+	return ret
+}
+
+// Clone returns an untrained ElasticNet with the same parameters
+func (e *ElasticNet) Clone() base.Classifier {
+	return &ElasticNet{ElasticNetParams: e.ElasticNetParams}
+}
+
+func (e *ElasticNet) String() string {
+	return fmt.Sprintf("ElasticNet(Lambda: %g, L1Ratio: %g, Folds: %d)", e.Lambda, e.L1Ratio, e.Folds)
+}
@@ -0,0 +1,136 @@
+package linear_models
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	base "github.com/sjwhitworth/golearn/base"
+)
+
+// sparseLinearData returns rows of 20 random features, whose class is
+// a linear function of only the first three, plus some noise
+func sparseLinearData(rows int, seed int64) *base.Instances {
+	rng := rand.New(rand.NewSource(seed))
+	attrs := make([]base.Attribute, 21)
+	for j := range attrs {
+		attrs[j] = base.NewFloatAttribute()
+		attrs[j].SetName(string(rune('a' + j)))
+	}
+	inst := base.NewInstances(attrs, rows)
+	for i := 0; i < rows; i++ {
+		y := 5.0
+		for j := 0; j < 20; j++ {
+			x := rng.NormFloat64() * float64(j+1)
+			inst.Set(i, j, x)
+			switch j {
+			case 0:
+				y += 3 * x
+			case 1:
+				y -= x
+			case 2:
+				y += 0.5 * x
+			}
+		}
+		inst.Set(i, 20, y+rng.NormFloat64()*0.1)
+	}
+	return inst
+}
+
+func TestElasticNet(testEnv *testing.T) {
+	inst := sparseLinearData(200, 1)
+
+	// With no penalty, it's ordinary least squares
+	ols := NewRidge(0)
+	ols.Fit(inst)
+	if math.Abs(ols.Weights[0]-3) > 0.01 || math.Abs(ols.Weights[1]+1) > 0.01 || math.Abs(ols.Intercept-5) > 0.05 {
+		testEnv.Error(ols.Weights[:3], ols.Intercept)
+	}
+	predictions := ols.Predict(inst)
+	for i := 0; i < inst.Rows; i++ {
+		if math.Abs(predictions.Get(i, 0)-inst.Get(i, 20)) > 0.5 {
+			testEnv.Fatalf("Row %d predicted %f, not %f", i, predictions.Get(i, 0), inst.Get(i, 20))
+		}
+	}
+
+	// The lasso zeroes the irrelevant weights
+	lasso := NewLasso(0.1)
+	lasso.Fit(inst)
+	for j, w := range lasso.Weights {
+		if (j < 3) != (w != 0) {
+			testEnv.Errorf("Feature %d has weight %f", j, w)
+		}
+	}
+
+	// Ridge regression shrinks the weights without zeroing them
+	ridge := NewRidge(1)
+	ridge.Fit(inst)
+	if math.Abs(ridge.Weights[0]) >= math.Abs(ols.Weights[0]) || ridge.Weights[5] == 0 {
+		testEnv.Error(ridge.Weights)
+	}
+
+	if _, err := NewElasticNetFromParams(ElasticNetParams{L1Ratio: 2, MaxIterations: 1}); err == nil {
+		testEnv.Error("Should reject an L1Ratio above one")
+	}
+}
+
+func TestRegularisationPath(testEnv *testing.T) {
+	inst := sparseLinearData(100, 2)
+	path, err := RegularisationPath(inst, NewLasso(0).ElasticNetParams, nil)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if len(path) != 100 {
+		testEnv.Fatal(len(path))
+	}
+	nonZero := func(e *ElasticNet) int {
+		ret := 0
+		for _, w := range e.Weights {
+			if w != 0 {
+				ret++
+			}
+		}
+		return ret
+	}
+	// The largest Lambda zeroes everything, and smaller ones let
+	// more features in, the most important first
+	if nonZero(path[0]) != 0 || nonZero(path[99]) < 3 {
+		testEnv.Error(nonZero(path[0]), nonZero(path[99]))
+	}
+	for _, e := range path {
+		if nonZero(e) == 1 && e.Weights[0] == 0 {
+			testEnv.Errorf("Feature %v entered first", e.Weights)
+		}
+	}
+	// Each model on the path matches fitting it separately
+	single := NewLasso(path[50].Lambda)
+	single.Fit(inst)
+	for j, w := range single.Weights {
+		if math.Abs(w-path[50].Weights[j]) > 1e-4 {
+			testEnv.Errorf("Feature %d has weight %f on the path and %f alone", j, path[50].Weights[j], w)
+		}
+	}
+}
+
+func TestElasticNetCrossValidation(testEnv *testing.T) {
+	inst := sparseLinearData(100, 3)
+	cls := NewElasticNet(0, 0.5)
+	cls.Folds = 5
+	cls.Seed = 1
+	cls.Fit(inst)
+	if len(cls.CVErrors) != 100 || len(cls.Lambdas) != 100 {
+		testEnv.Fatal(cls.CVErrors)
+	}
+	// The largest Lambda predicts the mean, which is much worse
+	// than the chosen one
+	best, chosen := math.Inf(1), 0.0
+	for l, err := range cls.CVErrors {
+		best = math.Min(best, err)
+		if cls.Lambdas[l] == cls.Lambda {
+			chosen = err
+		}
+	}
+	if chosen != best || best > cls.CVErrors[0]/10 {
+		testEnv.Error(cls.Lambda, best, cls.CVErrors[0])
+	}
+}
@@ -0,0 +1,123 @@
+package linear_models
+
+import (
+	"math"
+)
+
+// lbfgsMemory is the number of recent steps L-BFGS approximates the
+// curvature from
+const lbfgsMemory = 10
+
+// lbfgs minimises f, which returns its value at x and writes its
+// gradient into grad, with the limited-memory BFGS method and a
+// backtracking line search, starting from x and updating it. It stops
+// after maxIterations iterations, once no component of the gradient
+// is larger than tolerance, or once it can't decrease f any more.
+func lbfgs(f func(x, grad []float64) float64, x []float64, maxIterations int, tolerance float64) {
+	n := len(x)
+	grad := make([]float64, n)
+	fx := f(x, grad)
+	dir := make([]float64, n)
+	newX := make([]float64, n)
+	newGrad := make([]float64, n)
+	steps := make([][]float64, 0, lbfgsMemory)
+	changes := make([][]float64, 0, lbfgsMemory)
+	rhos := make([]float64, 0, lbfgsMemory)
+	alphas := make([]float64, lbfgsMemory)
+
+	for iteration := 0; iteration < maxIterations && maxAbs(grad) > tolerance; iteration++ {
+		// Approximate the inverse Hessian times the gradient with
+		// the two-loop recursion
+		copy(dir, grad)
+		for i := len(steps) - 1; i >= 0; i-- {
+			alphas[i] = rhos[i] * dot(steps[i], dir)
+			axpy(-alphas[i], changes[i], dir)
+		}
+		if last := len(steps) - 1; last >= 0 {
+			gamma := dot(steps[last], changes[last]) / dot(changes[last], changes[last])
+			for i := range dir {
+				dir[i] *= gamma
+			}
+		} else {
+			// Without any curvature yet, take a step of length one
+			norm := math.Sqrt(dot(grad, grad))
+			for i := range dir {
+				dir[i] /= math.Max(norm, 1)
+			}
+		}
+		for i := range steps {
+			beta := rhos[i] * dot(changes[i], dir)
+			axpy(alphas[i]-beta, steps[i], dir)
+		}
+		slope := -dot(grad, dir)
+		if slope >= 0 {
+			// Not a descent direction, so forget the curvature
+			steps, changes, rhos = steps[:0], changes[:0], rhos[:0]
+			copy(dir, grad)
+			slope = -dot(grad, grad)
+		}
+
+		// Backtrack until the step decreases f enough (the Armijo
+		// condition)
+		step, newF := 1.0, 0.0
+		for {
+			for i := range x {
+				newX[i] = x[i] - step*dir[i]
+			}
+			newF = f(newX, newGrad)
+			if newF <= fx+1e-4*step*slope {
+				break
+			}
+			step /= 2
+			if step < 1e-12 {
+				return
+			}
+		}
+
+		s := make([]float64, n)
+		y := make([]float64, n)
+		for i := range s {
+			s[i] = newX[i] - x[i]
+			y[i] = newGrad[i] - grad[i]
+		}
+		if sy := dot(s, y); sy > 1e-12 {
+			if len(steps) == lbfgsMemory {
+				steps, changes, rhos = steps[1:], changes[1:], rhos[1:]
+			}
+			steps = append(steps, s)
+			changes = append(changes, y)
+			rhos = append(rhos, 1/sy)
+		}
+		copy(x, newX)
+		copy(grad, newGrad)
+		if fx-newF <= 1e-12*math.Max(1, math.Abs(fx)) {
+			return
+		}
+		fx = newF
+	}
+}
+
+// dot returns the dot product of a and b
+func dot(a, b []float64) float64 {
+	ret := 0.0
+	for i := range a {
+		ret += a[i] * b[i]
+	}
+	return ret
+}
+
+// axpy adds alpha times x to y
+func axpy(alpha float64, x, y []float64) {
+	for i := range x {
+		y[i] += alpha * x[i]
+	}
+}
+
+// maxAbs returns the largest absolute value in xs
+func maxAbs(xs []float64) float64 {
+	ret := 0.0
+	for _, x := range xs {
+		ret = math.Max(ret, math.Abs(x))
+	}
+	return ret
+}
@@ -0,0 +1,20 @@
+package linear_models
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLBFGSRosenbrock(testEnv *testing.T) {
+	rosenbrock := func(x, grad []float64) float64 {
+		a, b := 1-x[0], x[1]-x[0]*x[0]
+		grad[0] = -2*a - 400*x[0]*b
+		grad[1] = 200 * b
+		return a*a + 100*b*b
+	}
+	x := []float64{-1.2, 1}
+	lbfgs(rosenbrock, x, 1000, 1e-8)
+	if math.Abs(x[0]-1) > 1e-4 || math.Abs(x[1]-1) > 1e-4 {
+		testEnv.Error(x)
+	}
+}
@@ -0,0 +1,422 @@
+// Package linear_models implements classifiers and regressors which
+// are linear in the features, as fast baselines for more complex
+// models.
+package linear_models
+
+import (
+	"encoding/gob"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	base "github.com/sjwhitworth/golearn/base"
+	mathutil "github.com/sjwhitworth/golearn/mathutil"
+)
+
+// LogisticRegressionParams holds the parameters of LogisticRegression.
+type LogisticRegressionParams struct {
+	// Epochs is the number of passes over the training rows
+	Epochs int
+	// BatchSize is the number of rows whose gradient is averaged
+	// for each step
+	BatchSize int
+	// LearningRate is the size of the first step
+	LearningRate float64
+	// Schedule is how the step size shrinks from one epoch to the
+	// next: "constant" (or ""), "inverse" (LearningRate divided by
+	// one plus Decay times the epoch) or "exponential" (LearningRate
+	// times e to the minus Decay times the epoch)
+	Schedule string
+	Decay    float64
+	// L2 is the strength of the penalty on the squared weights
+	// (but not the intercepts), which stops them growing large when
+	// the classes are separable
+	L2 float64
+	// Seed makes the order rows are visited in reproducible. Zero
+	// means a different random seed each time.
+	Seed int64
+	// Solver is how the loss is minimised: "sgd" (or ""), the
+	// mini-batch stochastic gradient descent configured above, or
+	// "lbfgs", which minimises the loss of all of the rows at once
+	// with L-BFGS. L-BFGS needs no learning rate, and converges
+	// reliably on small and medium datasets; Epochs is the most
+	// iterations it takes, and the other parameters above but L2
+	// are ignored.
+	Solver string
+	// Tolerance stops L-BFGS once no component of the gradient of
+	// the loss is larger
+	Tolerance float64
+}
+
+// DefaultLogisticRegressionParams returns the LogisticRegressionParams
+// used by NewLogisticRegression and the "logisticregression" entry
+// in the classifier registry.
+func DefaultLogisticRegressionParams() LogisticRegressionParams {
+	return LogisticRegressionParams{
+		Epochs:       100,
+		BatchSize:    16,
+		LearningRate: 0.1,
+		Schedule:     "inverse",
+		Decay:        0.01,
+		L2:           1e-4,
+		Solver:       "sgd",
+		Tolerance:    1e-6,
+	}
+}
+
+// Validate checks that the LogisticRegressionParams are usable.
+func (p LogisticRegressionParams) Validate() error {
+	if p.Epochs < 1 {
+		return fmt.Errorf("linear_models: Epochs should be at least 1, got %d", p.Epochs)
+	}
+	if p.BatchSize < 1 {
+		return fmt.Errorf("linear_models: BatchSize should be at least 1, got %d", p.BatchSize)
+	}
+	if p.LearningRate <= 0 {
+		return fmt.Errorf("linear_models: LearningRate should be positive, got %f", p.LearningRate)
+	}
+	if p.Decay < 0 {
+		return fmt.Errorf("linear_models: Decay can't be negative, got %f", p.Decay)
+	}
+	if p.L2 < 0 {
+		return fmt.Errorf("linear_models: L2 can't be negative, got %f", p.L2)
+	}
+	if p.Tolerance < 0 {
+		return fmt.Errorf("linear_models: Tolerance can't be negative, got %f", p.Tolerance)
+	}
+	switch p.Schedule {
+	case "", "constant", "inverse", "exponential":
+	default:
+		return fmt.Errorf("linear_models: unknown Schedule %q", p.Schedule)
+	}
+	switch p.Solver {
+	case "", "sgd", "lbfgs":
+	default:
+		return fmt.Errorf("linear_models: unknown Solver %q", p.Solver)
+	}
+	return nil
+}
+
+// stepSize returns the learning rate for the given epoch, from zero
+func (p LogisticRegressionParams) stepSize(epoch int) float64 {
+	switch p.Schedule {
+	case "inverse":
+		return p.LearningRate / (1 + p.Decay*float64(epoch))
+	case "exponential":
+		return p.LearningRate * math.Exp(-p.Decay*float64(epoch))
+	}
+	return p.LearningRate
+}
+
+// LogisticRegression models the log-odds of the class as a linear
+// function of the features, trained with mini-batch stochastic
+// gradient descent or L-BFGS (see Solver) on the (row-weighted) log
+// loss. Two classes are predicted with a single output for the
+// second, and more with a multinomial (softmax) model with an output
+// per class.
+//
+// Each weight multiplies the stored value of its feature, so a
+// categorical feature counts as the index of its value unless it's
+// one-hot encoded first (see pipeline.OneHot), and a missing value
+// counts as zero. Gradient descent takes steps of the same size in
+// every direction, and converges slowly unless the features have
+// similar scales (see pipeline.Standardise).
+type LogisticRegression struct {
+	base.BaseClassifier
+	LogisticRegressionParams
+	// Weights holds the weight of each feature for each output,
+	// and Intercepts the intercept of each output
+	Weights    [][]float64
+	Intercepts []float64
+	// Classes holds the class values seen during training, sorted
+	Classes []string
+	// Attributes holds the features trained on
+	Attributes []base.Attribute
+}
+
+// NewLogisticRegression returns a LogisticRegression with the
+// DefaultLogisticRegressionParams.
+func NewLogisticRegression() *LogisticRegression {
+	return &LogisticRegression{LogisticRegressionParams: DefaultLogisticRegressionParams()}
+}
+
+// NewLogisticRegressionFromParams returns a new LogisticRegression
+// with the given parameters, or an error if they're invalid.
+func NewLogisticRegressionFromParams(params LogisticRegressionParams) (*LogisticRegression, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	return &LogisticRegression{LogisticRegressionParams: params}, nil
+}
+
+func init() {
+	base.RegisterClassifier("logisticregression", func() base.Classifier {
+		return NewLogisticRegression()
+	})
+	gob.Register(&LogisticRegression{})
+}
+
+// features returns the features of row i of what, from the given
+// columns, with missing values as zero
+func features(what *base.Instances, i int, cols []int) []float64 {
+	ret := make([]float64, len(cols))
+	for k, j := range cols {
+		if v := what.Get(i, j); !base.IsMissingValue(v) {
+			ret[k] = v
+		}
+	}
+	return ret
+}
+
+// Fit trains the LogisticRegression on the given Instances, scaling
+// the loss of each row by its weight (see base.Instances.SetWeight).
+//
+// IMPORTANT: this function panic()s if the parameters are invalid or
+// the class Attribute is numeric.
+func (l *LogisticRegression) Fit(on *base.Instances) {
+	if err := l.Validate(); err != nil {
+		panic(err.Error())
+	}
+	if on.GetClassAttr().GetType() != base.CategoricalType {
+		panic("linear_models: LogisticRegression needs a categorical class")
+	}
+	cols := on.FeatureIndices()
+	l.Attributes = make([]base.Attribute, len(cols))
+	for k, j := range cols {
+		l.Attributes[k] = on.GetAttr(j)
+	}
+	l.Classes = make([]string, 0)
+	for c := range on.CountClassValues() {
+		l.Classes = append(l.Classes, c)
+	}
+	sort.Strings(l.Classes)
+	outputs := len(l.Classes)
+	if outputs <= 2 {
+		outputs = 1
+	}
+	l.Weights = make([][]float64, outputs)
+	for k := range l.Weights {
+		l.Weights[k] = make([]float64, len(cols))
+	}
+	l.Intercepts = make([]float64, outputs)
+
+	x := make([][]float64, on.Rows)
+	targets := make([]int, on.Rows)
+	for i := range x {
+		x[i] = features(on, i, cols)
+		targets[i] = sort.SearchStrings(l.Classes, on.GetClass(i))
+		if len(l.Classes) == 2 {
+			// The single output is for the second class
+			targets[i]--
+		}
+	}
+
+	if l.Solver == "lbfgs" {
+		l.fitLBFGS(on, x, targets)
+	} else {
+		l.fitSGD(on, x, targets)
+	}
+}
+
+// fitSGD trains the Weights and Intercepts on the features x and
+// the index of the output of each row (or -1 if it has none) with
+// mini-batch stochastic gradient descent
+func (l *LogisticRegression) fitSGD(on *base.Instances, x [][]float64, targets []int) {
+	outputs, cols := len(l.Weights), len(l.Attributes)
+	seed := l.Seed
+	if seed == 0 {
+		seed = rand.Int63()
+	}
+	rng := rand.New(rand.NewSource(seed))
+	weightGrads := make([][]float64, outputs)
+	for k := range weightGrads {
+		weightGrads[k] = make([]float64, cols)
+	}
+	interceptGrads := make([]float64, outputs)
+	for epoch := 0; epoch < l.Epochs; epoch++ {
+		step := l.stepSize(epoch)
+		order := rng.Perm(on.Rows)
+		for start := 0; start < len(order); start += l.BatchSize {
+			end := start + l.BatchSize
+			if end > len(order) {
+				end = len(order)
+			}
+			for k := range weightGrads {
+				for j := range weightGrads[k] {
+					weightGrads[k][j] = 0
+				}
+				interceptGrads[k] = 0
+			}
+			for _, i := range order[start:end] {
+				probs := l.outputProbabilities(x[i])
+				w := on.GetWeight(i)
+				for k, p := range probs {
+					err := p
+					if k == targets[i] {
+						err--
+					}
+					err *= w
+					for j, v := range x[i] {
+						weightGrads[k][j] += err * v
+					}
+					interceptGrads[k] += err
+				}
+			}
+			n := float64(end - start)
+			for k := range l.Weights {
+				for j := range l.Weights[k] {
+					l.Weights[k][j] -= step * (weightGrads[k][j]/n + l.L2*l.Weights[k][j])
+				}
+				l.Intercepts[k] -= step * interceptGrads[k] / n
+			}
+		}
+	}
+}
+
+// fitLBFGS trains the Weights and Intercepts like fitSGD, but with
+// L-BFGS on the mean loss of all of the rows, plus the L2 penalty
+func (l *LogisticRegression) fitLBFGS(on *base.Instances, x [][]float64, targets []int) {
+	outputs, cols := len(l.Weights), len(l.Attributes)
+	// Each output's weights are followed by its intercept
+	stride := cols + 1
+	params := make([]float64, outputs*stride)
+	n := float64(on.Rows)
+	loss := func(params, grad []float64) float64 {
+		for k := range l.Weights {
+			l.Weights[k] = params[k*stride : k*stride+cols]
+			l.Intercepts[k] = params[k*stride+cols]
+		}
+		for j := range grad {
+			grad[j] = 0
+		}
+		ret := 0.0
+		for i := range x {
+			scores := l.scores(x[i])
+			w := on.GetWeight(i)
+			if outputs == 1 {
+				// The log loss is log(1 + e^s) - ys
+				ret -= w * mathutil.LogSigmoid(-scores[0])
+				if targets[i] == 0 {
+					ret -= w * scores[0]
+				}
+			} else {
+				ret += w * (mathutil.LogSumExp(scores) - scores[targets[i]])
+			}
+			for k, p := range l.outputProbabilities(x[i]) {
+				err := p
+				if k == targets[i] {
+					err--
+				}
+				err *= w / n
+				for j, v := range x[i] {
+					grad[k*stride+j] += err * v
+				}
+				grad[k*stride+cols] += err
+			}
+		}
+		ret /= n
+		for k := range l.Weights {
+			for j, v := range l.Weights[k] {
+				ret += l.L2 / 2 * v * v
+				grad[k*stride+j] += l.L2 * v
+			}
+		}
+		return ret
+	}
+	lbfgs(loss, params, l.Epochs, l.Tolerance)
+	for k := range l.Weights {
+		l.Weights[k] = append([]float64(nil), params[k*stride:k*stride+cols]...)
+		l.Intercepts[k] = params[k*stride+cols]
+	}
+}
+
+// scores returns the log-odds of each output for a row of features
+func (l *LogisticRegression) scores(x []float64) []float64 {
+	ret := make([]float64, len(l.Weights))
+	for k, weights := range l.Weights {
+		ret[k] = l.Intercepts[k]
+		for j, v := range x {
+			ret[k] += weights[j] * v
+		}
+	}
+	return ret
+}
+
+// outputProbabilities returns the probability of each output for a
+// row of features
+func (l *LogisticRegression) outputProbabilities(x []float64) []float64 {
+	scores := l.scores(x)
+	if len(scores) == 1 {
+		return []float64{mathutil.Sigmoid(scores[0])}
+	}
+	return mathutil.Softmax(scores)
+}
+
+// getColumns returns the column of each of the training Attributes
+// in what.
+//
+// IMPORTANT: this function panic()s if Fit hasn't been called, or
+// if what is missing one of the Attributes.
+func (l *LogisticRegression) getColumns(what *base.Instances) []int {
+	if l.Weights == nil {
+		panic("Call Fit() beforehand")
+	}
+	return what.GetAttrIndices(l.Attributes)
+}
+
+// DecisionFunction returns the log-odds of each output for each row
+// of what.
+func (l *LogisticRegression) DecisionFunction(what *base.Instances) [][]float64 {
+	cols := l.getColumns(what)
+	ret := make([][]float64, what.Rows)
+	for i := range ret {
+		ret[i] = l.scores(features(what, i, cols))
+	}
+	return ret
+}
+
+// PredictProba returns the estimated probability of each class for
+// each row of what.
+func (l *LogisticRegression) PredictProba(what *base.Instances) []map[string]float64 {
+	cols := l.getColumns(what)
+	ret := make([]map[string]float64, what.Rows)
+	for i := range ret {
+		probs := l.outputProbabilities(features(what, i, cols))
+		ret[i] = make(map[string]float64)
+		if len(l.Classes) <= 2 {
+			ret[i][l.Classes[len(l.Classes)-1]] = probs[0]
+			if len(l.Classes) == 2 {
+				ret[i][l.Classes[0]] = 1 - probs[0]
+			}
+			continue
+		}
+		for k, p := range probs {
+			ret[i][l.Classes[k]] = p
+		}
+	}
+	return ret
+}
+
+// Predict returns the most probable class of each row of what.
+func (l *LogisticRegression) Predict(what *base.Instances) *base.Instances {
+	ret := what.GeneratePredictionVector()
+	for i, probs := range l.PredictProba(what) {
+		class, _ := base.MostLikelyClass(probs)
+		ret.SetAttrStr(i, 0, class)
+	}
+	return ret
+}
+
+// Clone returns an untrained LogisticRegression with the same
+// parameters
+func (l *LogisticRegression) Clone() base.Classifier {
+	return &LogisticRegression{LogisticRegressionParams: l.LogisticRegressionParams}
+}
+
+func (l *LogisticRegression) String() string {
+	if l.Solver == "lbfgs" {
+		return fmt.Sprintf("LogisticRegression(Solver: %q, Epochs: %d, L2: %g, Tolerance: %g)", l.Solver, l.Epochs, l.L2, l.Tolerance)
+	}
+	return fmt.Sprintf("LogisticRegression(Epochs: %d, BatchSize: %d, LearningRate: %g, Schedule: %q, L2: %g)", l.Epochs, l.BatchSize, l.LearningRate, l.Schedule, l.L2)
+}
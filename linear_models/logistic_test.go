@@ -0,0 +1,164 @@
+package linear_models
+
+import (
+	"math"
+	"testing"
+
+	base "github.com/sjwhitworth/golearn/base"
+	eval "github.com/sjwhitworth/golearn/evaluation"
+)
+
+func TestLogisticRegression(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	trainData, testData := base.InstancesTrainTestSplitWithSeed(inst, 0.5, 1)
+
+	cls := NewLogisticRegression()
+	cls.Seed = 1
+	cls.Fit(trainData)
+	if len(cls.Weights) != 3 || len(cls.Weights[0]) != 4 {
+		testEnv.Fatal(cls.Weights)
+	}
+	predictions := cls.Predict(testData)
+	if accuracy := eval.GetAccuracy(eval.GetConfusionMatrix(testData, predictions)); accuracy < 0.9 {
+		testEnv.Error(accuracy)
+	}
+	for i, probs := range cls.PredictProba(testData) {
+		total := 0.0
+		for _, p := range probs {
+			total += p
+		}
+		if len(probs) != 3 || math.Abs(total-1) > 1e-9 {
+			testEnv.Fatalf("Row %d has probabilities %v", i, probs)
+		}
+		if class, _ := base.MostLikelyClass(probs); class != predictions.GetClass(i) {
+			testEnv.Errorf("Row %d predicted %s, but %s is most likely", i, predictions.GetClass(i), class)
+		}
+	}
+
+	// The same seed trains the same model
+	again := cls.Clone().(*LogisticRegression)
+	again.Fit(trainData)
+	if again.Weights[1][2] != cls.Weights[1][2] {
+		testEnv.Error("Training with the same seed should be reproducible")
+	}
+
+	// A stronger penalty gives smaller weights
+	penalised := cls.Clone().(*LogisticRegression)
+	penalised.L2 = 0.1
+	penalised.Fit(trainData)
+	norm := func(weights [][]float64) float64 {
+		ret := 0.0
+		for _, w := range weights {
+			for _, v := range w {
+				ret += v * v
+			}
+		}
+		return ret
+	}
+	if norm(penalised.Weights) >= norm(cls.Weights) {
+		testEnv.Error("L2 should shrink the weights")
+	}
+}
+
+func TestLogisticRegressionLBFGS(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	trainData, testData := base.InstancesTrainTestSplitWithSeed(inst, 0.5, 1)
+	params := DefaultLogisticRegressionParams()
+	params.Solver = "lbfgs"
+	params.L2 = 0.01
+	cls, err := NewLogisticRegressionFromParams(params)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	cls.Fit(trainData)
+	predictions := cls.Predict(testData)
+	if accuracy := eval.GetAccuracy(eval.GetConfusionMatrix(testData, predictions)); accuracy < 0.9 {
+		testEnv.Error(accuracy)
+	}
+
+	// L-BFGS should fit the training rows at least as well as SGD
+	// with the same penalty
+	sgd := NewLogisticRegression()
+	sgd.L2 = 0.01
+	sgd.Seed = 1
+	sgd.Fit(trainData)
+	lbfgsLoss := eval.GetLogLoss(trainData, cls.PredictProba(trainData))
+	sgdLoss := eval.GetLogLoss(trainData, sgd.PredictProba(trainData))
+	if lbfgsLoss > sgdLoss+1e-3 {
+		testEnv.Errorf("L-BFGS log loss %.4f, SGD %.4f", lbfgsLoss, sgdLoss)
+	}
+
+	params.Solver = "newton"
+	if _, err := NewLogisticRegressionFromParams(params); err == nil {
+		testEnv.Error("Should reject an unknown solver")
+	}
+}
+
+func TestLogisticRegressionBinary(testEnv *testing.T) {
+	attrs := []base.Attribute{base.NewFloatAttribute(), base.NewCategoricalAttribute()}
+	attrs[0].SetName("x")
+	attrs[1].SetName("class")
+	inst := base.NewInstances(attrs, 40)
+	for i := 0; i < inst.Rows; i++ {
+		x := float64(i)/10 - 2
+		inst.Set(i, 0, x)
+		if x > 0.5 {
+			inst.SetAttrStr(i, 1, "yes")
+		} else {
+			inst.SetAttrStr(i, 1, "no")
+		}
+	}
+	params := DefaultLogisticRegressionParams()
+	params.Seed = 1
+	params.Schedule = "exponential"
+	cls, err := NewLogisticRegressionFromParams(params)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	cls.Fit(inst)
+	if len(cls.Weights) != 1 || cls.Weights[0][0] <= 0 {
+		testEnv.Fatal(cls.Weights)
+	}
+	// The decision boundary is between 0.4 and 0.6
+	if boundary := -cls.Intercepts[0] / cls.Weights[0][0]; boundary < 0 || boundary > 1 {
+		testEnv.Error(boundary)
+	}
+	probs := cls.PredictProba(inst)
+	if probs[0]["yes"] > 0.1 || probs[39]["yes"] < 0.9 {
+		testEnv.Error(probs[0], probs[39])
+	}
+
+	// Weighting the rows of a class heavily moves the boundary
+	// away from it
+	for i := 0; i < inst.Rows; i++ {
+		if inst.GetClass(i) == "yes" {
+			inst.SetWeight(i, 10)
+		}
+	}
+	weighted := cls.Clone().(*LogisticRegression)
+	weighted.Fit(inst)
+	if -weighted.Intercepts[0]/weighted.Weights[0][0] >= -cls.Intercepts[0]/cls.Weights[0][0] {
+		testEnv.Error("Weights should move the boundary")
+	}
+
+	params.Solver = "lbfgs"
+	exact, err := NewLogisticRegressionFromParams(params)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	exact.Fit(inst)
+	if boundary := -exact.Intercepts[0] / exact.Weights[0][0]; boundary < 0 || boundary > 1 {
+		testEnv.Error(boundary)
+	}
+
+	params.Schedule = "cyclic"
+	if _, err := NewLogisticRegressionFromParams(params); err == nil {
+		testEnv.Error("Should reject an unknown schedule")
+	}
+}
@@ -0,0 +1,358 @@
+package linear_models
+
+import (
+	"encoding/gob"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	base "github.com/sjwhitworth/golearn/base"
+)
+
+// LinearSVMParams holds the parameters of LinearSVM.
+type LinearSVMParams struct {
+	// C is the cost of each unit of hinge loss, relative to the
+	// squared size of the weights. Larger values fit the training
+	// rows more closely.
+	C float64
+	// Solver is how the loss is minimised: "dual" (or ""), dual
+	// coordinate descent as in liblinear, which converges quickly
+	// to the exact solution, or "pegasos", stochastic sub-gradient
+	// descent, which is cheaper per pass over very many rows
+	Solver string
+	// MaxIterations is the most passes either solver makes over the
+	// rows
+	MaxIterations int
+	// Tolerance stops dual coordinate descent once the projected
+	// gradients of every row's dual variable are within it of each
+	// other
+	Tolerance float64
+	// Seed makes the order rows are visited in reproducible. Zero
+	// means a different random seed each time.
+	Seed int64
+}
+
+// DefaultLinearSVMParams returns the LinearSVMParams used by
+// NewLinearSVM before its argument is applied.
+func DefaultLinearSVMParams() LinearSVMParams {
+	return LinearSVMParams{
+		C:             1.0,
+		Solver:        "dual",
+		MaxIterations: 1000,
+		Tolerance:     0.1,
+	}
+}
+
+// Validate checks that the LinearSVMParams are usable.
+func (p LinearSVMParams) Validate() error {
+	if p.C <= 0 {
+		return fmt.Errorf("linear_models: C should be positive, got %f", p.C)
+	}
+	if p.MaxIterations < 1 {
+		return fmt.Errorf("linear_models: MaxIterations should be at least 1, got %d", p.MaxIterations)
+	}
+	if p.Tolerance < 0 {
+		return fmt.Errorf("linear_models: Tolerance can't be negative, got %f", p.Tolerance)
+	}
+	switch p.Solver {
+	case "", "dual", "pegasos":
+	default:
+		return fmt.Errorf("linear_models: unknown Solver %q", p.Solver)
+	}
+	return nil
+}
+
+// LinearSVM is a support vector machine with a linear kernel: it
+// finds the hyperplane separating the classes with the widest margin,
+// minimising the hinge loss of the rows plus the squared size of the
+// weights. Two classes are separated by a single output for the
+// second, and more one-vs-rest, with an output per class. Each row's
+// loss is scaled by its weight (see base.Instances.SetWeight), and
+// sparse Instances are trained on without looking at their zeros.
+// The intercept is learnt as the weight of an extra feature which is
+// always one, so it's penalised too.
+//
+// Missing values count as zero, like the values sparse Instances
+// leave out, and categorical features as the index of their value
+// (see pipeline.OneHot). The penalty shrinks every weight alike, so
+// a feature with a larger scale needs a smaller weight and gets more
+// say in the margin (see pipeline.Standardise).
+type LinearSVM struct {
+	base.BaseClassifier
+	LinearSVMParams
+	// Weights holds the weight of each feature for each output,
+	// and Intercepts the intercept of each output
+	Weights    [][]float64
+	Intercepts []float64
+	// Classes holds the class values seen during training, sorted
+	Classes []string
+	// Attributes holds the features trained on
+	Attributes []base.Attribute
+}
+
+// NewLinearSVM returns a LinearSVM with the given C, and the
+// DefaultLinearSVMParams otherwise.
+func NewLinearSVM(c float64) *LinearSVM {
+	params := DefaultLinearSVMParams()
+	params.C = c
+	return &LinearSVM{LinearSVMParams: params}
+}
+
+// NewLinearSVMFromParams returns a new LinearSVM with the given
+// parameters, or an error if they're invalid.
+func NewLinearSVMFromParams(params LinearSVMParams) (*LinearSVM, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	return &LinearSVM{LinearSVMParams: params}, nil
+}
+
+func init() {
+	base.RegisterClassifier("linearsvm", func() base.Classifier {
+		return NewLinearSVM(1)
+	})
+	gob.Register(&LinearSVM{})
+}
+
+// sparseRow is the non-zero features of a row: their positions among
+// the features, and their values
+type sparseRow struct {
+	indices []int
+	values  []float64
+}
+
+// sparseFeatures returns the non-zero features of row i of what,
+// where positions gives the position of each column among the
+// features, or -1 if it isn't one. Missing values count as zero.
+func sparseFeatures(what *base.Instances, i int, positions []int) sparseRow {
+	cols, vals := what.NonZero(i)
+	ret := sparseRow{make([]int, 0, len(cols)), make([]float64, 0, len(cols))}
+	for k, j := range cols {
+		if positions[j] != -1 && !base.IsMissingValue(vals[k]) {
+			ret.indices = append(ret.indices, positions[j])
+			ret.values = append(ret.values, vals[k])
+		}
+	}
+	return ret
+}
+
+// featurePositions returns the position of each column of what among
+// cols, or -1 if it isn't one of them
+func featurePositions(what *base.Instances, cols []int) []int {
+	ret := make([]int, what.Cols)
+	for j := range ret {
+		ret[j] = -1
+	}
+	for k, j := range cols {
+		ret[j] = k
+	}
+	return ret
+}
+
+// score returns the dot product of weights and a row, plus intercept
+func (r sparseRow) score(weights []float64, intercept float64) float64 {
+	ret := intercept
+	for k, j := range r.indices {
+		ret += weights[j] * r.values[k]
+	}
+	return ret
+}
+
+// Fit trains the LinearSVM on the given Instances.
+//
+// IMPORTANT: this function panic()s if the parameters are invalid or
+// the class Attribute is numeric.
+func (s *LinearSVM) Fit(on *base.Instances) {
+	if err := s.Validate(); err != nil {
+		panic(err.Error())
+	}
+	if on.GetClassAttr().GetType() != base.CategoricalType {
+		panic("linear_models: LinearSVM needs a categorical class")
+	}
+	cols := on.FeatureIndices()
+	s.Attributes = make([]base.Attribute, len(cols))
+	for k, j := range cols {
+		s.Attributes[k] = on.GetAttr(j)
+	}
+	s.Classes = make([]string, 0)
+	for c := range on.CountClassValues() {
+		s.Classes = append(s.Classes, c)
+	}
+	sort.Strings(s.Classes)
+	positives := s.Classes
+	if len(s.Classes) <= 2 {
+		positives = s.Classes[len(s.Classes)-1:]
+	}
+
+	positions := featurePositions(on, cols)
+	rows := make([]sparseRow, on.Rows)
+	for i := range rows {
+		rows[i] = sparseFeatures(on, i, positions)
+	}
+	seed := s.Seed
+	if seed == 0 {
+		seed = rand.Int63()
+	}
+	rng := rand.New(rand.NewSource(seed))
+	s.Weights = make([][]float64, len(positives))
+	s.Intercepts = make([]float64, len(positives))
+	for k, c := range positives {
+		labels := make([]float64, on.Rows)
+		for i := range labels {
+			labels[i] = -1
+			if on.GetClass(i) == c {
+				labels[i] = 1
+			}
+		}
+		s.Weights[k] = make([]float64, len(cols))
+		if s.Solver == "pegasos" {
+			s.Intercepts[k] = s.fitPegasos(on, rows, labels, s.Weights[k], rng)
+		} else {
+			s.Intercepts[k] = s.fitDual(on, rows, labels, s.Weights[k], rng)
+		}
+	}
+}
+
+// fitDual sets weights, and returns the intercept, of the output
+// which separates the rows labelled 1 from those labelled -1, with
+// dual coordinate descent on the L1-loss SVM (Hsieh et al., 2008)
+func (s *LinearSVM) fitDual(on *base.Instances, rows []sparseRow, labels, weights []float64, rng *rand.Rand) float64 {
+	intercept := 0.0
+	alphas := make([]float64, len(rows))
+	// The diagonal of the kernel matrix, including the constant
+	// feature, and the upper bound of each dual variable
+	diagonal := make([]float64, len(rows))
+	bounds := make([]float64, len(rows))
+	for i, r := range rows {
+		diagonal[i] = 1 + dot(r.values, r.values)
+		bounds[i] = s.C * on.GetWeight(i)
+	}
+	for iteration := 0; iteration < s.MaxIterations; iteration++ {
+		maxGradient, minGradient := math.Inf(-1), math.Inf(1)
+		for _, i := range rng.Perm(len(rows)) {
+			gradient := labels[i]*rows[i].score(weights, intercept) - 1
+			projected := gradient
+			if alphas[i] == 0 {
+				projected = math.Min(gradient, 0)
+			} else if alphas[i] == bounds[i] {
+				projected = math.Max(gradient, 0)
+			}
+			maxGradient = math.Max(maxGradient, projected)
+			minGradient = math.Min(minGradient, projected)
+			if projected == 0 {
+				continue
+			}
+			old := alphas[i]
+			alphas[i] = math.Min(math.Max(alphas[i]-gradient/diagonal[i], 0), bounds[i])
+			change := (alphas[i] - old) * labels[i]
+			for k, j := range rows[i].indices {
+				weights[j] += change * rows[i].values[k]
+			}
+			intercept += change
+		}
+		if maxGradient-minGradient <= s.Tolerance {
+			break
+		}
+	}
+	return intercept
+}
+
+// fitPegasos is like fitDual, but with Pegasos (Shalev-Shwartz et
+// al., 2007): stochastic sub-gradient descent on the primal, with
+// the step size falling as one over the number of steps
+func (s *LinearSVM) fitPegasos(on *base.Instances, rows []sparseRow, labels, weights []float64, rng *rand.Rand) float64 {
+	// The weights are scale times v, so that shrinking them
+	// doesn't need to touch every one
+	lambda := 1 / (s.C * float64(len(rows)))
+	v := weights
+	scale, intercept := 1.0, 0.0
+	t := 0
+	for iteration := 0; iteration < s.MaxIterations; iteration++ {
+		for _, i := range rng.Perm(len(rows)) {
+			t++
+			margin := labels[i] * scale * rows[i].score(v, intercept)
+			scale *= 1 - 1/float64(t)
+			intercept *= 1 - 1/float64(t)
+			if scale == 0 {
+				// The first step discards the initial weights
+				for j := range v {
+					v[j] = 0
+				}
+				scale = 1
+			}
+			if margin < 1 {
+				step := labels[i] * on.GetWeight(i) / (lambda * float64(t) * scale)
+				for k, j := range rows[i].indices {
+					v[j] += step * rows[i].values[k]
+				}
+				intercept += step
+			}
+		}
+	}
+	for j := range weights {
+		weights[j] = v[j] * scale
+	}
+	return intercept * scale
+}
+
+// getColumns returns the column of each of the training Attributes
+// in what.
+//
+// IMPORTANT: this function panic()s if Fit hasn't been called, or
+// if what is missing one of the Attributes.
+func (s *LinearSVM) getColumns(what *base.Instances) []int {
+	if s.Weights == nil {
+		panic("Call Fit() beforehand")
+	}
+	return what.GetAttrIndices(s.Attributes)
+}
+
+// DecisionFunction returns the signed distance (scaled by the size
+// of the weights) of each row of what from each output's hyperplane:
+// positive on the side of its class.
+func (s *LinearSVM) DecisionFunction(what *base.Instances) [][]float64 {
+	positions := featurePositions(what, s.getColumns(what))
+	ret := make([][]float64, what.Rows)
+	for i := range ret {
+		r := sparseFeatures(what, i, positions)
+		ret[i] = make([]float64, len(s.Weights))
+		for k, weights := range s.Weights {
+			ret[i][k] = r.score(weights, s.Intercepts[k])
+		}
+	}
+	return ret
+}
+
+// Predict returns the class of each row of what whose output is the
+// largest, or, with two classes, the side of the hyperplane it's on.
+func (s *LinearSVM) Predict(what *base.Instances) *base.Instances {
+	ret := what.GeneratePredictionVector()
+	for i, scores := range s.DecisionFunction(what) {
+		if len(s.Classes) <= 2 {
+			if scores[0] >= 0 || len(s.Classes) == 1 {
+				ret.SetAttrStr(i, 0, s.Classes[len(s.Classes)-1])
+			} else {
+				ret.SetAttrStr(i, 0, s.Classes[0])
+			}
+			continue
+		}
+		best := 0
+		for k := range scores {
+			if scores[k] > scores[best] {
+				best = k
+			}
+		}
+		ret.SetAttrStr(i, 0, s.Classes[best])
+	}
+	return ret
+}
+
+// Clone returns an untrained LinearSVM with the same parameters
+func (s *LinearSVM) Clone() base.Classifier {
+	return &LinearSVM{LinearSVMParams: s.LinearSVMParams}
+}
+
+func (s *LinearSVM) String() string {
+	return fmt.Sprintf("LinearSVM(C: %g, Solver: %q, MaxIterations: %d)", s.C, s.Solver, s.MaxIterations)
+}
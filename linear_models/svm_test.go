@@ -0,0 +1,137 @@
+package linear_models
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	base "github.com/sjwhitworth/golearn/base"
+	eval "github.com/sjwhitworth/golearn/evaluation"
+)
+
+func TestLinearSVM(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	trainData, testData := base.InstancesTrainTestSplitWithSeed(inst, 0.5, 1)
+
+	for _, solver := range []string{"dual", "pegasos"} {
+		cls, err := NewLinearSVMFromParams(LinearSVMParams{C: 10, Solver: solver, MaxIterations: 1000, Tolerance: 0.01, Seed: 1})
+		if err != nil {
+			testEnv.Fatal(err)
+		}
+		cls.Fit(trainData)
+		if len(cls.Weights) != 3 || len(cls.Weights[0]) != 4 {
+			testEnv.Fatal(cls.Weights)
+		}
+		predictions := cls.Predict(testData)
+		if accuracy := eval.GetAccuracy(eval.GetConfusionMatrix(testData, predictions)); accuracy < 0.85 {
+			testEnv.Errorf("%s: %f", solver, accuracy)
+		}
+		for i, scores := range cls.DecisionFunction(testData) {
+			best := 0
+			for k := range scores {
+				if scores[k] > scores[best] {
+					best = k
+				}
+			}
+			if cls.Classes[best] != predictions.GetClass(i) {
+				testEnv.Errorf("%s: row %d predicted %s, but %s scores highest", solver, i, predictions.GetClass(i), cls.Classes[best])
+			}
+		}
+	}
+}
+
+// newLine returns Instances with a single feature and a class of A
+// or B.
+func newLine(xs []float64, classes []string) *base.Instances {
+	class := base.NewCategoricalAttribute()
+	class.GetSysValFromString("A")
+	class.GetSysValFromString("B")
+	ret := base.NewInstances([]base.Attribute{base.NewFloatAttribute(), class}, len(xs))
+	for i, x := range xs {
+		ret.Set(i, 0, x)
+		ret.SetAttrStr(i, 1, classes[i])
+	}
+	return ret
+}
+
+func TestLinearSVMWeights(testEnv *testing.T) {
+	trainData := newLine([]float64{-2, -1, 0.5, -0.5, 1, 2}, []string{"A", "A", "A", "B", "B", "B"})
+	testData := newLine([]float64{0.5}, []string{"A"})
+
+	cls := NewLinearSVM(1)
+	cls.Seed = 1
+	cls.Fit(trainData)
+	if len(cls.Weights) != 1 || cls.Weights[0][0] <= 0 {
+		testEnv.Fatal(cls.Weights)
+	}
+	if class := cls.Predict(testData).GetClass(0); class != "B" {
+		testEnv.Errorf("Unweighted, 0.5 should be on B's side, got %s", class)
+	}
+
+	// Making the misclassified A expensive moves the boundary past it
+	trainData.SetWeight(2, 100)
+	cls.Fit(trainData)
+	if class := cls.Predict(testData).GetClass(0); class != "A" {
+		testEnv.Errorf("Weighted, 0.5 should be on A's side, got %s", class)
+	}
+}
+
+func TestLinearSVMSparse(testEnv *testing.T) {
+	// Each row has a handful of 5,000 features, with the class
+	// given by whether one of the first 10 is among them
+	attrs := make([]base.Attribute, 5001)
+	for j := range attrs {
+		attr := base.NewFloatAttribute()
+		attr.SetName(fmt.Sprintf("%d", j))
+		attrs[j] = attr
+	}
+	class := base.NewCategoricalAttribute()
+	class.SetName("class")
+	class.GetSysValFromString("no")
+	class.GetSysValFromString("yes")
+	attrs[5000] = class
+	inst := base.NewSparseInstances(attrs, 400)
+	for i := 0; i < inst.Rows; i++ {
+		for k := 1; k <= 5; k++ {
+			inst.Set(i, 10+(i*7919*k)%4990, 1)
+		}
+		if i%2 == 0 {
+			inst.Set(i, (i/2)%10, 1)
+			inst.SetAttrStr(i, 5000, "yes")
+		} else {
+			inst.SetAttrStr(i, 5000, "no")
+		}
+	}
+	trainData, testData := base.InstancesTrainTestSplitWithSeed(inst, 0.25, 1)
+
+	cls := NewLinearSVM(1)
+	cls.Seed = 1
+	cls.Fit(trainData)
+	if accuracy := eval.GetAccuracy(eval.GetConfusionMatrix(testData, cls.Predict(testData))); accuracy < 0.95 {
+		testEnv.Error(accuracy)
+	}
+	for j := 10; j < 5000; j++ {
+		if math.Abs(cls.Weights[0][j]) > math.Abs(cls.Weights[0][j%10]) {
+			testEnv.Fatalf("Feature %d shouldn't outweigh feature %d", j, j%10)
+		}
+	}
+}
+
+func TestLinearSVMParams(testEnv *testing.T) {
+	if err := DefaultLinearSVMParams().Validate(); err != nil {
+		testEnv.Error(err)
+	}
+	for _, params := range []LinearSVMParams{
+		{C: 0, Solver: "dual", MaxIterations: 10},
+		{C: 1, Solver: "dual", MaxIterations: 0},
+		{C: 1, Solver: "dual", MaxIterations: 10, Tolerance: -1},
+		{C: 1, Solver: "newton", MaxIterations: 10},
+	} {
+		if _, err := NewLinearSVMFromParams(params); err == nil {
+			testEnv.Errorf("%+v should be invalid", params)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Package mathutil provides numerically stable versions of common
+// calculations, so that models don't overflow or underflow when
+// scores or probabilities get extreme (e.g. on wide datasets).
+package mathutil
+
+import "math"
+
+// LogSumExp returns log(sum(exp(x))) without overflowing or
+// underflowing, by factoring out the largest value. It returns -Inf
+// if xs is empty.
+func LogSumExp(xs []float64) float64 {
+	max := math.Inf(-1)
+	for _, x := range xs {
+		if x > max {
+			max = x
+		}
+	}
+	if math.IsInf(max, 0) {
+		return max
+	}
+	sum := 0.0
+	for _, x := range xs {
+		sum += math.Exp(x - max)
+	}
+	return max + math.Log(sum)
+}
+
+// Softmax returns exp(x) / sum(exp(x)) for each x in xs, which sum
+// to one. If all of xs are -Inf, the result is uniform.
+func Softmax(xs []float64) []float64 {
+	ret := make([]float64, len(xs))
+	lse := LogSumExp(xs)
+	if math.IsInf(lse, -1) {
+		for i := range ret {
+			ret[i] = 1 / float64(len(xs))
+		}
+		return ret
+	}
+	for i, x := range xs {
+		ret[i] = math.Exp(x - lse)
+	}
+	return ret
+}
+
+// Sigmoid returns 1 / (1 + exp(-x)), computed so that exp never
+// overflows.
+func Sigmoid(x float64) float64 {
+	if x >= 0 {
+		return 1 / (1 + math.Exp(-x))
+	}
+	e := math.Exp(x)
+	return e / (1 + e)
+}
+
+// LogSigmoid returns log(Sigmoid(x)), which stays accurate for very
+// negative x where Sigmoid(x) underflows to zero.
+func LogSigmoid(x float64) float64 {
+	if x >= 0 {
+		return -math.Log1p(math.Exp(-x))
+	}
+	return x - math.Log1p(math.Exp(x))
+}
+
+// ClampedLog returns log(x), treating any x below floor (including
+// zero) as floor, so that the result is never -Inf or NaN.
+func ClampedLog(x, floor float64) float64 {
+	if x < floor || math.IsNaN(x) {
+		x = floor
+	}
+	return math.Log(x)
+}
+
+// KahanSum adds up xs using Kahan's compensated summation, which
+// loses far less precision than adding them in turn when there are
+// many values of different magnitudes.
+func KahanSum(xs []float64) float64 {
+	var k KahanSummer
+	for _, x := range xs {
+		k.Add(x)
+	}
+	return k.Sum()
+}
+
+// KahanSummer accumulates a compensated sum one value at a time.
+// Its zero value is an empty sum.
+type KahanSummer struct {
+	sum          float64
+	compensation float64
+}
+
+// Add adds x to the sum
+func (k *KahanSummer) Add(x float64) {
+	y := x - k.compensation
+	t := k.sum + y
+	k.compensation = (t - k.sum) - y
+	k.sum = t
+}
+
+// Sum returns the sum so far
+func (k *KahanSummer) Sum() float64 {
+	return k.sum
+}
@@ -0,0 +1,64 @@
+package mathutil
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLogSumExp(testEnv *testing.T) {
+	if v := LogSumExp([]float64{1000, 1000}); math.Abs(v-(1000+math.Log(2))) > 1e-9 {
+		testEnv.Error(v)
+	}
+	if v := LogSumExp([]float64{-1000, -1000}); math.Abs(v-(-1000+math.Log(2))) > 1e-9 {
+		testEnv.Error(v)
+	}
+	if v := LogSumExp(nil); !math.IsInf(v, -1) {
+		testEnv.Error(v)
+	}
+}
+
+func TestSoftmax(testEnv *testing.T) {
+	p := Softmax([]float64{1000, 1000, math.Inf(-1)})
+	if math.Abs(p[0]-0.5) > 1e-12 || math.Abs(p[1]-0.5) > 1e-12 || p[2] != 0 {
+		testEnv.Error(p)
+	}
+	p = Softmax([]float64{-800, -801})
+	if math.Abs(p[0]+p[1]-1) > 1e-12 || p[0] <= p[1] {
+		testEnv.Error(p)
+	}
+	p = Softmax([]float64{math.Inf(-1), math.Inf(-1)})
+	if p[0] != 0.5 || p[1] != 0.5 {
+		testEnv.Error(p)
+	}
+}
+
+func TestSigmoid(testEnv *testing.T) {
+	if Sigmoid(0) != 0.5 || Sigmoid(1000) != 1 || Sigmoid(-1000) != 0 {
+		testEnv.Error(Sigmoid(0), Sigmoid(1000), Sigmoid(-1000))
+	}
+	if v := LogSigmoid(-1000); v != -1000 {
+		testEnv.Error(v)
+	}
+	if v := LogSigmoid(2); math.Abs(v-math.Log(Sigmoid(2))) > 1e-12 {
+		testEnv.Error(v)
+	}
+}
+
+func TestClampedLog(testEnv *testing.T) {
+	if v := ClampedLog(0, 1e-10); math.Abs(v-math.Log(1e-10)) > 1e-12 {
+		testEnv.Error(v)
+	}
+	if v := ClampedLog(math.E, 1e-10); math.Abs(v-1) > 1e-12 {
+		testEnv.Error(v)
+	}
+}
+
+func TestKahanSum(testEnv *testing.T) {
+	xs := []float64{1}
+	for i := 0; i < 1000000; i++ {
+		xs = append(xs, 1e-16)
+	}
+	if v := KahanSum(xs); math.Abs(v-(1+1e-10)) > 1e-15 {
+		testEnv.Errorf("%.17g", v)
+	}
+}
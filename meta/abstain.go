@@ -0,0 +1,68 @@
+package meta
+
+import (
+	"encoding/gob"
+	"fmt"
+
+	base "github.com/sjwhitworth/golearn/base"
+)
+
+// AbstainingClassifier wraps a base.ProbabilisticClassifier, and
+// abstains from predicting rows whose most likely class is less
+// likely than Threshold: their predicted class is missing (see
+// base.Instances.IsMissing). Use evaluation.GetCoverageCurve to see
+// how accuracy trades off against the proportion of rows predicted,
+// and pick the Threshold.
+type AbstainingClassifier struct {
+	Classifier base.ProbabilisticClassifier
+	Threshold  float64
+}
+
+func init() {
+	gob.Register(&AbstainingClassifier{})
+}
+
+// NewAbstainingClassifier returns an AbstainingClassifier around an
+// untrained Classifier.
+func NewAbstainingClassifier(cls base.ProbabilisticClassifier, threshold float64) *AbstainingClassifier {
+	return &AbstainingClassifier{cls, threshold}
+}
+
+// Fit trains the wrapped Classifier
+func (a *AbstainingClassifier) Fit(from *base.Instances) {
+	a.Classifier.Fit(from)
+}
+
+// Predict returns the wrapped Classifier's most likely class for each
+// row of what, or a missing value where it isn't confident enough.
+func (a *AbstainingClassifier) Predict(what *base.Instances) *base.Instances {
+	ret := what.GeneratePredictionVector()
+	for i, probs := range a.Classifier.PredictProba(what) {
+		class, prob := base.MostLikelyClass(probs)
+		if class == "" || prob < a.Threshold {
+			ret.SetMissing(i, 0)
+			continue
+		}
+		ret.SetAttrStr(i, 0, class)
+	}
+	return ret
+}
+
+// PredictProba returns the wrapped Classifier's class probabilities
+func (a *AbstainingClassifier) PredictProba(what *base.Instances) []map[string]float64 {
+	return a.Classifier.PredictProba(what)
+}
+
+// Clone returns an untrained AbstainingClassifier with the same
+// Threshold around an untrained copy of the wrapped Classifier
+func (a *AbstainingClassifier) Clone() base.Classifier {
+	return &AbstainingClassifier{
+		base.CloneClassifier(a.Classifier).(base.ProbabilisticClassifier),
+		a.Threshold,
+	}
+}
+
+// String returns a human-readable summary
+func (a *AbstainingClassifier) String() string {
+	return fmt.Sprintf("AbstainingClassifier(%.4f, %s)", a.Threshold, a.Classifier)
+}
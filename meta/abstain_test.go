@@ -0,0 +1,43 @@
+package meta
+
+import (
+	"testing"
+
+	base "github.com/sjwhitworth/golearn/base"
+	eval "github.com/sjwhitworth/golearn/evaluation"
+	knn "github.com/sjwhitworth/golearn/knn"
+)
+
+func TestAbstainingClassifier(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	trainData, testData := base.InstancesTrainTestSplit(inst, 0.5)
+
+	lastCoverage := 1.1
+	for _, threshold := range []float64{0, 0.7, 1.0} {
+		cls := NewAbstainingClassifier(knn.NewKnnClassifier("euclidean", 5), threshold)
+		cls.Fit(trainData)
+		predictions := cls.Predict(testData)
+		coverage := eval.GetCoverage(predictions)
+		if threshold == 0 && coverage != 1 {
+			testEnv.Errorf("Abstained with no threshold (coverage %.2f)", coverage)
+		}
+		if coverage > lastCoverage {
+			testEnv.Errorf("Coverage rose to %.2f at threshold %.2f", coverage, threshold)
+		}
+		lastCoverage = coverage
+		for i := 0; i < predictions.Rows; i++ {
+			if predictions.IsMissing(i, 0) {
+				continue
+			}
+			if _, prob := base.MostLikelyClass(cls.PredictProba(testData.ViewRows([]int{i}))[0]); prob < threshold {
+				testEnv.Errorf("Predicted row %d with probability %.2f", i, prob)
+			}
+		}
+	}
+	if lastCoverage == 0 {
+		testEnv.Error("Abstained on everything")
+	}
+}
@@ -1,53 +1,76 @@
 package meta
 
 import (
+	"bytes"
+	"encoding/gob"
 	"fmt"
 	base "github.com/sjwhitworth/golearn/base"
 	"math/rand"
-	"runtime"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // BaggedModel trains base.Classifiers on subsets of the original
 // Instances and combine the results through voting
 type BaggedModel struct {
 	base.BaseClassifier
-	Models             []base.Classifier
-	RandomFeatures     int
+	Models         []base.Classifier
+	RandomFeatures int
+	// SampleFraction controls the size of each model's training
+	// set relative to the original Instances. Zero means the same
+	// size.
+	SampleFraction float64
+	// WithoutReplacement draws each model's training set without
+	// replacement (aka "pasting") instead of bootstrapping.
+	WithoutReplacement bool
+	// Seed makes the sampling reproducible. Zero means a
+	// different random seed each time.
+	Seed int64
+	// Progress, if set, is called after each model is trained.
+	Progress ProgressFunc
+	// TrackOOBError includes the running out-of-bag error in
+	// each Progress report, at the cost of an extra prediction
+	// per model.
+	TrackOOBError      bool
 	lock               sync.Mutex
 	selectedAttributes map[int][]base.Attribute
+	trainingRows       map[int][]int
+	trainingData       *base.Instances
+	fitted             int
+	seeds              *rand.Rand
+}
+
+func init() {
+	gob.Register(&BaggedModel{})
+}
+
+// checkRandomFeatures returns an error if RandomFeatures is negative
+// or more than the number of features of from.
+func (b *BaggedModel) checkRandomFeatures(from *base.Instances) error {
+	if b.RandomFeatures < 0 {
+		return fmt.Errorf("meta: RandomFeatures can't be negative, got %d", b.RandomFeatures)
+	}
+	if features := len(from.FeatureIndices()); b.RandomFeatures > features {
+		return fmt.Errorf("meta: RandomFeatures is %d, but there are only %d features", b.RandomFeatures, features)
+	}
+	return nil
 }
 
 // generateTrainingAttrs selects RandomFeatures number of base.Attributes from
 // the provided base.Instances.
-func (b *BaggedModel) generateTrainingAttrs(model int, from *base.Instances) []base.Attribute {
+func (b *BaggedModel) generateTrainingAttrs(model int, from *base.Instances, rng *rand.Rand) []base.Attribute {
 	ret := make([]base.Attribute, 0)
+	features := from.FeatureIndices()
 	if b.RandomFeatures == 0 {
-		for j := 0; j < from.Cols; j++ {
-			attr := from.GetAttr(j)
-			ret = append(ret, attr)
+		for _, j := range features {
+			ret = append(ret, from.GetAttr(j))
 		}
 	} else {
-		for {
-			if len(ret) >= b.RandomFeatures {
-				break
-			}
-			attrIndex := rand.Intn(from.Cols)
-			if attrIndex == from.ClassIndex {
-				continue
-			}
-			attr := from.GetAttr(attrIndex)
-			matched := false
-			for _, a := range ret {
-				if a.Equals(attr) {
-					matched = true
-					break
-				}
-			}
-			if !matched {
-				ret = append(ret, attr)
-			}
+		for _, k := range rng.Perm(len(features))[:b.RandomFeatures] {
+			ret = append(ret, from.GetAttr(features[k]))
 		}
 	}
 	ret = append(ret, from.GetClassAttr())
@@ -61,47 +84,372 @@ func (b *BaggedModel) generateTrainingAttrs(model int, from *base.Instances) []b
 // requested base.Instances with only the base.Attributes selected
 // for training the model.
 func (b *BaggedModel) generatePredictionInstances(model int, from *base.Instances) *base.Instances {
+	b.lock.Lock()
 	selected := b.selectedAttributes[model]
-	return from.SelectAttributes(selected)
+	b.lock.Unlock()
+	return from.ViewAttributes(selected)
+}
+
+// ModelInstances returns a version of from with only the
+// base.Attributes which the given model was trained on, e.g.
+// to call methods of the model other than Predict.
+func (b *BaggedModel) ModelInstances(model int, from *base.Instances) *base.Instances {
+	return b.generatePredictionInstances(model, from)
+}
+
+// Bag records exactly what one of the models of a BaggedModel was
+// trained on, so that results can be audited and the model's
+// training set rebuilt.
+type Bag struct {
+	// Rows are the indices of the training rows in the order they
+	// were drawn. When bootstrapping, a row drawn several times
+	// appears several times.
+	Rows []int
+	// Attributes are the base.Attributes the model was trained on,
+	// with the class Attribute last.
+	Attributes []base.Attribute
+}
+
+// GetBag returns the Bag the given model was trained on.
+//
+// IMPORTANT: this function panic()s if the model hasn't been
+// fitted.
+func (b *BaggedModel) GetBag(model int) Bag {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	attrs, ok := b.selectedAttributes[model]
+	if !ok {
+		panic("Call Fit() beforehand")
+	}
+	rows := make([]int, len(b.trainingRows[model]))
+	copy(rows, b.trainingRows[model])
+	return Bag{rows, append([]base.Attribute(nil), attrs...)}
+}
+
+// Bags returns the Bag each model was trained on, in the same order
+// as Models.
+//
+// IMPORTANT: this function panic()s if Fit hasn't been called.
+func (b *BaggedModel) Bags() []Bag {
+	ret := make([]Bag, len(b.Models))
+	for i := range ret {
+		ret[i] = b.GetBag(i)
+	}
+	return ret
+}
+
+// BagInstances rebuilds the Instances the given model was trained
+// on from the Instances passed to Fit.
+//
+// IMPORTANT: this function panic()s if the model hasn't been
+// fitted.
+func (b *BaggedModel) BagInstances(model int, from *base.Instances) *base.Instances {
+	bag := b.GetBag(model)
+	return from.SelectRows(bag.Rows).SelectAttributes(bag.Attributes)
+}
+
+// getSampleSize returns the number of rows each model is trained
+// on, as determined by SampleFraction.
+//
+// IMPORTANT: when sampling WithoutReplacement, the size can't be
+// larger than the row count.
+func (b *BaggedModel) getSampleSize(from *base.Instances) int {
+	if b.SampleFraction <= 0 {
+		return from.Rows
+	}
+	size := int(b.SampleFraction*float64(from.Rows) + 0.5)
+	if size < 1 {
+		size = 1
+	}
+	if b.WithoutReplacement && size > from.Rows {
+		size = from.Rows
+	}
+	return size
+}
+
+// generateTrainingRows picks the rows each model is trained on
+func (b *BaggedModel) generateTrainingRows(from *base.Instances, rng *rand.Rand) []int {
+	size := b.getSampleSize(from)
+	if b.WithoutReplacement {
+		return rng.Perm(from.Rows)[:size]
+	}
+	ret := make([]int, size)
+	for i := range ret {
+		ret[i] = rng.Intn(from.Rows)
+	}
+	return ret
 }
 
 // generateTrainingInstances generates RandomFeatures number of
 // attributes and returns a modified version of base.Instances
 // for training the model
-func (b *BaggedModel) generateTrainingInstances(model int, from *base.Instances) *base.Instances {
-	insts := from.SampleWithReplacement(from.Rows)
-	selected := b.generateTrainingAttrs(model, from)
+func (b *BaggedModel) generateTrainingInstances(model int, from *base.Instances, rng *rand.Rand) *base.Instances {
+	rows := b.generateTrainingRows(from, rng)
+	b.lock.Lock()
+	b.trainingRows[model] = rows
+	b.lock.Unlock()
+	insts := from.SelectRows(rows)
+	selected := b.generateTrainingAttrs(model, from, rng)
 	return insts.SelectAttributes(selected)
 }
 
+// newRand returns the random source used to seed each model's
+// sampling, as determined by Seed.
+func (b *BaggedModel) newRand() *rand.Rand {
+	seed := b.Seed
+	if seed == 0 {
+		seed = rand.Int63()
+	}
+	return rand.New(rand.NewSource(seed))
+}
+
 // AddModel adds a base.Classifier to the current model
 func (b *BaggedModel) AddModel(m base.Classifier) {
 	b.Models = append(b.Models, m)
 }
 
+// AddModels adds n base.Classifiers created by factory to the current model
+func (b *BaggedModel) AddModels(n int, factory base.ClassifierFactory) {
+	for i := 0; i < n; i++ {
+		b.AddModel(factory())
+	}
+}
+
 // Train generates and trains each model on a randomised subset of
 // Instances.
+//
+// IMPORTANT: this function panic()s if RandomFeatures is negative or
+// more than the number of features of from.
 func (b *BaggedModel) Fit(from *base.Instances) {
-	var wait sync.WaitGroup
+	if err := b.checkRandomFeatures(from); err != nil {
+		panic(err.Error())
+	}
 	b.selectedAttributes = make(map[int][]base.Attribute)
-	for i, m := range b.Models {
+	b.trainingRows = make(map[int][]int)
+	b.trainingData = from
+	b.fitted = 0
+	b.seeds = b.newRand()
+	b.fitModels()
+}
+
+// WarmFit trains the models added since the last call to Fit or
+// WarmFit on the same Instances, leaving the existing ones as they
+// are. This allows an ensemble to be grown a few models at a time.
+//
+// IMPORTANT: this function panic()s if Fit hasn't been called.
+func (b *BaggedModel) WarmFit() {
+	if b.trainingData == nil {
+		panic("Call Fit() beforehand")
+	}
+	b.fitModels()
+}
+
+// Progress reports how far a BaggedModel has got with training,
+// after each model finishes.
+type Progress struct {
+	// Model is the index of the model which just finished
+	Model int
+	// Done is the number of models trained so far, out of Total
+	Done  int
+	Total int
+	// Elapsed is the time since training started
+	Elapsed time.Duration
+	// OOBError is the out-of-bag error of the models trained so far,
+	// or -1 if TrackOOBError isn't set
+	OOBError float64
+}
+
+// ProgressFunc is called by a BaggedModel with its Progress during
+// training. Returning false stops training early: the models which
+// haven't been reported on yet are discarded.
+type ProgressFunc func(Progress) bool
+
+// fitModels trains each of the models which hasn't been yet
+func (b *BaggedModel) fitModels() {
+	start := time.Now()
+	first := b.fitted
+	total := len(b.Models) - first
+	// Each model gets its own source so that the
+	// result doesn't depend on goroutine scheduling
+	rngs := make([]*rand.Rand, total)
+	pending := make(chan int, total)
+	for i := range rngs {
+		rngs[i] = rand.New(rand.NewSource(b.seeds.Int63()))
+		pending <- first + i
+	}
+	close(pending)
+
+	// Create workers to train the models, each of which needs a
+	// copy of the training data
+	var stopped int32
+	finished := make(chan int)
+	var wait sync.WaitGroup
+	workers := base.GetConfig().WorkersFor(base.InstancesBytes(b.trainingData.Rows, b.trainingData.Cols))
+	for w := 0; w < workers; w++ {
 		wait.Add(1)
-		go func(c base.Classifier, f *base.Instances, model int) {
-			l := b.generateTrainingInstances(model, f)
-			c.Fit(l)
+		go func() {
+			for i := range pending {
+				if atomic.LoadInt32(&stopped) != 0 {
+					continue
+				}
+				l := b.generateTrainingInstances(i, b.trainingData, rngs[i-first])
+				b.Models[i].Fit(l)
+				finished <- i
+			}
 			wait.Done()
-		}(m, from, i)
+		}()
+	}
+	go func() {
+		wait.Wait()
+		close(finished)
+	}()
+
+	// Report on each model as it finishes
+	trained := make([]bool, len(b.Models))
+	for i := 0; i < first; i++ {
+		trained[i] = true
+	}
+	var voting []map[string]int
+	if b.Progress != nil && b.TrackOOBError {
+		voting = b.newOOBVoting()
+		for i := 0; i < first; i++ {
+			b.addOOBVotes(i, voting)
+		}
+	}
+	done := 0
+	for i := range finished {
+		if atomic.LoadInt32(&stopped) != 0 {
+			continue
+		}
+		trained[i] = true
+		done++
+		if b.Progress == nil {
+			continue
+		}
+		p := Progress{i, done, total, time.Since(start), -1}
+		if voting != nil {
+			b.addOOBVotes(i, voting)
+			p.OOBError = b.getOOBError(voting)
+		}
+		if !b.Progress(p) {
+			atomic.StoreInt32(&stopped, 1)
+		}
+	}
+	if atomic.LoadInt32(&stopped) != 0 {
+		b.discardUntrained(trained)
+	}
+	b.fitted = len(b.Models)
+}
+
+// discardUntrained removes the models which weren't trained
+// after training was stopped early.
+func (b *BaggedModel) discardUntrained(trained []bool) {
+	models := make([]base.Classifier, 0)
+	selectedAttributes := make(map[int][]base.Attribute)
+	trainingRows := make(map[int][]int)
+	for i, m := range b.Models {
+		if !trained[i] {
+			continue
+		}
+		selectedAttributes[len(models)] = b.selectedAttributes[i]
+		trainingRows[len(models)] = b.trainingRows[i]
+		models = append(models, m)
+	}
+	b.Models = models
+	b.selectedAttributes = selectedAttributes
+	b.trainingRows = trainingRows
+}
+
+// newOOBVoting returns an empty vote count for each training row
+func (b *BaggedModel) newOOBVoting() []map[string]int {
+	ret := make([]map[string]int, b.trainingData.Rows)
+	for i := range ret {
+		ret[i] = make(map[string]int)
+	}
+	return ret
+}
+
+// addOOBVotes adds the given model's votes for each of the
+// training rows it wasn't trained on.
+func (b *BaggedModel) addOOBVotes(model int, voting []map[string]int) {
+	from := b.trainingData
+	inBag := make([]bool, from.Rows)
+	b.lock.Lock()
+	rows := b.trainingRows[model]
+	b.lock.Unlock()
+	for _, r := range rows {
+		inBag[r] = true
+	}
+	l := b.generatePredictionInstances(model, from)
+	predictions := b.Models[model].Predict(l)
+	for i := 0; i < from.Rows; i++ {
+		if !inBag[i] {
+			voting[i][predictions.GetClass(i)]++
+		}
+	}
+}
+
+// getOOBError returns the proportion of training rows with
+// votes whose majority class is wrong.
+func (b *BaggedModel) getOOBError(voting []map[string]int) float64 {
+	wrong, total := 0, 0
+	for i := range voting {
+		if len(voting[i]) == 0 {
+			continue
+		}
+		total++
+		if majorityClass(voting[i]) != b.trainingData.GetClass(i) {
+			wrong++
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(wrong) / float64(total)
+}
+
+// OOBError returns the out-of-bag error estimate: the proportion of
+// the training rows misclassified by the majority vote of the models
+// which weren't trained on them. Rows used by every model are ignored.
+//
+// IMPORTANT: this function panic()s if Fit hasn't been called.
+func (b *BaggedModel) OOBError() float64 {
+	if b.trainingData == nil {
+		panic("Call Fit() beforehand")
 	}
-	wait.Wait()
+	voting := b.newOOBVoting()
+	for model := 0; model < b.fitted; model++ {
+		b.addOOBVotes(model, voting)
+	}
+	return b.getOOBError(voting)
+}
+
+// majorityClass returns the class with the most votes, visiting
+// the classes in order so that ties are broken consistently
+func majorityClass(votes map[string]int) string {
+	classes := make([]string, 0, len(votes))
+	for c := range votes {
+		classes = append(classes, c)
+	}
+	sort.Strings(classes)
+	maxClass := ""
+	maxCount := 0
+	for _, c := range classes {
+		if votes[c] > maxCount {
+			maxClass = c
+			maxCount = votes[c]
+		}
+	}
+	return maxClass
 }
 
 // Predict gathers predictions from all the classifiers
 // and outputs the most common (majority) class
 //
-// IMPORTANT: in the event of a tie, the first class which
-// achieved the tie value is output.
+// IMPORTANT: in the event of a tie, the class which sorts
+// first is output.
 func (b *BaggedModel) Predict(from *base.Instances) *base.Instances {
-	n := runtime.NumCPU()
+	n := base.GetConfig().WorkersFor(base.InstancesBytes(from.Rows, from.Cols))
 	// Channel to receive the results as they come in
 	votes := make(chan *base.Instances, n)
 	// Count the votes for each class
@@ -164,21 +512,148 @@ func (b *BaggedModel) Predict(from *base.Instances) *base.Instances {
 	// Generate the overall consensus
 	ret := from.GeneratePredictionVector()
 	for i := range voting {
-		maxClass := ""
-		maxCount := 0
-		// Find the most popular class
-		for c := range voting[i] {
-			votes := voting[i][c]
-			if votes > maxCount {
-				maxClass = c
-				maxCount = votes
+		ret.SetAttrStr(i, 0, majorityClass(voting[i]))
+	}
+	return ret
+}
+
+// PredictProba averages the class probabilities estimated by each
+// of the models. Models which aren't base.ProbabilisticClassifiers
+// contribute a probability of one to the class they predict.
+func (b *BaggedModel) PredictProba(from *base.Instances) []map[string]float64 {
+	results := make([][]map[string]float64, len(b.Models))
+	workers := base.GetConfig().WorkersFor(base.InstancesBytes(from.Rows, from.Cols))
+	base.Parallel(len(b.Models), workers, func(model int) {
+		c := b.Models[model]
+		l := b.generatePredictionInstances(model, from)
+		if p, ok := c.(base.ProbabilisticClassifier); ok {
+			results[model] = p.PredictProba(l)
+		} else {
+			predictions := c.Predict(l)
+			dist := make([]map[string]float64, predictions.Rows)
+			for j := range dist {
+				dist[j] = make(map[string]float64)
+				dist[j][predictions.GetClass(j)] = 1.0
+			}
+			results[model] = dist
+		}
+	})
+
+	ret := make([]map[string]float64, from.Rows)
+	for j := range ret {
+		ret[j] = make(map[string]float64)
+		for _, r := range results {
+			for c := range r[j] {
+				ret[j][c] += r[j][c] / float64(len(b.Models))
 			}
 		}
-		ret.SetAttrStr(i, 0, maxClass)
 	}
 	return ret
 }
 
+// Clone returns an untrained BaggedModel with the same options and
+// untrained copies of each of the models
+func (b *BaggedModel) Clone() base.Classifier {
+	ret := &BaggedModel{
+		RandomFeatures:     b.RandomFeatures,
+		SampleFraction:     b.SampleFraction,
+		WithoutReplacement: b.WithoutReplacement,
+		Seed:               b.Seed,
+		Progress:           b.Progress,
+		TrackOOBError:      b.TrackOOBError,
+	}
+	for _, m := range b.Models {
+		ret.AddModel(base.CloneClassifier(m))
+	}
+	return ret
+}
+
+// baggedModelGob is how a BaggedModel is serialised
+type baggedModelGob struct {
+	Models             []base.Classifier
+	RandomFeatures     int
+	SampleFraction     float64
+	WithoutReplacement bool
+	Seed               int64
+	SelectedAttributes map[int][]base.Attribute
+	TrainingRows       map[int][]int
+}
+
+// GobEncode serialises the BaggedModel, its models and the Bag
+// each of them was trained on. The models must be registered with
+// gob.Register.
+func (b *BaggedModel) GobEncode() ([]byte, error) {
+	var buf bytes.Buffer
+	err := gob.NewEncoder(&buf).Encode(baggedModelGob{
+		b.Models,
+		b.RandomFeatures,
+		b.SampleFraction,
+		b.WithoutReplacement,
+		b.Seed,
+		b.selectedAttributes,
+		b.trainingRows,
+	})
+	return buf.Bytes(), err
+}
+
+// GobDecode deserialises a BaggedModel. It can predict straight
+// away, but the Instances it was trained on aren't kept, so OOBError
+// and WarmFit aren't available until it's fitted again.
+func (b *BaggedModel) GobDecode(data []byte) error {
+	var g baggedModelGob
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&g); err != nil {
+		return err
+	}
+	b.Models = g.Models
+	b.RandomFeatures = g.RandomFeatures
+	b.SampleFraction = g.SampleFraction
+	b.WithoutReplacement = g.WithoutReplacement
+	b.Seed = g.Seed
+	b.selectedAttributes = g.SelectedAttributes
+	b.trainingRows = g.TrainingRows
+	b.fitted = len(b.Models)
+	return nil
+}
+
+// Save serialises the trained BaggedModel to the file at path,
+// see LoadBaggedModel.
+func (b *BaggedModel) Save(path string) error {
+	return base.SaveClassifier(path, b)
+}
+
+// LoadBaggedModel deserialises a BaggedModel saved to the file
+// at path.
+func LoadBaggedModel(path string) (*BaggedModel, error) {
+	c, err := base.LoadClassifier(path)
+	if err != nil {
+		return nil, err
+	}
+	ret, ok := c.(*BaggedModel)
+	if !ok {
+		return nil, fmt.Errorf("meta: %s contains a %T, not a BaggedModel", path, c)
+	}
+	return ret, nil
+}
+
+// Summary returns the BaggedModel's options and how many
+// of each type of base.Classifier it contains
+func (b *BaggedModel) Summary() string {
+	counts := make(map[string]int)
+	for _, m := range b.Models {
+		counts[strings.TrimPrefix(fmt.Sprintf("%T", m), "*")]++
+	}
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for i, name := range names {
+		names[i] = fmt.Sprintf("%d %s", counts[name], name)
+	}
+	options := base.FormatParams(base.GetParams(b))
+	return fmt.Sprintf("BaggedModel(%s)\n%d model(s): %s\n", options, len(b.Models), strings.Join(names, ", "))
+}
+
 // String returns a human-readable representation of the
 // BaggedModel and everything it contains
 func (b *BaggedModel) String() string {
@@ -81,3 +81,215 @@ func TestRandomForest1(testEnv *testing.T) {
 	fmt.Println(eval.GetMacroRecall(confusionMat))
 	fmt.Println(eval.GetSummary(confusionMat))
 }
+
+// rowCounter is a trivial base.Classifier which remembers
+// how many rows it was trained on.
+type rowCounter struct {
+	rows int
+}
+
+func (r *rowCounter) Fit(on *base.Instances) {
+	r.rows = on.Rows
+}
+
+func (r *rowCounter) Predict(what *base.Instances) *base.Instances {
+	return what.GeneratePredictionVector()
+}
+
+func (r *rowCounter) String() string {
+	return fmt.Sprintf("rowCounter(%d)", r.rows)
+}
+
+func TestBaggingSampleFraction(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		panic(err)
+	}
+
+	rf := new(BaggedModel)
+	rf.AddModels(5, func() base.Classifier {
+		return new(rowCounter)
+	})
+	rf.Fit(inst)
+	for _, m := range rf.Models {
+		if m.(*rowCounter).rows != 150 {
+			testEnv.Error(m)
+		}
+	}
+
+	rf.SampleFraction = 0.5
+	rf.WithoutReplacement = true
+	rf.Fit(inst)
+	for _, m := range rf.Models {
+		if m.(*rowCounter).rows != 75 {
+			testEnv.Error(m)
+		}
+	}
+
+	rf.SampleFraction = 2.0
+	rf.Fit(inst)
+	for _, m := range rf.Models {
+		if m.(*rowCounter).rows != 150 {
+			testEnv.Error(m)
+		}
+	}
+}
+
+func TestBaggingWarmFit(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		panic(err)
+	}
+
+	rf := new(BaggedModel)
+	rf.SampleFraction = 0.5
+	rf.AddModels(2, func() base.Classifier {
+		return new(rowCounter)
+	})
+	rf.Fit(inst)
+	rf.SampleFraction = 0.2
+	rf.AddModels(3, func() base.Classifier {
+		return new(rowCounter)
+	})
+	rf.WarmFit()
+	for i, m := range rf.Models {
+		expected := 30
+		if i < 2 {
+			expected = 75
+		}
+		if m.(*rowCounter).rows != expected {
+			testEnv.Error(m)
+		}
+	}
+}
+
+func TestBaggingProgress(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		panic(err)
+	}
+	filt := filters.NewChiMergeFilter(inst, 0.90)
+	filt.AddAllNumericAttributes()
+	filt.Build()
+	filt.Run(inst)
+
+	rf := new(BaggedModel)
+	rf.RandomFeatures = 2
+	rf.TrackOOBError = true
+	rf.AddModels(10, func() base.Classifier {
+		return trees.NewRandomTree(2)
+	})
+	reports := make([]Progress, 0)
+	rf.Progress = func(p Progress) bool {
+		reports = append(reports, p)
+		return true
+	}
+	rf.Fit(inst)
+	if len(reports) != 10 {
+		testEnv.Fatal(reports)
+	}
+	for i, p := range reports {
+		if p.Done != i+1 || p.Total != 10 || p.OOBError < 0 || p.OOBError > 1 {
+			testEnv.Error(p)
+		}
+	}
+	if reports[9].OOBError != rf.OOBError() {
+		testEnv.Errorf("Expected an OOB error of %f, got %f", rf.OOBError(), reports[9].OOBError)
+	}
+
+	// Stop after three models
+	rf.TrackOOBError = false
+	rf.Progress = func(p Progress) bool {
+		if p.OOBError != -1 {
+			testEnv.Error(p)
+		}
+		return p.Done < 3
+	}
+	rf.Fit(inst)
+	if len(rf.Models) != 3 {
+		testEnv.Errorf("Expected 3 models, got %d", len(rf.Models))
+	}
+	predictions := rf.Predict(inst)
+	if predictions.Rows != inst.Rows {
+		testEnv.Error(predictions)
+	}
+}
+
+func TestBaggingBags(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		panic(err)
+	}
+
+	rf := new(BaggedModel)
+	rf.RandomFeatures = 2
+	rf.SampleFraction = 0.2
+	rf.Seed = 42
+	rf.AddModels(4, func() base.Classifier {
+		return new(rowCounter)
+	})
+	rf.Fit(inst)
+	bags := rf.Bags()
+	if len(bags) != 4 {
+		testEnv.Fatal(bags)
+	}
+	for i, bag := range bags {
+		if len(bag.Rows) != 30 || len(bag.Attributes) != 3 {
+			testEnv.Error(i, bag)
+		}
+		if !bag.Attributes[2].Equals(inst.GetClassAttr()) {
+			testEnv.Error(bag.Attributes)
+		}
+		rebuilt := rf.BagInstances(i, inst)
+		if rebuilt.Rows != rf.Models[i].(*rowCounter).rows || rebuilt.Cols != 3 {
+			testEnv.Error(rebuilt.Rows, rebuilt.Cols)
+		}
+		if rebuilt.RowStr(0) != inst.SelectAttributes(bag.Attributes).RowStr(bag.Rows[0]) {
+			testEnv.Error(rebuilt.RowStr(0))
+		}
+	}
+
+	// The same Seed reproduces the same Bags
+	rf.Fit(inst)
+	for i, bag := range rf.Bags() {
+		for k, r := range bag.Rows {
+			if r != bags[i].Rows[k] {
+				testEnv.Fatalf("Model %d differs at row %d", i, k)
+			}
+		}
+	}
+}
+
+func TestBaggingRandomFeatures(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		panic(err)
+	}
+
+	// Every feature can be picked, each only once
+	rf := new(BaggedModel)
+	rf.RandomFeatures = 4
+	rf.Seed = 1
+	rf.AddModels(3, func() base.Classifier {
+		return new(rowCounter)
+	})
+	rf.Fit(inst)
+	for i, bag := range rf.Bags() {
+		seen := make(map[string]bool)
+		for _, a := range bag.Attributes[:4] {
+			seen[a.GetName()] = true
+		}
+		if len(bag.Attributes) != 5 || len(seen) != 4 {
+			testEnv.Error(i, bag.Attributes)
+		}
+	}
+
+	// Asking for more features than there are used to hang
+	defer func() {
+		if recover() == nil {
+			testEnv.Error("Should panic with more RandomFeatures than features")
+		}
+	}()
+	rf.RandomFeatures = 5
+	rf.Fit(inst)
+}
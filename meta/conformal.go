@@ -0,0 +1,249 @@
+package meta
+
+import (
+	"encoding/gob"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	base "github.com/sjwhitworth/golearn/base"
+)
+
+// The types in this file wrap a model to make inductive conformal
+// predictions: part of the training data is held back to calibrate
+// how wrong the model tends to be, and predictions are sets of
+// classes (or intervals of values) which contain the truth for at
+// least 1-Significance of rows, whatever the model, as long as new
+// rows are drawn from the same distribution as the training data.
+
+// DefaultCalibrationSize is the proportion of the training rows held
+// back to calibrate conformal predictions.
+const DefaultCalibrationSize = 0.25
+
+// ConformalClassifier makes prediction sets with a
+// base.ProbabilisticClassifier. A row's nonconformity is one minus
+// the probability of its class.
+type ConformalClassifier struct {
+	Classifier base.ProbabilisticClassifier
+	// Significance is the proportion of rows whose set may miss
+	// their class, e.g. 0.1 for 90% coverage
+	Significance float64
+	// CalibrationSize is the proportion of the training rows held
+	// back from the Classifier to calibrate it
+	CalibrationSize float64
+	// Seed makes the calibration split reproducible. Zero means a
+	// random seed.
+	Seed int64
+	// scores holds the nonconformity of each calibration row,
+	// sorted
+	scores []float64
+}
+
+// ConformalRegressor makes prediction intervals with a base.Classifier
+// which predicts a numeric class. A row's nonconformity is the
+// absolute difference between its value and the prediction.
+type ConformalRegressor struct {
+	Regressor    base.Classifier
+	Significance float64
+	// CalibrationSize and Seed are as for ConformalClassifier
+	CalibrationSize float64
+	Seed            int64
+	scores          []float64
+}
+
+func init() {
+	gob.Register(&ConformalClassifier{})
+	gob.Register(&ConformalRegressor{})
+}
+
+// NewConformalClassifier returns a ConformalClassifier around an
+// untrained Classifier with the given Significance.
+func NewConformalClassifier(cls base.ProbabilisticClassifier, significance float64) *ConformalClassifier {
+	return &ConformalClassifier{cls, significance, DefaultCalibrationSize, 0, nil}
+}
+
+// NewConformalRegressor returns a ConformalRegressor around an
+// untrained Regressor with the given Significance.
+func NewConformalRegressor(reg base.Classifier, significance float64) *ConformalRegressor {
+	return &ConformalRegressor{reg, significance, DefaultCalibrationSize, 0, nil}
+}
+
+// splitCalibration randomly divides the rows of from into the
+// training rows and the given proportion of calibration rows, of
+// which there's at least one.
+func splitCalibration(from *base.Instances, proportion float64, seed int64) (*base.Instances, *base.Instances) {
+	if seed == 0 {
+		seed = rand.Int63()
+	}
+	perm := rand.New(rand.NewSource(seed)).Perm(from.Rows)
+	n := int(proportion * float64(from.Rows))
+	if n < 1 {
+		n = 1
+	}
+	return from.SelectRows(perm[n:]), from.SelectRows(perm[:n])
+}
+
+// conformalThreshold returns the largest nonconformity a prediction
+// can have at the given significance, from the sorted calibration
+// scores: +Inf if there are too few of them to be that sure.
+func conformalThreshold(scores []float64, significance float64) float64 {
+	if scores == nil {
+		panic("Call Fit() beforehand")
+	}
+	n := len(scores)
+	k := int(math.Ceil(float64(n+1) * (1 - significance)))
+	if k > n {
+		return math.Inf(1)
+	}
+	if k < 1 {
+		k = 1
+	}
+	return scores[k-1]
+}
+
+// Fit trains the Classifier on some of the rows of from, and
+// calibrates it with the rest.
+func (c *ConformalClassifier) Fit(from *base.Instances) {
+	train, calibration := splitCalibration(from, c.CalibrationSize, c.Seed)
+	c.Classifier.Fit(train)
+	c.Calibrate(calibration)
+}
+
+// Calibrate works out the nonconformity scores of an already trained
+// Classifier on the given rows, which it mustn't have been trained
+// on. Use it instead of Fit to train the Classifier separately.
+func (c *ConformalClassifier) Calibrate(calibration *base.Instances) {
+	c.scores = make([]float64, calibration.Rows)
+	for i, probs := range c.Classifier.PredictProba(calibration) {
+		c.scores[i] = 1 - probs[calibration.GetClass(i)]
+	}
+	sort.Float64s(c.scores)
+}
+
+// PredictSets returns the classes which can't be ruled out for each
+// row of what at the Significance, most likely first. A set can be
+// empty, if even the most likely class is unusual.
+//
+// IMPORTANT: this function panic()s if Fit hasn't been called, or if
+// the class Attribute isn't a CategoricalAttribute.
+func (c *ConformalClassifier) PredictSets(what *base.Instances) [][]string {
+	threshold := conformalThreshold(c.scores, c.Significance)
+	classes := what.GetClassAttr().(*base.CategoricalAttribute).GetValues()
+	ret := make([][]string, what.Rows)
+	for i, probs := range c.Classifier.PredictProba(what) {
+		set := make([]string, 0)
+		for _, class := range classes {
+			if 1-probs[class] <= threshold {
+				set = append(set, class)
+			}
+		}
+		sort.Stable(&byProbability{set, probs})
+		ret[i] = set
+	}
+	return ret
+}
+
+// byProbability sorts classes from the most to the least likely
+type byProbability struct {
+	classes []string
+	probs   map[string]float64
+}
+
+func (b *byProbability) Len() int {
+	return len(b.classes)
+}
+
+func (b *byProbability) Swap(i, j int) {
+	b.classes[i], b.classes[j] = b.classes[j], b.classes[i]
+}
+
+func (b *byProbability) Less(i, j int) bool {
+	return b.probs[b.classes[i]] > b.probs[b.classes[j]]
+}
+
+// Predict returns the Classifier's predictions
+func (c *ConformalClassifier) Predict(what *base.Instances) *base.Instances {
+	return c.Classifier.Predict(what)
+}
+
+// PredictProba returns the Classifier's class probabilities
+func (c *ConformalClassifier) PredictProba(what *base.Instances) []map[string]float64 {
+	return c.Classifier.PredictProba(what)
+}
+
+// Clone returns an untrained ConformalClassifier with the same
+// parameters around an untrained copy of the Classifier
+func (c *ConformalClassifier) Clone() base.Classifier {
+	return &ConformalClassifier{
+		base.CloneClassifier(c.Classifier).(base.ProbabilisticClassifier),
+		c.Significance,
+		c.CalibrationSize,
+		c.Seed,
+		nil,
+	}
+}
+
+// String returns a human-readable summary
+func (c *ConformalClassifier) String() string {
+	return fmt.Sprintf("ConformalClassifier(%.4f, %s)", c.Significance, c.Classifier)
+}
+
+// Fit trains the Regressor on some of the rows of from, and
+// calibrates it with the rest.
+func (r *ConformalRegressor) Fit(from *base.Instances) {
+	train, calibration := splitCalibration(from, r.CalibrationSize, r.Seed)
+	r.Regressor.Fit(train)
+	r.Calibrate(calibration)
+}
+
+// Calibrate works out the nonconformity scores of an already trained
+// Regressor on the given rows, which it mustn't have been trained on.
+func (r *ConformalRegressor) Calibrate(calibration *base.Instances) {
+	predictions := r.Regressor.Predict(calibration)
+	r.scores = make([]float64, calibration.Rows)
+	for i := range r.scores {
+		r.scores[i] = math.Abs(calibration.Get(i, calibration.ClassIndex) - predictions.Get(i, 0))
+	}
+	sort.Float64s(r.scores)
+}
+
+// PredictIntervals returns the lower and upper bounds of the
+// interval of values which can't be ruled out for each row of what at
+// the Significance. They're infinite if there were too few
+// calibration rows.
+//
+// IMPORTANT: this function panic()s if Fit hasn't been called.
+func (r *ConformalRegressor) PredictIntervals(what *base.Instances) ([]float64, []float64) {
+	threshold := conformalThreshold(r.scores, r.Significance)
+	predictions := r.Regressor.Predict(what)
+	lower := make([]float64, what.Rows)
+	upper := make([]float64, what.Rows)
+	for i := range lower {
+		lower[i] = predictions.Get(i, 0) - threshold
+		upper[i] = predictions.Get(i, 0) + threshold
+	}
+	return lower, upper
+}
+
+// Predict returns the Regressor's predictions
+func (r *ConformalRegressor) Predict(what *base.Instances) *base.Instances {
+	return r.Regressor.Predict(what)
+}
+
+// Clone returns an untrained ConformalRegressor with the same
+// parameters around an untrained copy of the Regressor
+func (r *ConformalRegressor) Clone() base.Classifier {
+	return &ConformalRegressor{
+		base.CloneClassifier(r.Regressor),
+		r.Significance,
+		r.CalibrationSize,
+		r.Seed,
+		nil,
+	}
+}
+
+// String returns a human-readable summary
+func (r *ConformalRegressor) String() string {
+	return fmt.Sprintf("ConformalRegressor(%.4f, %s)", r.Significance, r.Regressor)
+}
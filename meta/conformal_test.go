@@ -0,0 +1,101 @@
+package meta
+
+import (
+	"testing"
+
+	base "github.com/sjwhitworth/golearn/base"
+	knn "github.com/sjwhitworth/golearn/knn"
+)
+
+// meanRegressor always predicts the mean class value it was trained on
+type meanRegressor struct {
+	mean float64
+}
+
+func (m *meanRegressor) Fit(from *base.Instances) {
+	m.mean = 0
+	for i := 0; i < from.Rows; i++ {
+		m.mean += from.Get(i, from.ClassIndex) / float64(from.Rows)
+	}
+}
+
+func (m *meanRegressor) Predict(what *base.Instances) *base.Instances {
+	ret := what.GeneratePredictionVector()
+	for i := 0; i < ret.Rows; i++ {
+		ret.Set(i, 0, m.mean)
+	}
+	return ret
+}
+
+func (m *meanRegressor) String() string {
+	return "meanRegressor"
+}
+
+func TestConformalClassifier(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	inst.ShuffleWithSeed(1)
+	trainData, testData := inst.SelectRows(seq(0, 100)), inst.SelectRows(seq(100, 150))
+
+	cls := NewConformalClassifier(knn.NewKnnClassifier("euclidean", 5), 0.1)
+	cls.Seed = 1
+	cls.Fit(trainData)
+	covered, sizes := 0, 0
+	for i, set := range cls.PredictSets(testData) {
+		sizes += len(set)
+		for _, class := range set {
+			if class == testData.GetClass(i) {
+				covered++
+			}
+		}
+	}
+	if covered < 40 {
+		testEnv.Errorf("Only %d of 50 sets contained the class", covered)
+	}
+	if sizes >= 150 {
+		testEnv.Error("Every set contained every class")
+	}
+
+	// Nothing can be ruled out with too few calibration rows
+	cls.Significance = 0.001
+	for _, set := range cls.PredictSets(testData) {
+		if len(set) != 3 {
+			testEnv.Fatal(set)
+		}
+	}
+}
+
+func TestConformalRegressor(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	// Predict the petal width
+	inst = inst.SelectAttributes([]base.Attribute{inst.GetAttr(0), inst.GetAttr(1), inst.GetAttr(2), inst.GetAttr(3)})
+	inst.ShuffleWithSeed(1)
+	trainData, testData := inst.SelectRows(seq(0, 100)), inst.SelectRows(seq(100, 150))
+
+	reg := NewConformalRegressor(&meanRegressor{}, 0.2)
+	reg.Seed = 1
+	reg.Fit(trainData)
+	lower, upper := reg.PredictIntervals(testData)
+	covered := 0
+	for i := range lower {
+		if val := testData.Get(i, 3); val >= lower[i] && val <= upper[i] {
+			covered++
+		}
+	}
+	if covered < 35 {
+		testEnv.Errorf("Only %d of 50 intervals contained the value", covered)
+	}
+}
+
+func seq(from, to int) []int {
+	ret := make([]int, 0, to-from)
+	for i := from; i < to; i++ {
+		ret = append(ret, i)
+	}
+	return ret
+}
@@ -0,0 +1,102 @@
+package meta
+
+import (
+	"encoding/gob"
+	"fmt"
+
+	base "github.com/sjwhitworth/golearn/base"
+	eval "github.com/sjwhitworth/golearn/evaluation"
+)
+
+// ThresholdClassifier wraps a base.ProbabilisticClassifier, and
+// predicts Class for the rows whose probability of it is at least
+// Threshold, rather than whenever it's the most likely class. Other
+// rows get the most likely of the other classes. This suits binary
+// problems where the classes are imbalanced, or mistakes cost
+// different amounts: use Tune (or evaluation.TuneThreshold) to pick
+// the Threshold.
+type ThresholdClassifier struct {
+	Classifier base.ProbabilisticClassifier
+	Class      string
+	Threshold  float64
+}
+
+func init() {
+	gob.Register(&ThresholdClassifier{})
+}
+
+// NewThresholdClassifier returns a ThresholdClassifier around an
+// untrained Classifier.
+func NewThresholdClassifier(cls base.ProbabilisticClassifier, class string, threshold float64) *ThresholdClassifier {
+	return &ThresholdClassifier{cls, class, threshold}
+}
+
+// Fit trains the wrapped Classifier
+func (t *ThresholdClassifier) Fit(from *base.Instances) {
+	t.Classifier.Fit(from)
+}
+
+// Tune sets the Threshold to the one which maximises metric over
+// predictions for from made by cross-validation with the given number
+// of folds (see evaluation.CrossValPredict and
+// evaluation.TuneThreshold), then trains the wrapped Classifier on
+// all of from.
+func (t *ThresholdClassifier) Tune(from *base.Instances, metric eval.ThresholdMetric, folds int) error {
+	if from.Rows == 0 {
+		return fmt.Errorf("meta: no rows to tune the threshold on")
+	}
+	_, probabilities, err := eval.CrossValPredict(t.Classifier, from, folds)
+	if err != nil {
+		return err
+	}
+	t.Threshold, _ = eval.TuneThreshold(from, probabilities, t.Class, metric)
+	t.Fit(from)
+	return nil
+}
+
+// Predict returns Class for each row of what which is at least
+// Threshold likely to have it, and the most likely other class for
+// the rest.
+func (t *ThresholdClassifier) Predict(what *base.Instances) *base.Instances {
+	ret := what.GeneratePredictionVector()
+	for i, probs := range t.Classifier.PredictProba(what) {
+		if probs[t.Class] >= t.Threshold {
+			ret.SetAttrStr(i, 0, t.Class)
+			continue
+		}
+		others := make(map[string]float64, len(probs))
+		for c, p := range probs {
+			if c != t.Class {
+				others[c] = p
+			}
+		}
+		class, _ := base.MostLikelyClass(others)
+		if class == "" {
+			// The wrapped Classifier gave no other class a chance
+			ret.SetMissing(i, 0)
+			continue
+		}
+		ret.SetAttrStr(i, 0, class)
+	}
+	return ret
+}
+
+// PredictProba returns the wrapped Classifier's class probabilities
+func (t *ThresholdClassifier) PredictProba(what *base.Instances) []map[string]float64 {
+	return t.Classifier.PredictProba(what)
+}
+
+// Clone returns an untrained ThresholdClassifier with the same Class
+// and Threshold around an untrained copy of the wrapped Classifier
+func (t *ThresholdClassifier) Clone() base.Classifier {
+	return &ThresholdClassifier{
+		base.CloneClassifier(t.Classifier).(base.ProbabilisticClassifier),
+		t.Class,
+		t.Threshold,
+	}
+}
+
+// String returns a human-readable summary
+func (t *ThresholdClassifier) String() string {
+	return fmt.Sprintf("ThresholdClassifier(%s >= %.4f, %s)", t.Class, t.Threshold, t.Classifier)
+}
@@ -0,0 +1,50 @@
+package meta
+
+import (
+	"testing"
+
+	base "github.com/sjwhitworth/golearn/base"
+	eval "github.com/sjwhitworth/golearn/evaluation"
+	knn "github.com/sjwhitworth/golearn/knn"
+)
+
+func TestThresholdClassifier(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	trainData, testData := base.InstancesTrainTestSplit(inst, 0.5)
+	class := "Iris-virginica"
+
+	// Predicting the class whenever it has any chance at all can only
+	// recall more of it
+	lastRecall := -1.0
+	for _, threshold := range []float64{1.0, 0.5, 0.01} {
+		cls := NewThresholdClassifier(knn.NewKnnClassifier("euclidean", 5), class, threshold)
+		cls.Fit(trainData)
+		predictions := cls.Predict(testData)
+		probabilities := cls.PredictProba(testData)
+		for i := 0; i < predictions.Rows; i++ {
+			if (probabilities[i][class] >= threshold) != (predictions.GetClass(i) == class) {
+				testEnv.Errorf("Row %d with probability %.2f predicted %s", i, probabilities[i][class], predictions.GetClass(i))
+			}
+		}
+		confusion := eval.GetConfusionMatrix(testData, predictions)
+		recall := eval.GetRecall(class, confusion)
+		if recall < lastRecall {
+			testEnv.Errorf("Recall fell to %.2f at threshold %.2f", recall, threshold)
+		}
+		lastRecall = recall
+	}
+
+	cls := NewThresholdClassifier(knn.NewKnnClassifier("euclidean", 5), class, 0.5)
+	if err := cls.Tune(trainData, eval.F1Metric, 5); err != nil {
+		testEnv.Fatal(err)
+	}
+	if cls.Threshold <= 0 || cls.Threshold > 1 {
+		testEnv.Errorf("Tuned threshold %.2f", cls.Threshold)
+	}
+	if clone := cls.Clone().(*ThresholdClassifier); clone.Threshold != cls.Threshold || clone.Class != class {
+		testEnv.Error(clone)
+	}
+}
@@ -0,0 +1,345 @@
+package neural
+
+import (
+	"encoding/gob"
+	"fmt"
+	"math"
+	"sort"
+
+	base "github.com/sjwhitworth/golearn/base"
+)
+
+// MLPParams holds the parameters of MLPClassifier and MLPRegressor.
+type MLPParams struct {
+	// HiddenLayers holds the number of units in each hidden layer.
+	// With none, the network is a linear (or logistic) model.
+	HiddenLayers []int
+	// Activation is the function applied to the output of each
+	// hidden layer: "relu" (or ""), "tanh" or "sigmoid"
+	Activation string
+	// Dropout is the probability each hidden unit is ignored for a
+	// row during training, which stops units relying on each other
+	Dropout float64
+	// Optimiser is how the weights are updated from the gradient of
+	// each mini-batch: "adam" (or "") or "sgd", with Momentum
+	Optimiser    string
+	LearningRate float64
+	Momentum     float64
+	// Epochs is the number of passes over the training rows
+	Epochs int
+	// BatchSize is the number of rows in each mini-batch
+	BatchSize int
+	// L2 is the penalty on the squared size of the weights
+	L2 float64
+	// Seed makes the initial weights, the order rows are visited in
+	// and the dropout reproducible. Zero means a different random
+	// seed each time.
+	Seed int64
+}
+
+// DefaultMLPParams returns the MLPParams used by NewMLPClassifier and
+// NewMLPRegressor before their arguments are applied.
+func DefaultMLPParams() MLPParams {
+	return MLPParams{
+		HiddenLayers: []int{100},
+		Activation:   "relu",
+		Optimiser:    "adam",
+		LearningRate: 0.001,
+		Momentum:     0.9,
+		Epochs:       200,
+		BatchSize:    32,
+		L2:           1e-4,
+	}
+}
+
+// Validate checks that the MLPParams are usable.
+func (p MLPParams) Validate() error {
+	for _, units := range p.HiddenLayers {
+		if units < 1 {
+			return fmt.Errorf("neural: hidden layers need at least 1 unit, got %d", units)
+		}
+	}
+	switch p.Activation {
+	case "", "relu", "tanh", "sigmoid":
+	default:
+		return fmt.Errorf("neural: unknown Activation %q", p.Activation)
+	}
+	if p.Dropout < 0 || p.Dropout >= 1 {
+		return fmt.Errorf("neural: Dropout should be in [0, 1), got %f", p.Dropout)
+	}
+	switch p.Optimiser {
+	case "", "adam", "sgd":
+	default:
+		return fmt.Errorf("neural: unknown Optimiser %q", p.Optimiser)
+	}
+	if p.LearningRate <= 0 {
+		return fmt.Errorf("neural: LearningRate should be positive, got %f", p.LearningRate)
+	}
+	if p.Momentum < 0 || p.Momentum >= 1 {
+		return fmt.Errorf("neural: Momentum should be in [0, 1), got %f", p.Momentum)
+	}
+	if p.Epochs < 1 {
+		return fmt.Errorf("neural: Epochs should be at least 1, got %d", p.Epochs)
+	}
+	if p.BatchSize < 1 {
+		return fmt.Errorf("neural: BatchSize should be at least 1, got %d", p.BatchSize)
+	}
+	if p.L2 < 0 {
+		return fmt.Errorf("neural: L2 can't be negative, got %f", p.L2)
+	}
+	return nil
+}
+
+// clone returns a copy of p which doesn't share HiddenLayers
+func (p MLPParams) clone() MLPParams {
+	p.HiddenLayers = append([]int{}, p.HiddenLayers...)
+	return p
+}
+
+// newParams returns the DefaultMLPParams with the given hidden
+// layers, if there are any
+func newParams(hidden []int) MLPParams {
+	ret := DefaultMLPParams()
+	if len(hidden) > 0 {
+		ret.HiddenLayers = hidden
+	}
+	return ret
+}
+
+func init() {
+	base.RegisterClassifier("mlpclassifier", func() base.Classifier {
+		return NewMLPClassifier()
+	})
+	base.RegisterClassifier("mlpregressor", func() base.Classifier {
+		return NewMLPRegressor()
+	})
+	gob.Register(&MLPClassifier{})
+	gob.Register(&MLPRegressor{})
+}
+
+// features returns the features of row i of what, with missing
+// values as zero
+func features(what *base.Instances, i int, cols []int) []float64 {
+	ret := make([]float64, len(cols))
+	for k, j := range cols {
+		if v := what.Get(i, j); !base.IsMissingValue(v) {
+			ret[k] = v
+		}
+	}
+	return ret
+}
+
+// newNetwork returns an untrained Network with the given activation
+// and output, whose features are those of on, along with the
+// features of each row of on and their weights
+func newNetwork(on *base.Instances, activation string, softmax bool) (*Network, [][]float64, []float64) {
+	n := &Network{Activation: activation, Softmax: softmax}
+	cols := on.FeatureIndices()
+	n.Attributes = make([]base.Attribute, len(cols))
+	for k, j := range cols {
+		n.Attributes[k] = on.GetAttr(j)
+	}
+	inputs := make([][]float64, on.Rows)
+	weights := make([]float64, on.Rows)
+	for i := range inputs {
+		inputs[i] = features(on, i, cols)
+		weights[i] = on.GetWeight(i)
+	}
+	return n, inputs, weights
+}
+
+// getColumns returns the column of each of the training Attributes
+// in what.
+//
+// IMPORTANT: this function panic()s if n is nil, because Fit hasn't
+// been called, or if what is missing one of the Attributes.
+func (n *Network) getColumns(what *base.Instances) []int {
+	if n == nil {
+		panic("Call Fit() beforehand")
+	}
+	return what.GetAttrIndices(n.Attributes)
+}
+
+// MLPClassifier is a multilayer perceptron whose output layer has a
+// unit per class, turned into probabilities by a softmax, trained to
+// minimise the cross-entropy of each row's weight (see
+// base.Instances.SetWeight) with mini-batch gradient descent.
+//
+// The inputs are the stored values of the features, with missing
+// values as zero, so categorical features should be one-hot encoded
+// (see pipeline.OneHot). The initial weights are sized for inputs
+// with a variance of about one; much larger ones saturate the hidden
+// units from the start (see pipeline.Standardise).
+type MLPClassifier struct {
+	base.BaseClassifier
+	MLPParams
+	// Network is nil until Fit is called
+	Network *Network
+	// Classes holds the class values seen during training, sorted
+	Classes []string
+}
+
+// NewMLPClassifier returns an MLPClassifier with the given numbers of
+// units in its hidden layers, or the DefaultMLPParams if there are
+// none, and the DefaultMLPParams otherwise.
+func NewMLPClassifier(hidden ...int) *MLPClassifier {
+	return &MLPClassifier{MLPParams: newParams(hidden)}
+}
+
+// NewMLPClassifierFromParams returns a new MLPClassifier with the
+// given parameters, or an error if they're invalid.
+func NewMLPClassifierFromParams(params MLPParams) (*MLPClassifier, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	return &MLPClassifier{MLPParams: params.clone()}, nil
+}
+
+// Fit trains the MLPClassifier on the given Instances.
+//
+// IMPORTANT: this function panic()s if the parameters are invalid or
+// the class Attribute is numeric.
+func (m *MLPClassifier) Fit(on *base.Instances) {
+	if err := m.Validate(); err != nil {
+		panic(err.Error())
+	}
+	if on.GetClassAttr().GetType() != base.CategoricalType {
+		panic("neural: MLPClassifier needs a categorical class")
+	}
+	m.Classes = make([]string, 0)
+	for c := range on.CountClassValues() {
+		m.Classes = append(m.Classes, c)
+	}
+	sort.Strings(m.Classes)
+	network, inputs, weights := newNetwork(on, m.Activation, true)
+	targets := make([][]float64, on.Rows)
+	for i := range targets {
+		targets[i] = make([]float64, len(m.Classes))
+		targets[i][sort.SearchStrings(m.Classes, on.GetClass(i))] = 1
+	}
+	network.train(m.MLPParams, inputs, targets, weights)
+	m.Network = network
+}
+
+// PredictProba returns the probability of each class for each row of
+// what.
+func (m *MLPClassifier) PredictProba(what *base.Instances) []map[string]float64 {
+	cols := m.Network.getColumns(what)
+	ret := make([]map[string]float64, what.Rows)
+	for i := range ret {
+		ret[i] = make(map[string]float64)
+		for k, p := range m.Network.output(features(what, i, cols)) {
+			ret[i][m.Classes[k]] = p
+		}
+	}
+	return ret
+}
+
+// Predict returns the most probable class of each row of what.
+func (m *MLPClassifier) Predict(what *base.Instances) *base.Instances {
+	ret := what.GeneratePredictionVector()
+	for i, probs := range m.PredictProba(what) {
+		class, _ := base.MostLikelyClass(probs)
+		ret.SetAttrStr(i, 0, class)
+	}
+	return ret
+}
+
+// Clone returns an untrained MLPClassifier with the same parameters
+func (m *MLPClassifier) Clone() base.Classifier {
+	return &MLPClassifier{MLPParams: m.MLPParams.clone()}
+}
+
+func (m *MLPClassifier) String() string {
+	return fmt.Sprintf("MLPClassifier(HiddenLayers: %v, %s)", m.HiddenLayers, base.FormatParams(base.GetParams(m)))
+}
+
+// MLPRegressor is a multilayer perceptron with a single linear output
+// unit, trained to minimise the squared error of each row's weight
+// (see base.Instances.SetWeight) with mini-batch gradient descent.
+// The target is standardised for training, so the learning rate
+// doesn't depend on its scale.
+//
+// The features aren't: as for MLPClassifier, they're input as they're
+// stored, with missing values as zero, and should be standardised
+// (see pipeline.Standardise) and one-hot encoded (see
+// pipeline.OneHot) beforehand.
+type MLPRegressor struct {
+	base.BaseClassifier
+	MLPParams
+	// Network is nil until Fit is called
+	Network *Network
+	// TargetMean and TargetScale are the mean and standard
+	// deviation of the target during training
+	TargetMean, TargetScale float64
+}
+
+// NewMLPRegressor returns an MLPRegressor with the given numbers of
+// units in its hidden layers, or the DefaultMLPParams if there are
+// none, and the DefaultMLPParams otherwise.
+func NewMLPRegressor(hidden ...int) *MLPRegressor {
+	return &MLPRegressor{MLPParams: newParams(hidden)}
+}
+
+// NewMLPRegressorFromParams returns a new MLPRegressor with the given
+// parameters, or an error if they're invalid.
+func NewMLPRegressorFromParams(params MLPParams) (*MLPRegressor, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	return &MLPRegressor{MLPParams: params.clone()}, nil
+}
+
+// Fit trains the MLPRegressor on the given Instances.
+//
+// IMPORTANT: this function panic()s if the parameters are invalid or
+// the class Attribute isn't numeric.
+func (m *MLPRegressor) Fit(on *base.Instances) {
+	if err := m.Validate(); err != nil {
+		panic(err.Error())
+	}
+	if on.GetClassAttr().GetType() != base.Float64Type {
+		panic("neural: MLPRegressor needs a numeric class")
+	}
+	network, inputs, weights := newNetwork(on, m.Activation, false)
+	classCol := on.ClassIndex
+	total, sum, squares := 0.0, 0.0, 0.0
+	for i := 0; i < on.Rows; i++ {
+		y := on.Get(i, classCol)
+		total += weights[i]
+		sum += weights[i] * y
+		squares += weights[i] * y * y
+	}
+	m.TargetMean, m.TargetScale = 0, 1
+	if total > 0 {
+		m.TargetMean = sum / total
+		if variance := squares/total - m.TargetMean*m.TargetMean; variance > 0 {
+			m.TargetScale = math.Sqrt(variance)
+		}
+	}
+	targets := make([][]float64, on.Rows)
+	for i := range targets {
+		targets[i] = []float64{(on.Get(i, classCol) - m.TargetMean) / m.TargetScale}
+	}
+	network.train(m.MLPParams, inputs, targets, weights)
+	m.Network = network
+}
+
+// Predict returns the predicted target of each row of what.
+func (m *MLPRegressor) Predict(what *base.Instances) *base.Instances {
+	cols := m.Network.getColumns(what)
+	ret := what.GeneratePredictionVector()
+	for i := 0; i < what.Rows; i++ {
+		ret.Set(i, 0, m.TargetMean+m.TargetScale*m.Network.output(features(what, i, cols))[0])
+	}
+	return ret
+}
+
+// Clone returns an untrained MLPRegressor with the same parameters
+func (m *MLPRegressor) Clone() base.Classifier {
+	return &MLPRegressor{MLPParams: m.MLPParams.clone()}
+}
+
+func (m *MLPRegressor) String() string {
+	return fmt.Sprintf("MLPRegressor(HiddenLayers: %v, %s)", m.HiddenLayers, base.FormatParams(base.GetParams(m)))
+}
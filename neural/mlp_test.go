@@ -0,0 +1,194 @@
+package neural
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	base "github.com/sjwhitworth/golearn/base"
+	eval "github.com/sjwhitworth/golearn/evaluation"
+)
+
+// newXOR returns points around the corners of a square, whose class
+// is whether their coordinates have different signs, and so can't be
+// separated by a line
+func newXOR(rows int, seed int64) *base.Instances {
+	rng := rand.New(rand.NewSource(seed))
+	attrs := make([]base.Attribute, 3)
+	for j := 0; j < 2; j++ {
+		attr := base.NewFloatAttribute()
+		attr.SetName(fmt.Sprintf("x%d", j))
+		attrs[j] = attr
+	}
+	class := base.NewCategoricalAttribute()
+	class.SetName("class")
+	attrs[2] = class
+	ret := base.NewInstances(attrs, rows)
+	for i := 0; i < rows; i++ {
+		x, y := rng.Float64()*2-1, rng.Float64()*2-1
+		ret.Set(i, 0, x)
+		ret.Set(i, 1, y)
+		if (x > 0) != (y > 0) {
+			ret.SetAttrStr(i, 2, "different")
+		} else {
+			ret.SetAttrStr(i, 2, "same")
+		}
+	}
+	return ret
+}
+
+// newWave returns two features, whose target is a non-linear
+// function of them
+func newWave(rows int, seed int64) *base.Instances {
+	rng := rand.New(rand.NewSource(seed))
+	attrs := make([]base.Attribute, 3)
+	for j := range attrs {
+		attr := base.NewFloatAttribute()
+		attr.SetName(fmt.Sprintf("x%d", j))
+		attrs[j] = attr
+	}
+	ret := base.NewInstances(attrs, rows)
+	for i := 0; i < rows; i++ {
+		x, y := rng.Float64()*4-2, rng.Float64()*4-2
+		ret.Set(i, 0, x)
+		ret.Set(i, 1, y)
+		ret.Set(i, 2, 10*math.Sin(x)+5*y*y)
+	}
+	return ret
+}
+
+func TestMLPClassifier(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	trainData, testData := base.InstancesTrainTestSplitWithSeed(inst, 0.5, 1)
+
+	cls := NewMLPClassifier(16)
+	cls.LearningRate = 0.01
+	cls.Seed = 1
+	cls.Fit(trainData)
+	if len(cls.Network.Layers) != 2 || len(cls.Network.Layers[0].Weights) != 16 || len(cls.Network.Layers[1].Weights) != 3 {
+		testEnv.Fatal(cls.Network.Layers)
+	}
+	predictions := cls.Predict(testData)
+	if accuracy := eval.GetAccuracy(eval.GetConfusionMatrix(testData, predictions)); accuracy < 0.9 {
+		testEnv.Error(accuracy)
+	}
+	for i, probs := range cls.PredictProba(testData) {
+		total := 0.0
+		for _, p := range probs {
+			total += p
+		}
+		if len(probs) != 3 || math.Abs(total-1) > 1e-9 {
+			testEnv.Fatalf("Row %d has probabilities %v", i, probs)
+		}
+	}
+
+	// The same seed trains the same network
+	again := cls.Clone().(*MLPClassifier)
+	again.Fit(trainData)
+	if again.Network.Layers[0].Weights[3][2] != cls.Network.Layers[0].Weights[3][2] {
+		testEnv.Error("Training with the same seed should be reproducible")
+	}
+}
+
+func TestMLPClassifierNonLinear(testEnv *testing.T) {
+	trainData := newXOR(400, 1)
+	testData := newXOR(200, 2)
+
+	for _, params := range []MLPParams{
+		{HiddenLayers: []int{16, 16}, Activation: "relu", Optimiser: "adam", LearningRate: 0.01, Epochs: 100, BatchSize: 16, Seed: 1},
+		{HiddenLayers: []int{16}, Activation: "tanh", Optimiser: "sgd", LearningRate: 0.1, Momentum: 0.9, Epochs: 100, BatchSize: 16, Seed: 1},
+		{HiddenLayers: []int{32}, Activation: "sigmoid", Dropout: 0.1, Optimiser: "adam", LearningRate: 0.01, Epochs: 200, BatchSize: 16, Seed: 1},
+	} {
+		cls, err := NewMLPClassifierFromParams(params)
+		if err != nil {
+			testEnv.Fatal(err)
+		}
+		cls.Fit(trainData)
+		if accuracy := eval.GetAccuracy(eval.GetConfusionMatrix(testData, cls.Predict(testData))); accuracy < 0.9 {
+			testEnv.Errorf("%+v: %f", params, accuracy)
+		}
+	}
+
+	// With no hidden layers, it's logistic regression, which can't
+	// do better than guessing
+	linear, err := NewMLPClassifierFromParams(MLPParams{Optimiser: "adam", LearningRate: 0.01, Epochs: 50, BatchSize: 16, Seed: 1})
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	linear.Fit(trainData)
+	if accuracy := eval.GetAccuracy(eval.GetConfusionMatrix(testData, linear.Predict(testData))); accuracy > 0.75 {
+		testEnv.Error(accuracy)
+	}
+}
+
+func TestMLPRegressor(testEnv *testing.T) {
+	trainData := newWave(500, 1)
+	testData := newWave(200, 2)
+
+	reg := NewMLPRegressor(32, 32)
+	reg.LearningRate = 0.01
+	reg.Seed = 1
+	reg.Fit(trainData)
+	predictions := reg.Predict(testData)
+	squares, variance, mean := 0.0, 0.0, 0.0
+	for i := 0; i < testData.Rows; i++ {
+		mean += testData.Get(i, 2) / float64(testData.Rows)
+	}
+	for i := 0; i < testData.Rows; i++ {
+		squares += math.Pow(predictions.Get(i, 0)-testData.Get(i, 2), 2)
+		variance += math.Pow(testData.Get(i, 2)-mean, 2)
+	}
+	if r2 := 1 - squares/variance; r2 < 0.95 {
+		testEnv.Error(r2)
+	}
+}
+
+func TestMLPWeights(testEnv *testing.T) {
+	trainData := newXOR(200, 3)
+	// Weighting every "same" row to zero leaves nothing to learn
+	// but "different"
+	for i := 0; i < trainData.Rows; i++ {
+		if trainData.GetClass(i) == "same" {
+			trainData.SetWeight(i, 0)
+		}
+	}
+	cls := NewMLPClassifier(8)
+	cls.LearningRate = 0.01
+	cls.Epochs = 20
+	cls.Seed = 1
+	cls.Fit(trainData)
+	predictions := cls.Predict(trainData)
+	for i := 0; i < trainData.Rows; i++ {
+		if predictions.GetClass(i) != "different" {
+			testEnv.Fatalf("Row %d predicted %s", i, predictions.GetClass(i))
+		}
+	}
+}
+
+func TestMLPParams(testEnv *testing.T) {
+	if err := DefaultMLPParams().Validate(); err != nil {
+		testEnv.Error(err)
+	}
+	valid := DefaultMLPParams()
+	for _, change := range []func(p *MLPParams){
+		func(p *MLPParams) { p.HiddenLayers = []int{10, 0} },
+		func(p *MLPParams) { p.Activation = "softplus" },
+		func(p *MLPParams) { p.Dropout = 1 },
+		func(p *MLPParams) { p.Optimiser = "rmsprop" },
+		func(p *MLPParams) { p.LearningRate = 0 },
+		func(p *MLPParams) { p.Momentum = 1 },
+		func(p *MLPParams) { p.Epochs = 0 },
+		func(p *MLPParams) { p.BatchSize = 0 },
+		func(p *MLPParams) { p.L2 = -1 },
+	} {
+		params := valid.clone()
+		change(&params)
+		if _, err := NewMLPRegressorFromParams(params); err == nil {
+			testEnv.Errorf("%+v should be invalid", params)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+// Package neural implements feed-forward neural networks (multilayer
+// perceptrons) trained by backpropagation, for classification and
+// regression.
+package neural
+
+import (
+	"math"
+	"math/rand"
+
+	base "github.com/sjwhitworth/golearn/base"
+	mathutil "github.com/sjwhitworth/golearn/mathutil"
+)
+
+// Layer is a fully connected layer of a Network. Its outputs are its
+// Weights (one row per output, one column per input) times its
+// inputs, plus its Biases.
+type Layer struct {
+	Weights [][]float64
+	Biases  []float64
+}
+
+// newLayer returns a Layer with the given numbers of inputs and
+// outputs, whose weights are drawn to keep the variance of the
+// activations about the same from layer to layer (Glorot and Bengio,
+// 2010, or He et al., 2015, for rectified linear units)
+func newLayer(inputs, outputs int, activation string, rng *rand.Rand) Layer {
+	std := math.Sqrt(2 / float64(inputs+outputs))
+	if activation == "relu" || activation == "" {
+		std = math.Sqrt(2 / float64(inputs))
+	}
+	ret := Layer{make([][]float64, outputs), make([]float64, outputs)}
+	for k := range ret.Weights {
+		ret.Weights[k] = make([]float64, inputs)
+		for j := range ret.Weights[k] {
+			ret.Weights[k][j] = rng.NormFloat64() * std
+		}
+	}
+	return ret
+}
+
+// zeroLike returns a Layer of zeros shaped like l
+func zeroLike(l Layer) Layer {
+	ret := Layer{make([][]float64, len(l.Weights)), make([]float64, len(l.Biases))}
+	for k := range ret.Weights {
+		ret.Weights[k] = make([]float64, len(l.Weights[k]))
+	}
+	return ret
+}
+
+// Network is a stack of Layers, with an activation function between
+// them. The output of the last Layer goes through a softmax if the
+// Network classifies, and is used as it is if it regresses.
+type Network struct {
+	Layers []Layer
+	// Activation is applied to the output of every Layer but the
+	// last: "relu" (or ""), "tanh" or "sigmoid"
+	Activation string
+	Softmax    bool
+	// Attributes holds the features trained on
+	Attributes []base.Attribute
+}
+
+// activate applies the activation function to v in place
+func (n *Network) activate(v []float64) {
+	for k := range v {
+		switch n.Activation {
+		case "tanh":
+			v[k] = math.Tanh(v[k])
+		case "sigmoid":
+			v[k] = mathutil.Sigmoid(v[k])
+		default:
+			v[k] = math.Max(v[k], 0)
+		}
+	}
+}
+
+// derivative returns the derivative of the activation function at
+// the input which gave output
+func (n *Network) derivative(output float64) float64 {
+	switch n.Activation {
+	case "tanh":
+		return 1 - output*output
+	case "sigmoid":
+		return output * (1 - output)
+	}
+	if output > 0 {
+		return 1
+	}
+	return 0
+}
+
+// forward returns the output of every Layer for input, after its
+// activation, with input itself first. If dropout is positive, each
+// hidden unit's output is zeroed with that probability using rng,
+// and the others scaled up to make up for it: the factor each was
+// multiplied by is returned too.
+func (n *Network) forward(input []float64, dropout float64, rng *rand.Rand) ([][]float64, [][]float64) {
+	outputs := make([][]float64, len(n.Layers)+1)
+	masks := make([][]float64, len(n.Layers)+1)
+	outputs[0] = input
+	for l, layer := range n.Layers {
+		out := make([]float64, len(layer.Biases))
+		for k := range out {
+			out[k] = layer.Biases[k]
+			for j, w := range layer.Weights[k] {
+				out[k] += w * outputs[l][j]
+			}
+		}
+		if l < len(n.Layers)-1 {
+			n.activate(out)
+			if dropout > 0 {
+				masks[l+1] = make([]float64, len(out))
+				for k := range out {
+					if rng.Float64() >= dropout {
+						masks[l+1][k] = 1 / (1 - dropout)
+					}
+					out[k] *= masks[l+1][k]
+				}
+			}
+		} else if n.Softmax {
+			out = mathutil.Softmax(out)
+		}
+		outputs[l+1] = out
+	}
+	return outputs, masks
+}
+
+// output returns the output of the Network for input
+func (n *Network) output(input []float64) []float64 {
+	outputs, _ := n.forward(input, 0, nil)
+	return outputs[len(outputs)-1]
+}
+
+// backward adds scale times the gradient of the loss for one row to
+// gradients, given the outputs and dropout masks of every Layer from
+// forward, and the target. The loss is the cross-entropy if the Network classifies,
+// and half the squared error if it regresses, so either way its
+// gradient in the last Layer's output is the output minus target.
+func (n *Network) backward(outputs, masks [][]float64, target []float64, scale float64, gradients []Layer) {
+	last := outputs[len(outputs)-1]
+	delta := make([]float64, len(last))
+	for k := range delta {
+		delta[k] = last[k] - target[k]
+	}
+	for l := len(n.Layers) - 1; l >= 0; l-- {
+		input := outputs[l]
+		for k, d := range delta {
+			if d == 0 {
+				continue
+			}
+			gradients[l].Biases[k] += scale * d
+			for j, v := range input {
+				gradients[l].Weights[k][j] += scale * d * v
+			}
+		}
+		if l == 0 {
+			break
+		}
+		previous := make([]float64, len(input))
+		for j, v := range input {
+			mask := 1.0
+			if masks[l] != nil {
+				mask = masks[l][j]
+			}
+			if mask == 0 {
+				continue
+			}
+			for k, d := range delta {
+				previous[j] += d * n.Layers[l].Weights[k][j]
+			}
+			previous[j] *= mask * n.derivative(v/mask)
+		}
+		delta = previous
+	}
+}
+
+// optimiser updates the Layers of a Network given their gradients
+type optimiser interface {
+	step(layers, gradients []Layer)
+}
+
+// sgd is stochastic gradient descent with momentum
+type sgd struct {
+	learningRate, momentum float64
+	velocities             []Layer
+}
+
+func (o *sgd) step(layers, gradients []Layer) {
+	if o.velocities == nil {
+		for _, l := range layers {
+			o.velocities = append(o.velocities, zeroLike(l))
+		}
+	}
+	update := func(param, velocity *float64, gradient float64) {
+		*velocity = o.momentum**velocity - o.learningRate*gradient
+		*param += *velocity
+	}
+	for l := range layers {
+		for k := range layers[l].Weights {
+			for j := range layers[l].Weights[k] {
+				update(&layers[l].Weights[k][j], &o.velocities[l].Weights[k][j], gradients[l].Weights[k][j])
+			}
+			update(&layers[l].Biases[k], &o.velocities[l].Biases[k], gradients[l].Biases[k])
+		}
+	}
+}
+
+// adam is Adam (Kingma and Ba, 2014), which scales the step for each
+// parameter by running estimates of the mean and variance of its
+// gradient
+type adam struct {
+	learningRate float64
+	steps        int
+	means        []Layer
+	variances    []Layer
+}
+
+func (o *adam) step(layers, gradients []Layer) {
+	const beta1, beta2, epsilon = 0.9, 0.999, 1e-8
+	if o.means == nil {
+		for _, l := range layers {
+			o.means = append(o.means, zeroLike(l))
+			o.variances = append(o.variances, zeroLike(l))
+		}
+	}
+	o.steps++
+	rate := o.learningRate * math.Sqrt(1-math.Pow(beta2, float64(o.steps))) / (1 - math.Pow(beta1, float64(o.steps)))
+	update := func(param, mean, variance *float64, gradient float64) {
+		*mean = beta1**mean + (1-beta1)*gradient
+		*variance = beta2**variance + (1-beta2)*gradient*gradient
+		*param -= rate * *mean / (math.Sqrt(*variance) + epsilon)
+	}
+	for l := range layers {
+		for k := range layers[l].Weights {
+			for j := range layers[l].Weights[k] {
+				update(&layers[l].Weights[k][j], &o.means[l].Weights[k][j], &o.variances[l].Weights[k][j], gradients[l].Weights[k][j])
+			}
+			update(&layers[l].Biases[k], &o.means[l].Biases[k], &o.variances[l].Biases[k], gradients[l].Biases[k])
+		}
+	}
+}
+
+// train fits the Layers to map each row of inputs to its target,
+// weighting the loss of each row by weights, with the given
+// parameters
+func (n *Network) train(params MLPParams, inputs, targets [][]float64, weights []float64) {
+	seed := params.Seed
+	if seed == 0 {
+		seed = rand.Int63()
+	}
+	rng := rand.New(rand.NewSource(seed))
+	sizes := append(append([]int{len(inputs[0])}, params.HiddenLayers...), len(targets[0]))
+	n.Layers = make([]Layer, len(sizes)-1)
+	for l := range n.Layers {
+		n.Layers[l] = newLayer(sizes[l], sizes[l+1], n.Activation, rng)
+	}
+	var opt optimiser = &adam{learningRate: params.LearningRate}
+	if params.Optimiser == "sgd" {
+		opt = &sgd{learningRate: params.LearningRate, momentum: params.Momentum}
+	}
+
+	gradients := make([]Layer, len(n.Layers))
+	for epoch := 0; epoch < params.Epochs; epoch++ {
+		order := rng.Perm(len(inputs))
+		for start := 0; start < len(order); start += params.BatchSize {
+			end := start + params.BatchSize
+			if end > len(order) {
+				end = len(order)
+			}
+			total := 0.0
+			for _, i := range order[start:end] {
+				total += weights[i]
+			}
+			if total == 0 {
+				continue
+			}
+			for l := range gradients {
+				gradients[l] = zeroLike(n.Layers[l])
+			}
+			for _, i := range order[start:end] {
+				outputs, masks := n.forward(inputs[i], params.Dropout, rng)
+				n.backward(outputs, masks, targets[i], weights[i]/total, gradients)
+			}
+			for l := range gradients {
+				for k := range gradients[l].Weights {
+					for j, w := range n.Layers[l].Weights[k] {
+						gradients[l].Weights[k][j] += params.L2 * w
+					}
+				}
+			}
+			opt.step(n.Layers, gradients)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package pca
+
+import (
+	"github.com/gonum/matrix/mat64"
+	base "github.com/sjwhitworth/golearn/base"
+)
+
+// IncrementalPCA is a PCA which is fitted one mini-batch of rows at
+// a time, so the training data never has to be in memory at once.
+// It keeps the mean and the scatter matrix of the rows seen so far,
+// which take space proportional to the square of the number of
+// columns, and finds the components from them after every batch.
+// The result is the same as fitting a PCA to all of the rows.
+//
+// Together with base.CSVStream it reduces the dimensionality of
+// files which don't fit in memory:
+//
+//	p := pca.NewIncrementalPCA(2)
+//	for stream.Next() {
+//		p.PartialFitInstances(stream.Instances())
+//	}
+type IncrementalPCA struct {
+	PCA
+	count   int
+	scatter [][]float64
+}
+
+// NewIncrementalPCA returns an IncrementalPCA which keeps the given
+// number of components (zero means all of them).
+func NewIncrementalPCA(components int) *IncrementalPCA {
+	return &IncrementalPCA{PCA: PCA{Components: components}}
+}
+
+// PartialFit updates the principal components with the rows of
+// batch, which must have the same number of columns as the batches
+// before it.
+//
+// IMPORTANT: this function panic()s if the number of columns changes.
+func (p *IncrementalPCA) PartialFit(batch *mat64.Dense) {
+	rows, cols := batch.Dims()
+	if rows == 0 {
+		return
+	}
+	if p.scatter == nil {
+		p.Mean = make([]float64, cols)
+		p.scatter = make([][]float64, cols)
+		for j := range p.scatter {
+			p.scatter[j] = make([]float64, cols)
+		}
+	} else if cols != len(p.Mean) {
+		panic("pca: batch has the wrong number of columns")
+	}
+
+	// Compute the mean and scatter matrix of the batch, and merge
+	// them with those of the earlier rows (Chan et al., 1979)
+	mean := make([]float64, cols)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			mean[j] += batch.At(i, j) / float64(rows)
+		}
+	}
+	total := float64(p.count + rows)
+	scale := float64(p.count) * float64(rows) / total
+	delta := make([]float64, cols)
+	for j := range delta {
+		delta[j] = mean[j] - p.Mean[j]
+	}
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			dj := batch.At(i, j) - mean[j]
+			for k := j; k < cols; k++ {
+				p.scatter[j][k] += dj * (batch.At(i, k) - mean[k])
+			}
+		}
+	}
+	for j := 0; j < cols; j++ {
+		for k := j; k < cols; k++ {
+			p.scatter[j][k] += delta[j] * delta[k] * scale
+		}
+		p.Mean[j] += delta[j] * float64(rows) / total
+	}
+	p.count += rows
+
+	denom := float64(p.count - 1)
+	if p.count < 2 {
+		denom = 1
+	}
+	cov := make([][]float64, cols)
+	for j := range cov {
+		cov[j] = make([]float64, cols)
+		for k := j; k < cols; k++ {
+			cov[j][k] = p.scatter[j][k] / denom
+		}
+		for k := 0; k < j; k++ {
+			cov[j][k] = cov[k][j]
+		}
+	}
+	p.setCovariance(cov)
+}
+
+// PartialFitInstances updates the principal components with the
+// features of chunk (every column except the class, unless roles
+// have been set), e.g. a chunk read by a base.CSVStream. Use
+// InstancesToMatrix to transform Instances the same way.
+func (p *IncrementalPCA) PartialFitInstances(chunk *base.Instances) {
+	p.PartialFit(InstancesToMatrix(chunk))
+}
+
+// Count returns the number of rows seen so far
+func (p *IncrementalPCA) Count() int {
+	return p.count
+}
+
+// InstancesToMatrix returns the values of the features of inst (see
+// base.Instances.IsFeature), as the rows of a matrix.
+func InstancesToMatrix(inst *base.Instances) *mat64.Dense {
+	cols := inst.FeatureIndices()
+	ret := mat64.NewDense(inst.Rows, len(cols), nil)
+	for i := 0; i < inst.Rows; i++ {
+		for k, j := range cols {
+			ret.Set(i, k, inst.Get(i, j))
+		}
+	}
+	return ret
+}
@@ -0,0 +1,226 @@
+// Package pca implements Principal Component Analysis, which projects
+// data onto the orthogonal directions of greatest variance.
+package pca
+
+import (
+	"math"
+	"sort"
+
+	"github.com/gonum/matrix/mat64"
+)
+
+// PCA finds the principal components of a matrix whose rows are
+// observations and whose columns are variables.
+type PCA struct {
+	// Components is the number of components to keep. Zero
+	// means all of them.
+	Components int
+	// Mean of each column of the training data
+	Mean []float64
+	// Vectors holds one principal component per column, in
+	// order of decreasing variance
+	Vectors *mat64.Dense
+	// Variance explained by each of the components
+	Variance      []float64
+	totalVariance float64
+}
+
+// NewPCA returns a PCA which keeps the given number of components.
+func NewPCA(components int) *PCA {
+	return &PCA{Components: components}
+}
+
+// Fit finds the principal components of data.
+func (p *PCA) Fit(data *mat64.Dense) {
+	rows, cols := data.Dims()
+	p.Mean = make([]float64, cols)
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			p.Mean[j] += data.At(i, j) / float64(rows)
+		}
+	}
+
+	// Compute the covariance matrix
+	denom := float64(rows - 1)
+	if rows < 2 {
+		denom = 1
+	}
+	cov := make([][]float64, cols)
+	for j := range cov {
+		cov[j] = make([]float64, cols)
+	}
+	for i := 0; i < rows; i++ {
+		for j := 0; j < cols; j++ {
+			dj := data.At(i, j) - p.Mean[j]
+			for k := j; k < cols; k++ {
+				cov[j][k] += dj * (data.At(i, k) - p.Mean[k]) / denom
+			}
+		}
+	}
+	for j := 0; j < cols; j++ {
+		for k := 0; k < j; k++ {
+			cov[j][k] = cov[k][j]
+		}
+	}
+
+	p.setCovariance(cov)
+}
+
+// setCovariance finds the principal components from the covariance
+// matrix of the training data
+func (p *PCA) setCovariance(cov [][]float64) {
+	cols := len(cov)
+	p.totalVariance = 0
+	for j := 0; j < cols; j++ {
+		p.totalVariance += cov[j][j]
+	}
+
+	values, vectors := SymmetricEigen(cov)
+	components := p.Components
+	if components <= 0 || components > cols {
+		components = cols
+	}
+	p.Variance = values[:components]
+	p.Vectors = mat64.NewDense(cols, components, nil)
+	for j := 0; j < cols; j++ {
+		for k := 0; k < components; k++ {
+			p.Vectors.Set(j, k, vectors[j][k])
+		}
+	}
+}
+
+// Transform projects the rows of data onto the principal components.
+//
+// IMPORTANT: this function panic()s if Fit hasn't been called.
+func (p *PCA) Transform(data *mat64.Dense) *mat64.Dense {
+	if p.Vectors == nil {
+		panic("Call Fit() beforehand")
+	}
+	rows, cols := data.Dims()
+	_, components := p.Vectors.Dims()
+	ret := mat64.NewDense(rows, components, nil)
+	for i := 0; i < rows; i++ {
+		for k := 0; k < components; k++ {
+			sum := 0.0
+			for j := 0; j < cols; j++ {
+				sum += (data.At(i, j) - p.Mean[j]) * p.Vectors.At(j, k)
+			}
+			ret.Set(i, k, sum)
+		}
+	}
+	return ret
+}
+
+// FitTransform finds the principal components of data and
+// projects data onto them.
+func (p *PCA) FitTransform(data *mat64.Dense) *mat64.Dense {
+	p.Fit(data)
+	return p.Transform(data)
+}
+
+// ExplainedVarianceRatio returns the proportion of the total
+// variance of the training data explained by each component.
+func (p *PCA) ExplainedVarianceRatio() []float64 {
+	ret := make([]float64, len(p.Variance))
+	for i, v := range p.Variance {
+		if p.totalVariance > 0 {
+			ret[i] = v / p.totalVariance
+		}
+	}
+	return ret
+}
+
+// SymmetricEigen computes the eigenvalues and eigenvectors of the
+// symmetric matrix a using the cyclic Jacobi method. Eigenvalues are
+// returned in decreasing order, and the ith column of vectors is the
+// eigenvector corresponding to the ith eigenvalue. a isn't modified.
+func SymmetricEigen(a [][]float64) (values []float64, vectors [][]float64) {
+	n := len(a)
+	m := make([][]float64, n)
+	v := make([][]float64, n)
+	for i := range m {
+		m[i] = make([]float64, n)
+		copy(m[i], a[i])
+		v[i] = make([]float64, n)
+		v[i][i] = 1
+	}
+
+	for sweep := 0; sweep < 100; sweep++ {
+		off := 0.0
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				off += m[i][j] * m[i][j]
+			}
+		}
+		if off < 1e-22 {
+			break
+		}
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				if math.Abs(m[p][q]) < 1e-300 {
+					continue
+				}
+				// Find the rotation which zeroes m[p][q]
+				theta := (m[q][q] - m[p][p]) / (2 * m[p][q])
+				t := 1 / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				if theta < 0 {
+					t = -t
+				}
+				c := 1 / math.Sqrt(t*t+1)
+				s := t * c
+				for k := 0; k < n; k++ {
+					mkp, mkq := m[k][p], m[k][q]
+					m[k][p] = c*mkp - s*mkq
+					m[k][q] = s*mkp + c*mkq
+				}
+				for k := 0; k < n; k++ {
+					mpk, mqk := m[p][k], m[q][k]
+					m[p][k] = c*mpk - s*mqk
+					m[q][k] = s*mpk + c*mqk
+				}
+				for k := 0; k < n; k++ {
+					vkp, vkq := v[k][p], v[k][q]
+					v[k][p] = c*vkp - s*vkq
+					v[k][q] = s*vkp + c*vkq
+				}
+			}
+		}
+	}
+
+	// Sort by decreasing eigenvalue
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.Sort(&byValue{order, m})
+	values = make([]float64, n)
+	vectors = make([][]float64, n)
+	for i := range vectors {
+		vectors[i] = make([]float64, n)
+	}
+	for k, i := range order {
+		values[k] = m[i][i]
+		for j := 0; j < n; j++ {
+			vectors[j][k] = v[j][i]
+		}
+	}
+	return values, vectors
+}
+
+// byValue sorts indices by decreasing diagonal value
+type byValue struct {
+	order []int
+	m     [][]float64
+}
+
+func (b *byValue) Len() int {
+	return len(b.order)
+}
+
+func (b *byValue) Swap(i, j int) {
+	b.order[i], b.order[j] = b.order[j], b.order[i]
+}
+
+func (b *byValue) Less(i, j int) bool {
+	return b.m[b.order[i]][b.order[i]] > b.m[b.order[j]][b.order[j]]
+}
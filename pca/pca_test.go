@@ -0,0 +1,123 @@
+package pca
+
+import (
+	"math"
+	"testing"
+
+	"github.com/gonum/matrix/mat64"
+	base "github.com/sjwhitworth/golearn/base"
+)
+
+func TestSymmetricEigen(testEnv *testing.T) {
+	a := [][]float64{
+		{2, 1, 0},
+		{1, 2, 0},
+		{0, 0, 5},
+	}
+	values, vectors := SymmetricEigen(a)
+	expected := []float64{5, 3, 1}
+	for i := range expected {
+		if math.Abs(values[i]-expected[i]) > 1e-9 {
+			testEnv.Fatal(values)
+		}
+	}
+	// Check that a * v = lambda * v for each eigenvector
+	for k := range values {
+		for i := range a {
+			av := 0.0
+			for j := range a {
+				av += a[i][j] * vectors[j][k]
+			}
+			if math.Abs(av-values[k]*vectors[i][k]) > 1e-9 {
+				testEnv.Errorf("Eigenvector %d is wrong: %v", k, vectors)
+			}
+		}
+	}
+}
+
+func TestPCA(testEnv *testing.T) {
+	// Points along the line y = 2x, plus a little noise
+	data := mat64.NewDense(5, 2, []float64{
+		1, 2.1,
+		2, 3.9,
+		3, 6.0,
+		4, 8.1,
+		5, 9.9,
+	})
+	p := NewPCA(1)
+	out := p.FitTransform(data)
+	rows, cols := out.Dims()
+	if rows != 5 || cols != 1 {
+		testEnv.Fatalf("Expected a 5x1 matrix, got %dx%d", rows, cols)
+	}
+	// The first component should point along (1, 2)
+	x, y := p.Vectors.At(0, 0), p.Vectors.At(1, 0)
+	if math.Abs(y/x-2) > 0.05 {
+		testEnv.Error(x, y)
+	}
+	ratio := p.ExplainedVarianceRatio()
+	if ratio[0] < 0.99 || ratio[0] > 1 {
+		testEnv.Error(ratio)
+	}
+	// The projection should be centred
+	sum := 0.0
+	for i := 0; i < rows; i++ {
+		sum += out.At(i, 0)
+	}
+	if math.Abs(sum) > 1e-9 {
+		testEnv.Error(sum)
+	}
+}
+
+func TestIncrementalPCA(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	p := NewPCA(2)
+	p.Fit(InstancesToMatrix(inst))
+
+	stream, err := base.StreamCSV("../examples/datasets/iris_headers.csv", true, 40)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	defer stream.Close()
+	inc := NewIncrementalPCA(2)
+	for stream.Next() {
+		inc.PartialFitInstances(stream.Instances())
+	}
+	if err := stream.Err(); err != nil {
+		testEnv.Fatal(err)
+	}
+	if inc.Count() != 150 {
+		testEnv.Fatal(inc.Count())
+	}
+	for j := range p.Mean {
+		if math.Abs(p.Mean[j]-inc.Mean[j]) > 1e-9 {
+			testEnv.Error(p.Mean, inc.Mean)
+		}
+	}
+	for k := range p.Variance {
+		if math.Abs(p.Variance[k]-inc.Variance[k]) > 1e-9 {
+			testEnv.Error(p.Variance, inc.Variance)
+		}
+	}
+	// The components may differ in sign
+	ratio := inc.ExplainedVarianceRatio()
+	if ratio[0] < 0.9 {
+		testEnv.Error(ratio)
+	}
+	data := InstancesToMatrix(inst)
+	expected, actual := p.Transform(data), inc.Transform(data)
+	for k := 0; k < 2; k++ {
+		sign := 1.0
+		if expected.At(0, k)*actual.At(0, k) < 0 {
+			sign = -1.0
+		}
+		for i := 0; i < inst.Rows; i++ {
+			if math.Abs(expected.At(i, k)-sign*actual.At(i, k)) > 1e-6 {
+				testEnv.Fatalf("Row %d differs: %f, %f", i, expected.At(i, k), actual.At(i, k))
+			}
+		}
+	}
+}
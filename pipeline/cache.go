@@ -0,0 +1,95 @@
+package pipeline
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sync"
+
+	base "github.com/sjwhitworth/golearn/base"
+)
+
+// cacheEntry holds a trained Filter and what it made of the
+// Instances it was trained on.
+type cacheEntry struct {
+	filter Filter
+	output *base.Instances
+}
+
+// Cache memoises the trained Filters of one or more Pipelines,
+// keyed on each Filter's parameters and a fingerprint of the
+// Instances it's trained on. It's safe to share between Pipelines
+// trained concurrently.
+type Cache struct {
+	Hits    int
+	Misses  int
+	lock    sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+// NewCache returns an empty Cache.
+func NewCache() *Cache {
+	return &Cache{
+		entries: make(map[string]*cacheEntry),
+	}
+}
+
+// Len returns the number of trained Filters in the Cache.
+func (c *Cache) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.entries)
+}
+
+// Clear discards everything in the Cache.
+func (c *Cache) Clear() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.entries = make(map[string]*cacheEntry)
+	c.Hits = 0
+	c.Misses = 0
+}
+
+// fitTransform returns a trained version of f and its output on
+// the training Instances, using a previously trained equivalent
+// if there is one.
+func (c *Cache) fitTransform(f Filter, on *base.Instances) (Filter, *base.Instances) {
+	key := fmt.Sprintf("%s/%x", f, fingerprint(on))
+	c.lock.Lock()
+	entry, ok := c.entries[key]
+	if ok {
+		c.Hits++
+	} else {
+		c.Misses++
+	}
+	c.lock.Unlock()
+	if ok {
+		return entry.filter, entry.output
+	}
+
+	f.Fit(on)
+	entry = &cacheEntry{f, f.Transform(on)}
+	c.lock.Lock()
+	c.entries[key] = entry
+	c.lock.Unlock()
+	return entry.filter, entry.output
+}
+
+// fingerprint hashes the Attributes and values of inst.
+func fingerprint(inst *base.Instances) uint64 {
+	h := fnv.New64a()
+	buf := make([]byte, 8)
+	for i := 0; i < inst.Cols; i++ {
+		h.Write([]byte(inst.GetAttr(i).String()))
+	}
+	binary.LittleEndian.PutUint64(buf, uint64(inst.ClassIndex))
+	h.Write(buf)
+	for i := 0; i < inst.Rows; i++ {
+		for j := 0; j < inst.Cols; j++ {
+			binary.LittleEndian.PutUint64(buf, math.Float64bits(inst.Get(i, j)))
+			h.Write(buf)
+		}
+	}
+	return h.Sum64()
+}
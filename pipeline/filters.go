@@ -0,0 +1,190 @@
+package pipeline
+
+import (
+	"fmt"
+
+	"github.com/gonum/matrix/mat64"
+	base "github.com/sjwhitworth/golearn/base"
+	filters "github.com/sjwhitworth/golearn/filters"
+	kernels "github.com/sjwhitworth/golearn/kernels"
+	pairwise "github.com/sjwhitworth/golearn/metrics/pairwise"
+	pca "github.com/sjwhitworth/golearn/pca"
+)
+
+// copyInstances returns a copy of from, including its weights and
+// roles, which can be discretised without affecting it.
+func copyInstances(from *base.Instances) *base.Instances {
+	attrs := make([]base.Attribute, from.Cols)
+	for j := range attrs {
+		attrs[j] = from.GetAttr(j)
+	}
+	ret := base.NewInstances(attrs, from.Rows)
+	ret.ClassIndex = from.ClassIndex
+	for i := 0; i < from.Rows; i++ {
+		for j := 0; j < from.Cols; j++ {
+			ret.Set(i, j, from.Get(i, j))
+		}
+	}
+	ret.SetWeights(from.Weights())
+	for j := 0; j < from.Cols; j++ {
+		ret.SetRole(j, from.GetRole(j))
+	}
+	return ret
+}
+
+type chiMergeStep struct {
+	significance float64
+	filt         filters.ChiMergeFilter
+}
+
+// ChiMerge returns a Step which discretises every numeric
+// Attribute using a filters.ChiMergeFilter.
+func ChiMerge(significance float64) Step {
+	return func() Filter {
+		return &chiMergeStep{significance: significance}
+	}
+}
+
+func (c *chiMergeStep) Fit(on *base.Instances) {
+	c.filt = filters.NewChiMergeFilter(on, c.significance)
+	c.filt.AddAllNumericAttributes()
+	c.filt.Build()
+}
+
+func (c *chiMergeStep) Transform(what *base.Instances) *base.Instances {
+	ret := copyInstances(what)
+	c.filt.Run(ret)
+	return ret
+}
+
+func (c *chiMergeStep) Provenance() []base.Provenance {
+	return c.filt.Provenance()
+}
+
+func (c *chiMergeStep) String() string {
+	return fmt.Sprintf("ChiMerge(%f)", c.significance)
+}
+
+type binningStep struct {
+	bins int
+	filt filters.BinningFilter
+}
+
+// Binning returns a Step which discretises every numeric
+// Attribute using a filters.BinningFilter.
+func Binning(bins int) Step {
+	return func() Filter {
+		return &binningStep{bins: bins}
+	}
+}
+
+func (b *binningStep) Fit(on *base.Instances) {
+	b.filt = filters.NewBinningFilter(on, b.bins)
+	b.filt.AddAllNumericAttributes()
+	b.filt.Build()
+}
+
+func (b *binningStep) Transform(what *base.Instances) *base.Instances {
+	ret := copyInstances(what)
+	b.filt.Run(ret)
+	return ret
+}
+
+func (b *binningStep) Provenance() []base.Provenance {
+	return b.filt.Provenance()
+}
+
+func (b *binningStep) String() string {
+	return fmt.Sprintf("Binning(%d)", b.bins)
+}
+
+// featureMap is implemented by the transformers in the kernels
+// package
+type featureMap interface {
+	Fit(*mat64.Dense)
+	Transform(*mat64.Dense) *mat64.Dense
+}
+
+type featureMapStep struct {
+	name      string
+	transform string
+	m         featureMap
+	attrs     []base.Attribute
+	// inputs and class are the names of the features and class
+	// Attribute it was fitted on
+	inputs []string
+	class  string
+}
+
+// Nystroem returns a Step which replaces every Attribute except the
+// class with a kernels.Nystroem approximation of the RBF kernel with
+// the given gamma, so that a linear Classifier can learn non-linear
+// boundaries.
+func Nystroem(gamma float64, components int, seed int64) Step {
+	return func() Filter {
+		return &featureMapStep{
+			name:      fmt.Sprintf("Nystroem(%f, %d, %d)", gamma, components, seed),
+			transform: "nystroem",
+			m:         kernels.NewNystroem(pairwise.NewRBFKernel(gamma), components, seed),
+		}
+	}
+}
+
+// RandomFourier returns a Step which replaces every Attribute except
+// the class with kernels.RandomFourier features approximating the
+// RBF kernel with the given gamma.
+func RandomFourier(gamma float64, components int, seed int64) Step {
+	return func() Filter {
+		return &featureMapStep{
+			name:      fmt.Sprintf("RandomFourier(%f, %d, %d)", gamma, components, seed),
+			transform: "fourier",
+			m:         kernels.NewRandomFourier(gamma, components, seed),
+		}
+	}
+}
+
+func (f *featureMapStep) Fit(on *base.Instances) {
+	f.m.Fit(pca.InstancesToMatrix(on))
+	f.attrs = nil
+	f.inputs = make([]string, 0)
+	for _, j := range on.FeatureIndices() {
+		f.inputs = append(f.inputs, on.GetAttr(j).GetName())
+	}
+	f.class = on.GetClassAttr().GetName()
+}
+
+func (f *featureMapStep) Transform(what *base.Instances) *base.Instances {
+	features := f.m.Transform(pca.InstancesToMatrix(what))
+	rows, cols := features.Dims()
+	if f.attrs == nil {
+		f.attrs = make([]base.Attribute, cols)
+		for k := range f.attrs {
+			attr := base.NewFloatAttribute()
+			attr.SetName(fmt.Sprintf("feature%d", k))
+			f.attrs[k] = attr
+		}
+	}
+	attrs := append(f.attrs[:cols:cols], what.GetClassAttr())
+	ret := base.NewInstances(attrs, rows)
+	for i := 0; i < rows; i++ {
+		for k := 0; k < cols; k++ {
+			ret.Set(i, k, features.At(i, k))
+		}
+		ret.Set(i, cols, what.Get(i, what.ClassIndex))
+	}
+	return ret
+}
+
+// Provenance says that every feature is derived from all of the
+// features it was fitted on, and the class is passed through
+func (f *featureMapStep) Provenance() []base.Provenance {
+	ret := make([]base.Provenance, 0, len(f.attrs)+1)
+	for _, a := range f.attrs {
+		ret = append(ret, base.Provenance{Attribute: a.GetName(), Sources: f.inputs, Transform: f.transform})
+	}
+	return append(ret, base.PassThrough(f.class))
+}
+
+func (f *featureMapStep) String() string {
+	return f.name
+}
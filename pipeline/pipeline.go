@@ -0,0 +1,232 @@
+// Package pipeline chains preprocessing Filters and a final
+// base.Classifier together so they can be trained and used as one.
+package pipeline
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	base "github.com/sjwhitworth/golearn/base"
+)
+
+// Filter is a preprocessing stage which learns from a set of
+// training Instances and can then be applied to other Instances.
+type Filter interface {
+	// Fit trains the Filter on the given Instances.
+	Fit(*base.Instances)
+	// Transform returns a processed copy of the given Instances,
+	// leaving the original unchanged.
+	Transform(*base.Instances) *base.Instances
+	// String describes the Filter and all of its parameters.
+	// It's used to identify equivalent Filters when caching.
+	String() string
+}
+
+// Provenancer is implemented by Filters which can say where each
+// Attribute they output comes from, once they've been trained.
+// Filters which don't are assumed to pass through the Attributes they
+// output which have the same name as one of their inputs.
+type Provenancer interface {
+	Provenance() []base.Provenance
+}
+
+// Step creates a fresh, untrained Filter for a stage of a Pipeline.
+type Step func() Filter
+
+// Pipeline applies a sequence of Filters to the Instances before
+// passing them to a Classifier.
+type Pipeline struct {
+	Steps      []Step
+	Classifier base.Classifier
+	// Cache optionally memoises the output of each Step, so that
+	// re-fitting with identical preprocessing (e.g. when only the
+	// Classifier's parameters have changed) doesn't redo it.
+	Cache   *Cache
+	filters []Filter
+	// names holds the names of the Attributes going into each
+	// Filter, and coming out of the last one
+	names [][]string
+}
+
+// NewPipeline returns a Pipeline which runs the given steps in order
+// and then trains cls on the result.
+func NewPipeline(cls base.Classifier, steps ...Step) *Pipeline {
+	return &Pipeline{
+		steps,
+		cls,
+		nil,
+		nil,
+		nil,
+	}
+}
+
+// Fit trains each of the Filters in turn on the output of the
+// previous one, then trains the Classifier.
+func (p *Pipeline) Fit(on *base.Instances) {
+	p.filters = make([]Filter, len(p.Steps))
+	p.names = [][]string{attributeNames(on)}
+	cur := on
+	for i, s := range p.Steps {
+		f := s()
+		if p.Cache == nil {
+			f.Fit(cur)
+			p.filters[i] = f
+			cur = f.Transform(cur)
+		} else {
+			p.filters[i], cur = p.Cache.fitTransform(f, cur)
+		}
+		p.names = append(p.names, attributeNames(cur))
+	}
+	p.Classifier.Fit(cur)
+}
+
+// attributeNames returns the name of each Attribute of inst
+func attributeNames(inst *base.Instances) []string {
+	ret := make([]string, inst.Cols)
+	for j := range ret {
+		ret[j] = inst.GetAttr(j).GetName()
+	}
+	return ret
+}
+
+// Provenance returns where each Attribute the Classifier was trained
+// on comes from, in terms of the Attributes given to Fit: their
+// names, and the transforms applied to them in turn. Attributes whose
+// origin a Filter doesn't record (see Provenancer) have no Sources.
+//
+// IMPORTANT: this function panic()s if Fit hasn't been called.
+func (p *Pipeline) Provenance() []base.Provenance {
+	if p.filters == nil {
+		panic("Call Fit() beforehand")
+	}
+	cur := make(map[string]base.Provenance)
+	for _, name := range p.names[0] {
+		cur[name] = base.PassThrough(name)
+	}
+	var stage []base.Provenance
+	for i, f := range p.filters {
+		if pf, ok := f.(Provenancer); ok {
+			stage = pf.Provenance()
+		} else {
+			stage = make([]base.Provenance, len(p.names[i+1]))
+			for j, name := range p.names[i+1] {
+				stage[j] = base.Provenance{Attribute: name}
+				if _, ok := cur[name]; ok {
+					stage[j] = base.PassThrough(name)
+				}
+			}
+		}
+		next := make(map[string]base.Provenance)
+		for _, s := range stage {
+			next[s.Attribute] = composeProvenance(cur, s)
+		}
+		cur = next
+	}
+	ret := make([]base.Provenance, 0, len(stage))
+	for _, name := range p.names[len(p.names)-1] {
+		ret = append(ret, cur[name])
+	}
+	return ret
+}
+
+// composeProvenance returns the Provenance of s in terms of the
+// Attributes before, given the Provenance of each of its Sources.
+func composeProvenance(before map[string]base.Provenance, s base.Provenance) base.Provenance {
+	sources := make([]string, 0)
+	transforms := make([]string, 0)
+	seenSources := make(map[string]bool)
+	seenTransforms := make(map[string]bool)
+	for _, src := range s.Sources {
+		prev := before[src]
+		for _, name := range prev.Sources {
+			if !seenSources[name] {
+				seenSources[name] = true
+				sources = append(sources, name)
+			}
+		}
+		if prev.Transform != "" && !seenTransforms[prev.Transform] {
+			seenTransforms[prev.Transform] = true
+			transforms = append(transforms, prev.Transform)
+		}
+	}
+	if s.Transform != "" {
+		transforms = append(transforms, s.Transform)
+	}
+	return base.Provenance{Attribute: s.Attribute, Sources: sources, Transform: strings.Join(transforms, "→")}
+}
+
+// AggregateBySource re-expresses values given for the Attributes the
+// Classifier was trained on (e.g. importances, keyed on their names)
+// in terms of the Attributes given to Fit, sharing each value equally
+// between its Sources (see Provenance). Values whose source isn't
+// known are left out.
+//
+// IMPORTANT: this function panic()s if Fit hasn't been called.
+func (p *Pipeline) AggregateBySource(values map[string]float64) map[string]float64 {
+	ret := make(map[string]float64)
+	for _, prov := range p.Provenance() {
+		v, ok := values[prov.Attribute]
+		if !ok || len(prov.Sources) == 0 {
+			continue
+		}
+		for _, src := range prov.Sources {
+			ret[src] += v / float64(len(prov.Sources))
+		}
+	}
+	return ret
+}
+
+// Transform applies each of the trained Filters to what.
+//
+// IMPORTANT: this function panic()s if Fit hasn't been called.
+func (p *Pipeline) Transform(what *base.Instances) *base.Instances {
+	if p.filters == nil {
+		panic("Call Fit() beforehand")
+	}
+	for _, f := range p.filters {
+		what = f.Transform(what)
+	}
+	return what
+}
+
+// Predict transforms what and returns the Classifier's predictions.
+func (p *Pipeline) Predict(what *base.Instances) *base.Instances {
+	return p.Classifier.Predict(p.Transform(what))
+}
+
+// PredictProba transforms what and returns the Classifier's class
+// probability estimates. If the Classifier isn't probabilistic, the
+// predicted class is given a probability of one.
+func (p *Pipeline) PredictProba(what *base.Instances) []map[string]float64 {
+	transformed := p.Transform(what)
+	if c, ok := p.Classifier.(base.ProbabilisticClassifier); ok {
+		return c.PredictProba(transformed)
+	}
+	predictions := p.Classifier.Predict(transformed)
+	ret := make([]map[string]float64, predictions.Rows)
+	for i := range ret {
+		ret[i] = make(map[string]float64)
+		ret[i][predictions.GetClass(i)] = 1.0
+	}
+	return ret
+}
+
+// Clone returns an untrained Pipeline with the same Steps, Cache
+// and an untrained copy of the Classifier.
+func (p *Pipeline) Clone() base.Classifier {
+	ret := NewPipeline(base.CloneClassifier(p.Classifier), p.Steps...)
+	ret.Cache = p.Cache
+	return ret
+}
+
+// String returns a human-readable summary of the Pipeline.
+func (p *Pipeline) String() string {
+	var buffer bytes.Buffer
+	buffer.WriteString("Pipeline(\n")
+	for _, s := range p.Steps {
+		buffer.WriteString(fmt.Sprintf("\t%s\n", s()))
+	}
+	buffer.WriteString(fmt.Sprintf("\t%s\n)", p.Classifier))
+	return buffer.String()
+}
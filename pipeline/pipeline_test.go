@@ -0,0 +1,194 @@
+package pipeline
+
+import (
+	"testing"
+
+	base "github.com/sjwhitworth/golearn/base"
+	eval "github.com/sjwhitworth/golearn/evaluation"
+	trees "github.com/sjwhitworth/golearn/trees"
+)
+
+func TestPipeline(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Error(err)
+		return
+	}
+	trainData, testData := base.InstancesTrainTestSplitWithSeed(inst, 0.4, 1)
+	p := NewPipeline(trees.NewID3DecisionTree(0.0), ChiMerge(0.90))
+	p.Fit(trainData)
+	if trainData.GetAttr(0).GetType() != base.Float64Type {
+		testEnv.Error("Pipeline shouldn't modify its training data")
+	}
+	predictions := p.Predict(testData)
+	if testData.GetAttr(0).GetType() != base.Float64Type {
+		testEnv.Error("Pipeline shouldn't modify its input")
+	}
+	// The held-out rows have to go through the same preprocessing
+	// for the tree to classify them this well
+	accuracy := eval.GetAccuracy(eval.GetConfusionMatrix(testData, predictions))
+	if accuracy < 0.85 {
+		testEnv.Error(accuracy)
+	}
+}
+
+func TestPipelineCache(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Error(err)
+		return
+	}
+	cache := NewCache()
+	p := NewPipeline(trees.NewID3DecisionTree(0.0), ChiMerge(0.90))
+	p.Cache = cache
+	p.Fit(inst)
+	if cache.Hits != 0 || cache.Misses != 1 {
+		testEnv.Errorf("Expected 0 hits and 1 miss, got %d and %d", cache.Hits, cache.Misses)
+	}
+
+	// Changing the Classifier shouldn't redo the preprocessing
+	p.Classifier = trees.NewRandomTree(2)
+	p.Fit(inst)
+	if cache.Hits != 1 || cache.Misses != 1 {
+		testEnv.Errorf("Expected 1 hit and 1 miss, got %d and %d", cache.Hits, cache.Misses)
+	}
+
+	// ...but changing the Step's parameters should
+	p.Steps[0] = ChiMerge(0.95)
+	p.Fit(inst)
+	if cache.Misses != 2 || cache.Len() != 2 {
+		testEnv.Errorf("Expected 2 misses, got %d", cache.Misses)
+	}
+
+	// ...as should changing the data
+	trainData, _ := base.InstancesTrainTestSplit(inst, 0.5)
+	p.Fit(trainData)
+	if cache.Misses != 3 {
+		testEnv.Errorf("Expected 3 misses, got %d", cache.Misses)
+	}
+}
+
+func TestPipelineKernelApproximation(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	for _, step := range []Step{Nystroem(0.5, 30, 1), RandomFourier(0.5, 30, 1)} {
+		p := NewPipeline(trees.NewRandomTree(30), step)
+		p.Fit(inst)
+		transformed := p.Transform(inst)
+		if transformed.Cols != 31 || transformed.GetClassAttr() != inst.GetClassAttr() {
+			testEnv.Fatal(transformed.Cols)
+		}
+		predictions := p.Predict(inst)
+		if accuracy := eval.GetAccuracy(eval.GetConfusionMatrix(inst, predictions)); accuracy < 0.75 {
+			testEnv.Error(step().String(), accuracy)
+		}
+	}
+}
+
+func TestPipelineProvenance(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	p := NewPipeline(trees.NewRandomTree(2), Binning(3))
+	p.Fit(inst)
+	provenance := p.Provenance()
+	if len(provenance) != 5 {
+		testEnv.Fatal(provenance)
+	}
+	first := inst.GetAttr(0).GetName()
+	if s := provenance[0].String(); s != first+"→binned[3]" {
+		testEnv.Error(s)
+	}
+	if s := provenance[4].String(); s != inst.GetClassAttr().GetName() {
+		testEnv.Error(s)
+	}
+
+	p = NewPipeline(trees.NewRandomTree(2), Binning(3), Nystroem(0.5, 10, 1))
+	p.Fit(inst)
+	provenance = p.Provenance()
+	if len(provenance) != 11 {
+		testEnv.Fatal(provenance)
+	}
+	if len(provenance[0].Sources) != 4 || provenance[0].Transform != "binned[3]→nystroem" {
+		testEnv.Error(provenance[0])
+	}
+	importance := p.AggregateBySource(map[string]float64{"feature0": 4, "feature1": 2})
+	if len(importance) != 4 || importance[first] != 1.5 {
+		testEnv.Error(importance)
+	}
+}
+
+func TestPipelinePreprocessing(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	// Bin the features so that there's something to encode
+	p := NewPipeline(trees.NewRandomTree(2), Binning(3), OneHot(), SelectFeatures(0.5), Standardise())
+	p.Fit(inst)
+	transformed := p.Transform(inst)
+	if transformed.Cols != 7 || transformed.GetClassAttr().GetName() != inst.GetClassAttr().GetName() {
+		testEnv.Fatal(transformed)
+	}
+	// Petal length and width say the most about the class
+	provenance := p.Provenance()
+	for _, prov := range provenance[:6] {
+		if prov.Sources[0] != "Petal length" && prov.Sources[0] != "Petal width" {
+			testEnv.Error(prov)
+		}
+		if prov.Transform != "binned[3]→onehot→standardised" {
+			testEnv.Error(prov)
+		}
+	}
+	if name := transformed.GetAttr(0).GetName(); name != "Petal length=0" {
+		testEnv.Error(name)
+	}
+	mean := 0.0
+	for i := 0; i < transformed.Rows; i++ {
+		mean += transformed.Get(i, 0) / float64(transformed.Rows)
+	}
+	if mean > 1e-9 || mean < -1e-9 {
+		testEnv.Error(mean)
+	}
+}
+
+// mixedInstances returns rows with a categorical feature before a
+// numeric one: colour says nothing about the class, and x everything
+func mixedInstances() *base.Instances {
+	colour := base.NewCategoricalAttribute()
+	colour.SetName("colour")
+	x := base.NewFloatAttribute()
+	x.SetName("x")
+	class := base.NewCategoricalAttribute()
+	class.SetName("class")
+	inst := base.NewInstances([]base.Attribute{colour, x, class}, 30)
+	for i := 0; i < inst.Rows; i++ {
+		inst.SetAttrStr(i, 0, []string{"red", "green", "blue"}[i%3])
+		inst.Set(i, 1, float64(i)*0.3)
+		inst.SetAttrStr(i, 2, []string{"low", "mid", "high"}[i/10])
+	}
+	return inst
+}
+
+func TestPipelineMixedTypes(testEnv *testing.T) {
+	inst := mixedInstances()
+	p := NewPipeline(trees.NewID3DecisionTree(0.0), Binning(3))
+	p.Fit(inst)
+	transformed := p.Transform(inst)
+	// Only x is binned
+	for i := 0; i < inst.Rows; i++ {
+		if transformed.GetAttrStr(i, 0) != inst.GetAttrStr(i, 0) {
+			testEnv.Fatalf("Row %d: colour %s became %s", i, inst.GetAttrStr(i, 0), transformed.GetAttrStr(i, 0))
+		}
+		if bin := transformed.GetAttrStr(i, 1); bin != []string{"0", "1", "2"}[i/10] {
+			testEnv.Errorf("Row %d: x %f is in bin %s", i, inst.Get(i, 1), bin)
+		}
+	}
+	predictions := p.Predict(inst)
+	if accuracy := eval.GetAccuracy(eval.GetConfusionMatrix(inst, predictions)); accuracy < 0.9 {
+		testEnv.Error(accuracy)
+	}
+}
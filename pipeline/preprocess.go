@@ -0,0 +1,318 @@
+package pipeline
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	base "github.com/sjwhitworth/golearn/base"
+)
+
+type standardiseStep struct {
+	cols  []int
+	names []string
+	means []float64
+	sds   []float64
+}
+
+// Standardise returns a Step which centres and scales every numeric
+// feature to a (weighted) mean of zero and a standard deviation of
+// one, as distance-based Classifiers such as KNN need.
+func Standardise() Step {
+	return func() Filter {
+		return &standardiseStep{}
+	}
+}
+
+func (s *standardiseStep) Fit(on *base.Instances) {
+	s.cols, s.names, s.means, s.sds = nil, nil, nil, nil
+	for _, j := range on.FeatureIndices() {
+		if on.GetAttr(j).GetType() != base.Float64Type {
+			continue
+		}
+		total, sum, sumSq := 0.0, 0.0, 0.0
+		for i := 0; i < on.Rows; i++ {
+			if on.IsMissing(i, j) {
+				continue
+			}
+			w, v := on.GetWeight(i), on.Get(i, j)
+			total += w
+			sum += w * v
+			sumSq += w * v * v
+		}
+		mean, sd := 0.0, 1.0
+		if total > 0 {
+			mean = sum / total
+			if variance := sumSq/total - mean*mean; variance > 1e-12 {
+				sd = math.Sqrt(variance)
+			}
+		}
+		s.cols = append(s.cols, j)
+		s.names = append(s.names, on.GetAttr(j).GetName())
+		s.means = append(s.means, mean)
+		s.sds = append(s.sds, sd)
+	}
+}
+
+func (s *standardiseStep) Transform(what *base.Instances) *base.Instances {
+	ret := copyInstances(what)
+	for k, j := range s.cols {
+		for i := 0; i < ret.Rows; i++ {
+			if !ret.IsMissing(i, j) {
+				ret.Set(i, j, (ret.Get(i, j)-s.means[k])/s.sds[k])
+			}
+		}
+	}
+	return ret
+}
+
+func (s *standardiseStep) Provenance() []base.Provenance {
+	ret := make([]base.Provenance, 0)
+	for _, name := range s.names {
+		ret = append(ret, base.Provenance{Attribute: name, Sources: []string{name}, Transform: "standardised"})
+	}
+	return ret
+}
+
+func (s *standardiseStep) String() string {
+	return "Standardise()"
+}
+
+type oneHotStep struct {
+	// attrs holds the Attributes of the output, and sources and
+	// inputs the column of the input each comes from and its name
+	attrs      []base.Attribute
+	sources    []int
+	inputs     []string
+	classIndex int
+	// values holds the value each indicator column stands for, or
+	// "" for columns which are passed through
+	values []string
+}
+
+// valuesAttribute is implemented by categorical Attributes which
+// list their values, i.e. base.CategoricalAttribute and
+// base.HashedAttribute
+type valuesAttribute interface {
+	base.Attribute
+	GetValues() []string
+}
+
+// OneHot returns a Step which replaces every categorical feature with
+// an indicator Attribute (with a value of 0 or 1) for each of its
+// values, named "feature=value", so that Classifiers which treat
+// every Attribute as a number don't impose an order on the values.
+// Values which weren't seen in training have no indicator.
+func OneHot() Step {
+	return func() Filter {
+		return &oneHotStep{}
+	}
+}
+
+func (o *oneHotStep) Fit(on *base.Instances) {
+	o.attrs, o.sources, o.inputs, o.values = nil, nil, nil, nil
+	isFeature := make(map[int]bool)
+	for _, j := range on.FeatureIndices() {
+		isFeature[j] = true
+	}
+	for j := 0; j < on.Cols; j++ {
+		attr := on.GetAttr(j)
+		cat, ok := attr.(valuesAttribute)
+		if !ok || !isFeature[j] {
+			if j == on.ClassIndex {
+				o.classIndex = len(o.attrs)
+			}
+			o.attrs = append(o.attrs, attr)
+			o.sources = append(o.sources, j)
+			o.inputs = append(o.inputs, attr.GetName())
+			o.values = append(o.values, "")
+			continue
+		}
+		for _, v := range cat.GetValues() {
+			indicator := base.NewFloatAttribute()
+			indicator.SetName(fmt.Sprintf("%s=%s", attr.GetName(), v))
+			o.attrs = append(o.attrs, indicator)
+			o.sources = append(o.sources, j)
+			o.inputs = append(o.inputs, attr.GetName())
+			o.values = append(o.values, v)
+		}
+	}
+}
+
+func (o *oneHotStep) Transform(what *base.Instances) *base.Instances {
+	ret := base.NewInstances(o.attrs, what.Rows)
+	ret.ClassIndex = o.classIndex
+	for k, j := range o.sources {
+		if o.values[k] == "" {
+			ret.SetRole(k, what.GetRole(j))
+		}
+	}
+	for i := 0; i < what.Rows; i++ {
+		for k, j := range o.sources {
+			switch {
+			case what.IsMissing(i, j):
+				ret.SetMissing(i, k)
+			case o.values[k] == "":
+				ret.Set(i, k, what.Get(i, j))
+			case what.GetAttrStr(i, j) == o.values[k]:
+				ret.Set(i, k, 1)
+			}
+		}
+	}
+	ret.SetWeights(what.Weights())
+	return ret
+}
+
+// Provenance says that each indicator is derived from its categorical
+// feature, and everything else is passed through
+func (o *oneHotStep) Provenance() []base.Provenance {
+	ret := make([]base.Provenance, len(o.attrs))
+	for k, a := range o.attrs {
+		if o.values[k] == "" {
+			ret[k] = base.PassThrough(a.GetName())
+			continue
+		}
+		ret[k] = base.Provenance{Attribute: a.GetName(), Sources: []string{o.inputs[k]}, Transform: "onehot"}
+	}
+	return ret
+}
+
+func (o *oneHotStep) String() string {
+	return "OneHot()"
+}
+
+// selectionBins is the number of bins numeric features are divided
+// into to measure how much they say about the class
+const selectionBins = 10
+
+type selectFeaturesStep struct {
+	proportion float64
+	keep       []int
+}
+
+// SelectFeatures returns a Step which keeps the given proportion (at
+// least one) of the features: those with the most mutual information
+// with the class, numeric features being divided into bins of roughly
+// equal size. Attributes which aren't features are kept too.
+func SelectFeatures(proportion float64) Step {
+	return func() Filter {
+		return &selectFeaturesStep{proportion: proportion}
+	}
+}
+
+func (s *selectFeaturesStep) Fit(on *base.Instances) {
+	features := on.FeatureIndices()
+	scores := make([]float64, on.Cols)
+	for _, j := range features {
+		scores[j] = mutualInformation(on, j)
+	}
+	ranked := make([]int, len(features))
+	copy(ranked, features)
+	sort.Stable(&byScore{ranked, scores})
+	n := int(math.Ceil(s.proportion * float64(len(features))))
+	if n < 1 {
+		n = 1
+	}
+	if n > len(ranked) {
+		n = len(ranked)
+	}
+	selected := make(map[int]bool)
+	for _, j := range ranked[:n] {
+		selected[j] = true
+	}
+	isFeature := make(map[int]bool)
+	for _, j := range features {
+		isFeature[j] = true
+	}
+	s.keep = make([]int, 0)
+	for j := 0; j < on.Cols; j++ {
+		if selected[j] || !isFeature[j] {
+			s.keep = append(s.keep, j)
+		}
+	}
+}
+
+func (s *selectFeaturesStep) Transform(what *base.Instances) *base.Instances {
+	attrs := make([]base.Attribute, len(s.keep))
+	classIndex := -1
+	for k, j := range s.keep {
+		attrs[k] = what.GetAttr(j)
+		if j == what.ClassIndex {
+			classIndex = k
+		}
+	}
+	ret := what.SelectAttributes(attrs)
+	if classIndex != -1 {
+		ret.ClassIndex = classIndex
+	}
+	return ret
+}
+
+func (s *selectFeaturesStep) String() string {
+	return fmt.Sprintf("SelectFeatures(%f)", s.proportion)
+}
+
+// byScore sorts columns from the highest to the lowest score
+type byScore struct {
+	cols   []int
+	scores []float64
+}
+
+func (b *byScore) Len() int {
+	return len(b.cols)
+}
+
+func (b *byScore) Swap(i, j int) {
+	b.cols[i], b.cols[j] = b.cols[j], b.cols[i]
+}
+
+func (b *byScore) Less(i, j int) bool {
+	return b.scores[b.cols[i]] > b.scores[b.cols[j]]
+}
+
+// mutualInformation returns the (weighted) mutual information, in
+// nats, between the class and column j of inst, whose values are
+// divided into selectionBins bins if they're numeric. Missing values
+// count as a value of their own.
+func mutualInformation(inst *base.Instances, j int) float64 {
+	bin := func(i int) string { return inst.GetAttrStr(i, j) }
+	if inst.GetAttr(j).GetType() == base.Float64Type {
+		vals := make([]float64, 0, inst.Rows)
+		for i := 0; i < inst.Rows; i++ {
+			if !inst.IsMissing(i, j) {
+				vals = append(vals, inst.Get(i, j))
+			}
+		}
+		sort.Float64s(vals)
+		cuts := make([]float64, 0, selectionBins-1)
+		for b := 1; b < selectionBins && len(vals) > 0; b++ {
+			cuts = append(cuts, vals[b*len(vals)/selectionBins])
+		}
+		bin = func(i int) string {
+			if inst.IsMissing(i, j) {
+				return base.MissingString
+			}
+			return fmt.Sprintf("%d", sort.SearchFloat64s(cuts, inst.Get(i, j)))
+		}
+	}
+
+	joint := make(map[[2]string]float64)
+	values := make(map[string]float64)
+	classes := make(map[string]float64)
+	total := 0.0
+	for i := 0; i < inst.Rows; i++ {
+		w := inst.GetWeight(i)
+		v, c := bin(i), inst.GetClass(i)
+		joint[[2]string{v, c}] += w
+		values[v] += w
+		classes[c] += w
+		total += w
+	}
+	ret := 0.0
+	for key, w := range joint {
+		if w > 0 {
+			ret += w / total * math.Log(w*total/(values[key[0]]*classes[key[1]]))
+		}
+	}
+	return ret
+}
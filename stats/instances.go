@@ -0,0 +1,126 @@
+package stats
+
+import (
+	base "github.com/sjwhitworth/golearn/base"
+)
+
+// InstancesStats accumulates statistics of each Attribute of
+// Instances which are read a chunk at a time: the Moments and
+// quantiles of FloatAttributes, the counts of the values of
+// CategoricalAttributes, and how many values of each are missing.
+type InstancesStats struct {
+	attrs     []base.Attribute
+	moments   []*Moments
+	quantiles []*QuantileSketch
+	counts    []map[string]int
+	missing   []int
+	rows      int
+}
+
+// NewInstancesStats returns an empty InstancesStats for Instances
+// with the given Attributes, whose quantiles are estimated with
+// QuantileSketches of maxBins bins.
+func NewInstancesStats(attrs []base.Attribute, maxBins int) *InstancesStats {
+	ret := &InstancesStats{
+		attrs,
+		make([]*Moments, len(attrs)),
+		make([]*QuantileSketch, len(attrs)),
+		make([]map[string]int, len(attrs)),
+		make([]int, len(attrs)),
+		0,
+	}
+	for j, a := range attrs {
+		if a.GetType() == base.Float64Type {
+			ret.moments[j] = NewMoments()
+			ret.quantiles[j] = NewQuantileSketch(maxBins)
+		} else {
+			ret.counts[j] = make(map[string]int)
+		}
+	}
+	return ret
+}
+
+// StreamStats reads every chunk of stream and returns their
+// InstancesStats (see NewInstancesStats), or the stream's error.
+func StreamStats(stream *base.CSVStream, maxBins int) (*InstancesStats, error) {
+	ret := NewInstancesStats(stream.Attributes(), maxBins)
+	for stream.Next() {
+		ret.Add(stream.Instances())
+	}
+	if err := stream.Err(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// Add adds the rows of chunk, which must have the same Attributes
+func (s *InstancesStats) Add(chunk *base.Instances) {
+	for i := 0; i < chunk.Rows; i++ {
+		for j := range s.attrs {
+			val := chunk.Get(i, j)
+			if base.IsMissingValue(val) {
+				s.missing[j]++
+				continue
+			}
+			if s.moments[j] != nil {
+				s.moments[j].Add(val)
+				s.quantiles[j].Add(val)
+			} else {
+				s.counts[j][chunk.GetAttr(j).GetStringFromSysVal(val)]++
+			}
+		}
+	}
+	s.rows += chunk.Rows
+}
+
+// Merge adds the statistics accumulated by other, which must be for
+// the same Attributes
+func (s *InstancesStats) Merge(other *InstancesStats) {
+	for j := range s.attrs {
+		if s.moments[j] != nil {
+			s.moments[j].Merge(other.moments[j])
+			s.quantiles[j].Merge(other.quantiles[j])
+		} else {
+			for v, c := range other.counts[j] {
+				s.counts[j][v] += c
+			}
+		}
+		s.missing[j] += other.missing[j]
+	}
+	s.rows += other.rows
+}
+
+// Rows returns the number of rows added
+func (s *InstancesStats) Rows() int {
+	return s.rows
+}
+
+// Attributes returns the Attributes the statistics are for
+func (s *InstancesStats) Attributes() []base.Attribute {
+	return s.attrs
+}
+
+// Moments returns the Moments of the FloatAttribute at index col, or
+// nil if it's not a FloatAttribute
+func (s *InstancesStats) Moments(col int) *Moments {
+	return s.moments[col]
+}
+
+// Quantiles returns the QuantileSketch of the FloatAttribute at
+// index col, or nil if it's not a FloatAttribute
+func (s *InstancesStats) Quantiles(col int) *QuantileSketch {
+	return s.quantiles[col]
+}
+
+// Counts returns the number of times each value of the
+// CategoricalAttribute at index col occurs, or nil if it's not a
+// CategoricalAttribute
+func (s *InstancesStats) Counts(col int) map[string]int {
+	return s.counts[col]
+}
+
+// Missing returns the number of missing values of the Attribute at
+// index col
+func (s *InstancesStats) Missing(col int) int {
+	return s.missing[col]
+}
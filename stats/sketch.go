@@ -0,0 +1,199 @@
+package stats
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"sort"
+)
+
+// CountMinSketch estimates how often each of a stream of keys
+// occurs using a fixed amount of memory, however many distinct keys
+// there are (Cormode and Muthukrishnan). Estimates are never too
+// low, and with probability 1 - exp(-depth) they're too high by at
+// most e/width times the total count.
+type CountMinSketch struct {
+	width  int
+	depth  int
+	counts [][]uint64
+	total  uint64
+}
+
+// NewCountMinSketch returns an empty CountMinSketch with depth rows
+// of width counters.
+//
+// IMPORTANT: this function panic()s if width or depth is less than
+// one.
+func NewCountMinSketch(width, depth int) *CountMinSketch {
+	if width < 1 || depth < 1 {
+		panic(fmt.Sprintf("stats: invalid CountMinSketch size %dx%d", width, depth))
+	}
+	counts := make([][]uint64, depth)
+	for i := range counts {
+		counts[i] = make([]uint64, width)
+	}
+	return &CountMinSketch{width, depth, counts, 0}
+}
+
+// hashes returns two independent hashes of key, from which each
+// row's counter is picked (Kirsch and Mitzenmacher)
+func (c *CountMinSketch) hashes(key string) (uint64, uint64) {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	h1 := h.Sum64()
+	h.Write([]byte{0})
+	h2 := h.Sum64() | 1
+	return h1, h2
+}
+
+// Add adds count occurrences of key
+func (c *CountMinSketch) Add(key string, count uint64) {
+	h1, h2 := c.hashes(key)
+	for i := 0; i < c.depth; i++ {
+		c.counts[i][(h1+uint64(i)*h2)%uint64(c.width)] += count
+	}
+	c.total += count
+}
+
+// Count returns the estimated number of occurrences of key
+func (c *CountMinSketch) Count(key string) uint64 {
+	h1, h2 := c.hashes(key)
+	ret := uint64(math.MaxUint64)
+	for i := 0; i < c.depth; i++ {
+		if v := c.counts[i][(h1+uint64(i)*h2)%uint64(c.width)]; v < ret {
+			ret = v
+		}
+	}
+	return ret
+}
+
+// Total returns the total count of every key
+func (c *CountMinSketch) Total() uint64 {
+	return c.total
+}
+
+// Merge adds the counts of other, which must be the same size
+//
+// IMPORTANT: this function panic()s if the sizes differ.
+func (c *CountMinSketch) Merge(other *CountMinSketch) {
+	if c.width != other.width || c.depth != other.depth {
+		panic("stats: can't merge CountMinSketches of different sizes")
+	}
+	for i := range c.counts {
+		for j := range c.counts[i] {
+			c.counts[i][j] += other.counts[i][j]
+		}
+	}
+	c.total += other.total
+}
+
+// histogramBin is a bin of a QuantileSketch: count values around
+// value
+type histogramBin struct {
+	value float64
+	count float64
+}
+
+// QuantileSketch estimates the quantiles of a stream of values using
+// a histogram of at most a fixed number of bins, merging the closest
+// pair of bins whenever there are too many (Ben-Haim and Tom-Tov).
+// It's exact until more than that many distinct values are added.
+// NaNs (missing values) are ignored.
+type QuantileSketch struct {
+	maxBins int
+	bins    []histogramBin
+	count   float64
+	// exact is false once bins have been merged
+	exact bool
+}
+
+// NewQuantileSketch returns an empty QuantileSketch which keeps at
+// most maxBins bins.
+//
+// IMPORTANT: this function panic()s if maxBins is less than two.
+func NewQuantileSketch(maxBins int) *QuantileSketch {
+	if maxBins < 2 {
+		panic(fmt.Sprintf("stats: a QuantileSketch needs at least 2 bins, got %d", maxBins))
+	}
+	return &QuantileSketch{maxBins, make([]histogramBin, 0, maxBins+1), 0, true}
+}
+
+// Add adds x to the sketch
+func (q *QuantileSketch) Add(x float64) {
+	if math.IsNaN(x) {
+		return
+	}
+	q.insert(histogramBin{x, 1})
+	q.compress()
+}
+
+// insert adds a bin, keeping the bins in order of value
+func (q *QuantileSketch) insert(b histogramBin) {
+	q.count += b.count
+	i := sort.Search(len(q.bins), func(i int) bool {
+		return q.bins[i].value >= b.value
+	})
+	if i < len(q.bins) && q.bins[i].value == b.value {
+		q.bins[i].count += b.count
+		return
+	}
+	q.bins = append(q.bins, histogramBin{})
+	copy(q.bins[i+1:], q.bins[i:])
+	q.bins[i] = b
+}
+
+// compress merges the closest bins until there are few enough
+func (q *QuantileSketch) compress() {
+	for len(q.bins) > q.maxBins {
+		closest := 0
+		for i := 1; i < len(q.bins)-1; i++ {
+			if q.bins[i+1].value-q.bins[i].value < q.bins[closest+1].value-q.bins[closest].value {
+				closest = i
+			}
+		}
+		a, b := q.bins[closest], q.bins[closest+1]
+		count := a.count + b.count
+		q.bins[closest] = histogramBin{(a.value*a.count + b.value*b.count) / count, count}
+		q.bins = append(q.bins[:closest+1], q.bins[closest+2:]...)
+		q.exact = false
+	}
+}
+
+// Merge adds the values summarised by other
+func (q *QuantileSketch) Merge(other *QuantileSketch) {
+	for _, b := range other.bins {
+		q.insert(b)
+	}
+	q.exact = q.exact && other.exact
+	q.compress()
+}
+
+// Count returns the number of values added
+func (q *QuantileSketch) Count() int {
+	return int(q.count)
+}
+
+// Quantile returns an estimate of the smallest value which at least
+// the fraction p of the values are no greater than, or NaN if
+// nothing has been added. Once bins have been merged, each bin's
+// values are taken to be spread evenly back to the previous bin.
+func (q *QuantileSketch) Quantile(p float64) float64 {
+	if len(q.bins) == 0 {
+		return math.NaN()
+	}
+	target := math.Max(0, math.Min(1, p)) * q.count
+	cumulative := 0.0
+	for i, b := range q.bins {
+		if cumulative+b.count >= target {
+			if q.exact || i == 0 {
+				return b.value
+			}
+			// Interpolate from the previous bin
+			prev := q.bins[i-1]
+			frac := (target - cumulative) / b.count
+			return prev.value + frac*(b.value-prev.value)
+		}
+		cumulative += b.count
+	}
+	return q.bins[len(q.bins)-1].value
+}
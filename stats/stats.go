@@ -0,0 +1,99 @@
+// Package stats provides accumulators which compute statistics in a
+// single pass over data, one value or chunk of Instances at a time,
+// so that they can be used on data which doesn't fit in memory (e.g.
+// read with base.StreamCSV). Accumulators of the same kind can be
+// merged, e.g. after processing chunks in parallel.
+package stats
+
+import "math"
+
+// Moments accumulates the count, mean, variance and range of a
+// sequence of values using Welford's method, which stays accurate
+// when the mean is large compared to the variance. NaNs (missing
+// values) are ignored.
+type Moments struct {
+	count int
+	mean  float64
+	m2    float64
+	min   float64
+	max   float64
+}
+
+// NewMoments returns an empty Moments.
+func NewMoments() *Moments {
+	return &Moments{0, 0, 0, math.Inf(1), math.Inf(-1)}
+}
+
+// Add adds x to the sequence
+func (m *Moments) Add(x float64) {
+	if math.IsNaN(x) {
+		return
+	}
+	m.count++
+	delta := x - m.mean
+	m.mean += delta / float64(m.count)
+	m.m2 += delta * (x - m.mean)
+	m.min = math.Min(m.min, x)
+	m.max = math.Max(m.max, x)
+}
+
+// Merge adds the values accumulated by other, as if they'd been
+// added to m (Chan et al.'s method)
+func (m *Moments) Merge(other *Moments) {
+	if other.count == 0 {
+		return
+	}
+	count := m.count + other.count
+	delta := other.mean - m.mean
+	m.mean += delta * float64(other.count) / float64(count)
+	m.m2 += other.m2 + delta*delta*float64(m.count)*float64(other.count)/float64(count)
+	m.count = count
+	m.min = math.Min(m.min, other.min)
+	m.max = math.Max(m.max, other.max)
+}
+
+// Count returns the number of values added
+func (m *Moments) Count() int {
+	return m.count
+}
+
+// Mean returns the mean of the values, or NaN if there aren't any
+func (m *Moments) Mean() float64 {
+	if m.count == 0 {
+		return math.NaN()
+	}
+	return m.mean
+}
+
+// Variance returns the population variance of the values, or NaN if
+// there aren't any
+func (m *Moments) Variance() float64 {
+	if m.count == 0 {
+		return math.NaN()
+	}
+	return m.m2 / float64(m.count)
+}
+
+// SampleVariance returns the unbiased sample variance of the values,
+// or NaN if there are fewer than two
+func (m *Moments) SampleVariance() float64 {
+	if m.count < 2 {
+		return math.NaN()
+	}
+	return m.m2 / float64(m.count-1)
+}
+
+// StdDev returns the population standard deviation of the values
+func (m *Moments) StdDev() float64 {
+	return math.Sqrt(m.Variance())
+}
+
+// Min returns the smallest value, or +Inf if there aren't any
+func (m *Moments) Min() float64 {
+	return m.min
+}
+
+// Max returns the largest value, or -Inf if there aren't any
+func (m *Moments) Max() float64 {
+	return m.max
+}
@@ -0,0 +1,121 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	base "github.com/sjwhitworth/golearn/base"
+)
+
+func TestMoments(testEnv *testing.T) {
+	a, b, all := NewMoments(), NewMoments(), NewMoments()
+	for i := 0; i < 1000; i++ {
+		// A large offset loses precision with the naive formula
+		x := 1e9 + float64(i%10)
+		if i < 300 {
+			a.Add(x)
+		} else {
+			b.Add(x)
+		}
+		all.Add(x)
+	}
+	all.Add(math.NaN())
+	a.Merge(b)
+	for _, m := range []*Moments{a, all} {
+		if m.Count() != 1000 || math.Abs(m.Mean()-(1e9+4.5)) > 1e-6 || math.Abs(m.Variance()-8.25) > 1e-6 {
+			testEnv.Error(m.Count(), m.Mean(), m.Variance())
+		}
+		if m.Min() != 1e9 || m.Max() != 1e9+9 {
+			testEnv.Error(m.Min(), m.Max())
+		}
+	}
+	if v := NewMoments().Mean(); !math.IsNaN(v) {
+		testEnv.Error(v)
+	}
+}
+
+func TestCountMinSketch(testEnv *testing.T) {
+	c := NewCountMinSketch(200, 5)
+	for i := 0; i < 1000; i++ {
+		c.Add(fmt.Sprintf("key%d", i%100), 1)
+	}
+	c.Add("common", 500)
+	other := NewCountMinSketch(200, 5)
+	other.Add("common", 100)
+	c.Merge(other)
+	if n := c.Count("common"); n < 600 || n > 650 {
+		testEnv.Error(n)
+	}
+	for i := 0; i < 100; i++ {
+		if n := c.Count(fmt.Sprintf("key%d", i)); n < 10 || n > 60 {
+			testEnv.Error(i, n)
+		}
+	}
+	if c.Total() != 1600 {
+		testEnv.Error(c.Total())
+	}
+}
+
+func TestQuantileSketch(testEnv *testing.T) {
+	exact := NewQuantileSketch(10)
+	for _, x := range []float64{3, 1, 2, 2, 5} {
+		exact.Add(x)
+	}
+	if exact.Quantile(0) != 1 || exact.Quantile(0.5) != 2 || exact.Quantile(1) != 5 {
+		testEnv.Error(exact.Quantile(0), exact.Quantile(0.5), exact.Quantile(1))
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	a, b := NewQuantileSketch(50), NewQuantileSketch(50)
+	for i := 0; i < 20000; i++ {
+		if i%2 == 0 {
+			a.Add(rng.Float64())
+		} else {
+			b.Add(rng.Float64())
+		}
+	}
+	a.Merge(b)
+	if a.Count() != 20000 {
+		testEnv.Error(a.Count())
+	}
+	for _, p := range []float64{0.1, 0.5, 0.9} {
+		if q := a.Quantile(p); math.Abs(q-p) > 0.03 {
+			testEnv.Errorf("Quantile %g: %g", p, q)
+		}
+	}
+}
+
+func TestStreamStats(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	stream, err := base.StreamCSV("../examples/datasets/iris_headers.csv", true, 32)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	defer stream.Close()
+	s, err := StreamStats(stream, 100)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	if s.Rows() != 150 || s.Counts(4)["Iris-setosa"] != 50 || s.Moments(4) != nil {
+		testEnv.Error(s.Rows(), s.Counts(4))
+	}
+	sum := 0.0
+	for i := 0; i < inst.Rows; i++ {
+		sum += inst.Get(i, 0)
+	}
+	if m := s.Moments(0); math.Abs(m.Mean()-sum/150) > 1e-9 || m.Min() != 4.3 || m.Max() != 7.9 {
+		testEnv.Error(m.Mean(), m.Min(), m.Max())
+	}
+	// There are fewer distinct values than bins, so it's exact
+	if q := s.Quantiles(2).Quantile(0.5); q != 4.3 {
+		testEnv.Error(q)
+	}
+	if s.Missing(0) != 0 {
+		testEnv.Error(s.Missing(0))
+	}
+}
@@ -0,0 +1,494 @@
+// Package svm implements support vector machines with non-linear
+// kernels, for small datasets whose classes can't be separated by a
+// hyperplane in the original features. For large or sparse data see
+// linear_models.LinearSVM instead.
+package svm
+
+import (
+	"encoding/gob"
+	"fmt"
+	"math"
+	"sort"
+
+	base "github.com/sjwhitworth/golearn/base"
+)
+
+// SVCParams holds the parameters of SVC.
+type SVCParams struct {
+	// Kernel is the inner product the classes are separated under:
+	// "rbf" (or ""), exp(-Gamma * ||x - y||^2); "poly",
+	// (Gamma * x.y + Coef0)^Degree; or "linear", x.y
+	Kernel string
+	// C is the cost of each unit of hinge loss, relative to the
+	// width of the margin. Larger values fit the training rows more
+	// closely.
+	C float64
+	// Gamma scales the rbf and poly kernels. Zero means one over
+	// the number of features times the variance of their values.
+	Gamma  float64
+	Degree int
+	Coef0  float64
+	// Tolerance stops training once the Karush-Kuhn-Tucker
+	// conditions are violated by no more than it
+	Tolerance float64
+	// MaxIterations is the most pairs of rows each machine updates
+	MaxIterations int
+}
+
+// DefaultSVCParams returns the SVCParams used by NewSVC before its
+// arguments are applied.
+func DefaultSVCParams() SVCParams {
+	return SVCParams{
+		Kernel:        "rbf",
+		C:             1.0,
+		Degree:        3,
+		Tolerance:     1e-3,
+		MaxIterations: 100000,
+	}
+}
+
+// Validate checks that the SVCParams are usable.
+func (p SVCParams) Validate() error {
+	switch p.Kernel {
+	case "", "rbf", "poly", "linear":
+	default:
+		return fmt.Errorf("svm: unknown Kernel %q", p.Kernel)
+	}
+	if p.C <= 0 {
+		return fmt.Errorf("svm: C should be positive, got %f", p.C)
+	}
+	if p.Gamma < 0 {
+		return fmt.Errorf("svm: Gamma can't be negative, got %f", p.Gamma)
+	}
+	if p.Kernel == "poly" && p.Degree < 1 {
+		return fmt.Errorf("svm: Degree should be at least 1, got %d", p.Degree)
+	}
+	if p.Tolerance <= 0 {
+		return fmt.Errorf("svm: Tolerance should be positive, got %f", p.Tolerance)
+	}
+	if p.MaxIterations < 1 {
+		return fmt.Errorf("svm: MaxIterations should be at least 1, got %d", p.MaxIterations)
+	}
+	return nil
+}
+
+// Machine separates one pair of classes. Its decision value for a
+// row is the sum of Coefficients times the kernel between the row and
+// the corresponding support vectors, plus Intercept: positive for
+// Positive, negative for Negative.
+type Machine struct {
+	Negative, Positive string
+	// Support holds the positions in SVC.SupportVectors of the
+	// support vectors of this machine
+	Support []int
+	// Coefficients holds each support vector's dual coefficient
+	// times its label (+1 or -1)
+	Coefficients []float64
+	Intercept    float64
+}
+
+// SVC is a support vector classifier: it separates the classes with
+// the widest margin in the space of a kernel, found with sequential
+// minimal optimisation using second order working set selection, as
+// in LIBSVM (Fan et al., 2005). More than two classes are separated
+// one-vs-one, with a Machine for every pair voting for the class.
+// Each row's hinge loss is scaled by its weight (see
+// base.Instances.SetWeight).
+//
+// Training computes the kernel between every pair of rows, so its
+// time and memory grow with the square of the number of rows.
+//
+// The kernels compare the stored values of the features, with
+// missing values as zero and categorical values as their index (see
+// pipeline.OneHot). A single Gamma scales the distance in every
+// direction, so the features should have similar scales (see
+// pipeline.Standardise).
+type SVC struct {
+	base.BaseClassifier
+	SVCParams
+	// FittedGamma is the Gamma the kernel was computed with, which
+	// differs from Gamma if that's zero
+	FittedGamma float64
+	// Support holds the training rows which are support vectors of
+	// any Machine, and SupportVectors their features
+	Support        []int
+	SupportVectors [][]float64
+	Machines       []Machine
+	// Classes holds the class values seen during training, sorted
+	Classes []string
+	// Attributes holds the features trained on
+	Attributes []base.Attribute
+}
+
+// NewSVC returns an SVC with the given kernel and C, and the
+// DefaultSVCParams otherwise.
+func NewSVC(kernel string, c float64) *SVC {
+	params := DefaultSVCParams()
+	params.Kernel = kernel
+	params.C = c
+	return &SVC{SVCParams: params}
+}
+
+// NewSVCFromParams returns a new SVC with the given parameters, or an
+// error if they're invalid.
+func NewSVCFromParams(params SVCParams) (*SVC, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	return &SVC{SVCParams: params}, nil
+}
+
+func init() {
+	base.RegisterClassifier("svc", func() base.Classifier {
+		return NewSVC("rbf", 1)
+	})
+	gob.Register(&SVC{})
+}
+
+// features returns the features of row i of what, with missing
+// values as zero
+func features(what *base.Instances, i int, cols []int) []float64 {
+	ret := make([]float64, len(cols))
+	for k, j := range cols {
+		if v := what.Get(i, j); !base.IsMissingValue(v) {
+			ret[k] = v
+		}
+	}
+	return ret
+}
+
+// kernel returns the kernel between x and y
+func (s *SVC) kernel(x, y []float64) float64 {
+	switch s.Kernel {
+	case "linear":
+		return dot(x, y)
+	case "poly":
+		return math.Pow(s.FittedGamma*dot(x, y)+s.Coef0, float64(s.Degree))
+	}
+	ret := 0.0
+	for k := range x {
+		ret += (x[k] - y[k]) * (x[k] - y[k])
+	}
+	return math.Exp(-s.FittedGamma * ret)
+}
+
+func dot(x, y []float64) float64 {
+	ret := 0.0
+	for k := range x {
+		ret += x[k] * y[k]
+	}
+	return ret
+}
+
+// defaultGamma returns one over the number of features times the
+// variance of all their values, or one if that's zero
+func defaultGamma(rows [][]float64) float64 {
+	n, sum, squares := 0.0, 0.0, 0.0
+	for _, r := range rows {
+		for _, v := range r {
+			n++
+			sum += v
+			squares += v * v
+		}
+	}
+	if n == 0 {
+		return 1
+	}
+	variance := squares/n - (sum/n)*(sum/n)
+	if variance <= 0 {
+		return 1
+	}
+	return 1 / (float64(len(rows[0])) * variance)
+}
+
+// Fit trains the SVC on the given Instances.
+//
+// IMPORTANT: this function panic()s if the parameters are invalid or
+// the class Attribute is numeric.
+func (s *SVC) Fit(on *base.Instances) {
+	if err := s.Validate(); err != nil {
+		panic(err.Error())
+	}
+	if on.GetClassAttr().GetType() != base.CategoricalType {
+		panic("svm: SVC needs a categorical class")
+	}
+	cols := on.FeatureIndices()
+	s.Attributes = make([]base.Attribute, len(cols))
+	for k, j := range cols {
+		s.Attributes[k] = on.GetAttr(j)
+	}
+	s.Classes = make([]string, 0)
+	for c := range on.CountClassValues() {
+		s.Classes = append(s.Classes, c)
+	}
+	sort.Strings(s.Classes)
+
+	rows := make([][]float64, on.Rows)
+	byClass := make(map[string][]int)
+	for i := range rows {
+		rows[i] = features(on, i, cols)
+		byClass[on.GetClass(i)] = append(byClass[on.GetClass(i)], i)
+	}
+	s.FittedGamma = s.Gamma
+	if s.FittedGamma == 0 {
+		s.FittedGamma = defaultGamma(rows)
+	}
+	// Every pair of classes needs the kernel between its rows, so
+	// it's computed once for all of them
+	kernel := make([][]float64, on.Rows)
+	for i := range kernel {
+		kernel[i] = make([]float64, on.Rows)
+		for j := 0; j <= i; j++ {
+			kernel[i][j] = s.kernel(rows[i], rows[j])
+			kernel[j][i] = kernel[i][j]
+		}
+	}
+
+	s.Machines = make([]Machine, 0)
+	positions := make(map[int]int)
+	s.Support = make([]int, 0)
+	s.SupportVectors = make([][]float64, 0)
+	for a := 0; a < len(s.Classes); a++ {
+		for b := a + 1; b < len(s.Classes); b++ {
+			members := append(append([]int{}, byClass[s.Classes[b]]...), byClass[s.Classes[a]]...)
+			labels := make([]float64, len(members))
+			bounds := make([]float64, len(members))
+			for t, i := range members {
+				labels[t] = -1
+				if t < len(byClass[s.Classes[b]]) {
+					labels[t] = 1
+				}
+				bounds[t] = s.C * on.GetWeight(i)
+			}
+			alphas, rho := s.smo(kernel, members, labels, bounds)
+			m := Machine{Negative: s.Classes[a], Positive: s.Classes[b], Intercept: -rho}
+			for t, i := range members {
+				if alphas[t] == 0 {
+					continue
+				}
+				if _, ok := positions[i]; !ok {
+					positions[i] = len(s.Support)
+					s.Support = append(s.Support, i)
+					s.SupportVectors = append(s.SupportVectors, rows[i])
+				}
+				m.Support = append(m.Support, positions[i])
+				m.Coefficients = append(m.Coefficients, alphas[t]*labels[t])
+			}
+			s.Machines = append(s.Machines, m)
+		}
+	}
+}
+
+// smo returns the dual coefficients, and the negated intercept, of
+// the machine separating the members labelled 1 from those labelled
+// -1, with each member's coefficient between zero and its bound
+func (s *SVC) smo(kernel [][]float64, members []int, labels, bounds []float64) ([]float64, float64) {
+	// tau stands in for the curvature along a pair of rows when it
+	// isn't positive, as it can be for kernels which aren't
+	// positive definite
+	const tau = 1e-12
+	n := len(members)
+	q := func(t, u int) float64 {
+		return labels[t] * labels[u] * kernel[members[t]][members[u]]
+	}
+	alphas := make([]float64, n)
+	// gradients holds the gradient of the dual objective,
+	// 1/2 alpha'Q alpha - sum(alpha), in each coefficient
+	gradients := make([]float64, n)
+	for t := range gradients {
+		gradients[t] = -1
+	}
+	up := func(t int) bool {
+		return (labels[t] > 0 && alphas[t] < bounds[t]) || (labels[t] < 0 && alphas[t] > 0)
+	}
+	low := func(t int) bool {
+		return (labels[t] > 0 && alphas[t] > 0) || (labels[t] < 0 && alphas[t] < bounds[t])
+	}
+	for iteration := 0; iteration < s.MaxIterations; iteration++ {
+		// Pick the coefficient which most violates the optimality
+		// conditions, then the one which, paired with it, most
+		// decreases the objective
+		i, maxViolation := -1, math.Inf(-1)
+		for t := 0; t < n; t++ {
+			if up(t) && -labels[t]*gradients[t] >= maxViolation {
+				i, maxViolation = t, -labels[t]*gradients[t]
+			}
+		}
+		j, minViolation, best := -1, math.Inf(1), math.Inf(1)
+		for t := 0; t < n; t++ {
+			if !low(t) {
+				continue
+			}
+			violation := -labels[t] * gradients[t]
+			minViolation = math.Min(minViolation, violation)
+			if i == -1 || violation >= maxViolation {
+				continue
+			}
+			diff := maxViolation - violation
+			curvature := q(i, i) + q(t, t) - 2*labels[i]*labels[t]*q(i, t)
+			if curvature <= 0 {
+				curvature = tau
+			}
+			if gain := -diff * diff / curvature; gain <= best {
+				j, best = t, gain
+			}
+		}
+		if i == -1 || j == -1 || maxViolation-minViolation < s.Tolerance {
+			break
+		}
+
+		oldI, oldJ := alphas[i], alphas[j]
+		if labels[i] != labels[j] {
+			curvature := q(i, i) + q(j, j) + 2*q(i, j)
+			if curvature <= 0 {
+				curvature = tau
+			}
+			delta := (-gradients[i] - gradients[j]) / curvature
+			diff := alphas[i] - alphas[j]
+			alphas[i] += delta
+			alphas[j] += delta
+			if diff > 0 {
+				if alphas[j] < 0 {
+					alphas[j], alphas[i] = 0, diff
+				}
+			} else if alphas[i] < 0 {
+				alphas[i], alphas[j] = 0, -diff
+			}
+			if diff > bounds[i]-bounds[j] {
+				if alphas[i] > bounds[i] {
+					alphas[i], alphas[j] = bounds[i], bounds[i]-diff
+				}
+			} else if alphas[j] > bounds[j] {
+				alphas[j], alphas[i] = bounds[j], bounds[j]+diff
+			}
+		} else {
+			curvature := q(i, i) + q(j, j) - 2*q(i, j)
+			if curvature <= 0 {
+				curvature = tau
+			}
+			delta := (gradients[i] - gradients[j]) / curvature
+			sum := alphas[i] + alphas[j]
+			alphas[i] -= delta
+			alphas[j] += delta
+			if sum > bounds[i] {
+				if alphas[i] > bounds[i] {
+					alphas[i], alphas[j] = bounds[i], sum-bounds[i]
+				}
+			} else if alphas[j] < 0 {
+				alphas[j], alphas[i] = 0, sum
+			}
+			if sum > bounds[j] {
+				if alphas[j] > bounds[j] {
+					alphas[j], alphas[i] = bounds[j], sum-bounds[j]
+				}
+			} else if alphas[i] < 0 {
+				alphas[i], alphas[j] = 0, sum
+			}
+		}
+		changeI, changeJ := alphas[i]-oldI, alphas[j]-oldJ
+		for t := range gradients {
+			gradients[t] += q(t, i)*changeI + q(t, j)*changeJ
+		}
+	}
+
+	// The intercept is where the coefficients strictly between
+	// their bounds put it, or the middle of the range the others
+	// allow if there are none
+	free, sum := 0, 0.0
+	upper, lower := math.Inf(1), math.Inf(-1)
+	for t := range alphas {
+		value := labels[t] * gradients[t]
+		switch {
+		case alphas[t] >= bounds[t]:
+			if labels[t] < 0 {
+				upper = math.Min(upper, value)
+			} else {
+				lower = math.Max(lower, value)
+			}
+		case alphas[t] <= 0:
+			if labels[t] > 0 {
+				upper = math.Min(upper, value)
+			} else {
+				lower = math.Max(lower, value)
+			}
+		default:
+			free++
+			sum += value
+		}
+	}
+	if free > 0 {
+		return alphas, sum / float64(free)
+	}
+	if math.IsInf(upper, 0) || math.IsInf(lower, 0) {
+		return alphas, 0
+	}
+	return alphas, (upper + lower) / 2
+}
+
+// getColumns returns the column of each of the training Attributes
+// in what.
+//
+// IMPORTANT: this function panic()s if Fit hasn't been called, or
+// if what is missing one of the Attributes.
+func (s *SVC) getColumns(what *base.Instances) []int {
+	if s.Machines == nil {
+		panic("Call Fit() beforehand")
+	}
+	return what.GetAttrIndices(s.Attributes)
+}
+
+// DecisionFunction returns the decision value of each Machine for
+// each row of what: positive for its Positive class, negative for
+// its Negative one.
+func (s *SVC) DecisionFunction(what *base.Instances) [][]float64 {
+	cols := s.getColumns(what)
+	ret := make([][]float64, what.Rows)
+	kernels := make([]float64, len(s.SupportVectors))
+	for i := range ret {
+		row := features(what, i, cols)
+		for k, v := range s.SupportVectors {
+			kernels[k] = s.kernel(row, v)
+		}
+		ret[i] = make([]float64, len(s.Machines))
+		for k, m := range s.Machines {
+			ret[i][k] = m.Intercept
+			for t, v := range m.Support {
+				ret[i][k] += m.Coefficients[t] * kernels[v]
+			}
+		}
+	}
+	return ret
+}
+
+// Predict returns the class of each row of what with the most votes
+// from the Machines, breaking ties by the class which comes first.
+func (s *SVC) Predict(what *base.Instances) *base.Instances {
+	ret := what.GeneratePredictionVector()
+	for i, values := range s.DecisionFunction(what) {
+		votes := make(map[string]int)
+		for k, m := range s.Machines {
+			if values[k] > 0 {
+				votes[m.Positive]++
+			} else {
+				votes[m.Negative]++
+			}
+		}
+		best := s.Classes[0]
+		for _, c := range s.Classes {
+			if votes[c] > votes[best] {
+				best = c
+			}
+		}
+		ret.SetAttrStr(i, 0, best)
+	}
+	return ret
+}
+
+// Clone returns an untrained SVC with the same parameters
+func (s *SVC) Clone() base.Classifier {
+	return &SVC{SVCParams: s.SVCParams}
+}
+
+func (s *SVC) String() string {
+	return fmt.Sprintf("SVC(Kernel: %q, C: %g, Gamma: %g)", s.Kernel, s.C, s.Gamma)
+}
@@ -0,0 +1,164 @@
+package svm
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	base "github.com/sjwhitworth/golearn/base"
+	eval "github.com/sjwhitworth/golearn/evaluation"
+)
+
+// newCircles returns points inside the unit circle, labelled inner,
+// and on a ring around it, labelled outer, which no line separates
+func newCircles(rows int, seed int64) *base.Instances {
+	rng := rand.New(rand.NewSource(seed))
+	attrs := make([]base.Attribute, 3)
+	for j := 0; j < 2; j++ {
+		attr := base.NewFloatAttribute()
+		attr.SetName(fmt.Sprintf("x%d", j))
+		attrs[j] = attr
+	}
+	class := base.NewCategoricalAttribute()
+	class.SetName("class")
+	attrs[2] = class
+	ret := base.NewInstances(attrs, rows)
+	for i := 0; i < rows; i++ {
+		angle := rng.Float64() * 2 * math.Pi
+		radius, label := rng.Float64(), "inner"
+		if i%2 == 1 {
+			radius, label = 1.5+rng.Float64(), "outer"
+		}
+		ret.Set(i, 0, radius*math.Cos(angle))
+		ret.Set(i, 1, radius*math.Sin(angle))
+		ret.SetAttrStr(i, 2, label)
+	}
+	return ret
+}
+
+func TestSVC(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	trainData, testData := base.InstancesTrainTestSplitWithSeed(inst, 0.5, 1)
+
+	for _, kernel := range []string{"rbf", "poly", "linear"} {
+		cls := NewSVC(kernel, 1)
+		cls.Fit(trainData)
+		if len(cls.Machines) != 3 {
+			testEnv.Fatalf("%s: %d machines", kernel, len(cls.Machines))
+		}
+		predictions := cls.Predict(testData)
+		if accuracy := eval.GetAccuracy(eval.GetConfusionMatrix(testData, predictions)); accuracy < 0.9 {
+			testEnv.Errorf("%s: %f", kernel, accuracy)
+		}
+	}
+}
+
+func TestSVCNonLinear(testEnv *testing.T) {
+	trainData := newCircles(200, 1)
+	testData := newCircles(200, 2)
+
+	accuracy := func(cls *SVC) float64 {
+		cls.Fit(trainData)
+		return eval.GetAccuracy(eval.GetConfusionMatrix(testData, cls.Predict(testData)))
+	}
+	if a := accuracy(NewSVC("rbf", 1)); a < 0.95 {
+		testEnv.Errorf("rbf: %f", a)
+	}
+	// (x.y)^2 includes the squared radius
+	poly := NewSVC("poly", 1)
+	poly.Degree = 2
+	if a := accuracy(poly); a < 0.95 {
+		testEnv.Errorf("poly: %f", a)
+	}
+	if a := accuracy(NewSVC("linear", 1)); a > 0.8 {
+		testEnv.Errorf("linear: %f", a)
+	}
+}
+
+func TestSVCSupportVectors(testEnv *testing.T) {
+	trainData := newCircles(100, 3)
+	testData := newCircles(50, 4)
+	cls := NewSVC("rbf", 1)
+	cls.Fit(trainData)
+	if len(cls.Support) == 0 || len(cls.Support) == trainData.Rows {
+		testEnv.Fatalf("%d support vectors", len(cls.Support))
+	}
+	m := cls.Machines[0]
+	if m.Negative != "inner" || m.Positive != "outer" || len(m.Support) != len(cls.Support) {
+		testEnv.Fatal(m)
+	}
+	total := 0.0
+	for t, v := range m.Support {
+		i := cls.Support[v]
+		if cls.SupportVectors[v][0] != trainData.Get(i, 0) {
+			testEnv.Errorf("Support vector %d isn't row %d", v, i)
+		}
+		if (m.Coefficients[t] > 0) != (trainData.GetClass(i) == "outer") {
+			testEnv.Errorf("Support vector %d has coefficient %f", v, m.Coefficients[t])
+		}
+		if math.Abs(m.Coefficients[t]) > cls.C+1e-9 {
+			testEnv.Errorf("Coefficient %f is bigger than C", m.Coefficients[t])
+		}
+		total += m.Coefficients[t]
+	}
+	if math.Abs(total) > 1e-9 {
+		testEnv.Errorf("Coefficients should sum to zero, got %f", total)
+	}
+
+	// The support vectors alone train the same machine
+	support := trainData.ViewRows(cls.Support)
+	again := NewSVC("rbf", 1)
+	again.Gamma = cls.FittedGamma
+	again.Fit(support)
+	expected := cls.DecisionFunction(testData)
+	for i, values := range again.DecisionFunction(testData) {
+		if math.Abs(values[0]-expected[i][0]) > 0.01 {
+			testEnv.Errorf("Row %d scores %f, expected %f", i, values[0], expected[i][0])
+		}
+	}
+}
+
+func TestSVCWeights(testEnv *testing.T) {
+	class := base.NewCategoricalAttribute()
+	class.GetSysValFromString("A")
+	class.GetSysValFromString("B")
+	trainData := base.NewInstances([]base.Attribute{base.NewFloatAttribute(), class}, 6)
+	for i, x := range []float64{-2, -1, 0.5, -0.5, 1, 2} {
+		trainData.Set(i, 0, x)
+		trainData.SetAttrStr(i, 1, []string{"A", "B"}[i/3])
+	}
+	testData := trainData.ViewRows([]int{2})
+
+	cls := NewSVC("linear", 1)
+	cls.Fit(trainData)
+	if class := cls.Predict(testData).GetClass(0); class != "B" {
+		testEnv.Errorf("Unweighted, 0.5 should be on B's side, got %s", class)
+	}
+	trainData.SetWeight(2, 100)
+	cls.Fit(trainData)
+	if class := cls.Predict(testData).GetClass(0); class != "A" {
+		testEnv.Errorf("Weighted, 0.5 should be on A's side, got %s", class)
+	}
+}
+
+func TestSVCParams(testEnv *testing.T) {
+	if err := DefaultSVCParams().Validate(); err != nil {
+		testEnv.Error(err)
+	}
+	for _, params := range []SVCParams{
+		{Kernel: "sigmoid", C: 1, Tolerance: 1e-3, MaxIterations: 10},
+		{Kernel: "rbf", C: 0, Tolerance: 1e-3, MaxIterations: 10},
+		{Kernel: "rbf", C: 1, Gamma: -1, Tolerance: 1e-3, MaxIterations: 10},
+		{Kernel: "poly", C: 1, Degree: 0, Tolerance: 1e-3, MaxIterations: 10},
+		{Kernel: "rbf", C: 1, Tolerance: 0, MaxIterations: 10},
+		{Kernel: "rbf", C: 1, Tolerance: 1e-3, MaxIterations: 0},
+	} {
+		if _, err := NewSVCFromParams(params); err == nil {
+			testEnv.Errorf("%+v should be invalid", params)
+		}
+	}
+}
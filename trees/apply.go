@@ -0,0 +1,103 @@
+package trees
+
+import (
+	"sort"
+
+	base "github.com/sjwhitworth/golearn/base"
+)
+
+// LeafApplier implementations can report which of their leaves
+// each row of a set of Instances ends up in.
+type LeafApplier interface {
+	// Returns the index of the leaf each row lands in, between
+	// zero and LeafCount()-1, or -1 if it doesn't reach a leaf.
+	Apply(*base.Instances) []int
+	// Returns the number of leaves.
+	LeafCount() int
+}
+
+// getLeafIndices numbers the leaves below this node from left
+// to right, visiting the children in order of their values.
+func (d *DecisionTreeNode) getLeafIndices() map[*DecisionTreeNode]int {
+	ret := make(map[*DecisionTreeNode]int)
+	d.addLeafIndices(ret)
+	return ret
+}
+
+func (d *DecisionTreeNode) addLeafIndices(ret map[*DecisionTreeNode]int) {
+	if d.Children == nil {
+		ret[d] = len(ret)
+		return
+	}
+	keys := make([]string, 0, len(d.Children))
+	for k := range d.Children {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		d.Children[k].addLeafIndices(ret)
+	}
+}
+
+// LeafCount returns the number of leaves below this node
+func (d *DecisionTreeNode) LeafCount() int {
+	if d.Children == nil {
+		return 1
+	}
+	ret := 0
+	for _, c := range d.Children {
+		ret += c.LeafCount()
+	}
+	return ret
+}
+
+// Apply returns the index of the leaf each row of what lands in.
+// Leaves are numbered from left to right, visiting the children of
+// each node in order of their values. Rows which stop at a rule
+// node (because what lacks its Attribute) get -1.
+func (d *DecisionTreeNode) Apply(what *base.Instances) []int {
+	indices := d.getLeafIndices()
+	ret := make([]int, what.Rows)
+	for i := range ret {
+		if idx, ok := indices[d.getTerminalNode(what, i)]; ok {
+			ret[i] = idx
+		} else {
+			ret[i] = -1
+		}
+	}
+	return ret
+}
+
+// LeafRows groups the rows of from by the leaf they land in (see
+// Apply), e.g. to find out which training rows define each leaf.
+func (d *DecisionTreeNode) LeafRows(from *base.Instances) [][]int {
+	ret := make([][]int, d.LeafCount())
+	for i, idx := range d.Apply(from) {
+		if idx >= 0 {
+			ret[idx] = append(ret[idx], i)
+		}
+	}
+	return ret
+}
+
+// Apply returns the index of the leaf each row of what lands in
+// (see DecisionTreeNode.Apply)
+func (t *ID3DecisionTree) Apply(what *base.Instances) []int {
+	return t.Root.Apply(what)
+}
+
+// LeafCount returns the number of leaves in the tree
+func (t *ID3DecisionTree) LeafCount() int {
+	return t.Root.LeafCount()
+}
+
+// Apply returns the index of the leaf each row of what lands in
+// (see DecisionTreeNode.Apply)
+func (rt *RandomTree) Apply(what *base.Instances) []int {
+	return rt.Root.Apply(what)
+}
+
+// LeafCount returns the number of leaves in the tree
+func (rt *RandomTree) LeafCount() int {
+	return rt.Root.LeafCount()
+}
@@ -0,0 +1,294 @@
+package trees
+
+import (
+	"encoding/gob"
+	"fmt"
+	"sort"
+
+	base "github.com/sjwhitworth/golearn/base"
+)
+
+// CARTParams holds the parameters of a CARTDecisionTree.
+type CARTParams struct {
+	// MaxDepth limits the number of rules between the root
+	// and any leaf. Zero means unlimited.
+	MaxDepth int
+	// MinLeafSize is the smallest number of rows a node can have
+	MinLeafSize int
+	// CostWeight sets how strongly the cost of acquiring an
+	// Attribute (see CARTDecisionTree.Costs) counts against
+	// splitting on it. Zero ignores the costs, and one divides the
+	// benefit of a split by its cost plus one.
+	CostWeight float64
+}
+
+// Validate checks that the CARTParams are usable.
+func (p CARTParams) Validate() error {
+	if p.MaxDepth < 0 {
+		return fmt.Errorf("trees: MaxDepth can't be negative, got %d", p.MaxDepth)
+	}
+	if p.MinLeafSize < 1 {
+		return fmt.Errorf("trees: MinLeafSize should be at least 1, got %d", p.MinLeafSize)
+	}
+	if p.CostWeight < 0 {
+		return fmt.Errorf("trees: CostWeight can't be negative, got %f", p.CostWeight)
+	}
+	return nil
+}
+
+// CARTDecisionTree is a decision tree which splits FloatAttributes
+// in two at the threshold, and CategoricalAttributes on each of their
+// values, which scores best according to a SplitCriterion. Unlike
+// ID3DecisionTree, it doesn't need the data to be discretised first,
+// and rows can be weighted.
+type CARTDecisionTree struct {
+	base.BaseClassifier
+	CARTParams
+	// Criterion scores the candidate splits. If it's nil,
+	// GiniImpurity is used.
+	Criterion SplitCriterion
+	// Costs gives the cost of acquiring the value of each
+	// Attribute, by name, e.g. the price of a medical test.
+	// Attributes which aren't listed are free. See CostWeight and
+	// ExpectedCost.
+	Costs map[string]float64
+	Root  *DecisionTreeNode
+}
+
+// NewCARTDecisionTree returns a new CARTDecisionTree of unlimited
+// depth which uses the given SplitCriterion.
+func NewCARTDecisionTree(criterion SplitCriterion) *CARTDecisionTree {
+	return &CARTDecisionTree{
+		base.BaseClassifier{},
+		CARTParams{MinLeafSize: 1},
+		criterion,
+		nil,
+		nil,
+	}
+}
+
+// NewCARTDecisionTreeFromParams returns a new CARTDecisionTree with
+// the given parameters which uses GiniImpurity, or an error if the
+// parameters are invalid.
+func NewCARTDecisionTreeFromParams(params CARTParams) (*CARTDecisionTree, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	return &CARTDecisionTree{
+		base.BaseClassifier{},
+		params,
+		nil,
+		nil,
+		nil,
+	}, nil
+}
+
+func init() {
+	base.RegisterClassifier("cart", func() base.Classifier {
+		return NewCARTDecisionTree(nil)
+	})
+	gob.Register(&CARTDecisionTree{})
+	gob.Register(GiniImpurity{})
+	gob.Register(InformationGain{})
+	gob.Register(HellingerDistance{})
+}
+
+// Fit builds the tree, weighting each row by its weight in on (see
+// base.Instances.GetWeight)
+func (t *CARTDecisionTree) Fit(on *base.Instances) {
+	t.FitWeighted(on, on.Weights())
+}
+
+// FitWeighted builds the tree, counting each row of on as many times
+// as its weight when scoring splits and choosing the class of each
+// leaf. If weights is nil, every row has weight one.
+//
+// IMPORTANT: this function panic()s if there isn't one weight per row.
+func (t *CARTDecisionTree) FitWeighted(on *base.Instances, weights []float64) {
+	if weights != nil && len(weights) != on.Rows {
+		panic(fmt.Sprintf("trees: %d weight(s) for %d row(s)", len(weights), on.Rows))
+	}
+	criterion := t.Criterion
+	if criterion == nil {
+		criterion = GiniImpurity{}
+	}
+	attrs := make([]int, 0)
+	for i := 0; i < on.Cols; i++ {
+		if on.IsFeature(i) {
+			attrs = append(attrs, i)
+		}
+	}
+	rows := make([]int, on.Rows)
+	for i := range rows {
+		rows[i] = i
+	}
+	minLeafSize := t.MinLeafSize
+	if minLeafSize < 1 {
+		minLeafSize = 1
+	}
+	builder := &cartBuilder{on, unitWeights(on, weights), attrs, criterion, t.MaxDepth, minLeafSize, t.Costs, t.CostWeight}
+	t.Root = builder.build(rows, 0, make(map[string]bool))
+}
+
+// cartBuilder grows a CARTDecisionTree
+type cartBuilder struct {
+	from        *base.Instances
+	weights     []float64
+	attrs       []int
+	criterion   SplitCriterion
+	maxDepth    int
+	minLeafSize int
+	costs       map[string]float64
+	costWeight  float64
+}
+
+// cost returns the cost of splitting on the Attribute at index col,
+// given the names of those already split on above the node, which
+// are free since their values have been acquired.
+func (b *cartBuilder) cost(col int, acquired map[string]bool) float64 {
+	name := b.from.GetAttr(col).GetName()
+	if acquired[name] {
+		return 0
+	}
+	return b.costs[name]
+}
+
+// build returns the node for the given rows at the given depth,
+// below rule nodes which split on the acquired Attributes
+func (b *cartBuilder) build(rows []int, depth int, acquired map[string]bool) *DecisionTreeNode {
+	counts := make(map[string]int)
+	for _, r := range rows {
+		counts[b.from.GetClass(r)]++
+	}
+	dist := weightedClassDistribution(b.from, rows, b.weights)
+	classes := make([]string, 0, len(dist))
+	for c := range dist {
+		classes = append(classes, c)
+	}
+	sort.Strings(classes)
+	maxClass := ""
+	for _, c := range classes {
+		if maxClass == "" || dist[c] > dist[maxClass] {
+			maxClass = c
+		}
+	}
+	ret := &DecisionTreeNode{
+		LeafNode,
+		nil,
+		nil,
+		counts,
+		maxClass,
+		b.from.GetClassAttrPtr(),
+		false,
+		0,
+		"",
+	}
+	if len(counts) < 2 || (b.maxDepth > 0 && depth >= b.maxDepth) {
+		return ret
+	}
+	var cost func(col int) float64
+	if b.costWeight > 0 && len(b.costs) > 0 {
+		cost = func(col int) float64 {
+			return b.cost(col, acquired)
+		}
+	}
+	split := findBestSplit(b.from, rows, b.weights, b.attrs, b.criterion, b.minLeafSize, b.costWeight, cost)
+	if split == nil {
+		return ret
+	}
+	if !acquired[split.Attribute.GetName()] {
+		below := make(map[string]bool)
+		for name := range acquired {
+			below[name] = true
+		}
+		below[split.Attribute.GetName()] = true
+		acquired = below
+	}
+
+	col := b.from.GetAttrIndex(split.Attribute)
+	childRows := make(map[string][]int)
+	for _, r := range rows {
+		val := b.from.Get(r, col)
+		key := split.Attribute.GetStringFromSysVal(val)
+		if split.Missing != "" && base.IsMissingValue(val) {
+			key = split.Missing
+		} else if split.Numeric {
+			key = AboveThreshold
+			if val <= split.Threshold {
+				key = BelowThreshold
+			}
+		}
+		childRows[key] = append(childRows[key], r)
+	}
+	ret.Type = RuleNode
+	ret.SplitAttr = split.Attribute
+	ret.Numeric = split.Numeric
+	ret.Threshold = split.Threshold
+	ret.MissingBranch = split.Missing
+	ret.Children = make(map[string]*DecisionTreeNode)
+	for k := range childRows {
+		ret.Children[k] = b.build(childRows[k], depth+1, acquired)
+	}
+	return ret
+}
+
+// Predict outputs predictions from the tree
+func (t *CARTDecisionTree) Predict(what *base.Instances) *base.Instances {
+	return t.Root.Predict(what)
+}
+
+// PredictProba outputs class probability estimates from the tree
+func (t *CARTDecisionTree) PredictProba(what *base.Instances) []map[string]float64 {
+	return t.Root.PredictProba(what)
+}
+
+// Apply returns the index of the leaf each row of what lands in
+// (see DecisionTreeNode.Apply)
+func (t *CARTDecisionTree) Apply(what *base.Instances) []int {
+	return t.Root.Apply(what)
+}
+
+// LeafCount returns the number of leaves in the tree
+func (t *CARTDecisionTree) LeafCount() int {
+	return t.Root.LeafCount()
+}
+
+// PredictionCosts returns the cost (see Costs) of the Attributes
+// which the tree needs the values of to predict each row of what
+// (see DecisionTreeNode.PredictionCosts)
+func (t *CARTDecisionTree) PredictionCosts(what *base.Instances) []float64 {
+	return t.Root.PredictionCosts(what, t.Costs)
+}
+
+// ExpectedCost returns the mean cost of predicting a row of what
+// (see PredictionCosts), e.g. the expected spend on tests per patient
+func (t *CARTDecisionTree) ExpectedCost(what *base.Instances) float64 {
+	return t.Root.ExpectedCost(what, t.Costs)
+}
+
+// Clone returns an untrained CARTDecisionTree with the same
+// parameters, SplitCriterion and Costs
+func (t *CARTDecisionTree) Clone() base.Classifier {
+	return &CARTDecisionTree{
+		base.BaseClassifier{},
+		t.CARTParams,
+		t.Criterion,
+		t.Costs,
+		nil,
+	}
+}
+
+// Summary returns the CARTDecisionTree's parameters and a
+// summary of its shape (see SummariseTrees)
+func (t *CARTDecisionTree) Summary() string {
+	criterion := t.Criterion
+	if criterion == nil {
+		criterion = GiniImpurity{}
+	}
+	return fmt.Sprintf("CARTDecisionTree(%s, Criterion: %T)\n%s", base.FormatParams(base.GetParams(t)), criterion, SummariseTrees(TreeRoots([]base.Classifier{t})))
+}
+
+// String returns a human-readable version of this tree
+func (t *CARTDecisionTree) String() string {
+	return fmt.Sprintf("CARTDecisionTree(%s\n)", t.Root)
+}
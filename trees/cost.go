@@ -0,0 +1,40 @@
+package trees
+
+import (
+	base "github.com/sjwhitworth/golearn/base"
+)
+
+// PredictionCosts returns, for each row of what, the total cost of
+// the Attributes tested on its path down from this node, given the
+// cost of each by name. Each Attribute is only paid for once, however
+// many times it's tested, and those missing from costs are free.
+func (d *DecisionTreeNode) PredictionCosts(what *base.Instances, costs map[string]float64) []float64 {
+	ret := make([]float64, what.Rows)
+	for i := range ret {
+		acquired := make(map[string]bool)
+		for cur := d; cur != nil; cur = cur.getChild(what, i) {
+			if cur.Children == nil {
+				break
+			}
+			name := cur.SplitAttr.GetName()
+			if !acquired[name] {
+				acquired[name] = true
+				ret[i] += costs[name]
+			}
+		}
+	}
+	return ret
+}
+
+// ExpectedCost returns the mean of the PredictionCosts of the rows
+// of what, or zero if there aren't any.
+func (d *DecisionTreeNode) ExpectedCost(what *base.Instances, costs map[string]float64) float64 {
+	if what.Rows == 0 {
+		return 0
+	}
+	ret := 0.0
+	for _, c := range d.PredictionCosts(what, costs) {
+		ret += c
+	}
+	return ret / float64(what.Rows)
+}
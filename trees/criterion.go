@@ -0,0 +1,387 @@
+package trees
+
+import (
+	"math"
+	"sort"
+
+	base "github.com/sjwhitworth/golearn/base"
+)
+
+// SplitCriterion implementations measure how well splitting a node
+// separates the classes. They're used by CARTDecisionTree (and by
+// ID3 trees via CriterionRuleGenerator), so new criteria can be
+// plugged in without changing the tree building code.
+//
+// Class distributions map each class to the total weight of the
+// rows of that class; rows have weight one unless the tree was
+// fitted with weights.
+type SplitCriterion interface {
+	// Score returns how well splitting a node whose class
+	// distribution is parent into children with the given class
+	// distributions separates the classes. Higher is better, and
+	// zero or less means the split is useless.
+	Score(parent map[string]float64, children []map[string]float64) float64
+}
+
+// distributionTotal returns the total weight of a class distribution
+func distributionTotal(dist map[string]float64) float64 {
+	ret := 0.0
+	for _, w := range dist {
+		ret += w
+	}
+	return ret
+}
+
+// InformationGain scores a split by the decrease in the entropy
+// (in bits) of the class distribution, as ID3 does.
+type InformationGain struct{}
+
+// Entropy returns the entropy of a class distribution in bits
+func Entropy(dist map[string]float64) float64 {
+	total := distributionTotal(dist)
+	ret := 0.0
+	for _, w := range dist {
+		if w > 0 {
+			ret -= w / total * math.Log2(w/total)
+		}
+	}
+	return ret
+}
+
+// Score returns the information gain of the split
+func (InformationGain) Score(parent map[string]float64, children []map[string]float64) float64 {
+	total := distributionTotal(parent)
+	ret := Entropy(parent)
+	for _, c := range children {
+		ret -= distributionTotal(c) / total * Entropy(c)
+	}
+	return ret
+}
+
+// GiniImpurity scores a split by the decrease in the Gini impurity
+// of the class distribution, as CART does.
+type GiniImpurity struct{}
+
+// Gini returns the Gini impurity of a class distribution: the
+// chance that two rows drawn at random have different classes.
+func Gini(dist map[string]float64) float64 {
+	total := distributionTotal(dist)
+	if total == 0 {
+		return 0
+	}
+	ret := 1.0
+	for _, w := range dist {
+		ret -= (w / total) * (w / total)
+	}
+	return ret
+}
+
+// Score returns the decrease in Gini impurity from the split
+func (GiniImpurity) Score(parent map[string]float64, children []map[string]float64) float64 {
+	total := distributionTotal(parent)
+	ret := Gini(parent)
+	for _, c := range children {
+		ret -= distributionTotal(c) / total * Gini(c)
+	}
+	return ret
+}
+
+// HellingerDistance scores a split by the Hellinger distance
+// between the distributions of each class over the children
+// (Cieslak and Chawla, 2008). Unlike InformationGain and GiniImpurity
+// it doesn't depend on how common each class is, so trees grown with
+// it don't collapse to predicting the majority class when the classes
+// are very imbalanced. With more than two classes, the largest
+// distance between a class and the rest is used.
+type HellingerDistance struct{}
+
+// Score returns the Hellinger distance of the split
+func (HellingerDistance) Score(parent map[string]float64, children []map[string]float64) float64 {
+	if len(parent) < 2 {
+		return 0
+	}
+	total := distributionTotal(parent)
+	ret := 0.0
+	for class, positives := range parent {
+		negatives := total - positives
+		if positives <= 0 || negatives <= 0 {
+			continue
+		}
+		sum := 0.0
+		for _, c := range children {
+			pos := c[class]
+			neg := distributionTotal(c) - pos
+			d := math.Sqrt(pos/positives) - math.Sqrt(math.Max(neg, 0)/negatives)
+			sum += d * d
+		}
+		ret = math.Max(ret, math.Sqrt(sum))
+		if len(parent) == 2 {
+			// Both classes give the same distance
+			break
+		}
+	}
+	return ret
+}
+
+// Split describes how a node divides its rows: by the value of a
+// CategoricalAttribute (one child per value), or by whether the
+// value of a FloatAttribute is at most Threshold (two children).
+type Split struct {
+	Attribute base.Attribute
+	Numeric   bool
+	Threshold float64
+	// Score is the SplitCriterion's score for the split
+	Score float64
+	// Missing is the child (a value of Attribute, or BelowThreshold
+	// or AboveThreshold) which rows with a missing value go to,
+	// chosen to score best. It's empty if there weren't any.
+	Missing string
+}
+
+// FindBestSplit returns the best split of the rows of from on one of
+// the Attributes at the indices in attrs, according to criterion,
+// or nil if none of them gives a positive score. If weights is nil,
+// every row has weight one.
+func FindBestSplit(from *base.Instances, weights []float64, attrs []int, criterion SplitCriterion) *Split {
+	rows := make([]int, from.Rows)
+	for i := range rows {
+		rows[i] = i
+	}
+	return findBestSplit(from, rows, unitWeights(from, weights), attrs, criterion, 1, 0, nil)
+}
+
+// unitWeights returns weights, or a weight of one for each row of
+// from if it's nil
+func unitWeights(from *base.Instances, weights []float64) []float64 {
+	if weights != nil {
+		return weights
+	}
+	ret := make([]float64, from.Rows)
+	for i := range ret {
+		ret[i] = 1.0
+	}
+	return ret
+}
+
+// weightedClassDistribution returns the class distribution of
+// the given rows
+func weightedClassDistribution(from *base.Instances, rows []int, weights []float64) map[string]float64 {
+	ret := make(map[string]float64)
+	for _, r := range rows {
+		ret[from.GetClass(r)] += weights[r]
+	}
+	return ret
+}
+
+// findBestSplit is FindBestSplit over a subset of the rows, where
+// each child must have at least minRows rows. If cost isn't nil, it
+// gives the cost of splitting on each Attribute, and splits are
+// compared by their cost-sensitive score (see costSensitiveScore).
+func findBestSplit(from *base.Instances, rows []int, weights []float64, attrs []int, criterion SplitCriterion, minRows int, costWeight float64, cost func(col int) float64) *Split {
+	parent := weightedClassDistribution(from, rows, weights)
+	var best *Split
+	bestScore := 0.0
+	for _, a := range attrs {
+		var s *Split
+		switch from.GetAttr(a).GetType() {
+		case base.Float64Type:
+			s = findBestThreshold(from, rows, weights, a, parent, criterion, minRows)
+		case base.CategoricalType:
+			s = scoreCategoricalSplit(from, rows, weights, a, parent, criterion, minRows)
+		}
+		if s == nil || s.Score <= 1e-12 {
+			continue
+		}
+		score := s.Score
+		if cost != nil {
+			score = costSensitiveScore(s.Score, cost(a), costWeight)
+		}
+		if best == nil || score > bestScore {
+			best, bestScore = s, score
+		}
+	}
+	return best
+}
+
+// costSensitiveScore trades the score of a split off against the
+// cost of acquiring the Attribute it splits on, as Nunez's EG2
+// (1991) does with information gain: (2^score - 1) / (cost + 1)^w
+func costSensitiveScore(score, cost, costWeight float64) float64 {
+	return (math.Pow(2, score) - 1) / math.Pow(cost+1, costWeight)
+}
+
+// splitMissing divides rows into those with a value of the
+// Attribute at index a and those where it's missing, returning the
+// class distribution of the latter.
+func splitMissing(from *base.Instances, rows []int, weights []float64, a int) ([]int, []int, map[string]float64) {
+	present := make([]int, 0, len(rows))
+	missing := make([]int, 0)
+	for _, r := range rows {
+		if from.IsMissing(r, a) {
+			missing = append(missing, r)
+		} else {
+			present = append(present, r)
+		}
+	}
+	return present, missing, weightedClassDistribution(from, missing, weights)
+}
+
+// addDistributions returns the sum of two class distributions
+func addDistributions(a, b map[string]float64) map[string]float64 {
+	ret := make(map[string]float64)
+	for c, w := range a {
+		ret[c] += w
+	}
+	for c, w := range b {
+		ret[c] += w
+	}
+	return ret
+}
+
+// scoreCategoricalSplit scores splitting the rows on each value of
+// the CategoricalAttribute at index a. Rows where it's missing go to
+// whichever child scores best.
+func scoreCategoricalSplit(from *base.Instances, rows []int, weights []float64, a int, parent map[string]float64, criterion SplitCriterion, minRows int) *Split {
+	present, missing, missingDist := splitMissing(from, rows, weights, a)
+	dists := make(map[float64]map[string]float64)
+	counts := make(map[float64]int)
+	for _, r := range present {
+		val := from.Get(r, a)
+		if dists[val] == nil {
+			dists[val] = make(map[string]float64)
+		}
+		dists[val][from.GetClass(r)] += weights[r]
+		counts[val]++
+	}
+	if len(dists) < 2 {
+		return nil
+	}
+	vals := make([]float64, 0, len(dists))
+	for val := range dists {
+		vals = append(vals, val)
+	}
+	sort.Float64s(vals)
+	children := make([]map[string]float64, len(vals))
+	for k, val := range vals {
+		children[k] = dists[val]
+	}
+	if len(missing) == 0 {
+		for _, val := range vals {
+			if counts[val] < minRows {
+				return nil
+			}
+		}
+		return &Split{from.GetAttr(a), false, 0, criterion.Score(parent, children), ""}
+	}
+	var best *Split
+	for k, val := range vals {
+		tooSmall := false
+		for _, other := range vals {
+			n := counts[other]
+			if other == val {
+				n += len(missing)
+			}
+			tooSmall = tooSmall || n < minRows
+		}
+		if tooSmall {
+			continue
+		}
+		children[k] = addDistributions(dists[val], missingDist)
+		score := criterion.Score(parent, children)
+		children[k] = dists[val]
+		if best == nil || score > best.Score {
+			best = &Split{from.GetAttr(a), false, 0, score, from.GetAttr(a).GetStringFromSysVal(val)}
+		}
+	}
+	return best
+}
+
+// findBestThreshold finds the threshold of the FloatAttribute at
+// index a which best splits the rows in two. Rows where it's
+// missing go to whichever side scores best.
+func findBestThreshold(from *base.Instances, rows []int, weights []float64, a int, parent map[string]float64, criterion SplitCriterion, minRows int) *Split {
+	sorted, missing, missingDist := splitMissing(from, rows, weights, a)
+	sort.Sort(&rowsByValue{sorted, from, a})
+	left := make(map[string]float64)
+	right := make(map[string]float64)
+	for _, r := range sorted {
+		right[from.GetClass(r)] += weights[r]
+	}
+	var best *Split
+	for i := 0; i < len(sorted)-1; i++ {
+		c := from.GetClass(sorted[i])
+		left[c] += weights[sorted[i]]
+		right[c] -= weights[sorted[i]]
+		cur, next := from.Get(sorted[i], a), from.Get(sorted[i+1], a)
+		if cur == next {
+			continue
+		}
+		threshold := (cur + next) / 2
+		nLeft, nRight := i+1, len(sorted)-i-1
+		if len(missing) == 0 {
+			if nLeft < minRows || nRight < minRows {
+				continue
+			}
+			score := criterion.Score(parent, []map[string]float64{left, right})
+			if best == nil || score > best.Score {
+				best = &Split{from.GetAttr(a), true, threshold, score, ""}
+			}
+			continue
+		}
+		if nLeft+len(missing) >= minRows && nRight >= minRows {
+			score := criterion.Score(parent, []map[string]float64{addDistributions(left, missingDist), right})
+			if best == nil || score > best.Score {
+				best = &Split{from.GetAttr(a), true, threshold, score, BelowThreshold}
+			}
+		}
+		if nLeft >= minRows && nRight+len(missing) >= minRows {
+			score := criterion.Score(parent, []map[string]float64{left, addDistributions(right, missingDist)})
+			if best == nil || score > best.Score {
+				best = &Split{from.GetAttr(a), true, threshold, score, AboveThreshold}
+			}
+		}
+	}
+	return best
+}
+
+// rowsByValue sorts row indices by their value of an Attribute
+type rowsByValue struct {
+	rows []int
+	from *base.Instances
+	col  int
+}
+
+func (r *rowsByValue) Len() int {
+	return len(r.rows)
+}
+
+func (r *rowsByValue) Swap(i, j int) {
+	r.rows[i], r.rows[j] = r.rows[j], r.rows[i]
+}
+
+func (r *rowsByValue) Less(i, j int) bool {
+	return r.from.Get(r.rows[i], r.col) < r.from.Get(r.rows[j], r.col)
+}
+
+// CriterionRuleGenerator is a RuleGenerator which picks the
+// Attribute whose split scores best according to a SplitCriterion,
+// so that ID3 trees can use criteria other than information gain.
+// It only considers CategoricalAttributes.
+type CriterionRuleGenerator struct {
+	Criterion SplitCriterion
+}
+
+// GenerateSplitAttribute returns the CategoricalAttribute which
+// splits f best, or nil if none of them separates the classes.
+func (r *CriterionRuleGenerator) GenerateSplitAttribute(f *base.Instances) base.Attribute {
+	attrs := make([]int, 0)
+	for i := 0; i < f.Cols; i++ {
+		if f.IsFeature(i) && f.GetAttr(i).GetType() == base.CategoricalType {
+			attrs = append(attrs, i)
+		}
+	}
+	if s := FindBestSplit(f, nil, attrs, r.Criterion); s != nil {
+		return s.Attribute
+	}
+	return nil
+}
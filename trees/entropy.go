@@ -20,7 +20,7 @@ type InformationGainRuleGenerator struct {
 func (r *InformationGainRuleGenerator) GenerateSplitAttribute(f *base.Instances) base.Attribute {
 	allAttributes := make([]int, 0)
 	for i := 0; i < f.Cols; i++ {
-		if i != f.ClassIndex {
+		if f.IsFeature(i) {
 			allAttributes = append(allAttributes, i)
 		}
 	}
@@ -2,6 +2,7 @@ package trees
 
 import (
 	"bytes"
+	"encoding/gob"
 	"fmt"
 	base "github.com/sjwhitworth/golearn/base"
 	eval "github.com/sjwhitworth/golearn/evaluation"
@@ -24,6 +25,15 @@ type RuleGenerator interface {
 	GenerateSplitAttribute(*base.Instances) base.Attribute
 }
 
+// Keys of the Children of a DecisionTreeNode which splits on a
+// numeric threshold
+const (
+	// BelowThreshold holds the rows whose value is at most Threshold
+	BelowThreshold = "<="
+	// AboveThreshold holds the rest
+	AboveThreshold = ">"
+)
+
 // DecisionTreeNode represents a given portion of a decision tree
 type DecisionTreeNode struct {
 	Type      NodeType
@@ -32,11 +42,32 @@ type DecisionTreeNode struct {
 	ClassDist map[string]int
 	Class     string
 	ClassAttr *base.Attribute
+	// Numeric is true if the node splits on whether the value of
+	// SplitAttr is at most Threshold, rather than on each value
+	Numeric   bool
+	Threshold float64
+	// MissingBranch is the key of the child which rows with a
+	// missing value of SplitAttr go to, learnt from the training
+	// rows with missing values. If it's empty, they go wherever
+	// their value would take them.
+	MissingBranch string
 }
 
 // InferID3Tree builds a decision tree using a RuleGenerator
 // from a set of Instances (implements the ID3 algorithm)
 func InferID3Tree(from *base.Instances, with RuleGenerator) *DecisionTreeNode {
+	return InferID3TreeWithMaxDepth(from, with, 0)
+}
+
+// InferID3TreeWithMaxDepth builds a decision tree like InferID3Tree,
+// but stops splitting once there are maxDepth rules between the
+// root and a node. If maxDepth is zero, the depth is unlimited.
+func InferID3TreeWithMaxDepth(from *base.Instances, with RuleGenerator, maxDepth int) *DecisionTreeNode {
+	return inferID3Tree(from, with, maxDepth, 0)
+}
+
+// inferID3Tree builds the portion of a decision tree at the given depth
+func inferID3Tree(from *base.Instances, with RuleGenerator, maxDepth int, depth int) *DecisionTreeNode {
 	// Count the number of classes at this node
 	classes := from.CountClassValues()
 	// If there's only one class, return a DecisionTreeLeaf with
@@ -53,6 +84,9 @@ func InferID3Tree(from *base.Instances, with RuleGenerator) *DecisionTreeNode {
 			classes,
 			maxClass,
 			from.GetClassAttrPtr(),
+			false,
+			0,
+			"",
 		}
 		return ret
 	}
@@ -67,9 +101,10 @@ func InferID3Tree(from *base.Instances, with RuleGenerator) *DecisionTreeNode {
 		}
 	}
 
-	// If there are no more Attributes left to split on,
-	// return a DecisionTreeLeaf with the majority class
-	if from.GetAttributeCount() == 2 {
+	// If there are no more Attributes left to split on, or
+	// the tree's as deep as allowed, return a DecisionTreeLeaf
+	// with the majority class
+	if from.GetAttributeCount() == 2 || (maxDepth > 0 && depth >= maxDepth) {
 		ret := &DecisionTreeNode{
 			LeafNode,
 			nil,
@@ -77,6 +112,9 @@ func InferID3Tree(from *base.Instances, with RuleGenerator) *DecisionTreeNode {
 			classes,
 			maxClass,
 			from.GetClassAttrPtr(),
+			false,
+			0,
+			"",
 		}
 		return ret
 	}
@@ -88,6 +126,9 @@ func InferID3Tree(from *base.Instances, with RuleGenerator) *DecisionTreeNode {
 		classes,
 		maxClass,
 		from.GetClassAttrPtr(),
+		false,
+		0,
+		"",
 	}
 
 	// Generate a return structure
@@ -103,7 +144,7 @@ func InferID3Tree(from *base.Instances, with RuleGenerator) *DecisionTreeNode {
 	ret.Children = make(map[string]*DecisionTreeNode)
 	for k := range splitInstances {
 		newInstances := splitInstances[k]
-		ret.Children[k] = InferID3Tree(newInstances, with)
+		ret.Children[k] = inferID3Tree(newInstances, with, maxDepth, depth+1)
 	}
 	ret.SplitAttr = splitOnAttribute
 	return ret
@@ -121,7 +162,11 @@ func (d *DecisionTreeNode) getNestedString(level int) string {
 	if d.Children == nil {
 		buf.WriteString(fmt.Sprintf("Leaf(%s)", d.Class))
 	} else {
-		buf.WriteString(fmt.Sprintf("Rule(%s)", d.SplitAttr.GetName()))
+		if d.Numeric {
+			buf.WriteString(fmt.Sprintf("Rule(%s, %g)", d.SplitAttr.GetName(), d.Threshold))
+		} else {
+			buf.WriteString(fmt.Sprintf("Rule(%s)", d.SplitAttr.GetName()))
+		}
 		keys := make([]string, 0)
 		for k := range d.Children {
 			keys = append(keys, k)
@@ -161,7 +206,7 @@ func (d *DecisionTreeNode) Prune(using *base.Instances) {
 			return
 		}
 		// Recursively prune children of this node
-		sub := using.DecomposeOnAttributeValues(d.SplitAttr)
+		sub := d.decompose(using)
 		for k := range d.Children {
 			if sub[k] == nil {
 				continue
@@ -184,54 +229,160 @@ func (d *DecisionTreeNode) Prune(using *base.Instances) {
 	}
 }
 
+// decompose splits the rows of using between the children
+func (d *DecisionTreeNode) decompose(using *base.Instances) map[string]*base.Instances {
+	if !d.Numeric {
+		return using.DecomposeOnAttributeValues(d.SplitAttr)
+	}
+	ret := make(map[string]*base.Instances)
+	j := using.GetAttrIndex(d.SplitAttr)
+	if j == -1 {
+		return ret
+	}
+	rows := make(map[string][]int)
+	for i := 0; i < using.Rows; i++ {
+		if using.Get(i, j) <= d.Threshold {
+			rows[BelowThreshold] = append(rows[BelowThreshold], i)
+		} else {
+			rows[AboveThreshold] = append(rows[AboveThreshold], i)
+		}
+	}
+	for k := range rows {
+		ret[k] = using.SelectRows(rows[k])
+	}
+	return ret
+}
+
+// getTerminalNode follows the tree down from this node for the
+// given row of what, returning the node which decides its class.
+func (d *DecisionTreeNode) getTerminalNode(what *base.Instances, row int) *DecisionTreeNode {
+	cur := d
+	for {
+		next := cur.getChild(what, row)
+		if next == nil {
+			return cur
+		}
+		cur = next
+	}
+}
+
+// getChild returns the child of this node which the given row of
+// what goes to, or nil if this is a leaf or what lacks the Attribute
+// it splits on.
+func (d *DecisionTreeNode) getChild(what *base.Instances, row int) *DecisionTreeNode {
+	if d.Children == nil {
+		return nil
+	}
+	at := d.SplitAttr
+	j := what.GetAttrIndex(at)
+	if j == -1 {
+		return nil
+	}
+	if d.MissingBranch != "" && what.IsMissing(row, j) {
+		if next, ok := d.Children[d.MissingBranch]; ok {
+			return next
+		}
+	}
+	if d.Numeric {
+		if what.Get(row, j) <= d.Threshold {
+			return d.Children[BelowThreshold]
+		}
+		return d.Children[AboveThreshold]
+	}
+	classVar := at.GetStringFromSysVal(what.Get(row, j))
+	if next, ok := d.Children[classVar]; ok {
+		return next
+	}
+	var bestChild string
+	for c := range d.Children {
+		bestChild = c
+		if c > classVar {
+			break
+		}
+	}
+	return d.Children[bestChild]
+}
+
 // Predict outputs a base.Instances containing predictions from this tree
 func (d *DecisionTreeNode) Predict(what *base.Instances) *base.Instances {
 	outputAttrs := make([]base.Attribute, 1)
 	outputAttrs[0] = what.GetClassAttr()
 	predictions := base.NewInstances(outputAttrs, what.Rows)
 	for i := 0; i < what.Rows; i++ {
-		cur := d
-		for {
-			if cur.Children == nil {
-				predictions.SetAttrStr(i, 0, cur.Class)
-				break
-			} else {
-				at := cur.SplitAttr
-				j := what.GetAttrIndex(at)
-				if j == -1 {
-					predictions.SetAttrStr(i, 0, cur.Class)
-					break
-				}
-				classVar := at.GetStringFromSysVal(what.Get(i, j))
-				if next, ok := cur.Children[classVar]; ok {
-					cur = next
-				} else {
-					var bestChild string
-					for c := range cur.Children {
-						bestChild = c
-						if c > classVar {
-							break
-						}
-					}
-					cur = cur.Children[bestChild]
-				}
-			}
-		}
+		cur := d.getTerminalNode(what, i)
+		predictions.SetAttrStr(i, 0, cur.Class)
 	}
 	return predictions
 }
 
+// PredictProba estimates the class probabilities of each row in what
+// from the class distribution of the training Instances which
+// reached the same node.
+func (d *DecisionTreeNode) PredictProba(what *base.Instances) []map[string]float64 {
+	ret := make([]map[string]float64, what.Rows)
+	for i := 0; i < what.Rows; i++ {
+		cur := d.getTerminalNode(what, i)
+		total := 0
+		for c := range cur.ClassDist {
+			total += cur.ClassDist[c]
+		}
+		ret[i] = make(map[string]float64)
+		for c := range cur.ClassDist {
+			ret[i][c] = float64(cur.ClassDist[c]) / float64(total)
+		}
+	}
+	return ret
+}
+
 //
 // ID3 Tree type
 //
 
+// ID3Params holds the parameters of an ID3DecisionTree.
+type ID3Params struct {
+	// PruneSplit is the test-prune ratio. If it's less than
+	// 0.001, the tree isn't pruned.
+	PruneSplit float64
+	// MaxDepth limits the number of rules between the root
+	// and any leaf. Zero means unlimited.
+	MaxDepth int
+}
+
+// Validate checks that the ID3Params are usable.
+func (p ID3Params) Validate() error {
+	if p.PruneSplit < 0 || p.PruneSplit >= 1 {
+		return fmt.Errorf("trees: PruneSplit should be in [0, 1), got %f", p.PruneSplit)
+	}
+	if p.MaxDepth < 0 {
+		return fmt.Errorf("trees: MaxDepth can't be negative, got %d", p.MaxDepth)
+	}
+	return nil
+}
+
+// ID3Option modifies ID3Params, see NewID3DecisionTreeWithOptions.
+type ID3Option func(*ID3Params)
+
+// WithPruneSplit sets the test-prune ratio of an ID3DecisionTree.
+func WithPruneSplit(prune float64) ID3Option {
+	return func(p *ID3Params) {
+		p.PruneSplit = prune
+	}
+}
+
+// WithMaxDepth sets the maximum depth of an ID3DecisionTree.
+func WithMaxDepth(depth int) ID3Option {
+	return func(p *ID3Params) {
+		p.MaxDepth = depth
+	}
+}
+
 // ID3DecisionTree represents an ID3-based decision tree
 // using the Information Gain metric to select which attributes
 // to split on at each node.
 type ID3DecisionTree struct {
 	base.BaseClassifier
-	Root       *DecisionTreeNode
-	PruneSplit float64
+	ID3Params
+	Root *DecisionTreeNode
 }
 
 // Returns a new ID3DecisionTree with the specified test-prune
@@ -239,20 +390,51 @@ type ID3DecisionTree struct {
 func NewID3DecisionTree(prune float64) *ID3DecisionTree {
 	return &ID3DecisionTree{
 		base.BaseClassifier{},
+		ID3Params{PruneSplit: prune},
 		nil,
-		prune,
 	}
 }
 
+func init() {
+	base.RegisterClassifier("id3", func() base.Classifier {
+		return NewID3DecisionTree(0.0)
+	})
+	gob.Register(&ID3DecisionTree{})
+	gob.Register(&RandomTree{})
+}
+
+// NewID3DecisionTreeFromParams returns a new ID3DecisionTree with
+// the given parameters, or an error if they're invalid.
+func NewID3DecisionTreeFromParams(params ID3Params) (*ID3DecisionTree, error) {
+	if err := params.Validate(); err != nil {
+		return nil, err
+	}
+	return &ID3DecisionTree{
+		base.BaseClassifier{},
+		params,
+		nil,
+	}, nil
+}
+
+// NewID3DecisionTreeWithOptions returns a new, unpruned ID3DecisionTree
+// with unlimited depth, as modified by opts.
+func NewID3DecisionTreeWithOptions(opts ...ID3Option) (*ID3DecisionTree, error) {
+	params := ID3Params{}
+	for _, o := range opts {
+		o(&params)
+	}
+	return NewID3DecisionTreeFromParams(params)
+}
+
 // Fit builds the ID3 decision tree
 func (t *ID3DecisionTree) Fit(on *base.Instances) {
 	rule := new(InformationGainRuleGenerator)
 	if t.PruneSplit > 0.001 {
 		trainData, testData := base.InstancesTrainTestSplit(on, t.PruneSplit)
-		t.Root = InferID3Tree(trainData, rule)
+		t.Root = InferID3TreeWithMaxDepth(trainData, rule, t.MaxDepth)
 		t.Root.Prune(testData)
 	} else {
-		t.Root = InferID3Tree(on, rule)
+		t.Root = InferID3TreeWithMaxDepth(on, rule, t.MaxDepth)
 	}
 }
 
@@ -261,6 +443,20 @@ func (t *ID3DecisionTree) Predict(what *base.Instances) *base.Instances {
 	return t.Root.Predict(what)
 }
 
+// PredictProba outputs class probability estimates from the ID3 decision tree
+func (t *ID3DecisionTree) PredictProba(what *base.Instances) []map[string]float64 {
+	return t.Root.PredictProba(what)
+}
+
+// Clone returns an untrained ID3DecisionTree with the same parameters
+func (t *ID3DecisionTree) Clone() base.Classifier {
+	return &ID3DecisionTree{
+		base.BaseClassifier{},
+		t.ID3Params,
+		nil,
+	}
+}
+
 // String returns a human-readable version of this ID3 tree
 func (t *ID3DecisionTree) String() string {
 	return fmt.Sprintf("ID3DecisionTree(%s\n)", t.Root)
@@ -26,7 +26,7 @@ func (r *RandomTreeRuleGenerator) GenerateSplitAttribute(f *base.Instances) base
 		}
 		selectedAttribute := rand.Intn(maximumAttribute)
 		fmt.Println(selectedAttribute, attrCounter, consideredAttributes, len(consideredAttributes))
-		if selectedAttribute != f.ClassIndex {
+		if f.IsFeature(selectedAttribute) {
 			matched := false
 			for _, a := range consideredAttributes {
 				if a == selectedAttribute {
@@ -76,6 +76,17 @@ func (rt *RandomTree) Predict(from *base.Instances) *base.Instances {
 	return rt.Root.Predict(from)
 }
 
+// PredictProba returns the estimated class probabilities for each row
+func (rt *RandomTree) PredictProba(from *base.Instances) []map[string]float64 {
+	return rt.Root.PredictProba(from)
+}
+
+// Clone returns an untrained RandomTree which considers the same
+// number of attributes at each node
+func (rt *RandomTree) Clone() base.Classifier {
+	return NewRandomTree(rt.Rule.Attributes)
+}
+
 // String returns a human-readable representation of this structure
 func (rt *RandomTree) String() string {
 	return fmt.Sprintf("RandomTree(%s)", rt.Root)
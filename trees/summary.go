@@ -0,0 +1,140 @@
+package trees
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	base "github.com/sjwhitworth/golearn/base"
+)
+
+// LeafDepths returns the number of leaves at each depth below
+// this node, which is at depth zero.
+func (d *DecisionTreeNode) LeafDepths() map[int]int {
+	ret := make(map[int]int)
+	d.countLeafDepths(0, ret)
+	return ret
+}
+
+func (d *DecisionTreeNode) countLeafDepths(depth int, ret map[int]int) {
+	if d.Children == nil {
+		ret[depth]++
+		return
+	}
+	for _, c := range d.Children {
+		c.countLeafDepths(depth+1, ret)
+	}
+}
+
+// countSplits adds the number of nodes splitting on each Attribute
+// to ret, keyed on the Attribute's name.
+func (d *DecisionTreeNode) countSplits(ret map[string]int) {
+	if d.Children == nil {
+		return
+	}
+	ret[d.SplitAttr.GetName()]++
+	for _, c := range d.Children {
+		c.countSplits(ret)
+	}
+}
+
+// TreeRoots returns the roots of the trained decision trees
+// amongst models, skipping any other Classifiers.
+func TreeRoots(models []base.Classifier) []*DecisionTreeNode {
+	ret := make([]*DecisionTreeNode, 0)
+	for _, m := range models {
+		var root *DecisionTreeNode
+		switch t := m.(type) {
+		case *ID3DecisionTree:
+			root = t.Root
+		case *RandomTree:
+			root = t.Root
+		case *CARTDecisionTree:
+			root = t.Root
+		}
+		if root != nil {
+			ret = append(ret, root)
+		}
+	}
+	return ret
+}
+
+// SummariseTrees returns the size of the given trees, a histogram
+// of how deep their leaves are and the Attributes they split on
+// most often.
+func SummariseTrees(roots []*DecisionTreeNode) string {
+	if len(roots) == 0 {
+		return "No trained trees\n"
+	}
+	depths := make(map[int]int)
+	splits := make(map[string]int)
+	leaves, maxDepth := 0, 0
+	for _, r := range roots {
+		for depth, count := range r.LeafDepths() {
+			depths[depth] += count
+			leaves += count
+			if depth > maxDepth {
+				maxDepth = depth
+			}
+		}
+		r.countSplits(splits)
+	}
+	nodes := leaves
+	for _, count := range splits {
+		nodes += count
+	}
+
+	var buffer bytes.Buffer
+	buffer.WriteString(fmt.Sprintf("%d tree(s), %d node(s), %d leaves, max depth %d\n", len(roots), nodes, leaves, maxDepth))
+	buffer.WriteString("Leaf depths:\n")
+	buffer.WriteString(base.FormatHistogram(depths, 40))
+	if len(splits) > 0 {
+		names := make([]string, 0, len(splits))
+		for name := range splits {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		sort.Stable(sort.Reverse(&splitCounts{names, splits}))
+		top := make([]string, 0, 6)
+		for i, name := range names {
+			if i == 5 {
+				top = append(top, "...")
+				break
+			}
+			top = append(top, fmt.Sprintf("%s (%d)", name, splits[name]))
+		}
+		buffer.WriteString(fmt.Sprintf("Splits: %s\n", strings.Join(top, ", ")))
+	}
+	return buffer.String()
+}
+
+// splitCounts sorts Attribute names by the number of splits
+type splitCounts struct {
+	names  []string
+	counts map[string]int
+}
+
+func (s *splitCounts) Len() int {
+	return len(s.names)
+}
+
+func (s *splitCounts) Swap(i, j int) {
+	s.names[i], s.names[j] = s.names[j], s.names[i]
+}
+
+func (s *splitCounts) Less(i, j int) bool {
+	return s.counts[s.names[i]] < s.counts[s.names[j]]
+}
+
+// Summary returns the ID3DecisionTree's parameters and a
+// summary of its shape (see SummariseTrees)
+func (t *ID3DecisionTree) Summary() string {
+	return fmt.Sprintf("ID3DecisionTree(%s)\n%s", base.FormatParams(base.GetParams(t)), SummariseTrees(TreeRoots([]base.Classifier{t})))
+}
+
+// Summary returns the RandomTree's parameters and a
+// summary of its shape (see SummariseTrees)
+func (rt *RandomTree) Summary() string {
+	return fmt.Sprintf("RandomTree(Attributes: %d)\n%s", rt.Rule.Attributes, SummariseTrees(TreeRoots([]base.Classifier{rt})))
+}
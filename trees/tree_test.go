@@ -6,6 +6,7 @@ import (
 	eval "github.com/sjwhitworth/golearn/evaluation"
 	filters "github.com/sjwhitworth/golearn/filters"
 	"math"
+	"strings"
 	"testing"
 )
 
@@ -248,3 +249,437 @@ func TestID3(testEnv *testing.T) {
 		testEnv.Error(overcastChild)
 	}
 }
+
+func TestID3MaxDepth(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/tennis.csv", true)
+	if err != nil {
+		panic(err)
+	}
+
+	tree, err := NewID3DecisionTreeWithOptions(WithMaxDepth(1))
+	if err != nil {
+		panic(err)
+	}
+	tree.Fit(inst)
+	if tree.Root.SplitAttr.GetName() != "outlook" {
+		testEnv.Error(tree.Root)
+	}
+	for _, c := range tree.Root.Children {
+		if c.Type != LeafNode || c.SplitAttr != nil {
+			testEnv.Error(c)
+		}
+	}
+	if tree.Root.Children["overcast"].Class != "yes" {
+		testEnv.Error(tree.Root.Children["overcast"])
+	}
+}
+
+func TestID3Params(testEnv *testing.T) {
+	if _, err := NewID3DecisionTreeWithOptions(WithMaxDepth(-1)); err == nil {
+		testEnv.Error("Expected an error for a negative MaxDepth")
+	}
+	if _, err := NewID3DecisionTreeFromParams(ID3Params{PruneSplit: 1.5}); err == nil {
+		testEnv.Error("Expected an error for a PruneSplit above 1")
+	}
+	tree, err := NewID3DecisionTreeWithOptions(WithPruneSplit(0.6), WithMaxDepth(3))
+	if err != nil {
+		testEnv.Error(err)
+	}
+	clone := tree.Clone().(*ID3DecisionTree)
+	if clone.PruneSplit != 0.6 || clone.MaxDepth != 3 {
+		testEnv.Error(clone)
+	}
+}
+
+func TestID3Summary(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/tennis.csv", true)
+	if err != nil {
+		panic(err)
+	}
+	tree := NewID3DecisionTree(0.0)
+	tree.Fit(inst)
+	depths := tree.Root.LeafDepths()
+	if depths[1] != 1 || depths[2] != 4 || len(depths) != 2 {
+		testEnv.Error(depths)
+	}
+	summary := tree.Summary()
+	if !strings.Contains(summary, "1 tree(s), 8 node(s), 5 leaves, max depth 2") {
+		testEnv.Error(summary)
+	}
+	if !strings.Contains(summary, "Splits: humidity (1), outlook (1), windy (1)") {
+		testEnv.Error(summary)
+	}
+}
+
+func TestID3Apply(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/tennis.csv", true)
+	if err != nil {
+		panic(err)
+	}
+	tree := NewID3DecisionTree(0.0)
+	tree.Fit(inst)
+	if tree.LeafCount() != 5 {
+		testEnv.Error(tree.LeafCount())
+	}
+
+	// Leaves are numbered overcast, rainy/false, rainy/true,
+	// sunny/high, sunny/normal
+	expected := map[string]int{
+		"overcast": 0,
+		"rainy":    1,
+		"sunny":    3,
+	}
+	leaves := tree.Apply(inst)
+	for i, leaf := range leaves {
+		outlook := inst.GetAttrStr(i, 0)
+		if leaf < expected[outlook] || leaf > expected[outlook]+1 {
+			testEnv.Errorf("Row %d (%s) landed in leaf %d", i, outlook, leaf)
+		}
+		if outlook == "sunny" && inst.GetAttrStr(i, 2) == "high" && leaf != 3 {
+			testEnv.Errorf("Row %d landed in leaf %d", i, leaf)
+		}
+	}
+
+	rows := tree.Root.LeafRows(inst)
+	total := 0
+	for idx, r := range rows {
+		for _, i := range r {
+			if leaves[i] != idx {
+				testEnv.Error(rows)
+			}
+		}
+		total += len(r)
+	}
+	if total != inst.Rows {
+		testEnv.Error(rows)
+	}
+}
+
+func TestSplitCriteria(testEnv *testing.T) {
+	// The same split as TestInformationGain
+	parent := map[string]float64{"play": 9, "noplay": 5}
+	children := []map[string]float64{
+		{"play": 2, "noplay": 3},
+		{"play": 4},
+		{"play": 3, "noplay": 2},
+	}
+	gain := InformationGain{}.Score(parent, children)
+	if math.Abs(gain-(0.940-0.694)) > 0.001 {
+		testEnv.Error(gain)
+	}
+	if g := Gini(map[string]float64{"a": 1, "b": 1}); math.Abs(g-0.5) > 1e-9 {
+		testEnv.Error(g)
+	}
+	if g := (GiniImpurity{}).Score(parent, []map[string]float64{parent}); math.Abs(g) > 1e-9 {
+		testEnv.Error(g)
+	}
+}
+
+func TestCriterionRuleGenerator(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/tennis.csv", true)
+	if err != nil {
+		panic(err)
+	}
+	rule := &CriterionRuleGenerator{InformationGain{}}
+	expected := new(InformationGainRuleGenerator).GenerateSplitAttribute(inst)
+	if a := rule.GenerateSplitAttribute(inst); !a.Equals(expected) {
+		testEnv.Errorf("Expected %s, got %s", expected.GetName(), a.GetName())
+	}
+}
+
+// misclassification scores splits by the decrease in errors
+type misclassification struct{}
+
+func (misclassification) Score(parent map[string]float64, children []map[string]float64) float64 {
+	errors := func(dist map[string]float64) float64 {
+		total, max := 0.0, 0.0
+		for _, w := range dist {
+			total += w
+			max = math.Max(max, w)
+		}
+		return total - max
+	}
+	ret := errors(parent)
+	for _, c := range children {
+		ret -= errors(c)
+	}
+	return ret
+}
+
+func TestCART(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		panic(err)
+	}
+	for _, criterion := range []SplitCriterion{nil, InformationGain{}, misclassification{}} {
+		tree := NewCARTDecisionTree(criterion)
+		tree.MaxDepth = 3
+		tree.Fit(inst)
+		if !tree.Root.Numeric {
+			testEnv.Fatalf("%T: expected a numeric split, got %s", criterion, tree.Root)
+		}
+		accuracy := eval.GetAccuracy(eval.GetConfusionMatrix(inst, tree.Predict(inst)))
+		if accuracy < 0.94 {
+			testEnv.Errorf("%T: accuracy too low: %.3f", criterion, accuracy)
+		}
+		if depth := len(tree.Root.LeafDepths()); depth > 3 {
+			testEnv.Errorf("%T: too deep: %v", criterion, tree.Root.LeafDepths())
+		}
+		tree.Root.Prune(inst)
+		if a := eval.GetAccuracy(eval.GetConfusionMatrix(inst, tree.Predict(inst))); a < accuracy {
+			testEnv.Errorf("%T: pruning reduced accuracy to %.3f", criterion, a)
+		}
+	}
+
+	// Weighting a class heavily makes the tree predict it
+	weights := make([]float64, inst.Rows)
+	for i := range weights {
+		weights[i] = 1
+		if inst.GetClass(i) == "Iris-virginica" {
+			weights[i] = 100
+		}
+	}
+	tree := NewCARTDecisionTree(nil)
+	tree.MaxDepth = 1
+	tree.Fit(inst)
+	if counts := tree.Predict(inst).GetClassDistribution(); counts["Iris-virginica"] != 0 {
+		testEnv.Errorf("Didn't expect Iris-virginica to be predicted: %v", counts)
+	}
+	tree.FitWeighted(inst, weights)
+	if counts := tree.Predict(inst).GetClassDistribution(); counts["Iris-virginica"] < 50 {
+		testEnv.Errorf("Expected Iris-virginica to be predicted: %v", counts)
+	}
+	// Fit uses the weights of the Instances
+	weighted := inst.Copy()
+	for i, w := range weights {
+		weighted.SetWeight(i, w)
+	}
+	fitted := NewCARTDecisionTree(nil)
+	fitted.MaxDepth = 1
+	fitted.Fit(weighted)
+	got, expected := fitted.Predict(inst), tree.Predict(inst)
+	for i := 0; i < inst.Rows; i++ {
+		if got.GetClass(i) != expected.GetClass(i) {
+			testEnv.Fatalf("Row %d: Fit predicted %s, FitWeighted %s", i, got.GetClass(i), expected.GetClass(i))
+		}
+	}
+
+	if _, err := NewCARTDecisionTreeFromParams(CARTParams{}); err == nil {
+		testEnv.Error("Expected an error for a zero MinLeafSize")
+	}
+}
+
+// newSkewedInstances returns 1000 rows, 20 of class "b". x1 is
+// below 100 for all of them (and 80 of class "a"), x2 is zero for
+// half of them and one for everything else.
+func newSkewedInstances() *base.Instances {
+	x1 := base.NewFloatAttribute()
+	x1.SetName("x1")
+	x2 := base.NewFloatAttribute()
+	x2.SetName("x2")
+	class := base.NewCategoricalAttribute()
+	class.SetName("class")
+	inst := base.NewInstances([]base.Attribute{x1, x2, class}, 1000)
+	for i := 0; i < 1000; i++ {
+		inst.Set(i, 0, float64(i))
+		inst.Set(i, 1, 1)
+		inst.SetAttrStr(i, 2, "a")
+		if i%5 == 0 && i < 100 {
+			inst.SetAttrStr(i, 2, "b")
+			if i < 50 {
+				inst.Set(i, 1, 0)
+			}
+		}
+	}
+	return inst
+}
+
+func TestHellingerDistance(testEnv *testing.T) {
+	inst := newSkewedInstances()
+	attrs := []int{0, 1}
+	if s := FindBestSplit(inst, nil, attrs, GiniImpurity{}); s.Attribute.GetName() != "x2" {
+		testEnv.Errorf("Expected Gini to split on x2, got %s", s.Attribute.GetName())
+	}
+	s := FindBestSplit(inst, nil, attrs, HellingerDistance{})
+	if s.Attribute.GetName() != "x1" || s.Threshold < 95 || s.Threshold > 100 {
+		testEnv.Errorf("Expected Hellinger to split x1 around 100, got %s at %f", s.Attribute.GetName(), s.Threshold)
+	}
+
+	// The score doesn't depend on the class balance
+	weights := make([]float64, inst.Rows)
+	for i := range weights {
+		weights[i] = 1
+		if inst.GetClass(i) == "b" {
+			weights[i] = 50
+		}
+	}
+	if w := FindBestSplit(inst, weights, attrs, HellingerDistance{}); math.Abs(w.Score-s.Score) > 1e-9 {
+		testEnv.Errorf("Score changed from %f to %f", s.Score, w.Score)
+	}
+
+	// A split which separates the classes perfectly scores sqrt(2)
+	parent := map[string]float64{"a": 10, "b": 2}
+	children := []map[string]float64{{"a": 10}, {"b": 2}}
+	if d := (HellingerDistance{}).Score(parent, children); math.Abs(d-math.Sqrt2) > 1e-9 {
+		testEnv.Error(d)
+	}
+
+	tree := NewCARTDecisionTree(HellingerDistance{})
+	tree.MaxDepth = 1
+	tree.Fit(inst)
+	if tree.Root.SplitAttr.GetName() != "x1" {
+		testEnv.Errorf("Expected the root to split on x1, got %s", tree.Root)
+	}
+	for i, probs := range tree.PredictProba(inst) {
+		if inst.GetClass(i) == "b" && probs["b"] < 0.2 {
+			testEnv.Errorf("Row %d: %v", i, probs)
+		}
+	}
+}
+
+func TestCARTIgnoresNonFeatures(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	// Without the petal measurements, the tree has to split on a
+	// sepal measurement
+	inst.SetRole(2, base.IgnoredRole)
+	inst.SetRole(3, base.IDRole)
+	tree := NewCARTDecisionTree(nil)
+	tree.MaxDepth = 1
+	tree.Fit(inst)
+	if name := tree.Root.SplitAttr.GetName(); !strings.HasPrefix(name, "Sepal") {
+		testEnv.Error(name)
+	}
+}
+
+func TestCARTCosts(testEnv *testing.T) {
+	inst, err := base.ParseCSVToInstances("../examples/datasets/iris_headers.csv", true)
+	if err != nil {
+		testEnv.Fatal(err)
+	}
+	costs := map[string]float64{
+		inst.GetAttr(0).GetName(): 1,
+		inst.GetAttr(1).GetName(): 1,
+		inst.GetAttr(2).GetName(): 100,
+		inst.GetAttr(3).GetName(): 100,
+	}
+	free := NewCARTDecisionTree(nil)
+	free.MaxDepth = 3
+	free.Costs = costs
+	free.Fit(inst)
+
+	costly := NewCARTDecisionTree(nil)
+	costly.MaxDepth = 3
+	costly.Costs = costs
+	costly.CostWeight = 1
+	costly.Fit(inst)
+	if name := costly.Root.SplitAttr.GetName(); !strings.HasPrefix(name, "Sepal") {
+		testEnv.Error(name)
+	}
+	if free.ExpectedCost(inst) <= costly.ExpectedCost(inst) {
+		testEnv.Errorf("Expected cost %.2f isn't below %.2f", costly.ExpectedCost(inst), free.ExpectedCost(inst))
+	}
+	// Each Attribute is only paid for once
+	for i, c := range costly.PredictionCosts(inst) {
+		if c > 202 {
+			testEnv.Fatalf("Row %d costs %.2f", i, c)
+		}
+	}
+}
+
+// newInformativeMissingInstances returns 40 rows with one numeric
+// Attribute and a class: the "a" rows have x between 0 and 9 or
+// missing, and the "b" rows have x between 20 and 29.
+func newInformativeMissingInstances() *base.Instances {
+	x := base.NewFloatAttribute()
+	x.SetName("x")
+	class := base.NewCategoricalAttribute()
+	class.SetName("class")
+	inst := base.NewInstances([]base.Attribute{x, class}, 40)
+	for i := 0; i < 40; i++ {
+		switch {
+		case i < 10:
+			inst.Set(i, 0, float64(i))
+			inst.SetAttrStr(i, 1, "a")
+		case i < 20:
+			inst.SetMissing(i, 0)
+			inst.SetAttrStr(i, 1, "a")
+		default:
+			inst.Set(i, 0, float64(i))
+			inst.SetAttrStr(i, 1, "b")
+		}
+	}
+	return inst
+}
+
+func TestCARTMissingBranch(testEnv *testing.T) {
+	inst := newInformativeMissingInstances()
+	tree := NewCARTDecisionTree(nil)
+	tree.Fit(inst)
+	if tree.Root.MissingBranch != BelowThreshold || len(tree.Root.Children) != 2 {
+		testEnv.Fatal(tree)
+	}
+	predictions := tree.Predict(inst)
+	for i := 0; i < inst.Rows; i++ {
+		if predictions.GetClass(i) != inst.GetClass(i) {
+			testEnv.Errorf("Row %d: predicted %s", i, predictions.GetClass(i))
+		}
+	}
+
+	// Categorical values work the same way
+	color := base.NewCategoricalAttribute()
+	color.SetName("color")
+	class := inst.GetClassAttr()
+	cat := base.NewInstances([]base.Attribute{color, class}, 30)
+	for i := 0; i < 30; i++ {
+		switch {
+		case i < 10:
+			cat.SetAttrStr(i, 0, "red")
+			cat.SetAttrStr(i, 1, "a")
+		case i < 20:
+			cat.SetMissing(i, 0)
+			cat.SetAttrStr(i, 1, "b")
+		default:
+			cat.SetAttrStr(i, 0, "blue")
+			cat.SetAttrStr(i, 1, "b")
+		}
+	}
+	tree.Fit(cat)
+	if tree.Root.MissingBranch != "blue" || len(tree.Root.Children) != 2 {
+		testEnv.Fatal(tree)
+	}
+}
+
+func TestCARTOrdinal(testEnv *testing.T) {
+	// Only a threshold on the ranks of the levels puts the sizes
+	// from "m" up on one side with two branches
+	size := base.NewOrdinalAttribute("xs", "s", "m", "l", "xl")
+	size.SetName("size")
+	class := base.NewCategoricalAttribute()
+	class.SetName("class")
+	inst := base.NewInstances([]base.Attribute{size, class}, 25)
+	for i := 0; i < inst.Rows; i++ {
+		inst.Set(i, 0, float64(i%5))
+		if i%5 < 2 {
+			inst.SetAttrStr(i, 1, "small")
+		} else {
+			inst.SetAttrStr(i, 1, "large")
+		}
+	}
+	tree := NewCARTDecisionTree(nil)
+	tree.Fit(inst)
+	if !tree.Root.Numeric || len(tree.Root.Children) != 2 {
+		testEnv.Fatalf("Expected a threshold on the ranks, got %s", tree.Root)
+	}
+	if tree.Root.Threshold < 1 || tree.Root.Threshold >= 2 {
+		testEnv.Errorf("Threshold %f should be between s and m", tree.Root.Threshold)
+	}
+	predictions := tree.Predict(inst)
+	for i := 0; i < inst.Rows; i++ {
+		if predictions.GetClass(i) != inst.GetClass(i) {
+			testEnv.Errorf("Row %d: predicted %s", i, predictions.GetClass(i))
+		}
+	}
+}
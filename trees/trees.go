@@ -21,6 +21,20 @@
 			present, so discretise beforehand (see
 			filters)
 
+	CARTDecisionTree:
+		Builds a decision tree by picking the split which
+			scores best according to a SplitCriterion
+			(Gini impurity by default). FloatAttributes
+			are split in two at a threshold, so they
+			needn't be discretised, and rows can be
+			weighted.
+
+		HellingerDistance is a SplitCriterion which
+			copes with very imbalanced classes.
+
+		Implement SplitCriterion to try a new way of
+			scoring splits.
+
 */
 
 package trees
\ No newline at end of file